@@ -0,0 +1,113 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// githubOwnership recognizes stale branches as the user's own through
+// their GitHub account, for branches committed from another machine under
+// an email the identity does not list.
+type githubOwnership struct {
+	gh *ghclient.Client
+	// me is the login of the authenticated user.
+	me       string
+	identity branches.Identity
+
+	mu sync.Mutex
+	// logins caches the account each author email is linked to, "" for
+	// none.
+	logins map[string]string
+}
+
+// newGitHubOwnership returns the check for the authenticated GitHub user,
+// or nil when no stale branch with a remote needs it or the login cannot
+// be looked up.
+func newGitHubOwnership(gh *ghclient.Client, identity branches.Identity, stale []branches.StaleBranch) *githubOwnership {
+	needed := false
+	for _, s := range stale {
+		if s.HasRemote && !s.IsOwnBranch {
+			needed = true
+			break
+		}
+	}
+	if !needed {
+		return nil
+	}
+	me, err := gh.CurrentUser()
+	if err != nil {
+		slog.Debug("could not look up GitHub login, judging ownership by email only", "error", err)
+		return nil
+	}
+	return &githubOwnership{gh: gh, me: me, identity: identity, logins: make(map[string]string)}
+}
+
+// claim marks s as the user's own when GitHub says so: the branch's pull
+// request, still at the local tip, was opened by the user, or every
+// author email the identity does not list is linked to the user's
+// account. The reason is recorded to ex.
+func (o *githubOwnership) claim(s *branches.StaleBranch, owner, repo string, pr *ghclient.PRInfo, ex *explain.Log) {
+	if o == nil || s.IsOwnBranch {
+		return
+	}
+	if pr != nil && pr.Author != "" && strings.EqualFold(pr.Author, o.me) {
+		if sha, err := git.RevParse(s.RepoPath, s.Branch); err == nil && sha == pr.HeadSHA {
+			s.IsOwnBranch = true
+			ex.Addf(s.RepoName, s.Branch, explain.Reported, "your branch: PR #%d was opened by %s", pr.Number, o.me)
+			return
+		}
+	}
+
+	userEmail, _ := git.ConfigValue(s.RepoPath, "user.email")
+	identity := o.identity.With(userEmail)
+	var linked []string
+	for _, email := range s.Authors {
+		if identity.Matches(email) {
+			continue
+		}
+		if !strings.EqualFold(o.login(owner, repo, s.RepoPath, s.Branch, email), o.me) {
+			return
+		}
+		linked = append(linked, email)
+	}
+	if len(linked) == 0 {
+		return
+	}
+	s.IsOwnBranch = true
+	ex.Addf(s.RepoName, s.Branch, explain.Reported, "your branch: commits by %s are linked to GitHub user %s",
+		strings.Join(linked, ", "), o.me)
+}
+
+// login returns the GitHub account email is linked to, going by its
+// latest commit on branch, or "" when it is linked to none or the lookup
+// fails. Answers are cached per email; failures are not.
+func (o *githubOwnership) login(owner, repo, repoPath, branch, email string) string {
+	key := strings.ToLower(email)
+	o.mu.Lock()
+	login, ok := o.logins[key]
+	o.mu.Unlock()
+	if ok {
+		return login
+	}
+
+	sha, err := git.LastCommitBy(repoPath, branch, email)
+	if err != nil || sha == "" {
+		return ""
+	}
+	login, err = o.gh.CommitAuthorLogin(owner, repo, sha)
+	if err != nil {
+		slog.Debug("could not look up the GitHub account of a commit author",
+			"email", email, "commit", sha, "error", err)
+		return ""
+	}
+	o.mu.Lock()
+	o.logins[key] = login
+	o.mu.Unlock()
+	return login
+}
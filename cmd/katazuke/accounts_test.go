@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestGitHubOwnershipClaim(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "claim")
+	repo.CreateBranch("feature")
+	repo.Git("-c", "user.email=me@laptop.example", "commit", "--allow-empty", "-m", "from the laptop")
+	repo.Checkout("main")
+	tip, err := git.RevParse(repo.Path, "feature")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Answers are cached, so no lookup reaches the API.
+	owners := &githubOwnership{
+		me:       "octo",
+		identity: branches.NewIdentity("test@example.com"),
+		logins:   map[string]string{"me@laptop.example": "octo", "pal@work.example": "pal"},
+	}
+	branch := func(authors ...string) branches.StaleBranch {
+		return branches.StaleBranch{RepoPath: repo.Path, RepoName: "claim", Branch: "feature", HasRemote: true, Authors: authors}
+	}
+
+	tests := []struct {
+		name    string
+		authors []string
+		pr      *ghclient.PRInfo
+		want    bool
+	}{
+		{"email linked to my account", []string{"test@example.com", "me@laptop.example"}, nil, true},
+		{"email linked to someone else", []string{"me@laptop.example", "pal@work.example"}, nil, false},
+		{"PR opened by me at the tip", []string{"pal@work.example"}, &ghclient.PRInfo{Number: 3, Author: "octo", HeadSHA: tip}, true},
+		{"PR opened by me, branch moved on", []string{"pal@work.example"}, &ghclient.PRInfo{Number: 3, Author: "octo", HeadSHA: "old"}, false},
+		{"PR opened by someone else", []string{"pal@work.example"}, &ghclient.PRInfo{Number: 3, Author: "pal", HeadSHA: tip}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := branch(tt.authors...)
+			owners.claim(&s, "acme", "app", tt.pr, nil)
+			if s.IsOwnBranch != tt.want {
+				t.Errorf("IsOwnBranch = %v, want %v", s.IsOwnBranch, tt.want)
+			}
+		})
+	}
+
+	var none *githubOwnership
+	s := branch("pal@work.example")
+	none.claim(&s, "acme", "app", nil, nil)
+	if s.IsOwnBranch {
+		t.Error("expected a nil check to leave ownership alone")
+	}
+}
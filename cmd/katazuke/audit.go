@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -14,15 +15,26 @@ import (
 	"github.com/agrahamlincoln/katazuke/internal/audit"
 	"github.com/agrahamlincoln/katazuke/internal/branches"
 	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/metrics"
 	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
 // AuditCmd handles workspace auditing.
 type AuditCmd struct {
-	NonGit bool `name:"non-git" help:"Show only non-git directories."`
+	NonGit          bool   `name:"non-git" help:"Show only non-git directories." xor:"mode"`
+	Large           bool   `name:"large" help:"Show Git LFS caches and large blobs with reclaimable space." xor:"mode"`
+	Health          bool   `name:"health" help:"Check repos for corruption, dangling HEAD, stale lock files, rebase leftovers, and shallow clones." xor:"mode"`
+	Artifacts       bool   `name:"artifacts" help:"Show ignored build artifact directories (node_modules, target, .venv, dist, ...) inside repos and offer to delete them." xor:"mode"`
+	BlobThresholdMB int    `name:"blob-threshold-mb" help:"Minimum blob size reported by --large." default:"10"`
+	ArtifactMinMB   int    `name:"artifact-min-mb" help:"Minimum artifact directory size reported by --artifacts." default:"10"`
+	Pattern         string `name:"pattern" short:"f" help:"Filter repositories and directories by name or group path (glob, e.g. '*kafka*' or 'work/*')."`
 }
 
 // Run executes the audit command.
@@ -30,10 +42,51 @@ func (c *AuditCmd) Run(globals *CLI) error {
 	if c.NonGit {
 		return c.runNonGit(globals)
 	}
+	if c.Large {
+		return c.runLarge(globals)
+	}
+	if c.Health {
+		return c.runHealth(globals)
+	}
+	if c.Artifacts {
+		return c.runArtifacts(globals)
+	}
 
 	return c.runDashboard(globals)
 }
 
+// resolveRepos resolves the repositories to audit and applies --pattern.
+// ok is false, after telling the user, when the pattern matched nothing.
+func (c *AuditCmd) resolveRepos(globals *CLI, cfg config.Config) (repos []string, isLocal bool, ok bool, err error) {
+	repos, isLocal, err = resolveRepos(globals, cfg)
+	if err != nil {
+		return nil, false, false, err
+	}
+	repos, err = filterByPattern(repos, c.Pattern, resolveProjectsDir(globals.ProjectsDir, cfg))
+	if err != nil {
+		return nil, false, false, err
+	}
+	if c.Pattern != "" && len(repos) == 0 {
+		fmt.Printf("No repositories matching %q found.\n", c.Pattern)
+		return nil, isLocal, false, nil
+	}
+	return repos, isLocal, true, nil
+}
+
+// filterDirs applies --pattern to non-repository directories.
+func (c *AuditCmd) filterDirs(dirs []audit.NonRepoDir, projectsDir string) []audit.NonRepoDir {
+	if c.Pattern == "" {
+		return dirs
+	}
+	var filtered []audit.NonRepoDir
+	for _, d := range dirs {
+		if matchesPattern(d.Path, c.Pattern, projectsDir) {
+			filtered = append(filtered, d)
+		}
+	}
+	return filtered
+}
+
 func (c *AuditCmd) runDashboard(globals *CLI) error {
 	if globals.Verbose {
 		enableVerboseLogging()
@@ -46,28 +99,44 @@ func (c *AuditCmd) runDashboard(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
 	_ = ml.LogCommand("audit", flags)
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	repos, isLocal, err := resolveRepos(globals, cfg)
-	if err != nil {
+	repos, isLocal, ok, err := c.resolveRepos(globals, cfg)
+	if err != nil || !ok {
 		return err
 	}
 
-	// projectsDir is only needed for workspace-wide operations.
-	var projectsDir string
+	// scanRoot is only needed for workspace-wide operations.
+	// resolveRepos has already validated --group.
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	var scanRoot string
 	if isLocal {
 		fmt.Printf("Auditing 1 repository...\n")
 	} else {
-		projectsDir = resolveProjectsDir(globals.ProjectsDir, cfg)
-		fmt.Printf("Auditing %s (%d repos)...\n", projectsDir, len(repos))
+		scanRoot, _ = groupRoot(projectsDir, globals.Group)
+		fmt.Printf("Auditing %s (%d repos)...\n", scanRoot, len(repos))
 	}
 
-	workers := cfg.Workers
+	result, err := c.collectDashboard(cfg, repos, isLocal, projectsDir, scanRoot)
+	if err != nil {
+		return err
+	}
+	printDashboard(result)
+	return nil
+}
+
+// collectDashboard runs the audit analyses over repos. scanRoot is empty
+// in local mode, where non-git directories are not scanned.
+func (c *AuditCmd) collectDashboard(cfg config.Config, repos []string, isLocal bool, projectsDir, scanRoot string) (audit.DashboardResult, error) {
+	workers := workersFor(cfg, parallel.LocalWork, len(repos))
 	staleDays := cfg.StaleThresholdDays
 
 	// Run analysis sections concurrently. Non-git dir scanning is skipped
@@ -75,6 +144,7 @@ func (c *AuditCmd) runDashboard(globals *CLI) error {
 	var healthResults []audit.RepoHealth
 	var branchResult audit.BranchSummary
 	var nonGitDirs []audit.NonRepoDir
+	var violations []audit.RuleViolation
 	var healthErr, branchErr, nonGitErr error
 
 	var wg sync.WaitGroup
@@ -87,50 +157,76 @@ func (c *AuditCmd) runDashboard(globals *CLI) error {
 		branchResult, branchErr = analyzeBranches(repos, staleDays, workers)
 	})
 
+	wg.Go(func() {
+		violations = audit.CheckRules(repos, projectsDir, auditRules(cfg), workers)
+		for i := range violations {
+			violations[i].RepoName = groupedName(projectsDir, violations[i].RepoPath)
+		}
+	})
+
 	if !isLocal {
 		wg.Go(func() {
-			nonGitDirs, nonGitErr = audit.FindNonRepoDirs(projectsDir, audit.Options{
+			nonGitDirs, nonGitErr = audit.FindNonRepoDirs(scanRoot, audit.Options{
 				ExcludePatterns: cfg.ExcludePatterns,
+				ScanDepth:       cfg.ScanDepth,
 			}, workers)
+			nonGitDirs = c.filterDirs(nonGitDirs, projectsDir)
+			nonGitDirs, _ = audit.WithoutFreshNotes(nonGitDirs, noteTTL(cfg), time.Now())
+			il := loadIgnoreList()
+			nonGitDirs = slices.DeleteFunc(nonGitDirs, func(d audit.NonRepoDir) bool { return il.HasDir(d.Path) })
 		})
 	}
 
 	wg.Wait()
 
 	if healthErr != nil {
-		return fmt.Errorf("analyzing repo health: %w", healthErr)
+		return audit.DashboardResult{}, fmt.Errorf("analyzing repo health: %w", healthErr)
 	}
 	if branchErr != nil {
-		return fmt.Errorf("analyzing branches: %w", branchErr)
+		return audit.DashboardResult{}, fmt.Errorf("analyzing branches: %w", branchErr)
 	}
 	if nonGitErr != nil {
-		return fmt.Errorf("scanning non-git dirs: %w", nonGitErr)
+		return audit.DashboardResult{}, fmt.Errorf("scanning non-git dirs: %w", nonGitErr)
 	}
+	audit.MarkShadows(nonGitDirs, repos)
 
-	result := audit.DashboardResult{
+	return audit.DashboardResult{
 		ProjectsDir:   projectsDir,
 		RepoCount:     len(repos),
 		RepoHealth:    audit.SummarizeHealth(healthResults),
 		HealthDetails: healthResults,
 		Branches:      branchResult,
 		NonGitDirs:    nonGitDirs,
+		Violations:    violations,
 		StaleDays:     staleDays,
-	}
+	}, nil
+}
 
-	printDashboard(result)
-	return nil
+// auditRules converts the configured audit rules.
+func auditRules(cfg config.Config) []audit.Rule {
+	rules := make([]audit.Rule, len(cfg.Audit.Rules))
+	for i, r := range cfg.Audit.Rules {
+		rules[i] = audit.Rule{
+			Name:              r.Name,
+			Orgs:              r.Orgs,
+			Group:             r.Group,
+			NameMatchesRemote: r.NameMatchesRemote,
+			RequiredFiles:     r.RequiredFiles,
+		}
+	}
+	return rules
 }
 
 func analyzeBranches(repos []string, staleDays, workers int) (audit.BranchSummary, error) {
 	detector := merge.GitOnlyDetector()
 
-	merged, err := branches.FindMerged(repos, detector, workers, nil)
+	merged, err := branches.FindMerged(repos, detector, workers, nil, nil)
 	if err != nil {
 		return audit.BranchSummary{}, fmt.Errorf("finding merged branches: %w", err)
 	}
 
 	threshold := time.Duration(staleDays) * 24 * time.Hour
-	stale, err := branches.FindStale(repos, threshold, detector, workers, nil)
+	stale, err := branches.FindStale(repos, threshold, detector, branches.Identity{}, workers, nil, nil)
 	if err != nil {
 		return audit.BranchSummary{}, fmt.Errorf("finding stale branches: %w", err)
 	}
@@ -172,7 +268,7 @@ func sortRepoBranchCounts(counts map[string]int) []audit.RepoBranchCount {
 const maxDetailLines = 5
 
 func printDetailLines(lines []string) {
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 	limit := min(len(lines), maxDetailLines)
 	for _, line := range lines[:limit] {
 		fmt.Printf("         %s\n", dim.Sprint(line))
@@ -184,10 +280,10 @@ func printDetailLines(lines []string) {
 
 func printDashboard(r audit.DashboardResult) {
 	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
-	yellow := color.New(color.FgYellow)
-	red := color.New(color.FgRed)
-	dim := color.New(color.FgHiBlack)
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+	dim := ui.Muted()
 
 	h := r.RepoHealth
 	buckets := audit.ReposByBucket(r.HealthDetails)
@@ -196,10 +292,10 @@ func printDashboard(r audit.DashboardResult) {
 	// Repository Health section.
 	fmt.Printf("\n%s\n", bold.Sprint("Repository Health:"))
 	fmt.Printf("  %s %3d clean and up-to-date\n",
-		green.Sprint("ok"), h.CleanUpToDate)
+		success.Sprint("ok"), h.CleanUpToDate)
 	if h.NeedsManualFix > 0 {
 		fmt.Printf("  %s %3d needs manual fix\n",
-			red.Sprint("!!"), h.NeedsManualFix)
+			fail.Sprint("!!"), h.NeedsManualFix)
 		conflicted := make([]string, len(buckets.Conflicted))
 		sort.Slice(buckets.Conflicted, func(i, j int) bool {
 			return filepath.Base(buckets.Conflicted[i].Path) < filepath.Base(buckets.Conflicted[j].Path)
@@ -212,7 +308,7 @@ func printDashboard(r audit.DashboardResult) {
 	}
 	if h.BehindRemote > 0 {
 		fmt.Printf("  %s %3d behind remote         %s\n",
-			yellow.Sprint("!!"), h.BehindRemote, dim.Sprint("(run: katazuke sync)"))
+			warn.Sprint("!!"), h.BehindRemote, dim.Sprint("(run: katazuke sync)"))
 		behind := make([]string, len(buckets.Behind))
 		sort.Slice(buckets.Behind, func(i, j int) bool {
 			return buckets.Behind[i].BehindRemote > buckets.Behind[j].BehindRemote
@@ -230,7 +326,7 @@ func printDashboard(r audit.DashboardResult) {
 	}
 	if h.UncommittedChanges > 0 {
 		fmt.Printf("  %s %3d uncommitted changes\n",
-			yellow.Sprint("!!"), h.UncommittedChanges)
+			warn.Sprint("!!"), h.UncommittedChanges)
 		dirty := make([]string, len(buckets.Dirty))
 		sort.Slice(buckets.Dirty, func(i, j int) bool {
 			return filepath.Base(buckets.Dirty[i].Path) < filepath.Base(buckets.Dirty[j].Path)
@@ -252,7 +348,7 @@ func printDashboard(r audit.DashboardResult) {
 	}
 	if h.OnNonDefaultBranch > 0 {
 		fmt.Printf("  %s %3d on non-default branch\n",
-			yellow.Sprint("!!"), h.OnNonDefaultBranch)
+			warn.Sprint("!!"), h.OnNonDefaultBranch)
 		nonDefault := make([]string, len(buckets.NonDefault))
 		sort.Slice(buckets.NonDefault, func(i, j int) bool {
 			return filepath.Base(buckets.NonDefault[i].Path) < filepath.Base(buckets.NonDefault[j].Path)
@@ -278,7 +374,7 @@ func printDashboard(r audit.DashboardResult) {
 		fmt.Printf("\n%s\n", bold.Sprint("Branch Cleanup:"))
 		if b.MergedBranches > 0 {
 			fmt.Printf("  %s %3d merged branches across %d repos   %s\n",
-				yellow.Sprint("!!"), b.MergedBranches, b.MergedRepos,
+				warn.Sprint("!!"), b.MergedBranches, b.MergedRepos,
 				dim.Sprint("(run: katazuke branches --merged)"))
 			lines := make([]string, len(b.MergedByRepo))
 			for i, rc := range b.MergedByRepo {
@@ -293,7 +389,7 @@ func printDashboard(r audit.DashboardResult) {
 		}
 		if b.StaleBranches > 0 {
 			fmt.Printf("  %s %3d stale branches across %d repos    %s\n",
-				yellow.Sprint("!!"), b.StaleBranches, b.StaleRepos,
+				warn.Sprint("!!"), b.StaleBranches, b.StaleRepos,
 				dim.Sprintf("(run: katazuke branches --stale --stale-days=%d)", r.StaleDays))
 			lines := make([]string, len(b.StaleByRepo))
 			for i, rc := range b.StaleByRepo {
@@ -308,12 +404,31 @@ func printDashboard(r audit.DashboardResult) {
 		}
 	}
 
+	// Conformance section.
+	if len(r.Violations) > 0 {
+		repos := make(map[string]bool)
+		lines := make([]string, len(r.Violations))
+		for i, v := range r.Violations {
+			repos[v.RepoPath] = true
+			lines[i] = fmt.Sprintf("%s: %s (%s)", v.RepoName, v.Detail, v.Rule)
+		}
+		fmt.Printf("\n%s\n", bold.Sprint("Conformance:"))
+		fmt.Printf("  %s %3d rule %s in %d %s\n",
+			warn.Sprint("!!"), len(r.Violations), pluralize(len(r.Violations), "violation", "violations"),
+			len(repos), pluralize(len(repos), "repo", "repos"))
+		printDetailLines(lines)
+		actionable++
+	}
+
 	// Non-Git Directories section.
 	if len(r.NonGitDirs) > 0 {
 		fmt.Printf("\n%s\n", bold.Sprintf("Non-Git Directories (%d found):", len(r.NonGitDirs)))
 		for _, d := range r.NonGitDirs {
-			fmt.Printf("  %-20s %8s  %d files\n",
-				d.Name, formatSize(d.Size), d.FileCount)
+			fmt.Printf("  %-20s %8s  %d files", d.Name, formatSize(d.Size), d.FileCount)
+			if d.Shadows != "" {
+				fmt.Print(warn.Sprintf("  copy of %s?", groupedName(r.ProjectsDir, d.Shadows)))
+			}
+			fmt.Println()
 		}
 		fmt.Printf("  %s\n", dim.Sprint("(run: katazuke audit --non-git for details)"))
 		actionable++
@@ -328,7 +443,7 @@ func printDashboard(r audit.DashboardResult) {
 		}
 		fmt.Printf("%s\n", bold.Sprintf("%d actionable %s found.", actionable, noun))
 	} else {
-		fmt.Printf("%s\n", green.Sprint("Workspace is clean."))
+		fmt.Printf("%s\n", success.Sprint("Workspace is clean."))
 	}
 }
 
@@ -338,7 +453,7 @@ func (c *AuditCmd) runNonGit(globals *CLI) error {
 	}
 
 	// --non-git is inherently workspace-scoped; it doesn't apply to a single repo.
-	if !globals.Global {
+	if !globals.Global && globals.Group == "" {
 		cwd, err := os.Getwd()
 		if err == nil {
 			if _, tlErr := git.TopLevel(cwd); tlErr == nil {
@@ -360,24 +475,59 @@ func (c *AuditCmd) runNonGit(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
 	_ = ml.LogCommand("audit --non-git", flags)
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
 	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
 
-	fmt.Printf("Scanning %s for non-repository directories...\n", projectsDir)
+	scanRoot, err := groupRoot(projectsDir, globals.Group)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Scanning %s for non-repository directories...\n", scanRoot)
 
 	scanStart := time.Now()
-	dirs, err := audit.FindNonRepoDirs(projectsDir, audit.Options{
+
+	dirs, err := audit.FindNonRepoDirs(scanRoot, audit.Options{
 		ExcludePatterns: cfg.ExcludePatterns,
-	}, cfg.Workers)
+		ScanDepth:       cfg.ScanDepth,
+	}, workersFor(cfg, parallel.LocalWork, 0))
 	if err != nil {
 		return fmt.Errorf("scanning for non-repo directories: %w", err)
 	}
+	if _, err := filepath.Match(c.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid --pattern %q: %w", c.Pattern, err)
+	}
+	dirs = c.filterDirs(dirs, projectsDir)
+	il := loadIgnoreList()
+	dirs = withoutIgnored(dirs, func(d audit.NonRepoDir) bool { return il.HasDir(d.Path) }, "directory", "directories")
+	dirs, annotated := audit.WithoutFreshNotes(dirs, noteTTL(cfg), time.Now())
+	if annotated > 0 {
+		fmt.Println(ui.Muted().Sprintf("Skipping %d %s with a %s.", annotated,
+			pluralize(annotated, "directory", "directories"), audit.NoteFile))
+	}
+	if len(dirs) > 0 {
+		// Copies are matched against every checkout, not only those in
+		// the group being audited.
+		repos, err := scanner.Scan(projectsDir, scanner.Options{
+			ExcludePatterns: cfg.ExcludePatterns,
+			ExcludeRemotes:  cfg.ExcludeRemotes,
+			MaxDepth:        cfg.ScanDepth,
+			Workers:         workersFor(cfg, parallel.LocalWork, 0),
+		})
+		if err != nil {
+			return fmt.Errorf("scanning repositories: %w", err)
+		}
+		audit.MarkShadows(dirs, repos)
+	}
 	_ = ml.LogPerf(0, int(time.Since(scanStart).Milliseconds()))
 
 	if len(dirs) == 0 {
@@ -386,7 +536,7 @@ func (c *AuditCmd) runNonGit(globals *CLI) error {
 	}
 
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 
 	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d non-repository directory(ies):", len(dirs)))
 
@@ -396,6 +546,9 @@ func (c *AuditCmd) runNonGit(globals *CLI) error {
 		fmt.Printf("    Size:     %s\n", formatSize(d.Size))
 		fmt.Printf("    Modified: %s\n", dim.Sprint(formatAge(d.LastModified)))
 		fmt.Printf("    Files:    %d (%s)\n", d.FileCount, d.Summary)
+		if d.Shadows != "" {
+			printShadowComparison(d, projectsDir)
+		}
 		fmt.Println()
 	}
 
@@ -404,46 +557,125 @@ func (c *AuditCmd) runNonGit(globals *CLI) error {
 		return nil
 	}
 
-	return promptNonGitActions(dirs, ml, ol)
+	var cutoff time.Time
+	if days := cfg.Audit.AutoQuarantineAfterDays; days > 0 {
+		cutoff = time.Now().AddDate(0, 0, -days)
+		if aged := countAged(dirs, cutoff); aged > 0 && assumeYes {
+			fmt.Printf("Quarantining %d %s untouched for more than %d days (audit.auto_quarantine_after_days).\n",
+				aged, pluralize(aged, "directory", "directories"), days)
+		}
+	}
+	return promptNonGitActions(dirs, cutoff, fsops.OS{}, il, ml, ol)
+}
+
+// noteTTL returns how long a directory note suppresses the directory.
+func noteTTL(cfg config.Config) time.Duration {
+	return time.Duration(cfg.Audit.NoteTTLDays) * 24 * time.Hour
+}
+
+// untouchedSince reports whether nothing in d was modified after cutoff.
+// An empty directory has no modification time and never qualifies.
+func untouchedSince(d audit.NonRepoDir, cutoff time.Time) bool {
+	return !cutoff.IsZero() && !d.LastModified.IsZero() && d.LastModified.Before(cutoff)
+}
+
+func countAged(dirs []audit.NonRepoDir, cutoff time.Time) int {
+	n := 0
+	for _, d := range dirs {
+		if untouchedSince(d, cutoff) {
+			n++
+		}
+	}
+	return n
+}
+
+// maxShadowPaths caps how many differing paths are listed for each copy.
+const maxShadowPaths = 10
+
+// printShadowComparison shows how a non-git directory differs from the
+// checkout it looks like a copy of, in the style of diff --brief: "~" for
+// files that differ, "+" for files only in the copy, "-" for files only
+// in the checkout.
+func printShadowComparison(d audit.NonRepoDir, projectsDir string) {
+	warn := ui.Warn()
+	success := ui.Success()
+	fail := ui.Error()
+	dim := ui.Muted()
+
+	repoName := groupedName(projectsDir, d.Shadows)
+	cmp, err := audit.CompareWithRepo(d.Path, d.Shadows)
+	if err != nil {
+		fmt.Printf("    Copy of:  %s %s\n", repoName, dim.Sprintf("(could not compare: %v)", err))
+		return
+	}
+	fmt.Printf("    Copy of:  %s (%d identical, %d differ, %d only here, %d only in the repo)\n",
+		repoName, cmp.Same, len(cmp.Differ), len(cmp.OnlyInDir), len(cmp.OnlyInRepo))
+
+	var lines []string
+	for _, p := range cmp.Differ {
+		lines = append(lines, warn.Sprintf("~ %s", p))
+	}
+	for _, p := range cmp.OnlyInDir {
+		lines = append(lines, success.Sprintf("+ %s", p))
+	}
+	for _, p := range cmp.OnlyInRepo {
+		lines = append(lines, fail.Sprintf("- %s", p))
+	}
+	for i, line := range lines {
+		if i == maxShadowPaths {
+			fmt.Printf("      %s\n", dim.Sprintf("... and %d more", len(lines)-maxShadowPaths))
+			break
+		}
+		fmt.Printf("      %s\n", line)
+	}
+	if !cmp.HasUniqueContent() {
+		fmt.Printf("    %s\n", success.Sprintf("Nothing here that %s does not already have; safe to remove.", repoName))
+	}
 }
 
 const (
 	actionKeep   = "keep"
 	actionRemove = "remove"
 	actionMove   = "move"
+	actionNote   = "note"
+	actionIgnore = "ignore"
 )
 
-func promptNonGitActions(dirs []audit.NonRepoDir, ml *metrics.Logger, ol *oplog.Logger) error {
-	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
-	red := color.New(color.FgRed)
-	yellow := color.New(color.FgYellow)
-
+// promptNonGitActions asks what to do with each directory. Directories
+// untouched since cutoff default to the quarantine, so --yes moves them
+// there and keeps the rest; a zero cutoff defaults every directory to keep.
+// Directories the user snoozes or ignores are added to il.
+func promptNonGitActions(dirs []audit.NonRepoDir, cutoff time.Time, fs fsops.FileOps, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger) error {
 	quarantineDir, err := defaultQuarantinePath()
 	if err != nil {
 		return fmt.Errorf("resolving quarantine path: %w", err)
 	}
 
-	type dirAction struct {
-		dir    audit.NonRepoDir
-		action string
-	}
-
 	var actions []dirAction
+	var ignored []ignore.Entry
 
 	for _, d := range dirs {
-		var action string
+		action := actionKeep
 		label := fmt.Sprintf("%s (%s, %d files)", d.Name, formatSize(d.Size), d.FileCount)
+		if untouchedSince(d, cutoff) {
+			action = actionMove
+			label += fmt.Sprintf(", untouched since %s", d.LastModified.Format("2006-01-02"))
+		}
 
-		err := huh.NewForm(
+		options := []huh.Option[string]{
+			huh.NewOption("Keep (do nothing)", actionKeep),
+			huh.NewOption("Remove (delete permanently)", actionRemove),
+			huh.NewOption("Move to quarantine", actionMove),
+			huh.NewOption(fmt.Sprintf("Keep and annotate (write %s, skip in future audits)", audit.NoteFile), actionNote),
+		}
+		if il != nil {
+			options = append(options, huh.NewOption("Snooze or ignore (hide from future audits)", actionIgnore))
+		}
+		err := newForm(
 			huh.NewGroup(
 				huh.NewSelect[string]().
 					Title(label).
-					Options(
-						huh.NewOption("Keep (do nothing)", actionKeep),
-						huh.NewOption("Remove (delete permanently)", actionRemove),
-						huh.NewOption("Move to quarantine", actionMove),
-					).
+					Options(options...).
 					Value(&action),
 			),
 		).Run()
@@ -451,23 +683,87 @@ func promptNonGitActions(dirs []audit.NonRepoDir, ml *metrics.Logger, ol *oplog.
 			return fmt.Errorf("prompt failed: %w", err)
 		}
 
-		actions = append(actions, dirAction{dir: d, action: action})
+		var note string
+		if action == actionNote {
+			err := newForm(
+				huh.NewGroup(
+					huh.NewText().
+						Title(fmt.Sprintf("What is %s?", d.Name)).
+						Description("Saved with a summary of the directory's contents.").
+						Value(&note),
+				),
+			).Run()
+			if err != nil {
+				return fmt.Errorf("prompt failed: %w", err)
+			}
+		}
+
+		if action == actionIgnore {
+			days, err := promptHideDuration(fmt.Sprintf("Hide %s for how long?", d.Name))
+			if err != nil {
+				return err
+			}
+			ignored = append(ignored, ignore.Entry{Kind: ignore.KindDir, Path: d.Path, Until: hideUntil(days, time.Now())})
+			action = actionKeep
+		}
+		actions = append(actions, dirAction{dir: d, action: action, note: note})
 
 		accepted := action == actionRemove || action == actionMove
 		fp := metrics.Fingerprint(d.Path)
 		_ = ml.LogSuggestion("remove_non_git_dir", fp, accepted, 0)
 	}
 
-	// Execute actions.
-	var removed, moved, kept int
+	if err := addIgnored(il, ignored...); err != nil {
+		return err
+	}
+	removals := 0
+	for _, a := range actions {
+		if a.action == actionRemove {
+			removals++
+		}
+	}
+	if ok, err := confirmBulkDeletion(removals, "directories"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was changed.")
+		}
+		return err
+	}
+	executeNonGitActions(actions, quarantineDir, fs, ol)
+	return nil
+}
+
+// dirAction pairs a non-git directory with the action chosen for it.
+type dirAction struct {
+	dir    audit.NonRepoDir
+	action string
+	// note is the annotation for actionNote.
+	note string
+}
+
+// executeNonGitActions applies the chosen actions through fs, logging each
+// successful removal or move.
+func executeNonGitActions(actions []dirAction, quarantineDir string, fs fsops.FileOps, ol *oplog.Logger) {
+	bold := color.New(color.Bold)
+	success := ui.Success()
+	fail := ui.Error()
+	warn := ui.Warn()
+
+	var removed, moved, kept, annotated int
 	for _, a := range actions {
 		switch a.action {
 		case actionKeep:
 			kept++
+		case actionNote:
+			if err := audit.WriteNote(a.dir, a.note, time.Now()); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to annotate %s: %v", a.dir.Path, err))
+				continue
+			}
+			fmt.Printf("  %s\n", success.Sprintf("Wrote %s", filepath.Join(a.dir.Path, audit.NoteFile)))
+			annotated++
 		case actionRemove:
 			fmt.Printf("Removing %s...\n", a.dir.Path)
-			if err := os.RemoveAll(a.dir.Path); err != nil {
-				fmt.Printf("  %s\n", red.Sprintf("Failed to remove %s: %v", a.dir.Path, err))
+			if err := fs.Remove(a.dir.Path); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to remove %s: %v", a.dir.Path, err))
 				continue
 			}
 			_ = ol.Log(oplog.Operation{
@@ -475,13 +771,13 @@ func promptNonGitActions(dirs []audit.NonRepoDir, ml *metrics.Logger, ol *oplog.
 				Path:      a.dir.Path,
 				SizeBytes: a.dir.Size,
 			})
-			fmt.Printf("  %s\n", green.Sprintf("Removed %s", a.dir.Path))
+			fmt.Printf("  %s\n", success.Sprintf("Removed %s", a.dir.Path))
 			removed++
 		case actionMove:
 			dest := filepath.Join(quarantineDir, a.dir.Name)
 			fmt.Printf("Moving %s to %s...\n", a.dir.Path, dest)
-			if err := moveToQuarantine(a.dir.Path, dest); err != nil {
-				fmt.Printf("  %s\n", red.Sprintf("Failed to move %s: %v", a.dir.Path, err))
+			if err := moveToQuarantine(fs, a.dir.Path, dest); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to move %s: %v", a.dir.Path, err))
 				continue
 			}
 			_ = ol.Log(oplog.Operation{
@@ -490,7 +786,7 @@ func promptNonGitActions(dirs []audit.NonRepoDir, ml *metrics.Logger, ol *oplog.
 				Destination: dest,
 				SizeBytes:   a.dir.Size,
 			})
-			fmt.Printf("  %s\n", yellow.Sprintf("Moved to %s", dest))
+			fmt.Printf("  %s\n", warn.Sprintf("Moved to %s", dest))
 			moved++
 		}
 	}
@@ -505,15 +801,16 @@ func promptNonGitActions(dirs []audit.NonRepoDir, ml *metrics.Logger, ol *oplog.
 	if kept > 0 {
 		fmt.Println(bold.Sprintf("Kept %d directory(ies).", kept))
 	}
-
-	return nil
+	if annotated > 0 {
+		fmt.Println(bold.Sprintf("Annotated %d directory(ies).", annotated))
+	}
 }
 
-func moveToQuarantine(src, dest string) error {
-	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+func moveToQuarantine(fs fsops.FileOps, src, dest string) error {
+	if err := fs.EnsureDir(filepath.Dir(dest)); err != nil {
 		return fmt.Errorf("creating quarantine directory: %w", err)
 	}
-	return os.Rename(src, dest)
+	return fs.Move(src, dest)
 }
 
 func defaultQuarantinePath() (string, error) {
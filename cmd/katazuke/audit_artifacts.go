@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func (c *AuditCmd) runArtifacts(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("audit --artifacts", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	repoPaths, isLocal, ok, err := c.resolveRepos(globals, cfg)
+	if err != nil || !ok {
+		return err
+	}
+	slog.Debug("found repositories", "count", len(repoPaths))
+	printRepoCount("Checking", len(repoPaths), isLocal, " for build artifacts...")
+
+	minSize := int64(c.ArtifactMinMB) * 1024 * 1024
+	scanStart := time.Now()
+	progress := newProgress()
+	reports := audit.FindArtifacts(repoPaths, minSize, workersFor(cfg, parallel.LocalWork, len(repoPaths)), progress.Update)
+	progress.Stop()
+	il := loadIgnoreList()
+	reports = withoutIgnoredArtifacts(reports, il)
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range reports {
+		reports[i].RepoName = groupedName(projectsDir, reports[i].RepoPath)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(reports) == 0 {
+		fmt.Printf("No ignored build artifacts over %d MB found.\n", c.ArtifactMinMB)
+		return nil
+	}
+
+	// Biggest wins first.
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].TotalBytes() != reports[j].TotalBytes() {
+			return reports[i].TotalBytes() > reports[j].TotalBytes()
+		}
+		return reports[i].RepoName < reports[j].RepoName
+	})
+	printArtifactReports(reports)
+
+	if globals.DryRun {
+		fmt.Println(color.New(color.Bold).Sprint("Dry run -- no changes made."))
+		return nil
+	}
+	return promptArtifactDeletes(reports, fsops.OS{}, il, ml, ol)
+}
+
+func printArtifactReports(reports []audit.ArtifactReport) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	var total int64
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found build artifacts in %d %s:", len(reports), pluralize(len(reports), "repository", "repositories")))
+	for _, r := range reports {
+		fmt.Printf("  %s  %s  %s\n", bold.Sprint(r.RepoName), formatSize(r.TotalBytes()), dim.Sprint(r.RepoPath))
+		for _, d := range r.Dirs {
+			fmt.Printf("    %8s  %s\n", formatSize(d.Size), d.RelPath)
+		}
+		total += r.TotalBytes()
+	}
+	fmt.Printf("\n%s\n", bold.Sprintf("Reclaimable: %s", formatSize(total)))
+	fmt.Println(dim.Sprint("These directories are ignored by git and are recreated by the next install or build."))
+	fmt.Println()
+}
+
+// artifactDelete is an artifact directory selected for deletion, with the
+// repository it belongs to for display.
+type artifactDelete struct {
+	repoName string
+	dir      audit.ArtifactDir
+}
+
+// withoutIgnoredArtifacts drops ignored artifact directories, and the
+// repositories left with none.
+func withoutIgnoredArtifacts(reports []audit.ArtifactReport, il *ignore.List) []audit.ArtifactReport {
+	var kept []audit.ArtifactReport
+	skipped := 0
+	for _, r := range reports {
+		dirs := slices.DeleteFunc(r.Dirs, func(d audit.ArtifactDir) bool { return il.HasDir(d.Path) })
+		skipped += len(r.Dirs) - len(dirs)
+		if len(dirs) > 0 {
+			r.Dirs = dirs
+			kept = append(kept, r)
+		}
+	}
+	if skipped > 0 {
+		fmt.Println(ui.Muted().Sprintf("Skipping %d ignored or snoozed %s.", skipped, pluralize(skipped, "directory", "directories")))
+	}
+	return kept
+}
+
+// promptArtifactDeletes offers every artifact directory for deletion, then
+// offers to ignore the ones kept.
+func promptArtifactDeletes(reports []audit.ArtifactReport, fs fsops.FileOps, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger) error {
+	var all []artifactDelete
+	var options []huh.Option[int]
+	for _, r := range reports {
+		for _, d := range r.Dirs {
+			options = append(options, huh.NewOption(fmt.Sprintf("%s: %s (%s)", r.RepoName, d.RelPath, formatSize(d.Size)), len(all)))
+			all = append(all, artifactDelete{repoName: r.RepoName, dir: d})
+		}
+	}
+
+	var selected []int
+	if err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Delete these build artifact directories?").
+				Description("They are ignored by git; reinstall or rebuild to get them back.").
+				Options(options...).
+				Height(15).
+				Value(&selected),
+		),
+	).Run(); err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	selectedSet := make(map[int]bool, len(selected))
+	for _, i := range selected {
+		selectedSet[i] = true
+	}
+	var passed []ignoreCandidate
+	for i, a := range all {
+		_ = ml.LogSuggestion("delete_build_artifacts", metrics.Fingerprint(a.dir.Path), selectedSet[i], 0)
+		if !selectedSet[i] {
+			passed = append(passed, dirIgnoreCandidate(a.repoName+": "+a.dir.RelPath, a.dir.Path))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Println("No directories selected.")
+		return nil
+	}
+
+	toDelete := make([]artifactDelete, len(selected))
+	for i, idx := range selected {
+		toDelete[i] = all[idx]
+	}
+	if ok, err := confirmBulkDeletion(len(toDelete), "directories"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was deleted.")
+		}
+		return err
+	}
+	executeArtifactDeletes(toDelete, fs, ol)
+	return nil
+}
+
+// executeArtifactDeletes removes the selected directories through fs,
+// logging each removal and the space it freed.
+func executeArtifactDeletes(toDelete []artifactDelete, fs fsops.FileOps, ol *oplog.Logger) {
+	bold := color.New(color.Bold)
+	success := ui.Success()
+	fail := ui.Error()
+
+	var freed int64
+	for _, a := range toDelete {
+		if err := fs.Remove(a.dir.Path); err != nil {
+			fmt.Printf("  %s %s: %s (%v)\n", fail.Sprint("[fail]"), a.repoName, a.dir.RelPath, err)
+			continue
+		}
+		_ = ol.Log(oplog.Operation{
+			Type:      oplog.OpDeleteDir,
+			Path:      a.dir.Path,
+			SizeBytes: a.dir.Size,
+		})
+		recordImpact(metrics.ImpactEvent{BytesFreed: a.dir.Size})
+		freed += a.dir.Size
+		fmt.Printf("  %s %s: %s\n", success.Sprint("[deleted]"), a.repoName, a.dir.RelPath)
+	}
+	fmt.Printf("\n%s\n", bold.Sprintf("Freed %s.", formatSize(freed)))
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+func (c *AuditCmd) runHealth(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("audit --health", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	repoPaths, isLocal, ok, err := c.resolveRepos(globals, cfg)
+	if err != nil || !ok {
+		return err
+	}
+	slog.Debug("found repositories", "count", len(repoPaths))
+	printRepoCount("Checking", len(repoPaths), isLocal, " for corruption, stale locks, and shallow history...")
+
+	scanStart := time.Now()
+	progress := newProgress()
+	reports := audit.CheckIntegrity(repoPaths, workersFor(cfg, parallel.LocalWork, len(repoPaths)), time.Now(), progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range reports {
+		reports[i].RepoName = groupedName(projectsDir, reports[i].RepoPath)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(reports) == 0 {
+		fmt.Println("No integrity issues found.")
+		return nil
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].RepoName < reports[j].RepoName })
+	printIntegrityReports(reports)
+
+	if globals.DryRun {
+		return nil
+	}
+	return promptIntegrityFixes(reports, fsops.OS{}, loadIgnoreList(), ml)
+}
+
+func printIntegrityReports(reports []audit.IntegrityReport) {
+	bold := color.New(color.Bold)
+	fail := ui.Error()
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	issues, fixable := 0, 0
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) with integrity issues:", len(reports)))
+	for _, r := range reports {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.RepoName), dim.Sprint(r.RepoPath))
+		for _, issue := range r.Issues {
+			c := warn
+			if issue.Kind == audit.IssueCorrupt || issue.Kind == audit.IssueDanglingHead {
+				c = fail
+			}
+			fmt.Printf("    %s\n", c.Sprint(issue.Detail))
+			issues++
+			if issue.Fixable {
+				fixable++
+			}
+		}
+	}
+
+	fmt.Println()
+	if unfixable := issues - fixable; unfixable > 0 {
+		fmt.Println(dim.Sprintf("%d %s need manual repair (corrupt objects, dangling HEAD, or a rebase in progress).",
+			unfixable, pluralize(unfixable, "issue", "issues")))
+	}
+}
+
+// integrityFix pairs a fixable issue with its repository.
+type integrityFix struct {
+	repoPath string
+	repoName string
+	issue    audit.Issue
+}
+
+// fixLabel describes what applying the fix for issue will do.
+func fixLabel(f integrityFix) string {
+	switch f.issue.Kind {
+	case audit.IssueStaleLock:
+		return fmt.Sprintf("%s: remove %s", f.repoName, f.issue.Detail)
+	case audit.IssueShallow:
+		return fmt.Sprintf("%s: fetch full history (git fetch --unshallow)", f.repoName)
+	case audit.IssueRebaseLeftover:
+		return fmt.Sprintf("%s: discard rebase leftovers (git rebase --quit)", f.repoName)
+	}
+	return fmt.Sprintf("%s: %s", f.repoName, f.issue.Detail)
+}
+
+// promptIntegrityFixes offers the safe fixes for fixable issues, then
+// offers to ignore the repositories whose fixes were passed on. Nothing
+// is preselected: even safe fixes change repository state. Fixes in
+// ignored repositories are not offered, though their issues are still
+// reported.
+func promptIntegrityFixes(reports []audit.IntegrityReport, fs fsops.FileOps, il *ignore.List, ml *metrics.Logger) error {
+	var fixes []integrityFix
+	for _, r := range reports {
+		for _, issue := range r.Issues {
+			if issue.Fixable {
+				fixes = append(fixes, integrityFix{repoPath: r.RepoPath, repoName: r.RepoName, issue: issue})
+			}
+		}
+	}
+	fixes = withoutIgnored(fixes, func(f integrityFix) bool { return il.HasHealth(f.repoPath) }, "fix", "fixes")
+	if len(fixes) == 0 {
+		return nil
+	}
+
+	options := make([]huh.Option[int], len(fixes))
+	for i, f := range fixes {
+		options[i] = huh.NewOption(fixLabel(f), i)
+	}
+
+	var selected []int
+	if err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Apply these fixes?").
+				Description("Stale locks are only removed once no git process could still hold them.").
+				Options(options...).
+				Value(&selected),
+		),
+	).Run(); err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	selectedSet := make(map[int]bool, len(selected))
+	for _, i := range selected {
+		selectedSet[i] = true
+	}
+	var passed []ignoreCandidate
+	offered := make(map[string]bool)
+	for i, f := range fixes {
+		_ = ml.LogSuggestion("fix_"+string(f.issue.Kind), repoFingerprint(f.repoPath), selectedSet[i], 0)
+		if !selectedSet[i] && !offered[f.repoPath] {
+			offered[f.repoPath] = true
+			passed = append(passed, healthIgnoreCandidate(f.repoName, f.repoPath))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Println("No fixes selected.")
+		return nil
+	}
+	// Unshallowing only adds history; the other fixes delete lock files
+	// or rebase state.
+	removals := 0
+	for _, i := range selected {
+		if fixes[i].issue.Kind != audit.IssueShallow {
+			removals++
+		}
+	}
+	if ok, err := confirmBulkDeletion(removals, "lock files and rebase leftovers"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was changed.")
+		}
+		return err
+	}
+
+	success := ui.Success()
+	fail := ui.Error()
+	bold := color.New(color.Bold)
+
+	applied := 0
+	for _, i := range selected {
+		f := fixes[i]
+		if err := applyIntegrityFix(f, fs); err != nil {
+			fmt.Printf("  %s %s (%v)\n", fail.Sprint("[fail]"), fixLabel(f), err)
+			continue
+		}
+		applied++
+		fmt.Printf("  %s %s\n", success.Sprint("[fixed]"), fixLabel(f))
+	}
+	fmt.Printf("\n%s\n", bold.Sprintf("Applied %d %s.", applied, pluralize(applied, "fix", "fixes")))
+	return nil
+}
+
+// applyIntegrityFix performs the safe fix for a fixable issue. Lock files
+// are removed through fs.
+func applyIntegrityFix(f integrityFix, fs fsops.FileOps) error {
+	switch f.issue.Kind {
+	case audit.IssueStaleLock:
+		return fs.Remove(f.issue.Path)
+	case audit.IssueShallow:
+		remote := git.PrimaryRemote(f.repoPath)
+		if remote == "" {
+			return fmt.Errorf("no remote to fetch history from")
+		}
+		return git.Unshallow(f.repoPath, remote)
+	case audit.IssueRebaseLeftover:
+		return git.RebaseQuit(f.repoPath)
+	}
+	return fmt.Errorf("no automatic fix for %s", f.issue.Kind)
+}
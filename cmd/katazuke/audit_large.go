@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// maxBlobsPerRepo caps how many large blobs are listed for each repo.
+const maxBlobsPerRepo = 5
+
+func (c *AuditCmd) runLarge(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("audit --large", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	repoPaths, isLocal, ok, err := c.resolveRepos(globals, cfg)
+	if err != nil || !ok {
+		return err
+	}
+	slog.Debug("found repositories", "count", len(repoPaths))
+	printRepoCount("Checking", len(repoPaths), isLocal, " for LFS caches and large blobs...")
+
+	threshold := int64(c.BlobThresholdMB) * 1024 * 1024
+	scanStart := time.Now()
+	progress := newProgress()
+	reports := audit.AnalyzeStorage(repoPaths, threshold, workersFor(cfg, parallel.LocalWork, len(repoPaths)), progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range reports {
+		reports[i].RepoName = groupedName(projectsDir, reports[i].RepoPath)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(reports) == 0 {
+		fmt.Printf("No LFS caches or blobs over %d MB found.\n", c.BlobThresholdMB)
+		return nil
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].RepoName < reports[j].RepoName })
+	printStorageReports(reports)
+
+	if globals.DryRun {
+		return nil
+	}
+	return promptLFSPrune(reports, loadIgnoreList(), ml)
+}
+
+func printStorageReports(reports []audit.StorageReport) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	var lfsReclaimable, blobBytes int64
+	lfsUnchecked := false
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) with LFS caches or large blobs:", len(reports)))
+	for _, r := range reports {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.RepoName), dim.Sprint(r.RepoPath))
+		if r.HasLFS() {
+			line := fmt.Sprintf("LFS cache: %s", formatSize(r.LFSObjectsBytes))
+			switch {
+			case !r.LFSChecked:
+				line += dim.Sprint(" (install git-lfs to estimate prunable objects)")
+				lfsUnchecked = true
+			case r.LFSPrunable > 0:
+				line += warn.Sprintf(", %d prunable %s (%s reclaimable)", r.LFSPrunable,
+					pluralize(r.LFSPrunable, "object", "objects"), formatSize(r.LFSReclaimable))
+			default:
+				line += dim.Sprint(", nothing to prune")
+			}
+			fmt.Printf("    %s\n", line)
+			lfsReclaimable += r.LFSReclaimable
+		}
+		if len(r.LargeBlobs) > 0 {
+			fmt.Printf("    %s\n", warn.Sprintf("%d large %s in history (%s on disk)",
+				len(r.LargeBlobs), pluralize(len(r.LargeBlobs), "blob", "blobs"), formatSize(r.LargeBlobBytes())))
+			for i, b := range r.LargeBlobs {
+				if i == maxBlobsPerRepo {
+					fmt.Printf("      %s\n", dim.Sprintf("... and %d more", len(r.LargeBlobs)-maxBlobsPerRepo))
+					break
+				}
+				path := b.Path
+				if path == "" {
+					path = dim.Sprint("(unreachable object)")
+				}
+				fmt.Printf("      %8s  %s\n", formatSize(b.Size), path)
+			}
+			blobBytes += r.LargeBlobBytes()
+		}
+	}
+
+	fmt.Println()
+	if lfsReclaimable > 0 {
+		fmt.Println(bold.Sprintf("Reclaimable with git lfs prune: %s", formatSize(lfsReclaimable)))
+	}
+	if blobBytes > 0 {
+		fmt.Println(dim.Sprintf("Large blobs use %s; reclaiming it requires rewriting history (e.g. git filter-repo).", formatSize(blobBytes)))
+	}
+	if lfsUnchecked {
+		fmt.Println(dim.Sprint("git-lfs is not installed; prunable LFS objects could not be estimated."))
+	}
+}
+
+// promptLFSPrune offers to run `git lfs prune` in repos with prunable
+// objects, except ignored ones, then offers to ignore the repos passed on.
+func promptLFSPrune(reports []audit.StorageReport, il *ignore.List, ml *metrics.Logger) error {
+	var prunable []audit.StorageReport
+	for _, r := range reports {
+		if r.LFSPrunable > 0 {
+			prunable = append(prunable, r)
+		}
+	}
+	prunable = withoutIgnored(prunable, func(r audit.StorageReport) bool { return il.HasLFSPrune(r.RepoPath) }, "repository", "repositories")
+	if len(prunable) == 0 {
+		return nil
+	}
+
+	options := make([]huh.Option[int], len(prunable))
+	for i, r := range prunable {
+		label := fmt.Sprintf("%s (%s reclaimable)", r.RepoName, formatSize(r.LFSReclaimable))
+		options[i] = huh.NewOption(label, i)
+	}
+
+	var selected []int
+	if err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Run git lfs prune in these repositories?").
+				Description("Only objects not referenced by recent commits or unpushed work are removed; they can be re-downloaded.").
+				Options(options...).
+				Value(&selected),
+		),
+	).Run(); err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	selectedSet := make(map[int]bool, len(selected))
+	for _, i := range selected {
+		selectedSet[i] = true
+	}
+	var passed []ignoreCandidate
+	for i, r := range prunable {
+		_ = ml.LogSuggestion("lfs_prune", repoFingerprint(r.RepoPath), selectedSet[i], 0)
+		if !selectedSet[i] {
+			passed = append(passed, lfsPruneIgnoreCandidate(r.RepoName, r.RepoPath))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
+	}
+	if len(selected) == 0 {
+		fmt.Println("No repositories selected.")
+		return nil
+	}
+
+	success := ui.Success()
+	fail := ui.Error()
+	bold := color.New(color.Bold)
+
+	var freed int64
+	for _, i := range selected {
+		r := prunable[i]
+		if err := git.LFSPrune(r.RepoPath); err != nil {
+			fmt.Printf("  %s %s (%v)\n", fail.Sprint("[fail]"), r.RepoName, err)
+			continue
+		}
+		freed += r.LFSReclaimable
+		fmt.Printf("  %s %s\n", success.Sprint("[pruned]"), r.RepoName)
+	}
+	fmt.Printf("\n%s\n", bold.Sprintf("Freed approximately %s.", formatSize(freed)))
+	return nil
+}
@@ -1,6 +1,17 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
 
 func TestFormatSize(t *testing.T) {
 	tests := []struct {
@@ -29,3 +40,144 @@ func TestFormatSize(t *testing.T) {
 		})
 	}
 }
+
+func TestExecuteNonGitActions(t *testing.T) {
+	actions := []dirAction{
+		{dir: audit.NonRepoDir{Name: "scratch", Path: "/p/scratch"}, action: actionRemove},
+		{dir: audit.NonRepoDir{Name: "notes", Path: "/p/notes"}, action: actionKeep},
+		{dir: audit.NonRepoDir{Name: "old", Path: "/p/old"}, action: actionMove},
+	}
+
+	rec := helpers.NewFSRecorder()
+	executeNonGitActions(actions, "/q", rec, nil)
+
+	want := []helpers.FSOp{
+		{Kind: helpers.FSRemove, Path: "/p/scratch"},
+		{Kind: helpers.FSEnsureDir, Path: "/q"},
+		{Kind: helpers.FSMove, Path: "/p/old", Dest: "/q/old"},
+	}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+}
+
+func TestExecuteNonGitActions_QuarantineDirFails(t *testing.T) {
+	actions := []dirAction{
+		{dir: audit.NonRepoDir{Name: "old", Path: "/p/old"}, action: actionMove},
+	}
+
+	rec := helpers.NewFSRecorder()
+	rec.Errors = map[string]error{"/q": errors.New("read-only")}
+	executeNonGitActions(actions, "/q", rec, nil)
+
+	// The move must not be attempted when the destination can't be created.
+	want := []helpers.FSOp{{Kind: helpers.FSEnsureDir, Path: "/q"}}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+}
+
+func TestApplyIntegrityFixRemovesStaleLock(t *testing.T) {
+	rec := helpers.NewFSRecorder()
+	fix := integrityFix{
+		repoPath: "/p/app",
+		repoName: "app",
+		issue:    audit.Issue{Kind: audit.IssueStaleLock, Path: "/p/app/.git/index.lock", Fixable: true},
+	}
+	if err := applyIntegrityFix(fix, rec); err != nil {
+		t.Fatalf("applyIntegrityFix: %v", err)
+	}
+	want := []helpers.FSOp{{Kind: helpers.FSRemove, Path: "/p/app/.git/index.lock"}}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+
+	if err := applyIntegrityFix(integrityFix{issue: audit.Issue{Kind: audit.IssueCorrupt}}, rec); err == nil {
+		t.Error("expected an error for an issue without an automatic fix")
+	}
+}
+
+func TestPromptIntegrityFixesSkipsIgnoredRepos(t *testing.T) {
+	il, err := ignore.Load(filepath.Join(t.TempDir(), "ignore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := il.Add(time.Now(), ignore.Entry{Kind: ignore.KindHealth, Path: "/p/app"}); err != nil {
+		t.Fatal(err)
+	}
+	reports := []audit.IntegrityReport{{
+		RepoPath: "/p/app",
+		RepoName: "app",
+		Issues:   []audit.Issue{{Kind: audit.IssueStaleLock, Path: "/p/app/.git/index.lock", Fixable: true}},
+	}}
+
+	// With every fix ignored there is nothing to ask, so even a
+	// non-interactive run without --yes succeeds.
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, false)
+	rec := helpers.NewFSRecorder()
+	if err := promptIntegrityFixes(reports, rec, il, nil); err != nil {
+		t.Fatalf("promptIntegrityFixes: %v", err)
+	}
+	if got := rec.Ops(); len(got) != 0 {
+		t.Errorf("expected no operations, got %v", got)
+	}
+	if err := promptIntegrityFixes(reports, rec, nil, nil); !errors.Is(err, errNonInteractive) {
+		t.Errorf("expected the fix to be offered without an ignore list, got %v", err)
+	}
+}
+
+func TestExecuteArtifactDeletes(t *testing.T) {
+	toDelete := []artifactDelete{
+		{repoName: "api", dir: audit.ArtifactDir{Path: "/p/api/target", RelPath: "target", Size: 200}},
+		{repoName: "web", dir: audit.ArtifactDir{Path: "/p/web/node_modules", RelPath: "node_modules", Size: 300}},
+	}
+
+	rec := helpers.NewFSRecorder()
+	rec.Errors = map[string]error{"/p/api/target": errors.New("permission denied")}
+	executeArtifactDeletes(toDelete, rec, nil)
+
+	// A failed removal does not stop the rest.
+	want := []helpers.FSOp{
+		{Kind: helpers.FSRemove, Path: "/p/api/target"},
+		{Kind: helpers.FSRemove, Path: "/p/web/node_modules"},
+	}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+}
+
+func TestPromptNonGitActionsAutoQuarantine(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+
+	now := time.Now()
+	dirs := []audit.NonRepoDir{
+		{Name: "old-export", Path: "/p/old-export", LastModified: now.AddDate(0, 0, -200)},
+		{Name: "drafts", Path: "/p/drafts", LastModified: now.AddDate(0, 0, -3)},
+		{Name: "empty", Path: "/p/empty"},
+	}
+
+	rec := helpers.NewFSRecorder()
+	if err := promptNonGitActions(dirs, now.AddDate(0, 0, -90), rec, nil, nil, nil); err != nil {
+		t.Fatalf("promptNonGitActions: %v", err)
+	}
+	quarantine := filepath.Join(home, "katazuke-quarantine")
+	want := []helpers.FSOp{
+		{Kind: helpers.FSEnsureDir, Path: quarantine},
+		{Kind: helpers.FSMove, Path: "/p/old-export", Dest: filepath.Join(quarantine, "old-export")},
+	}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+
+	// Without a cutoff, --yes keeps everything.
+	rec = helpers.NewFSRecorder()
+	if err := promptNonGitActions(dirs, time.Time{}, rec, nil, nil, nil); err != nil {
+		t.Fatalf("promptNonGitActions: %v", err)
+	}
+	if got := rec.Ops(); len(got) != 0 {
+		t.Errorf("expected no operations, got %v", got)
+	}
+}
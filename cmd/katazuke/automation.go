@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// runArchiveAutomation deletes every local automation branch (dependabot,
+// renovate, release-please) that is merged or stale, after a single
+// confirmation that --yes answers. The bots recreate such branches on
+// demand, so only local copies are removed and their remote branches are
+// left to the tool that owns them. --merged or --stale limit the cleanup
+// to one kind.
+func (c *BranchesCmd) runArchiveAutomation(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Merged {
+		flags = append(flags, "--merged")
+	}
+	if c.Stale {
+		flags = append(flags, "--stale")
+	}
+	_ = ml.LogCommand("branches --archive-automation", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	il := loadIgnoreList()
+	showBoth := !c.Merged && !c.Stale
+	var toDelete []branchToDelete
+	if c.Merged || showBoth {
+		merged, err := c.scanMerged(globals, cfg, il, ml)
+		if err != nil {
+			return err
+		}
+		toDelete = append(toDelete, mergedToDelete(automationMerged(merged))...)
+	}
+	if c.Stale || showBoth {
+		stale, _, err := c.scanStale(globals, cfg, il, ml)
+		if err != nil {
+			return err
+		}
+		toDelete = append(toDelete, staleToDelete(automationStale(stale))...)
+	}
+
+	if len(toDelete) == 0 {
+		fmt.Println("No merged or stale automation branches found.")
+		return nil
+	}
+	if globals.DryRun {
+		printAutomationSummary("Would delete", toDelete)
+		return nil
+	}
+	printAutomationSummary("Found", toDelete)
+	if ok, err := confirmArchiveAutomation(len(toDelete)); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was deleted.")
+		}
+		return err
+	}
+
+	bk, err := newBackupStore(cfg)
+	if err != nil {
+		return err
+	}
+	deleteErr := deleteBranches(toDelete, false, bk, ol)
+
+	// Report only the branches that are actually gone.
+	var removed []branchToDelete
+	for _, b := range toDelete {
+		if !git.BranchExists(b.repoPath, b.branch) {
+			removed = append(removed, b)
+		}
+	}
+	if len(removed) > 0 {
+		printAutomationSummary("Removed", removed)
+	}
+	return deleteErr
+}
+
+// confirmArchiveAutomation is the single confirmation of an
+// --archive-automation run. It defaults to yes, so --yes runs the cleanup
+// unattended, and a run that is neither interactive nor given --yes fails
+// rather than deleting anything.
+func confirmArchiveAutomation(count int) (bool, error) {
+	proceed := true
+	err := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Delete %d local automation %s?", count, pluralize(count, "branch", "branches"))).
+				Description("Their bots recreate them when needed. Remote branches are left alone; stale ones are deleted with git branch -D.").
+				Value(&proceed),
+		),
+	).Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+	return proceed, nil
+}
+
+// automationMerged returns the merged branches created by automation.
+func automationMerged(merged []branches.MergedBranch) []branches.MergedBranch {
+	var auto []branches.MergedBranch
+	for _, m := range merged {
+		if branches.IsAutomationBranch(m.Branch) {
+			auto = append(auto, m)
+		}
+	}
+	return auto
+}
+
+// automationStale returns the stale branches created by automation.
+func automationStale(stale []branches.StaleBranch) []branches.StaleBranch {
+	var auto []branches.StaleBranch
+	for _, s := range stale {
+		if s.IsAutomation {
+			auto = append(auto, s)
+		}
+	}
+	return auto
+}
+
+// printAutomationSummary lists branches per repository under a heading
+// such as "Removed 5 automation branches:".
+func printAutomationSummary(verb string, list []branchToDelete) {
+	printBranchesByRepo(fmt.Sprintf("%s %d automation %s:", verb, len(list), pluralize(len(list), "branch", "branches")), list)
+}
+
+// printBranchesByRepo lists branches per repository under a heading.
+func printBranchesByRepo(heading string, list []branchToDelete) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	var order []string
+	byRepo := make(map[string][]string)
+	for _, b := range list {
+		if _, seen := byRepo[b.repoName]; !seen {
+			order = append(order, b.repoName)
+		}
+		byRepo[b.repoName] = append(byRepo[b.repoName], b.branch)
+	}
+
+	fmt.Printf("\n%s\n", bold.Sprint(heading))
+	for _, repo := range order {
+		names := byRepo[repo]
+		fmt.Printf("  %s  %s\n", bold.Sprint(repo), dim.Sprintf("(%d)", len(names)))
+		for _, name := range names {
+			fmt.Printf("    %s\n", name)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func TestAutomationFilters(t *testing.T) {
+	merged := automationMerged([]branches.MergedBranch{
+		{RepoName: "api", Branch: "dependabot/npm_and_yarn/lodash-4.17.21"},
+		{RepoName: "api", Branch: "feature/login"},
+		{RepoName: "web", Branch: "renovate/react-18.x"},
+	})
+	if len(merged) != 2 || merged[0].Branch != "dependabot/npm_and_yarn/lodash-4.17.21" || merged[1].Branch != "renovate/react-18.x" {
+		t.Errorf("expected the two bot branches, got %+v", merged)
+	}
+
+	stale := automationStale([]branches.StaleBranch{
+		{RepoName: "api", Branch: "release-please--branches--main", IsAutomation: true},
+		{RepoName: "api", Branch: "old-experiment"},
+	})
+	if len(stale) != 1 || stale[0].Branch != "release-please--branches--main" {
+		t.Errorf("expected only the release-please branch, got %+v", stale)
+	}
+}
+
+func TestConfirmArchiveAutomation(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, true)
+	if ok, err := confirmArchiveAutomation(3); err != nil || !ok {
+		t.Errorf("expected --yes to confirm, got %v, %v", ok, err)
+	}
+
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, false)
+	if ok, err := confirmArchiveAutomation(3); !errors.Is(err, errNonInteractive) || ok {
+		t.Errorf("expected a non-interactive run without --yes to fail, got %v, %v", ok, err)
+	}
+}
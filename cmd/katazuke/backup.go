@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/backup"
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// backupDir returns the configured backup directory, falling back to the
+// default location.
+func backupDir(cfg config.Config) (string, error) {
+	if cfg.Safety.BackupDir != "" {
+		return cfg.Safety.BackupDir, nil
+	}
+	return backup.DefaultDir()
+}
+
+// newBackupStore returns the store used to bundle branches and repos
+// before deletion, or nil when safety.bundle_before_delete is off. Unlike
+// metrics, a failure here is an error: the user asked for deletions to be
+// recoverable, so nothing may be deleted without a backup.
+func newBackupStore(cfg config.Config) (*backup.Store, error) {
+	if !cfg.Safety.BundleBeforeDelete {
+		return nil, nil
+	}
+	dir, err := backupDir(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return backup.New(dir, cfg.Safety.BackupRetentionDays), nil
+}
+
+// printBackupNote tells the user where bundles were written and how long
+// they are kept. Prints nothing for a nil store.
+func printBackupNote(bk *backup.Store) {
+	if bk == nil {
+		return
+	}
+	dim := ui.Muted()
+	expiry := "kept until removed manually"
+	if retentionDays := bk.RetentionDays(); retentionDays > 0 {
+		expiry = fmt.Sprintf("kept for %d %s", retentionDays, pluralize(retentionDays, "day", "days"))
+	}
+	fmt.Println(dim.Sprintf("Backup bundles written to %s (%s).", bk.Dir(), expiry))
+}
+
+// pruneBackups deletes expired bundles once per invocation. Like metrics
+// retention it is best-effort and runs even when bundling is disabled, so
+// turning the option off still cleans up old backups. cfg is the config
+// main loaded for the run.
+func pruneBackups(cfg config.Config) {
+	dir, err := backupDir(cfg)
+	if err != nil {
+		return
+	}
+	deleted, err := backup.Prune(dir, cfg.Safety.BackupRetentionDays, time.Now())
+	if err != nil {
+		slog.Debug("backup retention failed", "error", err)
+		return
+	}
+	if len(deleted) > 0 {
+		slog.Debug("expired backup bundles removed", "count", len(deleted))
+	}
+}
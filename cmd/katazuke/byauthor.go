@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// unknownAuthor heads the --by-author group of branches with no commits
+// of their own, whose authors cannot be told apart from the base branch's.
+const unknownAuthor = "No commits of their own"
+
+// authorGroup is one contributor's stale branches in the --by-author
+// summary.
+type authorGroup struct {
+	author   string
+	branches []branches.StaleBranch
+	repos    int
+	oldest   time.Time
+}
+
+// groupByAuthor collects stale branches under each author of their
+// commits, so a branch several people committed to is listed under each
+// of them. Authors with the most stale branches come first, then those
+// with the oldest; order within an author is kept.
+func groupByAuthor(stale []branches.StaleBranch) []authorGroup {
+	index := make(map[string]int)
+	var groups []authorGroup
+	repos := make(map[string]map[string]bool)
+	add := func(author string, s branches.StaleBranch) {
+		i, ok := index[author]
+		if !ok {
+			i = len(groups)
+			index[author] = i
+			groups = append(groups, authorGroup{author: author})
+			repos[author] = make(map[string]bool)
+		}
+		g := &groups[i]
+		g.branches = append(g.branches, s)
+		if g.oldest.IsZero() || s.LastCommit.Before(g.oldest) {
+			g.oldest = s.LastCommit
+		}
+		repos[author][s.RepoPath] = true
+		g.repos = len(repos[author])
+	}
+	for _, s := range stale {
+		if len(s.Authors) == 0 {
+			add(unknownAuthor, s)
+			continue
+		}
+		for _, a := range s.Authors {
+			add(a, s)
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		gi, gj := groups[i], groups[j]
+		if (gi.author == unknownAuthor) != (gj.author == unknownAuthor) {
+			return gj.author == unknownAuthor
+		}
+		if len(gi.branches) != len(gj.branches) {
+			return len(gi.branches) > len(gj.branches)
+		}
+		return gi.oldest.Before(gj.oldest)
+	})
+	return groups
+}
+
+// printStaleSummaryByAuthor lists stale branches under each of their
+// authors with a per-person tally, for nudging owners about old work.
+func printStaleSummaryByAuthor(stale []branches.StaleBranch) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	groups := groupByAuthor(stale)
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d stale branch(es) by %d %s:",
+		len(stale), len(groups), pluralize(len(groups), "author", "authors")))
+
+	for _, g := range groups {
+		fmt.Printf("  %s  %s\n", bold.Sprint(g.author), dim.Sprintf("%d %s in %d %s, oldest %s",
+			len(g.branches), pluralize(len(g.branches), "branch", "branches"),
+			g.repos, pluralize(g.repos, "repository", "repositories"), formatAge(g.oldest)))
+		for _, s := range g.branches {
+			fmt.Println("    " + staleSummaryLine(s, bold.Sprint(s.RepoName)+": "+s.Branch))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+)
+
+func TestGroupByAuthor(t *testing.T) {
+	now := time.Now()
+	old, older := now.Add(-60*24*time.Hour), now.Add(-400*24*time.Hour)
+	stale := []branches.StaleBranch{
+		{RepoPath: "/p/api", RepoName: "api", Branch: "a", LastCommit: old, Authors: []string{"bob@example.com"}},
+		{RepoPath: "/p/api", RepoName: "api", Branch: "b", LastCommit: old, Authors: []string{"ann@example.com", "bob@example.com"}},
+		{RepoPath: "/p/web", RepoName: "web", Branch: "c", LastCommit: older, Authors: []string{"bob@example.com"}},
+		{RepoPath: "/p/web", RepoName: "web", Branch: "d", LastCommit: older},
+		{RepoPath: "/p/web", RepoName: "web", Branch: "e", LastCommit: older, Authors: []string{"cat@example.com"}},
+	}
+
+	groups := groupByAuthor(stale)
+	var got []string
+	for _, g := range groups {
+		got = append(got, g.author)
+	}
+	want := []string{"bob@example.com", "cat@example.com", "ann@example.com", unknownAuthor}
+	if len(got) != len(want) {
+		t.Fatalf("got authors %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got authors %v, want %v", got, want)
+		}
+	}
+
+	bob := groups[0]
+	if len(bob.branches) != 3 || bob.repos != 2 || !bob.oldest.Equal(older) {
+		t.Errorf("unexpected group for bob: %d branches in %d repos, oldest %v", len(bob.branches), bob.repos, bob.oldest)
+	}
+	if bob.branches[0].Branch != "a" || bob.branches[2].Branch != "c" {
+		t.Errorf("expected branch order to be kept, got %v", bob.branches)
+	}
+}
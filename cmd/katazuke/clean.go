@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/merge"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/internal/sync"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// CleanCmd runs the common cleanup in one go: fetch with --prune, switch
+// repositories off merged branches, delete merged and safe stale
+// branches, and report what was done.
+type CleanCmd struct {
+	StaleDays int    `name:"stale-days" help:"Days before a branch is considered stale." default:"30"`
+	Pattern   string `name:"pattern" short:"f" help:"Filter repositories by name or group path (glob, e.g. '*kafka*' or 'work/*')."`
+}
+
+// cleanSummary counts what a clean run did, for the closing report.
+type cleanSummary struct {
+	fetched, fetchFailed int
+	switched             int
+	dirty                []string
+	deleted              int
+}
+
+// Run executes the clean command. It asks once, before changing
+// anything; after that, only the safety guards on large deletions can
+// prompt again. Only local branches are deleted, each bundled first when
+// safety.bundle_before_delete is on: remote branches and the "needs
+// review" stale tier are left to the branches command.
+func (c *CleanCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("clean", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	b := &BranchesCmd{StaleDays: c.StaleDays, Pattern: c.Pattern}
+	if c.Pattern != "" {
+		if err := b.scopeRepos(globals); err != nil || b.scoped == nil {
+			return err
+		}
+	}
+	repoPaths, isLocal, err := b.resolveRepos(globals, cfg)
+	if err != nil {
+		return err
+	}
+	if len(repoPaths) == 0 {
+		fmt.Println("No repositories found.")
+		return nil
+	}
+	b.scoped, b.scopedLocal = repoPaths, isLocal
+
+	if !globals.DryRun {
+		ok, err := confirmClean(len(repoPaths))
+		if err != nil || !ok {
+			if err == nil {
+				fmt.Println("Cancelled. Nothing was changed.")
+			}
+			return err
+		}
+	}
+
+	var sum cleanSummary
+	if globals.DryRun {
+		fmt.Println("Dry run -- not fetching; results reflect the last fetch.")
+	} else {
+		sum.fetched, sum.fetchFailed = fetchPruneAll(repoPaths, cfg, isLocal)
+	}
+
+	printRepoCount("Checking", len(repoPaths), isLocal, " for merged checkouts...")
+	detector := merge.NewDetector(merge.RealGitChecker{}, newGitHubClient(cfg))
+	progress := newProgress()
+	onMerged := repos.FindOnMergedBranch(repoPaths, detector, workersFor(cfg, parallel.LocalWork, len(repoPaths)), nil, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	fail := ui.Error()
+	success := ui.Success()
+	for _, r := range onMerged {
+		r.Name = groupedName(projectsDir, r.Path)
+		switch {
+		case !r.IsClean:
+			sum.dirty = append(sum.dirty, r.Name)
+		case globals.DryRun:
+			fmt.Printf("  Would switch %s from %s to %s\n", r.Name, r.CurrentBranch, r.DefaultBranch)
+		default:
+			if err := switchToDefault(r, ol); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to switch %s: %v", r.Name, err))
+				continue
+			}
+			fmt.Printf("  %s\n", success.Sprintf("Switched %s from %s to %s", r.Name, r.CurrentBranch, r.DefaultBranch))
+			sum.switched++
+		}
+	}
+
+	// Scan after switching, so the branches just switched off are found.
+	il := loadIgnoreList()
+	merged, err := b.scanMerged(globals, cfg, il, ml)
+	if err != nil {
+		return err
+	}
+	stale, _, err := b.scanStale(globals, cfg, il, ml)
+	if err != nil {
+		return err
+	}
+	toDelete := cleanBranches(merged, stale)
+
+	var deleteErr error
+	switch {
+	case len(toDelete) == 0:
+	case globals.DryRun:
+		printBranchesByRepo(fmt.Sprintf("Would delete %d %s:", len(toDelete), pluralize(len(toDelete), "branch", "branches")), toDelete)
+	default:
+		bk, err := newBackupStore(cfg)
+		if err != nil {
+			return err
+		}
+		deleteErr = deleteBranches(toDelete, false, bk, ol)
+		for _, d := range toDelete {
+			if !git.BranchExists(d.repoPath, d.branch) {
+				sum.deleted++
+			}
+		}
+	}
+
+	printCleanSummary(sum, len(toDelete), globals.DryRun)
+	return deleteErr
+}
+
+// confirmClean is the single up-front confirmation of a clean run. It
+// defaults to yes, so --yes runs the cleanup unattended.
+func confirmClean(repoCount int) (bool, error) {
+	proceed := true
+	err := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Clean up %d %s?", repoCount, pluralize(repoCount, "repository", "repositories"))).
+				Description("Fetches with --prune, switches clean checkouts off merged branches, and deletes merged and safe stale local branches.").
+				Value(&proceed),
+		),
+	).Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+	return proceed, nil
+}
+
+// fetchPruneAll fetches the primary remote of every repository with
+// --prune and returns how many were fetched and how many failed.
+// Repositories without a remote are skipped, and fetches that could not
+// reach the remote are retried as the sync settings allow.
+func fetchPruneAll(repoPaths []string, cfg config.Config, isLocal bool) (fetched, failed int) {
+	printRepoCount("Fetching", len(repoPaths), isLocal, "...")
+	progress := newProgress()
+	workers := workersFor(cfg, parallel.NetworkWork, len(repoPaths))
+	retryDelay := time.Duration(cfg.Sync.RetryDelaySeconds) * time.Second
+	results := parallel.RunHosts(repoPaths, workers, repoHost(cfg.HostLimits), cfg.HostLimits, func(repoPath string) error {
+		remote := git.PrimaryRemote(repoPath)
+		if remote == "" {
+			return nil
+		}
+		if _, err := sync.Retry(cfg.Sync.Retries, retryDelay, func() error { return git.FetchPrune(repoPath, remote) }); err != nil {
+			slog.Warn("fetch failed", "repo", filepath.Base(repoPath), "remote", remote, "error", err)
+			return err
+		}
+		return nil
+	}, func(completed, total int, _ error) { progress.Update(completed, total) })
+	progress.Stop()
+	for _, err := range results {
+		if err != nil {
+			failed++
+		}
+	}
+	return len(results) - failed, failed
+}
+
+// cleanBranches returns the branches a clean run deletes: every merged
+// branch, and the stale branches in the "safe to delete" tier whose tip is
+// verified to be in the default branch or a remote-tracking branch, so
+// force-deleting them loses no commits. Stale branches with commits found
+// nowhere else are left to the branches command.
+func cleanBranches(merged []branches.MergedBranch, stale []branches.StaleBranch) []branchToDelete {
+	toDelete := mergedToDelete(merged)
+	seen := make(map[string]bool, len(toDelete))
+	for _, d := range toDelete {
+		seen[d.repoPath+":"+d.branch] = true
+	}
+	safe, _, _, _ := categorizeStaleBranches(stale)
+	var verified []branches.StaleBranch
+	for _, s := range safe {
+		if s.TipReachable {
+			verified = append(verified, s)
+		}
+	}
+	for _, d := range staleToDelete(verified) {
+		if !seen[d.repoPath+":"+d.branch] {
+			toDelete = append(toDelete, d)
+		}
+	}
+	return toDelete
+}
+
+// printCleanSummary reports what a clean run did, and what it left for
+// the individual commands.
+func printCleanSummary(sum cleanSummary, candidates int, dryRun bool) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n", bold.Sprint("Clean summary:"))
+	if dryRun {
+		fmt.Printf("  Would delete %d %s.\n", candidates, pluralize(candidates, "branch", "branches"))
+		fmt.Println(bold.Sprint("Dry run -- no changes made."))
+	} else {
+		fmt.Printf("  Fetched %d %s", sum.fetched, pluralize(sum.fetched, "repository", "repositories"))
+		if sum.fetchFailed > 0 {
+			fmt.Print(warn.Sprintf(" (%d failed)", sum.fetchFailed))
+		}
+		fmt.Println(".")
+		fmt.Printf("  Switched %d %s off merged branches.\n", sum.switched, pluralize(sum.switched, "repository", "repositories"))
+		fmt.Printf("  Deleted %d %s.\n", sum.deleted, pluralize(sum.deleted, "branch", "branches"))
+	}
+	for _, name := range sum.dirty {
+		fmt.Printf("  %s\n", warn.Sprintf("%s is on a merged branch with uncommitted changes; left as is.", name))
+	}
+	fmt.Println(dim.Sprint("Stale branches that need review, and remote branches, are left to `katazuke branches`."))
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func TestCleanBranches(t *testing.T) {
+	merged := []branches.MergedBranch{
+		{RepoPath: "/p/api", RepoName: "api", Branch: "feature/login"},
+	}
+	stale := []branches.StaleBranch{
+		{RepoPath: "/p/api", RepoName: "api", Branch: "feature/login", HasRemote: true, IsOwnBranch: true},
+		{RepoPath: "/p/api", RepoName: "api", Branch: "old-spike", HasRemote: true, IsOwnBranch: true, TipReachable: true},
+		{RepoPath: "/p/api", RepoName: "api", Branch: "ahead-of-remote", HasRemote: true, IsOwnBranch: true},
+		{RepoPath: "/p/api", RepoName: "api", Branch: "local-only", IsOwnBranch: true},
+		{RepoPath: "/p/api", RepoName: "api", Branch: "teammate", HasRemote: true},
+		{RepoPath: "/p/web", RepoName: "web", Branch: "renovate/react", HasRemote: true, IsOwnBranch: true, IsAutomation: true},
+	}
+
+	got := cleanBranches(merged, stale)
+	var names []string
+	for _, d := range got {
+		names = append(names, d.branch)
+	}
+	if len(names) != 2 || names[0] != "feature/login" || names[1] != "old-spike" {
+		t.Errorf("expected the merged branch and the verified safe stale one, got %v", names)
+	}
+}
+
+func TestConfirmCleanAssumeYes(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+	if ok, err := confirmClean(3); err != nil || !ok {
+		t.Errorf("expected --yes to confirm, got %v (%v)", ok, err)
+	}
+
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, false)
+	if _, err := confirmClean(3); err == nil {
+		t.Error("expected an error without a terminal or --yes")
+	}
+}
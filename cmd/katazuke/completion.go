@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// CompletionCmd groups diagnostics for how katazuke integrates with the
+// user's terminal and shell.
+type CompletionCmd struct {
+	Doctor CompletionDoctorCmd `cmd:"" help:"Show detected terminal capabilities and the active UI mode."`
+}
+
+// CompletionDoctorCmd prints the terminal capabilities detected at startup.
+type CompletionDoctorCmd struct{}
+
+// Run executes the completion doctor command.
+func (c *CompletionDoctorCmd) Run(_ *CLI) error {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	termName := uiCaps.Term
+	if termName == "" {
+		termName = "(unset)"
+	}
+	width := "unknown"
+	if uiCaps.Width > 0 {
+		width = fmt.Sprintf("%d columns", uiCaps.Width)
+	}
+
+	fmt.Println(bold.Sprint("Terminal capabilities:"))
+	fmt.Printf("  TERM:      %s\n", termName)
+	fmt.Printf("  TTY:       %s\n", yesNo(uiCaps.IsTTY))
+	fmt.Printf("  Stdin TTY: %s\n", yesNo(uiCaps.StdinTTY))
+	fmt.Printf("  Color:     %s\n", yesNo(!uiCaps.NoColor))
+	fmt.Printf("  Width:     %s\n", width)
+	fmt.Println()
+	fmt.Printf("%s %s\n", bold.Sprint("UI mode:"), uiCaps.Mode)
+	if uiCaps.Reason != "" {
+		fmt.Printf("  %s\n", dim.Sprintf("(%s: progress counters hidden, prompts are line-oriented)", uiCaps.Reason))
+	}
+	if !uiCaps.Interactive() {
+		fmt.Printf("  %s\n", dim.Sprint("(prompts disabled: re-run with --yes to accept default selections)"))
+	}
+	return nil
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// ConfigCmd inspects the effective configuration.
+type ConfigCmd struct {
+	Env ConfigEnvCmd `cmd:"" help:"List every supported environment variable with the effective value of its setting and where that value came from (default, file, profile, env, policy, or flag)."`
+}
+
+// ConfigEnvCmd prints the environment variable reference with effective
+// values, for debugging which layer wins.
+type ConfigEnvCmd struct{}
+
+// profileFromFlag is set when --profile, rather than KATAZUKE_PROFILE,
+// selected the profile. main exports the flag to the environment, so
+// config env could not tell them apart afterwards.
+var profileFromFlag bool
+
+// envRow is one variable in the config env report.
+type envRow struct {
+	name, key, value, source string
+	// note explains why a variable that is set had no effect.
+	note string
+}
+
+// Run executes the config env command.
+func (c *ConfigEnvCmd) Run(g *CLI) error {
+	cfg, sources, err := config.LoadWithSources()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := g.applyFlags(&cfg, sources); err != nil {
+		return err
+	}
+	if g.ProjectsDir != "" && g.ProjectsDir != os.Getenv("KATAZUKE_PROJECTS_DIR") {
+		cfg.ProjectsDir = resolveProjectsDir(g.ProjectsDir, cfg)
+		sources["projects_dir"] = config.Origin{Source: config.SourceFlag, Via: "--projects-dir"}
+	}
+	if profileFromFlag {
+		sources["profile"] = config.Origin{Source: config.SourceFlag, Via: "--profile"}
+	}
+
+	rows := envReport(cfg, sources, os.Getenv)
+	nameWidth, settingWidth := 0, 0
+	for _, r := range rows {
+		nameWidth = max(nameWidth, len(r.name))
+		settingWidth = max(settingWidth, len(r.key)+3+len(r.value))
+	}
+
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+	warn := ui.Warn()
+	fmt.Println(bold.Sprint("Environment variables, with the effective value of each setting and its source:"))
+	fmt.Println()
+	for _, r := range rows {
+		setting := r.key + " = " + r.value
+		fmt.Printf("  %-*s  %-*s  %s\n", nameWidth, r.name, settingWidth, setting, dim.Sprint(r.source))
+		if r.note != "" {
+			fmt.Printf("  %-*s  %s\n", nameWidth, "", warn.Sprint(r.note))
+		}
+	}
+	fmt.Println()
+	fmt.Println(dim.Sprint("Precedence, lowest first: default, file, profile, env, policy, flag. An empty variable counts as unset."))
+	return nil
+}
+
+// envReport describes each supported variable: the effective value of
+// its setting, that value's source, and, for a variable that is set but
+// did not take effect, why.
+func envReport(cfg config.Config, sources config.Sources, getenv func(string) string) []envRow {
+	var rows []envRow
+	for _, e := range config.EnvVars() {
+		v, _ := cfg.Lookup(e.Key)
+		origin := sources.Of(e.Key)
+		r := envRow{
+			name:   e.Name,
+			key:    e.Key,
+			value:  formatSetting(v, e.Secret),
+			source: describeOrigin(origin, e.Name),
+		}
+		if set := getenv(e.Name); set != "" && (origin.Source != config.SourceEnv || origin.Via != e.Name) {
+			shown := fmt.Sprintf("%q", set)
+			if e.Secret {
+				shown = "a value"
+			}
+			switch {
+			case origin.Source == config.SourceFlag || origin.Source == config.SourcePolicy:
+				r.note = fmt.Sprintf("set to %s but overridden by the %s", shown, origin.Source)
+			case origin.Source == config.SourceEnv:
+				r.note = fmt.Sprintf("set to %s but overridden by %s", shown, origin.Via)
+			default:
+				r.note = fmt.Sprintf("set to %s but ignored: not a valid value", shown)
+			}
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// describeOrigin names a source, adding what set it when that is not
+// the variable the row is about, e.g. "file (sync.workers)".
+func describeOrigin(o config.Origin, name string) string {
+	if o.Via == "" || o.Via == name {
+		return string(o.Source)
+	}
+	return fmt.Sprintf("%s (%s)", o.Source, o.Via)
+}
+
+// formatSetting renders a setting's value on one line. Credentials only
+// show whether they are set.
+func formatSetting(v any, secret bool) string {
+	if secret {
+		if v == "" {
+			return "(unset)"
+		}
+		return "(set)"
+	}
+	switch v := v.(type) {
+	case string:
+		if v == "" {
+			return "(unset)"
+		}
+		return v
+	case []string:
+		if len(v) == 0 {
+			return "(none)"
+		}
+		return strings.Join(v, ",")
+	case map[string]int:
+		if len(v) == 0 {
+			return "(none)"
+		}
+		items := make([]string, 0, len(v))
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			items = append(items, fmt.Sprintf("%s=%d", k, v[k]))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+)
+
+func TestEnvReport(t *testing.T) {
+	cfg := config.Defaults()
+	cfg.Sync.Strategy = "merge"
+	cfg.Workers = 5
+	cfg.GithubToken = "secret"
+	cfg.HostLimits = map[string]int{"github.com": 2, "api.github.com": 4}
+	sources := config.Sources{
+		"sync.strategy": {Source: config.SourceEnv, Via: "KATAZUKE_SYNC_STRATEGY"},
+		"workers":       {Source: config.SourceFlag, Via: "--workers"},
+		"github_token":  {Source: config.SourceEnv, Via: "GH_TOKEN"},
+		"host_limits":   {Source: config.SourceFile},
+	}
+	env := map[string]string{
+		"KATAZUKE_SYNC_STRATEGY": "merge",
+		"KATAZUKE_WORKERS":       "3",
+		"KATAZUKE_SYNC_RETRIES":  "often",
+		"GH_TOKEN":               "secret",
+		"KATAZUKE_GITHUB_TOKEN":  "",
+		"GITHUB_TOKEN":           "other",
+	}
+	rows := make(map[string]envRow)
+	for _, r := range envReport(cfg, sources, func(k string) string { return env[k] }) {
+		rows[r.name] = r
+	}
+
+	tests := []struct {
+		name, value, source, note string
+	}{
+		{"KATAZUKE_SYNC_STRATEGY", "merge", "env", ""},
+		{"KATAZUKE_WORKERS", "5", "flag (--workers)", `set to "3" but overridden by the flag`},
+		{"KATAZUKE_SYNC_RETRIES", "2", "default", `set to "often" but ignored: not a valid value`},
+		{"GH_TOKEN", "(set)", "env", ""},
+		{"KATAZUKE_GITHUB_TOKEN", "(set)", "env (GH_TOKEN)", ""},
+		{"GITHUB_TOKEN", "(set)", "env (GH_TOKEN)", "set to a value but overridden by GH_TOKEN"},
+		{"KATAZUKE_HOST_LIMITS", "api.github.com=4,github.com=2", "file", ""},
+		{"KATAZUKE_PROTECTED_BRANCHES", "(none)", "default", ""},
+		{"KATAZUKE_POLICY_URL", "(unset)", "default", ""},
+	}
+	for _, tt := range tests {
+		r, ok := rows[tt.name]
+		if !ok {
+			t.Errorf("%s missing from the report", tt.name)
+			continue
+		}
+		if r.value != tt.value || r.source != tt.source || r.note != tt.note {
+			t.Errorf("%s: got %q, %q, %q; want %q, %q, %q", tt.name, r.value, r.source, r.note, tt.value, tt.source, tt.note)
+		}
+		if strings.Contains(r.value+r.note, "secret") || strings.Contains(r.note, "other") {
+			t.Errorf("%s: credential leaked: %+v", tt.name, r)
+		}
+	}
+}
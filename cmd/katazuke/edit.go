@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/backup"
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/tags"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// branchAction is what --edit does with a branch.
+type branchAction string
+
+// Actions accepted in an --edit buffer.
+const (
+	branchKeep    branchAction = "keep"
+	branchDelete  branchAction = "delete"
+	branchArchive branchAction = "archive"
+)
+
+// parseBranchAction accepts an action keyword or its first letter.
+func parseBranchAction(word string) (branchAction, bool) {
+	switch strings.ToLower(word) {
+	case "keep", "k":
+		return branchKeep, true
+	case "delete", "d":
+		return branchDelete, true
+	case "archive", "a":
+		return branchArchive, true
+	}
+	return "", false
+}
+
+// editSection is a titled block of branches in an --edit buffer, all
+// starting with the same action.
+type editSection struct {
+	title  string
+	labels []string
+	action branchAction
+}
+
+// editInstructions explains the --edit buffer format. It follows the
+// caller's header.
+var editInstructions = []string{
+	"",
+	"Commands:",
+	"  keep    (k) = leave the branch alone",
+	"  delete  (d) = delete the branch",
+	"  archive (a) = tag its tip as " + tags.ArchivePrefix + "<branch>, then delete the branch",
+	"",
+	"Change the word at the start of a line to choose. Removing a line",
+	"keeps that branch. Lines starting with # are ignored, and the number",
+	"after the command identifies the branch.",
+}
+
+// editSelect generates an --edit buffer with one "<action> <n> <label>"
+// line per branch, opens it in the editor, and returns the chosen action of
+// every item, per section. A buffer that cannot be parsed aborts without
+// acting on anything. With --yes the editor is skipped and the initial
+// actions are used.
+func editSelect(header []string, sections []editSection) ([][]branchAction, error) {
+	if assumeYes {
+		return defaultEditActions(sections), nil
+	}
+	if !uiCaps.Interactive() {
+		return nil, errNonInteractive
+	}
+
+	edited, err := editText(formatEdit(header, sections))
+	if err != nil {
+		return nil, err
+	}
+	return parseEdit(edited, sections)
+}
+
+// defaultEditActions returns each section's initial action for all of
+// its items.
+func defaultEditActions(sections []editSection) [][]branchAction {
+	actions := make([][]branchAction, len(sections))
+	for i, s := range sections {
+		actions[i] = make([]branchAction, len(s.labels))
+		for j := range s.labels {
+			actions[i][j] = s.action
+		}
+	}
+	return actions
+}
+
+// formatEdit renders the --edit buffer.
+func formatEdit(header []string, sections []editSection) string {
+	blocks := make([]selectionBlock, len(sections))
+	for i, s := range sections {
+		blocks[i] = selectionBlock{title: s.title, labels: s.labels, marker: string(s.action)}
+	}
+	return formatSelection(header, editInstructions, blocks, len(branchArchive))
+}
+
+// parseEdit reads the actions from an edited --edit buffer. Items whose
+// line was removed are kept.
+func parseEdit(content string, sections []editSection) ([][]branchAction, error) {
+	blocks := make([]selectionBlock, len(sections))
+	for i, s := range sections {
+		blocks[i] = selectionBlock{title: s.title, labels: s.labels}
+	}
+	return parseSelection(content, "edit buffer", blocks, branchKeep, parseActionWord)
+}
+
+// parseActionWord reads the action at the start of an --edit buffer line.
+func parseActionWord(line string) (branchAction, string, error) {
+	word := strings.Fields(line)[0]
+	action, ok := parseBranchAction(word)
+	if !ok {
+		return "", "", fmt.Errorf("unknown command %q (use keep, delete, or archive)", word)
+	}
+	return action, line[len(word):], nil
+}
+
+// editMergedSelection is the --edit counterpart of promptForDeletion.
+// Every branch starts as keep.
+func editMergedSelection(merged []branches.MergedBranch) (toDelete, toArchive []branches.MergedBranch, err error) {
+	labels := make([]string, len(merged))
+	for i, m := range merged {
+		labels[i] = m.Label()
+	}
+	header := []string{
+		fmt.Sprintf("katazuke branches --merged: %d merged %s", len(merged), pluralize(len(merged), "branch", "branches")),
+	}
+	actions, err := editSelect(header, []editSection{{title: "Merged branches", labels: labels, action: branchKeep}})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i, action := range actions[0] {
+		switch action {
+		case branchDelete:
+			toDelete = append(toDelete, merged[i])
+		case branchArchive:
+			toArchive = append(toArchive, merged[i])
+		}
+	}
+	return toDelete, toArchive, nil
+}
+
+// editStaleSelection is the --edit counterpart of the per-tier stale
+// prompts. Preselected tiers start as delete, the rest as keep.
+func editStaleSelection(tiers []staleTier) (toDelete, toArchive []branches.StaleBranch, err error) {
+	sections := make([]editSection, len(tiers))
+	total := 0
+	for i, t := range tiers {
+		labels := make([]string, len(t.branches))
+		for j, s := range t.branches {
+			labels[j] = staleBranchLabel(s)
+		}
+		action := branchKeep
+		if t.preselect {
+			action = branchDelete
+		}
+		sections[i] = editSection{title: t.title, labels: labels, action: action}
+		total += len(t.branches)
+	}
+
+	header := []string{fmt.Sprintf("katazuke branches --stale: %d stale %s", total, pluralize(total, "branch", "branches"))}
+	for _, t := range tiers {
+		if len(t.branches) > 0 {
+			header = append(header, fmt.Sprintf("  %s: %d. %s", t.title, len(t.branches), t.description))
+		}
+	}
+
+	actions, err := editSelect(header, sections)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, t := range tiers {
+		for j, action := range actions[i] {
+			switch action {
+			case branchDelete:
+				toDelete = append(toDelete, t.branches[j])
+			case branchArchive:
+				toArchive = append(toArchive, t.branches[j])
+			}
+		}
+	}
+	return toDelete, toArchive, nil
+}
+
+// archiveTagName returns the tag that preserves branch's tip.
+func archiveTagName(branch string) string {
+	return tags.ArchivePrefix + branch
+}
+
+// archiveBranches tags the tip of each branch as archive/<branch> and then
+// deletes the local branch. Remote branches are never touched: the point
+// of archiving is to keep the work reachable. A branch whose tag cannot be
+// created is kept.
+func archiveBranches(toArchive []branchToDelete, bk *backup.Store, ol *oplog.Logger) error {
+	success := ui.Success()
+	fail := ui.Error()
+
+	toArchive, err := keepMyBranches(toArchive)
+	if err != nil {
+		return err
+	}
+	var tagged []branchToDelete
+	var failed []string
+	for _, b := range toArchive {
+		tag := archiveTagName(b.branch)
+		if _, err := git.RevParse(b.repoPath, "refs/tags/"+tag); err == nil {
+			fmt.Printf("  %s %s: %s (tag %s already exists, not deleting)\n", fail.Sprint("[fail]"), b.repoName, b.branch, tag)
+			failed = append(failed, fmt.Sprintf("%s: %s", b.repoName, b.branch))
+			continue
+		}
+		if err := git.CreateTag(b.repoPath, tag, "refs/heads/"+b.branch); err != nil {
+			fmt.Printf("  %s %s: %s (%v)\n", fail.Sprint("[fail]"), b.repoName, b.branch, err)
+			failed = append(failed, fmt.Sprintf("%s: %s", b.repoName, b.branch))
+			continue
+		}
+		fmt.Printf("  %s %s: %s as %s\n", success.Sprint("[archived]"), b.repoName, b.branch, tag)
+
+		b.canDeleteRemote = false
+		b.forceLocal = true // the tag keeps the commits reachable
+		tagged = append(tagged, b)
+	}
+
+	if len(tagged) > 0 {
+		err = deleteBranches(tagged, false, bk, ol)
+	}
+	if len(failed) > 0 {
+		archiveErr := fmt.Errorf("failed to archive %d branch(es): %s", len(failed), strings.Join(failed, ", "))
+		if err != nil {
+			return fmt.Errorf("%w; %w", archiveErr, err)
+		}
+		return archiveErr
+	}
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/backup"
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestEditStaleSelection(t *testing.T) {
+	withPromptMode(t, interactiveCaps, false)
+	stale := []branches.StaleBranch{
+		{RepoName: "api", Branch: "mine", HasRemote: true, IsOwnBranch: true},
+		{RepoName: "api", Branch: "dependabot/x", IsAutomation: true, IsOwnBranch: true},
+		{RepoName: "web", Branch: "local-work", IsOwnBranch: true},
+	}
+
+	var written string
+	withEditor(t, func(content string) string {
+		written = content
+		// Drop the automation branch's line and archive the local-only one.
+		var kept []string
+		for _, line := range strings.Split(content, "\n") {
+			if !strings.HasPrefix(line, "delete  2 ") {
+				kept = append(kept, line)
+			}
+		}
+		return strings.Replace(strings.Join(kept, "\n"), "keep    3 ", "a 3 ", 1)
+	})
+
+	toDelete, toArchive, err := editStaleSelection(staleTiers(stale))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(written, "# == Needs review (1) ==\nkeep    3 web: local-work") {
+		t.Errorf("unexpected edit buffer:\n%s", written)
+	}
+	if len(toDelete) != 1 || toDelete[0].Branch != "mine" {
+		t.Errorf("expected only mine deleted, got %+v", toDelete)
+	}
+	if len(toArchive) != 1 || toArchive[0].Branch != "local-work" {
+		t.Errorf("expected local-work archived, got %+v", toArchive)
+	}
+}
+
+func TestEditMergedSelectionRejectsBadEdits(t *testing.T) {
+	withPromptMode(t, interactiveCaps, false)
+	merged := []branches.MergedBranch{{RepoName: "api", Branch: "done"}}
+
+	for name, edit := range map[string]func(string) string{
+		"unknown command": func(c string) string { return strings.Replace(c, "keep    1", "drop 1", 1) },
+		"unknown number":  func(c string) string { return c + "delete 9 nope\n" },
+		"missing number":  func(c string) string { return c + "delete\n" },
+		"duplicate":       func(c string) string { return c + "archive 1 again\n" },
+	} {
+		withEditor(t, edit)
+		if _, _, err := editMergedSelection(merged); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// With --yes every merged branch is kept.
+	withPromptMode(t, interactiveCaps, true)
+	toDelete, toArchive, err := editMergedSelection(merged)
+	if err != nil || len(toDelete) != 0 || len(toArchive) != 0 {
+		t.Errorf("expected nothing selected with --yes, got %+v, %+v, %v", toDelete, toArchive, err)
+	}
+}
+
+func TestArchiveBranches(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "archive")
+	repo.CreateBranch("old")
+	repo.WriteFile("old.txt", "old\n")
+	repo.AddFile("old.txt")
+	repo.Commit("unmerged work")
+	repo.Checkout("main")
+	repo.CreateBranch("taken")
+	repo.Checkout("main")
+	repo.Git("tag", "archive/taken")
+
+	tip, err := git.RevParse(repo.Path, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bk := backup.New(t.TempDir(), 30)
+	err = archiveBranches([]branchToDelete{
+		{repoPath: repo.Path, repoName: "archive", branch: "old", hasRemote: true, canDeleteRemote: true},
+		{repoPath: repo.Path, repoName: "archive", branch: "taken"},
+	}, bk, nil)
+	if err == nil || !strings.Contains(err.Error(), "archive: taken") {
+		t.Errorf("expected the existing tag to fail taken, got %v", err)
+	}
+
+	if got, err := git.RevParse(repo.Path, "refs/tags/archive/old"); err != nil || got != tip {
+		t.Errorf("expected archive/old at %s, got %q, %v", tip, got, err)
+	}
+	names, err := git.ListBranches(repo.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(names, ",") != "main,taken" {
+		t.Errorf("expected old deleted and taken kept, got %v", names)
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// newExplainLog returns a decision log when --explain is set, or nil so
+// scanners skip recording entirely.
+func newExplainLog(enabled bool) *explain.Log {
+	if !enabled {
+		return nil
+	}
+	return explain.New()
+}
+
+// printExplanations prints every recorded decision grouped by repository
+// and subject. Repository-level decisions (empty subject) come first.
+func printExplanations(ex *explain.Log) {
+	entries := ex.Entries()
+	if len(entries) == 0 {
+		return
+	}
+
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	fmt.Printf("%s\n", bold.Sprint("Explanation:"))
+	currentRepo, currentSubject := "", ""
+	for i, e := range entries {
+		if i == 0 || e.Repo != currentRepo {
+			currentRepo, currentSubject = e.Repo, ""
+			fmt.Printf("  %s\n", bold.Sprint(e.Repo))
+			if e.Subject != "" {
+				currentSubject = e.Subject
+				fmt.Printf("    %s\n", e.Subject)
+			}
+		} else if e.Subject != currentSubject {
+			currentSubject = e.Subject
+			fmt.Printf("    %s\n", e.Subject)
+		}
+		indent := "      "
+		if e.Subject == "" {
+			indent = "    "
+		}
+		fmt.Printf("%s%s %s\n", indent, verdictColor(e.Verdict).Sprintf("%-9s", e.Verdict), dim.Sprint(e.Reason))
+	}
+	fmt.Println()
+}
+
+func verdictColor(verdict string) *color.Color {
+	switch verdict {
+	case explain.Reported:
+		return ui.Success()
+	case explain.Protected:
+		return ui.Warn()
+	case explain.Skipped:
+		return ui.Error()
+	default:
+		return ui.Muted()
+	}
+}
+
+// explainStaleTiers records which safety tier each stale branch lands in
+// and, for branches with a remote, whether remote deletion is allowed.
+func explainStaleTiers(stale []branches.StaleBranch, ex *explain.Log) {
+	if ex == nil {
+		return
+	}
+	for _, s := range stale {
+		switch {
+		case s.IsAutomation:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: automation branches (name matches a bot prefix)")
+		case s.Diverged:
+			ex.Addf(s.RepoName, s.Branch, explain.Reported, "tier: diverged from remote (%d local and %d remote commits the other copy lacks)",
+				s.UpstreamAhead, s.UpstreamBehind)
+		case s.OpenPRNumber > 0:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: needs review (has an open draft PR)")
+		case s.IsUpstreamGone && goneVerified(s):
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: safe to delete (its upstream branch was deleted and its commits exist elsewhere)")
+		case s.IsUpstreamGone:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: needs review (its upstream branch was deleted, but its commits were not found elsewhere or it has other authors)")
+		case s.HasRemote && s.IsOwnBranch:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: safe to delete (you are the sole author and it is backed up remotely)")
+		case !s.HasRemote:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: needs review (no remote copy; commits may exist only here)")
+		default:
+			ex.Add(s.RepoName, s.Branch, explain.Reported, "tier: needs review (has commits by other authors)")
+		}
+
+		if !s.HasRemote {
+			continue
+		}
+		switch {
+		case s.IsAutomation:
+			ex.Add(s.RepoName, s.Branch, explain.Protected, "remote deletion blocked: automation branch, managed by its tool")
+		case !s.IsOwnBranch:
+			ex.Add(s.RepoName, s.Branch, explain.Protected, "remote deletion blocked: has commits by other authors")
+		case s.Diverged:
+			ex.Add(s.RepoName, s.Branch, explain.Protected, "remote deletion blocked: the remote branch has commits the local one lacks")
+		case s.OpenPRNumber > 0:
+			ex.Add(s.RepoName, s.Branch, explain.Protected, fmt.Sprintf("remote deletion blocked: deleting it would close open PR #%d", s.OpenPRNumber))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+)
+
+// runGone deletes the local branches whose upstream was deleted from the
+// remote, the usual leftovers of PRs merged with auto-delete, after a
+// single confirmation. Only branches whose commits are in the default
+// branch or a remote-tracking branch are deleted, and with git branch -d;
+// the rest are listed for review. It skips the age threshold, merge
+// detection, and GitHub lookups, so it only sees what the last fetch with
+// --prune recorded.
+func (c *BranchesCmd) runGone(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.Limit > 0 {
+		flags = append(flags, fmt.Sprintf("--limit=%d", c.Limit))
+	}
+	_ = ml.LogCommand("branches --gone", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	scanStart := time.Now()
+	repos, isLocal, err := c.resolveRepos(globals, cfg)
+	if err != nil {
+		return err
+	}
+	workers := workersFor(cfg, parallel.LocalWork, len(repos))
+	slog.Debug("using worker pool", "workers", workers)
+	printRepoCount("Scanning", len(repos), isLocal, " for branches with a gone upstream...")
+
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	gone := branches.FindUpstreamGone(repos, workers, ex, progress.Update)
+	progress.Stop()
+	il := loadIgnoreList()
+	gone = withoutIgnored(gone, func(s branches.StaleBranch) bool { return il.HasBranch(s.RepoPath, s.Branch) }, "branch", "branches")
+	_ = ml.LogPerf(len(repos), int(time.Since(scanStart).Milliseconds()))
+	printExplanations(ex)
+
+	if len(gone) == 0 {
+		fmt.Println("No branches with a gone upstream found.")
+		return nil
+	}
+
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range gone {
+		gone[i].RepoName = groupedName(projectsDir, gone[i].RepoPath)
+	}
+	gone = limitOldest(gone, c.Limit, "gone", func(s branches.StaleBranch) time.Time { return s.LastCommit })
+	toDelete, unverified := goneToDelete(gone)
+	if len(unverified) > 0 {
+		printBranchesByRepo(fmt.Sprintf("Skipping %d %s with commits found in no other branch (review with --stale):",
+			len(unverified), pluralize(len(unverified), "branch", "branches")), unverified)
+	}
+	if len(toDelete) == 0 {
+		fmt.Println("\nNo branches with a gone upstream are safe to delete.")
+		return nil
+	}
+	printBranchesByRepo(fmt.Sprintf("Found %d %s with a gone upstream:", len(toDelete), pluralize(len(toDelete), "branch", "branches")), toDelete)
+	if globals.DryRun {
+		return nil
+	}
+
+	ok, err := confirmGone(len(toDelete))
+	if err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was changed.")
+		}
+		return err
+	}
+
+	bk, err := newBackupStore(cfg)
+	if err != nil {
+		return err
+	}
+	return deleteBranches(toDelete, false, bk, ol)
+}
+
+// goneToDelete splits branches with a gone upstream into those whose tip
+// is reachable elsewhere, to delete without force, and the rest, which are
+// left for review.
+func goneToDelete(gone []branches.StaleBranch) (toDelete, unverified []branchToDelete) {
+	for i, d := range staleToDelete(gone) {
+		d.forceLocal = false
+		if gone[i].TipReachable {
+			toDelete = append(toDelete, d)
+		} else {
+			unverified = append(unverified, d)
+		}
+	}
+	return toDelete, unverified
+}
+
+// confirmGone is the single confirmation of a --gone run. It defaults to
+// no, so --yes alone does not delete anything.
+func confirmGone(count int) (bool, error) {
+	proceed := false
+	err := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Delete %d local %s?", count, pluralize(count, "branch", "branches"))).
+				Description("Their remote branches were deleted, usually after their PRs merged, and their commits are in the default branch or another remote branch. Deleted with git branch -d.").
+				Value(&proceed),
+		),
+	).Run()
+	if err != nil {
+		return false, fmt.Errorf("prompt failed: %w", err)
+	}
+	return proceed, nil
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func TestGoneToDelete(t *testing.T) {
+	toDelete, unverified := goneToDelete([]branches.StaleBranch{
+		{RepoName: "api", Branch: "landed", IsUpstreamGone: true, TipReachable: true},
+		{RepoName: "api", Branch: "lost", IsUpstreamGone: true},
+	})
+	if len(toDelete) != 1 || toDelete[0].branch != "landed" || toDelete[0].forceLocal {
+		t.Errorf("expected landed deleted without force, got %+v", toDelete)
+	}
+	if len(unverified) != 1 || unverified[0].branch != "lost" {
+		t.Errorf("expected lost left for review, got %+v", unverified)
+	}
+}
+
+func TestConfirmGoneDefaultsToNo(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+	if ok, err := confirmGone(3); err != nil || ok {
+		t.Errorf("expected --yes to keep the default of no, got %v (%v)", ok, err)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// githubAPIHost is the host_limits key that caps concurrent GitHub API
+// lookups.
+const githubAPIHost = "api.github.com"
+
+// prCheckHost is the host of a stale branch's PR status check. Branches
+// without a remote are never looked up, so they take no API slot.
+func prCheckHost(s branches.StaleBranch) string {
+	if !s.HasRemote {
+		return ""
+	}
+	return githubAPIHost
+}
+
+// repoHost returns a parallel.RunHosts host function for repository
+// paths: the longest host_limits path containing the repo, such as a
+// network mount, or else the host of the repo's primary remote. It returns
+// nil when no limits are configured, which skips the remote lookups.
+func repoHost(limits parallel.Limits) func(repoPath string) string {
+	if len(limits) == 0 {
+		return nil
+	}
+	var paths []string
+	byName := false
+	for host := range limits {
+		if filepath.IsAbs(host) {
+			paths = append(paths, filepath.Clean(host))
+		} else {
+			byName = true
+		}
+	}
+
+	return func(repoPath string) string {
+		best := ""
+		for _, p := range paths {
+			if (repoPath == p || strings.HasPrefix(repoPath, p+string(filepath.Separator))) && len(p) > len(best) {
+				best = p
+			}
+		}
+		if best != "" || !byName {
+			return best
+		}
+		url, err := git.RemoteURL(repoPath, git.PrimaryRemote(repoPath))
+		if err != nil {
+			return ""
+		}
+		return git.URLHost(url)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestRepoHost(t *testing.T) {
+	if repoHost(nil) != nil {
+		t.Error("expected no host function without limits")
+	}
+
+	repo := helpers.NewTestRepo(t, "hosted")
+	repo.Git("remote", "add", "origin", "git@github.com:owner/hosted.git")
+	local := helpers.NewTestRepo(t, "local")
+	mount := filepath.Dir(repo.Path)
+
+	host := repoHost(parallel.Limits{"github.com": 2})
+	if got := host(repo.Path); got != "github.com" {
+		t.Errorf("expected the origin host, got %q", got)
+	}
+	if got := host(local.Path); got != "" {
+		t.Errorf("expected no host for a repo without origin, got %q", got)
+	}
+
+	// The configured primary remote wins over origin.
+	repo.Git("remote", "add", "work", "git@gitlab.example.com:owner/hosted.git")
+	git.SetPreferredRemote("work")
+	t.Cleanup(func() { git.SetPreferredRemote("") })
+	if got := host(repo.Path); got != "gitlab.example.com" {
+		t.Errorf("expected the primary remote's host, got %q", got)
+	}
+
+	// A path limit containing the repo takes precedence over its remote.
+	host = repoHost(parallel.Limits{"github.com": 2, mount: 1, filepath.Dir(mount): 1})
+	if got := host(repo.Path); got != mount {
+		t.Errorf("expected the closest mount %q, got %q", mount, got)
+	}
+}
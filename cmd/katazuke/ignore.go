@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// loadIgnoreList returns the persisted ignore list, or nil, which ignores
+// nothing, when it cannot be read. Repositories are matched by their
+// fingerprint too, so snoozes survive a move or a fresh clone.
+func loadIgnoreList() *ignore.List {
+	path, err := ignore.DefaultPath()
+	if err != nil {
+		slog.Warn("could not locate ignore list", "error", err)
+		return nil
+	}
+	l, err := ignore.Load(path)
+	if err != nil {
+		slog.Warn("could not load ignore list", "error", err)
+		return nil
+	}
+	l.SetFingerprint(repoFingerprint)
+	return l
+}
+
+// ignoreCandidate is a suggestion the user passed on, which they can
+// snooze or ask never to see again.
+type ignoreCandidate struct {
+	label string
+	entry ignore.Entry
+}
+
+// snoozeForever is the hideFor choice that ignores an item for good.
+const snoozeForever = 0
+
+// promptHideDuration asks how long to hide items for, in days, or
+// snoozeForever. Snoozing for 30 days is the default.
+func promptHideDuration(title string) (int, error) {
+	days := 30
+	err := newForm(
+		huh.NewGroup(
+			huh.NewSelect[int]().
+				Title(title).
+				Options(
+					huh.NewOption("Snooze for 7 days", 7),
+					huh.NewOption("Snooze for 30 days", 30),
+					huh.NewOption("Snooze for 90 days", 90),
+					huh.NewOption("Ignore forever", snoozeForever),
+				).
+				Value(&days),
+		),
+	).Run()
+	if err != nil {
+		return 0, fmt.Errorf("prompt failed: %w", err)
+	}
+	return days, nil
+}
+
+// hideUntil returns when an item hidden for days expires, zero for
+// snoozeForever.
+func hideUntil(days int, now time.Time) time.Time {
+	if days == snoozeForever {
+		return time.Time{}
+	}
+	return now.AddDate(0, 0, days)
+}
+
+// offerToHide asks which of the suggestions the user passed on should be
+// snoozed or never offered again, and for how long, and adds those to
+// the ignore list. Nothing is preselected, so --yes hides nothing.
+func offerToHide(il *ignore.List, candidates []ignoreCandidate) error {
+	if il == nil || len(candidates) == 0 {
+		return nil
+	}
+
+	options := make([]huh.Option[int], len(candidates))
+	for i, c := range candidates {
+		options[i] = huh.NewOption(c.label, i)
+	}
+	var selected []int
+	err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Snooze or ignore any of the others?").
+				Description("Hidden items are listed in " + il.Path() + ".").
+				Options(options...).
+				Height(15).
+				Value(&selected),
+		),
+	).Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	days, err := promptHideDuration(fmt.Sprintf("Hide %d %s for how long?", len(selected), pluralize(len(selected), "item", "items")))
+	if err != nil {
+		return err
+	}
+
+	until := hideUntil(days, time.Now())
+	entries := make([]ignore.Entry, len(selected))
+	for i, idx := range selected {
+		entries[i] = candidates[idx].entry
+		entries[i].Until = until
+	}
+	return addIgnored(il, entries...)
+}
+
+// addIgnored adds entries to the ignore list and says so.
+func addIgnored(il *ignore.List, entries ...ignore.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if err := il.Add(time.Now(), entries...); err != nil {
+		return err
+	}
+	dim := ui.Muted()
+	var snoozed, ignored int
+	for _, e := range entries {
+		if e.Until.IsZero() {
+			ignored++
+		} else {
+			snoozed++
+		}
+	}
+	if ignored > 0 {
+		fmt.Println(dim.Sprintf("Ignoring %d more %s from now on (%s).", ignored, pluralize(ignored, "item", "items"), il.Path()))
+	}
+	if snoozed > 0 {
+		fmt.Println(dim.Sprintf("Snoozed %d %s (%s).", snoozed, pluralize(snoozed, "item", "items"), il.Path()))
+	}
+	return nil
+}
+
+// withoutIgnored drops the items ignored reports as ignored and says how
+// many were left out.
+func withoutIgnored[T any](items []T, ignored func(T) bool, singular, plural string) []T {
+	kept := items[:0:0]
+	for _, item := range items {
+		if !ignored(item) {
+			kept = append(kept, item)
+		}
+	}
+	if n := len(items) - len(kept); n > 0 {
+		fmt.Println(ui.Muted().Sprintf("Skipping %d ignored or snoozed %s.", n, pluralize(n, singular, plural)))
+	}
+	return kept
+}
+
+func branchIgnoreCandidate(repoName, repoPath, branch string) ignoreCandidate {
+	return ignoreCandidate{
+		label: repoName + ": " + branch,
+		entry: ignore.Entry{Kind: ignore.KindBranch, Path: repoPath, Branch: branch},
+	}
+}
+
+func repoIgnoreCandidate(name, path string) ignoreCandidate {
+	return ignoreCandidate{label: name, entry: ignore.Entry{Kind: ignore.KindRepo, Path: path}}
+}
+
+func dirIgnoreCandidate(label, path string) ignoreCandidate {
+	return ignoreCandidate{label: label, entry: ignore.Entry{Kind: ignore.KindDir, Path: path}}
+}
+
+func healthIgnoreCandidate(name, path string) ignoreCandidate {
+	return ignoreCandidate{label: name, entry: ignore.Entry{Kind: ignore.KindHealth, Path: path}}
+}
+
+func lfsPruneIgnoreCandidate(name, path string) ignoreCandidate {
+	return ignoreCandidate{label: name, entry: ignore.Entry{Kind: ignore.KindLFSPrune, Path: path}}
+}
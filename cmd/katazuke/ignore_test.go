@@ -0,0 +1,52 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+)
+
+func TestWithoutIgnored(t *testing.T) {
+	il, err := ignore.Load(filepath.Join(t.TempDir(), "ignore.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := il.Add(time.Now(),
+		ignore.Entry{Kind: ignore.KindRepo, Path: "/p/api"},
+		ignore.Entry{Kind: ignore.KindDir, Path: "/p/web/node_modules"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	repos := withoutIgnored([]string{"/p/api", "/p/web"}, il.HasRepo, "repository", "repositories")
+	if !reflect.DeepEqual(repos, []string{"/p/web"}) {
+		t.Errorf("repos = %v", repos)
+	}
+
+	reports := withoutIgnoredArtifacts([]audit.ArtifactReport{
+		{RepoPath: "/p/web", Dirs: []audit.ArtifactDir{{Path: "/p/web/node_modules"}}},
+		{RepoPath: "/p/cli", Dirs: []audit.ArtifactDir{{Path: "/p/cli/target"}, {Path: "/p/cli/dist"}}},
+	}, il)
+	if len(reports) != 1 || reports[0].RepoPath != "/p/cli" || len(reports[0].Dirs) != 2 {
+		t.Errorf("reports = %+v", reports)
+	}
+
+	// A nil list, from an unreadable file, ignores nothing.
+	if got := withoutIgnored([]string{"/p/api"}, (*ignore.List)(nil).HasRepo, "repository", "repositories"); len(got) != 1 {
+		t.Errorf("expected nothing ignored, got %v", got)
+	}
+}
+
+func TestHideUntil(t *testing.T) {
+	now := time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC)
+	if got := hideUntil(30, now); !got.Equal(time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("hideUntil(30) = %v", got)
+	}
+	if got := hideUntil(snoozeForever, now); !got.IsZero() {
+		t.Errorf("expected forever to have no expiry, got %v", got)
+	}
+}
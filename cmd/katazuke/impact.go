@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+)
+
+// runImpact tallies what the current invocation cleaned up. Deletions
+// record into it as they succeed, and main reports it once the command
+// returns, so commands that delete in several places need no plumbing.
+var runImpact struct {
+	mu      sync.Mutex
+	started time.Time
+	total   metrics.ImpactEvent
+}
+
+// startImpact marks the start of the run whose duration an impact event
+// records.
+func startImpact() {
+	runImpact.mu.Lock()
+	runImpact.started = time.Now()
+	runImpact.mu.Unlock()
+}
+
+// recordImpact adds to the current run's cleanup totals.
+func recordImpact(e metrics.ImpactEvent) {
+	runImpact.mu.Lock()
+	runImpact.total.Add(e)
+	runImpact.mu.Unlock()
+}
+
+// takeImpact returns the current run's totals, with the time since
+// startImpact, and resets them.
+func takeImpact() metrics.ImpactEvent {
+	runImpact.mu.Lock()
+	defer runImpact.mu.Unlock()
+	total := runImpact.total
+	if !runImpact.started.IsZero() {
+		total.DurationMs = time.Since(runImpact.started).Milliseconds()
+	}
+	runImpact.total = metrics.ImpactEvent{}
+	return total
+}
+
+// reportImpact prints what the run cleaned up and records it for
+// 'katazuke metrics summary'. It prints nothing when nothing was removed,
+// and also runs after a failed command since earlier deletions still
+// happened.
+func reportImpact() {
+	total := takeImpact()
+	if total.IsZero() {
+		return
+	}
+	fmt.Printf("\n%s %s\n", color.New(color.Bold).Sprint("This run:"), describeImpact(total))
+
+	ml := metrics.NewOrNil()
+	_ = ml.LogImpact(total)
+	_ = ml.Close()
+}
+
+// describeImpact summarizes cleanup totals in one line, e.g. "removed 3
+// local branches, 1 remote branch; removed 1 repository, freeing 2.0 MB
+// (took 1m5s)".
+func describeImpact(e metrics.ImpactEvent) string {
+	var parts []string
+	if e.BranchesDeleted > 0 || e.RemoteBranchesDeleted > 0 {
+		parts = append(parts, fmt.Sprintf("removed %d local %s, %d remote %s",
+			e.BranchesDeleted, pluralize(e.BranchesDeleted, "branch", "branches"),
+			e.RemoteBranchesDeleted, pluralize(e.RemoteBranchesDeleted, "branch", "branches")))
+	}
+	if e.ReposRemoved > 0 {
+		parts = append(parts, fmt.Sprintf("removed %d %s, freeing %s",
+			e.ReposRemoved, pluralize(e.ReposRemoved, "repository", "repositories"), formatSize(e.BytesFreed)))
+	}
+	line := strings.Join(parts, "; ")
+	if e.DurationMs > 0 {
+		took := (time.Duration(e.DurationMs) * time.Millisecond).Round(time.Second)
+		line += fmt.Sprintf(" (took %s)", took)
+	}
+	return line
+}
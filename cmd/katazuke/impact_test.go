@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+)
+
+func TestRecordAndTakeImpact(t *testing.T) {
+	takeImpact()
+	recordImpact(metrics.ImpactEvent{BranchesDeleted: 1})
+	recordImpact(metrics.ImpactEvent{BranchesDeleted: 1, RemoteBranchesDeleted: 1})
+	recordImpact(metrics.ImpactEvent{ReposRemoved: 1, BytesFreed: 3 * 1024 * 1024})
+
+	got := takeImpact()
+	want := metrics.ImpactEvent{BranchesDeleted: 2, RemoteBranchesDeleted: 1, ReposRemoved: 1, BytesFreed: 3 * 1024 * 1024}
+	got.DurationMs = 0
+	if got != want {
+		t.Errorf("takeImpact() = %+v, want %+v", got, want)
+	}
+	if !takeImpact().IsZero() {
+		t.Error("expected takeImpact to reset the totals")
+	}
+
+	got.DurationMs = 65_200
+	wantLine := "removed 2 local branches, 1 remote branch; removed 1 repository, freeing 3.0 MB (took 1m5s)"
+	if line := describeImpact(got); line != wantLine {
+		t.Errorf("describeImpact() = %q, want %q", line, wantLine)
+	}
+}
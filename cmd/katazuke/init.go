@@ -13,6 +13,7 @@ import (
 
 	"github.com/agrahamlincoln/katazuke/internal/config"
 	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
@@ -30,7 +31,7 @@ type dirInfo struct {
 
 // Run executes the init command.
 func (c *InitCmd) Run(globals *CLI) error {
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
@@ -45,7 +46,7 @@ func (c *InitCmd) Run(globals *CLI) error {
 		return fmt.Errorf("init: %s is not a directory", dir)
 	}
 
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 
 	fmt.Printf("Scanning %s...\n\n", dir)
 
@@ -149,7 +150,7 @@ func promptGroups(candidates []dirInfo, existing scanner.IndexFile) ([]string, e
 	}
 
 	var selected []string
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
 				Title("Which directories are groups (contain sub-projects)?").
@@ -189,7 +190,7 @@ func promptIgnores(dirs []dirInfo, selectedGroups []string, existing scanner.Ind
 	}
 
 	var selected []string
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
 				Title("Which directories should be ignored?").
@@ -206,7 +207,7 @@ func promptIgnores(dirs []dirInfo, selectedGroups []string, existing scanner.Ind
 // previewIndex prints the generated YAML and discovery count.
 func previewIndex(yamlBytes []byte, dirs []dirInfo, groups, ignores []string) {
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 
 	fmt.Printf("\n%s\n\n", bold.Sprint("Generated .katazuke:"))
 	for _, line := range strings.Split(strings.TrimRight(string(yamlBytes), "\n"), "\n") {
@@ -226,7 +227,7 @@ func writeIndex(dir string, yamlBytes []byte) error {
 	indexPath := filepath.Join(dir, ".katazuke")
 
 	var confirmed bool
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title(fmt.Sprintf("Write to %s?", indexPath)).
@@ -282,7 +283,7 @@ func classifyChildren(dir string) ([]dirInfo, error) {
 		}
 
 		child := filepath.Join(dir, name)
-		if git.IsRepo(child) {
+		if git.HasGitDir(child) || git.IsBareRepo(child) {
 			result = append(result, dirInfo{Name: name, IsRepo: true})
 			continue
 		}
@@ -305,7 +306,7 @@ func countRepoChildren(dir string) int {
 		if strings.HasPrefix(entry.Name(), ".") || !entry.IsDir() {
 			continue
 		}
-		if git.IsRepo(filepath.Join(dir, entry.Name())) {
+		if path := filepath.Join(dir, entry.Name()); git.HasGitDir(path) || git.IsBareRepo(path) {
 			count++
 		}
 	}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/issues"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// issueStatusFunc looks up the status of a stale branch's issue. It
+// returns false when the issue's tracker is not configured.
+type issueStatusFunc func(s branches.StaleBranch) (issues.Status, bool, error)
+
+// lookupIssueStatuses fills in the issue status of stale branches whose
+// names carry an issue key: from Jira when issues.jira_url is set, and
+// from the repository's GitHub issues for "#42" keys.
+func lookupIssueStatuses(stale []branches.StaleBranch, cfg config.Config, gh *ghclient.Client) {
+	var jira *issues.Jira
+	if cfg.Issues.JiraURL != "" {
+		jira = issues.NewJira(cfg.Issues.JiraURL, cfg.Issues.JiraEmail, cfg.Issues.JiraToken)
+	}
+	lookup := func(s branches.StaleBranch) (issues.Status, bool, error) {
+		if n := issues.GitHubNumber(s.IssueKey); n > 0 {
+			return githubIssueStatus(gh, s.RepoPath, n)
+		}
+		if jira == nil {
+			return issues.Status{}, false, nil
+		}
+		status, err := jira.Status(s.IssueKey)
+		return status, true, err
+	}
+	applyIssueStatuses(stale, lookup, workersFor(cfg, parallel.NetworkWork, len(stale)))
+}
+
+// githubIssueStatus looks up issue number in the GitHub repository the
+// branch's primary remote points at.
+func githubIssueStatus(gh *ghclient.Client, repoPath string, number int) (issues.Status, bool, error) {
+	remotes, err := git.RemoteURLs(repoPath, git.PrimaryRemote(repoPath))
+	if err != nil {
+		return issues.Status{}, false, nil
+	}
+	owner, repo, ok := ghclient.ParseGitHubRemotes(remotes)
+	if !ok {
+		return issues.Status{}, false, nil
+	}
+	issue, err := gh.GetIssue(owner, repo, number)
+	if err != nil {
+		return issues.Status{}, true, err
+	}
+	return issues.Status{Name: issue.State, Done: issue.State == "closed"}, true, nil
+}
+
+// issueResult is the looked up status of the issue shared by the stale
+// branches at indices.
+type issueResult struct {
+	indices []int
+	status  issues.Status
+	ok      bool
+}
+
+// applyIssueStatuses looks up each distinct issue once and sets the
+// status on every branch carrying it. Failed lookups are logged and
+// leave the status empty.
+func applyIssueStatuses(stale []branches.StaleBranch, lookup issueStatusFunc, workers int) {
+	byIssue := make(map[string][]int)
+	var order []string
+	for i, s := range stale {
+		if s.IssueKey == "" {
+			continue
+		}
+		id := issueGroup(s)
+		if _, ok := byIssue[id]; !ok {
+			order = append(order, id)
+		}
+		byIssue[id] = append(byIssue[id], i)
+	}
+	if len(order) == 0 {
+		return
+	}
+
+	fmt.Printf("Looking up %d %s...\n", len(order), pluralize(len(order), "issue", "issues"))
+	progress := newProgress()
+	results := parallel.Run(order, workers, func(id string) issueResult {
+		indices := byIssue[id]
+		s := stale[indices[0]]
+		status, ok, err := lookup(s)
+		if err != nil {
+			slog.Debug("could not look up issue status", "repo", s.RepoName, "issue", s.IssueKey, "error", err)
+			ok = false
+		}
+		return issueResult{indices: indices, status: status, ok: ok}
+	}, func(completed, total int, _ issueResult) { progress.Update(completed, total) })
+	progress.Stop()
+
+	for _, r := range results {
+		if !r.ok {
+			continue
+		}
+		for _, i := range r.indices {
+			stale[i].IssueStatus, stale[i].IssueDone = r.status.Name, r.status.Done
+		}
+	}
+}
+
+// issueGroup names the issue a stale branch belongs to. GitHub issue
+// numbers are only unique within a repository, so they are qualified
+// with the repository's name.
+func issueGroup(s branches.StaleBranch) string {
+	if issues.GitHubNumber(s.IssueKey) > 0 {
+		return s.RepoName + s.IssueKey
+	}
+	return s.IssueKey
+}
+
+// groupByIssue orders stale branches by issue: done issues first, since
+// their branches are the easiest to delete, then other issues by key,
+// then branches without an issue key. Order within an issue is kept.
+func groupByIssue(stale []branches.StaleBranch) []branches.StaleBranch {
+	rank := func(s branches.StaleBranch) int {
+		switch {
+		case s.IssueKey == "":
+			return 2
+		case s.IssueDone:
+			return 0
+		}
+		return 1
+	}
+	grouped := append([]branches.StaleBranch(nil), stale...)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		if ri, rj := rank(grouped[i]), rank(grouped[j]); ri != rj {
+			return ri < rj
+		}
+		return issueGroup(grouped[i]) < issueGroup(grouped[j])
+	})
+	return grouped
+}
+
+// printStaleSummaryByIssue lists stale branches under the issue in their
+// names, with the issue's status when it was looked up.
+func printStaleSummaryByIssue(stale []branches.StaleBranch) {
+	bold := color.New(color.Bold)
+	success := ui.Success()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d stale branch(es):", len(stale)))
+
+	current := "\x00"
+	for _, s := range groupByIssue(stale) {
+		if id := issueGroup(s); id != current {
+			current = id
+			switch {
+			case id == "":
+				fmt.Printf("  %s\n", bold.Sprint("No issue key"))
+			case s.IssueDone:
+				fmt.Printf("  %s %s\n", bold.Sprint(id), success.Sprintf("(%s)", s.IssueStatus))
+			case s.IssueStatus != "":
+				fmt.Printf("  %s %s\n", bold.Sprint(id), dim.Sprintf("(%s)", s.IssueStatus))
+			default:
+				fmt.Printf("  %s\n", bold.Sprint(id))
+			}
+		}
+		fmt.Println("    " + staleSummaryLine(s, bold.Sprint(s.RepoName)+": "+s.Branch))
+	}
+	fmt.Println()
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/issues"
+)
+
+func TestApplyIssueStatuses(t *testing.T) {
+	stale := []branches.StaleBranch{
+		{RepoName: "app", Branch: "ABC-1-login", IssueKey: "ABC-1"},
+		{RepoName: "api", Branch: "abc-1-login", IssueKey: "ABC-1"},
+		{RepoName: "app", Branch: "gh-7", IssueKey: "#7"},
+		{RepoName: "api", Branch: "gh-7", IssueKey: "#7"},
+		{RepoName: "app", Branch: "ABC-2", IssueKey: "ABC-2"},
+		{RepoName: "app", Branch: "spike"},
+	}
+	var calls atomic.Int32
+	lookup := func(s branches.StaleBranch) (issues.Status, bool, error) {
+		calls.Add(1)
+		switch s.IssueKey {
+		case "ABC-1":
+			return issues.Status{Name: "Done", Done: true}, true, nil
+		case "#7":
+			return issues.Status{Name: "open"}, true, nil
+		}
+		return issues.Status{}, true, errors.New("not found")
+	}
+	applyIssueStatuses(stale, lookup, 2)
+
+	// ABC-1 is looked up once for both repos; #7 once per repo.
+	if n := calls.Load(); n != 4 {
+		t.Errorf("expected 4 lookups, got %d", n)
+	}
+	if !stale[0].IssueDone || !stale[1].IssueDone || stale[1].IssueStatus != "Done" {
+		t.Errorf("expected both ABC-1 branches done, got %+v and %+v", stale[0], stale[1])
+	}
+	if stale[2].IssueStatus != "open" || stale[2].IssueDone {
+		t.Errorf("unexpected #7 status %+v", stale[2])
+	}
+	if stale[4].IssueStatus != "" {
+		t.Errorf("expected a failed lookup to leave the status empty, got %q", stale[4].IssueStatus)
+	}
+}
+
+func TestGroupByIssue(t *testing.T) {
+	stale := []branches.StaleBranch{
+		{RepoName: "app", Branch: "spike"},
+		{RepoName: "app", Branch: "ABC-9", IssueKey: "ABC-9"},
+		{RepoName: "app", Branch: "gh-7", IssueKey: "#7", IssueDone: true},
+		{RepoName: "api", Branch: "ABC-2", IssueKey: "ABC-2", IssueDone: true},
+		{RepoName: "api", Branch: "abc-9-more", IssueKey: "ABC-9"},
+	}
+	var got []string
+	for _, s := range groupByIssue(stale) {
+		got = append(got, s.RepoName+":"+s.Branch)
+	}
+	want := []string{"api:ABC-2", "app:gh-7", "app:ABC-9", "api:abc-9-more", "app:spike"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
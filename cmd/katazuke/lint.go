@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// runLint reports local branches whose names break the conventions in
+// branch_naming, grouped by repository. It fails when any do, so it can
+// gate a script or CI job.
+func (c *BranchesCmd) runLint(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	var flags []string
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("branches --lint", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	rules := branches.NamingRules{
+		Prefixes:  cfg.BranchNaming.Prefixes,
+		MaxLength: cfg.BranchNaming.MaxLength,
+		Lowercase: cfg.BranchNaming.Lowercase,
+	}
+	if rules.IsZero() {
+		return fmt.Errorf("no branch naming conventions configured; set branch_naming prefixes, max_length, or lowercase in the config file or policy")
+	}
+
+	repos, isLocal, err := c.resolveRepos(globals, cfg)
+	if err != nil {
+		return err
+	}
+	printRepoCount("Checking branch names in", len(repos), isLocal, "...")
+	progress := newProgress()
+	violations := branches.LintNames(repos, rules, workersFor(cfg, parallel.LocalWork, len(repos)), progress.Update)
+	progress.Stop()
+
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range violations {
+		violations[i].RepoName = groupedName(projectsDir, violations[i].RepoPath)
+	}
+	if len(violations) == 0 {
+		fmt.Println("All branch names follow the naming conventions.")
+		return nil
+	}
+	printNamingViolations(violations)
+	return fmt.Errorf("%d branch %s break the naming conventions", len(violations), pluralize(len(violations), "name", "names"))
+}
+
+// printNamingViolations lists branch names that break the naming
+// conventions under their repository, with what each breaks.
+func printNamingViolations(violations []branches.NamingViolation) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d branch name(s) breaking naming conventions:", len(violations)))
+	currentRepo := ""
+	for _, v := range violations {
+		if v.RepoName != currentRepo {
+			currentRepo = v.RepoName
+			fmt.Printf("  %s\n", bold.Sprint(v.RepoName))
+		}
+		fmt.Printf("    %s  %s\n", v.Branch, warn.Sprintf("(%s)", strings.Join(v.Problems, "; ")))
+	}
+	fmt.Println()
+}
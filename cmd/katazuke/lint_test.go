@@ -0,0 +1,14 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBranchesLintRejectsCleanupOptions(t *testing.T) {
+	c := &BranchesCmd{Lint: true, Stale: true}
+	err := c.Run(&CLI{})
+	if err == nil || !strings.Contains(err.Error(), "--lint") {
+		t.Errorf("expected --lint to refuse --stale, got %v", err)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/fatih/color"
 
 	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 )
 
 // LogCmd shows recent destructive operations.
@@ -30,7 +31,7 @@ func (c *LogCmd) Run(_ *CLI) error {
 	}
 
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 
 	fmt.Printf("Operations from the last %d days:\n\n", c.Days)
 
@@ -40,6 +41,10 @@ func (c *LogCmd) Run(_ *CLI) error {
 		switch op.Type {
 		case oplog.OpDeleteBranch:
 			repoName := filepath.Base(op.RepoPath)
+			if op.RepoPath == "" {
+				// Deleted from GitHub without a local checkout.
+				repoName = op.RemoteURL
+			}
 			remoteTag := ""
 			if op.DeletedRemote {
 				remoteTag = " [+ remote]"
@@ -52,6 +57,32 @@ func (c *LogCmd) Run(_ *CLI) error {
 					op.CommitSHA[:min(12, len(op.CommitSHA))],
 					dim.Sprintf("(recoverable: git branch %s %s)", op.Branch, op.CommitSHA[:min(12, len(op.CommitSHA))]))
 			}
+			if op.Destination != "" {
+				fmt.Printf("%s  Bundle: %s %s\n",
+					dim.Sprint(strings.Repeat(" ", 16)), op.Destination,
+					dim.Sprintf("(restore with: git fetch %s %s:%s)", op.Destination, op.Branch, op.Branch))
+			}
+
+		case oplog.OpDeleteTag:
+			repoName := filepath.Base(op.RepoPath)
+			remoteTag := ""
+			if op.DeletedRemote {
+				remoteTag = " [+ remote]"
+			}
+			fmt.Printf("%s  %s  %s: %s%s\n",
+				dim.Sprint(ts), bold.Sprint("delete_tag"), repoName, op.Tag, remoteTag)
+			if op.CommitSHA != "" {
+				fmt.Printf("%s  SHA: %s %s\n",
+					dim.Sprint(strings.Repeat(" ", 16)),
+					op.CommitSHA[:min(12, len(op.CommitSHA))],
+					dim.Sprintf("(recoverable: git tag %s %s)", op.Tag, op.CommitSHA[:min(12, len(op.CommitSHA))]))
+			}
+			if op.Destination != "" {
+				ref := "refs/tags/" + op.Tag
+				fmt.Printf("%s  Bundle: %s %s\n",
+					dim.Sprint(strings.Repeat(" ", 16)), op.Destination,
+					dim.Sprintf("(restore with: git fetch %s %s:%s)", op.Destination, ref, ref))
+			}
 
 		case oplog.OpDeleteRepo:
 			fmt.Printf("%s  %s  %s\n",
@@ -60,6 +91,10 @@ func (c *LogCmd) Run(_ *CLI) error {
 				fmt.Printf("%s  Remote: %s\n",
 					dim.Sprint(strings.Repeat(" ", 16)), op.RemoteURL)
 			}
+			if op.Destination != "" {
+				fmt.Printf("%s  Bundle: %s (restore with: git clone %s)\n",
+					dim.Sprint(strings.Repeat(" ", 16)), op.Destination, op.Destination)
+			}
 
 		case oplog.OpDeleteDir:
 			fmt.Printf("%s  %s  %s\n",
@@ -74,6 +109,25 @@ func (c *LogCmd) Run(_ *CLI) error {
 			fmt.Printf("%s  %s  %s: %s -> %s\n",
 				dim.Sprint(ts), bold.Sprint("switch_branch"), repoName, op.PreviousBranch, op.Branch)
 
+		case oplog.OpStashWIP:
+			repoName := filepath.Base(op.RepoPath)
+			fmt.Printf("%s  %s  %s\n",
+				dim.Sprint(ts), bold.Sprint("stash_wip"), repoName)
+			if op.CommitSHA != "" {
+				fmt.Printf("%s  SHA: %s %s\n",
+					dim.Sprint(strings.Repeat(" ", 16)),
+					op.CommitSHA[:min(12, len(op.CommitSHA))],
+					dim.Sprintf("(restore with: git stash apply %s)", op.CommitSHA[:min(12, len(op.CommitSHA))]))
+			}
+
+		case oplog.OpCommitWIP:
+			repoName := filepath.Base(op.RepoPath)
+			fmt.Printf("%s  %s  %s: %s\n",
+				dim.Sprint(ts), bold.Sprint("commit_wip"), repoName, op.Branch)
+			fmt.Printf("%s  %s\n",
+				dim.Sprint(strings.Repeat(" ", 16)),
+				dim.Sprintf("(resume with: git checkout %s && git reset HEAD~1)", op.Branch))
+
 		default:
 			fmt.Printf("%s  %s  %s\n",
 				dim.Sprint(ts), bold.Sprint(string(op.Type)), op.Path)
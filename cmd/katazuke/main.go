@@ -2,24 +2,35 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/charmbracelet/huh"
 	"github.com/fatih/color"
 
+	"github.com/agrahamlincoln/katazuke/internal/backup"
 	"github.com/agrahamlincoln/katazuke/internal/branches"
 	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
 	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
+	"github.com/agrahamlincoln/katazuke/internal/issues"
+	"github.com/agrahamlincoln/katazuke/internal/lock"
+	"github.com/agrahamlincoln/katazuke/internal/logging"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/metrics"
 	"github.com/agrahamlincoln/katazuke/internal/oplog"
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/internal/state"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
@@ -31,18 +42,36 @@ var (
 
 // CLI defines the top-level command structure for katazuke.
 type CLI struct {
-	DryRun      bool   `name:"dry-run" short:"n" help:"Show what would be done without making changes."`
-	Verbose     bool   `name:"verbose" short:"v" help:"Verbose output."`
-	Global      bool   `name:"global" short:"g" help:"Operate on all repositories instead of just the current one."`
-	ProjectsDir string `name:"projects-dir" short:"p" help:"Projects directory (default: from config file, or ~/projects)." default:"" env:"KATAZUKE_PROJECTS_DIR"`
-
-	Branches BranchesCmd `cmd:"" help:"Manage branches across repositories."`
-	Repos    ReposCmd    `cmd:"" help:"Manage repository checkouts."`
-	Audit    AuditCmd    `cmd:"" help:"Run full workspace audit."`
-	Sync     SyncCmd     `cmd:"" help:"Sync all repositories."`
-	Init     InitCmd     `cmd:"" help:"Create .katazuke index file interactively."`
-	Log      LogCmd      `cmd:"" help:"Show recent operations."`
-	Version  VersionCmd  `cmd:"" help:"Show version information."`
+	DryRun      bool     `name:"dry-run" short:"n" help:"Show what would be done without making changes."`
+	Verbose     bool     `name:"verbose" short:"v" help:"Verbose output."`
+	Global      bool     `name:"global" short:"g" help:"Operate on all repositories instead of just the current one."`
+	Group       string   `name:"group" help:"Only operate on repositories under this group path in the projects directory (e.g. work/client-a). Implies --global."`
+	Only        []string `name:"only" sep:"," help:"Only operate on repositories whose directory name or remote URL matches one of these patterns, in place of include_patterns from config (e.g. 'api-*,github.com/acme/*'). Implies --global."`
+	LogFile     string   `name:"log-file" type:"path" help:"Also write debug logs to this file, regardless of -v (default: log_file from config)."`
+	Yes         bool     `name:"yes" short:"y" help:"Accept the default answer to every prompt. Required when not running in a terminal."`
+	Force       bool     `name:"force" help:"Delete more branches, repositories, or directories in one run than safety.max_deletions_per_run without typing a confirmation."`
+	ForceLock   bool     `name:"force-lock" help:"Run even if another katazuke run appears to be working on the same projects directory."`
+	MineOnly    bool     `name:"mine-only" help:"Only delete branches whose commits are all yours, and only move or remove checkouts you last worked in, going by identity.emails and your GitHub login. For machines shared with other people."`
+	Workers     int      `name:"workers" short:"j" help:"Parallel workers for this run (default: workers from config, or sized per task)."`
+	Profile     string   `name:"profile" help:"Apply the named profile from the config file on top of its top-level settings." default:"" env:"KATAZUKE_PROFILE"`
+	ProjectsDir string   `name:"projects-dir" short:"p" help:"Projects directory (default: from config file, or ~/projects)." default:"" env:"KATAZUKE_PROJECTS_DIR"`
+
+	Branches   BranchesCmd   `cmd:"" help:"Manage branches across repositories."`
+	Repos      ReposCmd      `cmd:"" help:"Manage repository checkouts."`
+	Tags       TagsCmd       `cmd:"" help:"Find and remove unpushed, expired, or orphaned tags."`
+	Clean      CleanCmd      `cmd:"" help:"Fetch, switch off merged branches, and delete merged and safe stale branches in one go."`
+	Audit      AuditCmd      `cmd:"" help:"Run full workspace audit."`
+	Report     ReportCmd     `cmd:"" help:"Write the cleanup backlog as a Markdown checklist, or file it as a GitHub issue."`
+	Sync       SyncCmd       `cmd:"" help:"Sync all repositories."`
+	Init       InitCmd       `cmd:"" help:"Create .katazuke index file interactively."`
+	Log        LogCmd        `cmd:"" help:"Show recent operations."`
+	Export     ExportCmd     `cmd:"" help:"Write a manifest of all repositories (remotes, groups, branches)."`
+	Import     ImportCmd     `cmd:"" help:"Clone the repositories listed in a manifest."`
+	Metrics    MetricsCmd    `cmd:"" help:"Inspect local usage metrics."`
+	Config     ConfigCmd     `cmd:"" help:"Inspect the effective configuration."`
+	Completion CompletionCmd `cmd:"" help:"Inspect terminal and shell integration."`
+	SelfUpdate SelfUpdateCmd `cmd:"" name:"self-update" help:"Replace katazuke with the latest release, after verifying its checksum."`
+	Version    VersionCmd    `cmd:"" help:"Show version information."`
 }
 
 // BranchesCmd handles branch management across repositories.
@@ -50,6 +79,62 @@ type BranchesCmd struct {
 	Merged    bool `help:"Filter to only merged branches."`
 	Stale     bool `help:"Filter to only stale branches."`
 	StaleDays int  `name:"stale-days" help:"Days before a branch is considered stale (only applies to stale filtering)." default:"30"`
+	Explain   bool `help:"Explain why each branch was reported, excluded, or protected from remote deletion."`
+	Histogram bool `help:"Also show a month-by-month histogram of stale branch ages."`
+	Review    bool `help:"Write the full report to a file and open it in $EDITOR to mark branches for deletion, instead of selecting in the terminal."`
+	Edit      bool `help:"Choose keep, delete, or archive for each branch in $EDITOR, one branch per line."`
+	Resume    bool `help:"Continue an interrupted cleanup from its saved scan results and selections instead of scanning again."`
+	Rehearse  bool `help:"Walk through the selection prompts as usual, then list what would be deleted or archived instead of doing it (implies --dry-run)."`
+
+	Lint              bool `help:"Check local branch names against the branch_naming conventions (allowed prefixes, max length, lowercase) and report violations per repository."`
+	ByIssue           bool `name:"by-issue" help:"Group the stale branch summary by the issue key in each branch name (e.g. ABC-123 or gh-42), with the issue's status when a tracker is configured."`
+	ByAuthor          bool `name:"by-author" help:"Group the stale branch summary by commit author, with each person's branch count, repositories, and oldest branch, to nudge owners on a shared checkout."`
+	RespectCheckouts  bool `name:"respect-checkouts" help:"Leave out stale branches checked out within the stale threshold, according to the reflog, even without new commits."`
+	IncludeDraftPRs   bool `name:"include-draft-prs" help:"List stale branches whose open PR is a draft as candidates, with the PR's state, instead of leaving them out like other branches with open PRs."`
+	RemoteOnly        bool `name:"remote-only" help:"List your branches on GitHub whose pull requests were merged or closed, including in repositories you have not cloned, and offer to delete them from GitHub."`
+	ArchiveAutomation bool `name:"archive-automation" help:"Delete every local dependabot, renovate, and release-please branch that is merged or stale, after one confirmation that --yes answers. Remote branches are left to the tools that own them."`
+	Gone              bool `help:"Quickly find every local branch whose upstream was deleted from the remote ([gone]), whatever its age, and delete those whose commits exist in another branch with git branch -d after one confirmation. Uses no merge detection or GitHub lookups, so it reflects the last fetch with --prune."`
+
+	Repo              string `name:"repo" type:"path" help:"Operate only on the repository containing this path (e.g. '.'), without scanning the projects directory." placeholder:"PATH"`
+	Pattern           string `name:"pattern" short:"f" help:"Filter repositories by name or group path (glob, e.g. '*kafka*' or 'work/*')."`
+	InteractiveSelect bool   `name:"interactive-select" help:"Pick the repositories to scan from a list before scanning."`
+	Limit             int    `help:"Process only the N oldest merged or stale branches this run, leaving the rest for later runs (0 means no limit)." placeholder:"N"`
+	Sort              string `help:"Order branch summaries and prompts by age (oldest first), repo, ahead (most unmerged commits first), or size (most diverged first)." placeholder:"ORDER"`
+
+	// scoped caches the repositories left after --pattern and
+	// --interactive-select so --merged and --stale share a single prompt.
+	scoped      []string
+	scopedLocal bool
+}
+
+// selectionMode is how the user picks the branches to act on.
+type selectionMode int
+
+const (
+	selectPrompt selectionMode = iota // multi-selects in the terminal
+	selectReview                      // --review: checkbox file in $EDITOR
+	selectEdit                        // --edit: an action per line in $EDITOR
+)
+
+// String names the mode, e.g. for saved session steps.
+func (m selectionMode) String() string {
+	switch m {
+	case selectReview:
+		return "review"
+	case selectEdit:
+		return "edit"
+	}
+	return "prompt"
+}
+
+func (c *BranchesCmd) selectionMode() selectionMode {
+	switch {
+	case c.Edit:
+		return selectEdit
+	case c.Review:
+		return selectReview
+	}
+	return selectPrompt
 }
 
 // Run executes the branches command.
@@ -57,6 +142,60 @@ type BranchesCmd struct {
 func (c *BranchesCmd) Run(globals *CLI) error {
 	showBoth := !c.Merged && !c.Stale
 
+	if c.Review && c.Edit {
+		return fmt.Errorf("--review and --edit cannot be combined")
+	}
+	if err := validateSort(c.Sort); err != nil {
+		return err
+	}
+	if c.Limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	if c.ArchiveAutomation && (c.Review || c.Edit || c.Resume) {
+		return fmt.Errorf("--archive-automation does not prompt and cannot be combined with --review, --edit, or --resume")
+	}
+	if c.ByIssue && c.ByAuthor {
+		return fmt.Errorf("--by-issue and --by-author cannot be combined")
+	}
+	if c.Rehearse && (c.Resume || c.ArchiveAutomation || c.RemoteOnly || c.Lint || c.Gone) {
+		return fmt.Errorf("--rehearse cannot be combined with --resume, --archive-automation, --remote-only, --lint, or --gone")
+	}
+	if c.Gone && (c.Merged || c.Stale || c.ArchiveAutomation || c.Review || c.Edit || c.Resume || c.ByIssue || c.ByAuthor || c.RespectCheckouts || c.IncludeDraftPRs) {
+		return fmt.Errorf("--gone asks once for every branch with a gone upstream and cannot be combined with --merged, --stale, or their selection options")
+	}
+	if c.Resume && (c.Pattern != "" || c.InteractiveSelect) {
+		return fmt.Errorf("--resume continues the saved scan and cannot be combined with --pattern or --interactive-select")
+	}
+	if c.RemoteOnly {
+		if c.Merged || c.Stale || c.Lint || c.ByIssue || c.ByAuthor || c.IncludeDraftPRs || c.Review || c.Edit || c.Resume || c.ArchiveAutomation || c.Gone || c.Repo != "" || c.Pattern != "" || c.InteractiveSelect {
+			return fmt.Errorf("--remote-only lists branches on GitHub and cannot be combined with local scan options")
+		}
+		return c.runRemoteOnly(globals)
+	}
+	if c.Lint && (c.Merged || c.Stale || c.ByIssue || c.ByAuthor || c.IncludeDraftPRs || c.Review || c.Edit || c.Resume || c.ArchiveAutomation || c.Gone || c.Limit > 0) {
+		return fmt.Errorf("--lint only checks branch names and cannot be combined with cleanup options")
+	}
+
+	if c.Repo != "" {
+		if err := c.scopeRepo(globals); err != nil {
+			return err
+		}
+	} else if c.Pattern != "" || c.InteractiveSelect {
+		if err := c.scopeRepos(globals); err != nil || c.scoped == nil {
+			return err
+		}
+	}
+
+	if c.Lint {
+		return c.runLint(globals)
+	}
+	if c.ArchiveAutomation {
+		return c.runArchiveAutomation(globals)
+	}
+	if c.Gone {
+		return c.runGone(globals)
+	}
+
 	if c.Merged || showBoth {
 		if err := c.runMerged(globals); err != nil {
 			return err
@@ -92,75 +231,199 @@ func (c *BranchesCmd) runMerged(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.InteractiveSelect {
+		flags = append(flags, "--interactive-select")
+	}
+	if c.Review {
+		flags = append(flags, "--review")
+	}
+	if c.Edit {
+		flags = append(flags, "--edit")
+	}
+	if c.Resume {
+		flags = append(flags, "--resume")
+	}
+	if c.Rehearse {
+		flags = append(flags, "--rehearse")
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
+	if c.Limit > 0 {
+		flags = append(flags, fmt.Sprintf("--limit=%d", c.Limit))
+	}
 	_ = ml.LogCommand("branches --merged", flags)
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	il := loadIgnoreList()
 
+	var merged []branches.MergedBranch
+	var sess *branchSession
+	if c.Resume {
+		if sess, err = resumeBranchSession(mergedSessionName); err != nil {
+			return err
+		}
+		if sess == nil {
+			fmt.Println("No interrupted merged branch cleanup to resume.")
+			return nil
+		}
+		merged = remainingMerged(sess.state.Merged, sess.state.Tips)
+		printResuming("merged", sess, len(merged))
+	} else if merged, err = c.scanMerged(globals, cfg, il, ml); err != nil {
+		return err
+	}
+
+	if len(merged) == 0 {
+		sess.finish()
+		fmt.Println("No merged branches found.")
+		return nil
+	}
+
+	merged = limitOldest(merged, c.Limit, "merged", func(m branches.MergedBranch) time.Time { return m.LastCommit })
+	sortMerged(merged, c.Sort)
+	printMergedSummary(merged)
+
+	if c.Rehearse {
+		return c.executeMergedActions(merged, nil, cfg, il, ml, ol)
+	}
+	if globals.DryRun {
+		return nil
+	}
+
+	if sess == nil {
+		sess = startBranchSession(mergedSessionName, branchSessionState{Merged: merged})
+	}
+	if err := c.executeMergedActions(merged, sess, cfg, il, ml, ol); err != nil {
+		sess.printResumeHint("--merged")
+		return err
+	}
+	sess.finish()
+	return nil
+}
+
+// scanMerged finds the merged branches of the repositories in scope,
+// minus those on the ignore list.
+func (c *BranchesCmd) scanMerged(globals *CLI, cfg config.Config, il *ignore.List, ml *metrics.Logger) ([]branches.MergedBranch, error) {
 	scanStart := time.Now()
-	repos, isLocal, err := resolveRepos(globals, cfg)
+	repos, isLocal, err := c.resolveRepos(globals, cfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	slog.Debug("found repositories", "count", len(repos))
 
-	workers := cfg.Workers
+	workers := workersFor(cfg, parallel.LocalWork, len(repos))
 	slog.Debug("using worker pool", "workers", workers)
 	printRepoCount("Scanning", len(repos), isLocal, " for merged branches...")
 
-	gh := ghclient.NewClient(cfg.GithubToken)
+	gh := newGitHubClient(cfg)
 	detector := merge.NewDetector(merge.RealGitChecker{}, gh)
-	merged, err := branches.FindMerged(repos, detector, workers, progressPrinter())
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	merged, err := branches.FindMerged(repos, detector, workers, ex, progress.Update)
+	progress.Stop()
 	if err != nil {
-		return fmt.Errorf("finding merged branches: %w", err)
+		return nil, fmt.Errorf("finding merged branches: %w", err)
 	}
+	merged = withoutIgnored(merged, func(m branches.MergedBranch) bool { return il.HasBranch(m.RepoPath, m.Branch) }, "branch", "branches")
 	_ = ml.LogPerf(len(repos), int(time.Since(scanStart).Milliseconds()))
 
-	// Enrich GitHub-detected branches with merge method (merge vs squash).
-	merged = branches.EnrichMergeMethod(merged, gh, workers)
-
-	if len(merged) == 0 {
-		fmt.Println("No merged branches found.")
-		return nil
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range merged {
+		merged[i].RepoName = groupedName(projectsDir, merged[i].RepoPath)
 	}
 
-	printMergedSummary(merged)
+	// Enrich GitHub-detected branches with merge method (merge vs squash).
+	merged = branches.EnrichMergeMethod(merged, gh, workersFor(cfg, parallel.NetworkWork, len(merged)))
+	merged = branches.EnrichCIStatus(merged, gh, workersFor(cfg, parallel.NetworkWork, len(merged)))
 
-	if globals.DryRun {
-		return nil
-	}
+	printExplanations(ex)
 
-	selected, err := promptForDeletion(merged)
+	return merged, nil
+}
+
+// executeMergedActions asks which merged branches to delete or archive,
+// then does so. Answers are recorded to sess as they are given. With
+// --rehearse it only lists what it would do.
+func (c *BranchesCmd) executeMergedActions(merged []branches.MergedBranch, sess *branchSession, cfg config.Config, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger) error {
+	mode := c.selectionMode()
+	selected, archived, err := sessionStep(sess, mode.String(), merged, mergedKey, func() ([]branches.MergedBranch, []branches.MergedBranch, error) {
+		switch mode {
+		case selectEdit:
+			return editMergedSelection(merged)
+		case selectReview:
+			selected, err := reviewMergedSelection(merged)
+			return selected, nil, err
+		}
+		selected, err := promptForDeletion(merged)
+		return selected, nil, err
+	})
 	if err != nil {
 		return err
 	}
+	if c.Rehearse {
+		deleteRemote, err := promptForRemoteDeletion(selected)
+		if err != nil {
+			return err
+		}
+		return printRehearsal(mergedToDelete(selected), mergedToDelete(archived), deleteRemote)
+	}
 
-	// Log suggestion events for each merged branch.
-	selectedSet := make(map[string]bool, len(selected))
-	for _, s := range selected {
+	// Log suggestion events for each merged branch. Archiving counts as
+	// accepting the suggestion: the branch is removed either way.
+	selectedSet := make(map[string]bool, len(selected)+len(archived))
+	for _, s := range append(append([]branches.MergedBranch{}, selected...), archived...) {
 		selectedSet[s.RepoPath+":"+s.Branch] = true
 	}
+	var passed []ignoreCandidate
 	for _, m := range merged {
 		accepted := selectedSet[m.RepoPath+":"+m.Branch]
 		fp := branchFingerprint(m.RepoPath, m.Branch)
 		ageDays := int(time.Since(m.LastCommit).Hours() / 24)
 		_ = ml.LogSuggestion("delete_merged_branch", fp, accepted, ageDays)
+		if !accepted {
+			passed = append(passed, branchIgnoreCandidate(m.RepoName, m.RepoPath, m.Branch))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
 	}
 
-	if len(selected) == 0 {
+	if len(selected) == 0 && len(archived) == 0 {
 		fmt.Println("No branches selected for deletion.")
 		return nil
 	}
 
-	deleteRemote, err := promptForRemoteDeletion(selected)
+	bk, err := newBackupStore(cfg)
 	if err != nil {
 		return err
 	}
 
-	return deleteSelectedBranches(selected, deleteRemote, ol)
+	var archiveErr error
+	if len(archived) > 0 {
+		archiveErr = archiveBranches(mergedToDelete(archived), bk, ol)
+		if len(selected) == 0 {
+			return archiveErr
+		}
+	}
+
+	deleteRemote, err := promptForRemoteDeletion(selected)
+	if err != nil {
+		return err
+	}
+	if err := deleteSelectedBranches(selected, deleteRemote, bk, ol); err != nil {
+		return err
+	}
+	return archiveErr
 }
 
 // mergedSummaryThreshold is the number of branches above which the
@@ -169,7 +432,7 @@ const mergedSummaryThreshold = 25
 
 func printMergedSummary(merged []branches.MergedBranch) {
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
+	dim := ui.Muted()
 
 	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d merged branch(es):", len(merged)))
 
@@ -190,14 +453,24 @@ func printMergedSummary(merged []branches.MergedBranch) {
 			fmt.Printf("  %s  %s\n", bold.Sprint(repo), dim.Sprintf("(%d %s)", counts[repo], noun))
 		}
 	} else {
+		// A --sort order other than repo interleaves repositories, so each
+		// line names its own instead of sitting under a heading.
+		grouped := groupedByRepo(merged, func(m branches.MergedBranch) string { return m.RepoName })
 		currentRepo := ""
 		for _, m := range merged {
+			age := formatAge(m.LastCommit)
+			prInfo := mergedPRSuffix(m)
+			if note := m.CIStatus.Describe(); note != "" {
+				prInfo += ", " + note
+			}
+			if !grouped {
+				fmt.Printf("  %s: %s  %s\n", bold.Sprint(m.RepoName), m.Branch, dim.Sprintf("(%s%s)", age, prInfo))
+				continue
+			}
 			if m.RepoName != currentRepo {
 				currentRepo = m.RepoName
 				fmt.Printf("  %s\n", bold.Sprint(m.RepoName))
 			}
-			age := formatAge(m.LastCommit)
-			prInfo := mergedPRSuffix(m)
 			fmt.Printf("    %s  %s\n", m.Branch, dim.Sprintf("(%s%s)", age, prInfo))
 		}
 	}
@@ -211,6 +484,9 @@ func mergedPRSuffix(m branches.MergedBranch) string {
 		// GitHub-detected branches (ForceDelete) with no PR info still
 		// deserve a hint; git-detected merges need no suffix.
 		if m.ForceDelete {
+			if m.MergeMethod != "" {
+				return ", " + m.MergeMethod + "-merged"
+			}
 			return ", merged"
 		}
 		return ""
@@ -232,7 +508,7 @@ func promptForDeletion(merged []branches.MergedBranch) ([]branches.MergedBranch,
 	}
 
 	var selectedIndices []int
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[int]().
 				Title("Select branches to delete").
@@ -268,10 +544,10 @@ func promptForRemoteDeletion(selected []branches.MergedBranch) (bool, error) {
 	}
 
 	var deleteRemote bool
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Also delete remote branches on origin?").
+				Title("Also delete remote branches?").
 				Value(&deleteRemote),
 		),
 	)
@@ -288,6 +564,9 @@ type branchToDelete struct {
 	repoName  string
 	branch    string
 	hasRemote bool
+	// remote is the remote holding the branch, where remote deletion
+	// happens.
+	remote string
 	// canDeleteRemote is false for automation branches and branches
 	// with other contributors, preventing remote deletion even when
 	// the user opts in.
@@ -300,25 +579,56 @@ type branchToDelete struct {
 
 // deleteBranches deletes branches locally and optionally their remote
 // counterparts. Each branch's forceLocal field controls whether
-// git branch -D (force) is used for that specific branch. Successful
-// operations are logged to the oplog with the branch SHA for recovery.
-func deleteBranches(toDelete []branchToDelete, deleteRemote bool, ol *oplog.Logger) error {
+// git branch -D (force) is used for that specific branch. When bk is
+// non-nil each branch is bundled first and is kept if bundling fails.
+// Successful operations are logged to the oplog with the branch SHA and
+// bundle path for recovery.
+func deleteBranches(toDelete []branchToDelete, deleteRemote bool, bk *backup.Store, ol *oplog.Logger) error {
 	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
-	yellow := color.New(color.FgYellow)
-	red := color.New(color.FgRed)
-	dim := color.New(color.FgHiBlack)
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+
+	toDelete, err := keepMyBranches(toDelete)
+	if err != nil || len(toDelete) == 0 {
+		return err
+	}
+	if ok, err := confirmBulkDeletion(len(toDelete), "branches"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was deleted.")
+		}
+		return err
+	}
+	if deleteRemote {
+		var remoteNames []string
+		for _, b := range toDelete {
+			if b.hasRemote && b.canDeleteRemote {
+				remoteNames = append(remoteNames, branchRemote(b)+"/"+b.branch)
+			}
+		}
+		if ok, err := confirmIrreversible(remoteNames, "remote branch", "remote branches"); err != nil || !ok {
+			if err == nil {
+				fmt.Println("Cancelled. Nothing was deleted.")
+			}
+			return err
+		}
+	}
 
 	var localFailed []string
 	var remoteFailed []string
 	total := len(toDelete)
 
+	progress := newProgress()
 	for i, b := range toDelete {
-		completed := i + 1
-		remaining := total - completed
 		label := fmt.Sprintf("%s: %s", b.repoName, b.branch)
 
-		fmt.Print(clearLine)
+		// A rebase or merge started since the scan may need the branch.
+		if op := git.ConflictState(b.repoPath); op != "" {
+			progress.Printf("  %s %s: %s (%s in progress, not deleting)", warn.Sprint("[skip]"), b.repoName, b.branch, op)
+			localFailed = append(localFailed, label)
+			progress.Update(i+1, total)
+			continue
+		}
 
 		// Capture SHA before deletion for audit recovery.
 		sha, err := git.RevParse(b.repoPath, b.branch)
@@ -326,31 +636,39 @@ func deleteBranches(toDelete []branchToDelete, deleteRemote bool, ol *oplog.Logg
 			slog.Debug("could not capture SHA before deletion",
 				"repo", b.repoName, "branch", b.branch, "error", err)
 		}
-		remoteURL, _ := git.RemoteURL(b.repoPath, "origin")
+		remoteURL, _ := git.RemoteURL(b.repoPath, branchRemote(b))
+
+		bundlePath, err := bk.BundleBranch(b.repoPath, b.branch)
+		if err != nil {
+			progress.Printf("  %s %s: %s (backup failed, not deleting: %v)", fail.Sprint("[fail]"), b.repoName, b.branch, err)
+			localFailed = append(localFailed, label)
+			progress.Update(i+1, total)
+			continue
+		}
 
 		slog.Debug("deleting branch", "repo", b.repoName, "branch", b.branch)
 		if err := git.DeleteLocalBranch(b.repoPath, b.branch, b.forceLocal); err != nil {
-			fmt.Printf("  %s %s: %s (%v)\n", red.Sprint("[fail]"), b.repoName, b.branch, err)
+			progress.Printf("  %s %s: %s (%v)", fail.Sprint("[fail]"), b.repoName, b.branch, err)
 			localFailed = append(localFailed, label)
-			if remaining > 0 {
-				fmt.Printf("%s  %s %d remaining...", clearLine, dim.Sprintf("[%d/%d]", completed, total), remaining)
-			}
+			progress.Update(i+1, total)
 			continue
 		}
-		fmt.Printf("  %s %s: %s\n", green.Sprint("[deleted]"), b.repoName, b.branch)
+		progress.Printf("  %s %s: %s", success.Sprint("[deleted]"), b.repoName, b.branch)
+		recordImpact(metrics.ImpactEvent{BranchesDeleted: 1})
 
 		deletedRemote := false
 		if deleteRemote && b.hasRemote && b.canDeleteRemote {
-			if err := git.DeleteRemoteBranch(b.repoPath, "origin", b.branch); err != nil {
+			if err := git.DeleteRemoteBranch(b.repoPath, branchRemote(b), b.branch); err != nil {
 				if isRemoteRefNotFound(err) {
-					fmt.Printf("  %s %s: %s (remote already deleted)\n", yellow.Sprint("[skip]"), b.repoName, b.branch)
+					progress.Printf("  %s %s: %s (remote already deleted)", warn.Sprint("[skip]"), b.repoName, b.branch)
 				} else {
-					fmt.Printf("  %s %s: %s remote (%v)\n", red.Sprint("[fail]"), b.repoName, b.branch, err)
+					progress.Printf("  %s %s: %s remote (%v)", fail.Sprint("[fail]"), b.repoName, b.branch, err)
 					remoteFailed = append(remoteFailed, label)
 				}
 			} else {
 				deletedRemote = true
-				fmt.Printf("  %s %s: %s (remote)\n", green.Sprint("[deleted]"), b.repoName, b.branch)
+				recordImpact(metrics.ImpactEvent{RemoteBranchesDeleted: 1})
+				progress.Printf("  %s %s: %s (remote)", success.Sprint("[deleted]"), b.repoName, b.branch)
 			}
 		}
 
@@ -364,19 +682,18 @@ func deleteBranches(toDelete []branchToDelete, deleteRemote bool, ol *oplog.Logg
 			RemoteURL:     remoteURL,
 			WasForce:      b.forceLocal,
 			DeletedRemote: deletedRemote,
+			Destination:   bundlePath,
 		})
 
-		if remaining > 0 {
-			fmt.Printf("%s  %s %d remaining...", clearLine, dim.Sprintf("[%d/%d]", completed, total), remaining)
-		}
+		progress.Update(i+1, total)
 	}
-
-	fmt.Print(clearLine)
+	progress.Stop()
 
 	fmt.Println()
 	deleted := len(toDelete) - len(localFailed)
 	if deleted > 0 {
 		fmt.Println(bold.Sprintf("Deleted %d branch(es).", deleted))
+		printBackupNote(bk)
 	}
 	if deleteRemote {
 		remoteCount := 0
@@ -406,7 +723,22 @@ func deleteBranches(toDelete []branchToDelete, deleteRemote bool, ol *oplog.Logg
 	return nil
 }
 
-func deleteSelectedBranches(selected []branches.MergedBranch, deleteRemote bool, ol *oplog.Logger) error {
+// branchRemote returns the remote holding a branch to delete, falling back
+// to the repository's primary remote for branches scanned before remotes
+// were recorded, e.g. in an older saved session.
+func branchRemote(b branchToDelete) string {
+	if b.remote != "" {
+		return b.remote
+	}
+	return git.PrimaryRemote(b.repoPath)
+}
+
+func deleteSelectedBranches(selected []branches.MergedBranch, deleteRemote bool, bk *backup.Store, ol *oplog.Logger) error {
+	return deleteBranches(mergedToDelete(selected), deleteRemote, bk, ol)
+}
+
+// mergedToDelete converts merged branches to deletion requests.
+func mergedToDelete(selected []branches.MergedBranch) []branchToDelete {
 	toDelete := make([]branchToDelete, len(selected))
 	for i, m := range selected {
 		toDelete[i] = branchToDelete{
@@ -414,11 +746,12 @@ func deleteSelectedBranches(selected []branches.MergedBranch, deleteRemote bool,
 			repoName:        m.RepoName,
 			branch:          m.Branch,
 			hasRemote:       m.HasRemote,
+			remote:          m.Remote,
 			canDeleteRemote: true,
 			forceLocal:      m.ForceDelete,
 		}
 	}
-	return deleteBranches(toDelete, deleteRemote, ol)
+	return toDelete
 }
 
 func (c *BranchesCmd) runStale(globals *CLI) error {
@@ -439,19 +772,124 @@ func (c *BranchesCmd) runStale(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.InteractiveSelect {
+		flags = append(flags, "--interactive-select")
+	}
+	if c.Review {
+		flags = append(flags, "--review")
+	}
+	if c.Edit {
+		flags = append(flags, "--edit")
+	}
+	if c.Resume {
+		flags = append(flags, "--resume")
+	}
+	if c.Rehearse {
+		flags = append(flags, "--rehearse")
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
+	if c.Limit > 0 {
+		flags = append(flags, fmt.Sprintf("--limit=%d", c.Limit))
+	}
+	if c.IncludeDraftPRs {
+		flags = append(flags, "--include-draft-prs")
+	}
+	if c.ByIssue {
+		flags = append(flags, "--by-issue")
+	}
+	if c.ByAuthor {
+		flags = append(flags, "--by-author")
+	}
+	if c.RespectCheckouts {
+		flags = append(flags, "--respect-checkouts")
+	}
 	flags = append(flags, fmt.Sprintf("--stale-days=%d", c.StaleDays))
 	_ = ml.LogCommand("branches --stale", flags)
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
+	il := loadIgnoreList()
 
-	scanStart := time.Now()
-	repos, isLocal, err := resolveRepos(globals, cfg)
+	var stale []branches.StaleBranch
+	var staleDays int
+	var sess *branchSession
+	if c.Resume {
+		if sess, err = resumeBranchSession(staleSessionName); err != nil {
+			return err
+		}
+		if sess == nil {
+			fmt.Println("No interrupted stale branch cleanup to resume.")
+			return nil
+		}
+		stale, staleDays = remainingStale(sess.state.Stale, sess.state.Tips), sess.state.StaleDays
+		printResuming("stale", sess, len(stale))
+	} else if stale, staleDays, err = c.scanStale(globals, cfg, il, ml); err != nil {
+		return err
+	}
+
+	if len(stale) == 0 {
+		sess.finish()
+		fmt.Println("No stale branches found.")
+		return nil
+	}
+
+	stale = limitOldest(stale, c.Limit, "stale", func(s branches.StaleBranch) time.Time { return s.LastCommit })
+	sortStale(stale, c.Sort)
+	printStaleAnalysisSummary(stale, staleDays)
+	printStaleAgeSummary(stale, time.Now(), c.Histogram)
+	if c.selectionMode() == selectPrompt || (globals.DryRun && !c.Rehearse) {
+		// With --review or --edit the full listing goes to the editor instead.
+		switch {
+		case c.ByIssue:
+			printStaleSummaryByIssue(stale)
+		case c.ByAuthor:
+			printStaleSummaryByAuthor(stale)
+		default:
+			printStaleSummary(stale)
+		}
+	}
+
+	if c.Rehearse {
+		return promptAndExecuteStaleActions(stale, c.selectionMode(), nil, nil, il, ml, ol, true)
+	}
+	if globals.DryRun {
+		return nil
+	}
+
+	bk, err := newBackupStore(cfg)
 	if err != nil {
 		return err
 	}
+	if sess == nil {
+		sess = startBranchSession(staleSessionName, branchSessionState{StaleDays: staleDays, Stale: stale})
+	}
+	if err := promptAndExecuteStaleActions(stale, c.selectionMode(), sess, bk, il, ml, ol, false); err != nil {
+		sess.printResumeHint("--stale")
+		return err
+	}
+	sess.finish()
+	return nil
+}
+
+// scanStale finds the stale branches of the repositories in scope, minus
+// those with open pull requests or on the ignore list, and returns them
+// with the threshold used.
+func (c *BranchesCmd) scanStale(globals *CLI, cfg config.Config, il *ignore.List, ml *metrics.Logger) ([]branches.StaleBranch, int, error) {
+	scanStart := time.Now()
+	repos, isLocal, err := c.resolveRepos(globals, cfg)
+	if err != nil {
+		return nil, 0, err
+	}
 
 	staleDays := c.StaleDays
 	if staleDays <= 0 {
@@ -460,36 +898,45 @@ func (c *BranchesCmd) runStale(globals *CLI) error {
 
 	slog.Debug("found repositories", "count", len(repos))
 
-	workers := cfg.Workers
+	workers := workersFor(cfg, parallel.LocalWork, len(repos))
 	slog.Debug("using worker pool", "workers", workers)
 	printRepoCount("Scanning", len(repos), isLocal, " for stale branches...")
 
-	gh := ghclient.NewClient(cfg.GithubToken)
+	gh := newGitHubClient(cfg)
 	detector := merge.NewDetector(merge.RealGitChecker{}, gh)
 
 	threshold := time.Duration(staleDays) * 24 * time.Hour
-	stale, err := branches.FindStale(repos, threshold, detector, workers, progressPrinter())
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	stale, err := branches.FindStale(repos, threshold, detector, branches.NewIdentity(cfg.Identity.Emails...), workers, ex, progress.Update)
+	progress.Stop()
 	if err != nil {
-		return fmt.Errorf("finding stale branches: %w", err)
+		return nil, 0, fmt.Errorf("finding stale branches: %w", err)
 	}
+	if c.RespectCheckouts {
+		stale = branches.WithoutRecentCheckouts(stale, time.Now().Add(-threshold), ex)
+	}
+	stale = withoutIgnored(stale, func(s branches.StaleBranch) bool { return il.HasBranch(s.RepoPath, s.Branch) }, "branch", "branches")
 	_ = ml.LogPerf(len(repos), int(time.Since(scanStart).Milliseconds()))
 
-	// Filter out branches with open PRs using GitHub API.
-	stale = filterByPRStatus(stale, gh, workers)
-
-	if len(stale) == 0 {
-		fmt.Println("No stale branches found.")
-		return nil
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range stale {
+		stale[i].RepoName = groupedName(projectsDir, stale[i].RepoPath)
+		stale[i].IssueKey = issues.Extract(stale[i].Branch, cfg.Issues.Projects)
 	}
 
-	printStaleAnalysisSummary(stale, staleDays)
-	printStaleSummary(stale)
-
-	if globals.DryRun {
-		return nil
+	// Filter out branches with open PRs using GitHub API, and recognize
+	// branches committed under another of the user's emails.
+	owners := newGitHubOwnership(gh, branches.NewIdentity(cfg.Identity.Emails...), stale)
+	stale = filterByPRStatus(stale, gh, owners, workersFor(cfg, parallel.NetworkWork, len(stale)), cfg.HostLimits, c.IncludeDraftPRs, ex)
+	if c.ByIssue {
+		lookupIssueStatuses(stale, cfg, gh)
 	}
 
-	return promptAndExecuteStaleActions(stale, ml, ol)
+	explainStaleTiers(stale, ex)
+	printExplanations(ex)
+
+	return stale, staleDays, nil
 }
 
 // prCheckResult pairs a stale branch with the outcome of its PR status check.
@@ -500,25 +947,29 @@ type prCheckResult struct {
 
 // filterByPRStatus uses the GitHub API to exclude branches with open PRs
 // from the stale list. Branches whose PRs were merged are kept as cleanup
-// candidates. API failures are logged but do not prevent the branch from
-// appearing in results (fail-open).
-func filterByPRStatus(stale []branches.StaleBranch, gh *ghclient.Client, workers int) []branches.StaleBranch {
+// candidates, and with includeDrafts so are branches whose open PR is a
+// draft, marked with the PR's state. API failures are logged but do not
+// prevent the branch from appearing in results (fail-open). Exclusions
+// are recorded to ex when it is non-nil. API lookups count against the
+// api.github.com host limit. With owners, branches GitHub attributes to
+// the user are marked as their own.
+func filterByPRStatus(stale []branches.StaleBranch, gh *ghclient.Client, owners *githubOwnership, workers int, limits parallel.Limits, includeDrafts bool, ex *explain.Log) []branches.StaleBranch {
 	slog.Debug("checking PR status for stale branches", "count", len(stale))
 
-	dim := color.New(color.FgHiBlack)
 	fmt.Printf("Checking PR status for %d branches...\n", len(stale))
+	progress := newProgress()
 
-	results := parallel.Run(stale, workers, func(s branches.StaleBranch) prCheckResult {
+	results := parallel.RunHosts(stale, workers, prCheckHost, limits, func(s branches.StaleBranch) prCheckResult {
 		if !s.HasRemote {
 			return prCheckResult{branch: s}
 		}
 
-		remote, err := git.RemoteURL(s.RepoPath, "origin")
+		remotes, err := git.RemoteURLs(s.RepoPath, git.PrimaryRemote(s.RepoPath))
 		if err != nil {
 			return prCheckResult{branch: s}
 		}
 
-		owner, repo, ok := ghclient.ParseGitHubRemote(remote)
+		owner, repo, ok := ghclient.ParseGitHubRemotes(remotes)
 		if !ok {
 			return prCheckResult{branch: s}
 		}
@@ -527,34 +978,52 @@ func filterByPRStatus(stale []branches.StaleBranch, gh *ghclient.Client, workers
 		if err != nil {
 			slog.Debug("could not check PR status, keeping branch in results",
 				"repo", s.RepoName, "branch", s.Branch, "error", err)
+			ex.Addf(s.RepoName, s.Branch, explain.Reported, "PR status unknown (%v); kept as a candidate", err)
 			return prCheckResult{branch: s}
 		}
+		owners.claim(&s, owner, repo, info, ex)
 
 		if info.State == ghclient.PRStateOpen {
+			state := openPRState(gh, owner, repo, info, includeDrafts || ex != nil)
+			if info.Draft && includeDrafts {
+				s.OpenPRNumber, s.OpenPRState = info.Number, state
+				s.CIStatus = prCIStatus(gh, owner, repo, info.HeadSHA)
+				ex.Addf(s.RepoName, s.Branch, explain.Reported, "open PR #%d is a %s; kept by --include-draft-prs", info.Number, state)
+				return prCheckResult{branch: s}
+			}
 			slog.Debug("excluding branch with open PR",
 				"repo", s.RepoName, "branch", s.Branch)
+			switch {
+			case info.Draft:
+				ex.Addf(s.RepoName, s.Branch, explain.Excluded, "open PR #%d (%s); --include-draft-prs lists it", info.Number, state)
+			case state != "":
+				ex.Addf(s.RepoName, s.Branch, explain.Excluded, "open PR #%d (%s)", info.Number, state)
+			default:
+				ex.Addf(s.RepoName, s.Branch, explain.Excluded, "open PR #%d", info.Number)
+			}
 			return prCheckResult{branch: s, exclude: true}
 		}
 
-		if info.State == ghclient.PRStateMerged {
+		if info.State == ghclient.PRStateMerged || info.State == ghclient.PRStateClosed {
 			// Verify local branch tip matches the PR's head SHA
 			// to prevent false positives from reused branch names.
 			localSHA, shaErr := git.RevParse(s.RepoPath, s.Branch)
 			if shaErr == nil && localSHA == info.HeadSHA {
-				s.PRNumber = info.Number
-				s.PRMergedAt = info.MergedAt
+				if info.State == ghclient.PRStateMerged {
+					s.PRNumber = info.Number
+					s.PRMergedAt = info.MergedAt
+				} else {
+					s.ClosedPRNumber = info.Number
+				}
+				s.CIStatus = prCIStatus(gh, owner, repo, info.HeadSHA)
 			}
 		}
 
 		return prCheckResult{branch: s}
 	}, func(completed, total int, _ prCheckResult) {
-		remaining := total - completed
-		if remaining > 0 {
-			fmt.Printf("%s  %s", clearLine, dim.Sprintf("[%d/%d]", completed, total))
-		} else {
-			fmt.Print(clearLine)
-		}
+		progress.Update(completed, total)
 	})
+	progress.Stop()
 
 	filtered := make([]branches.StaleBranch, 0, len(stale))
 	for _, r := range results {
@@ -569,7 +1038,7 @@ func filterByPRStatus(stale []branches.StaleBranch, gh *ghclient.Client, workers
 // branch safety, helping users understand why branches were categorized.
 func printStaleAnalysisSummary(stale []branches.StaleBranch, staleDays int) {
 	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
+	success := ui.Success()
 
 	// Count how many branches have remotes and are own branches.
 	hasRemote := 0
@@ -585,59 +1054,122 @@ func printStaleAnalysisSummary(stale []branches.StaleBranch, staleDays int) {
 
 	fmt.Println()
 	fmt.Println(bold.Sprint("Safety checks completed:"))
-	fmt.Printf("  %s Verified commit authorship (%d branches are yours)\n", green.Sprint("✓"), ownBranch)
-	fmt.Printf("  %s Confirmed remote backup status (%d have remote backups)\n", green.Sprint("✓"), hasRemote)
-	fmt.Printf("  %s Excluded branches with open pull requests\n", green.Sprint("✓"))
-	fmt.Printf("  %s Applied %d-day staleness threshold\n", green.Sprint("✓"), staleDays)
+	fmt.Printf("  %s Verified commit authorship (%d branches are yours)\n", success.Sprint("✓"), ownBranch)
+	fmt.Printf("  %s Confirmed remote backup status (%d have remote backups)\n", success.Sprint("✓"), hasRemote)
+	fmt.Printf("  %s Excluded branches with open pull requests\n", success.Sprint("✓"))
+	fmt.Printf("  %s Applied %d-day staleness threshold\n", success.Sprint("✓"), staleDays)
 	fmt.Println()
 }
 
 func printStaleSummary(stale []branches.StaleBranch) {
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
-	yellow := color.New(color.FgYellow)
 
 	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d stale branch(es):", len(stale)))
 
+	// See printMergedSummary.
+	grouped := groupedByRepo(stale, func(s branches.StaleBranch) string { return s.RepoName })
 	currentRepo := ""
 	for _, s := range stale {
-		if s.RepoName != currentRepo {
+		indent, name := "    ", s.Branch
+		if !grouped {
+			indent, name = "  ", bold.Sprint(s.RepoName)+": "+s.Branch
+		} else if s.RepoName != currentRepo {
 			currentRepo = s.RepoName
 			fmt.Printf("  %s\n", bold.Sprint(s.RepoName))
 		}
 
-		scope := "local only"
-		if s.HasRemote {
-			scope = "local + remote"
-		}
+		fmt.Println(indent + staleSummaryLine(s, name))
+	}
+	fmt.Println()
+}
 
-		age := formatAge(s.LastCommit)
-		subject := truncate(s.LastCommitMessage, maxCommitSummaryLen)
+// staleSummaryLine describes a stale branch for the summary views: name,
+// scope, age (and last checkout, when later), commit subject, and commit
+// delta.
+func staleSummaryLine(s branches.StaleBranch, name string) string {
+	dim := ui.Muted()
+	warn := ui.Warn()
 
-		// Highlight local-only branches with commits ahead to warn about data loss.
-		aheadStr := fmt.Sprintf("+%d", s.CommitsAhead)
-		if s.IsLocalOnly && s.CommitsAhead > 0 {
-			aheadStr = yellow.Sprintf("+%d", s.CommitsAhead)
-		}
+	scope := "local only"
+	switch {
+	case s.Diverged:
+		scope = warn.Sprintf("diverged: %d local-only, %d remote-only commits", s.UpstreamAhead, s.UpstreamBehind)
+	case s.IsUpstreamGone:
+		scope = "upstream gone"
+	case s.HasRemote:
+		scope = "local + remote"
+	}
 
-		fmt.Printf("    %s (%s)  %s  %s  %s/-%d\n",
-			s.Branch,
-			scope,
-			dim.Sprintf("last commit %s", age),
-			dim.Sprint(subject),
-			aheadStr, s.CommitsBehind,
-		)
+	age := "last commit " + formatAge(s.LastCommit)
+	if s.LastCheckout.After(s.LastCommit) {
+		age += ", checked out " + formatAge(s.LastCheckout)
 	}
-	fmt.Println()
+	subject := truncate(s.LastCommitMessage, maxCommitSummaryLen)
+
+	// Highlight local-only branches with commits ahead to warn about data loss.
+	aheadStr := fmt.Sprintf("+%d", s.CommitsAhead)
+	if s.IsLocalOnly && s.CommitsAhead > 0 {
+		aheadStr = warn.Sprintf("+%d", s.CommitsAhead)
+	}
+
+	return fmt.Sprintf("%s (%s)  %s  %s  %s/-%d",
+		name,
+		scope,
+		dim.Sprint(age),
+		dim.Sprint(subject),
+		aheadStr, s.CommitsBehind,
+	)
 }
 
 // maxCommitSummaryLen is the maximum characters for commit messages in the
 // stale branch summary view.
 const maxCommitSummaryLen = 50
 
-// clearLine is the ANSI escape sequence to move the cursor to the start
-// of the line and erase its contents.
-const clearLine = "\r\033[2K"
+// uiCaps holds the terminal capabilities detected at startup. Commands
+// consult it to decide whether in-place progress and full-screen prompts
+// are usable.
+var uiCaps ui.Capabilities
+
+// assumeYes is set from the global --yes flag. When true, prompts are not
+// shown and every field keeps its default value.
+var assumeYes bool
+
+// errNonInteractive is returned when a command needs an answer from the
+// user but stdin or stdout is not a terminal.
+var errNonInteractive = errors.New("interactive prompt required but not running in a terminal; " +
+	"re-run with --yes to accept the default selections, or --dry-run to preview")
+
+// prompt wraps a huh form so every interactive step honors the detected
+// terminal capabilities and the --yes flag.
+type prompt struct {
+	form *huh.Form
+}
+
+// newForm builds a prompt that falls back to line-oriented accessible
+// prompts when the terminal cannot render the interactive UI.
+func newForm(groups ...*huh.Group) *prompt {
+	return &prompt{form: huh.NewForm(groups...).WithTheme(ui.FormTheme())}
+}
+
+// Run shows the form. With --yes the form is answered non-interactively:
+// each field receives end-of-input, which huh treats as accepting the
+// default (preselected options stay selected, confirmations keep their
+// bound value). Without --yes, a non-interactive session is an error
+// rather than a prompt that blocks forever or reads from a pipe.
+func (p *prompt) Run() error {
+	switch {
+	case assumeYes:
+		return p.form.
+			WithAccessible(true).
+			WithInput(strings.NewReader("")).
+			WithOutput(io.Discard).
+			Run()
+	case !uiCaps.Interactive():
+		return errNonInteractive
+	default:
+		return p.form.WithAccessible(uiCaps.Plain()).Run()
+	}
+}
 
 // printRepoCount prints a status line like "Scanning 42 repositories for merged branches..."
 // In local mode it always says "1 repository" instead of the count.
@@ -649,96 +1181,172 @@ func printRepoCount(verb string, count int, isLocal bool, suffix string) {
 	}
 }
 
-// progressPrinter returns a callback that displays an inline progress
-// counter. The line is cleared when all items complete.
-func progressPrinter() func(completed, total int) {
-	dim := color.New(color.FgHiBlack)
-	return func(completed, total int) {
-		remaining := total - completed
-		if remaining > 0 {
-			fmt.Printf("%s  %s %d remaining...",
-				clearLine, dim.Sprintf("[%d/%d]", completed, total), remaining)
-		} else {
-			fmt.Print(clearLine)
-		}
-	}
+// newProgress starts a progress renderer on stdout that matches the
+// detected terminal capabilities. Callers must Stop it before printing
+// anything else.
+func newProgress() *ui.Progress {
+	return ui.NewProgress(os.Stdout, uiCaps.Plain())
 }
 
 // promptAndExecuteStaleActions categorizes stale branches into safety tiers,
-// presents a multi-select per tier, and deletes the selected branches.
-func promptAndExecuteStaleActions(stale []branches.StaleBranch, ml *metrics.Logger, ol *oplog.Logger) error {
-	safe, automation, review := categorizeStaleBranches(stale)
-
-	tiers := []struct {
-		title       string
-		description string
-		branches    []branches.StaleBranch
-		preselect   bool
-	}{
-		{
-			"Safe to delete",
-			"Branches you authored that have remote backups. Verified: you're the sole author, work exists remotely.",
-			safe, true,
-		},
-		{
-			"Automation branches",
-			"Created by tools like Dependabot or Renovate. The remote tool manages these.",
-			automation, true,
-		},
-		{
-			"Needs review",
-			"Local-only or other-author branches. Check before deleting -- work may not exist elsewhere.",
-			review, false,
-		},
+// presents a multi-select per tier (or a single editor buffer with
+// --review or --edit), and deletes or archives the selected branches.
+// Answers are recorded to sess, and steps it already has are skipped.
+// When rehearse is set the selected actions are only listed.
+func promptAndExecuteStaleActions(stale []branches.StaleBranch, mode selectionMode, sess *branchSession, bk *backup.Store, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger, rehearse bool) error {
+	tiers := staleTiers(stale)
+
+	var selected, archived []branches.StaleBranch
+	var err error
+	switch mode {
+	case selectEdit:
+		selected, archived, err = sessionStep(sess, mode.String(), stale, staleKey, func() ([]branches.StaleBranch, []branches.StaleBranch, error) {
+			return editStaleSelection(tiers)
+		})
+	case selectReview:
+		selected, _, err = sessionStep(sess, mode.String(), stale, staleKey, func() ([]branches.StaleBranch, []branches.StaleBranch, error) {
+			selected, err := reviewStaleSelection(tiers)
+			return selected, nil, err
+		})
+	default:
+		// Each tier is its own step so a resumed session skips the tiers
+		// already answered.
+		for _, tier := range tiers {
+			if len(tier.branches) == 0 {
+				continue
+			}
+			tierSelected, _, tierErr := sessionStep(sess, mode.String()+":"+tier.title, tier.branches, staleKey, func() ([]branches.StaleBranch, []branches.StaleBranch, error) {
+				tierSelected, err := promptTierSelection(tier.title, tier.description, tier.branches, tier.preselect)
+				return tierSelected, nil, err
+			})
+			if tierErr != nil {
+				return tierErr
+			}
+			selected = append(selected, tierSelected...)
+		}
+	}
+	if err != nil {
+		return err
 	}
 
-	var selected []branches.StaleBranch
-	for _, tier := range tiers {
-		if len(tier.branches) == 0 {
-			continue
-		}
-		tierSelected, err := promptTierSelection(tier.title, tier.description, tier.branches, tier.preselect)
+	// Archiving keeps the commits under a tag, so only outright deletions
+	// of other people's work need a second confirmation.
+	selected, err = confirmOtherAuthorDeletes(selected)
+	if err != nil {
+		return err
+	}
+	if rehearse {
+		deleteRemote, err := promptForStaleRemoteDeletion(selected)
 		if err != nil {
 			return err
 		}
-		selected = append(selected, tierSelected...)
+		return printRehearsal(staleToDelete(selected), staleToDelete(archived), deleteRemote)
 	}
 
 	// Log metrics for all branches.
-	selectedSet := make(map[string]bool, len(selected))
-	for _, s := range selected {
+	selectedSet := make(map[string]bool, len(selected)+len(archived))
+	for _, s := range append(append([]branches.StaleBranch{}, selected...), archived...) {
 		selectedSet[s.RepoPath+":"+s.Branch] = true
 	}
+	var passed []ignoreCandidate
 	for _, s := range stale {
 		fp := branchFingerprint(s.RepoPath, s.Branch)
 		ageDays := int(time.Since(s.LastCommit).Hours() / 24)
-		_ = ml.LogSuggestion("delete_stale_branch", fp, selectedSet[s.RepoPath+":"+s.Branch], ageDays)
+		accepted := selectedSet[s.RepoPath+":"+s.Branch]
+		_ = ml.LogSuggestion("delete_stale_branch", fp, accepted, ageDays)
+		if !accepted {
+			passed = append(passed, branchIgnoreCandidate(s.RepoName, s.RepoPath, s.Branch))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
 	}
 
-	if len(selected) == 0 {
+	if len(selected) == 0 && len(archived) == 0 {
 		fmt.Println("No branches selected for deletion.")
 		return nil
 	}
 
+	var archiveErr error
+	if len(archived) > 0 {
+		archiveErr = archiveBranches(staleToDelete(archived), bk, ol)
+		if len(selected) == 0 {
+			return archiveErr
+		}
+	}
+
 	deleteRemote, err := promptForStaleRemoteDeletion(selected)
 	if err != nil {
 		return err
 	}
+	if err := executeStaleDeletes(selected, deleteRemote, bk, ol); err != nil {
+		return err
+	}
+	return archiveErr
+}
 
-	return executeStaleDeletes(selected, deleteRemote, ol)
+// staleTier is one safety tier of stale branches as offered for deletion.
+type staleTier struct {
+	title       string
+	description string
+	branches    []branches.StaleBranch
+	preselect   bool
+}
+
+// staleTiers splits stale branches into the tiers offered for deletion,
+// in the order they are presented.
+func staleTiers(stale []branches.StaleBranch) []staleTier {
+	safe, automation, diverged, review := categorizeStaleBranches(stale)
+	return []staleTier{
+		{
+			"Safe to delete",
+			"Branches you authored that have remote backups, and your branches whose upstream was deleted after their commits landed elsewhere.",
+			safe, true,
+		},
+		{
+			"Automation branches",
+			"Created by tools like Dependabot or Renovate. The remote tool manages these.",
+			automation, true,
+		},
+		{
+			"Diverged from remote",
+			"Force-pushed or rewritten: the local and remote copies each have commits the other lacks. " +
+				"Compare with git log <branch>...<remote>/<branch> first -- deleting here drops the local side.",
+			diverged, false,
+		},
+		{
+			"Needs review",
+			"Local-only or other-author branches. Check before deleting -- work may not exist elsewhere.",
+			review, false,
+		},
+	}
 }
 
 // categorizeStaleBranches groups branches into safety tiers for the
 // multi-select UI. Automation branches are always in their own tier
-// regardless of other properties. Own branches with remotes are "safe"
-// because the work exists elsewhere. Everything else (local-only,
-// other-author) needs manual review.
-func categorizeStaleBranches(stale []branches.StaleBranch) (safe, automation, review []branches.StaleBranch) {
+// regardless of other properties. Branches that diverged from their
+// upstream get their own tier, since the remote copy no longer backs up
+// the local commits. Own branches with remotes are "safe" because the
+// work exists elsewhere. So are your branches whose upstream is gone,
+// which is what a merged PR with auto-delete leaves behind, but only when
+// their tip is verified to exist elsewhere: in the default branch, a
+// remote-tracking branch, or the head of their merged PR. Everything else
+// (local-only, other-author, unverified gone upstream, or with an open
+// draft PR) needs manual review.
+func categorizeStaleBranches(stale []branches.StaleBranch) (safe, automation, diverged, review []branches.StaleBranch) {
 	for _, s := range stale {
 		switch {
 		case s.IsAutomation:
 			automation = append(automation, s)
-		case s.HasRemote && s.IsOwnBranch:
+		case s.Diverged:
+			diverged = append(diverged, s)
+		case s.IsUpstreamGone:
+			if goneVerified(s) {
+				safe = append(safe, s)
+			} else {
+				review = append(review, s)
+			}
+		case s.HasRemote && s.IsOwnBranch && s.OpenPRNumber == 0:
 			safe = append(safe, s)
 		default:
 			review = append(review, s)
@@ -747,6 +1355,14 @@ func categorizeStaleBranches(stale []branches.StaleBranch) (safe, automation, re
 	return
 }
 
+// goneVerified reports whether a branch whose upstream is gone is the
+// user's and its commits exist elsewhere: its tip is reachable from the
+// default branch or a remote-tracking branch, or is the head of its merged
+// PR (PRNumber is only set when the local tip matches).
+func goneVerified(s branches.StaleBranch) bool {
+	return s.IsOwnBranch && s.OpenPRNumber == 0 && (s.TipReachable || s.PRNumber > 0)
+}
+
 // promptTierSelection presents a multi-select for a single tier of stale
 // branches. Returns the branches the user selected for deletion.
 func promptTierSelection(title, description string, tier []branches.StaleBranch, preselect bool) ([]branches.StaleBranch, error) {
@@ -756,7 +1372,7 @@ func promptTierSelection(title, description string, tier []branches.StaleBranch,
 	}
 
 	var selectedIndices []int
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[int]().
 				Title(title).
@@ -778,11 +1394,45 @@ func promptTierSelection(title, description string, tier []branches.StaleBranch,
 	return result, nil
 }
 
+// prCIStatus returns the CI status of a PR's head commit, or
+// ghclient.CINone when it cannot be looked up.
+func prCIStatus(gh *ghclient.Client, owner, repo, sha string) ghclient.CIStatus {
+	status, err := gh.CommitCIStatus(owner, repo, sha)
+	if err != nil {
+		slog.Debug("could not look up CI status", "repo", owner+"/"+repo, "sha", sha, "error", err)
+	}
+	return status
+}
+
+// openPRState describes an open PR: "draft", "changes requested", both,
+// or "" for neither. Reviews are only looked up when withReviews is set,
+// since they cost an API call per PR; a failed lookup is left out.
+func openPRState(gh *ghclient.Client, owner, repo string, info *ghclient.PRInfo, withReviews bool) string {
+	var parts []string
+	if info.Draft {
+		parts = append(parts, "draft")
+	}
+	if withReviews {
+		changes, err := gh.ChangesRequested(owner, repo, info.Number)
+		if err != nil {
+			slog.Debug("could not check PR reviews", "repo", owner+"/"+repo, "pr", info.Number, "error", err)
+		} else if changes {
+			parts = append(parts, "changes requested")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
 // staleBranchLabel builds a display label for a stale branch option including
 // scope, age, commit subject, commit delta, and PR merge info.
 func staleBranchLabel(s branches.StaleBranch) string {
 	scope := "local only"
-	if s.HasRemote {
+	switch {
+	case s.Diverged:
+		scope = fmt.Sprintf("diverged from remote: %d local-only, %d remote-only commits", s.UpstreamAhead, s.UpstreamBehind)
+	case s.IsUpstreamGone:
+		scope = "upstream gone"
+	case s.HasRemote:
 		scope = "backed up remotely"
 	}
 
@@ -802,10 +1452,75 @@ func staleBranchLabel(s branches.StaleBranch) string {
 			label += fmt.Sprintf(" [merged PR #%d]", s.PRNumber)
 		}
 	}
+	if s.OpenPRNumber > 0 {
+		label += fmt.Sprintf(" [open PR #%d: %s]", s.OpenPRNumber, s.OpenPRState)
+	}
+	if s.ClosedPRNumber > 0 {
+		label += fmt.Sprintf(" [PR #%d closed unmerged]", s.ClosedPRNumber)
+	}
+	if note := s.CIStatus.Describe(); note != "" {
+		label += " [" + note + "]"
+	}
+	if s.IssueStatus != "" {
+		label += fmt.Sprintf(" [%s: %s]", s.IssueKey, s.IssueStatus)
+	}
+
+	if hasOtherAuthors(s) && len(s.Authors) > 0 {
+		label += fmt.Sprintf(" [authors: %s]", strings.Join(s.Authors, ", "))
+	}
 
 	return label
 }
 
+// hasOtherAuthors reports whether the branch contains commits by someone
+// other than the user. Automation branches are excluded since their
+// authors are bots.
+func hasOtherAuthors(s branches.StaleBranch) bool {
+	return !s.IsOwnBranch && !s.IsAutomation
+}
+
+// confirmOtherAuthorDeletes asks for a second confirmation before deleting
+// branches that contain other people's commits, the riskiest deletions.
+// Declined branches are dropped from the selection. The confirmation
+// defaults to no, so --yes never deletes these.
+func confirmOtherAuthorDeletes(selected []branches.StaleBranch) ([]branches.StaleBranch, error) {
+	var risky []string
+	for _, s := range selected {
+		if hasOtherAuthors(s) {
+			risky = append(risky, fmt.Sprintf("%s: %s (%s)", s.RepoName, s.Branch, strings.Join(s.Authors, ", ")))
+		}
+	}
+	if len(risky) == 0 {
+		return selected, nil
+	}
+
+	var confirmed bool
+	form := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Really delete %d %s with commits by other authors?",
+					len(risky), pluralize(len(risky), "branch", "branches"))).
+				Description(strings.Join(risky, "\n")).
+				Value(&confirmed),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("prompt failed: %w", err)
+	}
+	if confirmed {
+		return selected, nil
+	}
+
+	kept := make([]branches.StaleBranch, 0, len(selected))
+	for _, s := range selected {
+		if !hasOtherAuthors(s) {
+			kept = append(kept, s)
+		}
+	}
+	fmt.Printf("Keeping %d %s with other authors.\n", len(risky), pluralize(len(risky), "branch", "branches"))
+	return kept, nil
+}
+
 // promptForStaleRemoteDeletion asks whether to also delete remote branches
 // when any of the selected stale branches have a remote that is safe to delete.
 func promptForStaleRemoteDeletion(selected []branches.StaleBranch) (bool, error) {
@@ -821,10 +1536,10 @@ func promptForStaleRemoteDeletion(selected []branches.StaleBranch) (bool, error)
 	}
 
 	var deleteRemote bool
-	form := huh.NewForm(
+	form := newForm(
 		huh.NewGroup(
 			huh.NewConfirm().
-				Title("Also delete remote branches on origin?").
+				Title("Also delete remote branches?").
 				Description("Only your own branches will be deleted remotely. Automation and other-author branches are skipped.").
 				Value(&deleteRemote),
 		),
@@ -836,22 +1551,28 @@ func promptForStaleRemoteDeletion(selected []branches.StaleBranch) (bool, error)
 }
 
 // isRemoteRefNotFound returns true if the error indicates the remote
-// branch has already been deleted. This matches against git's error
-// message text, which could vary across locales or git versions.
+// branch has already been deleted.
 func isRemoteRefNotFound(err error) bool {
-	return strings.Contains(err.Error(), "remote ref does not exist")
+	return errors.Is(err, git.ErrRemoteRefNotFound)
 }
 
 // safeToDeleteRemote returns true if the branch can safely have its remote
 // deleted. Automation branches and branches with other contributors should
-// never have their remotes deleted by this tool.
+// never have their remotes deleted by this tool, nor branches with an open
+// PR, which deleting the remote branch would close, nor diverged branches,
+// whose remote holds commits the local branch does not.
 func safeToDeleteRemote(s branches.StaleBranch) bool {
-	return !s.IsAutomation && s.IsOwnBranch
+	return !s.IsAutomation && s.IsOwnBranch && s.OpenPRNumber == 0 && !s.Diverged
 }
 
 // executeStaleDeletes deletes the selected stale branches locally, and
 // optionally their remote counterparts where safe.
-func executeStaleDeletes(selected []branches.StaleBranch, deleteRemote bool, ol *oplog.Logger) error {
+func executeStaleDeletes(selected []branches.StaleBranch, deleteRemote bool, bk *backup.Store, ol *oplog.Logger) error {
+	return deleteBranches(staleToDelete(selected), deleteRemote, bk, ol)
+}
+
+// staleToDelete converts stale branches to deletion requests.
+func staleToDelete(selected []branches.StaleBranch) []branchToDelete {
 	toDelete := make([]branchToDelete, len(selected))
 	for i, s := range selected {
 		toDelete[i] = branchToDelete{
@@ -859,11 +1580,12 @@ func executeStaleDeletes(selected []branches.StaleBranch, deleteRemote bool, ol
 			repoName:        s.RepoName,
 			branch:          s.Branch,
 			hasRemote:       s.HasRemote,
+			remote:          s.Remote,
 			canDeleteRemote: safeToDeleteRemote(s),
 			forceLocal:      true,
 		}
 	}
-	return deleteBranches(toDelete, deleteRemote, ol)
+	return toDelete
 }
 
 func truncate(s string, maxLen int) string {
@@ -874,10 +1596,15 @@ func truncate(s string, maxLen int) string {
 }
 
 func formatAge(t time.Time) string {
+	return formatAgeSince(t, time.Now())
+}
+
+// formatAgeSince is formatAge measured from now.
+func formatAgeSince(t, now time.Time) string {
 	if t.IsZero() {
 		return "unknown date"
 	}
-	days := int(time.Since(t).Hours() / 24)
+	days := int(now.Sub(t).Hours() / 24)
 	switch {
 	case days == 0:
 		return "today"
@@ -903,19 +1630,127 @@ func formatAge(t time.Time) string {
 // branchFingerprint returns a stable fingerprint for a branch using the
 // repo's remote URL when available, falling back to the repo path.
 func branchFingerprint(repoPath, branch string) string {
-	remote, err := git.RemoteURL(repoPath, "origin")
+	remote, err := git.RemoteURL(repoPath, git.PrimaryRemote(repoPath))
 	if err != nil || remote == "" {
 		remote = repoPath
 	}
 	return metrics.Fingerprint(remote, branch)
 }
 
+// consoleLevel is the minimum level written to stderr. -v lowers it to debug.
+var consoleLevel = new(slog.LevelVar)
+
+// logFileHandler receives every record at debug level when a log file is
+// configured, independent of -v. Nil when file logging is off.
+var logFileHandler slog.Handler
+
 // enableVerboseLogging configures the default slog logger to emit debug-level
 // messages to stderr.
 func enableVerboseLogging() {
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
-	})))
+	consoleLevel.Set(slog.LevelDebug)
+	installLogger()
+}
+
+// installLogger sets the default slog logger to write to stderr at
+// consoleLevel and, if a log file is open, to the file at debug level.
+func installLogger() {
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: consoleLevel})
+	if logFileHandler != nil {
+		h = logging.Tee(h, logFileHandler)
+	}
+	slog.SetDefault(slog.New(h))
+}
+
+// setupLogFile opens the log file at path and routes all slog output to
+// it. An empty path means file logging is not configured, and a nil file
+// is returned.
+func setupLogFile(path string) (*logging.RotatingFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := logging.OpenRotating(config.ExpandHome(path), logging.DefaultMaxBytes, logging.DefaultBackups)
+	if err != nil {
+		return nil, fmt.Errorf("opening log file: %w", err)
+	}
+	logFileHandler = slog.NewTextHandler(f, &slog.HandlerOptions{Level: slog.LevelDebug})
+	installLogger()
+	slog.Debug("katazuke started", "version", version, "args", os.Args[1:])
+	return f, nil
+}
+
+// githubToken is the token source of the first GitHub client built. It
+// resolves the token once per run, so a token command or keychain lookup
+// that prompts for an unlock runs at most once however many clients a
+// command builds.
+var githubToken func() (string, error)
+
+// newGitHubClient creates a GitHub client honoring the configured token
+// sources and org allowlist/denylist for API lookups.
+func newGitHubClient(cfg config.Config) *ghclient.Client {
+	if githubToken == nil {
+		githubToken = sync.OnceValues(cfg.ResolveGithubToken)
+	}
+	return ghclient.NewClientWithTokenSource(githubToken).
+		WithOrgFilter(cfg.GitHub.APIOrgsAllow, cfg.GitHub.APIOrgsDeny)
+}
+
+// loadConfig loads the configuration and applies the global flags that
+// override it for a single run.
+func (g *CLI) loadConfig() (config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return cfg, err
+	}
+	if err := g.applyFlags(&cfg, nil); err != nil {
+		return cfg, err
+	}
+	ui.SetColor(cfg.UI.Color)
+	ui.SetTheme(cfg.UI.Theme)
+	git.SetPreferredRemote(cfg.PrimaryRemote)
+	git.SetIsolation(cfg.Safety.IsolateHooks)
+	branches.SetProtectedPatterns(cfg.ProtectedBranches)
+	branches.SetAutomationPatterns(cfg.AutomationPatterns)
+	deletionThreshold = cfg.Safety.MaxDeletionsPerRun
+	typedConfirmThreshold = cfg.Safety.TypedConfirmThreshold
+	setMineOnly(cfg)
+	return cfg, nil
+}
+
+// applyFlags applies the global flags that override settings for a
+// single run, recording them in sources when it is not nil.
+func (g *CLI) applyFlags(cfg *config.Config, sources config.Sources) error {
+	if g.Workers < 0 {
+		return fmt.Errorf("invalid --workers %d (must be at least 1)", g.Workers)
+	}
+	if g.Workers > 0 {
+		cfg.Workers = g.Workers
+		if sources != nil {
+			sources["workers"] = config.Origin{Source: config.SourceFlag, Via: "--workers"}
+		}
+	}
+	if g.MineOnly {
+		cfg.Safety.MineOnly = true
+		if sources != nil {
+			sources["safety.mine_only"] = config.Origin{Source: config.SourceFlag, Via: "--mine-only"}
+		}
+	}
+	if len(g.Only) > 0 {
+		cfg.IncludePatterns = g.Only
+		if sources != nil {
+			sources["include_patterns"] = config.Origin{Source: config.SourceFlag, Via: "--only"}
+		}
+	}
+	return nil
+}
+
+// workersFor returns the pool size for items of workload w: the
+// configured worker count when set, otherwise parallel.AutoWorkers.
+func workersFor(cfg config.Config, w parallel.Workload, items int) int {
+	if cfg.Workers > 0 {
+		return cfg.Workers
+	}
+	return parallel.AutoWorkers(w, items)
 }
 
 // resolveProjectsDir returns the projects directory from the CLI flag if
@@ -927,13 +1762,81 @@ func resolveProjectsDir(cliValue string, cfg config.Config) string {
 	return cfg.ProjectsDir
 }
 
+// scopeRepos resolves the repositories once and applies --pattern and
+// --interactive-select, caching the result for resolveRepos. c.scoped
+// stays nil, after telling the user why, when nothing is left to scan.
+func (c *BranchesCmd) scopeRepos(globals *CLI) error {
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	repos, isLocal, err := resolveRepos(globals, cfg)
+	if err != nil {
+		return err
+	}
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	if repos, err = filterByPattern(repos, c.Pattern, projectsDir); err != nil {
+		return err
+	}
+	if len(repos) == 0 {
+		fmt.Printf("No repositories matching %q found.\n", c.Pattern)
+		return nil
+	}
+	if c.InteractiveSelect && !isLocal {
+		if repos, err = selectRepos(repos, projectsDir); err != nil {
+			return err
+		}
+		if len(repos) == 0 {
+			fmt.Println("No repositories selected.")
+			return nil
+		}
+	}
+	c.scoped, c.scopedLocal = repos, isLocal
+	return nil
+}
+
+// scopeRepo limits the run to the repository named by --repo, so the
+// projects directory is never scanned.
+func (c *BranchesCmd) scopeRepo(globals *CLI) error {
+	if globals.Global || globals.Group != "" || c.Pattern != "" || c.InteractiveSelect {
+		return fmt.Errorf("--repo selects a single repository and cannot be combined with --global, --group, --pattern, or --interactive-select")
+	}
+	root, err := git.TopLevel(c.Repo)
+	if err != nil {
+		return fmt.Errorf("--repo %s is not inside a git repository", c.Repo)
+	}
+	c.scoped, c.scopedLocal = []string{root}, true
+	return nil
+}
+
+// resolveRepos returns the repositories cached by scopeRepos or
+// scopeRepo, or resolves them from the working directory and projects
+// directory.
+func (c *BranchesCmd) resolveRepos(globals *CLI, cfg config.Config) ([]string, bool, error) {
+	if c.scoped != nil {
+		return c.scoped, c.scopedLocal, nil
+	}
+	return resolveRepos(globals, cfg)
+}
+
 // resolveRepos determines the set of repositories to operate on. When --global
 // is not set and the cwd is inside a git repo, it returns just that single repo
 // (local mode). Otherwise it falls back to scanning the full projects directory.
+// Bare repositories are left out; see resolveReposIncludingBare.
 func resolveRepos(globals *CLI, cfg config.Config) (repos []string, isLocal bool, err error) {
+	return resolveReposWith(globals, cfg, false)
+}
+
+// resolveReposIncludingBare is resolveRepos for commands that also work on
+// bare repositories and mirrors, such as sync.
+func resolveReposIncludingBare(globals *CLI, cfg config.Config) (repos []string, isLocal bool, err error) {
+	return resolveReposWith(globals, cfg, true)
+}
+
+func resolveReposWith(globals *CLI, cfg config.Config, includeBare bool) (repos []string, isLocal bool, err error) {
 	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
 
-	if !globals.Global {
+	if !globals.Global && globals.Group == "" {
 		cwd, wdErr := os.Getwd()
 		if wdErr == nil {
 			repoRoot, tlErr := git.TopLevel(cwd)
@@ -941,12 +1844,26 @@ func resolveRepos(globals *CLI, cfg config.Config) (repos []string, isLocal bool
 				slog.Debug("detected local repo", "root", repoRoot)
 				return []string{repoRoot}, true, nil
 			}
+			if includeBare && git.IsBareRepo(cwd) {
+				slog.Debug("detected local bare repo", "root", cwd)
+				return []string{cwd}, true, nil
+			}
 		}
 	}
 
-	slog.Debug("scanning for repositories", "dir", projectsDir)
-	repos, err = scanner.Scan(projectsDir, scanner.Options{
+	scanRoot, err := groupRoot(projectsDir, globals.Group)
+	if err != nil {
+		return nil, false, err
+	}
+
+	slog.Debug("scanning for repositories", "dir", scanRoot)
+	repos, err = scanner.Scan(scanRoot, scanner.Options{
 		ExcludePatterns: cfg.ExcludePatterns,
+		ExcludeRemotes:  cfg.ExcludeRemotes,
+		IncludePatterns: cfg.IncludePatterns,
+		MaxDepth:        cfg.ScanDepth,
+		Workers:         workersFor(cfg, parallel.LocalWork, 0),
+		IncludeBare:     includeBare,
 	})
 	if err != nil {
 		return nil, false, fmt.Errorf("scanning repositories: %w", err)
@@ -964,6 +1881,9 @@ func (c *VersionCmd) Run() error {
 }
 
 func main() {
+	uiCaps = ui.Detect()
+	uiCaps.Apply()
+
 	var cli CLI
 	ctx := kong.Parse(&cli,
 		kong.Name("katazuke"),
@@ -975,7 +1895,61 @@ and out-of-date checkouts.`),
 		kong.UsageOnError(),
 		kong.Vars{"version": fmt.Sprintf("%s (commit: %s, built: %s)", version, commit, date)},
 	)
-	err := ctx.Run(&cli)
+	assumeYes = cli.Yes
+	forceDeletions = cli.Force
+	// config.Load reads the profile from the environment, so exporting the
+	// flag makes every load use it, including those made before the
+	// command runs.
+	if cli.Profile != "" {
+		profileFromFlag = cli.Profile != os.Getenv("KATAZUKE_PROFILE")
+		ctx.FatalIfErrorf(os.Setenv("KATAZUKE_PROFILE", cli.Profile))
+	}
+	// Like --group, --only narrows a scan of the projects directory.
+	if len(cli.Only) > 0 {
+		cli.Global = true
+	}
+	// A rehearsal changes nothing, so it runs like --dry-run, without the
+	// run lock.
+	if cli.Branches.Rehearse {
+		cli.DryRun = true
+	}
+	// The housekeeping around the command shares one load of the config.
+	// A config error is reported by the command when it loads the config
+	// itself, and the housekeeping that needs it is skipped.
+	cfg, cfgErr := config.Load()
+	logPath := cli.LogFile
+	if logPath == "" && cfgErr == nil {
+		logPath = cfg.LogFile
+	}
+	logFile, err := setupLogFile(logPath)
+	ctx.FatalIfErrorf(err)
+	state.Migrate()
+	if cfgErr == nil {
+		pruneMetrics(cfg)
+		pruneBackups(cfg)
+	}
+	startImpact()
+	// Without a config the command fails when it loads it, before
+	// touching any repository, so it needs no lock.
+	var runLock *lock.Lock
+	if cfgErr == nil {
+		runLock, err = acquireRunLock(&cli, ctx.Command(), cfg)
+		ctx.FatalIfErrorf(err)
+	}
+	startUpdateNotice(ctx.Command())
+	err = ctx.Run(&cli)
+	releaseRunLock(runLock)
+	if err != nil {
+		slog.Debug("command failed", "error", err)
+	}
+	reportImpact()
+	if cfgErr == nil {
+		submitMetrics(cfg)
+	}
+	finishUpdateNotice()
+	if logFile != nil {
+		_ = logFile.Close()
+	}
 	ctx.FatalIfErrorf(err)
 	// Explicitly exit with 0 on success so tests can verify exit behavior.
 	os.Exit(0)
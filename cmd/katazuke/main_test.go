@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/huh"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// withPromptMode sets the package-level prompt state for one test.
+func withPromptMode(t *testing.T, caps ui.Capabilities, yes bool) {
+	t.Helper()
+	prevCaps, prevYes := uiCaps, assumeYes
+	uiCaps, assumeYes = caps, yes
+	t.Cleanup(func() { uiCaps, assumeYes = prevCaps, prevYes })
+}
+
+func TestPromptNonInteractiveWithoutYes(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, false)
+
+	confirmed := false
+	err := newForm(huh.NewGroup(huh.NewConfirm().Title("Proceed?").Value(&confirmed))).Run()
+	if !errors.Is(err, errNonInteractive) {
+		t.Fatalf("expected errNonInteractive, got %v", err)
+	}
+}
+
+func TestPromptYesAcceptsDefaults(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+
+	var selected []int
+	confirmed := false
+	choice := "keep"
+	err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Select branches").
+				Options(
+					huh.NewOption("a", 0).Selected(true),
+					huh.NewOption("b", 1),
+					huh.NewOption("c", 2).Selected(true),
+				).
+				Value(&selected),
+			huh.NewSelect[string]().
+				Title("Action").
+				Options(huh.NewOption("Keep", "keep"), huh.NewOption("Remove", "remove")).
+				Value(&choice),
+			huh.NewConfirm().Title("Also delete remote?").Value(&confirmed),
+		),
+	).Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(selected) != 2 || selected[0] != 0 || selected[1] != 2 {
+		t.Errorf("expected preselected options [0 2], got %v", selected)
+	}
+	if choice != "keep" {
+		t.Errorf("expected default select value to be kept, got %q", choice)
+	}
+	if confirmed {
+		t.Error("expected confirmation to keep its default (false)")
+	}
+}
+
+func TestSetupLogFileCapturesDebug(t *testing.T) {
+	prevLogger, prevHandler := slog.Default(), logFileHandler
+	t.Cleanup(func() {
+		slog.SetDefault(prevLogger)
+		logFileHandler = prevHandler
+	})
+
+	path := filepath.Join(t.TempDir(), "katazuke.log")
+	f, err := setupLogFile(path)
+	if err != nil {
+		t.Fatalf("setupLogFile: %v", err)
+	}
+	slog.Debug("skipping branch", "branch", "feature/x", "reason", "open PR")
+	_ = f.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `msg="skipping branch" branch=feature/x`) {
+		t.Errorf("debug record not written to log file:\n%s", data)
+	}
+}
+
+func TestWorkersFlagOverridesConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	if err := os.MkdirAll(filepath.Join(dir, "katazuke"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "katazuke", "config.yaml"), []byte("workers: 6\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := (&CLI{}).loadConfig()
+	if err != nil || cfg.Workers != 6 {
+		t.Fatalf("expected workers 6 from config, got %d, %v", cfg.Workers, err)
+	}
+	if got := workersFor(cfg, parallel.NetworkWork, 100); got != 6 {
+		t.Errorf("expected configured workers to win over automatic sizing, got %d", got)
+	}
+
+	cfg, err = (&CLI{Workers: 2}).loadConfig()
+	if err != nil || cfg.Workers != 2 {
+		t.Errorf("expected --workers 2 to override config, got %d, %v", cfg.Workers, err)
+	}
+
+	if _, err := (&CLI{Workers: -1}).loadConfig(); err == nil {
+		t.Error("expected error for negative --workers")
+	}
+
+	cfg.Workers = 0
+	if got := workersFor(cfg, parallel.LocalWork, 1); got != 1 {
+		t.Errorf("expected automatic sizing capped at the item count, got %d", got)
+	}
+}
+
+func TestBranchesScopeRepo(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "tidy")
+	sub := filepath.Join(repo.Path, "src")
+	if err := os.MkdirAll(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &BranchesCmd{Repo: sub}
+	if err := c.scopeRepo(&CLI{}); err != nil {
+		t.Fatalf("scopeRepo: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(repo.Path)
+	if len(c.scoped) != 1 || !c.scopedLocal {
+		t.Fatalf("scoped = %v (local %v)", c.scoped, c.scopedLocal)
+	}
+	if got, _ := filepath.EvalSymlinks(c.scoped[0]); got != want {
+		t.Errorf("scoped to %s, want %s", got, want)
+	}
+
+	if err := (&BranchesCmd{Repo: t.TempDir()}).scopeRepo(&CLI{}); err == nil {
+		t.Error("expected an error for a path outside any repository")
+	}
+	if err := (&BranchesCmd{Repo: repo.Path}).scopeRepo(&CLI{Global: true}); err == nil {
+		t.Error("expected --repo and --global to conflict")
+	}
+}
+
+func TestGitHubTokenResolvedOnce(t *testing.T) {
+	// Without gh CLI authentication, each client falls back to the token
+	// sources.
+	t.Setenv("GH_CONFIG_DIR", t.TempDir())
+	t.Setenv("GH_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_ENTERPRISE_TOKEN", "")
+	t.Setenv("GITHUB_ENTERPRISE_TOKEN", "")
+	prev := githubToken
+	githubToken = nil
+	t.Cleanup(func() { githubToken = prev })
+
+	calls := filepath.Join(t.TempDir(), "calls")
+	cfg := config.Config{GitHub: config.GitHubConfig{
+		TokenCommand: "echo call >> '" + calls + "' && echo ghp_test",
+	}}
+	newGitHubClient(cfg)
+	newGitHubClient(cfg)
+
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "call"); n != 1 {
+		t.Errorf("expected the token command to run once, ran %d times", n)
+	}
+}
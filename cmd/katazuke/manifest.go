@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/manifest"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// ExportCmd writes a manifest of every repository in the projects directory.
+type ExportCmd struct {
+	Output string `name:"output" short:"o" help:"Write the manifest to this file instead of stdout." default:""`
+}
+
+// Run executes the export command.
+func (c *ExportCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	_ = ml.LogCommand("export", nil)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+
+	scanRoot, err := groupRoot(projectsDir, globals.Group)
+	if err != nil {
+		return err
+	}
+
+	// Progress goes to stderr so stdout stays a clean manifest.
+	fmt.Fprintf(os.Stderr, "Scanning %s for repositories...\n", scanRoot)
+	repoPaths, err := scanner.Scan(scanRoot, scanner.Options{
+		ExcludePatterns: cfg.ExcludePatterns,
+		ExcludeRemotes:  cfg.ExcludeRemotes,
+		IncludePatterns: cfg.IncludePatterns,
+		MaxDepth:        cfg.ScanDepth,
+		Workers:         workersFor(cfg, parallel.LocalWork, 0),
+	})
+	if err != nil {
+		return fmt.Errorf("scanning repositories: %w", err)
+	}
+
+	scanStart := time.Now()
+	m, skipped, err := manifest.Build(projectsDir, repoPaths, workersFor(cfg, parallel.LocalWork, len(repoPaths)))
+	if err != nil {
+		return fmt.Errorf("building manifest: %w", err)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("encoding manifest: %w", err)
+	}
+
+	if c.Output == "" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(c.Output, data, 0o600)
+	}
+	if err != nil {
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+
+	warn := ui.Warn()
+	for _, p := range skipped {
+		fmt.Fprintf(os.Stderr, "  %s %s: no origin remote\n", warn.Sprint("[skip]"), filepath.Base(p))
+	}
+	dest := "stdout"
+	if c.Output != "" {
+		dest = c.Output
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d repositories to %s.\n", len(m.Repos), dest)
+	return nil
+}
+
+// ImportCmd re-clones the repositories listed in a manifest.
+type ImportCmd struct {
+	Manifest string `arg:"" help:"Manifest file produced by 'katazuke export'." type:"existingfile"`
+}
+
+// Run executes the import command.
+func (c *ImportCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	_ = ml.LogCommand("import", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+
+	m, err := manifest.Read(c.Manifest)
+	if err != nil {
+		return err
+	}
+	if len(m.Repos) == 0 {
+		fmt.Println("Manifest contains no repositories.")
+		return nil
+	}
+
+	workers := workersFor(cfg, parallel.NetworkWork, len(m.Repos))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Restoring %d repositories into %s...\n", len(m.Repos), projectsDir)
+
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	var cloned, existing, failed int
+	start := time.Now()
+	progress := newProgress()
+	_, err = manifest.Restore(projectsDir, m, globals.DryRun, workers, func(completed, total int, r manifest.RestoreResult) {
+		switch r.Status {
+		case manifest.Cloned:
+			cloned++
+			if r.Message != "" {
+				progress.Printf("  %s %s: %s", success.Sprint("[cloned]"), r.Repo.Path, r.Message)
+			} else {
+				progress.Printf("  %s %s", success.Sprint("[cloned]"), r.Repo.Path)
+			}
+		case manifest.Planned:
+			cloned++
+			progress.Printf("  %s %s: %s", dim.Sprint("[plan]"), r.Repo.Path, r.Message)
+		case manifest.Exists:
+			existing++
+		case manifest.Failed:
+			failed++
+			progress.Printf("  %s %s: %s", fail.Sprint("[fail]"), r.Repo.Path, r.Message)
+		}
+		if r.Repo.Stashes > 0 && r.Status != manifest.Exists {
+			progress.Printf("    %s", warn.Sprintf("had %d stash %s on the old machine (not restored)",
+				r.Repo.Stashes, pluralize(r.Repo.Stashes, "entry", "entries")))
+		}
+		progress.Update(completed, total)
+	})
+	progress.Stop()
+	if err != nil {
+		return err
+	}
+	_ = ml.LogPerf(len(m.Repos), int(time.Since(start).Milliseconds()))
+
+	fmt.Println()
+	verb := "Cloned"
+	if globals.DryRun {
+		verb = "Would clone"
+	}
+	fmt.Println(bold.Sprintf("%s %d, already present %d, failed %d", verb, cloned, existing, failed))
+	return nil
+}
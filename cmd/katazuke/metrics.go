@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// MetricsCmd inspects the local usage metrics store.
+type MetricsCmd struct {
+	Summary MetricsSummaryCmd `cmd:"" help:"Show metrics disk usage, retention settings, and cumulative cleanup impact."`
+	Export  MetricsExportCmd  `cmd:"" help:"Aggregate metrics into CSV or a Prometheus textfile for graphing."`
+}
+
+// MetricsSummaryCmd reports the metrics directory's disk footprint and
+// what katazuke has cleaned up so far.
+type MetricsSummaryCmd struct{}
+
+// Run executes the metrics summary command.
+func (c *MetricsSummaryCmd) Run(_ *CLI) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	dir, err := metrics.DefaultDir()
+	if err != nil {
+		return err
+	}
+	usage, err := metrics.DiskUsage(dir)
+	if err != nil {
+		return err
+	}
+
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	fmt.Printf("%s %s\n\n", bold.Sprint("Metrics directory:"), dir)
+	if len(usage.Files) == 0 {
+		fmt.Println("No metrics recorded yet.")
+	} else {
+		for _, f := range usage.Files {
+			note := ""
+			if f.Compressed {
+				note = dim.Sprint(" (gzip)")
+			}
+			fmt.Printf("  %s  %8s%s\n", f.Month.Format("2006-01"), formatSize(f.Size), note)
+		}
+		fmt.Printf("\n%s\n", bold.Sprintf("Total: %s across %d file(s)", formatSize(usage.TotalBytes), len(usage.Files)))
+	}
+
+	fmt.Printf("%s\n", dim.Sprintf("Retention: %s, size limit: %s",
+		limitLabel(cfg.Metrics.RetentionMonths, "months"),
+		limitLabel(cfg.Metrics.MaxTotalMB, "MB")))
+
+	impact, runs, err := metrics.TotalImpact(dir)
+	if err != nil {
+		return err
+	}
+	if runs > 0 {
+		fmt.Printf("\n%s\n", bold.Sprint("Cleanup impact"))
+		fmt.Printf("  %s\n", describeImpact(impact))
+		fmt.Printf("%s\n", dim.Sprintf("Across %d %s within retention.", runs, pluralize(runs, "run", "runs")))
+	}
+	return nil
+}
+
+func limitLabel(n int, unit string) string {
+	if n <= 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d %s", n, unit)
+}
+
+// metricsRetention converts the metrics config into a retention policy.
+func metricsRetention(cfg config.Config) metrics.Retention {
+	return metrics.Retention{
+		Months:   cfg.Metrics.RetentionMonths,
+		MaxBytes: int64(cfg.Metrics.MaxTotalMB) * 1024 * 1024,
+	}
+}
+
+// pruneMetrics enforces metrics retention once per invocation. Like all
+// metrics handling it is best-effort: failures are logged at debug level
+// and never affect the command being run. cfg is the config main loaded
+// for the run.
+func pruneMetrics(cfg config.Config) {
+	dir, err := metrics.DefaultDir()
+	if err != nil {
+		return
+	}
+	result, err := metrics.Prune(dir, metricsRetention(cfg), time.Now())
+	if err != nil {
+		slog.Debug("metrics retention failed", "error", err)
+		return
+	}
+	if len(result.Deleted) > 0 || len(result.Compressed) > 0 {
+		slog.Debug("metrics retention applied",
+			"deleted", len(result.Deleted), "compressed", len(result.Compressed))
+	}
+}
+
+// MetricsExportCmd aggregates the metrics files for graphing elsewhere.
+type MetricsExportCmd struct {
+	Format string `help:"Output format: csv (one row per month) or prom (Prometheus textfile collector)." enum:"csv,prom" default:"csv"`
+	Output string `name:"output" short:"o" help:"Write to this file instead of stdout. The file is replaced atomically, so a textfile collector never reads it half-written."`
+}
+
+// Run executes the metrics export command.
+func (c *MetricsExportCmd) Run(_ *CLI) error {
+	dir, err := metrics.DefaultDir()
+	if err != nil {
+		return err
+	}
+	months, err := metrics.Aggregate(dir)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if c.Format == "prom" {
+		err = metrics.WritePrometheus(&buf, months)
+	} else {
+		err = metrics.WriteCSV(&buf, months)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding metrics: %w", err)
+	}
+
+	if c.Output == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+	} else {
+		err = writeFileAtomic(c.Output, buf.Bytes())
+	}
+	if err != nil {
+		return fmt.Errorf("writing metrics: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, werr := tmp.Write(data)
+	if cerr := tmp.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		// CreateTemp makes the file 0600; a textfile collector running as
+		// another user needs to read it.
+		werr = os.Chmod(tmp.Name(), 0o644) // #nosec G302 - metrics export is meant to be shared
+	}
+	if werr == nil {
+		werr = os.Rename(tmp.Name(), path)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return werr
+}
+
+// submitMetrics sends new events to metrics.remote_url, asking for consent
+// the first time a URL is configured. Consent covers events recorded from
+// then on, not earlier history. Like all metrics handling it is
+// best-effort: failures are logged at debug level and retried next run.
+func submitMetrics(cfg config.Config) {
+	if cfg.Metrics.RemoteURL == "" {
+		return
+	}
+	dir, err := metrics.DefaultDir()
+	if err != nil {
+		return
+	}
+	st, err := metrics.LoadRemoteState(dir)
+	if err != nil {
+		slog.Debug("metrics submission skipped", "error", err)
+		return
+	}
+
+	if st.URL != cfg.Metrics.RemoteURL {
+		// Never record an answer the user did not give: without a
+		// terminal, or with --yes, ask again on a later run.
+		if assumeYes || !uiCaps.Interactive() {
+			return
+		}
+		consent, err := askMetricsConsent(cfg.Metrics.RemoteURL)
+		if err != nil {
+			return
+		}
+		st = metrics.RemoteState{URL: cfg.Metrics.RemoteURL, Consent: consent, SentUntil: time.Now()}
+		if err := metrics.SaveRemoteState(dir, st); err != nil {
+			slog.Debug("could not save metrics consent", "error", err)
+			return
+		}
+	}
+	if !st.Consent {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	sub := &metrics.Submitter{Dir: dir, URL: cfg.Metrics.RemoteURL, Token: cfg.Metrics.RemoteToken}
+	sent, err := sub.Submit(ctx)
+	if err != nil {
+		slog.Debug("metrics submission failed", "sent", sent, "error", err)
+		return
+	}
+	if sent > 0 {
+		slog.Debug("metrics submitted", "events", sent)
+	}
+}
+
+// askMetricsConsent asks whether events may be sent to url.
+func askMetricsConsent(url string) (bool, error) {
+	var consent bool
+	form := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Send anonymized katazuke usage metrics to %s?", url)).
+				Description("Your config sets metrics.remote_url. Only command names, flag names, counts,\n" +
+					"timings, and hashed fingerprints are sent, never repository or branch names.\n" +
+					"Events from now on are sent; remove remote_url to stop.").
+				Affirmative("Send").
+				Negative("Keep local").
+				Value(&consent),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return false, err
+	}
+	return consent, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// mineOnly is set by loadConfig when safety.mine_only (or --mine-only) is
+// on. The check is built on first use, so runs that delete nothing make
+// no GitHub call for the user's login.
+var mineOnly func() (*ownership, error)
+
+// setMineOnly enables the --mine-only check for cfg, or disables it.
+func setMineOnly(cfg config.Config) {
+	mineOnly = nil
+	if cfg.Safety.MineOnly {
+		mineOnly = sync.OnceValues(func() (*ownership, error) { return newOwnership(cfg) })
+	}
+}
+
+// currentOwnership returns the --mine-only check, or nil when every
+// branch and repository may be touched.
+func currentOwnership() (*ownership, error) {
+	if mineOnly == nil {
+		return nil, nil
+	}
+	return mineOnly()
+}
+
+// ownership decides which branches and checkouts are the user's own on a
+// machine shared with other people. Unlike the stale branch authorship
+// check it ignores each repository's user.email: on a shared account that
+// is as likely to be a colleague's as the user's.
+type ownership struct {
+	identity branches.Identity
+}
+
+// newOwnership returns the check for the emails in identity.emails and,
+// when a GitHub token is available, the noreply addresses of the user's
+// GitHub login.
+func newOwnership(cfg config.Config) (*ownership, error) {
+	id := branches.NewIdentity(cfg.Identity.Emails...)
+	login, err := newGitHubClient(cfg).CurrentUser()
+	if err != nil {
+		slog.Debug("could not look up GitHub login for --mine-only", "error", err)
+	}
+	id = id.WithLogin(login)
+	if id.Empty() {
+		return nil, errors.New("--mine-only needs identity.emails in the config, or a GitHub token to look up your login, to tell your work from other people's")
+	}
+	return &ownership{identity: id}, nil
+}
+
+// ownsBranch reports whether the branch's tip and every commit on it
+// since it diverged from the default branch were authored by the user.
+// Otherwise it also returns the other authors, for the skip message.
+func (o *ownership) ownsBranch(repoPath, branch string) (bool, []string) {
+	if o == nil {
+		return true, nil
+	}
+	var authors []string
+	if tip, err := git.CommitAuthor(repoPath, "refs/heads/"+branch); err == nil && tip != "" {
+		authors = append(authors, tip)
+	}
+	if base, err := git.DefaultBranch(repoPath); err == nil && base != branch {
+		unique, _ := git.CommitAuthors(repoPath, branch, base)
+		authors = append(authors, unique...)
+	}
+	if len(authors) == 0 {
+		return false, []string{"unknown authors"}
+	}
+	others := o.strangers(authors)
+	return len(others) == 0, others
+}
+
+// ownsRepo reports whether the user was the last to work in the checkout,
+// going by who last moved HEAD, or when the reflog is empty, by who
+// authored the checked out commit. Otherwise it also returns who that was.
+func (o *ownership) ownsRepo(repoPath string) (bool, string) {
+	if o == nil {
+		return true, ""
+	}
+	who, err := git.LastHeadUser(repoPath)
+	if err != nil || who == "" {
+		who, _ = git.CommitAuthor(repoPath, "HEAD")
+	}
+	if who == "" {
+		return false, "an unknown user"
+	}
+	return o.identity.Matches(who), who
+}
+
+// strangers returns the distinct emails in authors that are not the
+// user's.
+func (o *ownership) strangers(authors []string) []string {
+	var others []string
+	for _, a := range authors {
+		if !o.identity.Matches(a) && !containsFold(others, a) {
+			others = append(others, a)
+		}
+	}
+	return others
+}
+
+// containsFold reports whether list holds s, ignoring case.
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// keepMyBranches drops the branches in toDelete that are not the user's
+// under --mine-only, saying which and why.
+func keepMyBranches(toDelete []branchToDelete) ([]branchToDelete, error) {
+	o, err := currentOwnership()
+	if err != nil || o == nil {
+		return toDelete, err
+	}
+	warn := ui.Warn()
+	kept := make([]branchToDelete, 0, len(toDelete))
+	for _, b := range toDelete {
+		if mine, others := o.ownsBranch(b.repoPath, b.branch); !mine {
+			fmt.Printf("  %s %s: %s (not yours: commits by %s; --mine-only)\n",
+				warn.Sprint("[skip]"), b.repoName, b.branch, strings.Join(others, ", "))
+			continue
+		}
+		kept = append(kept, b)
+	}
+	return kept, nil
+}
+
+// keepMyRepos drops the archived repository actions for checkouts the
+// user did not last work in under --mine-only, saying which and why.
+func keepMyRepos(actions []archivedRepoAction) ([]archivedRepoAction, error) {
+	o, err := currentOwnership()
+	if err != nil || o == nil {
+		return actions, err
+	}
+	warn := ui.Warn()
+	kept := make([]archivedRepoAction, 0, len(actions))
+	for _, a := range actions {
+		if mine, who := o.ownsRepo(a.repo.Path); !mine {
+			fmt.Printf("  %s\n", warn.Sprintf("Skipping %s: last used by %s (--mine-only)", a.repo.Path, who))
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestOwnership(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "shared")
+	repo.CreateBranch("mine")
+	repo.WriteFile("mine.txt", "mine")
+	repo.AddFile("mine.txt")
+	repo.Commit("my work")
+	repo.Checkout("main")
+	repo.CreateBranch("theirs")
+	repo.Git("-c", "user.email=colleague@example.com", "commit", "--allow-empty", "-m", "their work")
+	repo.Checkout("main")
+
+	me := &ownership{identity: branches.NewIdentity("test@example.com")}
+	if mine, _ := me.ownsBranch(repo.Path, "mine"); !mine {
+		t.Error("expected mine to be owned")
+	}
+	mine, others := me.ownsBranch(repo.Path, "theirs")
+	if mine || len(others) != 1 || others[0] != "colleague@example.com" {
+		t.Errorf("expected theirs to belong to colleague@example.com, got %v %v", mine, others)
+	}
+	if mine, who := me.ownsRepo(repo.Path); !mine {
+		t.Errorf("expected the checkout to be owned, last used by %s", who)
+	}
+
+	colleague := &ownership{identity: branches.NewIdentity("colleague@example.com")}
+	if mine, who := colleague.ownsRepo(repo.Path); mine || who != "test@example.com" {
+		t.Errorf("expected the checkout to be last used by test@example.com, got %v %s", mine, who)
+	}
+
+	mineOnly = func() (*ownership, error) { return me, nil }
+	t.Cleanup(func() { mineOnly = nil })
+	kept, err := keepMyBranches([]branchToDelete{
+		{repoPath: repo.Path, repoName: "shared", branch: "mine"},
+		{repoPath: repo.Path, repoName: "shared", branch: "theirs"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kept) != 1 || kept[0].branch != "mine" {
+		t.Errorf("expected only mine to be kept, got %+v", kept)
+	}
+
+	var nobody *ownership
+	if mine, _ := nobody.ownsBranch(repo.Path, "theirs"); !mine {
+		t.Error("expected a nil check to allow every branch")
+	}
+}
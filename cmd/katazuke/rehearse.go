@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// rehearsal is what a --rehearse run would have done with the branches
+// the user picked, had it not been a rehearsal.
+type rehearsal struct {
+	deletes  []branchToDelete
+	remotes  []branchToDelete
+	archives []branchToDelete
+	// overThreshold is set when the deletions exceed
+	// safety.max_deletions_per_run, so the real run would ask for a typed
+	// confirmation or --force.
+	overThreshold bool
+}
+
+// planRehearsal works out which of toDelete and toArchive would be acted
+// on, applying the deletion threshold and the remote safety checks the
+// same way deleteBranches does.
+func planRehearsal(toDelete, toArchive []branchToDelete, deleteRemote bool, threshold int) rehearsal {
+	var r rehearsal
+	r.overThreshold = threshold > 0 && len(toDelete) > threshold
+	r.deletes = toDelete
+	r.archives = toArchive
+	if deleteRemote {
+		for _, b := range toDelete {
+			if b.hasRemote && b.canDeleteRemote {
+				r.remotes = append(r.remotes, b)
+			}
+		}
+	}
+	return r
+}
+
+// summary returns the one-line tally, e.g. "would delete 14 local and 9
+// remote branches".
+func (r rehearsal) summary() string {
+	var parts []string
+	if n := len(r.deletes); n > 0 {
+		part := fmt.Sprintf("delete %d local", n)
+		if m := len(r.remotes); m > 0 {
+			part += fmt.Sprintf(" and %d remote", m)
+			n = m
+		}
+		parts = append(parts, part+" "+pluralize(n, "branch", "branches"))
+	}
+	if n := len(r.archives); n > 0 {
+		parts = append(parts, fmt.Sprintf("archive %d %s", n, pluralize(n, "branch", "branches")))
+	}
+	if len(parts) == 0 {
+		return "would change nothing"
+	}
+	return "would " + strings.Join(parts, ", and ")
+}
+
+// printRehearsal lists what deleting toDelete (and their remotes, with
+// deleteRemote) and archiving toArchive would do, without doing it.
+// Branches --mine-only would skip are listed as skipped.
+func printRehearsal(toDelete, toArchive []branchToDelete, deleteRemote bool) error {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	muted := ui.Muted()
+
+	fmt.Println()
+	toDelete, err := keepMyBranches(toDelete)
+	if err != nil {
+		return err
+	}
+	if toArchive, err = keepMyBranches(toArchive); err != nil {
+		return err
+	}
+	r := planRehearsal(toDelete, toArchive, deleteRemote, deletionThreshold)
+	remote := make(map[branchToDelete]bool, len(r.remotes))
+	for _, b := range r.remotes {
+		remote[b] = true
+	}
+
+	for _, b := range r.archives {
+		tag := archiveTagName(b.branch)
+		if _, err := git.RevParse(b.repoPath, "refs/tags/"+tag); err == nil {
+			fmt.Printf("  %s %s: %s (tag %s already exists, would be kept)\n", warn.Sprint("[would skip]"), b.repoName, b.branch, tag)
+			continue
+		}
+		fmt.Printf("  %s %s: %s as %s\n", warn.Sprint("[would archive]"), b.repoName, b.branch, tag)
+	}
+	for _, b := range r.deletes {
+		line := fmt.Sprintf("  %s %s: %s", warn.Sprint("[would delete]"), b.repoName, b.branch)
+		if remote[b] {
+			line += muted.Sprintf(" (and %s/%s)", branchRemote(b), b.branch)
+		}
+		fmt.Println(line)
+	}
+	if r.overThreshold {
+		fmt.Printf("  %s\n", muted.Sprintf("More than safety.max_deletions_per_run (%d); the run would ask you to type a confirmation.", deletionThreshold))
+	}
+
+	fmt.Println()
+	fmt.Printf("%s %s. Nothing was changed.\n", bold.Sprint("Rehearsal:"), r.summary())
+	return nil
+}
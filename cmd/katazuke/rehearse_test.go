@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestPlanRehearsal(t *testing.T) {
+	own := branchToDelete{repoName: "api", branch: "feat-a", hasRemote: true, canDeleteRemote: true}
+	shared := branchToDelete{repoName: "api", branch: "feat-b", hasRemote: true}
+	local := branchToDelete{repoName: "web", branch: "spike"}
+	archive := branchToDelete{repoName: "web", branch: "old"}
+
+	tests := []struct {
+		name         string
+		toDelete     []branchToDelete
+		toArchive    []branchToDelete
+		deleteRemote bool
+		threshold    int
+		wantOver     bool
+		want         string
+	}{
+		{
+			name:     "local only",
+			toDelete: []branchToDelete{own, shared, local},
+			want:     "would delete 3 local branches",
+		},
+		{
+			name:         "remotes only where safe",
+			toDelete:     []branchToDelete{own, shared, local},
+			deleteRemote: true,
+			want:         "would delete 3 local and 1 remote branch",
+		},
+		{
+			name:         "with archives",
+			toDelete:     []branchToDelete{own},
+			toArchive:    []branchToDelete{archive},
+			deleteRemote: true,
+			want:         "would delete 1 local and 1 remote branch, and archive 1 branch",
+		},
+		{
+			name:         "over safety.max_deletions_per_run",
+			toDelete:     []branchToDelete{local, own, shared},
+			deleteRemote: true,
+			threshold:    1,
+			wantOver:     true,
+			want:         "would delete 3 local and 1 remote branch",
+		},
+		{
+			name: "nothing picked",
+			want: "would change nothing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := planRehearsal(tt.toDelete, tt.toArchive, tt.deleteRemote, tt.threshold)
+			if got := r.summary(); got != tt.want {
+				t.Errorf("summary() = %q, want %q", got, tt.want)
+			}
+			if r.overThreshold != tt.wantOver {
+				t.Errorf("overThreshold = %v, want %v", r.overThreshold, tt.wantOver)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// runOutdatedReleases lists repositories checked out at a release tag at
+// least --releases-behind releases (or a major version) behind the latest
+// release, and offers to check out the latest one.
+func (c *ReposCmd) runOutdatedReleases(globals *CLI) error {
+	if c.ReleasesBehind < 1 {
+		return fmt.Errorf("invalid --releases-behind %d (must be at least 1)", c.ReleasesBehind)
+	}
+
+	repoPaths, cfg, ml, err := c.loadRepos(globals)
+	if err != nil {
+		return err
+	}
+	if repoPaths == nil {
+		return nil
+	}
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if globals.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	flags = append(flags, fmt.Sprintf("--releases-behind=%d", c.ReleasesBehind))
+	_ = ml.LogCommand("repos --outdated-releases", flags)
+
+	workers := workersFor(*cfg, parallel.NetworkWork, len(repoPaths))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Checking %d repositories for outdated release checkouts...\n", len(repoPaths))
+
+	scanStart := time.Now()
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	outdated := repos.FindOutdatedReleases(repoPaths, newGitHubClient(*cfg), c.ReleasesBehind, workers, ex, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range outdated {
+		outdated[i].Name = groupedName(projectsDir, outdated[i].Path)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	printExplanations(ex)
+
+	if len(outdated) == 0 {
+		fmt.Println("No outdated release checkouts found.")
+		return nil
+	}
+	// Furthest behind first.
+	sort.Slice(outdated, func(i, j int) bool {
+		if outdated[i].Behind != outdated[j].Behind {
+			return outdated[i].Behind > outdated[j].Behind
+		}
+		return outdated[i].Name < outdated[j].Name
+	})
+	printOutdatedReleases(outdated)
+
+	if globals.DryRun {
+		bold := color.New(color.Bold)
+		fmt.Println(bold.Sprint("Dry run -- no changes made."))
+		return nil
+	}
+
+	return promptOutdatedReleases(outdated)
+}
+
+// printOutdatedReleases lists repositories checked out at an old release.
+func printOutdatedReleases(outdated []repos.OutdatedRelease) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) checked out at an outdated release:", len(outdated)))
+	for _, r := range outdated {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.Name), dim.Sprint(r.Path))
+		line := fmt.Sprintf("    at %s, latest %s (%s)", r.Current, r.Latest,
+			warn.Sprintf("%d %s behind", r.Behind, pluralize(r.Behind, "release", "releases")))
+		if r.Branch != "" {
+			line += " on branch " + r.Branch
+		}
+		if !r.IsClean {
+			line += ", " + warn.Sprint("uncommitted changes")
+		}
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+// promptOutdatedReleases asks, for each clean repository, whether to check
+// out the latest release. Repositories with uncommitted changes are left
+// as they are.
+func promptOutdatedReleases(outdated []repos.OutdatedRelease) error {
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+
+	for _, r := range outdated {
+		if !r.IsClean {
+			fmt.Printf("  %s %s: uncommitted changes, left at %s\n", warn.Sprint("[skip]"), r.Name, r.Current)
+			continue
+		}
+
+		title := fmt.Sprintf("%s: check out %s (now at %s)?", r.Name, r.Latest, r.Current)
+		if r.Branch != "" {
+			title = fmt.Sprintf("%s: check out %s (branch %s stays at %s)?", r.Name, r.Latest, r.Branch, r.Current)
+		}
+		update := false
+		err := newForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(title).
+					Affirmative("Update").
+					Negative("Leave").
+					Value(&update),
+			),
+		).Run()
+		if errors.Is(err, errNonInteractive) {
+			fmt.Printf("%s outdated checkouts left as is; run 'katazuke repos --outdated-releases' in a terminal to update them.\n", warn.Sprint("Note:"))
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+		if !update {
+			continue
+		}
+
+		if err := repos.UpdateRelease(r); err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+			continue
+		}
+		fmt.Printf("  %s %s: %s -> %s (back with: git checkout %s)\n", success.Sprint("[updated]"), r.Name, r.Current, r.Latest, r.Current)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// remotePRBranch is a closed pull request and whether its head branch is
+// left over on GitHub.
+type remotePRBranch struct {
+	pr       ghclient.PRBranch
+	leftOver bool
+}
+
+// runRemoteOnly lists the user's branches on GitHub whose pull requests
+// were merged or closed, whether or not the repository is cloned, and
+// offers to delete them from GitHub.
+func (c *BranchesCmd) runRemoteOnly(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Limit > 0 {
+		flags = append(flags, fmt.Sprintf("--limit=%d", c.Limit))
+	}
+	_ = ml.LogCommand("branches --remote-only", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	gh := newGitHubClient(cfg)
+	login, err := gh.CurrentUser()
+	if err != nil {
+		return fmt.Errorf("%w; listing your remote branches needs GitHub authentication (gh auth login or github_token)", err)
+	}
+
+	fmt.Printf("Searching closed pull requests by %s...\n", login)
+	prs, err := gh.ClosedPRs(login, cfg.GitHub.APIOrgsAllow)
+	if err != nil {
+		return err
+	}
+	leftOver := findLeftOverPRBranches(prs, gh, workersFor(cfg, parallel.NetworkWork, len(prs)))
+	if len(leftOver) == 0 {
+		fmt.Println("No branches left over from closed pull requests.")
+		return nil
+	}
+
+	leftOver = limitOldest(leftOver, c.Limit, "remote", func(pr ghclient.PRBranch) time.Time { return pr.ClosedAt })
+	printRemotePRBranches(leftOver)
+	if globals.DryRun {
+		return nil
+	}
+
+	selected, err := promptForRemotePRDeletion(leftOver)
+	if err != nil || len(selected) == 0 {
+		return err
+	}
+	return deleteRemotePRBranches(selected, gh, ol)
+}
+
+// findLeftOverPRBranches returns the head branches of prs that are left
+// over on GitHub, once per branch and sorted by repository, leaving out
+// protected branches. A pull request whose branch cannot be checked is
+// left out.
+func findLeftOverPRBranches(prs []ghclient.PRBranch, gh *ghclient.Client, workers int) []ghclient.PRBranch {
+	progress := newProgress()
+	results := parallel.Run(prs, workers, func(pr ghclient.PRBranch) remotePRBranch {
+		ok, err := gh.ResolvePRBranch(&pr)
+		if err != nil {
+			slog.Debug("could not check pull request branch", "pr", pr.URL, "error", err)
+		}
+		return remotePRBranch{pr: pr, leftOver: ok}
+	}, func(completed, total int, _ remotePRBranch) { progress.Update(completed, total) })
+	progress.Stop()
+
+	seen := make(map[string]bool)
+	var leftOver []ghclient.PRBranch
+	for _, r := range results {
+		if !r.leftOver || seen[r.pr.FullName()] || branches.IsProtectedBranch(r.pr.Branch) {
+			continue
+		}
+		seen[r.pr.FullName()] = true
+		leftOver = append(leftOver, r.pr)
+	}
+	sort.SliceStable(leftOver, func(i, j int) bool {
+		return strings.ToLower(leftOver[i].HeadOwner+"/"+leftOver[i].HeadRepo) < strings.ToLower(leftOver[j].HeadOwner+"/"+leftOver[j].HeadRepo)
+	})
+	return leftOver
+}
+
+// printRemotePRBranches lists branches left over from closed pull
+// requests, grouped by the repository holding them.
+func printRemotePRBranches(list []ghclient.PRBranch) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d branch(es) left over from closed pull requests:", len(list)))
+	currentRepo := ""
+	for _, pr := range list {
+		repo := pr.HeadOwner + "/" + pr.HeadRepo
+		if repo != currentRepo {
+			currentRepo = repo
+			fmt.Printf("  %s\n", bold.Sprint(repo))
+		}
+		fmt.Printf("    %s  %s\n", pr.Branch, dim.Sprintf("(%s)", remotePRSuffix(pr)))
+	}
+	fmt.Println()
+}
+
+// remotePRSuffix describes the pull request a left over branch came from,
+// e.g. "PR #12 merged Jan 2".
+func remotePRSuffix(pr ghclient.PRBranch) string {
+	ref := fmt.Sprintf("PR #%d", pr.Number)
+	if pr.Owner != pr.HeadOwner || pr.Repo != pr.HeadRepo {
+		ref = fmt.Sprintf("%s/%s#%d", pr.Owner, pr.Repo, pr.Number)
+	}
+	s := fmt.Sprintf("%s %s", ref, pr.State)
+	if !pr.ClosedAt.IsZero() {
+		s += " " + pr.ClosedAt.Format("Jan 2")
+	}
+	return s
+}
+
+// promptForRemotePRDeletion asks which left over branches to delete from
+// GitHub. None are selected to begin with, since there is no local copy
+// to recover them from.
+func promptForRemotePRDeletion(list []ghclient.PRBranch) ([]ghclient.PRBranch, error) {
+	options := make([]huh.Option[int], len(list))
+	for i, pr := range list {
+		options[i] = huh.NewOption(fmt.Sprintf("%s (%s)", pr.FullName(), remotePRSuffix(pr)), i)
+	}
+
+	var selectedIndices []int
+	form := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Select branches to delete from GitHub").
+				Options(options...).
+				Height(15).
+				Value(&selectedIndices),
+		),
+	)
+	if err := form.Run(); err != nil {
+		return nil, fmt.Errorf("prompt failed: %w", err)
+	}
+
+	selected := make([]ghclient.PRBranch, len(selectedIndices))
+	for i, idx := range selectedIndices {
+		selected[i] = list[idx]
+	}
+	return selected, nil
+}
+
+// deleteRemotePRBranches deletes branches from GitHub, subject to the
+// same limits and confirmations as other deletions, and logs each one
+// with its head commit so it can be pushed again.
+func deleteRemotePRBranches(selected []ghclient.PRBranch, gh *ghclient.Client, ol *oplog.Logger) error {
+	bold := color.New(color.Bold)
+	success := ui.Success()
+	fail := ui.Error()
+
+	names := make([]string, len(selected))
+	for i, pr := range selected {
+		names[i] = pr.FullName()
+	}
+	ok, err := confirmBulkDeletion(len(selected), "branches")
+	if err == nil && ok {
+		ok, err = confirmIrreversible(names, "remote branch", "remote branches")
+	}
+	if err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was deleted.")
+		}
+		return err
+	}
+
+	var failed []string
+	for _, pr := range selected {
+		if err := gh.DeleteBranch(pr.HeadOwner, pr.HeadRepo, pr.Branch); err != nil {
+			fmt.Printf("  %s %s (%v)\n", fail.Sprint("[fail]"), pr.FullName(), err)
+			failed = append(failed, pr.FullName())
+			continue
+		}
+		fmt.Printf("  %s %s\n", success.Sprint("[deleted]"), pr.FullName())
+		recordImpact(metrics.ImpactEvent{RemoteBranchesDeleted: 1})
+		_ = ol.Log(oplog.Operation{
+			Type:          oplog.OpDeleteBranch,
+			Branch:        pr.Branch,
+			CommitSHA:     pr.SHA,
+			RemoteURL:     fmt.Sprintf("https://github.com/%s/%s", pr.HeadOwner, pr.HeadRepo),
+			DeletedRemote: true,
+		})
+	}
+
+	fmt.Println()
+	if deleted := len(selected) - len(failed); deleted > 0 {
+		fmt.Println(bold.Sprintf("Deleted %d remote branch(es).", deleted))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d remote branch(es): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
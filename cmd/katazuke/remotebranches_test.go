@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+)
+
+func TestRemotePRSuffix(t *testing.T) {
+	pr := ghclient.PRBranch{
+		Owner: "acme", Repo: "app", Number: 12, State: ghclient.PRStateMerged,
+		ClosedAt:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		HeadOwner: "acme", HeadRepo: "app", Branch: "feature",
+	}
+	if got := remotePRSuffix(pr); got != "PR #12 merged Jan 2" {
+		t.Errorf("unexpected suffix %q", got)
+	}
+
+	// A branch in a fork names the repository the pull request went to.
+	pr.HeadOwner, pr.State, pr.ClosedAt = "me", ghclient.PRStateClosed, time.Time{}
+	if got := remotePRSuffix(pr); got != "acme/app#12 closed" {
+		t.Errorf("unexpected suffix %q", got)
+	}
+}
+
+func TestBranchesRemoteOnlyRejectsLocalOptions(t *testing.T) {
+	c := &BranchesCmd{RemoteOnly: true, Merged: true}
+	err := c.Run(&CLI{})
+	if err == nil || !strings.Contains(err.Error(), "--remote-only") {
+		t.Errorf("expected --remote-only to refuse --merged, got %v", err)
+	}
+}
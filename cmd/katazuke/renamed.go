@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// renameAction is what to do about a repository that moved on GitHub.
+type renameAction int
+
+const (
+	renameLeave renameAction = iota
+	renameRemote
+	renameRemoteAndDir
+)
+
+// runRenameDetect lists repositories whose GitHub remote was renamed or
+// transferred, and offers to point the remote at the new location and to
+// rename the checkout to match.
+func (c *ReposCmd) runRenameDetect(globals *CLI) error {
+	repoPaths, cfg, ml, err := c.loadRepos(globals)
+	if err != nil {
+		return err
+	}
+	if repoPaths == nil {
+		return nil
+	}
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if globals.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("repos --rename-detect", flags)
+
+	workers := workersFor(*cfg, parallel.NetworkWork, len(repoPaths))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Checking %d repositories for renamed or transferred GitHub remotes...\n", len(repoPaths))
+
+	scanStart := time.Now()
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	renamed := repos.FindRenamed(repoPaths, newGitHubClient(*cfg), workers, ex, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range renamed {
+		renamed[i].Name = groupedName(projectsDir, renamed[i].Path)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	printExplanations(ex)
+
+	if len(renamed) == 0 {
+		fmt.Println("No renamed or transferred repositories found.")
+		return nil
+	}
+	sort.Slice(renamed, func(i, j int) bool { return renamed[i].Name < renamed[j].Name })
+	printRenamedRepos(renamed)
+
+	if globals.DryRun {
+		bold := color.New(color.Bold)
+		fmt.Println(bold.Sprint("Dry run -- no changes made."))
+		return nil
+	}
+
+	for _, r := range renamed {
+		action, err := promptRenamed(r)
+		if errors.Is(err, errNonInteractive) {
+			fmt.Printf("%s remotes left as is; run 'katazuke repos --rename-detect' in a terminal to update them.\n", ui.Warn().Sprint("Note:"))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		applyRename(r, action, fsops.OS{}, ol)
+	}
+	return nil
+}
+
+// printRenamedRepos lists repositories that moved on GitHub.
+func printRenamedRepos(renamed []repos.RenamedRepo) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) whose GitHub remote moved:", len(renamed)))
+	for _, r := range renamed {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.Name), dim.Sprint(r.Path))
+		fmt.Printf("    %s -> %s\n", r.OldName(), warn.Sprint(r.NewName()))
+	}
+	fmt.Println()
+}
+
+// promptRenamed asks what to do about one repository that moved.
+func promptRenamed(r repos.RenamedRepo) (renameAction, error) {
+	options := []huh.Option[renameAction]{
+		huh.NewOption(fmt.Sprintf("Point %s at %s", r.Remote, r.NewName()), renameRemote),
+	}
+	if r.NewPath != "" {
+		options = append(options, huh.NewOption(fmt.Sprintf("Point %s at %s and rename the directory to %s", r.Remote, r.NewName(), r.NewRepo), renameRemoteAndDir))
+	}
+	options = append(options, huh.NewOption("Leave as is", renameLeave))
+
+	action := renameRemote
+	err := newForm(
+		huh.NewGroup(
+			huh.NewSelect[renameAction]().
+				Title(fmt.Sprintf("%s: %s is now %s", r.Name, r.OldName(), r.NewName())).
+				Options(options...).
+				Value(&action),
+		),
+	).Run()
+	if errors.Is(err, errNonInteractive) {
+		return renameLeave, err
+	}
+	if err != nil {
+		return renameLeave, fmt.Errorf("prompt failed: %w", err)
+	}
+	return action, nil
+}
+
+// applyRename updates a repository's remote and, when asked, renames its
+// directory, logging the move. A failed remote update leaves the directory
+// alone.
+func applyRename(r repos.RenamedRepo, action renameAction, fs fsops.FileOps, ol *oplog.Logger) {
+	success := ui.Success()
+	fail := ui.Error()
+
+	if action == renameLeave {
+		return
+	}
+	if err := repos.UpdateRemote(r); err != nil {
+		fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+		return
+	}
+	fmt.Printf("  %s %s: %s now points at %s\n", success.Sprint("[updated]"), r.Name, r.Remote, r.NewName())
+
+	if action != renameRemoteAndDir || r.NewPath == "" {
+		return
+	}
+	if err := fs.Move(r.Path, r.NewPath); err != nil {
+		fmt.Printf("  %s %s: could not rename directory: %v\n", fail.Sprint("[fail]"), r.Name, err)
+		return
+	}
+	_ = ol.Log(oplog.Operation{
+		Type:        oplog.OpMoveDir,
+		Path:        r.Path,
+		Destination: r.NewPath,
+		RemoteURL:   r.NewURL,
+	})
+	fmt.Printf("  %s %s -> %s\n", success.Sprint("[moved]"), r.Path, r.NewPath)
+}
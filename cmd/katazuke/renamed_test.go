@@ -0,0 +1,68 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestApplyRenameUpdatesRemoteAndLogsMove(t *testing.T) {
+	ol, err := oplog.NewWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ol.Close() }()
+
+	repo := helpers.NewTestRepo(t, "old-name")
+	repo.Git("remote", "add", "origin", "https://github.com/acme/old-name.git")
+	newPath := filepath.Join(filepath.Dir(repo.Path), "new-name")
+	r := repos.RenamedRepo{
+		Path:    repo.Path,
+		Name:    "old-name",
+		Remote:  "origin",
+		NewURL:  "https://github.com/acme/new-name.git",
+		NewPath: newPath,
+	}
+
+	fs := helpers.NewFSRecorder()
+	applyRename(r, renameRemoteAndDir, fs, ol)
+
+	if url, _ := git.RemoteURL(repo.Path, "origin"); url != r.NewURL {
+		t.Errorf("expected the remote to be updated, got %q", url)
+	}
+	if ops := fs.Ops(); len(ops) != 1 || ops[0].Kind != helpers.FSMove || ops[0].Dest != newPath {
+		t.Errorf("expected the directory to be moved to %s, got %v", newPath, ops)
+	}
+	logged, err := ol.ReadOps(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0].Type != oplog.OpMoveDir || logged[0].Destination != newPath {
+		t.Errorf("expected the move to be logged, got %+v", logged)
+	}
+}
+
+func TestApplyRenameRemoteOnlyKeepsDirectory(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "old-name")
+	repo.Git("remote", "add", "origin", "git@github.com:acme/old-name.git")
+	r := repos.RenamedRepo{
+		Path:    repo.Path,
+		Remote:  "origin",
+		NewURL:  "git@github.com:acme/new-name.git",
+		NewPath: filepath.Join(filepath.Dir(repo.Path), "new-name"),
+	}
+
+	fs := helpers.NewFSRecorder()
+	applyRename(r, renameRemote, fs, nil)
+	if ops := fs.Ops(); len(ops) != 0 {
+		t.Errorf("expected the directory to stay put, got %v", ops)
+	}
+	if url, _ := git.RemoteURL(repo.Path, "origin"); url != r.NewURL {
+		t.Errorf("expected the remote to be updated, got %q", url)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// backlogMarker identifies the issue a previous report created, so the
+// next run by the same GitHub user updates it instead of filing a
+// duplicate.
+const backlogMarker = "<!-- katazuke:cleanup-backlog -->"
+
+// ReportCmd writes the workspace cleanup backlog as a Markdown checklist.
+type ReportCmd struct {
+	CreateIssue string `name:"create-issue" help:"File the backlog as an issue in this GitHub repository, or update the one a previous run filed." placeholder:"OWNER/REPO"`
+	Title       string `help:"Title of the issue filed by --create-issue." default:"Workspace cleanup backlog"`
+	Pattern     string `name:"pattern" short:"f" help:"Filter repositories by name or group path (glob, e.g. '*kafka*' or 'work/*')."`
+}
+
+// Run executes the report command.
+func (c *ReportCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	var owner, repo string
+	if c.CreateIssue != "" {
+		var ok bool
+		owner, repo, ok = strings.Cut(c.CreateIssue, "/")
+		if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+			return fmt.Errorf("invalid --create-issue %q (expected OWNER/REPO)", c.CreateIssue)
+		}
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.CreateIssue != "" {
+		flags = append(flags, "--create-issue")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("report", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	auditCmd := &AuditCmd{Pattern: c.Pattern}
+	repos, isLocal, ok, err := auditCmd.resolveRepos(globals, cfg)
+	if err != nil || !ok {
+		return err
+	}
+	var projectsDir, scanRoot string
+	if !isLocal {
+		projectsDir = resolveProjectsDir(globals.ProjectsDir, cfg)
+		scanRoot, _ = groupRoot(projectsDir, globals.Group)
+	}
+
+	// The checklist goes to stdout on its own, so it can be piped.
+	fmt.Fprintf(os.Stderr, "Collecting the cleanup backlog of %d %s...\n", len(repos), pluralize(len(repos), "repository", "repositories"))
+	result, err := auditCmd.collectDashboard(cfg, repos, isLocal, projectsDir, scanRoot)
+	if err != nil {
+		return err
+	}
+	body := backlogMarkdown(result, time.Now())
+
+	if c.CreateIssue == "" {
+		fmt.Print(body)
+		return nil
+	}
+	if globals.DryRun {
+		fmt.Print(body)
+		fmt.Fprintf(os.Stderr, "Dry run -- would file or update %q in %s.\n", c.Title, c.CreateIssue)
+		return nil
+	}
+
+	gh := newGitHubClient(cfg)
+	login, err := gh.CurrentUser()
+	if err != nil {
+		return err
+	}
+	existing, err := gh.FindIssue(owner, repo, login, backlogMarker)
+	if err != nil {
+		return err
+	}
+	success := ui.Success()
+	if existing != nil {
+		issue, err := gh.UpdateIssue(owner, repo, existing.Number, c.Title, body)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s issue #%d: %s\n", success.Sprint("Updated"), issue.Number, issue.HTMLURL)
+		return nil
+	}
+	issue, err := gh.CreateIssue(owner, repo, c.Title, body)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s issue #%d: %s\n", success.Sprint("Created"), issue.Number, issue.HTMLURL)
+	return nil
+}
+
+// backlogMarkdown renders the audit result as a Markdown checklist, one
+// item per repository or directory that needs attention, grouped the way
+// the audit dashboard groups them.
+func backlogMarkdown(r audit.DashboardResult, now time.Time) string {
+	var b strings.Builder
+	b.WriteString(backlogMarker + "\n")
+	fmt.Fprintf(&b, "Cleanup backlog of %d %s, generated by katazuke on %s.\n",
+		r.RepoCount, pluralize(r.RepoCount, "repository", "repositories"), now.Format("2006-01-02"))
+
+	items := 0
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		sort.Strings(lines)
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "- [ ] %s\n", line)
+		}
+		items += len(lines)
+	}
+
+	buckets := audit.ReposByBucket(r.HealthDetails)
+	var repoLines []string
+	for _, h := range buckets.Conflicted {
+		repoLines = append(repoLines, fmt.Sprintf("**%s**: in the middle of a %s; finish or abort it", filepath.Base(h.Path), h.ConflictState))
+	}
+	for _, h := range buckets.Dirty {
+		repoLines = append(repoLines, fmt.Sprintf("**%s**: uncommitted changes", filepath.Base(h.Path)))
+	}
+	for _, h := range buckets.NonDefault {
+		switch {
+		case h.CurrentBranch == "":
+			repoLines = append(repoLines, fmt.Sprintf("**%s**: detached HEAD", filepath.Base(h.Path)))
+		case h.IsMergedBranch:
+			repoLines = append(repoLines, fmt.Sprintf("**%s**: on merged branch `%s` (`katazuke repos --merged`)", filepath.Base(h.Path), h.CurrentBranch))
+		default:
+			repoLines = append(repoLines, fmt.Sprintf("**%s**: on branch `%s`", filepath.Base(h.Path), h.CurrentBranch))
+		}
+	}
+	for _, h := range buckets.Behind {
+		repoLines = append(repoLines, fmt.Sprintf("**%s**: %d %s behind remote (`katazuke sync`)",
+			filepath.Base(h.Path), h.BehindRemote, pluralize(h.BehindRemote, "commit", "commits")))
+	}
+	section("Repositories", repoLines)
+
+	var branchLines []string
+	for _, rc := range r.Branches.MergedByRepo {
+		branchLines = append(branchLines, fmt.Sprintf("**%s**: %d merged %s (`katazuke branches --merged`)",
+			rc.RepoName, rc.Count, pluralize(rc.Count, "branch", "branches")))
+	}
+	for _, rc := range r.Branches.StaleByRepo {
+		branchLines = append(branchLines, fmt.Sprintf("**%s**: %d stale %s (`katazuke branches --stale --stale-days=%d`)",
+			rc.RepoName, rc.Count, pluralize(rc.Count, "branch", "branches"), r.StaleDays))
+	}
+	section("Branches", branchLines)
+
+	var dirLines []string
+	for _, d := range r.NonGitDirs {
+		line := fmt.Sprintf("**%s**: not a git repository, %s in %d %s", d.Name, formatSize(d.Size), d.FileCount, pluralize(d.FileCount, "file", "files"))
+		if d.Shadows != "" {
+			line += fmt.Sprintf(", possibly a copy of %s", groupedName(r.ProjectsDir, d.Shadows))
+		}
+		dirLines = append(dirLines, line+" (`katazuke audit --non-git`)")
+	}
+	section("Non-git directories", dirLines)
+
+	if items == 0 {
+		b.WriteString("\nNothing to clean up.\n")
+	}
+	return b.String()
+}
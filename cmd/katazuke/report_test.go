@@ -0,0 +1,50 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+)
+
+func TestBacklogMarkdown(t *testing.T) {
+	now := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	r := audit.DashboardResult{
+		RepoCount: 4,
+		StaleDays: 30,
+		HealthDetails: []audit.RepoHealth{
+			{Path: "/p/clean", IsClean: true, OnDefaultBranch: true},
+			{Path: "/p/dirty", OnDefaultBranch: true},
+			{Path: "/p/rebasing", ConflictState: "rebase"},
+			{Path: "/p/behind", IsClean: true, OnDefaultBranch: true, BehindRemote: 1},
+		},
+		Branches: audit.BranchSummary{
+			MergedByRepo: []audit.RepoBranchCount{{RepoName: "api", Count: 2}},
+			StaleByRepo:  []audit.RepoBranchCount{{RepoName: "web", Count: 1}},
+		},
+		NonGitDirs: []audit.NonRepoDir{{Name: "scratch", Size: 2048, FileCount: 3}},
+	}
+
+	got := backlogMarkdown(r, now)
+	for _, want := range []string{
+		backlogMarker,
+		"Cleanup backlog of 4 repositories, generated by katazuke on 2026-03-14.",
+		"### Repositories\n\n- [ ] **behind**: 1 commit behind remote (`katazuke sync`)\n- [ ] **dirty**: uncommitted changes\n- [ ] **rebasing**: in the middle of a rebase; finish or abort it\n",
+		"- [ ] **api**: 2 merged branches (`katazuke branches --merged`)",
+		"- [ ] **web**: 1 stale branch (`katazuke branches --stale --stale-days=30`)",
+		"- [ ] **scratch**: not a git repository, 2.0 KB in 3 files",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("backlog missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "**clean**") {
+		t.Errorf("clean repo should not be listed:\n%s", got)
+	}
+
+	empty := backlogMarkdown(audit.DashboardResult{RepoCount: 1}, now)
+	if !strings.Contains(empty, "Nothing to clean up.") || strings.Contains(empty, "- [ ]") {
+		t.Errorf("expected an empty backlog, got:\n%s", empty)
+	}
+}
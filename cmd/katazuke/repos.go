@@ -3,26 +3,43 @@ package main
 import (
 	"fmt"
 	"log/slog"
-	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
 	"github.com/fatih/color"
 
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/backup"
 	"github.com/agrahamlincoln/katazuke/internal/config"
-	"github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+	"github.com/agrahamlincoln/katazuke/internal/ignore"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/metrics"
 	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/internal/repos"
 	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
 // ReposCmd handles repository checkout management.
 type ReposCmd struct {
-	Archived bool `help:"Show only archived repositories." xor:"mode"`
-	Merged   bool `help:"Show only repos on merged branches." xor:"mode"`
+	Archived         bool   `help:"Show only archived repositories." xor:"mode"`
+	Merged           bool   `help:"Show only repos on merged branches." xor:"mode"`
+	Unpushed         bool   `help:"Show repos with local-only work (unpushed commits, stashes, uncommitted changes)." xor:"mode"`
+	RemoteHead       bool   `help:"Find repos whose origin/HEAD is missing or stale after a default branch rename, and repair them. Queries every remote." xor:"mode"`
+	ForgottenWIP     bool   `name:"forgotten-wip" help:"Find uncommitted changes untouched for --wip-days, and stash them, commit them to a WIP branch, or park them and switch to the default branch." xor:"mode"`
+	WIPDays          int    `name:"wip-days" help:"Days since changed files were last modified before --forgotten-wip reports them (0 for every dirty repo)." default:"14"`
+	OutdatedReleases bool   `name:"outdated-releases" help:"Find repos checked out at a release tag far behind the latest GitHub release or tag, and offer to check out the latest." xor:"mode"`
+	ReleasesBehind   int    `name:"releases-behind" help:"Releases a checkout must trail the latest by before --outdated-releases reports it (a major version behind always counts)." default:"3"`
+	RenameDetect     bool   `name:"rename-detect" help:"Find repos whose GitHub remote was renamed or transferred, and offer to update the remote URL and rename the directory to match." xor:"mode"`
+	Explain          bool   `help:"Explain why each repository was reported or skipped."`
+	Pattern          string `name:"pattern" short:"f" help:"Filter repositories by name or group path (glob, e.g. '*kafka*' or 'work/*')."`
+	Sort             string `help:"Order repository lists and prompts by age (oldest HEAD commit first), repo, ahead (most unpushed commits first), or size (largest on disk first)." placeholder:"ORDER"`
 }
 
 // Run executes the repos command.
@@ -30,6 +47,9 @@ func (c *ReposCmd) Run(globals *CLI) error {
 	if globals.Verbose {
 		enableVerboseLogging()
 	}
+	if err := validateSort(c.Sort); err != nil {
+		return err
+	}
 
 	if c.Archived {
 		return c.runArchived(globals)
@@ -37,6 +57,21 @@ func (c *ReposCmd) Run(globals *CLI) error {
 	if c.Merged {
 		return c.runMerged(globals)
 	}
+	if c.Unpushed {
+		return c.runUnpushed(globals)
+	}
+	if c.RemoteHead {
+		return c.runRemoteHead(globals)
+	}
+	if c.ForgottenWIP {
+		return c.runForgottenWIP(globals)
+	}
+	if c.OutdatedReleases {
+		return c.runOutdatedReleases(globals)
+	}
+	if c.RenameDetect {
+		return c.runRenameDetect(globals)
+	}
 
 	// No flags: show summary + all issue types.
 	return c.runAll(globals)
@@ -45,7 +80,7 @@ func (c *ReposCmd) Run(globals *CLI) error {
 func (c *ReposCmd) loadRepos(globals *CLI) ([]string, *config.Config, *metrics.Logger, error) {
 	ml := metrics.NewOrNil()
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		_ = ml.Close()
 		return nil, nil, nil, fmt.Errorf("loading config: %w", err)
@@ -53,10 +88,20 @@ func (c *ReposCmd) loadRepos(globals *CLI) ([]string, *config.Config, *metrics.L
 
 	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
 
-	fmt.Printf("Scanning %s for repositories...\n", projectsDir)
+	scanRoot, err := groupRoot(projectsDir, globals.Group)
+	if err != nil {
+		_ = ml.Close()
+		return nil, nil, nil, err
+	}
 
-	repoPaths, err := scanner.Scan(projectsDir, scanner.Options{
+	fmt.Printf("Scanning %s for repositories...\n", scanRoot)
+
+	repoPaths, err := scanner.Scan(scanRoot, scanner.Options{
 		ExcludePatterns: cfg.ExcludePatterns,
+		ExcludeRemotes:  cfg.ExcludeRemotes,
+		IncludePatterns: cfg.IncludePatterns,
+		MaxDepth:        cfg.ScanDepth,
+		Workers:         workersFor(cfg, parallel.LocalWork, 0),
 	})
 	if err != nil {
 		_ = ml.Close()
@@ -69,6 +114,22 @@ func (c *ReposCmd) loadRepos(globals *CLI) ([]string, *config.Config, *metrics.L
 		return nil, nil, nil, nil
 	}
 
+	if c.Pattern != "" {
+		repoPaths, err = filterByPattern(repoPaths, c.Pattern, projectsDir)
+		if err != nil {
+			_ = ml.Close()
+			return nil, nil, nil, err
+		}
+		if len(repoPaths) == 0 {
+			fmt.Printf("No repositories matching %q found.\n", c.Pattern)
+			_ = ml.Close()
+			return nil, nil, nil, nil
+		}
+	}
+
+	il := loadIgnoreList()
+	repoPaths = withoutIgnored(repoPaths, il.HasRepo, "repository", "repositories")
+
 	slog.Debug("found repositories", "count", len(repoPaths))
 	return repoPaths, &cfg, ml, nil
 }
@@ -92,17 +153,33 @@ func (c *ReposCmd) runAll(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
 	_ = ml.LogCommand("repos", flags)
 
+	bk, err := newBackupStore(*cfg)
+	if err != nil {
+		return err
+	}
+
 	bold := color.New(color.Bold)
-	workers := cfg.Workers
+	workers := workersFor(*cfg, parallel.LocalWork, len(repoPaths))
 	slog.Debug("using worker pool", "workers", workers)
 
 	scanStart := time.Now()
 
 	// Repository summary.
 	fmt.Printf("Summarizing %d repositories...\n", len(repoPaths))
-	summary := repos.Summarize(repoPaths, workers, progressPrinter())
+	progress := newProgress()
+	summary := repos.Summarize(repoPaths, workers, progress.Update)
+	progress.Stop()
 	fmt.Printf("\n%s\n", bold.Sprint("Repository Summary"))
 	fmt.Printf("  Total: %d\n", summary.Total)
 	fmt.Printf("  Clean: %d\n", summary.Clean)
@@ -110,24 +187,37 @@ func (c *ReposCmd) runAll(globals *CLI) error {
 	fmt.Println()
 
 	// Find merged branch repos.
-	ghClient := github.NewClient(cfg.GithubToken)
+	ghClient := newGitHubClient(*cfg)
 	detector := merge.NewDetector(merge.RealGitChecker{}, ghClient)
+	ex := newExplainLog(c.Explain)
 	fmt.Printf("Checking for repos on merged branches...\n")
-	mergedRepos := repos.FindOnMergedBranch(repoPaths, detector, workers, progressPrinter())
+	progress = newProgress()
+	mergedRepos := repos.FindOnMergedBranch(repoPaths, detector, workers, ex, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range mergedRepos {
+		mergedRepos[i].Name = groupedName(projectsDir, mergedRepos[i].Path)
+	}
 
 	// Find archived repos.
 	fmt.Printf("Checking archive status...\n")
-	archived := repos.FindArchived(repoPaths, ghClient, workers, progressPrinter())
+	progress = newProgress()
+	archived := repos.FindArchived(repoPaths, ghClient, workersFor(*cfg, parallel.NetworkWork, len(repoPaths)), ex, progress.Update)
+	progress.Stop()
+	c.sortMergedRepos(mergedRepos)
+	c.sortArchivedRepos(archived)
 
 	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
 
+	printExplanations(ex)
+
 	hasIssues := false
 
 	if len(mergedRepos) > 0 {
 		hasIssues = true
 		printMergedRepos(mergedRepos)
 		if !globals.DryRun {
-			if err := promptMergedRepoActions(mergedRepos, ml, ol); err != nil {
+			if err := promptMergedRepoActions(mergedRepos, bk, loadIgnoreList(), ml, ol); err != nil {
 				return err
 			}
 		}
@@ -137,7 +227,7 @@ func (c *ReposCmd) runAll(globals *CLI) error {
 		hasIssues = true
 		printArchivedRepos(archived)
 		if !globals.DryRun {
-			if err := promptArchivedRepoActions(archived, ml, ol); err != nil {
+			if err := promptArchivedRepoActions(archived, resolveProjectsDir(globals.ProjectsDir, *cfg), fsops.OS{}, bk, loadIgnoreList(), ml, ol); err != nil {
 				return err
 			}
 		}
@@ -173,24 +263,48 @@ func (c *ReposCmd) runMerged(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
 	_ = ml.LogCommand("repos --merged", flags)
 
-	workers := cfg.Workers
+	bk, err := newBackupStore(*cfg)
+	if err != nil {
+		return err
+	}
+
+	workers := workersFor(*cfg, parallel.LocalWork, len(repoPaths))
 	slog.Debug("using worker pool", "workers", workers)
 	fmt.Printf("Checking %d repositories for merged branches...\n", len(repoPaths))
 
-	ghClient := github.NewClient(cfg.GithubToken)
+	ghClient := newGitHubClient(*cfg)
 	detector := merge.NewDetector(merge.RealGitChecker{}, ghClient)
 
 	scanStart := time.Now()
-	mergedRepos := repos.FindOnMergedBranch(repoPaths, detector, workers, progressPrinter())
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	mergedRepos := repos.FindOnMergedBranch(repoPaths, detector, workers, ex, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range mergedRepos {
+		mergedRepos[i].Name = groupedName(projectsDir, mergedRepos[i].Path)
+	}
 	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
 
+	printExplanations(ex)
+
 	if len(mergedRepos) == 0 {
 		fmt.Println("No repositories are on merged branches.")
 		return nil
 	}
 
+	c.sortMergedRepos(mergedRepos)
 	printMergedRepos(mergedRepos)
 
 	if globals.DryRun {
@@ -199,7 +313,7 @@ func (c *ReposCmd) runMerged(globals *CLI) error {
 		return nil
 	}
 
-	return promptMergedRepoActions(mergedRepos, ml, ol)
+	return promptMergedRepoActions(mergedRepos, bk, loadIgnoreList(), ml, ol)
 }
 
 func (c *ReposCmd) runArchived(globals *CLI) error {
@@ -221,24 +335,44 @@ func (c *ReposCmd) runArchived(globals *CLI) error {
 	if globals.Verbose {
 		flags = append(flags, "--verbose")
 	}
+	if c.Explain {
+		flags = append(flags, "--explain")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
 	_ = ml.LogCommand("repos --archived", flags)
 
-	workers := cfg.Workers
+	bk, err := newBackupStore(*cfg)
+	if err != nil {
+		return err
+	}
+
+	workers := workersFor(*cfg, parallel.NetworkWork, len(repoPaths))
 	slog.Debug("using worker pool", "workers", workers)
 
 	scanStart := time.Now()
-	ghClient := github.NewClient(cfg.GithubToken)
+	ghClient := newGitHubClient(*cfg)
 
 	fmt.Printf("Checking archive status of %d repositories...\n", len(repoPaths))
 
-	archived := repos.FindArchived(repoPaths, ghClient, workers, progressPrinter())
+	ex := newExplainLog(c.Explain)
+	progress := newProgress()
+	archived := repos.FindArchived(repoPaths, ghClient, workers, ex, progress.Update)
+	progress.Stop()
 	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
 
+	printExplanations(ex)
+
 	if len(archived) == 0 {
 		fmt.Println("No archived repositories found.")
 		return nil
 	}
 
+	c.sortArchivedRepos(archived)
 	printArchivedRepos(archived)
 
 	if globals.DryRun {
@@ -247,13 +381,208 @@ func (c *ReposCmd) runArchived(globals *CLI) error {
 		return nil
 	}
 
-	return promptArchivedRepoActions(archived, ml, ol)
+	return promptArchivedRepoActions(archived, resolveProjectsDir(globals.ProjectsDir, *cfg), fsops.OS{}, bk, loadIgnoreList(), ml, ol)
+}
+
+func (c *ReposCmd) runUnpushed(globals *CLI) error {
+	repoPaths, cfg, ml, err := c.loadRepos(globals)
+	if err != nil {
+		return err
+	}
+	if repoPaths == nil {
+		return nil
+	}
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	if c.Sort != "" {
+		flags = append(flags, "--sort="+c.Sort)
+	}
+	_ = ml.LogCommand("repos --unpushed", flags)
+
+	workers := workersFor(*cfg, parallel.LocalWork, len(repoPaths))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Checking %d repositories for local-only work...\n", len(repoPaths))
+
+	scanStart := time.Now()
+	progress := newProgress()
+	unpushed := repos.FindUnpushed(repoPaths, workers, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range unpushed {
+		unpushed[i].Name = groupedName(projectsDir, unpushed[i].Path)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(unpushed) == 0 {
+		fmt.Println("No local-only work found. Everything is pushed.")
+		return nil
+	}
+
+	if c.Sort == "" {
+		sort.Slice(unpushed, func(i, j int) bool { return unpushed[i].Name < unpushed[j].Name })
+	}
+	sortRepos(unpushed, c.Sort, func(r repos.UnpushedRepo) string { return r.Name }, func(r repos.UnpushedRepo) string { return r.Path })
+	printUnpushedRepos(unpushed)
+	return nil
+}
+
+func (c *ReposCmd) runRemoteHead(globals *CLI) error {
+	repoPaths, cfg, ml, err := c.loadRepos(globals)
+	if err != nil {
+		return err
+	}
+	if repoPaths == nil {
+		return nil
+	}
+	defer func() { _ = ml.Close() }()
+
+	var flags []string
+	if globals.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	_ = ml.LogCommand("repos --remote-head", flags)
+
+	workers := workersFor(*cfg, parallel.NetworkWork, len(repoPaths))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Checking the remote HEAD of %d repositories...\n", len(repoPaths))
+
+	scanStart := time.Now()
+	progress := newProgress()
+	stale := repos.FindStaleRemoteHeads(repoPaths, workers, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range stale {
+		stale[i].Name = groupedName(projectsDir, stale[i].Path)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(stale) == 0 {
+		fmt.Println("Every remote HEAD matches its remote.")
+		return nil
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].Name < stale[j].Name })
+	printRemoteHeads(stale)
+
+	bold := color.New(color.Bold)
+	if globals.DryRun {
+		fmt.Println(bold.Sprint("Dry run -- no changes made."))
+		return nil
+	}
+
+	repair := true
+	err = newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Repair %d remote %s?", len(stale), pluralize(len(stale), "HEAD", "HEADs"))).
+				Value(&repair),
+		),
+	).Run()
+	if err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+	if !repair {
+		return nil
+	}
+
+	success := ui.Success()
+	fail := ui.Error()
+	repaired := 0
+	for _, r := range stale {
+		if err := repos.RepairRemoteHead(r); err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+			continue
+		}
+		repaired++
+		fmt.Printf("  %s %s: %s/HEAD -> %s/%s\n", success.Sprint("[fixed]"), r.Name, r.Remote, r.Remote, r.Actual)
+	}
+	fmt.Printf("\n%s\n", bold.Sprintf("Repaired %d of %d.", repaired, len(stale)))
+	return nil
+}
+
+// printRemoteHeads lists repositories whose remote HEAD symref is stale.
+func printRemoteHeads(stale []repos.RemoteHeadRepo) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) with a stale remote HEAD:", len(stale)))
+	for _, r := range stale {
+		var problem string
+		switch {
+		case r.Recorded == "":
+			problem = fmt.Sprintf("%s/HEAD is not set", r.Remote)
+		case r.Dangling:
+			problem = fmt.Sprintf("%s/HEAD points to deleted branch %s", r.Remote, r.Recorded)
+		default:
+			problem = fmt.Sprintf("%s/HEAD points to %s", r.Remote, r.Recorded)
+		}
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.Name), dim.Sprint(r.Path))
+		fmt.Printf("    %s, remote default is %s\n", warn.Sprint(problem), r.Actual)
+	}
+	fmt.Println()
+}
+
+// sortMergedRepos orders repositories on merged branches for --sort.
+func (c *ReposCmd) sortMergedRepos(mergedRepos []repos.MergedBranchRepo) {
+	sortRepos(mergedRepos, c.Sort, func(r repos.MergedBranchRepo) string { return r.Name }, func(r repos.MergedBranchRepo) string { return r.Path })
+}
+
+// sortArchivedRepos orders archived repositories for --sort.
+func (c *ReposCmd) sortArchivedRepos(archived []repos.ArchivedRepo) {
+	sortRepos(archived, c.Sort, func(r repos.ArchivedRepo) string { return r.Name }, func(r repos.ArchivedRepo) string { return r.Path })
+}
+
+func printUnpushedRepos(unpushed []repos.UnpushedRepo) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) with local-only work:", len(unpushed)))
+
+	for _, r := range unpushed {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.Name), dim.Sprint(r.Path))
+		for _, b := range r.Branches {
+			note := ""
+			switch {
+			case !b.HasUpstream:
+				note = dim.Sprint(" (never pushed)")
+			case b.Behind > 0:
+				note = dim.Sprintf(" (%d behind upstream)", b.Behind)
+			}
+			fmt.Printf("    %s  %s%s\n", b.Name, warn.Sprintf("%d unpushed %s", b.Commits, pluralize(b.Commits, "commit", "commits")), note)
+		}
+		if r.Stashes > 0 {
+			fmt.Printf("    %s\n", warn.Sprintf("%d stash %s", r.Stashes, pluralize(r.Stashes, "entry", "entries")))
+		}
+		if !r.IsClean {
+			fmt.Printf("    %s\n", warn.Sprint("uncommitted changes"))
+		}
+	}
+	fmt.Println()
+}
+
+// pluralize returns singular when n is 1 and plural otherwise.
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return singular
+	}
+	return plural
 }
 
 func printMergedRepos(mergedRepos []repos.MergedBranchRepo) {
 	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
-	yellow := color.New(color.FgYellow)
+	success := ui.Success()
+	warn := ui.Warn()
 
 	fmt.Printf("%s\n\n", bold.Sprintf("Found %d repo(s) on merged branches:", len(mergedRepos)))
 
@@ -261,15 +590,15 @@ func printMergedRepos(mergedRepos []repos.MergedBranchRepo) {
 		fmt.Printf("  %s\n", bold.Sprint(r.Name))
 		fmt.Printf("    Branch: %s (merged into %s)\n", r.CurrentBranch, r.DefaultBranch)
 		if r.IsClean {
-			fmt.Printf("    %s\n", green.Sprint("Status: clean (safe to switch)"))
+			fmt.Printf("    %s\n", success.Sprint("Status: clean (safe to switch)"))
 		} else {
-			fmt.Printf("    %s\n", yellow.Sprint("Status: dirty working tree"))
+			fmt.Printf("    %s\n", warn.Sprint("Status: dirty working tree"))
 		}
 	}
 	fmt.Println()
 }
 
-func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.Logger, ol *oplog.Logger) error {
+func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, bk *backup.Store, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger) error {
 	// Filter to only switchable repos (clean working tree).
 	var switchable []repos.MergedBranchRepo
 	for _, r := range mergedRepos {
@@ -289,7 +618,7 @@ func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.L
 	}
 
 	var selected []string
-	err := huh.NewForm(
+	err := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
 				Title("Select repos to switch to default branch").
@@ -307,10 +636,17 @@ func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.L
 	}
 
 	// Log suggestions.
+	var passed []ignoreCandidate
 	for _, r := range switchable {
 		accepted := selectedSet[r.Path]
 		fp := repoFingerprint(r.Path)
 		_ = ml.LogSuggestion("switch_merged_branch_repo", fp, accepted, 0)
+		if !accepted {
+			passed = append(passed, repoIgnoreCandidate(r.Name, r.Path))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
 	}
 
 	if len(selected) == 0 {
@@ -320,7 +656,7 @@ func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.L
 
 	// Ask whether to also delete the old branch.
 	var deleteBranch bool
-	err = huh.NewForm(
+	err = newForm(
 		huh.NewGroup(
 			huh.NewConfirm().
 				Title("Also delete the merged branch after switching?").
@@ -332,8 +668,8 @@ func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.L
 	}
 
 	bold := color.New(color.Bold)
-	green := color.New(color.FgGreen)
-	red := color.New(color.FgRed)
+	success := ui.Success()
+	fail := ui.Error()
 	switched := 0
 
 	for _, r := range switchable {
@@ -341,46 +677,64 @@ func promptMergedRepoActions(mergedRepos []repos.MergedBranchRepo, ml *metrics.L
 			continue
 		}
 
-		slog.Debug("switching to default branch", "repo", r.Name, "from", r.CurrentBranch, "to", r.DefaultBranch)
-		if err := git.Checkout(r.Path, r.DefaultBranch); err != nil {
-			fmt.Printf("  %s\n", red.Sprintf("Failed to switch %s: %v", r.Name, err))
+		if err := switchToDefault(r, ol); err != nil {
+			fmt.Printf("  %s\n", fail.Sprintf("Failed to switch %s: %v", r.Name, err))
 			continue
 		}
-		_ = ol.Log(oplog.Operation{
-			Type:           oplog.OpSwitchBranch,
-			RepoPath:       r.Path,
-			Branch:         r.DefaultBranch,
-			PreviousBranch: r.CurrentBranch,
-		})
-		fmt.Printf("  %s\n", green.Sprintf("Switched %s to %s", r.Name, r.DefaultBranch))
+		fmt.Printf("  %s\n", success.Sprintf("Switched %s to %s", r.Name, r.DefaultBranch))
 		switched++
 
 		if deleteBranch {
 			sha, _ := git.RevParse(r.Path, r.CurrentBranch)
-			remoteURL, _ := git.RemoteURL(r.Path, "origin")
+			remoteURL, _ := git.RemoteURL(r.Path, git.PrimaryRemote(r.Path))
+			bundlePath, err := bk.BundleBranch(r.Path, r.CurrentBranch)
+			if err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Not deleting branch %s in %s: %v", r.CurrentBranch, r.Name, err))
+				continue
+			}
 			if err := git.DeleteLocalBranch(r.Path, r.CurrentBranch, false); err != nil {
-				fmt.Printf("  %s\n", red.Sprintf("Failed to delete branch %s in %s: %v", r.CurrentBranch, r.Name, err))
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to delete branch %s in %s: %v", r.CurrentBranch, r.Name, err))
 			} else {
 				_ = ol.Log(oplog.Operation{
-					Type:      oplog.OpDeleteBranch,
-					RepoPath:  r.Path,
-					Branch:    r.CurrentBranch,
-					CommitSHA: sha,
-					RemoteURL: remoteURL,
+					Type:        oplog.OpDeleteBranch,
+					RepoPath:    r.Path,
+					Branch:      r.CurrentBranch,
+					CommitSHA:   sha,
+					RemoteURL:   remoteURL,
+					Destination: bundlePath,
 				})
-				fmt.Printf("  %s\n", green.Sprintf("Deleted branch %s in %s", r.CurrentBranch, r.Name))
+				fmt.Printf("  %s\n", success.Sprintf("Deleted branch %s in %s", r.CurrentBranch, r.Name))
 			}
 		}
 	}
 
 	fmt.Printf("\n%s\n", bold.Sprintf("Switched %d repo(s) to default branch.", switched))
+	if deleteBranch {
+		printBackupNote(bk)
+	}
+	return nil
+}
+
+// switchToDefault checks out the default branch of a repository that is
+// on a merged branch and logs the switch so it can be undone.
+func switchToDefault(r repos.MergedBranchRepo, ol *oplog.Logger) error {
+	slog.Debug("switching to default branch", "repo", r.Name, "from", r.CurrentBranch, "to", r.DefaultBranch)
+	if err := git.Checkout(r.Path, r.DefaultBranch); err != nil {
+		return err
+	}
+	_ = ol.Log(oplog.Operation{
+		Type:           oplog.OpSwitchBranch,
+		RepoPath:       r.Path,
+		Branch:         r.DefaultBranch,
+		PreviousBranch: r.CurrentBranch,
+	})
 	return nil
 }
 
 func printArchivedRepos(archived []repos.ArchivedRepo) {
 	bold := color.New(color.Bold)
-	yellow := color.New(color.FgYellow)
-	green := color.New(color.FgGreen)
+	warn := ui.Warn()
+	success := ui.Success()
 
 	fmt.Printf("%s\n\n", bold.Sprintf("Found %d archived repo(s):", len(archived)))
 
@@ -388,45 +742,57 @@ func printArchivedRepos(archived []repos.ArchivedRepo) {
 		fmt.Printf("  %s/%s\n", r.Owner, r.Repo)
 		fmt.Printf("    Path: %s\n", r.Path)
 		if r.IsClean {
-			fmt.Printf("    %s\n", green.Sprint("Status: clean working tree"))
+			fmt.Printf("    %s\n", success.Sprint("Status: clean working tree"))
 		} else {
-			fmt.Printf("    %s\n", yellow.Sprint("Status: uncommitted changes (will not be removed)"))
+			fmt.Printf("    %s\n", warn.Sprint("Status: uncommitted changes (can only be moved to .archive/)"))
 		}
 	}
 	fmt.Println()
 }
 
-func promptArchivedRepoActions(archived []repos.ArchivedRepo, ml *metrics.Logger, ol *oplog.Logger) error {
-	red := color.New(color.FgRed)
-	green := color.New(color.FgGreen)
-	bold := color.New(color.Bold)
+// Actions offered for archived repositories.
+const (
+	archiveActionMove   = "move"
+	archiveActionBundle = "bundle"
+	archiveActionRemove = "remove"
+)
 
-	// Filter to only removable repos (clean working tree).
-	var removable []repos.ArchivedRepo
-	for _, r := range archived {
-		if r.IsClean {
-			removable = append(removable, r)
-		}
-	}
+// archivedRepoAction pairs an archived repository with where it goes.
+// dest is the directory for moves and the bundle file for bundles.
+type archivedRepoAction struct {
+	repo   repos.ArchivedRepo
+	action string
+	dest   string
+}
 
-	if len(removable) == 0 {
-		fmt.Println(red.Sprint("No archived repositories can be removed (all have uncommitted changes)."))
-		return nil
-	}
+func promptArchivedRepoActions(archived []repos.ArchivedRepo, projectsDir string, fs fsops.FileOps, bk *backup.Store, il *ignore.List, ml *metrics.Logger, ol *oplog.Logger) error {
+	archiveDir := filepath.Join(projectsDir, ".archive")
 
-	options := make([]huh.Option[string], len(removable))
-	for i, r := range removable {
+	options := make([]huh.Option[string], len(archived))
+	for i, r := range archived {
 		label := fmt.Sprintf("%s/%s (%s)", r.Owner, r.Repo, r.Path)
+		if !r.IsClean {
+			label += " [uncommitted changes]"
+		}
 		options[i] = huh.NewOption(label, r.Path)
 	}
 
 	var selected []string
-	err := huh.NewForm(
+	action := archiveActionMove
+	err := newForm(
 		huh.NewGroup(
 			huh.NewMultiSelect[string]().
-				Title("Select archived repositories to remove").
+				Title("Select archived repositories to clean up").
 				Options(options...).
 				Value(&selected),
+			huh.NewSelect[string]().
+				Title("What should happen to them?").
+				Options(
+					huh.NewOption(fmt.Sprintf("Move to %s (keep locally, excluded from scans)", archiveDir), archiveActionMove),
+					huh.NewOption(fmt.Sprintf("Write a git bundle to %s, then remove", archiveDir), archiveActionBundle),
+					huh.NewOption("Remove permanently", archiveActionRemove),
+				).
+				Value(&action),
 		),
 	).Run()
 	if err != nil {
@@ -437,10 +803,17 @@ func promptArchivedRepoActions(archived []repos.ArchivedRepo, ml *metrics.Logger
 	for _, s := range selected {
 		selectedSet[s] = true
 	}
-	for _, r := range removable {
+	var passed []ignoreCandidate
+	for _, r := range archived {
 		accepted := selectedSet[r.Path]
 		fp := repoFingerprint(r.Path)
 		_ = ml.LogSuggestion("delete_archived_repo", fp, accepted, 0)
+		if !accepted {
+			passed = append(passed, repoIgnoreCandidate(r.Owner+"/"+r.Repo, r.Path))
+		}
+	}
+	if err := offerToHide(il, passed); err != nil {
+		return err
 	}
 
 	if len(selected) == 0 {
@@ -448,35 +821,170 @@ func promptArchivedRepoActions(archived []repos.ArchivedRepo, ml *metrics.Logger
 		return nil
 	}
 
-	removed := 0
-	for _, r := range removable {
-		if !selectedSet[r.Path] {
-			continue
+	var actions []archivedRepoAction
+	for _, r := range archived {
+		if selectedSet[r.Path] {
+			actions = append(actions, planArchivedRepoAction(r, action, projectsDir, archiveDir))
+		}
+	}
+	actions, err = keepMyRepos(actions)
+	if err != nil || len(actions) == 0 {
+		return err
+	}
+	removals := 0
+	for _, a := range actions {
+		if a.action != archiveActionMove {
+			removals++
+		}
+	}
+	if ok, err := confirmBulkDeletion(removals, "repositories"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was removed.")
+		}
+		return err
+	}
+	// Permanent removals leave no bundle behind, unless safety backups
+	// are on; repositories with uncommitted changes are never removed.
+	var permanent []string
+	for _, a := range actions {
+		if a.action == archiveActionRemove && a.repo.IsClean && bk == nil {
+			permanent = append(permanent, a.repo.Owner+"/"+a.repo.Repo)
+		}
+	}
+	if ok, err := confirmIrreversible(permanent, "repository", "repositories"); err != nil || !ok {
+		if err == nil {
+			fmt.Println("Cancelled. Nothing was removed.")
 		}
+		return err
+	}
+	executeArchivedRepoActions(actions, fs, git.CreateBundle, bk, ol)
+	return nil
+}
 
-		remoteURL, _ := git.RemoteURL(r.Path, "origin")
-		fmt.Printf("Removing %s/%s at %s...\n", r.Owner, r.Repo, r.Path)
-		if err := os.RemoveAll(r.Path); err != nil {
-			fmt.Printf("  %s\n", red.Sprintf("Failed to remove %s: %v", r.Path, err))
+// planArchivedRepoAction decides where r goes under archiveDir. The path
+// relative to projectsDir is kept so same-named repos in different groups
+// do not collide.
+func planArchivedRepoAction(r repos.ArchivedRepo, action, projectsDir, archiveDir string) archivedRepoAction {
+	rel, err := filepath.Rel(projectsDir, r.Path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		rel = filepath.Base(r.Path)
+	}
+	a := archivedRepoAction{repo: r, action: action}
+	switch action {
+	case archiveActionMove:
+		a.dest = filepath.Join(archiveDir, rel)
+	case archiveActionBundle:
+		a.dest = filepath.Join(archiveDir, rel+".bundle")
+	}
+	return a
+}
+
+// executeArchivedRepoActions applies the chosen actions through fs. Repos
+// with uncommitted changes are only ever moved: a bundle does not capture
+// the working tree, so bundling or removing them would lose work. bundle
+// writes a git bundle of a repository to a file. When bk is non-nil,
+// permanent removals are backed up to it first. Returns the number of
+// repositories handled.
+func executeArchivedRepoActions(actions []archivedRepoAction, fs fsops.FileOps, bundle func(repoPath, dest string) error, bk *backup.Store, ol *oplog.Logger) int {
+	fail := ui.Error()
+	success := ui.Success()
+	warn := ui.Warn()
+	bold := color.New(color.Bold)
+
+	var moved, bundled, removed int
+	for _, a := range actions {
+		r := a.repo
+		if a.action != archiveActionMove && !r.IsClean {
+			fmt.Printf("  %s\n", warn.Sprintf("Skipping %s: uncommitted changes (move it to .archive/ instead)", r.Path))
 			continue
 		}
-		_ = ol.Log(oplog.Operation{
-			Type:      oplog.OpDeleteRepo,
-			Path:      r.Path,
-			RemoteURL: remoteURL,
-		})
-		fmt.Printf("  %s\n", green.Sprintf("Removed %s", r.Path))
-		removed++
+		remoteURL, _ := git.RemoteURL(r.Path, git.PrimaryRemote(r.Path))
+		var size int64
+		if a.action != archiveActionMove {
+			size = audit.DirSize(r.Path)
+		}
+
+		switch a.action {
+		case archiveActionMove:
+			fmt.Printf("Moving %s/%s to %s...\n", r.Owner, r.Repo, a.dest)
+			if err := moveToQuarantine(fs, r.Path, a.dest); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to move %s: %v", r.Path, err))
+				continue
+			}
+			_ = ol.Log(oplog.Operation{
+				Type:        oplog.OpMoveDir,
+				Path:        r.Path,
+				Destination: a.dest,
+				RemoteURL:   remoteURL,
+			})
+			fmt.Printf("  %s\n", success.Sprintf("Moved to %s", a.dest))
+			moved++
+
+		case archiveActionBundle:
+			fmt.Printf("Bundling %s/%s to %s...\n", r.Owner, r.Repo, a.dest)
+			if err := fs.EnsureDir(filepath.Dir(a.dest)); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to create %s: %v", filepath.Dir(a.dest), err))
+				continue
+			}
+			if err := bundle(r.Path, a.dest); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to bundle %s, not removing: %v", r.Path, err))
+				continue
+			}
+			if err := fs.Remove(r.Path); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Bundled, but failed to remove %s: %v", r.Path, err))
+				continue
+			}
+			_ = ol.Log(oplog.Operation{
+				Type:        oplog.OpDeleteRepo,
+				Path:        r.Path,
+				Destination: a.dest,
+				RemoteURL:   remoteURL,
+			})
+			fmt.Printf("  %s\n", success.Sprintf("Removed %s (bundle: %s)", r.Path, a.dest))
+			recordImpact(metrics.ImpactEvent{ReposRemoved: 1, BytesFreed: size})
+			bundled++
+
+		case archiveActionRemove:
+			fmt.Printf("Removing %s/%s at %s...\n", r.Owner, r.Repo, r.Path)
+			bundlePath, err := bk.BundleRepo(r.Path)
+			if err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to back up %s, not removing: %v", r.Path, err))
+				continue
+			}
+			if err := fs.Remove(r.Path); err != nil {
+				fmt.Printf("  %s\n", fail.Sprintf("Failed to remove %s: %v", r.Path, err))
+				continue
+			}
+			_ = ol.Log(oplog.Operation{
+				Type:        oplog.OpDeleteRepo,
+				Path:        r.Path,
+				RemoteURL:   remoteURL,
+				Destination: bundlePath,
+			})
+			fmt.Printf("  %s\n", success.Sprintf("Removed %s", r.Path))
+			recordImpact(metrics.ImpactEvent{ReposRemoved: 1, BytesFreed: size})
+			removed++
+		}
 	}
 
-	fmt.Printf("\n%s\n", bold.Sprintf("Removed %d archived repositories.", removed))
-	return nil
+	fmt.Println()
+	if moved > 0 {
+		fmt.Println(bold.Sprintf("Moved %d archived %s to .archive/.", moved, pluralize(moved, "repository", "repositories")))
+	}
+	if bundled > 0 {
+		fmt.Println(bold.Sprintf("Bundled and removed %d archived %s.", bundled, pluralize(bundled, "repository", "repositories")))
+	}
+	if removed > 0 {
+		fmt.Println(bold.Sprintf("Removed %d archived %s.", removed, pluralize(removed, "repository", "repositories")))
+		printBackupNote(bk)
+	}
+	return moved + bundled + removed
 }
 
 // repoFingerprint returns a stable fingerprint for a repository using
 // its remote URL when available, falling back to the repo path.
 func repoFingerprint(repoPath string) string {
-	remote, err := git.RemoteURL(repoPath, "origin")
+	remote, err := git.RemoteURL(repoPath, git.PrimaryRemote(repoPath))
 	if err != nil || remote == "" {
 		remote = repoPath
 	}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/backup"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestRemoveArchivedRepos(t *testing.T) {
+	toRemove := []repos.ArchivedRepo{
+		{Path: "/p/legacy", Owner: "acme", Repo: "legacy", IsClean: true},
+		{Path: "/p/locked", Owner: "acme", Repo: "locked", IsClean: true},
+		{Path: "/p/old-tool", Owner: "acme", Repo: "old-tool", IsClean: true},
+	}
+
+	rec := helpers.NewFSRecorder()
+	rec.Errors = map[string]error{"/p/locked": errors.New("permission denied")}
+	var actions []archivedRepoAction
+	for _, r := range toRemove {
+		actions = append(actions, archivedRepoAction{repo: r, action: archiveActionRemove})
+	}
+	noBundle := func(string, string) error {
+		t.Fatal("bundle called for a plain removal")
+		return nil
+	}
+	removed := executeArchivedRepoActions(actions, rec, noBundle, nil, nil)
+
+	if removed != 2 {
+		t.Errorf("expected 2 removed, got %d", removed)
+	}
+	want := []helpers.FSOp{
+		{Kind: helpers.FSRemove, Path: "/p/legacy"},
+		{Kind: helpers.FSRemove, Path: "/p/locked"},
+		{Kind: helpers.FSRemove, Path: "/p/old-tool"},
+	}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+}
+
+func TestArchiveArchivedRepos(t *testing.T) {
+	projects := "/p"
+	archiveDir := filepath.Join(projects, ".archive")
+	clean := repos.ArchivedRepo{Path: "/p/work/legacy", Owner: "acme", Repo: "legacy", IsClean: true}
+	dirty := repos.ArchivedRepo{Path: "/p/scratch", Owner: "acme", Repo: "scratch", IsClean: false}
+	broken := repos.ArchivedRepo{Path: "/p/broken", Owner: "acme", Repo: "broken", IsClean: true}
+
+	actions := []archivedRepoAction{
+		planArchivedRepoAction(dirty, archiveActionMove, projects, archiveDir),
+		planArchivedRepoAction(clean, archiveActionBundle, projects, archiveDir),
+		planArchivedRepoAction(dirty, archiveActionBundle, projects, archiveDir),
+		planArchivedRepoAction(dirty, archiveActionRemove, projects, archiveDir),
+		planArchivedRepoAction(broken, archiveActionBundle, projects, archiveDir),
+	}
+
+	var bundled []string
+	bundle := func(repoPath, dest string) error {
+		if repoPath == broken.Path {
+			return errors.New("bundle failed")
+		}
+		bundled = append(bundled, repoPath+" -> "+dest)
+		return nil
+	}
+
+	rec := helpers.NewFSRecorder()
+	handled := executeArchivedRepoActions(actions, rec, bundle, nil, nil)
+
+	// Dirty repos are only moved, and a failed bundle keeps the checkout.
+	if handled != 2 {
+		t.Errorf("expected 2 handled, got %d", handled)
+	}
+	wantBundles := []string{"/p/work/legacy -> /p/.archive/work/legacy.bundle"}
+	if !reflect.DeepEqual(bundled, wantBundles) {
+		t.Errorf("bundles = %v, want %v", bundled, wantBundles)
+	}
+	want := []helpers.FSOp{
+		{Kind: helpers.FSEnsureDir, Path: "/p/.archive"},
+		{Kind: helpers.FSMove, Path: "/p/scratch", Dest: "/p/.archive/scratch"},
+		{Kind: helpers.FSEnsureDir, Path: "/p/.archive/work"},
+		{Kind: helpers.FSRemove, Path: "/p/work/legacy"},
+		{Kind: helpers.FSEnsureDir, Path: "/p/.archive"},
+	}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+}
+
+func TestPlanArchivedRepoActionOutsideProjects(t *testing.T) {
+	r := repos.ArchivedRepo{Path: "/elsewhere/tool", IsClean: true}
+	a := planArchivedRepoAction(r, archiveActionMove, "/p", "/p/.archive")
+	if a.dest != "/p/.archive/tool" {
+		t.Errorf("dest = %q, want /p/.archive/tool", a.dest)
+	}
+}
+
+func TestRemoveArchivedReposBacksUpFirst(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "legacy")
+	actions := []archivedRepoAction{
+		{repo: repos.ArchivedRepo{Path: repo.Path, Owner: "acme", Repo: "legacy", IsClean: true}, action: archiveActionRemove},
+		// Not a git repository, so the backup fails and it must be kept.
+		{repo: repos.ArchivedRepo{Path: t.TempDir(), Owner: "acme", Repo: "ghost", IsClean: true}, action: archiveActionRemove},
+	}
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	rec := helpers.NewFSRecorder()
+	removed := executeArchivedRepoActions(actions, rec, git.CreateBundle, backup.New(dir, 30), nil)
+
+	if removed != 1 {
+		t.Errorf("expected 1 removed, got %d", removed)
+	}
+	want := []helpers.FSOp{{Kind: helpers.FSRemove, Path: repo.Path}}
+	if got := rec.Ops(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ops = %v, want %v", got, want)
+	}
+	bundles, _ := filepath.Glob(filepath.Join(dir, "legacy-*.bundle"))
+	if len(bundles) != 1 {
+		t.Errorf("expected one legacy bundle in %s, got %v", dir, bundles)
+	}
+}
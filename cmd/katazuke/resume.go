@@ -0,0 +1,225 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/session"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// Session names of the branches subcommands.
+const (
+	mergedSessionName = "branches-merged"
+	staleSessionName  = "branches-stale"
+)
+
+// branchSession is a branches cleanup saved for --resume: the scan results
+// and every selection step answered so far. Each change is saved right
+// away, so quitting at any prompt loses at most that prompt. A nil session
+// saves nothing.
+type branchSession struct {
+	store   *session.Store
+	name    string
+	savedAt time.Time
+	state   branchSessionState
+}
+
+// branchSessionState is the saved part of a branchSession.
+type branchSessionState struct {
+	StaleDays int                     `json:"stale_days,omitempty"`
+	Merged    []branches.MergedBranch `json:"merged,omitempty"`
+	Stale     []branches.StaleBranch  `json:"stale,omitempty"`
+	// Tips holds each saved branch's tip commit at scan time, keyed like
+	// picks, so a resumed run never deletes a branch that moved since.
+	Tips map[string]string `json:"tips,omitempty"`
+	// Picks holds the answer of each completed selection step, keyed by
+	// step name.
+	Picks map[string]sessionPick `json:"picks,omitempty"`
+}
+
+// sessionPick is the answer to one selection step, as branch keys.
+type sessionPick struct {
+	Delete  []string `json:"delete,omitempty"`
+	Archive []string `json:"archive,omitempty"`
+}
+
+// startBranchSession saves freshly scanned results as the session called
+// name, replacing any earlier one. Sessions are best-effort: when saving
+// fails the cleanup goes on without one.
+func startBranchSession(name string, state branchSessionState) *branchSession {
+	store := session.NewOrNil()
+	if store == nil {
+		return nil
+	}
+	state.Tips = make(map[string]string, len(state.Merged)+len(state.Stale))
+	for _, m := range state.Merged {
+		recordTip(state.Tips, m.RepoPath, m.Branch, mergedKey(m))
+	}
+	for _, st := range state.Stale {
+		recordTip(state.Tips, st.RepoPath, st.Branch, staleKey(st))
+	}
+	s := &branchSession{store: store, name: name, state: state}
+	if err := store.Save(name, s.state); err != nil {
+		slog.Debug("could not save session", "session", name, "error", err)
+		return nil
+	}
+	return s
+}
+
+// resumeBranchSession loads the session called name. It returns nil
+// without an error when there is nothing to resume.
+func resumeBranchSession(name string) (*branchSession, error) {
+	s := &branchSession{store: session.NewOrNil(), name: name}
+	savedAt, err := s.store.Load(name, &s.state)
+	if errors.Is(err, session.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.savedAt = savedAt
+	return s, nil
+}
+
+// pick returns the saved answer of a selection step.
+func (s *branchSession) pick(step string) (sessionPick, bool) {
+	if s == nil {
+		return sessionPick{}, false
+	}
+	p, ok := s.state.Picks[step]
+	return p, ok
+}
+
+// record saves the answer of a selection step.
+func (s *branchSession) record(step string, p sessionPick) {
+	if s == nil {
+		return
+	}
+	if s.state.Picks == nil {
+		s.state.Picks = make(map[string]sessionPick)
+	}
+	s.state.Picks[step] = p
+	if err := s.store.Save(s.name, s.state); err != nil {
+		slog.Debug("could not save session", "session", s.name, "error", err)
+	}
+}
+
+// finish removes the session once the cleanup has run to completion.
+func (s *branchSession) finish() {
+	if s == nil {
+		return
+	}
+	if err := s.store.Remove(s.name); err != nil {
+		slog.Debug("could not remove session", "session", s.name, "error", err)
+	}
+}
+
+// printResumeHint tells the user how to continue an interrupted cleanup.
+func (s *branchSession) printResumeHint(flag string) {
+	if s == nil {
+		return
+	}
+	fmt.Printf("Progress saved. Run 'katazuke branches %s --resume' to continue.\n", flag)
+}
+
+// sessionStep runs one selection step, or reuses its answer when a
+// resumed session already has one. ask returns the items to delete and to
+// archive.
+func sessionStep[T any](s *branchSession, step string, items []T, key func(T) string, ask func() ([]T, []T, error)) ([]T, []T, error) {
+	if p, ok := s.pick(step); ok {
+		return pickItems(items, p.Delete, key), pickItems(items, p.Archive, key), nil
+	}
+	toDelete, toArchive, err := ask()
+	if err != nil {
+		return nil, nil, err
+	}
+	s.record(step, sessionPick{Delete: itemKeys(toDelete, key), Archive: itemKeys(toArchive, key)})
+	return toDelete, toArchive, nil
+}
+
+// itemKeys returns the key of every item.
+func itemKeys[T any](items []T, key func(T) string) []string {
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = key(item)
+	}
+	return keys
+}
+
+// pickItems returns the items whose key is in keys, in item order. Keys of
+// items that no longer exist are ignored.
+func pickItems[T any](items []T, keys []string, key func(T) string) []T {
+	want := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		want[k] = true
+	}
+	var picked []T
+	for _, item := range items {
+		if want[key(item)] {
+			picked = append(picked, item)
+		}
+	}
+	return picked
+}
+
+func mergedKey(m branches.MergedBranch) string { return m.RepoPath + ":" + m.Branch }
+
+func staleKey(s branches.StaleBranch) string { return s.RepoPath + ":" + s.Branch }
+
+// recordTip saves the tip commit of branch under key, when it resolves.
+func recordTip(tips map[string]string, repoPath, branch, key string) {
+	if sha, err := git.RevParse(repoPath, "refs/heads/"+branch); err == nil {
+		tips[key] = sha
+	}
+}
+
+// remainingMerged drops saved branches that have been deleted since the
+// session was saved, e.g. by the interrupted run itself, and skips, saying
+// so, those whose tip is not the one saved in tips.
+func remainingMerged(merged []branches.MergedBranch, tips map[string]string) []branches.MergedBranch {
+	var left []branches.MergedBranch
+	for _, m := range merged {
+		if unchangedSince(m.RepoPath, m.RepoName, m.Branch, tips[mergedKey(m)]) {
+			left = append(left, m)
+		}
+	}
+	return left
+}
+
+// remainingStale is the stale counterpart of remainingMerged.
+func remainingStale(stale []branches.StaleBranch, tips map[string]string) []branches.StaleBranch {
+	var left []branches.StaleBranch
+	for _, s := range stale {
+		if unchangedSince(s.RepoPath, s.RepoName, s.Branch, tips[staleKey(s)]) {
+			left = append(left, s)
+		}
+	}
+	return left
+}
+
+// unchangedSince reports whether branch still exists at the saved tip. A
+// branch that gained commits, or has no saved tip to compare with, is
+// skipped with a message: the saved scan no longer describes it.
+func unchangedSince(repoPath, repoName, branch, tip string) bool {
+	if !git.BranchExists(repoPath, branch) {
+		return false
+	}
+	sha, err := git.RevParse(repoPath, "refs/heads/"+branch)
+	if err == nil && tip != "" && sha == tip {
+		return true
+	}
+	fmt.Printf("  %s %s: %s (changed since the session was saved; run a new scan to include it)\n",
+		ui.Warn().Sprint("[skip]"), repoName, branch)
+	return false
+}
+
+// printResuming announces which saved cleanup is being continued.
+func printResuming(kind string, s *branchSession, count int) {
+	fmt.Printf("Resuming %s branch cleanup saved %s: %d %s left.\n",
+		kind, formatAge(s.savedAt), count, pluralize(count, "branch", "branches"))
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/session"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// testBranchSession returns a session saved in a temporary directory.
+func testBranchSession(t *testing.T, state branchSessionState) *branchSession {
+	t.Helper()
+	store, err := session.NewWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(staleSessionName, state); err != nil {
+		t.Fatal(err)
+	}
+	return &branchSession{store: store, name: staleSessionName, state: state}
+}
+
+func TestSessionStepReusesSavedAnswers(t *testing.T) {
+	stale := []branches.StaleBranch{
+		{RepoPath: "/p/api", Branch: "a"},
+		{RepoPath: "/p/api", Branch: "b"},
+		{RepoPath: "/p/web", Branch: "c"},
+	}
+	sess := testBranchSession(t, branchSessionState{Stale: stale})
+
+	asked := 0
+	ask := func() ([]branches.StaleBranch, []branches.StaleBranch, error) {
+		asked++
+		return stale[:1], stale[2:], nil
+	}
+	if _, _, err := sessionStep(sess, "edit", stale, staleKey, ask); err != nil {
+		t.Fatal(err)
+	}
+
+	// A resumed session reads the answer back from disk without asking.
+	resumed := &branchSession{store: sess.store, name: sess.name}
+	if _, err := resumed.store.Load(resumed.name, &resumed.state); err != nil {
+		t.Fatal(err)
+	}
+	toDelete, toArchive, err := sessionStep(resumed, "edit", stale, staleKey, ask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asked != 1 {
+		t.Errorf("expected one prompt across both runs, got %d", asked)
+	}
+	if len(toDelete) != 1 || toDelete[0].Branch != "a" || len(toArchive) != 1 || toArchive[0].Branch != "c" {
+		t.Errorf("unexpected saved answer: delete %+v, archive %+v", toDelete, toArchive)
+	}
+
+	// Another step has not been answered yet.
+	if _, ok := resumed.pick("prompt:Safe to delete"); ok {
+		t.Error("expected an unanswered step")
+	}
+
+	resumed.finish()
+	if _, err := resumed.store.Load(resumed.name, &resumed.state); err == nil {
+		t.Error("expected the session to be removed after finishing")
+	}
+}
+
+func TestSessionStepNilSession(t *testing.T) {
+	items := []branches.MergedBranch{{RepoPath: "/p/api", Branch: "done"}}
+	toDelete, _, err := sessionStep(nil, "prompt", items, mergedKey, func() ([]branches.MergedBranch, []branches.MergedBranch, error) {
+		return items, nil, nil
+	})
+	if err != nil || len(toDelete) != 1 {
+		t.Errorf("expected the answer to pass through, got %+v, %v", toDelete, err)
+	}
+}
+
+func TestRemainingStaleDropsDeletedAndChangedBranches(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "resume")
+	repo.CreateBranch("kept")
+	repo.Checkout("main")
+	repo.CreateBranch("moved")
+	repo.Checkout("main")
+
+	stale := []branches.StaleBranch{
+		{RepoPath: repo.Path, Branch: "kept"},
+		{RepoPath: repo.Path, Branch: "moved"},
+		{RepoPath: repo.Path, Branch: "already-deleted"},
+	}
+	tips := make(map[string]string)
+	for _, s := range stale {
+		recordTip(tips, s.RepoPath, s.Branch, staleKey(s))
+	}
+
+	// "moved" gains a commit after the session was saved.
+	repo.Checkout("moved")
+	repo.WriteFile("new.txt", "new work")
+	repo.AddFile("new.txt")
+	repo.Commit("new work")
+	repo.Checkout("main")
+
+	left := remainingStale(stale, tips)
+	if len(left) != 1 || left[0].Branch != "kept" {
+		t.Errorf("expected only kept to remain, got %+v", left)
+	}
+	if left := remainingStale(stale[:1], nil); len(left) != 0 {
+		t.Errorf("expected a branch without a saved tip to be skipped, got %+v", left)
+	}
+}
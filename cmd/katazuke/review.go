@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+)
+
+// reviewSection is a titled block of items in a review file. Items in a
+// marked section start out selected.
+type reviewSection struct {
+	title  string
+	labels []string
+	marked bool
+}
+
+// launchEditor opens path in the user's editor and waits for it to exit.
+// Tests replace it to simulate edits.
+var launchEditor = runEditor
+
+// editorCommand returns the editor to launch, following git's order of
+// $VISUAL, then $EDITOR, then vi (notepad on Windows).
+func editorCommand(goos string) string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if v := strings.TrimSpace(os.Getenv(env)); v != "" {
+			return v
+		}
+	}
+	if goos == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+// editorArgs returns the command that opens path in editor. Elsewhere it
+// runs through the shell so values like "code --wait" work, passing path
+// as a separate argument. Windows has no sh, so the editor's words are
+// run directly with path appended.
+func editorArgs(goos, editor, path string) (string, []string) {
+	if goos == "windows" {
+		fields := strings.Fields(editor)
+		return fields[0], append(fields[1:], path)
+	}
+	return "sh", []string{"-c", editor + ` "$1"`, "katazuke-editor", path}
+}
+
+// runEditor runs the user's editor on path.
+func runEditor(path string) error {
+	editor := editorCommand(runtime.GOOS)
+	name, args := editorArgs(runtime.GOOS, editor, path)
+	// #nosec G204 - the editor is chosen by the user's own environment
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+	return nil
+}
+
+// reviewSelect writes a review file with header comments and one
+// checkbox line per item, opens it in the editor, and returns the indices
+// of the items left marked, per section. Like git rebase -i, an edit that
+// cannot be parsed aborts without selecting anything. With --yes the
+// editor is skipped and the initial marks are used.
+func reviewSelect(header []string, sections []reviewSection) ([][]int, error) {
+	if assumeYes {
+		return defaultReviewSelection(sections), nil
+	}
+	if !uiCaps.Interactive() {
+		return nil, errNonInteractive
+	}
+
+	edited, err := editText(formatReview(header, sections))
+	if err != nil {
+		return nil, err
+	}
+	return parseReview(edited, sections)
+}
+
+// editText writes content to a temporary file, opens it in the editor,
+// and returns the edited text. The file is removed afterwards.
+func editText(content string) (string, error) {
+	f, err := os.CreateTemp("", "katazuke-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("creating selection file: %w", err)
+	}
+	path := f.Name()
+	defer func() { _ = os.Remove(path) }()
+
+	_, werr := f.WriteString(content)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr != nil {
+		return "", fmt.Errorf("writing selection file: %w", werr)
+	}
+
+	if err := launchEditor(path); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path) // #nosec G304 - path is our own temp file
+	if err != nil {
+		return "", fmt.Errorf("reading selection file: %w", err)
+	}
+	return string(data), nil
+}
+
+// defaultReviewSelection selects every item of the marked sections.
+func defaultReviewSelection(sections []reviewSection) [][]int {
+	selected := make([][]int, len(sections))
+	for i, s := range sections {
+		if !s.marked {
+			continue
+		}
+		for j := range s.labels {
+			selected[i] = append(selected[i], j)
+		}
+	}
+	return selected
+}
+
+// reviewInstructions explains the review file format. It follows the
+// caller's header.
+var reviewInstructions = []string{
+	"",
+	"Mark branches to delete with [x]; leave [ ] to keep them. Save and",
+	"quit the editor to continue. Removing a line keeps that branch;",
+	"an empty file deletes nothing. Lines starting with # are ignored,",
+	"and the number after the checkbox identifies the branch.",
+}
+
+// formatReview renders the review file.
+func formatReview(header []string, sections []reviewSection) string {
+	blocks := make([]selectionBlock, len(sections))
+	for i, s := range sections {
+		box := "[ ]"
+		if s.marked {
+			box = "[x]"
+		}
+		blocks[i] = selectionBlock{title: s.title, labels: s.labels, marker: box}
+	}
+	return formatSelection(header, reviewInstructions, blocks, 0)
+}
+
+// parseReview returns the marked items of an edited review file, per
+// section. Every non-comment line must start with a checkbox and an item
+// number from the original file.
+func parseReview(content string, sections []reviewSection) ([][]int, error) {
+	blocks := make([]selectionBlock, len(sections))
+	for i, s := range sections {
+		blocks[i] = selectionBlock{title: s.title, labels: s.labels}
+	}
+	marks, err := parseSelection(content, "review file", blocks, false, parseCheckbox)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([][]int, len(sections))
+	for i, m := range marks {
+		for j, marked := range m {
+			if marked {
+				selected[i] = append(selected[i], j)
+			}
+		}
+	}
+	return selected, nil
+}
+
+// parseCheckbox reads the checkbox at the start of a review file line.
+func parseCheckbox(line string) (bool, string, error) {
+	var marked bool
+	switch {
+	case strings.HasPrefix(line, "[x]"), strings.HasPrefix(line, "[X]"):
+		marked = true
+	case strings.HasPrefix(line, "[ ]"), strings.HasPrefix(line, "[]"):
+	default:
+		return false, "", fmt.Errorf("expected [ ] or [x], got %q", line)
+	}
+	_, rest, _ := strings.Cut(line, "]")
+	return marked, rest, nil
+}
+
+// selectionBlock is a titled block of items in a file edited to select
+// them, as --review and --edit use. Every item starts with marker.
+type selectionBlock struct {
+	title  string
+	labels []string
+	marker string
+}
+
+// formatSelection renders a selection file: the header and instructions
+// as comments, then one "<marker> <n> <label>" line per item, with markers
+// padded to width. Items are numbered from 1 across all blocks.
+func formatSelection(header, instructions []string, blocks []selectionBlock, width int) string {
+	var b strings.Builder
+	for _, line := range append(append([]string{}, header...), instructions...) {
+		b.WriteString(strings.TrimRight("# "+line, " ") + "\n")
+	}
+
+	id := 1
+	for _, s := range blocks {
+		if len(s.labels) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n# == %s (%d) ==\n", s.title, len(s.labels))
+		for _, label := range s.labels {
+			fmt.Fprintf(&b, "%-*s %d %s\n", width, s.marker, id, label)
+			id++
+		}
+	}
+	return b.String()
+}
+
+// parseSelection reads an edited selection file and returns the value of
+// every item, per block. parseMarker reads the marker at the start of a
+// line, returning its value and the rest of the line, which must start
+// with an item number from the original file. Items whose line was
+// removed get removed. name identifies the file in errors.
+func parseSelection[T any](content, name string, blocks []selectionBlock, removed T, parseMarker func(line string) (T, string, error)) ([][]T, error) {
+	type position struct{ block, index int }
+	var positions []position
+	values := make([][]T, len(blocks))
+	for i, s := range blocks {
+		values[i] = make([]T, len(s.labels))
+		for j := range s.labels {
+			positions = append(positions, position{i, j})
+			values[i][j] = removed
+		}
+	}
+
+	seen := make(map[int]bool)
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		value, rest, err := parseMarker(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", name, lineNo, err)
+		}
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("%s line %d: missing branch number", name, lineNo)
+		}
+		id, err := strconv.Atoi(fields[0])
+		if err != nil || id < 1 || id > len(positions) {
+			return nil, fmt.Errorf("%s line %d: unknown branch number %q", name, lineNo, fields[0])
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("%s line %d: branch %d listed twice", name, lineNo, id)
+		}
+		seen[id] = true
+
+		p := positions[id-1]
+		values[p.block][p.index] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return values, nil
+}
+
+// reviewMergedSelection is the --review counterpart of promptForDeletion.
+func reviewMergedSelection(merged []branches.MergedBranch) ([]branches.MergedBranch, error) {
+	labels := make([]string, len(merged))
+	for i, m := range merged {
+		labels[i] = m.Label()
+	}
+	header := []string{
+		fmt.Sprintf("katazuke branches --merged: %d merged %s in %d %s",
+			len(merged), pluralize(len(merged), "branch", "branches"),
+			countMergedRepos(merged), pluralize(countMergedRepos(merged), "repository", "repositories")),
+	}
+	picked, err := reviewSelect(header, []reviewSection{{title: "Merged branches", labels: labels}})
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]branches.MergedBranch, 0, len(picked[0]))
+	for _, i := range picked[0] {
+		selected = append(selected, merged[i])
+	}
+	return selected, nil
+}
+
+func countMergedRepos(merged []branches.MergedBranch) int {
+	repos := make(map[string]bool)
+	for _, m := range merged {
+		repos[m.RepoPath] = true
+	}
+	return len(repos)
+}
+
+// reviewStaleSelection is the --review counterpart of the per-tier stale
+// prompts. Tiers keep their usual preselection.
+func reviewStaleSelection(tiers []staleTier) ([]branches.StaleBranch, error) {
+	sections := make([]reviewSection, len(tiers))
+	total := 0
+	for i, t := range tiers {
+		labels := make([]string, len(t.branches))
+		for j, s := range t.branches {
+			labels[j] = staleBranchLabel(s)
+		}
+		sections[i] = reviewSection{title: t.title, labels: labels, marked: t.preselect}
+		total += len(t.branches)
+	}
+
+	header := []string{fmt.Sprintf("katazuke branches --stale: %d stale %s", total, pluralize(total, "branch", "branches"))}
+	for _, t := range tiers {
+		if len(t.branches) > 0 {
+			header = append(header, fmt.Sprintf("  %s: %d. %s", t.title, len(t.branches), t.description))
+		}
+	}
+
+	picked, err := reviewSelect(header, sections)
+	if err != nil {
+		return nil, err
+	}
+	var selected []branches.StaleBranch
+	for i, t := range tiers {
+		for _, j := range picked[i] {
+			selected = append(selected, t.branches[j])
+		}
+	}
+	return selected, nil
+}
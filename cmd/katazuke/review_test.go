@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+var interactiveCaps = ui.Capabilities{IsTTY: true, StdinTTY: true, Mode: ui.ModeRich}
+
+// withEditor replaces the editor with edit, which rewrites the review file
+// contents.
+func withEditor(t *testing.T, edit func(content string) string) {
+	t.Helper()
+	prev := launchEditor
+	launchEditor = func(path string) error {
+		data, err := os.ReadFile(path) // #nosec G304 - test temp file
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(edit(string(data))), 0o600)
+	}
+	t.Cleanup(func() { launchEditor = prev })
+}
+
+func TestReviewStaleSelection(t *testing.T) {
+	withPromptMode(t, interactiveCaps, false)
+	stale := []branches.StaleBranch{
+		{RepoName: "api", Branch: "mine", HasRemote: true, IsOwnBranch: true},
+		{RepoName: "api", Branch: "dependabot/x", IsAutomation: true, IsOwnBranch: true},
+		{RepoName: "web", Branch: "local-work", IsOwnBranch: true},
+	}
+
+	var written string
+	withEditor(t, func(content string) string {
+		written = content
+		// Keep the automation branch, delete the local-only one.
+		content = strings.Replace(content, "[x] 2 ", "[ ] 2 ", 1)
+		return strings.Replace(content, "[ ] 3 ", "[X] 3 ", 1)
+	})
+
+	selected, err := reviewStaleSelection(staleTiers(stale))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(written, "# katazuke branches --stale: 3 stale branches") ||
+		!strings.Contains(written, "# == Needs review (1) ==\n[ ] 3 web: local-work") {
+		t.Errorf("unexpected review file:\n%s", written)
+	}
+	if len(selected) != 2 || selected[0].Branch != "mine" || selected[1].Branch != "local-work" {
+		t.Errorf("expected mine and local-work selected, got %+v", selected)
+	}
+}
+
+func TestReviewMergedSelectionRejectsBadEdits(t *testing.T) {
+	withPromptMode(t, interactiveCaps, false)
+	merged := []branches.MergedBranch{{RepoName: "api", Branch: "done"}}
+
+	for name, edit := range map[string]func(string) string{
+		"unknown number": func(c string) string { return c + "[x] 9 nope\n" },
+		"no checkbox":    func(c string) string { return strings.Replace(c, "[ ] 1", "delete 1", 1) },
+		"duplicate":      func(c string) string { return c + "[x] 1 again\n" },
+	} {
+		withEditor(t, edit)
+		if _, err := reviewMergedSelection(merged); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	// Emptying the file selects nothing.
+	withEditor(t, func(string) string { return "" })
+	selected, err := reviewMergedSelection(merged)
+	if err != nil || len(selected) != 0 {
+		t.Errorf("expected no selection from an empty file, got %+v, %v", selected, err)
+	}
+}
+
+func TestReviewSelectModes(t *testing.T) {
+	sections := []reviewSection{
+		{title: "a", labels: []string{"one", "two"}, marked: true},
+		{title: "b", labels: []string{"three"}},
+	}
+	withEditor(t, func(string) string {
+		t.Error("editor must not be launched")
+		return ""
+	})
+
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, true)
+	picked, err := reviewSelect(nil, sections)
+	if err != nil || len(picked[0]) != 2 || len(picked[1]) != 0 {
+		t.Errorf("--yes should keep the initial marks, got %v, %v", picked, err)
+	}
+
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, false)
+	if _, err := reviewSelect(nil, sections); !errors.Is(err, errNonInteractive) {
+		t.Errorf("expected errNonInteractive, got %v", err)
+	}
+}
+
+func TestEditorArgs(t *testing.T) {
+	name, args := editorArgs("linux", "code --wait", "/tmp/review.md")
+	if name != "sh" || strings.Join(args, " ") != `-c code --wait "$1" katazuke-editor /tmp/review.md` {
+		t.Errorf("unexpected shell invocation %s %q", name, args)
+	}
+
+	name, args = editorArgs("windows", "code --wait", `C:\Temp\review.md`)
+	if name != "code" || strings.Join(args, " ") != `--wait C:\Temp\review.md` {
+		t.Errorf("expected the editor to run directly on Windows, got %s %q", name, args)
+	}
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	if got := editorCommand("windows"); got != "notepad" {
+		t.Errorf("expected notepad as the Windows default, got %q", got)
+	}
+	if got := editorCommand("darwin"); got != "vi" {
+		t.Errorf("expected vi as the default, got %q", got)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/lock"
+)
+
+// lockedCommands are the commands that change repositories in the
+// projects directory, and so must not run alongside each other.
+var lockedCommands = map[string]bool{
+	"branches": true,
+	"repos":    true,
+	"tags":     true,
+	"clean":    true,
+	"audit":    true,
+	"sync":     true,
+	"import":   true,
+}
+
+// needsRunLock reports whether command (as given by kong, e.g. "branches"
+// or "metrics summary") takes the projects directory lock. Dry runs
+// change nothing, so they never wait on or block another run.
+func needsRunLock(command string, dryRun bool) bool {
+	if dryRun {
+		return false
+	}
+	name, _, _ := strings.Cut(command, " ")
+	return lockedCommands[name]
+}
+
+// acquireRunLock takes the lock for the projects directory when command
+// changes repositories. It returns a nil lock for commands that do not
+// need one, and when the lock directory cannot be determined, in which
+// case the run goes ahead unprotected rather than failing. cfg is the
+// config main loaded for the run.
+func acquireRunLock(g *CLI, command string, cfg config.Config) (*lock.Lock, error) {
+	if !needsRunLock(command, g.DryRun) {
+		return nil, nil
+	}
+	dir, err := lock.DefaultDir()
+	if err != nil {
+		slog.Debug("run lock disabled", "error", err)
+		return nil, nil
+	}
+	return lock.Acquire(dir, resolveProjectsDir(g.ProjectsDir, cfg), command, g.ForceLock)
+}
+
+// releaseRunLock removes the lock taken by acquireRunLock, if any.
+func releaseRunLock(l *lock.Lock) {
+	if err := l.Release(); err != nil {
+		slog.Debug("could not release run lock", "error", err)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestNeedsRunLock(t *testing.T) {
+	tests := []struct {
+		command string
+		dryRun  bool
+		want    bool
+	}{
+		{"branches", false, true},
+		{"sync", false, true},
+		{"audit", false, true},
+		{"branches", true, false},
+		{"metrics summary", false, false},
+		{"completion doctor", false, false},
+		{"log", false, false},
+		{"version", false, false},
+	}
+	for _, tt := range tests {
+		if got := needsRunLock(tt.command, tt.dryRun); got != tt.want {
+			t.Errorf("needsRunLock(%q, %v) = %v, want %v", tt.command, tt.dryRun, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// deletionThreshold is safety.max_deletions_per_run: a run that would
+// delete more branches, or remove more repositories or directories, than
+// this needs --force or a typed confirmation. 0 disables the check.
+var deletionThreshold int
+
+// typedConfirmThreshold is safety.typed_confirm_threshold: from this many
+// irreversible deletions on, a yes/no answer is not enough and the user
+// types what is being deleted. 0 disables it.
+var typedConfirmThreshold int
+
+// forceDeletions is set from the global --force flag.
+var forceDeletions bool
+
+// confirmBulkDeletion guards against a selection that is much larger than
+// intended, such as a select-all in a long multi-select. Under the
+// threshold, or with --force, it returns true without asking. Otherwise
+// the user must type "delete N"; --yes and non-interactive runs cannot
+// answer that, so they get an error pointing at --force.
+func confirmBulkDeletion(n int, noun string) (bool, error) {
+	if deletionThreshold <= 0 || n <= deletionThreshold || forceDeletions {
+		return true, nil
+	}
+	if assumeYes || !uiCaps.Interactive() {
+		return false, fmt.Errorf("refusing to delete %d %s, more than safety.max_deletions_per_run (%d); re-run with --force to proceed",
+			n, noun, deletionThreshold)
+	}
+	return typeToConfirm(
+		fmt.Sprintf("This run deletes %d %s, more than safety.max_deletions_per_run (%d).", n, noun, deletionThreshold),
+		fmt.Sprintf("delete %d", n),
+	)
+}
+
+// confirmIrreversible asks the user to type what is about to be destroyed
+// for good, the way GitHub asks for a repository's name before deleting
+// it: the name itself when there is one item, "delete N <noun>" when
+// there are several. Below safety.typed_confirm_threshold, or with
+// --force, it returns true without asking, leaving the yes/no prompt
+// that led here as the only confirmation. --yes and non-interactive runs
+// cannot type, so they get an error pointing at --force.
+func confirmIrreversible(names []string, singular, plural string) (bool, error) {
+	n := len(names)
+	noun := pluralize(n, singular, plural)
+	if n == 0 || typedConfirmThreshold <= 0 || n < typedConfirmThreshold || forceDeletions {
+		return true, nil
+	}
+	if assumeYes || !uiCaps.Interactive() {
+		return false, fmt.Errorf("refusing to delete %d %s without a typed confirmation; re-run with --force to proceed", n, noun)
+	}
+	want := fmt.Sprintf("delete %d %s", n, noun)
+	if n == 1 {
+		want = names[0]
+	}
+	return typeToConfirm(
+		fmt.Sprintf("This permanently deletes %d %s:\n  %s", n, noun, strings.Join(names, "\n  ")),
+		want,
+	)
+}
+
+// typeToConfirm shows title and returns whether the user typed want.
+func typeToConfirm(title, want string) (bool, error) {
+	var typed string
+	err := newForm(
+		huh.NewGroup(
+			huh.NewInput().
+				Title(title).
+				Description(fmt.Sprintf("Type %q to continue, anything else cancels.", want)).
+				Value(&typed),
+		),
+	).Run()
+	if err != nil {
+		return false, fmt.Errorf("confirmation prompt: %w", err)
+	}
+	return strings.TrimSpace(typed) == want, nil
+}
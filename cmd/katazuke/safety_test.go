@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func TestConfirmBulkDeletion(t *testing.T) {
+	nonTTY := ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}
+	prevThreshold, prevForce := deletionThreshold, forceDeletions
+	t.Cleanup(func() { deletionThreshold, forceDeletions = prevThreshold, prevForce })
+
+	tests := []struct {
+		name      string
+		threshold int
+		force     bool
+		yes       bool
+		n         int
+		wantOK    bool
+		wantErr   bool
+	}{
+		{name: "disabled", threshold: 0, n: 500, wantOK: true},
+		{name: "at threshold", threshold: 100, n: 100, wantOK: true},
+		{name: "forced", threshold: 100, force: true, n: 101, wantOK: true},
+		{name: "over with --yes", threshold: 100, yes: true, n: 101, wantErr: true},
+		{name: "over without a terminal", threshold: 100, n: 101, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPromptMode(t, nonTTY, tt.yes)
+			deletionThreshold, forceDeletions = tt.threshold, tt.force
+
+			ok, err := confirmBulkDeletion(tt.n, "branches")
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("confirmBulkDeletion(%d) = %v, %v; want %v, error %v", tt.n, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--force") {
+				t.Errorf("expected the error to mention --force, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfirmIrreversible(t *testing.T) {
+	nonTTY := ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}
+	prevThreshold, prevForce := typedConfirmThreshold, forceDeletions
+	t.Cleanup(func() { typedConfirmThreshold, forceDeletions = prevThreshold, prevForce })
+
+	three := []string{"origin/a", "origin/b", "origin/c"}
+	tests := []struct {
+		name      string
+		threshold int
+		force     bool
+		yes       bool
+		names     []string
+		wantOK    bool
+		wantErr   bool
+	}{
+		{name: "nothing to delete", threshold: 1, names: nil, wantOK: true},
+		{name: "disabled", threshold: 0, names: three, wantOK: true},
+		{name: "below threshold", threshold: 4, names: three, wantOK: true},
+		{name: "forced", threshold: 1, force: true, names: three, wantOK: true},
+		{name: "at threshold with --yes", threshold: 3, yes: true, names: three, wantErr: true},
+		{name: "single item without a terminal", threshold: 1, names: three[:1], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withPromptMode(t, nonTTY, tt.yes)
+			typedConfirmThreshold, forceDeletions = tt.threshold, tt.force
+
+			ok, err := confirmIrreversible(tt.names, "remote branch", "remote branches")
+			if ok != tt.wantOK || (err != nil) != tt.wantErr {
+				t.Fatalf("confirmIrreversible(%v) = %v, %v; want %v, error %v", tt.names, ok, err, tt.wantOK, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "--force") {
+				t.Errorf("expected the error to mention --force, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// groupRoot returns the directory to scan for --group: the group's subtree
+// under projectsDir, or projectsDir itself when group is empty.
+func groupRoot(projectsDir, group string) (string, error) {
+	if group == "" {
+		return projectsDir, nil
+	}
+	clean := filepath.Clean(filepath.FromSlash(group))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("--group %q must be a path inside %s", group, projectsDir)
+	}
+	root := filepath.Join(projectsDir, clean)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("group %q not found in %s", group, projectsDir)
+	}
+	return root, nil
+}
+
+// groupedName returns the repository's name prefixed with its group path,
+// e.g. "work/client-a/api", so same-named repos in different groups can be
+// told apart in results. Repos at the top level keep their bare name.
+func groupedName(projectsDir, repoPath string) string {
+	if g := repoGroup(projectsDir, repoPath); g != "" {
+		return g + "/" + filepath.Base(repoPath)
+	}
+	return filepath.Base(repoPath)
+}
+
+// matchesPattern reports whether the glob matches the repository's name or
+// its path relative to projectsDir, so "api" and "work/*" both select
+// projectsDir/work/api. As with filepath.Match, "*" does not cross "/".
+func matchesPattern(path, pattern, projectsDir string) bool {
+	if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+		return true
+	}
+	rel, err := filepath.Rel(projectsDir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	matched, _ := filepath.Match(pattern, filepath.ToSlash(rel))
+	return matched
+}
+
+// filterByPattern keeps the repositories matching pattern (see
+// matchesPattern). An empty pattern keeps everything.
+func filterByPattern(repos []string, pattern, projectsDir string) ([]string, error) {
+	if pattern == "" {
+		return repos, nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid --pattern %q: %w", pattern, err)
+	}
+	var filtered []string
+	for _, repoPath := range repos {
+		if matchesPattern(repoPath, pattern, projectsDir) {
+			filtered = append(filtered, repoPath)
+		}
+	}
+	return filtered, nil
+}
+
+// repoGroup returns the .katazuke group path of repo relative to
+// projectsDir, e.g. "work/client-a", or "" for repos at the top level.
+func repoGroup(projectsDir, repo string) string {
+	rel, err := filepath.Rel(projectsDir, filepath.Dir(repo))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// groupRepos buckets repos by group. Groups are returned sorted with the
+// top level ("") first; repos keep their discovery order within a group.
+func groupRepos(projectsDir string, repos []string) ([]string, map[string][]string) {
+	byGroup := make(map[string][]string)
+	var groups []string
+	for _, r := range repos {
+		g := repoGroup(projectsDir, r)
+		if _, ok := byGroup[g]; !ok {
+			groups = append(groups, g)
+		}
+		byGroup[g] = append(byGroup[g], r)
+	}
+	sort.Strings(groups)
+	return groups, byGroup
+}
+
+// selectRepos presents the discovered repositories as a multi-select, one
+// page per .katazuke group, and returns the chosen subset in discovery
+// order. Nothing is preselected.
+func selectRepos(repos []string, projectsDir string) ([]string, error) {
+	groups, byGroup := groupRepos(projectsDir, repos)
+
+	picked := make([][]string, len(groups))
+	formGroups := make([]*huh.Group, len(groups))
+	for i, g := range groups {
+		options := make([]huh.Option[string], len(byGroup[g]))
+		for j, r := range byGroup[g] {
+			options[j] = huh.NewOption(filepath.Base(r), r)
+		}
+		title := "Select repositories"
+		if len(groups) > 1 {
+			name := g
+			if name == "" {
+				name = "(top level)"
+			}
+			title = fmt.Sprintf("Select repositories in %s (%d of %d)", name, i+1, len(groups))
+		}
+		formGroups[i] = huh.NewGroup(
+			huh.NewMultiSelect[string]().
+				Title(title).
+				Options(options...).
+				Value(&picked[i]),
+		)
+	}
+
+	if err := newForm(formGroups...).Run(); err != nil {
+		return nil, fmt.Errorf("repository selection: %w", err)
+	}
+
+	chosen := make(map[string]bool)
+	for _, p := range picked {
+		for _, r := range p {
+			chosen[r] = true
+		}
+	}
+	selected := []string{}
+	for _, r := range repos {
+		if chosen[r] {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
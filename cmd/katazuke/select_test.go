@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+func TestGroupRepos(t *testing.T) {
+	repos := []string{
+		"/p/work/client-a/api",
+		"/p/dotfiles",
+		"/p/oss/katazuke",
+		"/p/work/client-a/web",
+		"/elsewhere/tool",
+	}
+	groups, byGroup := groupRepos("/p", repos)
+
+	if want := []string{"", "oss", "work/client-a"}; !reflect.DeepEqual(groups, want) {
+		t.Errorf("groups = %v, want %v", groups, want)
+	}
+	if want := []string{"/p/work/client-a/api", "/p/work/client-a/web"}; !reflect.DeepEqual(byGroup["work/client-a"], want) {
+		t.Errorf("work/client-a = %v, want %v", byGroup["work/client-a"], want)
+	}
+	if want := []string{"/p/dotfiles", "/elsewhere/tool"}; !reflect.DeepEqual(byGroup[""], want) {
+		t.Errorf("top level = %v, want %v", byGroup[""], want)
+	}
+}
+
+func TestSelectReposNothingPreselected(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+
+	selected, err := selectRepos([]string{"/p/a", "/p/work/b"}, "/p")
+	if err != nil {
+		t.Fatalf("selectRepos: %v", err)
+	}
+	if selected == nil || len(selected) != 0 {
+		t.Errorf("expected an empty, non-nil selection, got %#v", selected)
+	}
+}
+
+func TestFilterByPattern(t *testing.T) {
+	repos := []string{
+		"/p/kafka-tools",
+		"/p/work/api",
+		"/p/work/client-a/web",
+		"/p/oss/kafka-streams",
+	}
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"", repos},
+		{"*kafka*", []string{"/p/kafka-tools", "/p/oss/kafka-streams"}},
+		{"work/*", []string{"/p/work/api"}},
+		{"work/*/*", []string{"/p/work/client-a/web"}},
+		{"oss/kafka-*", []string{"/p/oss/kafka-streams"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		got, err := filterByPattern(repos, tt.pattern, "/p")
+		if err != nil {
+			t.Fatalf("%q: unexpected error: %v", tt.pattern, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: got %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+
+	if _, err := filterByPattern(repos, "[", "/p"); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestGroupRoot(t *testing.T) {
+	projects := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(projects, "work", "client-a"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if root, err := groupRoot(projects, ""); err != nil || root != projects {
+		t.Errorf("empty group = %q, %v; want projects dir", root, err)
+	}
+	if root, err := groupRoot(projects, "work/client-a/"); err != nil || root != filepath.Join(projects, "work", "client-a") {
+		t.Errorf("work/client-a = %q, %v", root, err)
+	}
+	for _, bad := range []string{"missing", "../elsewhere", "/abs", "."} {
+		if _, err := groupRoot(projects, bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestGroupedName(t *testing.T) {
+	tests := map[string]string{
+		"/p/api":                "api",
+		"/p/work/client-a/api":  "work/client-a/api",
+		"/elsewhere/checkout/x": "x",
+	}
+	for path, want := range tests {
+		if got := groupedName("/p", path); got != want {
+			t.Errorf("groupedName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/config"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/internal/update"
+)
+
+// SelfUpdateCmd replaces the running binary with the latest release.
+type SelfUpdateCmd struct {
+	CheckOnly bool `name:"check-only" help:"Report whether a newer release is available without installing it."`
+}
+
+// Run executes the self-update command.
+func (c *SelfUpdateCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	rel, err := update.Latest()
+	if err != nil {
+		return err
+	}
+	latest := rel.Version()
+	cachePath := update.DefaultCachePath()
+	cache := update.LoadCache(cachePath)
+	cache.CheckedAt, cache.Latest = time.Now(), latest
+	if err := cache.Save(cachePath); err != nil {
+		slog.Debug("could not write update check cache", "error", err)
+	}
+
+	if !update.IsRelease(version) {
+		fmt.Printf("This is a development build (%s); the latest release is %s.\n", version, latest)
+		return nil
+	}
+	if !update.Newer(version, latest) {
+		fmt.Printf("katazuke %s is up to date.\n", version)
+		return nil
+	}
+	fmt.Printf("katazuke %s is available (you have %s): %s\n", latest, version, rel.HTMLURL)
+	if c.CheckOnly {
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locating the running binary: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	switch update.ManagedBy(exe) {
+	case update.Homebrew:
+		return fmt.Errorf("katazuke was installed with Homebrew; run `brew upgrade katazuke` instead")
+	case update.SystemPackage:
+		return fmt.Errorf("katazuke was installed as a system package (%s); upgrade it with your package manager instead", exe)
+	}
+	if globals.DryRun {
+		fmt.Printf("Dry run -- would replace %s with %s.\n", exe, latest)
+		return nil
+	}
+
+	binary, err := update.Download(rel, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return err
+	}
+	if err := update.Replace(exe, binary); err != nil {
+		return err
+	}
+	success := ui.Success()
+	fmt.Println(success.Sprintf("Updated %s from %s to %s.", exe, version, latest))
+	return nil
+}
+
+// updateCheckDone is closed when the background release check started
+// by startUpdateNotice finishes; nil when none was started.
+var updateCheckDone chan struct{}
+
+// startUpdateNotice mentions a newer release found by an earlier check,
+// at most once a day, and starts a new check in the background when the
+// last one is a day old. Development builds, output that is not a
+// terminal, the self-update and version commands, and update_check:
+// false skip it.
+func startUpdateNotice(command string) {
+	if command == "self-update" || command == "version" || !uiCaps.IsTTY || !update.IsRelease(version) {
+		return
+	}
+	cfg, err := config.Load()
+	if err != nil || !cfg.UpdateCheck {
+		return
+	}
+
+	path := update.DefaultCachePath()
+	cache := update.LoadCache(path)
+	now := time.Now()
+	if latest := cache.Notice(version, now); latest != "" {
+		warn := ui.Warn()
+		fmt.Fprintln(os.Stderr, warn.Sprintf("katazuke %s is available (you have %s); run `katazuke self-update` to upgrade.", latest, version))
+		cache.NotifiedAt = now
+		if err := cache.Save(path); err != nil {
+			slog.Debug("could not write update check cache", "error", err)
+		}
+	}
+	if !cache.CheckDue(now) {
+		return
+	}
+
+	done := make(chan struct{})
+	updateCheckDone = done
+	go func() {
+		defer close(done)
+		rel, err := update.Latest()
+		if err != nil {
+			slog.Debug("update check failed", "error", err)
+			return
+		}
+		cache.CheckedAt, cache.Latest = time.Now(), rel.Version()
+		if err := cache.Save(path); err != nil {
+			slog.Debug("could not write update check cache", "error", err)
+		}
+	}()
+}
+
+// finishUpdateNotice gives a background release check a moment to record
+// its result before the process exits. A check that takes longer is
+// abandoned and retried on a later run.
+func finishUpdateNotice() {
+	if updateCheckDone == nil {
+		return
+	}
+	select {
+	case <-updateCheckDone:
+	case <-time.After(2 * time.Second):
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/audit"
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// sortOrders are the values --sort accepts. Without --sort, items keep the
+// order the scan produced them in.
+var sortOrders = []string{"age", "repo", "ahead", "size"}
+
+// validateSort checks a --sort value.
+func validateSort(by string) error {
+	if by == "" || slices.Contains(sortOrders, by) {
+		return nil
+	}
+	return fmt.Errorf("invalid --sort %q: must be one of %s", by, strings.Join(sortOrders, ", "))
+}
+
+// sortMerged orders merged branches for --sort. age puts the oldest last
+// commit first and repo sorts by repository, then branch. Merged branches
+// carry no divergence, so ahead and size fall back to age.
+func sortMerged(merged []branches.MergedBranch, by string) {
+	switch by {
+	case "repo":
+		sort.SliceStable(merged, func(i, j int) bool {
+			return repoBranchLess(merged[i].RepoName, merged[i].Branch, merged[j].RepoName, merged[j].Branch)
+		})
+	case "age", "ahead", "size":
+		sort.SliceStable(merged, func(i, j int) bool {
+			return merged[i].LastCommit.Before(merged[j].LastCommit)
+		})
+	}
+}
+
+// sortStale orders stale branches for --sort. ahead puts the branches with
+// the most commits of their own first and size the most diverged, counting
+// commits both ahead and behind. Ties keep the oldest first.
+func sortStale(stale []branches.StaleBranch, by string) {
+	var key func(branches.StaleBranch) int
+	switch by {
+	case "repo":
+		sort.SliceStable(stale, func(i, j int) bool {
+			return repoBranchLess(stale[i].RepoName, stale[i].Branch, stale[j].RepoName, stale[j].Branch)
+		})
+		return
+	case "age":
+		key = func(branches.StaleBranch) int { return 0 }
+	case "ahead":
+		key = func(s branches.StaleBranch) int { return s.CommitsAhead }
+	case "size":
+		key = func(s branches.StaleBranch) int { return s.CommitsAhead + s.CommitsBehind }
+	default:
+		return
+	}
+	sort.SliceStable(stale, func(i, j int) bool {
+		if ki, kj := key(stale[i]), key(stale[j]); ki != kj {
+			return ki > kj
+		}
+		return stale[i].LastCommit.Before(stale[j].LastCommit)
+	})
+}
+
+func repoBranchLess(repoA, branchA, repoB, branchB string) bool {
+	if repoA != repoB {
+		return repoA < repoB
+	}
+	return branchA < branchB
+}
+
+// repoSortKey is what --sort orders a repository by. Only the field the
+// order needs is filled in, since each costs git calls or a disk walk.
+type repoSortKey struct {
+	lastCommit time.Time
+	ahead      int
+	size       int64
+}
+
+// sortRepos orders repository results for --sort. age puts the repository
+// whose HEAD commit is oldest first, ahead the most commits found on no
+// remote first, and size the largest checkout on disk first. Ties and the
+// repo order sort by name.
+func sortRepos[T any](items []T, by string, name, path func(T) string) {
+	if by == "" {
+		return
+	}
+	keys := make(map[string]repoSortKey, len(items))
+	for _, item := range items {
+		keys[path(item)] = loadRepoSortKey(path(item), by)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		ki, kj := keys[path(items[i])], keys[path(items[j])]
+		switch {
+		case by == "age" && !ki.lastCommit.Equal(kj.lastCommit):
+			return ki.lastCommit.Before(kj.lastCommit)
+		case by == "ahead" && ki.ahead != kj.ahead:
+			return ki.ahead > kj.ahead
+		case by == "size" && ki.size != kj.size:
+			return ki.size > kj.size
+		}
+		return name(items[i]) < name(items[j])
+	})
+}
+
+// loadRepoSortKey reads the key of the repository at path that by sorts on.
+// Keys that cannot be read stay zero.
+func loadRepoSortKey(path, by string) repoSortKey {
+	var k repoSortKey
+	switch by {
+	case "age":
+		k.lastCommit, _ = git.CommitDate(path, "HEAD")
+	case "ahead":
+		k.ahead, _ = git.UnpushedCount(path, "--branches")
+	case "size":
+		k.size = audit.DirSize(path)
+	}
+	return k
+}
+
+// groupedByRepo reports whether every repository's items are adjacent, so a
+// summary can print each repository as a heading above its branches.
+func groupedByRepo[T any](items []T, repo func(T) string) bool {
+	seen := make(map[string]bool)
+	prev := ""
+	for i, item := range items {
+		r := repo(item)
+		if i > 0 && r == prev {
+			continue
+		}
+		if seen[r] {
+			return false
+		}
+		seen[r] = true
+		prev = r
+	}
+	return true
+}
+
+// limitOldest keeps the limit items with the oldest lastCommit, in their
+// original order, so --limit chips away at a large backlog oldest first.
+// It says how many were held back for later runs. A limit of 0 keeps all.
+func limitOldest[T any](items []T, limit int, kind string, lastCommit func(T) time.Time) []T {
+	if limit <= 0 || len(items) <= limit {
+		return items
+	}
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return lastCommit(items[order[i]]).Before(lastCommit(items[order[j]]))
+	})
+	keep := make([]bool, len(items))
+	for _, i := range order[:limit] {
+		keep[i] = true
+	}
+	kept := make([]T, 0, limit)
+	for i, item := range items {
+		if keep[i] {
+			kept = append(kept, item)
+		}
+	}
+	fmt.Printf("Limiting this run to the %d oldest of %d %s branches; run again for the rest.\n", limit, len(items), kind)
+	return kept
+}
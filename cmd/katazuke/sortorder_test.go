@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestValidateSort(t *testing.T) {
+	for _, by := range []string{"", "age", "repo", "ahead", "size"} {
+		if err := validateSort(by); err != nil {
+			t.Errorf("validateSort(%q) = %v", by, err)
+		}
+	}
+	if err := validateSort("name"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func staleBranchNames(stale []branches.StaleBranch) string {
+	names := make([]string, len(stale))
+	for i, s := range stale {
+		names[i] = s.Branch
+	}
+	return strings.Join(names, ",")
+}
+
+func TestSortStale(t *testing.T) {
+	now := time.Now()
+	fixture := func() []branches.StaleBranch {
+		return []branches.StaleBranch{
+			{RepoName: "web", Branch: "b", LastCommit: now.Add(-40 * day), CommitsAhead: 1, CommitsBehind: 9},
+			{RepoName: "api", Branch: "c", LastCommit: now.Add(-90 * day), CommitsAhead: 1},
+			{RepoName: "api", Branch: "a", LastCommit: now.Add(-60 * day), CommitsAhead: 5},
+		}
+	}
+
+	tests := []struct {
+		by   string
+		want string
+	}{
+		{"", "b,c,a"},
+		{"age", "c,a,b"},
+		{"repo", "a,c,b"},
+		{"ahead", "a,c,b"},
+		{"size", "b,a,c"},
+	}
+	for _, tt := range tests {
+		stale := fixture()
+		sortStale(stale, tt.by)
+		if got := staleBranchNames(stale); got != tt.want {
+			t.Errorf("sortStale(%q) = %s, want %s", tt.by, got, tt.want)
+		}
+	}
+}
+
+func TestSortMerged(t *testing.T) {
+	now := time.Now()
+	merged := []branches.MergedBranch{
+		{RepoName: "web", Branch: "new", LastCommit: now},
+		{RepoName: "api", Branch: "old", LastCommit: now.Add(-30 * day)},
+	}
+	sortMerged(merged, "size")
+	if merged[0].Branch != "old" {
+		t.Errorf("expected size to fall back to age for merged branches, got %+v", merged)
+	}
+}
+
+func TestSortReposByAhead(t *testing.T) {
+	clean, _ := helpers.NewClonedRepo(t, "clean")
+	busy, _ := helpers.NewClonedRepo(t, "busy")
+	busy.WriteFile("a.txt", "a")
+	busy.AddFile("a.txt")
+	busy.Commit("local work")
+
+	type item struct{ name, path string }
+	items := []item{{"clean", clean.Path}, {"busy", busy.Path}}
+	name := func(i item) string { return i.name }
+	path := func(i item) string { return i.path }
+
+	sortRepos(items, "repo", name, path)
+	if items[0].name != "busy" {
+		t.Errorf("expected repo order to sort by name, got %+v", items)
+	}
+	items[0], items[1] = items[1], items[0]
+	sortRepos(items, "ahead", name, path)
+	if items[0].name != "busy" {
+		t.Errorf("expected the repo with unpushed commits first, got %+v", items)
+	}
+}
+
+func TestGroupedByRepo(t *testing.T) {
+	repo := func(s string) string { return s }
+	if !groupedByRepo([]string{"api", "api", "web"}, repo) {
+		t.Error("expected adjacent repos to be grouped")
+	}
+	if groupedByRepo([]string{"api", "web", "api"}, repo) {
+		t.Error("expected interleaved repos not to be grouped")
+	}
+}
+
+func TestLimitOldest(t *testing.T) {
+	now := time.Now()
+	stale := []branches.StaleBranch{
+		{Branch: "a", LastCommit: now.Add(-10 * day)},
+		{Branch: "b", LastCommit: now.Add(-90 * day)},
+		{Branch: "c", LastCommit: now.Add(-30 * day)},
+		{Branch: "d", LastCommit: now.Add(-60 * day)},
+	}
+	lastCommit := func(s branches.StaleBranch) time.Time { return s.LastCommit }
+
+	if got := staleBranchNames(limitOldest(stale, 2, "stale", lastCommit)); got != "b,d" {
+		t.Errorf("expected the two oldest in scan order, got %s", got)
+	}
+	if got := limitOldest(stale, 0, "stale", lastCommit); len(got) != 4 {
+		t.Errorf("expected no limit to keep everything, got %d", len(got))
+	}
+	if got := limitOldest(stale, 10, "stale", lastCommit); len(got) != 4 {
+		t.Errorf("expected a limit above the count to keep everything, got %d", len(got))
+	}
+}
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 )
 
 func TestCategorizeStaleBranches(t *testing.T) {
@@ -12,6 +18,7 @@ func TestCategorizeStaleBranches(t *testing.T) {
 		input          []branches.StaleBranch
 		wantSafe       int
 		wantAutomation int
+		wantDiverged   int
 		wantReview     int
 	}{
 		{
@@ -46,6 +53,43 @@ func TestCategorizeStaleBranches(t *testing.T) {
 			},
 			wantReview: 1,
 		},
+		{
+			name: "own branch with an open draft PR goes to review",
+			input: []branches.StaleBranch{
+				{Branch: "draft", HasRemote: true, IsOwnBranch: true, OpenPRNumber: 7, OpenPRState: "draft"},
+			},
+			wantReview: 1,
+		},
+		{
+			name: "diverged own branch goes to diverged",
+			input: []branches.StaleBranch{
+				{Branch: "rebased", HasRemote: true, IsOwnBranch: true, Diverged: true, UpstreamAhead: 2, UpstreamBehind: 3},
+			},
+			wantDiverged: 1,
+		},
+		{
+			name: "diverged automation branch stays in automation",
+			input: []branches.StaleBranch{
+				{Branch: "dependabot/go/y", IsAutomation: true, HasRemote: true, Diverged: true},
+			},
+			wantAutomation: 1,
+		},
+		{
+			name: "own branch with a gone upstream and a reachable tip goes to safe",
+			input: []branches.StaleBranch{
+				{Branch: "landed", IsUpstreamGone: true, IsOwnBranch: true, TipReachable: true},
+				{Branch: "squashed", IsUpstreamGone: true, IsOwnBranch: true, PRNumber: 12},
+			},
+			wantSafe: 2,
+		},
+		{
+			name: "unverified or other-author branch with a gone upstream goes to review",
+			input: []branches.StaleBranch{
+				{Branch: "unpushed-work", IsUpstreamGone: true, IsOwnBranch: true},
+				{Branch: "colleague", IsUpstreamGone: true, TipReachable: true},
+			},
+			wantReview: 2,
+		},
 		{
 			name: "automation without remote still goes to automation",
 			input: []branches.StaleBranch{
@@ -70,22 +114,164 @@ func TestCategorizeStaleBranches(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			safe, automation, review := categorizeStaleBranches(tt.input)
+			safe, automation, diverged, review := categorizeStaleBranches(tt.input)
 			if len(safe) != tt.wantSafe {
 				t.Errorf("safe: got %d, want %d", len(safe), tt.wantSafe)
 			}
 			if len(automation) != tt.wantAutomation {
 				t.Errorf("automation: got %d, want %d", len(automation), tt.wantAutomation)
 			}
+			if len(diverged) != tt.wantDiverged {
+				t.Errorf("diverged: got %d, want %d", len(diverged), tt.wantDiverged)
+			}
 			if len(review) != tt.wantReview {
 				t.Errorf("review: got %d, want %d", len(review), tt.wantReview)
 			}
 
 			// Verify no branches were lost or duplicated.
-			total := len(safe) + len(automation) + len(review)
+			total := len(safe) + len(automation) + len(diverged) + len(review)
 			if total != len(tt.input) {
 				t.Errorf("total categorized: got %d, want %d", total, len(tt.input))
 			}
 		})
 	}
 }
+
+func TestExplainStaleTiers(t *testing.T) {
+	stale := []branches.StaleBranch{
+		{RepoName: "r", Branch: "mine", HasRemote: true, IsOwnBranch: true},
+		{RepoName: "r", Branch: "dependabot/npm", HasRemote: true, IsOwnBranch: true, IsAutomation: true},
+		{RepoName: "r", Branch: "shared", HasRemote: true},
+		{RepoName: "r", Branch: "local", IsOwnBranch: true},
+		{RepoName: "r", Branch: "rebased", HasRemote: true, IsOwnBranch: true, Diverged: true, UpstreamAhead: 1, UpstreamBehind: 4},
+	}
+	ex := explain.New()
+	explainStaleTiers(stale, ex)
+
+	protected := make(map[string]string)
+	for _, e := range ex.Entries() {
+		if e.Verdict == explain.Protected {
+			protected[e.Subject] = e.Reason
+		}
+	}
+	if len(protected) != 3 {
+		t.Fatalf("expected 3 protected branches, got %v", protected)
+	}
+	if !strings.Contains(protected["dependabot/npm"], "automation") {
+		t.Errorf("automation branch reason: %q", protected["dependabot/npm"])
+	}
+	if !strings.Contains(protected["shared"], "other authors") {
+		t.Errorf("other-author branch reason: %q", protected["shared"])
+	}
+	if !strings.Contains(protected["rebased"], "commits the local one lacks") {
+		t.Errorf("diverged branch reason: %q", protected["rebased"])
+	}
+
+	// A nil log must be a no-op.
+	explainStaleTiers(stale, nil)
+}
+
+func TestStaleBranchLabelShowsOtherAuthors(t *testing.T) {
+	s := branches.StaleBranch{
+		RepoName: "r", Branch: "shared", HasRemote: true,
+		Authors: []string{"me@example.com", "other@example.com"},
+	}
+	if label := staleBranchLabel(s); !strings.Contains(label, "[authors: me@example.com, other@example.com]") {
+		t.Errorf("expected authors in label, got %q", label)
+	}
+
+	s.IsOwnBranch = true
+	if label := staleBranchLabel(s); strings.Contains(label, "authors:") {
+		t.Errorf("own branch should not list authors, got %q", label)
+	}
+}
+
+func TestStaleBranchLabelShowsOpenDraftPR(t *testing.T) {
+	s := branches.StaleBranch{
+		RepoName: "r", Branch: "draft", HasRemote: true, IsOwnBranch: true,
+		OpenPRNumber: 7, OpenPRState: "draft, changes requested",
+	}
+	if label := staleBranchLabel(s); !strings.Contains(label, "[open PR #7: draft, changes requested]") {
+		t.Errorf("expected the PR state in the label, got %q", label)
+	}
+	if safeToDeleteRemote(s) {
+		t.Error("expected a branch with an open PR to keep its remote")
+	}
+}
+
+func TestStaleBranchLabelShowsPROutcome(t *testing.T) {
+	s := branches.StaleBranch{
+		RepoName: "r", Branch: "abandoned", HasRemote: true, IsOwnBranch: true,
+		ClosedPRNumber: 4, CIStatus: ghclient.CIFailing,
+	}
+	if label := staleBranchLabel(s); !strings.HasSuffix(label, "[PR #4 closed unmerged] [CI failing]") {
+		t.Errorf("expected the closed PR and failing CI in the label, got %q", label)
+	}
+
+	s.ClosedPRNumber, s.PRNumber, s.CIStatus = 0, 5, ghclient.CIPassing
+	if label := staleBranchLabel(s); !strings.HasSuffix(label, "[merged PR #5] [all checks green]") {
+		t.Errorf("expected the merged PR and green checks in the label, got %q", label)
+	}
+}
+
+func TestConfirmOtherAuthorDeletesDefaultsToKeeping(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{Mode: ui.ModePlain}, true)
+
+	selected := []branches.StaleBranch{
+		{RepoName: "r", Branch: "mine", IsOwnBranch: true},
+		{RepoName: "r", Branch: "shared", Authors: []string{"other@example.com"}},
+		{RepoName: "r", Branch: "dependabot/npm", IsAutomation: true},
+	}
+	kept, err := confirmOtherAuthorDeletes(selected)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(kept) != 2 {
+		t.Fatalf("expected other-author branch to be dropped, got %+v", kept)
+	}
+	for _, s := range kept {
+		if s.Branch == "shared" {
+			t.Error("other-author branch should require explicit confirmation")
+		}
+	}
+}
+
+func TestStaleAgeBuckets(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	ago := func(days int) time.Time { return now.Add(-time.Duration(days) * 24 * time.Hour) }
+	stale := []branches.StaleBranch{
+		{RepoName: "api", LastCommit: ago(10)},
+		{RepoName: "api", LastCommit: ago(45)},
+		{RepoName: "web", LastCommit: ago(100)},
+		{RepoName: "api", LastCommit: ago(200)},
+		{RepoName: "web", LastCommit: ago(400)},
+		{RepoName: "cli", LastCommit: ago(1000)},
+	}
+
+	var got []int
+	for _, b := range staleAgeBuckets(stale, now) {
+		got = append(got, b.count)
+	}
+	if fmt.Sprint(got) != "[2 1 1 2]" {
+		t.Errorf("expected bucket counts [2 1 1 2], got %v", got)
+	}
+
+	repos := staleRepoCounts(stale)
+	if len(repos) != 3 || repos[0] != (repoCount{"api", 3}) || repos[1] != (repoCount{"web", 2}) || repos[2] != (repoCount{"cli", 1}) {
+		t.Errorf("unexpected repo counts %+v", repos)
+	}
+
+	months := staleMonthHistogram(stale, now)
+	if months[0] != 1 || months[1] != 1 || months[histogramMonths-1] != 1 {
+		t.Errorf("unexpected month histogram %v", months)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int{0, 1, 4, 8}); got != " ▁▄█" {
+		t.Errorf("unexpected sparkline %q", got)
+	}
+	if got := sparkline([]int{0, 0}); got != "  " {
+		t.Errorf("expected blanks without data, got %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+)
+
+// staleAgeBucket counts the stale branches whose last commit falls in an
+// age range.
+type staleAgeBucket struct {
+	label string
+	max   time.Duration // exclusive upper bound, 0 for the last bucket
+	count int
+}
+
+// day is used for age ranges.
+const day = 24 * time.Hour
+
+// staleAgeBuckets groups stale branches by the age of their last commit.
+// The first bucket also holds anything younger than a month, which only
+// happens with a --stale-days below 30.
+func staleAgeBuckets(stale []branches.StaleBranch, now time.Time) []staleAgeBucket {
+	buckets := []staleAgeBucket{
+		{label: "up to 3 months", max: 90 * day},
+		{label: "3-6 months", max: 180 * day},
+		{label: "6-12 months", max: 365 * day},
+		{label: "over 1 year"},
+	}
+	for _, s := range stale {
+		age := now.Sub(s.LastCommit)
+		for i := range buckets {
+			if buckets[i].max == 0 || age < buckets[i].max {
+				buckets[i].count++
+				break
+			}
+		}
+	}
+	return buckets
+}
+
+// repoCount is the number of stale branches in one repository.
+type repoCount struct {
+	repo  string
+	count int
+}
+
+// staleRepoCounts returns the number of stale branches per repository,
+// most first and by name among equals.
+func staleRepoCounts(stale []branches.StaleBranch) []repoCount {
+	counts := make(map[string]int)
+	for _, s := range stale {
+		counts[s.RepoName]++
+	}
+	list := make([]repoCount, 0, len(counts))
+	for repo, n := range counts {
+		list = append(list, repoCount{repo, n})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].count != list[j].count {
+			return list[i].count > list[j].count
+		}
+		return list[i].repo < list[j].repo
+	})
+	return list
+}
+
+// histogramMonths is how many monthly columns the age histogram has; the
+// last one also holds everything older.
+const histogramMonths = 24
+
+// staleMonthHistogram counts stale branches per month of age, from under
+// a month old to histogramMonths-1 months or more.
+func staleMonthHistogram(stale []branches.StaleBranch, now time.Time) []int {
+	counts := make([]int, histogramMonths)
+	for _, s := range stale {
+		month := int(now.Sub(s.LastCommit) / (30 * day))
+		counts[max(0, min(month, histogramMonths-1))]++
+	}
+	return counts
+}
+
+// sparkline renders counts as a row of block characters scaled to the
+// largest count. Zero counts render as a space.
+func sparkline(counts []int) string {
+	levels := []rune("▁▂▃▄▅▆▇█")
+	peak := 0
+	for _, n := range counts {
+		peak = max(peak, n)
+	}
+	var b strings.Builder
+	for _, n := range counts {
+		if n == 0 || peak == 0 {
+			b.WriteRune(' ')
+			continue
+		}
+		b.WriteRune(levels[(n*len(levels)-1)/peak])
+	}
+	return b.String()
+}
+
+// maxSummaryRepos is how many repositories the age summary lists by name.
+const maxSummaryRepos = 5
+
+// ageBarWidth is the length of the bar drawn for the largest age bucket.
+const ageBarWidth = 30
+
+// printStaleAgeSummary shows how old the stale branches are and where they
+// pile up, so the scale of the cleanup is clear before any prompt. With
+// histogram it adds a month-by-month sparkline of their ages.
+func printStaleAgeSummary(stale []branches.StaleBranch, now time.Time, histogram bool) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	buckets := staleAgeBuckets(stale, now)
+	peak := 0
+	for _, b := range buckets {
+		peak = max(peak, b.count)
+	}
+	fmt.Println(bold.Sprint("Stale branches by age:"))
+	for _, b := range buckets {
+		bar := ""
+		if peak > 0 && b.count > 0 {
+			bar = strings.Repeat("█", max(1, b.count*ageBarWidth/peak))
+		}
+		fmt.Printf("  %-15s %4d  %s\n", b.label, b.count, bar)
+	}
+
+	repos := staleRepoCounts(stale)
+	fmt.Println(bold.Sprint("Stale branches by repository:"))
+	width := 0
+	for _, r := range repos[:min(len(repos), maxSummaryRepos)] {
+		width = max(width, len(r.repo))
+	}
+	for _, r := range repos[:min(len(repos), maxSummaryRepos)] {
+		fmt.Printf("  %-*s %4d\n", width, r.repo, r.count)
+	}
+	if rest := len(repos) - maxSummaryRepos; rest > 0 {
+		fmt.Println(dim.Sprintf("  and %d more %s", rest, pluralize(rest, "repository", "repositories")))
+	}
+
+	if histogram {
+		fmt.Println(bold.Sprint("Age by month:"))
+		fmt.Printf("  |%s|  %s\n", sparkline(staleMonthHistogram(stale, now)),
+			dim.Sprintf("one column per month, newest first; the last holds %d+ months", histogramMonths-1))
+	}
+	fmt.Println()
+}
@@ -1,23 +1,31 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/charmbracelet/huh"
 	"github.com/fatih/color"
 
-	"github.com/agrahamlincoln/katazuke/internal/config"
-	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/internal/sync"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
 // SyncCmd handles repository synchronization.
 type SyncCmd struct {
-	Pattern string `name:"pattern" short:"f" help:"Filter repositories by name pattern (glob)." default:""`
+	Pattern           string `name:"pattern" short:"f" help:"Filter repositories by name or group path (glob, e.g. '*kafka*' or 'work/*')." default:""`
+	InteractiveSelect bool   `name:"interactive-select" help:"Pick the repositories to sync from a list before syncing."`
+	Status            bool   `help:"Show when each repository last synced successfully, and which have not in over a week, instead of syncing."`
+	Details           bool   `help:"After syncing, list the commits each repository pulled and the top-level paths they changed."`
+	Digest            bool   `help:"After syncing, summarize what changed upstream in each repository since it last synced: new tags and merges into the default branch."`
 }
 
 // Run executes the sync command.
@@ -39,14 +47,26 @@ func (c *SyncCmd) Run(globals *CLI) error {
 	if c.Pattern != "" {
 		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
 	}
+	if c.InteractiveSelect {
+		flags = append(flags, "--interactive-select")
+	}
+	if c.Status {
+		flags = append(flags, "--status")
+	}
+	if c.Details {
+		flags = append(flags, "--details")
+	}
+	if c.Digest {
+		flags = append(flags, "--digest")
+	}
 	_ = ml.LogCommand("sync", flags)
 
-	cfg, err := config.Load()
+	cfg, err := globals.loadConfig()
 	if err != nil {
 		return fmt.Errorf("loading config: %w", err)
 	}
 
-	repoPaths, isLocal, err := resolveRepos(globals, cfg)
+	repoPaths, isLocal, err := resolveReposIncludingBare(globals, cfg)
 	if err != nil {
 		return err
 	}
@@ -57,96 +77,533 @@ func (c *SyncCmd) Run(globals *CLI) error {
 	}
 
 	if c.Pattern != "" {
-		repoPaths = filterByPattern(repoPaths, c.Pattern)
+		repoPaths, err = filterByPattern(repoPaths, c.Pattern, resolveProjectsDir(globals.ProjectsDir, cfg))
+		if err != nil {
+			return err
+		}
 		if len(repoPaths) == 0 {
 			fmt.Printf("No repositories matching %q found.\n", c.Pattern)
 			return nil
 		}
 	}
 
+	if c.InteractiveSelect && !isLocal {
+		repoPaths, err = selectRepos(repoPaths, resolveProjectsDir(globals.ProjectsDir, cfg))
+		if err != nil {
+			return err
+		}
+		if len(repoPaths) == 0 {
+			fmt.Println("No repositories selected.")
+			return nil
+		}
+	}
+
 	slog.Debug("found repositories", "count", len(repoPaths))
 
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	statePath, err := sync.DefaultStatePath()
+	if err != nil {
+		return err
+	}
+	if c.Status {
+		state, err := sync.LoadState(statePath)
+		if err != nil {
+			return err
+		}
+		printSyncStatus(repoPaths, state, projectsDir, time.Now())
+		return nil
+	}
+
 	opts := sync.Options{
 		Strategy:           cfg.Sync.Strategy,
 		SkipDirty:          cfg.Sync.SkipDirty,
 		AutoStash:          cfg.Sync.AutoStash,
 		SwitchMergedBranch: cfg.Sync.SwitchMergedBranch,
+		DirtyAction:        cfg.Sync.DirtyAction,
 		DryRun:             globals.DryRun,
 		Verbose:            globals.Verbose,
+		HostOf:             repoHost(cfg.HostLimits),
+		HostLimits:         cfg.HostLimits,
+		Retries:            cfg.Sync.Retries,
+		RetryDelay:         time.Duration(cfg.Sync.RetryDelaySeconds) * time.Second,
+		Details:            c.Details,
 	}
 
-	workers := cfg.Workers
+	workers := workersFor(cfg, parallel.NetworkWork, len(repoPaths))
 	slog.Debug("using worker pool", "workers", workers)
 	printRepoCount("Syncing", len(repoPaths), isLocal, "...\n")
 
-	green := color.New(color.FgGreen)
-	yellow := color.New(color.FgYellow)
-	red := color.New(color.FgRed)
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
 	bold := color.New(color.Bold)
-	dim := color.New(color.FgHiBlack)
 
-	gh := ghclient.NewClient(cfg.GithubToken)
+	// The digest looks back to each repository's last sync, which this
+	// run is about to overwrite.
+	var prior sync.State
+	if c.Digest {
+		if prior, err = sync.LoadState(statePath); err != nil {
+			slog.Debug("could not load sync state, digest covers the default window", "error", err)
+		}
+	}
+
+	gh := newGitHubClient(cfg)
 	detector := merge.NewDetector(merge.RealGitChecker{}, gh)
 	gitOps := sync.NewRealGitOps(detector)
 
-	var synced, skipped, failed, switched, upToDate int
+	var synced, skipped, failed, switched, upToDate, diverged, detached int
+	var wipResults []sync.Result
+	var needAuth, unreachable []string
+	var notOwned []sync.Result
 	syncStart := time.Now()
 
-	sync.All(repoPaths, opts, gitOps, workers, func(completed, total int, r sync.Result) {
-		remaining := total - completed
+	progress := newProgress()
+	results := sync.All(repoPaths, opts, gitOps, workers, func(completed, total int, r sync.Result) {
+		r.RepoName = groupedName(projectsDir, r.RepoPath)
+		if r.WIPBranch != "" {
+			wipResults = append(wipResults, r)
+		}
 
-		// Clear the status line, print result, redraw status.
-		fmt.Print(clearLine)
 		switch r.Status {
 		case sync.Synced:
 			synced++
 			if r.Message != "" {
-				fmt.Printf("  %s %s: %s\n", green.Sprint("[synced]"), r.RepoName, r.Message)
+				progress.Printf("  %s %s: %s", success.Sprint("[synced]"), r.RepoName, r.Message)
 			} else {
-				fmt.Printf("  %s %s\n", green.Sprint("[synced]"), r.RepoName)
+				progress.Printf("  %s %s", success.Sprint("[synced]"), r.RepoName)
 			}
 		case sync.UpToDate:
 			upToDate++
 		case sync.Switched:
 			switched++
-			fmt.Printf("  %s %s: %s\n", green.Sprint("[switched]"), r.RepoName, r.Message)
+			progress.Printf("  %s %s: %s", success.Sprint("[switched]"), r.RepoName, r.Message)
 		case sync.Skipped:
 			skipped++
-			fmt.Printf("  %s %s: %s\n", yellow.Sprint("[skip]"), r.RepoName, r.Message)
+			label := "[skip]"
+			switch r.SkipReason {
+			case sync.SkipAuthRequired:
+				needAuth = append(needAuth, r.RepoName)
+				label = "[auth]"
+			case sync.SkipUnreachable:
+				unreachable = append(unreachable, r.RepoName)
+				label = "[unreachable]"
+			case sync.SkipInProgress:
+				label = "[in progress]"
+			case sync.SkipDubiousOwnership:
+				notOwned = append(notOwned, r)
+				label = "[ownership]"
+			}
+			progress.Printf("  %s %s: %s", warn.Sprint(label), r.RepoName, r.Message)
 		case sync.Failed:
 			failed++
-			fmt.Printf("  %s %s: %s\n", red.Sprint("[fail]"), r.RepoName, r.Message)
-		}
-
-		if remaining > 0 {
-			fmt.Printf("%s  %s %d remaining...",
-				clearLine, dim.Sprintf("[%d/%d]", completed, total),
-				remaining)
+			progress.Printf("  %s %s: %s", fail.Sprint("[fail]"), r.RepoName, r.Message)
+		case sync.Diverged:
+			diverged++
+			progress.Printf("  %s %s: %s", warn.Sprint("[diverged]"), r.RepoName, r.Message)
+		case sync.Detached:
+			detached++
+			progress.Printf("  %s %s: %s", warn.Sprint("[detached]"), r.RepoName, r.Message)
 		}
+		progress.Update(completed, total)
 	})
+	progress.Stop()
 
 	_ = ml.LogPerf(len(repoPaths), int(time.Since(syncStart).Milliseconds()))
 
-	// Clear final status line.
-	fmt.Print(clearLine)
 	fmt.Println()
 	summary := fmt.Sprintf("Synced %d, up-to-date %d, switched %d, skipped %d, failed %d", synced, upToDate, switched, skipped, failed)
+	if diverged > 0 {
+		summary += fmt.Sprintf(", diverged %d", diverged)
+	}
+	if detached > 0 {
+		summary += fmt.Sprintf(", detached %d", detached)
+	}
 	if globals.DryRun {
 		summary += " (dry run)"
 	}
 	fmt.Println(bold.Sprint(summary))
+
+	if c.Details {
+		printSyncDetails(results, projectsDir)
+	}
+	if c.Digest {
+		digests := sync.Digests(results, prior, gitOps, workersFor(cfg, parallel.LocalWork, len(results)), time.Now())
+		printSyncDigest(digests, projectsDir)
+	}
+	printUnusableRemotes(needAuth, unreachable)
+	if len(notOwned) > 0 {
+		offerSafeDirectories(notOwned, globals.DryRun)
+	}
+	if len(wipResults) > 0 {
+		printWIPRecovery(wipResults)
+	}
+	if diverged > 0 && !globals.DryRun {
+		resolveDiverged(results, projectsDir, gitOps)
+	}
+	if detached > 0 && !globals.DryRun {
+		resolveDetached(results, projectsDir, opts, gitOps)
+	}
+	if !globals.DryRun {
+		saveSyncState(statePath, results, time.Now())
+	}
 	return nil
 }
 
-// filterByPattern filters repository paths by matching the base name against
-// a glob pattern.
-func filterByPattern(repos []string, pattern string) []string {
-	var filtered []string
-	for _, repoPath := range repos {
-		name := filepath.Base(repoPath)
-		if matched, _ := filepath.Match(pattern, name); matched {
-			filtered = append(filtered, repoPath)
+// printSyncDetails lists, for each repository a pull brought something
+// into, the new commits and the top-level paths they changed.
+func printSyncDetails(results []sync.Result, projectsDir string) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	var landed []sync.Result
+	for _, r := range results {
+		if len(r.NewCommits) > 0 || len(r.ChangedPaths) > 0 {
+			r.RepoName = groupedName(projectsDir, r.RepoPath)
+			landed = append(landed, r)
+		}
+	}
+	if len(landed) == 0 {
+		return
+	}
+	sort.Slice(landed, func(i, j int) bool { return landed[i].RepoName < landed[j].RepoName })
+
+	fmt.Printf("\n%s\n", bold.Sprint("What landed:"))
+	for _, r := range landed {
+		count := max(r.CommitsPulled, len(r.NewCommits))
+		fmt.Printf("\n  %s  %s\n", bold.Sprint(r.RepoName), dim.Sprintf("%d %s", count, pluralize(count, "commit", "commits")))
+		for _, c := range r.NewCommits {
+			fmt.Printf("    %s %s %s\n", dim.Sprint(c.SHA[:min(7, len(c.SHA))]), c.Subject, dim.Sprintf("(%s)", c.Author))
+		}
+		if more := count - len(r.NewCommits); more > 0 {
+			fmt.Printf("    %s\n", dim.Sprintf("... and %d more", more))
+		}
+		if len(r.ChangedPaths) > 0 {
+			fmt.Printf("    changed: %s\n", strings.Join(r.ChangedPaths, ", "))
+		}
+	}
+	fmt.Println()
+}
+
+// printSyncDigest lists, per repository, the tags created and the merges
+// into the default branch since it last synced.
+func printSyncDigest(digests []sync.Digest, projectsDir string) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+	cyan := color.New(color.FgCyan)
+
+	fmt.Println()
+	if len(digests) == 0 {
+		fmt.Println("Nothing notable upstream since the last sync.")
+		return
+	}
+	for i := range digests {
+		digests[i].RepoName = groupedName(projectsDir, digests[i].RepoPath)
+	}
+	sort.Slice(digests, func(i, j int) bool { return digests[i].RepoName < digests[j].RepoName })
+
+	fmt.Println(bold.Sprintf("Upstream changes in %d %s:", len(digests), pluralize(len(digests), "repository", "repositories")))
+	for _, d := range digests {
+		fmt.Printf("\n  %s  %s\n", bold.Sprint(d.RepoName), dim.Sprintf("since %s", d.Since.Format("2006-01-02 15:04")))
+		for _, t := range d.Tags {
+			fmt.Printf("    %s %s\n", cyan.Sprint("tag"), t.Name)
+		}
+		for _, m := range d.Merges {
+			fmt.Printf("    %s %s %s\n", dim.Sprint(m.SHA[:min(7, len(m.SHA))]), m.Subject, dim.Sprintf("(%s)", m.Author))
+		}
+		if len(d.Merges) == sync.MaxDigestMerges {
+			fmt.Printf("    %s\n", dim.Sprintf("... showing the latest %d merges into %s", sync.MaxDigestMerges, d.Ref))
+		}
+	}
+	fmt.Println()
+}
+
+// printUnusableRemotes lists the repositories skipped because their
+// remote asked for credentials, which sync never prompts for, or could not
+// be reached.
+func printUnusableRemotes(needAuth, unreachable []string) {
+	warn := ui.Warn()
+	list := func(names []string, why string) {
+		sort.Strings(names)
+		fmt.Println(warn.Sprintf("%d %s skipped because %s:", len(names), pluralize(len(names), "repository was", "repositories were"), why))
+		for _, name := range names {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	if len(needAuth) > 0 {
+		list(needAuth, "the remote needs credentials")
+		fmt.Println("Set up an SSH key or a git credential helper for them, or fetch them once by hand.")
+	}
+	if len(unreachable) > 0 {
+		list(unreachable, "the remote could not be reached")
+	}
+}
+
+// offerSafeDirectories lists the repositories git refused to work in
+// because another user owns them, and asks whether to trust them by adding
+// each to safe.directory in the global git config.
+func offerSafeDirectories(results []sync.Result, dryRun bool) {
+	warn := ui.Warn()
+	success := ui.Success()
+	fail := ui.Error()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].RepoName < results[j].RepoName })
+	n := len(results)
+	fmt.Println(warn.Sprintf("%d %s skipped because another user owns %s:", n,
+		pluralize(n, "repository was", "repositories were"), pluralize(n, "it", "them")))
+	for _, r := range results {
+		fmt.Printf("  %s\n", r.RepoName)
+	}
+	if dryRun {
+		return
+	}
+
+	var trust bool
+	err := newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("Add %s to safe.directory in your global git config?", pluralize(n, "it", "them"))).
+				Description("Only do this for repositories whose owner you trust: git runs hooks and config from them.").
+				Value(&trust),
+		),
+	).Run()
+	if errors.Is(err, errNonInteractive) {
+		fmt.Println("Trust them with 'git config --global --add safe.directory <path>', or run 'katazuke sync' in a terminal.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("  %s prompt failed: %v\n", fail.Sprint("[fail]"), err)
+		return
+	}
+	if !trust {
+		return
+	}
+	for _, r := range results {
+		if err := git.AddSafeDirectory(r.RepoPath); err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.RepoName, err)
+			continue
+		}
+		fmt.Printf("  %s %s\n", success.Sprint("[trusted]"), r.RepoName)
+	}
+	fmt.Println("Run 'katazuke sync' again to sync them.")
+}
+
+// resolveDiverged asks, for each repository whose default branch has
+// diverged from its remote, whether to rebase the local commits, back them
+// up and reset, or leave the branch alone. Results are updated in place so
+// the saved sync state reflects the resolution.
+func resolveDiverged(results []sync.Result, projectsDir string, gitOps sync.GitOps) {
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+
+	var idx []int
+	for i, r := range results {
+		if r.Status == sync.Diverged {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool { return results[idx[a]].RepoPath < results[idx[b]].RepoPath })
+
+	fmt.Println()
+	for _, i := range idx {
+		r := results[i]
+		r.RepoName = groupedName(projectsDir, r.RepoPath)
+		remoteRef := r.Remote + "/" + r.Branch
+
+		choice := sync.ResolveSkip
+		err := newForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(fmt.Sprintf("%s: %s has %d local and %d remote %s", r.RepoName, r.Branch,
+						r.CommitsAhead, r.CommitsBehind, pluralize(r.CommitsBehind, "commit", "commits"))).
+					Options(
+						huh.NewOption(fmt.Sprintf("Rebase local commits onto %s", remoteRef), sync.ResolveRebase),
+						huh.NewOption(fmt.Sprintf("Save local commits on a backup branch, then reset to %s", remoteRef), sync.ResolveReset),
+						huh.NewOption("Skip", sync.ResolveSkip),
+					).
+					Value(&choice),
+			),
+		).Run()
+		if errors.Is(err, errNonInteractive) {
+			fmt.Printf("%s %d diverged %s left as is; run 'katazuke sync' in a terminal to resolve %s.\n",
+				warn.Sprint("Note:"), len(idx), pluralize(len(idx), "repository", "repositories"),
+				pluralize(len(idx), "it", "them"))
+			return
+		}
+		if err != nil {
+			fmt.Printf("  %s %s: prompt failed: %v\n", fail.Sprint("[fail]"), r.RepoName, err)
+			continue
+		}
+
+		resolved := sync.ResolveDiverged(r, choice, gitOps, time.Now())
+		results[i] = resolved
+		switch resolved.Status {
+		case sync.Synced:
+			fmt.Printf("  %s %s: %s\n", success.Sprint("[synced]"), r.RepoName, resolved.Message)
+		case sync.Failed:
+			fmt.Printf("  %s %s: %s\n", fail.Sprint("[fail]"), r.RepoName, resolved.Message)
+		default:
+			fmt.Printf("  %s %s: %s\n", warn.Sprint("[skip]"), r.RepoName, resolved.Message)
+		}
+	}
+}
+
+// resolveDetached asks, for each repository left on a detached HEAD that
+// no branch or tag keeps, whether to save the commit on a branch before
+// switching to the default branch, switch anyway, or leave it. Results
+// are updated in place so the saved sync state reflects the resolution.
+func resolveDetached(results []sync.Result, projectsDir string, opts sync.Options, gitOps sync.GitOps) {
+	success := ui.Success()
+	warn := ui.Warn()
+	fail := ui.Error()
+
+	var idx []int
+	for i, r := range results {
+		if r.Status == sync.Detached {
+			idx = append(idx, i)
+		}
+	}
+	sort.Slice(idx, func(a, b int) bool { return results[idx[a]].RepoPath < results[idx[b]].RepoPath })
+
+	fmt.Println()
+	for _, i := range idx {
+		r := results[i]
+		r.RepoName = groupedName(projectsDir, r.RepoPath)
+
+		title := fmt.Sprintf("%s: detached HEAD at %s", r.RepoName, r.Detached.Describe())
+		if !r.Detached.Date.IsZero() {
+			title += ", committed " + formatAge(r.Detached.Date)
+		}
+		choice := sync.ResolveSkip
+		err := newForm(
+			huh.NewGroup(
+				huh.NewSelect[string]().
+					Title(title).
+					Options(
+						huh.NewOption(fmt.Sprintf("Create a branch at this commit, then switch to %s", r.Branch), sync.ResolveKeepBranch),
+						huh.NewOption(fmt.Sprintf("Switch to %s (the commit stays in the reflog)", r.Branch), sync.ResolveSwitch),
+						huh.NewOption("Skip", sync.ResolveSkip),
+					).
+					Value(&choice),
+			),
+		).Run()
+		if errors.Is(err, errNonInteractive) {
+			fmt.Printf("%s %d detached %s left as is; run 'katazuke sync' in a terminal to resolve %s.\n",
+				warn.Sprint("Note:"), len(idx), pluralize(len(idx), "repository", "repositories"),
+				pluralize(len(idx), "it", "them"))
+			return
 		}
+		if err != nil {
+			fmt.Printf("  %s %s: prompt failed: %v\n", fail.Sprint("[fail]"), r.RepoName, err)
+			continue
+		}
+
+		resolved := sync.ResolveDetached(r, choice, opts, gitOps, time.Now())
+		results[i] = resolved
+		switch resolved.Status {
+		case sync.Switched, sync.Synced:
+			fmt.Printf("  %s %s: %s\n", success.Sprint("[switched]"), r.RepoName, resolved.Message)
+		case sync.Diverged:
+			fmt.Printf("  %s %s: %s\n", warn.Sprint("[diverged]"), r.RepoName, resolved.Message)
+		case sync.Failed:
+			fmt.Printf("  %s %s: %s\n", fail.Sprint("[fail]"), r.RepoName, resolved.Message)
+		default:
+			fmt.Printf("  %s %s: %s\n", warn.Sprint("[skip]"), r.RepoName, resolved.Message)
+		}
+	}
+}
+
+// printWIPRecovery explains how to get uncommitted changes back after the
+// wip-commit dirty action moved them to a side branch.
+func printWIPRecovery(results []sync.Result) {
+	sort.Slice(results, func(i, j int) bool { return results[i].RepoName < results[j].RepoName })
+
+	dim := ui.Muted()
+	fmt.Printf("\nUncommitted changes were committed to WIP branches:\n")
+	for _, r := range results {
+		fmt.Printf("  %s: %s\n", r.RepoName, r.WIPBranch)
+	}
+	fmt.Println(dim.Sprint("To restore them onto the current branch, run in each repo:"))
+	fmt.Println(dim.Sprint("  git cherry-pick --no-commit <branch> && git reset && git branch -D <branch>"))
+}
+
+// syncStaleAfter is how long since its last successful sync before
+// sync --status calls a repository out.
+const syncStaleAfter = 7 * 24 * time.Hour
+
+// saveSyncState records the results of a run in the sync state file for
+// sync --status. It is best-effort: a failure is logged at debug level and
+// never fails the sync.
+func saveSyncState(path string, results []sync.Result, at time.Time) {
+	state, err := sync.LoadState(path)
+	if err != nil {
+		slog.Debug("could not load sync state, starting over", "error", err)
+	}
+	for _, r := range results {
+		state.Record(r, at)
+	}
+	if err := state.Save(path); err != nil {
+		slog.Debug("could not save sync state", "error", err)
+	}
+}
+
+// printSyncStatus lists the repositories in scope by when they last synced
+// successfully, least recent first, and then those not synced within
+// syncStaleAfter.
+func printSyncStatus(repoPaths []string, state sync.State, projectsDir string, now time.Time) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+	warn := ui.Warn()
+	fail := ui.Error()
+
+	type row struct {
+		name  string
+		state sync.RepoState
+	}
+	rows := make([]row, len(repoPaths))
+	for i, p := range repoPaths {
+		rows[i] = row{groupedName(projectsDir, p), state.Repos[p]}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		si, sj := rows[i].state.LastSuccess, rows[j].state.LastSuccess
+		if !si.Equal(sj) {
+			return si.Before(sj)
+		}
+		return rows[i].name < rows[j].name
+	})
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Last successful sync of %d %s:", len(rows), pluralize(len(rows), "repository", "repositories")))
+	var overdue []string
+	for _, r := range rows {
+		last := "never"
+		if !r.state.LastSuccess.IsZero() {
+			last = formatAgeSince(r.state.LastSuccess, now)
+		}
+		if r.state.LastSuccess.IsZero() || now.Sub(r.state.LastSuccess) > syncStaleAfter {
+			overdue = append(overdue, r.name)
+			last = warn.Sprint(last)
+		}
+
+		note := ""
+		switch {
+		case r.state.LastRun.IsZero():
+		case r.state.LastStatus == sync.Failed.String():
+			note = fail.Sprintf("  last run failed %s: %s", formatAgeSince(r.state.LastRun, now), r.state.Message)
+		case r.state.LastStatus == sync.Skipped.String():
+			note = dim.Sprintf("  last run skipped %s: %s", formatAgeSince(r.state.LastRun, now), r.state.Message)
+		case r.state.CommitsPulled > 0:
+			note = dim.Sprintf("  pulled %d %s", r.state.CommitsPulled, pluralize(r.state.CommitsPulled, "commit", "commits"))
+		}
+		fmt.Printf("  %s  %s%s\n", bold.Sprint(r.name), last, note)
+	}
+
+	fmt.Println()
+	if len(overdue) == 0 {
+		fmt.Println("Every repository has synced within the last week.")
+		return
+	}
+	fmt.Println(bold.Sprintf("%d %s not synced in over a week:", len(overdue), pluralize(len(overdue), "repository", "repositories")))
+	for _, name := range overdue {
+		fmt.Printf("  %s\n", name)
 	}
-	return filtered
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/backup"
+	"github.com/agrahamlincoln/katazuke/internal/metrics"
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/tags"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// TagsCmd finds and removes tags that are no longer useful.
+type TagsCmd struct {
+	ArchiveDays int  `name:"archive-days" help:"Days before an archive/* tag is considered expired." default:"90"`
+	Offline     bool `name:"offline" help:"Skip comparing tags against the primary remote (tags missing from it are not detected)."`
+}
+
+// Run executes the tags command.
+func (c *TagsCmd) Run(globals *CLI) error {
+	if globals.Verbose {
+		enableVerboseLogging()
+	}
+
+	ml := metrics.NewOrNil()
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if c.Offline {
+		flags = append(flags, "--offline")
+	}
+	_ = ml.LogCommand("tags", flags)
+
+	cfg, err := globals.loadConfig()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	repoPaths, isLocal, err := resolveRepos(globals, cfg)
+	if err != nil {
+		return err
+	}
+	slog.Debug("found repositories", "count", len(repoPaths))
+
+	printRepoCount("Scanning", len(repoPaths), isLocal, " for tags to clean up...")
+
+	opts := tags.Options{
+		ArchiveMaxAge: time.Duration(c.ArchiveDays) * 24 * time.Hour,
+		CheckRemote:   !c.Offline,
+	}
+	scanStart := time.Now()
+	progress := newProgress()
+	workload := parallel.LocalWork
+	if opts.CheckRemote {
+		workload = parallel.NetworkWork
+	}
+	found := tags.Find(repoPaths, opts, workersFor(cfg, workload, len(repoPaths)), progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, cfg)
+	for i := range found {
+		found[i].RepoName = groupedName(projectsDir, found[i].RepoPath)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(found) == 0 {
+		fmt.Println("No tags to clean up.")
+		return nil
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].RepoName != found[j].RepoName {
+			return found[i].RepoName < found[j].RepoName
+		}
+		return found[i].Name < found[j].Name
+	})
+	printTags(found)
+
+	if globals.DryRun {
+		return nil
+	}
+
+	bk, err := newBackupStore(cfg)
+	if err != nil {
+		return err
+	}
+	return promptAndDeleteTags(found, bk, ml, ol)
+}
+
+func printTags(found []tags.Tag) {
+	bold := color.New(color.Bold)
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d tag(s) to review:", len(found)))
+	for _, t := range found {
+		fmt.Printf("  %s  %s %s\n", t.Label(), dim.Sprint(formatAge(t.Date)),
+			dim.Sprintf("(%s)", strings.Join(t.Reasons(), ", ")))
+	}
+	fmt.Println()
+}
+
+// promptAndDeleteTags lets the user pick tags to delete, then optionally
+// deletes the ones that also exist on origin.
+func promptAndDeleteTags(found []tags.Tag, bk *backup.Store, ml *metrics.Logger, ol *oplog.Logger) error {
+	options := make([]huh.Option[int], len(found))
+	for i, t := range found {
+		label := fmt.Sprintf("%s (%s)", t.Label(), strings.Join(t.Reasons(), ", "))
+		// Expired archive tags are the ones katazuke itself created, so
+		// they are the safest to preselect.
+		options[i] = huh.NewOption(label, i).Selected(t.ExpiredArchive)
+	}
+
+	var selectedIndices []int
+	if err := newForm(
+		huh.NewGroup(
+			huh.NewMultiSelect[int]().
+				Title("Select tags to delete").
+				Options(options...).
+				Height(15).
+				Value(&selectedIndices),
+		),
+	).Run(); err != nil {
+		return fmt.Errorf("prompt failed: %w", err)
+	}
+
+	selectedSet := make(map[int]bool, len(selectedIndices))
+	for _, idx := range selectedIndices {
+		selectedSet[idx] = true
+	}
+	for i, t := range found {
+		ageDays := int(time.Since(t.Date).Hours() / 24)
+		_ = ml.LogSuggestion("delete_tag", branchFingerprint(t.RepoPath, "refs/tags/"+t.Name), selectedSet[i], ageDays)
+	}
+
+	if len(selectedIndices) == 0 {
+		fmt.Println("No tags selected for deletion.")
+		return nil
+	}
+
+	selected := make([]tags.Tag, 0, len(selectedIndices))
+	onRemote := 0
+	for i, t := range found {
+		if selectedSet[i] {
+			selected = append(selected, t)
+			if t.OnRemote {
+				onRemote++
+			}
+		}
+	}
+
+	deleteRemote := false
+	if onRemote > 0 {
+		if err := newForm(
+			huh.NewGroup(
+				huh.NewConfirm().
+					Title(fmt.Sprintf("Also delete %d tag(s) from the remote?", onRemote)).
+					Description("Remote tags are shared with everyone who fetches the repository.").
+					Value(&deleteRemote),
+			),
+		).Run(); err != nil {
+			return fmt.Errorf("prompt failed: %w", err)
+		}
+	}
+
+	return deleteTags(selected, deleteRemote, bk, ol)
+}
+
+// deleteTags deletes the given tags locally and, when deleteRemote is set,
+// on origin for tags known to exist there. With a backup store, each tag
+// is bundled first and kept if its bundle cannot be written.
+func deleteTags(selected []tags.Tag, deleteRemote bool, bk *backup.Store, ol *oplog.Logger) error {
+	bold := color.New(color.Bold)
+	success := ui.Success()
+	fail := ui.Error()
+
+	var failed []string
+	localDeleted, remoteDeleted := 0, 0
+	for _, t := range selected {
+		bundlePath, err := bk.BundleTag(t.RepoPath, t.Name)
+		if err != nil {
+			fmt.Printf("  %s %s (backup failed, not deleting: %v)\n", fail.Sprint("[fail]"), t.Label(), err)
+			failed = append(failed, t.Label())
+			continue
+		}
+		if err := git.DeleteTag(t.RepoPath, t.Name); err != nil {
+			fmt.Printf("  %s %s (%v)\n", fail.Sprint("[fail]"), t.Label(), err)
+			failed = append(failed, t.Label())
+			continue
+		}
+		fmt.Printf("  %s %s\n", success.Sprint("[deleted]"), t.Label())
+		localDeleted++
+
+		deletedRemote := false
+		if deleteRemote && t.OnRemote {
+			if err := git.DeleteRemoteTag(t.RepoPath, t.Remote, t.Name); err != nil {
+				fmt.Printf("  %s %s remote (%v)\n", fail.Sprint("[fail]"), t.Label(), err)
+				failed = append(failed, t.Label()+" (remote)")
+			} else {
+				deletedRemote = true
+				remoteDeleted++
+				fmt.Printf("  %s %s (remote)\n", success.Sprint("[deleted]"), t.Label())
+			}
+		}
+
+		remoteURL, _ := git.RemoteURL(t.RepoPath, git.PrimaryRemote(t.RepoPath))
+		_ = ol.Log(oplog.Operation{
+			Type:          oplog.OpDeleteTag,
+			RepoPath:      t.RepoPath,
+			Tag:           t.Name,
+			CommitSHA:     t.Commit,
+			RemoteURL:     remoteURL,
+			DeletedRemote: deletedRemote,
+			Destination:   bundlePath,
+		})
+	}
+
+	fmt.Println()
+	fmt.Println(bold.Sprintf("Deleted %d tag(s).", localDeleted))
+	if localDeleted > 0 {
+		printBackupNote(bk)
+	}
+	if remoteDeleted > 0 {
+		fmt.Println(bold.Sprintf("Deleted %d remote tag(s).", remoteDeleted))
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete %d tag(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
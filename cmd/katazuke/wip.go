@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/huh"
+	"github.com/fatih/color"
+
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// wipAction is what to do with a repository's forgotten changes.
+type wipAction int
+
+const (
+	wipLeave wipAction = iota
+	wipStash
+	wipCommit
+	wipPark
+	wipParkPush
+)
+
+// runForgottenWIP lists repositories whose uncommitted changes have not
+// been touched in --wip-days, and offers to stash each one, commit it to
+// a WIP branch, or park it so the work is kept and the tree is clean.
+func (c *ReposCmd) runForgottenWIP(globals *CLI) error {
+	if c.WIPDays < 0 {
+		return fmt.Errorf("invalid --wip-days %d (use 0 for every dirty repo)", c.WIPDays)
+	}
+
+	repoPaths, cfg, ml, err := c.loadRepos(globals)
+	if err != nil {
+		return err
+	}
+	if repoPaths == nil {
+		return nil
+	}
+	defer func() { _ = ml.Close() }()
+	ol := oplog.NewOrNil()
+	defer func() { _ = ol.Close() }()
+
+	var flags []string
+	if globals.DryRun {
+		flags = append(flags, "--dry-run")
+	}
+	if globals.Verbose {
+		flags = append(flags, "--verbose")
+	}
+	if c.Pattern != "" {
+		flags = append(flags, fmt.Sprintf("--pattern=%s", c.Pattern))
+	}
+	flags = append(flags, fmt.Sprintf("--wip-days=%d", c.WIPDays))
+	_ = ml.LogCommand("repos --forgotten-wip", flags)
+
+	workers := workersFor(*cfg, parallel.LocalWork, len(repoPaths))
+	slog.Debug("using worker pool", "workers", workers)
+	fmt.Printf("Checking %d repositories for forgotten uncommitted work...\n", len(repoPaths))
+
+	scanStart := time.Now()
+	cutoff := scanStart.AddDate(0, 0, -c.WIPDays)
+	progress := newProgress()
+	forgotten := repos.FindForgottenWIP(repoPaths, cutoff, workers, progress.Update)
+	progress.Stop()
+	projectsDir := resolveProjectsDir(globals.ProjectsDir, *cfg)
+	for i := range forgotten {
+		forgotten[i].Name = groupedName(projectsDir, forgotten[i].Path)
+	}
+	_ = ml.LogPerf(len(repoPaths), int(time.Since(scanStart).Milliseconds()))
+
+	if len(forgotten) == 0 && c.WIPDays == 0 {
+		fmt.Println("No uncommitted changes found.")
+		return nil
+	}
+	if len(forgotten) == 0 {
+		fmt.Printf("No uncommitted changes older than %d %s.\n", c.WIPDays, pluralize(c.WIPDays, "day", "days"))
+		return nil
+	}
+	// Longest forgotten first.
+	sort.Slice(forgotten, func(i, j int) bool { return forgotten[i].LastModified.Before(forgotten[j].LastModified) })
+	printForgottenWIP(forgotten)
+
+	if globals.DryRun {
+		bold := color.New(color.Bold)
+		fmt.Println(bold.Sprint("Dry run -- no changes made."))
+		return nil
+	}
+
+	for _, r := range forgotten {
+		action, err := promptForgottenWIP(r)
+		if err == nil && action == wipParkPush {
+			action, err = confirmParkPush(r)
+		}
+		if err != nil {
+			return err
+		}
+		preserveWIP(r, action, ol, time.Now())
+	}
+	return nil
+}
+
+// printForgottenWIP lists repositories with forgotten uncommitted work.
+func printForgottenWIP(forgotten []repos.ForgottenWIPRepo) {
+	bold := color.New(color.Bold)
+	warn := ui.Warn()
+	dim := ui.Muted()
+
+	fmt.Printf("\n%s\n\n", bold.Sprintf("Found %d repo(s) with forgotten uncommitted work:", len(forgotten)))
+	for _, r := range forgotten {
+		fmt.Printf("  %s  %s\n", bold.Sprint(r.Name), dim.Sprint(r.Path))
+		fmt.Printf("    %s on %s, last modified %s\n",
+			warn.Sprintf("%d changed %s", r.Files, pluralize(r.Files, "file", "files")), wipBranchLabel(r), formatAge(r.LastModified))
+	}
+	fmt.Println()
+}
+
+// wipBranchLabel names the branch the changes sit on for display.
+func wipBranchLabel(r repos.ForgottenWIPRepo) string {
+	if r.Branch == "" {
+		return "detached HEAD"
+	}
+	return r.Branch
+}
+
+// promptForgottenWIP asks what to do with one repository's changes.
+func promptForgottenWIP(r repos.ForgottenWIPRepo) (wipAction, error) {
+	action := wipLeave
+	err := newForm(
+		huh.NewGroup(
+			huh.NewSelect[wipAction]().
+				Title(fmt.Sprintf("%s: %d changed %s on %s, last modified %s",
+					r.Name, r.Files, pluralize(r.Files, "file", "files"), wipBranchLabel(r), formatAge(r.LastModified))).
+				Options(
+					huh.NewOption("Stash with a message", wipStash),
+					huh.NewOption("Commit to a WIP branch", wipCommit),
+					huh.NewOption("Park on a wip/ branch and switch to the default branch", wipPark),
+					huh.NewOption("Park, push the wip/ branch, and switch to the default branch", wipParkPush),
+					huh.NewOption("Leave as is", wipLeave),
+				).
+				Value(&action),
+		),
+	).Run()
+	if err != nil {
+		return wipLeave, fmt.Errorf("prompt failed: %w", err)
+	}
+	return action, nil
+}
+
+// confirmParkPush lists the untracked files that parking would commit and
+// push, since they may never have been meant to leave the machine, and
+// asks before pushing them. It defaults to no, which parks the branch
+// without pushing it.
+func confirmParkPush(r repos.ForgottenWIPRepo) (wipAction, error) {
+	files, err := git.UntrackedFiles(r.Path)
+	if err != nil {
+		slog.Debug("could not list untracked files", "repo", r.Name, "error", err)
+		return wipPark, nil
+	}
+	if len(files) == 0 {
+		return wipParkPush, nil
+	}
+	push := false
+	err = newForm(
+		huh.NewGroup(
+			huh.NewConfirm().
+				Title(fmt.Sprintf("%s: push %d untracked %s?", r.Name, len(files), pluralize(len(files), "file", "files"))).
+				Description("Parking commits these to the wip/ branch:\n  " + strings.Join(files, "\n  ") + "\nIf not, the branch is parked without pushing it.").
+				Value(&push),
+		),
+	).Run()
+	if err != nil {
+		return wipLeave, fmt.Errorf("prompt failed: %w", err)
+	}
+	if !push {
+		return wipPark, nil
+	}
+	return wipParkPush, nil
+}
+
+// preserveWIP stashes, commits, or parks a repository's changes and logs
+// where they went. Failures are reported; a failed push leaves the work
+// parked locally.
+func preserveWIP(r repos.ForgottenWIPRepo, action wipAction, ol *oplog.Logger, now time.Time) {
+	success := ui.Success()
+	fail := ui.Error()
+
+	switch action {
+	case wipStash:
+		message := fmt.Sprintf("katazuke: forgotten WIP on %s, last modified %s", wipBranchLabel(r), r.LastModified.Format("2006-01-02"))
+		sha, err := repos.StashWIP(r, message)
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+			return
+		}
+		fmt.Printf("  %s %s: %q\n", success.Sprint("[stashed]"), r.Name, message)
+		_ = ol.Log(oplog.Operation{
+			Type:      oplog.OpStashWIP,
+			RepoPath:  r.Path,
+			Branch:    r.Branch,
+			CommitSHA: sha,
+		})
+	case wipCommit:
+		branch, sha, err := repos.CommitWIP(r, now)
+		if branch != "" {
+			_ = ol.Log(oplog.Operation{
+				Type:           oplog.OpCommitWIP,
+				RepoPath:       r.Path,
+				Branch:         branch,
+				CommitSHA:      sha,
+				PreviousBranch: r.Branch,
+			})
+		}
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+			return
+		}
+		fmt.Printf("  %s %s: %s\n", success.Sprint("[committed]"), r.Name, branch)
+	case wipPark, wipParkPush:
+		parked, err := repos.Park(r, now, action == wipParkPush)
+		if parked.Branch != "" {
+			_ = ol.Log(oplog.Operation{
+				Type:           oplog.OpCommitWIP,
+				RepoPath:       r.Path,
+				Branch:         parked.Branch,
+				CommitSHA:      parked.SHA,
+				PreviousBranch: r.Branch,
+			})
+		}
+		if err != nil {
+			fmt.Printf("  %s %s: %v\n", fail.Sprint("[fail]"), r.Name, err)
+			return
+		}
+		where := parked.Branch
+		if parked.Remote != "" {
+			where += " (pushed to " + parked.Remote + ")"
+		}
+		fmt.Printf("  %s %s: %s, now on %s\n", success.Sprint("[parked]"), r.Name, where, parked.DefaultBranch)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/oplog"
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/internal/ui"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestPreserveWIPLogsWhereTheWorkWent(t *testing.T) {
+	ol, err := oplog.NewWithDir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ol.Close() }()
+
+	stashed := helpers.NewTestRepo(t, "stashed")
+	stashed.WriteFile("README.md", "edited")
+	committed := helpers.NewTestRepo(t, "committed")
+	committed.WriteFile("notes.txt", "untracked")
+	left := helpers.NewTestRepo(t, "left")
+	left.WriteFile("README.md", "edited")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	preserveWIP(repos.ForgottenWIPRepo{Path: stashed.Path, Name: "stashed", Branch: "main"}, wipStash, ol, now)
+	preserveWIP(repos.ForgottenWIPRepo{Path: committed.Path, Name: "committed", Branch: "main"}, wipCommit, ol, now)
+	preserveWIP(repos.ForgottenWIPRepo{Path: left.Path, Name: "left", Branch: "main"}, wipLeave, ol, now)
+
+	ops, err := ol.ReadOps(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 {
+		t.Fatalf("expected 2 logged operations, got %d: %+v", len(ops), ops)
+	}
+	if stash, _ := git.RevParse(stashed.Path, "refs/stash"); ops[0].Type != oplog.OpStashWIP || ops[0].CommitSHA != stash {
+		t.Errorf("expected the stash %s to be logged, got %+v", stash, ops[0])
+	}
+	if ops[1].Type != oplog.OpCommitWIP || ops[1].Branch != "wip/katazuke-2026-03-01" || ops[1].PreviousBranch != "main" {
+		t.Errorf("expected the WIP branch to be logged, got %+v", ops[1])
+	}
+	if files, _ := git.ChangedFiles(left.Path); len(files) != 1 {
+		t.Errorf("expected the left repo untouched, got %v", files)
+	}
+}
+
+func TestConfirmParkPushDefaultsToLocal(t *testing.T) {
+	withPromptMode(t, ui.Capabilities{IsTTY: false, StdinTTY: false, Mode: ui.ModePlain}, true)
+
+	tracked := helpers.NewTestRepo(t, "tracked")
+	tracked.WriteFile("README.md", "edited")
+	if action, err := confirmParkPush(repos.ForgottenWIPRepo{Path: tracked.Path, Name: "tracked"}); err != nil || action != wipParkPush {
+		t.Errorf("expected tracked changes to be pushed without asking, got %v (%v)", action, err)
+	}
+
+	untracked := helpers.NewTestRepo(t, "untracked")
+	untracked.WriteFile(".env", "TOKEN=secret")
+	if action, err := confirmParkPush(repos.ForgottenWIPRepo{Path: untracked.Path, Name: "untracked"}); err != nil || action != wipPark {
+		t.Errorf("expected --yes to park untracked files without pushing, got %v (%v)", action, err)
+	}
+}
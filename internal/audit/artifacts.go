@@ -0,0 +1,106 @@
+package audit
+
+import (
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// ArtifactDirNames are the directory names recognized as build artifacts:
+// dependency caches, virtualenvs, and build output that a package manager
+// or build tool recreates on demand.
+var ArtifactDirNames = []string{
+	"node_modules",
+	"target",
+	".venv",
+	"venv",
+	"dist",
+	"build",
+	"__pycache__",
+	".gradle",
+	".next",
+	".tox",
+}
+
+// ArtifactDir is an ignored build artifact directory inside a repository.
+type ArtifactDir struct {
+	// Path is the absolute path of the directory.
+	Path string
+	// RelPath is Path relative to the repository root.
+	RelPath string
+	Size    int64
+}
+
+// ArtifactReport lists the artifact directories of one repository,
+// largest first.
+type ArtifactReport struct {
+	RepoPath string
+	RepoName string
+	Dirs     []ArtifactDir
+}
+
+// TotalBytes returns the space all artifact directories of the repository
+// take.
+func (r ArtifactReport) TotalBytes() int64 {
+	var total int64
+	for _, d := range r.Dirs {
+		total += d.Size
+	}
+	return total
+}
+
+// FindArtifacts looks in each repository for directories named like build
+// artifacts (see ArtifactDirNames) that git ignores, so deleting them
+// cannot lose tracked or untracked work. Directories smaller than minSize
+// bytes, and repositories with none left, are omitted. Work is
+// parallelized across the given number of workers.
+func FindArtifacts(repos []string, minSize int64, workers int, onProgress func(completed, total int)) []ArtifactReport {
+	var resultCb func(int, int, *ArtifactReport)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *ArtifactReport) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, func(repoPath string) *ArtifactReport {
+		return findArtifacts(repoPath, minSize)
+	}, resultCb)
+
+	var reports []ArtifactReport
+	for _, r := range results {
+		if r != nil {
+			reports = append(reports, *r)
+		}
+	}
+	return reports
+}
+
+func findArtifacts(repoPath string, minSize int64) *ArtifactReport {
+	r := &ArtifactReport{RepoPath: repoPath, RepoName: filepath.Base(repoPath)}
+
+	ignored, err := git.IgnoredDirs(repoPath)
+	if err != nil {
+		slog.Debug("could not list ignored directories", "repo", r.RepoName, "error", err)
+		return nil
+	}
+	for _, rel := range ignored {
+		if !slices.Contains(ArtifactDirNames, filepath.Base(rel)) {
+			continue
+		}
+		path := filepath.Join(repoPath, rel)
+		size := DirSize(path)
+		if size < minSize {
+			continue
+		}
+		r.Dirs = append(r.Dirs, ArtifactDir{Path: path, RelPath: rel, Size: size})
+	}
+	if len(r.Dirs) == 0 {
+		return nil
+	}
+	sort.Slice(r.Dirs, func(i, j int) bool { return r.Dirs[i].Size > r.Dirs[j].Size })
+	return r
+}
@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFindArtifacts(t *testing.T) {
+	web := helpers.NewTestRepo(t, "web")
+	web.WriteFile(".gitignore", "node_modules/\n/app/dist/\n")
+	web.AddFile(".gitignore")
+	web.Commit("ignore artifacts")
+	writeNested(t, filepath.Join(web.Path, "node_modules/left-pad/index.js"), strings.Repeat("x", 8*1024))
+	writeNested(t, filepath.Join(web.Path, "node_modules/left-pad/node_modules/dep/index.js"), "nested")
+	writeNested(t, filepath.Join(web.Path, "app/dist/bundle.js"), strings.Repeat("y", 2*1024))
+	// A tracked directory named like an artifact is not one.
+	writeNested(t, filepath.Join(web.Path, "build/script.sh"), strings.Repeat("z", 8*1024))
+	web.AddFile("build/script.sh")
+	web.Commit("add build script")
+	// Neither is an untracked one git does not ignore.
+	writeNested(t, filepath.Join(web.Path, "target/notes.txt"), strings.Repeat("n", 8*1024))
+
+	clean := helpers.NewTestRepo(t, "clean")
+
+	reports := FindArtifacts([]string{web.Path, clean.Path}, 0, 2, nil)
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %+v", reports)
+	}
+	r := reports[0]
+	if r.RepoName != "web" || len(r.Dirs) != 2 {
+		t.Fatalf("unexpected report %+v", r)
+	}
+	if r.Dirs[0].RelPath != "node_modules" || r.Dirs[0].Path != filepath.Join(web.Path, "node_modules") {
+		t.Errorf("expected node_modules first, got %+v", r.Dirs[0])
+	}
+	if r.Dirs[1].RelPath != filepath.Join("app", "dist") {
+		t.Errorf("expected app/dist second, got %+v", r.Dirs[1])
+	}
+	if r.TotalBytes() != r.Dirs[0].Size+r.Dirs[1].Size || r.Dirs[0].Size < 8*1024 {
+		t.Errorf("unexpected sizes %+v", r.Dirs)
+	}
+
+	reports = FindArtifacts([]string{web.Path}, 4*1024, 1, nil)
+	if len(reports) != 1 || len(reports[0].Dirs) != 1 || reports[0].Dirs[0].RelPath != "node_modules" {
+		t.Errorf("expected only node_modules above 4 KB, got %+v", reports)
+	}
+}
+
+func writeNested(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -16,7 +16,7 @@ type RepoHealth struct {
 	CurrentBranch   string
 	BehindRemote    int // commits behind origin, -1 if unknown
 	HasRemote       bool
-	ConflictState   string // "rebase", "merge", "cherry-pick", or ""
+	ConflictState   string // "rebase", "merge", "cherry-pick", "revert", "bisect", or ""
 	IsMergedBranch  bool   // non-default branch merged into origin/default
 }
 
@@ -55,6 +55,7 @@ type DashboardResult struct {
 	HealthDetails []RepoHealth
 	Branches      BranchSummary
 	NonGitDirs    []NonRepoDir
+	Violations    []RuleViolation // sorted by repository
 	StaleDays     int
 }
 
@@ -92,11 +93,12 @@ func inspectRepo(repoPath string) RepoHealth {
 
 	h.CurrentBranch = currentBranch
 	h.OnDefaultBranch = currentBranch == defaultBranch
-	h.HasRemote = git.HasRemote(repoPath, "origin")
+	remote := git.PrimaryRemote(repoPath)
+	h.HasRemote = remote != ""
 
 	// Only check behind-remote when on default branch with a remote.
 	if h.OnDefaultBranch && h.HasRemote {
-		count, err := git.RevListCount(repoPath, "HEAD..origin/"+defaultBranch)
+		count, err := git.RevListCount(repoPath, "HEAD.."+remote+"/"+defaultBranch)
 		if err != nil {
 			slog.Debug("could not check behind remote", "repo", repoName, "error", err)
 		} else {
@@ -104,9 +106,9 @@ func inspectRepo(repoPath string) RepoHealth {
 		}
 	}
 
-	// Check if non-default branch has been merged into origin/default.
+	// Check if non-default branch has been merged into <remote>/default.
 	if !h.OnDefaultBranch && h.HasRemote && currentBranch != "" {
-		merged, err := git.IsMerged(repoPath, currentBranch, "origin/"+defaultBranch)
+		merged, err := git.IsMerged(repoPath, currentBranch, remote+"/"+defaultBranch)
 		if err != nil {
 			slog.Debug("could not check merge status", "repo", repoName, "error", err)
 		} else {
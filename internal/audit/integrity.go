@@ -0,0 +1,239 @@
+package audit
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// StaleLockAge is how old a lock file must be before it is considered
+// abandoned. Git holds locks for the duration of a single command, so a
+// lock this old was left behind by an interrupted process.
+const StaleLockAge = 10 * time.Minute
+
+// staleRebaseAge is how long a rebase may sit in progress on a detached
+// HEAD before its state is reported as a leftover.
+const staleRebaseAge = 7 * 24 * time.Hour
+
+// IssueKind identifies a repository integrity problem.
+type IssueKind string
+
+// Issue kinds reported by CheckIntegrity.
+const (
+	IssueCorrupt        IssueKind = "corrupt"
+	IssueDanglingHead   IssueKind = "dangling_head"
+	IssueStaleLock      IssueKind = "stale_lock"
+	IssueRebaseLeftover IssueKind = "rebase_leftover"
+	IssueShallow        IssueKind = "shallow"
+)
+
+// Issue is a single integrity problem found in a repository.
+type Issue struct {
+	Kind   IssueKind
+	Detail string
+	// Path is the lock file or rebase state directory involved, if any.
+	Path string
+	// Fixable is true when the issue has a safe automatic fix: removing a
+	// stale lock, fetching full history, or quitting an orphaned rebase.
+	Fixable bool
+}
+
+// IntegrityReport lists the integrity issues found in one repository.
+type IntegrityReport struct {
+	RepoPath string
+	RepoName string
+	Issues   []Issue
+}
+
+// CheckIntegrity runs git fsck and inspects the git directory of each
+// repository for a dangling HEAD, stale lock files, rebase leftovers, and
+// shallow history. Repos without issues are omitted. Work is parallelized
+// across the given number of workers.
+func CheckIntegrity(repos []string, workers int, now time.Time, onProgress func(completed, total int)) []IntegrityReport {
+	var resultCb func(int, int, *IntegrityReport)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *IntegrityReport) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, func(repoPath string) *IntegrityReport {
+		return checkIntegrity(repoPath, now)
+	}, resultCb)
+
+	var reports []IntegrityReport
+	for _, r := range results {
+		if r != nil {
+			reports = append(reports, *r)
+		}
+	}
+	return reports
+}
+
+func checkIntegrity(repoPath string, now time.Time) *IntegrityReport {
+	r := &IntegrityReport{RepoPath: repoPath, RepoName: filepath.Base(repoPath)}
+
+	gitDir, err := git.GitDir(repoPath)
+	if err != nil {
+		r.Issues = append(r.Issues, Issue{Kind: IssueCorrupt, Detail: fmt.Sprintf("git directory unreadable: %v", firstLine(err.Error()))})
+		return r
+	}
+
+	// Locks come first: a stale index.lock makes other checks fail in
+	// confusing ways.
+	r.Issues = append(r.Issues, findStaleLocks(gitDir, now)...)
+
+	headRef, err := git.SymbolicHead(repoPath)
+	if err != nil {
+		slog.Debug("could not read HEAD", "repo", r.RepoName, "error", err)
+	}
+	// A repo with no branches at all has not had its first commit yet: its
+	// HEAD is unborn, not dangling.
+	if _, err := git.RevParse(repoPath, "HEAD"); err != nil && headRef != "" && hasBranches(repoPath) {
+		r.Issues = append(r.Issues, Issue{
+			Kind:   IssueDanglingHead,
+			Detail: fmt.Sprintf("HEAD points to %s, which does not exist", headRef),
+		})
+	}
+
+	r.Issues = append(r.Issues, findRebaseLeftovers(gitDir, headRef, now)...)
+
+	if shallow, err := git.IsShallow(repoPath); err != nil {
+		slog.Debug("could not check shallow state", "repo", r.RepoName, "error", err)
+	} else if shallow {
+		r.Issues = append(r.Issues, Issue{
+			Kind:    IssueShallow,
+			Detail:  "shallow clone: history is truncated, so merge detection may be wrong",
+			Fixable: git.PrimaryRemote(repoPath) != "",
+		})
+	}
+
+	problems, err := git.Fsck(repoPath)
+	if err != nil {
+		slog.Debug("git fsck failed to run", "repo", r.RepoName, "error", err)
+	}
+	if len(problems) > 0 {
+		detail := fmt.Sprintf("git fsck: %s", problems[0])
+		if len(problems) > 1 {
+			detail += fmt.Sprintf(" (and %d more)", len(problems)-1)
+		}
+		r.Issues = append(r.Issues, Issue{Kind: IssueCorrupt, Detail: detail})
+	}
+
+	if len(r.Issues) == 0 {
+		return nil
+	}
+	return r
+}
+
+// hasBranches reports whether the repo has any local branch. When the
+// branches cannot be listed it assumes so, and HEAD is checked as usual.
+func hasBranches(repoPath string) bool {
+	branches, err := git.ListBranches(repoPath)
+	return err != nil || len(branches) > 0
+}
+
+// findStaleLocks returns lock files in gitDir and under refs/ older than
+// StaleLockAge. The object store is not walked.
+func findStaleLocks(gitDir string, now time.Time) []Issue {
+	var locks []string
+	entries, _ := os.ReadDir(gitDir)
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".lock") {
+			locks = append(locks, filepath.Join(gitDir, e.Name()))
+		}
+	}
+	_ = filepath.WalkDir(filepath.Join(gitDir, "refs"), func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && strings.HasSuffix(d.Name(), ".lock") {
+			locks = append(locks, path)
+		}
+		return nil
+	})
+	sort.Strings(locks)
+
+	var issues []Issue
+	for _, path := range locks {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		age := now.Sub(info.ModTime())
+		if age < StaleLockAge {
+			continue
+		}
+		rel, _ := filepath.Rel(gitDir, path)
+		issues = append(issues, Issue{
+			Kind:    IssueStaleLock,
+			Detail:  fmt.Sprintf("stale lock file %s (%s old)", rel, formatAge(age)),
+			Path:    path,
+			Fixable: true,
+		})
+	}
+	return issues
+}
+
+// findRebaseLeftovers reports rebase state directories that no longer
+// belong to a live rebase. Git detaches HEAD while rebasing, so state
+// alongside an attached HEAD is orphaned and safe to quit. State on a
+// detached HEAD is only reported once it is older than staleRebaseAge,
+// and is left for the user to continue or abort. git am also keeps its
+// state in rebase-apply, marked by an applying file, without detaching
+// HEAD; that state is never reported.
+func findRebaseLeftovers(gitDir, headRef string, now time.Time) []Issue {
+	var issues []Issue
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		path := filepath.Join(gitDir, name)
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(path, "applying")); err == nil {
+			continue
+		}
+		age := now.Sub(info.ModTime())
+		switch {
+		case headRef != "":
+			issues = append(issues, Issue{
+				Kind:    IssueRebaseLeftover,
+				Detail:  fmt.Sprintf("%s/ left over from an abandoned rebase (HEAD is on %s)", name, strings.TrimPrefix(headRef, "refs/heads/")),
+				Path:    path,
+				Fixable: true,
+			})
+		case age >= staleRebaseAge:
+			issues = append(issues, Issue{
+				Kind:   IssueRebaseLeftover,
+				Detail: fmt.Sprintf("rebase in progress for %s (continue it or run git rebase --abort)", formatAge(age)),
+				Path:   path,
+			})
+		}
+	}
+	return issues
+}
+
+// formatAge renders a duration in the largest whole unit: minutes, hours,
+// or days.
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -0,0 +1,158 @@
+package audit
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func issueKinds(r IntegrityReport) []IssueKind {
+	kinds := make([]IssueKind, len(r.Issues))
+	for i, issue := range r.Issues {
+		kinds[i] = issue.Kind
+	}
+	return kinds
+}
+
+func touchAged(t *testing.T, path string, age time.Duration, now time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mtime := now.Add(-age)
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckIntegrity(t *testing.T) {
+	now := time.Now()
+
+	healthy := helpers.NewTestRepo(t, "healthy")
+	// A fresh lock belongs to a running git process and is not reported.
+	touchAged(t, filepath.Join(healthy.Path, ".git", "index.lock"), time.Minute, now)
+
+	locked := helpers.NewTestRepo(t, "locked")
+	touchAged(t, filepath.Join(locked.Path, ".git", "index.lock"), 2*time.Hour, now)
+	touchAged(t, filepath.Join(locked.Path, ".git", "refs", "heads", "main.lock"), 3*24*time.Hour, now)
+
+	dangling := helpers.NewTestRepo(t, "dangling")
+	dangling.Git("symbolic-ref", "HEAD", "refs/heads/gone")
+
+	// A repo before its first commit has an unborn HEAD, which is not
+	// dangling.
+	unborn := filepath.Join(t.TempDir(), "unborn")
+	// #nosec G204 - test-controlled arguments
+	if out, err := exec.Command("git", "init", "--quiet", unborn).CombinedOutput(); err != nil {
+		t.Fatalf("git init: %v: %s", err, out)
+	}
+
+	leftover := helpers.NewTestRepo(t, "leftover")
+	if err := os.Mkdir(filepath.Join(leftover.Path, ".git", "rebase-merge"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	src := helpers.NewTestRepo(t, "source")
+	src.WriteFile("second.txt", "more history\n")
+	src.AddFile("second.txt")
+	src.Commit("second commit")
+	shallowPath := filepath.Join(t.TempDir(), "shallow")
+	// #nosec G204 - test-controlled arguments
+	if out, err := exec.Command("git", "clone", "--quiet", "--depth", "1", "file://"+src.Path, shallowPath).CombinedOutput(); err != nil {
+		t.Fatalf("shallow clone: %v: %s", err, out)
+	}
+
+	corrupt := helpers.NewTestRepo(t, "corrupt")
+	// #nosec G204 - test-controlled arguments
+	out, err := exec.Command("git", "-C", corrupt.Path, "rev-parse", "HEAD:README.md").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := strings.TrimSpace(string(out))
+	if err := os.Remove(filepath.Join(corrupt.Path, ".git", "objects", blob[:2], blob[2:])); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := CheckIntegrity([]string{healthy.Path, locked.Path, dangling.Path, unborn, leftover.Path, shallowPath, corrupt.Path}, 2, now, nil)
+	byName := make(map[string]IntegrityReport)
+	for _, r := range reports {
+		byName[r.RepoName] = r
+	}
+
+	if _, ok := byName["healthy"]; ok {
+		t.Errorf("healthy repo should not be reported: %+v", byName["healthy"])
+	}
+
+	lr := byName["locked"]
+	if len(lr.Issues) != 2 || lr.Issues[0].Kind != IssueStaleLock || !lr.Issues[0].Fixable {
+		t.Fatalf("expected two fixable stale locks, got %+v", lr.Issues)
+	}
+	if !strings.Contains(lr.Issues[0].Detail, "index.lock (2h old)") {
+		t.Errorf("unexpected lock detail %q", lr.Issues[0].Detail)
+	}
+
+	if kinds := issueKinds(byName["dangling"]); len(kinds) == 0 || kinds[0] != IssueDanglingHead {
+		t.Errorf("expected dangling HEAD first, got %v", kinds)
+	}
+
+	if ur, ok := byName["unborn"]; ok {
+		t.Errorf("repo with an unborn HEAD should not be reported: %+v", ur)
+	}
+
+	lo := byName["leftover"]
+	if len(lo.Issues) != 1 || lo.Issues[0].Kind != IssueRebaseLeftover || !lo.Issues[0].Fixable {
+		t.Errorf("expected one fixable rebase leftover, got %+v", lo.Issues)
+	}
+
+	sh := byName["shallow"]
+	if len(sh.Issues) != 1 || sh.Issues[0].Kind != IssueShallow || !sh.Issues[0].Fixable {
+		t.Errorf("expected one fixable shallow issue, got %+v", sh.Issues)
+	}
+
+	co := byName["corrupt"]
+	if len(co.Issues) != 1 || co.Issues[0].Kind != IssueCorrupt || co.Issues[0].Fixable {
+		t.Errorf("expected one unfixable corruption issue, got %+v", co.Issues)
+	}
+}
+
+func TestFindRebaseLeftoversDetachedHead(t *testing.T) {
+	gitDir := t.TempDir()
+	now := time.Now()
+	dir := filepath.Join(gitDir, "rebase-apply")
+	if err := os.Mkdir(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	// A recent rebase on a detached HEAD is a rebase in progress.
+	if issues := findRebaseLeftovers(gitDir, "", now); len(issues) != 0 {
+		t.Errorf("expected no issues for a live rebase, got %+v", issues)
+	}
+
+	old := now.Add(-10 * 24 * time.Hour)
+	if err := os.Chtimes(dir, old, old); err != nil {
+		t.Fatal(err)
+	}
+	issues := findRebaseLeftovers(gitDir, "", now)
+	if len(issues) != 1 || issues[0].Fixable {
+		t.Errorf("expected one unfixable stale rebase, got %+v", issues)
+	}
+}
+
+func TestFindRebaseLeftoversSkipsAm(t *testing.T) {
+	gitDir := t.TempDir()
+	dir := filepath.Join(gitDir, "rebase-apply")
+	if err := os.Mkdir(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "applying"), nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if issues := findRebaseLeftovers(gitDir, "refs/heads/main", time.Now()); len(issues) != 0 {
+		t.Errorf("expected git am state to be left alone, got %+v", issues)
+	}
+}
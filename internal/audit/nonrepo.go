@@ -23,11 +23,20 @@ type NonRepoDir struct {
 	LastModified time.Time // Most recent modification time
 	FileCount    int       // Number of files
 	Summary      string    // Brief contents summary (e.g., "12 .go, 5 .yaml, 3 .md, 2 others")
+	// Shadows is the repository this directory looks like a copy of,
+	// set by MarkShadows. Empty when none matches.
+	Shadows string
+	// NoteReviewed is when the directory's NoteFile was written, zero
+	// when it has none. The note itself is left out of the other fields.
+	NoteReviewed time.Time
 }
 
 // Options controls non-repo detection behavior.
 type Options struct {
 	ExcludePatterns []string
+	// ScanDepth mirrors scanner.Options.MaxDepth: directories holding a
+	// repository within that many levels are organizational, not clutter.
+	ScanDepth int
 }
 
 // FindNonRepoDirs finds directories under rootPath that are not git repositories.
@@ -40,10 +49,10 @@ func FindNonRepoDirs(rootPath string, opts Options, workers int) ([]NonRepoDir,
 		return nil, err
 	}
 
-	// Filter to non-repos first (cheap check).
+	// Filter to non-repos first (a stat, so cheap).
 	var nonRepos []string
 	for _, child := range children {
-		if !git.IsRepo(child) {
+		if !git.HasGitDir(child) && !git.IsBareRepo(child) && !containsRepo(child, opts) {
 			nonRepos = append(nonRepos, child)
 		}
 	}
@@ -66,6 +75,20 @@ func FindNonRepoDirs(rootPath string, opts Options, workers int) ([]NonRepoDir,
 	return result, nil
 }
 
+// containsRepo reports whether dir holds a repository that the scanner
+// would discover at the configured scan depth.
+func containsRepo(dir string, opts Options) bool {
+	if opts.ScanDepth <= 1 {
+		return false
+	}
+	repos, err := scanner.Scan(dir, scanner.Options{
+		ExcludePatterns: opts.ExcludePatterns,
+		MaxDepth:        opts.ScanDepth - 1,
+		IncludeBare:     true,
+	})
+	return err == nil && len(repos) > 0
+}
+
 // listCandidates returns the list of candidate child directory paths to check.
 // If a .katazuke index file exists, it respects groups and ignores.
 // Otherwise, it lists all immediate non-hidden subdirectories.
@@ -112,13 +135,16 @@ func inspectDir(dirPath string) (NonRepoDir, error) {
 	var lastModified time.Time
 	extCounts := make(map[string]int)
 
-	err := filepath.WalkDir(dirPath, func(_ string, d os.DirEntry, err error) error {
+	err := filepath.WalkDir(dirPath, func(path string, d os.DirEntry, err error) error {
 		if err != nil {
 			return nil // skip unreadable entries
 		}
 		if d.IsDir() {
 			return nil
 		}
+		if d.Name() == NoteFile && filepath.Dir(path) == dirPath {
+			return nil
+		}
 
 		fileCount++
 
@@ -150,6 +176,7 @@ func inspectDir(dirPath string) (NonRepoDir, error) {
 		LastModified: lastModified,
 		FileCount:    fileCount,
 		Summary:      buildSummary(extCounts),
+		NoteReviewed: readNoteDate(dirPath),
 	}, nil
 }
 
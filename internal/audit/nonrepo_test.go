@@ -108,6 +108,10 @@ func TestFindNonRepoDirsAllRepos(t *testing.T) {
 
 	initGitRepo(t, filepath.Join(root, "repo1"))
 	initGitRepo(t, filepath.Join(root, "repo2"))
+	// A bare mirror is a repository too, not clutter.
+	mirror := filepath.Join(root, "mirror.git")
+	createDir(t, mirror, nil)
+	gitRun(t, mirror, "init", "--bare")
 
 	result, err := FindNonRepoDirs(root, Options{}, 1)
 	if err != nil {
@@ -172,6 +176,33 @@ func TestFindNonRepoDirsExcludePatterns(t *testing.T) {
 	}
 }
 
+func TestFindNonRepoDirsScanDepth(t *testing.T) {
+	root := t.TempDir()
+
+	initGitRepo(t, filepath.Join(root, "org", "api"))
+	createDir(t, filepath.Join(root, "notes"), map[string]string{
+		"todo.md": "# TODO",
+	})
+
+	// At depth 1 the org directory is not a repo and is reported.
+	result, err := FindNonRepoDirs(root, Options{ScanDepth: 1}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 results at depth 1, got %d: %+v", len(result), result)
+	}
+
+	// At depth 2 it holds a discoverable repo and is left alone.
+	result, err = FindNonRepoDirs(root, Options{ScanDepth: 2}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 || result[0].Name != "notes" {
+		t.Fatalf("expected only notes at depth 2, got %+v", result)
+	}
+}
+
 func TestFindNonRepoDirsWithKatazukeIndex(t *testing.T) {
 	root := t.TempDir()
 
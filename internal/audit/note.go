@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NoteFile is the name of the annotation katazuke writes into a non-git
+// directory to record what it is.
+const NoteFile = "KATAZUKE-NOTE.md"
+
+// noteMarker starts the line recording when a note was written, so it
+// can be read back regardless of how the rest of the file is edited.
+const noteMarker = "<!-- katazuke:note reviewed="
+
+// WriteNote writes NoteFile into d with the user's annotation and a
+// summary of what the directory held when it was reviewed.
+func WriteNote(d NonRepoDir, text string, now time.Time) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s%s -->\n", noteMarker, now.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "# %s\n\n", d.Name)
+	if text = strings.TrimSpace(text); text != "" {
+		b.WriteString(text + "\n\n")
+	}
+	fmt.Fprintf(&b, "Reviewed with katazuke on %s:\n\n", now.Format(time.DateOnly))
+	fmt.Fprintf(&b, "- %d files (%s), %s\n", d.FileCount, d.Summary, formatBytes(d.Size))
+	if !d.LastModified.IsZero() {
+		fmt.Fprintf(&b, "- last modified %s\n", d.LastModified.Format(time.DateOnly))
+	}
+	if d.Shadows != "" {
+		fmt.Fprintf(&b, "- possibly a copy of %s\n", d.Shadows)
+	}
+
+	path := filepath.Join(d.Path, NoteFile)
+	if err := os.WriteFile(path, []byte(b.String()), 0o600); err != nil {
+		return fmt.Errorf("writing note: %w", err)
+	}
+	return nil
+}
+
+// readNoteDate returns when the note in dir was written, or the zero time
+// when dir has no readable note.
+func readNoteDate(dir string) time.Time {
+	// #nosec G304 - fixed file name inside a directory under the projects dir
+	f, err := os.Open(filepath.Join(dir, NoteFile))
+	if err != nil {
+		return time.Time{}
+	}
+	defer func() { _ = f.Close() }()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(s.Text()), noteMarker)
+		if !ok {
+			continue
+		}
+		stamp, _, _ := strings.Cut(rest, " ")
+		if t, err := time.Parse(time.RFC3339, stamp); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// WithoutFreshNotes drops the directories annotated with WriteNote whose
+// note is still fresh: written less than ttl ago (0 never expires) and
+// with nothing in the directory modified since. It returns the remaining
+// directories and how many were dropped.
+func WithoutFreshNotes(dirs []NonRepoDir, ttl time.Duration, now time.Time) ([]NonRepoDir, int) {
+	kept := make([]NonRepoDir, 0, len(dirs))
+	for _, d := range dirs {
+		fresh := !d.NoteReviewed.IsZero() &&
+			(ttl <= 0 || now.Sub(d.NoteReviewed) < ttl) &&
+			!d.LastModified.After(d.NoteReviewed)
+		if !fresh {
+			kept = append(kept, d)
+		}
+	}
+	return kept, len(dirs) - len(kept)
+}
+
+// formatBytes formats a size for a note, e.g. "1.5 MB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
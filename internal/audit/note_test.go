@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteNote(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "scans")
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "receipt.pdf"), make([]byte, 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	before, err := inspectDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := WriteNote(before, "Tax receipts for 2025, keep until 2032.", now); err != nil {
+		t.Fatalf("WriteNote: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, NoteFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"# scans", "Tax receipts for 2025", "1 files (1 .pdf), 2.0 KB"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("note is missing %q:\n%s", want, data)
+		}
+	}
+
+	// The note is read back and does not count as content.
+	after, err := inspectDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.NoteReviewed.Equal(now) || after.FileCount != 1 || after.Size != 2048 {
+		t.Errorf("unexpected directory after annotating: %+v", after)
+	}
+}
+
+func TestWithoutFreshNotes(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	reviewed := now.AddDate(0, 0, -30)
+	dirs := []NonRepoDir{
+		{Name: "fresh", LastModified: reviewed.AddDate(0, 0, -5), NoteReviewed: reviewed},
+		{Name: "changed", LastModified: reviewed.AddDate(0, 0, 2), NoteReviewed: reviewed},
+		{Name: "expired", LastModified: reviewed.AddDate(-1, 0, 0), NoteReviewed: now.AddDate(0, 0, -200)},
+		{Name: "unannotated", LastModified: reviewed},
+	}
+
+	kept, hidden := WithoutFreshNotes(dirs, 180*24*time.Hour, now)
+	var names []string
+	for _, d := range kept {
+		names = append(names, d.Name)
+	}
+	if hidden != 1 || strings.Join(names, ",") != "changed,expired,unannotated" {
+		t.Errorf("kept %v (hidden %d)", names, hidden)
+	}
+
+	// Without a TTL only a change brings a directory back.
+	if _, hidden := WithoutFreshNotes(dirs, 0, now); hidden != 2 {
+		t.Errorf("expected 2 hidden without a TTL, got %d", hidden)
+	}
+}
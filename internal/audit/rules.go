@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// Rule is a conformance check on where a repository lives and what it
+// contains. Empty fields are not checked.
+type Rule struct {
+	Name string
+	// Orgs limits the rule to repositories whose GitHub remote belongs to
+	// one of these owners; empty applies it to every repository.
+	Orgs []string
+	// Group is the group path the repository must live in, with "{org}"
+	// standing for its GitHub owner.
+	Group string
+	// NameMatchesRemote requires the directory to be named after the
+	// repository on its remote.
+	NameMatchesRemote bool
+	// RequiredFiles are names or globs that must match a file at the
+	// repository root, in .github/, or in docs/, where GitHub looks for
+	// community files such as CODEOWNERS.
+	RequiredFiles []string
+}
+
+// Label returns the rule's name, or a description of its checks.
+func (r Rule) Label() string {
+	if r.Name != "" {
+		return r.Name
+	}
+	var checks []string
+	if r.Group != "" {
+		checks = append(checks, "lives in "+r.Group)
+	}
+	if r.NameMatchesRemote {
+		checks = append(checks, "named after its remote")
+	}
+	if len(r.RequiredFiles) > 0 {
+		checks = append(checks, "has "+strings.Join(r.RequiredFiles, ", "))
+	}
+	label := strings.Join(checks, "; ")
+	if len(r.Orgs) > 0 {
+		label = strings.Join(r.Orgs, ", ") + " repos: " + label
+	}
+	return label
+}
+
+// RuleViolation is a repository that breaks a rule.
+type RuleViolation struct {
+	RepoPath string
+	RepoName string
+	Rule     string
+	// Detail says what is wrong, e.g. "missing CODEOWNERS".
+	Detail string
+}
+
+// requiredFileDirs are where required files are looked for, relative to
+// the repository root.
+var requiredFileDirs = []string{".", ".github", "docs"}
+
+// CheckRules checks repos against rules and returns the violations,
+// sorted by repository. Groups are relative to projectsDir; a repository
+// outside it is not checked for its group.
+func CheckRules(repos []string, projectsDir string, rules []Rule, workers int) []RuleViolation {
+	if len(rules) == 0 {
+		return nil
+	}
+	results := parallel.Run(repos, workers, func(repoPath string) []RuleViolation {
+		return checkRepoRules(repoPath, projectsDir, rules)
+	}, nil)
+
+	var violations []RuleViolation
+	for _, r := range results {
+		violations = append(violations, r...)
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].RepoPath < violations[j].RepoPath })
+	return violations
+}
+
+func checkRepoRules(repoPath, projectsDir string, rules []Rule) []RuleViolation {
+	repoName := filepath.Base(repoPath)
+	var urls []string
+	if remote := git.PrimaryRemote(repoPath); remote != "" {
+		var err error
+		if urls, err = git.RemoteURLs(repoPath, remote); err != nil {
+			slog.Debug("could not read remote URLs", "repo", repoName, "error", err)
+		}
+	}
+	owner, _, isGitHub := ghclient.ParseGitHubRemotes(urls)
+
+	var violations []RuleViolation
+	add := func(rule Rule, format string, args ...any) {
+		violations = append(violations, RuleViolation{RepoPath: repoPath, RepoName: repoName, Rule: rule.Label(), Detail: fmt.Sprintf(format, args...)})
+	}
+	for _, rule := range rules {
+		if len(rule.Orgs) > 0 && (!isGitHub || !slices.ContainsFunc(rule.Orgs, func(o string) bool { return strings.EqualFold(o, owner) })) {
+			continue
+		}
+
+		if rule.Group != "" {
+			if want, ok := expectedGroup(rule.Group, owner, isGitHub); ok {
+				if got, inside := groupOf(projectsDir, repoPath); inside && got != want {
+					if got == "" {
+						got = "the top level"
+					}
+					add(rule, "in %s, expected %s", got, want)
+				}
+			}
+		}
+
+		if rule.NameMatchesRemote && len(urls) > 0 {
+			if name := remoteRepoName(urls[0]); name != "" && name != repoName {
+				add(rule, "directory is %s but the remote repository is %s", repoName, name)
+			}
+		}
+
+		for _, pattern := range rule.RequiredFiles {
+			if !hasFile(repoPath, pattern) {
+				add(rule, "missing %s", pattern)
+			}
+		}
+	}
+	return violations
+}
+
+// expectedGroup fills in "{org}" in a rule's group. ok is false when the
+// group needs an org the repository does not have.
+func expectedGroup(group, owner string, isGitHub bool) (string, bool) {
+	if strings.Contains(group, "{org}") {
+		if !isGitHub {
+			return "", false
+		}
+		group = strings.ReplaceAll(group, "{org}", owner)
+	}
+	return strings.Trim(filepath.ToSlash(filepath.Clean(group)), "/"), true
+}
+
+// groupOf returns the repository's group path relative to projectsDir,
+// "" for the top level. inside is false when the repository is not
+// under projectsDir.
+func groupOf(projectsDir, repoPath string) (group string, inside bool) {
+	if projectsDir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(projectsDir, filepath.Dir(repoPath))
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// remoteRepoName returns the repository name at the end of a remote URL,
+// e.g. "app" for git@github.com:acme/app.git.
+func remoteRepoName(remoteURL string) string {
+	u := strings.TrimSuffix(strings.TrimRight(remoteURL, "/"), ".git")
+	if i := strings.LastIndexAny(u, "/:"); i >= 0 {
+		u = u[i+1:]
+	}
+	return u
+}
+
+// hasFile reports whether pattern matches a file in one of the
+// requiredFileDirs of the repository.
+func hasFile(repoPath, pattern string) bool {
+	for _, dir := range requiredFileDirs {
+		if matches, _ := filepath.Glob(filepath.Join(repoPath, dir, pattern)); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
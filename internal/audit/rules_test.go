@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestCheckRules(t *testing.T) {
+	filed := helpers.NewTestRepo(t, "acme/api")
+	filed.AddRemote("origin", "git@github.com:acme/api.git")
+	if err := os.MkdirAll(filepath.Join(filed.Path, ".github"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	filed.WriteFile(".github/CODEOWNERS", "* @acme/team\n")
+	filed.WriteFile("LICENSE.md", "MIT\n")
+	projectsDir := filepath.Dir(filepath.Dir(filed.Path))
+
+	misplaced := helpers.NewTestRepo(t, "web-old")
+	misplaced.AddRemote("origin", "https://github.com/acme/web.git")
+	misplaced.WriteFile("LICENSE", "MIT\n")
+	// Move it next to api, at the top level of the same projects dir.
+	moved := filepath.Join(projectsDir, "web-old")
+	if err := os.Rename(misplaced.Path, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	other := helpers.NewTestRepo(t, "other")
+	other.AddRemote("origin", "git@github.com:someone/other.git")
+
+	rules := []Rule{
+		{Orgs: []string{"acme"}, Group: "{org}", NameMatchesRemote: true},
+		{Name: "community files", Orgs: []string{"ACME"}, RequiredFiles: []string{"CODEOWNERS", "LICENSE*"}},
+	}
+	violations := CheckRules([]string{filed.Path, moved, other.Path}, projectsDir, rules, 2)
+
+	want := []RuleViolation{
+		{RepoPath: moved, RepoName: "web-old", Rule: "acme repos: lives in {org}; named after its remote", Detail: "in the top level, expected acme"},
+		{RepoPath: moved, RepoName: "web-old", Rule: "acme repos: lives in {org}; named after its remote", Detail: "directory is web-old but the remote repository is web"},
+		{RepoPath: moved, RepoName: "web-old", Rule: "community files", Detail: "missing CODEOWNERS"},
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("got %+v, want %+v", violations, want)
+	}
+	for i := range want {
+		if violations[i] != want[i] {
+			t.Errorf("violation %d: got %+v, want %+v", i, violations[i], want[i])
+		}
+	}
+}
+
+func TestRemoteRepoName(t *testing.T) {
+	for url, want := range map[string]string{
+		"git@github.com:acme/app.git":      "app",
+		"https://gitlab.com/g/sub/app/":    "app",
+		"ssh://git@host:2222/acme/app.git": "app",
+	} {
+		if got := remoteRepoName(url); got != want {
+			t.Errorf("remoteRepoName(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// copySuffix matches what copying, extracting, or renaming a checkout
+// typically appends to its name: "-old", "_backup", " copy", "(1)",
+// "-main" from a GitHub zip, or a version number from a release tarball.
+var copySuffix = regexp.MustCompile(`(?i)(?:[ ._-](?:old|copy|backup|bak|orig|archive|tmp|main|master)|[ ._-]?\(\d+\)|[ ._-]v?\d+(?:\.\d+)*)$`)
+
+// copyBaseName strips copy suffixes from a directory name, lower-cased so
+// "API-old" matches a checkout named "api".
+func copyBaseName(name string) string {
+	name = strings.ToLower(name)
+	for {
+		stripped := copySuffix.ReplaceAllString(name, "")
+		if stripped == name || stripped == "" {
+			return name
+		}
+		name = stripped
+	}
+}
+
+// MarkShadows sets Shadows on each non-git directory whose name, ignoring
+// copy suffixes like "-old" or "-main", matches the name of one of repos:
+// likely an extracted archive or a stale copy of that checkout.
+func MarkShadows(dirs []NonRepoDir, repos []string) {
+	byName := make(map[string]string, len(repos))
+	sorted := append([]string(nil), repos...)
+	sort.Strings(sorted)
+	for _, r := range sorted {
+		name := strings.ToLower(filepath.Base(r))
+		if _, ok := byName[name]; !ok {
+			byName[name] = r
+		}
+	}
+	for i := range dirs {
+		if repo, ok := byName[copyBaseName(dirs[i].Name)]; ok {
+			dirs[i].Shadows = repo
+		}
+	}
+}
+
+// DirComparison compares the files of a non-git directory with the
+// checkout it shadows. Paths are relative and slash-separated.
+type DirComparison struct {
+	// Same is the number of files identical in both.
+	Same int
+	// Differ lists files present in both with different contents.
+	Differ []string
+	// OnlyInDir lists files the repository checkout does not have.
+	OnlyInDir []string
+	// OnlyInRepo lists files of the checkout missing from the directory.
+	OnlyInRepo []string
+}
+
+// HasUniqueContent reports whether the directory holds anything the
+// checkout does not: deleting it would lose those files or changes.
+func (c DirComparison) HasUniqueContent() bool {
+	return len(c.Differ) > 0 || len(c.OnlyInDir) > 0
+}
+
+// CompareWithRepo compares the files under dir with those in the working
+// tree of repoPath, ignoring .git. Files of equal size are compared by
+// content hash.
+func CompareWithRepo(dir, repoPath string) (DirComparison, error) {
+	var c DirComparison
+	dirFiles, err := listFiles(dir)
+	if err != nil {
+		return c, err
+	}
+	repoFiles, err := listFiles(repoPath)
+	if err != nil {
+		return c, err
+	}
+
+	for rel, size := range dirFiles {
+		repoSize, ok := repoFiles[rel]
+		switch {
+		case !ok:
+			c.OnlyInDir = append(c.OnlyInDir, rel)
+		case size != repoSize || !sameContent(filepath.Join(dir, rel), filepath.Join(repoPath, rel)):
+			c.Differ = append(c.Differ, rel)
+		default:
+			c.Same++
+		}
+	}
+	for rel := range repoFiles {
+		if _, ok := dirFiles[rel]; !ok {
+			c.OnlyInRepo = append(c.OnlyInRepo, rel)
+		}
+	}
+	sort.Strings(c.Differ)
+	sort.Strings(c.OnlyInDir)
+	sort.Strings(c.OnlyInRepo)
+	return c, nil
+}
+
+// listFiles returns the size of every regular file under root, keyed by
+// slash-separated relative path, skipping .git.
+func listFiles(root string) (map[string]int64, error) {
+	files := make(map[string]int64)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // skip unreadable entries
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		files[filepath.ToSlash(rel)] = info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// sameContent reports whether two files have the same contents. Files
+// that cannot be read count as different.
+func sameContent(a, b string) bool {
+	ha, err := hashFile(a)
+	if err != nil {
+		return false
+	}
+	hb, err := hashFile(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(ha, hb)
+}
+
+func hashFile(path string) ([]byte, error) {
+	// #nosec G304 - path comes from walking a directory under the projects dir
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
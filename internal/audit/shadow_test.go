@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCopyBaseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "api", want: "api"},
+		{name: "api-old", want: "api"},
+		{name: "API_backup", want: "api"},
+		{name: "api copy", want: "api"},
+		{name: "api (1)", want: "api"},
+		{name: "api-main", want: "api"},
+		{name: "api-1.4.2", want: "api"},
+		{name: "api-v2-old", want: "api"},
+		{name: "main", want: "main"},
+		{name: "old-api", want: "old-api"},
+	}
+	for _, tt := range tests {
+		if got := copyBaseName(tt.name); got != tt.want {
+			t.Errorf("copyBaseName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMarkShadows(t *testing.T) {
+	dirs := []NonRepoDir{{Name: "api-old"}, {Name: "scratch"}, {Name: "Web-main"}}
+	MarkShadows(dirs, []string{"/p/work/api", "/p/web", "/p/tools"})
+
+	got := []string{dirs[0].Shadows, dirs[1].Shadows, dirs[2].Shadows}
+	want := []string{"/p/work/api", "", "/p/web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Shadows = %q, want %q", got, want)
+	}
+}
+
+func TestCompareWithRepo(t *testing.T) {
+	root := t.TempDir()
+	repo := filepath.Join(root, "api")
+	dir := filepath.Join(root, "api-old")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(repo, ".git", "HEAD"), "ref: refs/heads/main\n")
+	write(filepath.Join(repo, "main.go"), "package main\n")
+	write(filepath.Join(repo, "cmd", "run.go"), "package cmd\n")
+	write(filepath.Join(repo, "README.md"), "# api\n")
+	write(filepath.Join(dir, "main.go"), "package main\n")
+	write(filepath.Join(dir, "cmd", "run.go"), "package cmd // edited\n")
+	write(filepath.Join(dir, "notes.txt"), "todo\n")
+
+	cmp, err := CompareWithRepo(dir, repo)
+	if err != nil {
+		t.Fatalf("CompareWithRepo: %v", err)
+	}
+	want := DirComparison{
+		Same:       1,
+		Differ:     []string{"cmd/run.go"},
+		OnlyInDir:  []string{"notes.txt"},
+		OnlyInRepo: []string{"README.md"},
+	}
+	if !reflect.DeepEqual(cmp, want) {
+		t.Errorf("got %+v, want %+v", cmp, want)
+	}
+	if !cmp.HasUniqueContent() {
+		t.Error("expected unique content")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+	write(filepath.Join(dir, "cmd", "run.go"), "package cmd\n")
+	cmp, err = CompareWithRepo(dir, repo)
+	if err != nil {
+		t.Fatalf("CompareWithRepo: %v", err)
+	}
+	if cmp.HasUniqueContent() || cmp.Same != 2 {
+		t.Errorf("expected a subset of the repo, got %+v", cmp)
+	}
+}
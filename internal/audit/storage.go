@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// StorageReport describes reclaimable disk space in a single repository:
+// Git LFS objects that could be pruned and large blobs in history.
+type StorageReport struct {
+	RepoPath string
+	RepoName string
+
+	// LFSObjectsBytes is the total size of the local LFS object cache.
+	// Zero when the repo has no LFS objects.
+	LFSObjectsBytes int64
+	// LFSPrunable is the number of LFS objects `git lfs prune` would remove.
+	LFSPrunable int
+	// LFSReclaimable is the space `git lfs prune` would free.
+	LFSReclaimable int64
+	// LFSChecked is false when git-lfs is not installed, in which case
+	// prunable counts are unknown.
+	LFSChecked bool
+
+	// LargeBlobs lists blobs at or above the size threshold, largest first.
+	LargeBlobs []git.Blob
+}
+
+// HasLFS returns true if the repository has a local LFS object cache.
+func (r StorageReport) HasLFS() bool {
+	return r.LFSObjectsBytes > 0
+}
+
+// LargeBlobBytes returns the on-disk size of all large blobs.
+func (r StorageReport) LargeBlobBytes() int64 {
+	var total int64
+	for _, b := range r.LargeBlobs {
+		total += b.DiskSize
+	}
+	return total
+}
+
+// AnalyzeStorage inspects each repository for LFS objects and blobs of at
+// least blobThreshold bytes. Repos with nothing to report are omitted.
+// Work is parallelized across the given number of workers.
+func AnalyzeStorage(repos []string, blobThreshold int64, workers int, onProgress func(completed, total int)) []StorageReport {
+	var resultCb func(int, int, *StorageReport)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *StorageReport) {
+			onProgress(completed, total)
+		}
+	}
+
+	lfs := git.LFSAvailable()
+	results := parallel.Run(repos, workers, func(repoPath string) *StorageReport {
+		return analyzeStorage(repoPath, blobThreshold, lfs)
+	}, resultCb)
+
+	var reports []StorageReport
+	for _, r := range results {
+		if r != nil {
+			reports = append(reports, *r)
+		}
+	}
+	return reports
+}
+
+func analyzeStorage(repoPath string, blobThreshold int64, lfs bool) *StorageReport {
+	r := &StorageReport{RepoPath: repoPath, RepoName: filepath.Base(repoPath)}
+
+	gitDir, err := git.GitDir(repoPath)
+	if err != nil {
+		slog.Debug("could not resolve git dir", "repo", r.RepoName, "error", err)
+		return nil
+	}
+
+	lfsDir := filepath.Join(gitDir, "lfs", "objects")
+	r.LFSObjectsBytes = DirSize(lfsDir)
+	if r.HasLFS() && lfs {
+		oids, err := git.LFSPrunable(repoPath)
+		if err != nil {
+			slog.Debug("git lfs prune --dry-run failed", "repo", r.RepoName, "error", err)
+		} else {
+			r.LFSChecked = true
+			r.LFSPrunable = len(oids)
+			for _, oid := range oids {
+				if info, err := os.Stat(lfsObjectPath(lfsDir, oid)); err == nil {
+					r.LFSReclaimable += info.Size()
+				}
+			}
+		}
+	}
+
+	if blobThreshold > 0 {
+		blobs, err := git.LargeBlobs(repoPath, blobThreshold)
+		if err != nil {
+			slog.Debug("could not list large blobs", "repo", r.RepoName, "error", err)
+		}
+		sort.Slice(blobs, func(i, j int) bool { return blobs[i].Size > blobs[j].Size })
+		r.LargeBlobs = blobs
+	}
+
+	if !r.HasLFS() && len(r.LargeBlobs) == 0 {
+		return nil
+	}
+	return r
+}
+
+// lfsObjectPath returns where git-lfs stores the object with the given
+// OID: objects/<oid[0:2]>/<oid[2:4]>/<oid>.
+func lfsObjectPath(lfsDir, oid string) string {
+	return filepath.Join(lfsDir, oid[0:2], oid[2:4], oid)
+}
+
+// DirSize returns the total size of regular files under dir, or zero if
+// dir does not exist.
+func DirSize(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			total += info.Size()
+		}
+		return nil
+	})
+	return total
+}
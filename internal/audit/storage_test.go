@@ -0,0 +1,61 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestAnalyzeStorage(t *testing.T) {
+	big := helpers.NewTestRepo(t, "big")
+	big.WriteFile("asset.bin", strings.Repeat("a", 64*1024))
+	big.AddFile("asset.bin")
+	big.Commit("add asset")
+
+	small := helpers.NewTestRepo(t, "small")
+
+	lfs := helpers.NewTestRepo(t, "lfs")
+	oid := strings.Repeat("ab", 32)
+	objDir := filepath.Join(lfs.Path, ".git", "lfs", "objects", "ab", "ab")
+	if err := os.MkdirAll(objDir, 0750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(objDir, oid), make([]byte, 2048), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	reports := AnalyzeStorage([]string{big.Path, small.Path, lfs.Path}, 32*1024, 2, nil)
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %+v", reports)
+	}
+
+	byName := make(map[string]StorageReport)
+	for _, r := range reports {
+		byName[r.RepoName] = r
+	}
+
+	b := byName["big"]
+	if len(b.LargeBlobs) != 1 || b.LargeBlobs[0].Path != "asset.bin" || b.HasLFS() {
+		t.Errorf("big: unexpected report %+v", b)
+	}
+	if b.LargeBlobBytes() <= 0 {
+		t.Error("big: expected non-zero on-disk blob size")
+	}
+
+	l := byName["lfs"]
+	if !l.HasLFS() || l.LFSObjectsBytes != 2048 || len(l.LargeBlobs) != 0 {
+		t.Errorf("lfs: unexpected report %+v", l)
+	}
+}
+
+func TestLFSObjectPath(t *testing.T) {
+	oid := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	got := lfsObjectPath("/r/.git/lfs/objects", oid)
+	want := filepath.Join("/r/.git/lfs/objects", "4d", "7a", oid)
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
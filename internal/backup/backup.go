@@ -0,0 +1,178 @@
+// Package backup writes git bundles of branches and repositories before
+// they are deleted, so destructive operations stay recoverable until the
+// bundles expire.
+package backup
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// bundleExt is the file extension of every bundle written by a Store.
+// Prune only considers files with this extension.
+const bundleExt = ".bundle"
+
+// Store writes bundles into a single backup directory. A nil *Store is
+// valid and makes every bundle call a no-op, so callers do not need to
+// check whether backups are enabled.
+type Store struct {
+	dir           string
+	retentionDays int
+	now           func() time.Time
+}
+
+// DefaultDir returns the default backup directory
+// (~/.local/share/katazuke/backups/).
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("backup: home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "katazuke", "backups"), nil
+}
+
+// New returns a Store writing to dir. The directory is created on the
+// first bundle. retentionDays is informational; expiry is enforced by
+// Prune.
+func New(dir string, retentionDays int) *Store {
+	return &Store{dir: dir, retentionDays: retentionDays, now: time.Now}
+}
+
+// Dir returns the backup directory, or "" for a nil Store.
+func (s *Store) Dir() string {
+	if s == nil {
+		return ""
+	}
+	return s.dir
+}
+
+// RetentionDays returns how long bundles are kept, 0 meaning forever.
+func (s *Store) RetentionDays() int {
+	if s == nil {
+		return 0
+	}
+	return s.retentionDays
+}
+
+// BundleBranch writes a bundle containing the given local branch and
+// returns its path. Restore with: git fetch <bundle> <branch>:<branch>.
+// Returns "" without error on a nil Store.
+func (s *Store) BundleBranch(repoPath, branch string) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	dest, err := s.reserve(repoPath, "@"+branch)
+	if err != nil {
+		return "", err
+	}
+	if err := git.CreateBranchBundle(repoPath, dest, branch); err != nil {
+		_ = os.Remove(dest)
+		return "", fmt.Errorf("backup: bundle %s: %w", branch, err)
+	}
+	return dest, nil
+}
+
+// BundleTag writes a bundle containing the given tag and returns its
+// path. Restore with: git fetch <bundle> refs/tags/<tag>:refs/tags/<tag>.
+// Returns "" without error on a nil Store.
+func (s *Store) BundleTag(repoPath, tag string) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	dest, err := s.reserve(repoPath, "@tags/"+tag)
+	if err != nil {
+		return "", err
+	}
+	if err := git.CreateTagBundle(repoPath, dest, tag); err != nil {
+		_ = os.Remove(dest)
+		return "", fmt.Errorf("backup: bundle tag %s: %w", tag, err)
+	}
+	return dest, nil
+}
+
+// BundleRepo writes a bundle of every ref in the repository and returns
+// its path. Restore with: git clone <bundle>. Returns "" without error on
+// a nil Store.
+func (s *Store) BundleRepo(repoPath string) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	dest, err := s.reserve(repoPath, "")
+	if err != nil {
+		return "", err
+	}
+	if err := git.CreateBundle(repoPath, dest); err != nil {
+		_ = os.Remove(dest)
+		return "", fmt.Errorf("backup: bundle %s: %w", repoPath, err)
+	}
+	return dest, nil
+}
+
+// reserve creates a new, empty bundle file for repoPath and returns its
+// path, e.g. widgets-1f2e3d4c@feature-x-20260301-093000-123456.bundle. The
+// name carries a hash of the repository's absolute path, so repositories
+// with the same directory name do not collide, and the file is created
+// exclusively with a random suffix, so an existing bundle is never
+// overwritten. suffix (e.g. "@branch") follows the repository name;
+// slashes in it are flattened so every bundle lives directly in the backup
+// directory.
+func (s *Store) reserve(repoPath, suffix string) (string, error) {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return "", fmt.Errorf("backup: create %s: %w", s.dir, err)
+	}
+	abs, err := filepath.Abs(repoPath)
+	if err != nil {
+		abs = repoPath
+	}
+	sum := sha256.Sum256([]byte(abs))
+	name := fmt.Sprintf("%s-%x%s-%s-", filepath.Base(repoPath), sum[:4],
+		strings.ReplaceAll(suffix, "/", "-"), s.now().Format("20060102-150405"))
+	f, err := os.CreateTemp(s.dir, name+"*"+bundleExt)
+	if err != nil {
+		return "", fmt.Errorf("backup: create bundle file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("backup: create bundle file: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// Prune deletes bundles in dir last modified more than retentionDays
+// before now and returns their names. A retentionDays of zero or less
+// keeps bundles forever. A missing directory is not an error.
+func Prune(dir string, retentionDays int, now time.Time) ([]string, error) {
+	if retentionDays <= 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("backup: read directory: %w", err)
+	}
+
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	var deleted []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), bundleExt) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil || !info.ModTime().Before(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+			return deleted, fmt.Errorf("backup: remove %s: %w", e.Name(), err)
+		}
+		deleted = append(deleted, e.Name())
+	}
+	return deleted, nil
+}
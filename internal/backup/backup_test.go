@@ -0,0 +1,157 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestNilStoreIsNoOp(t *testing.T) {
+	var s *Store
+	if p, err := s.BundleBranch("/nowhere", "main"); p != "" || err != nil {
+		t.Errorf("BundleBranch on nil store = %q, %v", p, err)
+	}
+	if p, err := s.BundleTag("/nowhere", "v1"); p != "" || err != nil {
+		t.Errorf("BundleTag on nil store = %q, %v", p, err)
+	}
+	if p, err := s.BundleRepo("/nowhere"); p != "" || err != nil {
+		t.Errorf("BundleRepo on nil store = %q, %v", p, err)
+	}
+	if s.Dir() != "" {
+		t.Errorf("Dir on nil store = %q", s.Dir())
+	}
+}
+
+func TestBundleBranch(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "widgets")
+	repo.CreateBranch("feature/doomed")
+	repo.WriteFile("doomed.txt", "keep me\n")
+	repo.AddFile("doomed.txt")
+	repo.Commit("doomed work")
+	repo.Checkout("main")
+
+	s := New(filepath.Join(t.TempDir(), "backups"), 30)
+	s.now = func() time.Time { return time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC) }
+
+	path, err := s.BundleBranch(repo.Path, "feature/doomed")
+	if err != nil {
+		t.Fatalf("BundleBranch: %v", err)
+	}
+	name := filepath.Base(path)
+	if filepath.Dir(path) != s.Dir() || !strings.HasPrefix(name, "widgets-") ||
+		!strings.Contains(name, "@feature-doomed-20260301-093000-") || !strings.HasSuffix(name, ".bundle") {
+		t.Errorf("unexpected bundle path %q", path)
+	}
+
+	// A second bundle in the same second, and one of a same-named
+	// repository elsewhere, get their own files.
+	again, err := s.BundleBranch(repo.Path, "feature/doomed")
+	if err != nil {
+		t.Fatalf("BundleBranch again: %v", err)
+	}
+	other := helpers.NewTestRepo(t, "widgets")
+	other.CreateBranch("feature/doomed")
+	elsewhere, err := s.BundleBranch(other.Path, "feature/doomed")
+	if err != nil {
+		t.Fatalf("BundleBranch of the other repo: %v", err)
+	}
+	if again == path || elsewhere == path || elsewhere == again {
+		t.Errorf("expected distinct bundles, got %q, %q, %q", path, again, elsewhere)
+	}
+	if strings.SplitN(filepath.Base(elsewhere), "@", 2)[0] == strings.SplitN(name, "@", 2)[0] {
+		t.Errorf("expected the repository hash to differ, got %q and %q", name, filepath.Base(elsewhere))
+	}
+
+	// A failed bundle is an error and leaves no file behind.
+	before, _ := os.ReadDir(s.Dir())
+	if _, err := s.BundleBranch(repo.Path, "no-such-branch"); err == nil {
+		t.Error("expected an error bundling a missing branch")
+	}
+	if after, _ := os.ReadDir(s.Dir()); len(after) != len(before) {
+		t.Errorf("expected no leftover file, had %d entries, now %d", len(before), len(after))
+	}
+
+	// The branch must be recoverable after deletion.
+	repo.Git("branch", "-D", "feature/doomed")
+	repo.Git("fetch", path, "feature/doomed:feature/doomed")
+	// #nosec G204 - test-controlled arguments
+	out, err := exec.Command("git", "-C", repo.Path, "show", "feature/doomed:doomed.txt").CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "keep me" {
+		t.Errorf("restored branch content = %q, %v", out, err)
+	}
+}
+
+func TestBundleTag(t *testing.T) {
+	// archive/feature is the only ref to its commit, as after archiving a
+	// branch.
+	repo := helpers.NewTestRepo(t, "widgets")
+	repo.CreateBranch("feature")
+	repo.WriteFile("archived.txt", "keep me\n")
+	repo.AddFile("archived.txt")
+	repo.Commit("archived work")
+	repo.Git("tag", "archive/feature")
+	repo.Checkout("main")
+	repo.Git("branch", "-D", "feature")
+	s := New(filepath.Join(t.TempDir(), "backups"), 30)
+
+	path, err := s.BundleTag(repo.Path, "archive/feature")
+	if err != nil {
+		t.Fatalf("BundleTag: %v", err)
+	}
+	if name := filepath.Base(path); !strings.Contains(name, "@tags-archive-feature-") {
+		t.Errorf("unexpected bundle path %q", path)
+	}
+	if _, err := s.BundleTag(repo.Path, "no-such-tag"); err == nil {
+		t.Error("expected an error bundling a missing tag")
+	}
+
+	// The tag and its commit must be recoverable after deletion.
+	repo.Git("tag", "-d", "archive/feature")
+	repo.Git("fetch", path, "refs/tags/archive/feature:refs/tags/archive/feature")
+	// #nosec G204 - test-controlled arguments
+	out, err := exec.Command("git", "-C", repo.Path, "show", "archive/feature:archived.txt").CombinedOutput()
+	if err != nil || strings.TrimSpace(string(out)) != "keep me" {
+		t.Errorf("restored tag content = %q, %v", out, err)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	files := map[string]time.Time{
+		"old@main-20260101-000000.bundle": now.AddDate(0, 0, -45),
+		"new@main-20260330-000000.bundle": now.AddDate(0, 0, -1),
+		"notes.txt":                       now.AddDate(0, 0, -90),
+	}
+	for name, mtime := range files {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := Prune(dir, 30, now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if want := []string{"old@main-20260101-000000.bundle"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("deleted = %v, want %v", deleted, want)
+	}
+
+	// Zero retention keeps everything; a missing directory is fine.
+	if deleted, err := Prune(dir, 0, now); err != nil || len(deleted) != 0 {
+		t.Errorf("Prune with zero retention = %v, %v", deleted, err)
+	}
+	if _, err := Prune(filepath.Join(dir, "missing"), 30, now); err != nil {
+		t.Errorf("Prune on missing dir: %v", err)
+	}
+}
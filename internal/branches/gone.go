@@ -0,0 +1,117 @@
+package branches
+
+import (
+	"log/slog"
+	"path/filepath"
+
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// FindUpstreamGone scans the given repositories and returns the branches
+// whose upstream was deleted from the remote, whatever their age. It reads
+// only the branch metadata git already has, with no merge detection or
+// GitHub lookups, so it reflects the last fetch with --prune. Each
+// branch's TipReachable says whether its commits are in the default branch
+// or a remote-tracking branch. The default branch, the currently checked
+// out branch, and protected branches are excluded. Each decision is
+// recorded to ex when it is non-nil.
+func FindUpstreamGone(repos []string, workers int, ex *explain.Log, onProgress func(completed, total int)) []StaleBranch {
+	var resultCb func(int, int, []StaleBranch)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ []StaleBranch) {
+			onProgress(completed, total)
+		}
+	}
+
+	repoResults := parallel.Run(repos, workers, func(repoPath string) []StaleBranch {
+		return findUpstreamGoneInRepo(repoPath, ex)
+	}, resultCb)
+
+	var results []StaleBranch
+	for _, rr := range repoResults {
+		results = append(results, rr...)
+	}
+	return results
+}
+
+func findUpstreamGoneInRepo(repoPath string, ex *explain.Log) []StaleBranch {
+	repoName := filepath.Base(repoPath)
+
+	if op := git.ConflictState(repoPath); op != "" {
+		slog.Warn("skipping repo: operation in progress", "repo", repoName, "operation", op)
+		ex.Addf(repoName, "", explain.Skipped, "%s in progress", op)
+		return nil
+	}
+	if err := git.CheckOwnership(repoPath); err != nil {
+		slog.Warn("skipping repo: owned by another user", "repo", repoName)
+		ex.Addf(repoName, "", explain.Skipped, "owned by another user and not listed in safe.directory")
+		return nil
+	}
+
+	infos, err := git.BranchInfos(repoPath)
+	if err != nil {
+		slog.Warn("skipping repo: could not list branches",
+			"repo", repoName, "error", err)
+		return nil
+	}
+	var gone []git.BranchInfo
+	for _, info := range infos {
+		if info.UpstreamGone {
+			gone = append(gone, info)
+		}
+	}
+	if len(gone) == 0 {
+		return nil
+	}
+
+	// Only repos with a gone upstream pay for the default and current
+	// branch lookups.
+	defaultBranch, err := git.DefaultBranch(repoPath)
+	if err != nil {
+		slog.Warn("skipping repo: could not determine default branch",
+			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine default branch")
+		return nil
+	}
+	currentBranch, err := git.CurrentBranch(repoPath)
+	if err != nil {
+		slog.Warn("skipping repo: could not determine current branch",
+			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine current branch")
+		return nil
+	}
+	keep := make(map[string]bool, len(gone))
+	for _, name := range excludeDefaultAndCurrent(branchNames(gone), defaultBranch, currentBranch, repoName, ex) {
+		keep[name] = true
+	}
+
+	var results []StaleBranch
+	for _, info := range gone {
+		if !keep[info.Name] {
+			continue
+		}
+		reachable, err := git.IsReachable(repoPath, "refs/heads/"+info.Name, defaultBranch)
+		if err != nil {
+			slog.Debug("could not check whether the branch tip is reachable",
+				"repo", repoName, "branch", info.Name, "error", err)
+		}
+		if reachable {
+			ex.Add(repoName, info.Name, explain.Reported, "upstream branch was deleted from the remote; its commits exist elsewhere")
+		} else {
+			ex.Add(repoName, info.Name, explain.Reported, "upstream branch was deleted from the remote, but its tip is in no other branch; needs review")
+		}
+		results = append(results, StaleBranch{
+			RepoPath:          repoPath,
+			RepoName:          repoName,
+			Branch:            info.Name,
+			LastCommit:        info.CommitDate,
+			LastCommitMessage: info.Subject,
+			IsUpstreamGone:    true,
+			TipReachable:      reachable,
+			IsAutomation:      IsAutomationBranch(info.Name),
+		})
+	}
+	return results
+}
@@ -0,0 +1,47 @@
+package branches_test
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFindUpstreamGone(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "find-gone")
+
+	// "landed", "lost" and "checked-out" lose their remote branches;
+	// "active" keeps it and "local" never had one. "landed" is merged into
+	// main first, so only "lost" has commits found nowhere else.
+	for _, name := range []string{"landed", "lost", "checked-out", "active"} {
+		repo.CreateBranch(name)
+		repo.WriteFile(name+".txt", name)
+		repo.AddFile(name + ".txt")
+		repo.Commit(name + " commit")
+		repo.Git("push", "-u", "origin", name)
+		repo.Checkout("main")
+	}
+	repo.Merge("landed")
+	repo.CreateBranch("local")
+	repo.Git("push", "origin", "--delete", "landed", "lost", "checked-out")
+	repo.Checkout("checked-out")
+
+	results := branches.FindUpstreamGone([]string{repo.Path}, 1, nil, nil)
+	byName := make(map[string]branches.StaleBranch)
+	for _, s := range results {
+		byName[s.Branch] = s
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected landed and lost, got %+v", results)
+	}
+	if s := byName["landed"]; !s.IsUpstreamGone || !s.TipReachable || s.LastCommitMessage != "landed commit" {
+		t.Errorf("expected landed to be gone and reachable, got %+v", s)
+	}
+	if s := byName["lost"]; !s.IsUpstreamGone || s.TipReachable {
+		t.Errorf("expected lost to be gone and unreachable, got %+v", s)
+	}
+
+	if results := branches.FindUpstreamGone([]string{helpers.NewTestRepo(t, "no-remote").Path}, 1, nil, nil); len(results) != 0 {
+		t.Errorf("expected nothing in a repo without a remote, got %+v", results)
+	}
+}
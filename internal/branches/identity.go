@@ -0,0 +1,73 @@
+package branches
+
+import (
+	"strings"
+)
+
+// noreplyDomain is the domain GitHub uses for private commit emails, in
+// either the legacy "login@" or the current "12345+login@" form.
+const noreplyDomain = "@users.noreply.github.com"
+
+// Identity is the set of author emails treated as the user's own when
+// deciding whether a branch is safe to delete. The zero value matches
+// nothing beyond the repo's configured user.email.
+type Identity struct {
+	emails map[string]bool
+}
+
+// NewIdentity returns an Identity matching any of the given emails.
+// Matching is case-insensitive, and GitHub noreply aliases match in both
+// forms, so configuring "12345+me@users.noreply.github.com" also matches
+// "me@users.noreply.github.com".
+func NewIdentity(emails ...string) Identity {
+	id := Identity{emails: make(map[string]bool, len(emails))}
+	for _, e := range emails {
+		if e = strings.TrimSpace(e); e != "" {
+			id.emails[normalizeEmail(e)] = true
+		}
+	}
+	return id
+}
+
+// With returns a copy of id that also matches email. Used to add a repo's
+// user.email to the configured identities.
+func (id Identity) With(email string) Identity {
+	out := NewIdentity(email)
+	for e := range id.emails {
+		out.emails[e] = true
+	}
+	return out
+}
+
+// WithLogin returns a copy of id that also matches the GitHub noreply
+// address of login, which GitHub uses for commits made in its web UI and
+// by users who keep their email private.
+func (id Identity) WithLogin(login string) Identity {
+	if login == "" {
+		return id
+	}
+	return id.With(login + noreplyDomain)
+}
+
+// Empty reports whether the identity has no emails, in which case
+// ownership cannot be determined.
+func (id Identity) Empty() bool {
+	return len(id.emails) == 0
+}
+
+// Matches reports whether email belongs to the user.
+func (id Identity) Matches(email string) bool {
+	return id.emails[normalizeEmail(email)]
+}
+
+// normalizeEmail lowercases email and strips the numeric ID prefix from
+// GitHub noreply addresses.
+func normalizeEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if local, ok := strings.CutSuffix(email, noreplyDomain); ok {
+		if _, login, found := strings.Cut(local, "+"); found {
+			return login + noreplyDomain
+		}
+	}
+	return email
+}
@@ -0,0 +1,50 @@
+package branches
+
+import "testing"
+
+func TestIdentityMatches(t *testing.T) {
+	id := NewIdentity("Me@Work.example", "12345+me@users.noreply.github.com", " ").With("me@personal.example")
+
+	tests := []struct {
+		email string
+		want  bool
+	}{
+		{"me@work.example", true},
+		{"ME@PERSONAL.EXAMPLE", true},
+		{"me@users.noreply.github.com", true},
+		{"99999+me@users.noreply.github.com", true},
+		{"someone@work.example", false},
+		{"notme@users.noreply.github.com", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := id.Matches(tt.email); got != tt.want {
+			t.Errorf("Matches(%q) = %v, want %v", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityEmpty(t *testing.T) {
+	if !NewIdentity().Empty() {
+		t.Error("expected identity with no emails to be empty")
+	}
+	if !(Identity{}).With("").Empty() {
+		t.Error("adding an empty email should keep the identity empty")
+	}
+	if (Identity{}).With("me@example.com").Empty() {
+		t.Error("expected identity to be non-empty after adding an email")
+	}
+}
+
+func TestIdentityWithLogin(t *testing.T) {
+	id := NewIdentity("me@work.example").WithLogin("octo")
+	if !id.Matches("octo@users.noreply.github.com") || !id.Matches("42+octo@users.noreply.github.com") {
+		t.Error("expected the login's noreply addresses to match")
+	}
+	if !id.Matches("me@work.example") {
+		t.Error("expected the configured email to still match")
+	}
+	if !(Identity{}).WithLogin("").Empty() {
+		t.Error("an empty login should keep the identity empty")
+	}
+}
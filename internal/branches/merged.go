@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/agrahamlincoln/katazuke/internal/explain"
 	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
@@ -20,6 +21,12 @@ type MergeMethodResolver interface {
 	PRMergeMethod(owner, repo, mergeCommitSHA string) (string, error)
 }
 
+// CIStatusResolver reports the CI status of a commit. Implemented by
+// *github.Client.
+type CIStatusResolver interface {
+	CommitCIStatus(owner, repo, sha string) (ghclient.CIStatus, error)
+}
+
 // MergedBranch represents a branch that has been merged into the default branch.
 type MergedBranch struct {
 	RepoPath   string
@@ -27,9 +34,13 @@ type MergedBranch struct {
 	Branch     string
 	LastCommit time.Time
 	HasRemote  bool
+	// Remote is the remote that has a branch of the same name, where
+	// remote deletion happens; "" when HasRemote is false.
+	Remote string
 	// ForceDelete is true when the branch was detected as merged via the
-	// GitHub API (e.g. squash-merge) rather than by git. These branches
-	// require git branch -D because git does not recognize them as merged.
+	// GitHub API or by patch ID (e.g. squash-merge) rather than by git.
+	// These branches require git branch -D because git does not recognize
+	// them as merged.
 	ForceDelete bool
 	// PRNumber is the GitHub PR number (0 if not available or git-detected).
 	PRNumber int
@@ -40,14 +51,18 @@ type MergedBranch struct {
 	MergeCommitSHA string
 	// MergeMethod is "merge", "squash", or "" if unknown.
 	MergeMethod string
+	// CIStatus is the CI status of the branch tip, looked up for branches
+	// with a PR; ghclient.CINone when unknown.
+	CIStatus ghclient.CIStatus
 }
 
 // FindMerged scans the given repositories and returns branches that have been
 // merged into each repo's default branch. The current branch and the default
 // branch itself are excluded from results. Work is parallelized across the
 // given number of workers. The detector combines local git checks with
-// GitHub API lookups to catch squash-merges.
-func FindMerged(repos []string, detector *merge.Detector, workers int, onProgress func(completed, total int)) ([]MergedBranch, error) {
+// GitHub API lookups to catch squash-merges. Each decision is recorded to
+// ex when it is non-nil.
+func FindMerged(repos []string, detector *merge.Detector, workers int, ex *explain.Log, onProgress func(completed, total int)) ([]MergedBranch, error) {
 	var resultCb func(int, int, []MergedBranch)
 	if onProgress != nil {
 		resultCb = func(completed, total int, _ []MergedBranch) {
@@ -56,7 +71,7 @@ func FindMerged(repos []string, detector *merge.Detector, workers int, onProgres
 	}
 
 	repoResults := parallel.Run(repos, workers, func(repoPath string) []MergedBranch {
-		return findMergedInRepo(repoPath, detector)
+		return findMergedInRepo(repoPath, detector, ex)
 	}, resultCb)
 
 	results := make([]MergedBranch, 0, len(repoResults))
@@ -80,28 +95,13 @@ func EnrichMergeMethod(merged []MergedBranch, resolver MergeMethodResolver, work
 		mergeCommitSHA string
 	}
 
-	// Cache remote URL per repo to avoid redundant git subprocess calls.
-	remoteCache := make(map[string]string)
-
+	repos := newGitHubRepos()
 	var jobs []enrichJob
 	for i, m := range merged {
 		if m.MergeCommitSHA == "" {
 			continue
 		}
-		remote, cached := remoteCache[m.RepoPath]
-		if !cached {
-			var err error
-			remote, err = git.RemoteURL(m.RepoPath, "origin")
-			if err != nil {
-				remoteCache[m.RepoPath] = ""
-				continue
-			}
-			remoteCache[m.RepoPath] = remote
-		}
-		if remote == "" {
-			continue
-		}
-		owner, repo, ok := ghclient.ParseGitHubRemote(remote)
+		owner, repo, ok := repos.lookup(m.RepoPath)
 		if !ok {
 			continue
 		}
@@ -141,13 +141,93 @@ func EnrichMergeMethod(merged []MergedBranch, resolver MergeMethodResolver, work
 	return merged
 }
 
-func findMergedInRepo(repoPath string, detector *merge.Detector) []MergedBranch {
+// EnrichCIStatus looks up the CI status of the tip of each branch with a
+// PR, so prompts can tell finished work from abandoned work. The returned
+// slice is the same as the input; items are modified in place.
+func EnrichCIStatus(merged []MergedBranch, resolver CIStatusResolver, workers int) []MergedBranch {
+	if resolver == nil {
+		return merged
+	}
+	type ciJob struct {
+		index            int
+		owner, repo, sha string
+	}
+
+	repos := newGitHubRepos()
+	var jobs []ciJob
+	for i, m := range merged {
+		if m.PRNumber == 0 {
+			continue
+		}
+		owner, repo, ok := repos.lookup(m.RepoPath)
+		if !ok {
+			continue
+		}
+		sha, err := git.RevParse(m.RepoPath, m.Branch)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, ciJob{index: i, owner: owner, repo: repo, sha: sha})
+	}
+
+	type ciResult struct {
+		index  int
+		status ghclient.CIStatus
+	}
+
+	results := parallel.Run(jobs, workers, func(j ciJob) ciResult {
+		status, err := resolver.CommitCIStatus(j.owner, j.repo, j.sha)
+		if err != nil {
+			slog.Debug("could not look up CI status", "repo", j.owner+"/"+j.repo, "sha", j.sha, "error", err)
+		}
+		return ciResult{index: j.index, status: status}
+	}, nil)
+	for _, r := range results {
+		merged[r.index].CIStatus = r.status
+	}
+	return merged
+}
+
+// githubRepos maps repositories to the GitHub owner and repo of their
+// primary remote, caching the lookups to avoid redundant git subprocess
+// calls.
+type githubRepos map[string][2]string
+
+func newGitHubRepos() githubRepos { return make(githubRepos) }
+
+// lookup returns the GitHub owner and repo of repoPath's primary remote, or false
+// when it has none.
+func (g githubRepos) lookup(repoPath string) (owner, repo string, ok bool) {
+	if r, cached := g[repoPath]; cached {
+		return r[0], r[1], r[0] != ""
+	}
+	remotes, err := git.RemoteURLs(repoPath, git.PrimaryRemote(repoPath))
+	if err == nil {
+		owner, repo, ok = ghclient.ParseGitHubRemotes(remotes)
+	}
+	g[repoPath] = [2]string{owner, repo}
+	return owner, repo, ok
+}
+
+func findMergedInRepo(repoPath string, detector *merge.Detector, ex *explain.Log) []MergedBranch {
 	repoName := filepath.Base(repoPath)
 
+	if op := git.ConflictState(repoPath); op != "" {
+		slog.Warn("skipping repo: operation in progress", "repo", repoName, "operation", op)
+		ex.Addf(repoName, "", explain.Skipped, "%s in progress", op)
+		return nil
+	}
+	if err := git.CheckOwnership(repoPath); err != nil {
+		slog.Warn("skipping repo: owned by another user", "repo", repoName)
+		ex.Addf(repoName, "", explain.Skipped, "owned by another user and not listed in safe.directory")
+		return nil
+	}
+
 	defaultBranch, err := git.DefaultBranch(repoPath)
 	if err != nil {
 		slog.Warn("skipping repo: could not determine default branch",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine default branch")
 		return nil
 	}
 
@@ -155,6 +235,7 @@ func findMergedInRepo(repoPath string, detector *merge.Detector) []MergedBranch
 	if err != nil {
 		slog.Warn("skipping repo: could not determine current branch",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine current branch")
 		return nil
 	}
 
@@ -162,66 +243,125 @@ func findMergedInRepo(repoPath string, detector *merge.Detector) []MergedBranch
 		slog.Debug("repo has detached HEAD, no branch to exclude", "repo", repoName)
 	}
 
-	allBranches, err := git.ListBranches(repoPath)
+	infos, err := git.BranchInfos(repoPath)
 	if err != nil {
 		slog.Warn("skipping repo: could not list branches",
 			"repo", repoName, "error", err)
 		return nil
 	}
+	allBranches := branchNames(infos)
+	byName := make(map[string]git.BranchInfo, len(infos))
+	for _, info := range infos {
+		byName[info.Name] = info
+	}
 
 	// Filter out default and current branches before passing to the detector
 	// to avoid unnecessary API calls for branches we'd discard anyway.
-	candidates := make([]string, 0, len(allBranches))
-	for _, b := range allBranches {
-		if b != defaultBranch && b != currentBranch {
-			candidates = append(candidates, b)
-		}
-	}
+	candidates := excludeDefaultAndCurrent(allBranches, defaultBranch, currentBranch, repoName, ex)
 
 	detected, err := detector.MergedBranches(repoPath, defaultBranch, candidates)
 	if err != nil {
 		slog.Warn("skipping repo: could not list merged branches",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not list merged branches")
 		return nil
 	}
 
+	if ex != nil {
+		detectedSet := make(map[string]bool, len(detected))
+		for _, d := range detected {
+			detectedSet[d.Name] = true
+		}
+		for _, b := range candidates {
+			if !detectedSet[b] {
+				ex.Addf(repoName, b, explain.Excluded,
+					"not merged: git does not see it in %s and no merged PR was found", defaultBranch)
+			}
+		}
+	}
+
 	// The detector's git-merged set can include default/current
 	// branches since git branch --merged is not filtered by the
 	// candidates list. Exclude them here as a safety net.
 	var results []MergedBranch
 	for _, d := range detected {
-		if d.Name == defaultBranch || d.Name == currentBranch {
+		if d.Name == defaultBranch || d.Name == currentBranch || IsProtectedBranch(d.Name) {
 			continue
 		}
 
-		commitDate, err := git.CommitDate(repoPath, d.Name)
-		if err != nil {
-			slog.Warn("could not get commit date, using zero time",
-				"repo", repoName, "branch", d.Name, "error", err)
+		info, ok := byName[d.Name]
+		if !ok {
+			slog.Warn("merged branch missing from branch list, using zero time",
+				"repo", repoName, "branch", d.Name)
 		}
 
-		hasRemote, err := git.HasRemoteBranch(repoPath, "origin", d.Name)
-		if err != nil {
-			slog.Debug("could not check remote branch",
-				"repo", repoName, "branch", d.Name, "error", err)
-		}
+		ex.Add(repoName, d.Name, explain.Reported, mergedReason(d, defaultBranch))
 
 		results = append(results, MergedBranch{
 			RepoPath:       repoPath,
 			RepoName:       repoName,
 			Branch:         d.Name,
-			LastCommit:     commitDate,
-			HasRemote:      hasRemote,
-			ForceDelete:    d.Method == merge.DetectedByGitHub,
+			LastCommit:     info.CommitDate,
+			HasRemote:      info.Remote != "",
+			Remote:         info.Remote,
+			ForceDelete:    d.Method != merge.DetectedByGit,
 			PRNumber:       d.PRNumber,
 			PRMergedAt:     d.PRMergedAt,
 			MergeCommitSHA: d.MergeCommitSHA,
+			MergeMethod:    patchMergeMethod(d.Method),
 		})
 	}
 
 	return results
 }
 
+// excludeDefaultAndCurrent drops the default, currently checked-out, and
+// protected branches from all, recording why each was dropped.
+func excludeDefaultAndCurrent(all []string, defaultBranch, currentBranch, repoName string, ex *explain.Log) []string {
+	candidates := make([]string, 0, len(all))
+	for _, b := range all {
+		switch b {
+		case defaultBranch:
+			ex.Add(repoName, b, explain.Excluded, "default branch")
+		case currentBranch:
+			ex.Add(repoName, b, explain.Excluded, "currently checked out")
+		default:
+			if IsProtectedBranch(b) {
+				ex.Add(repoName, b, explain.Protected, "matches a protected branch pattern")
+				continue
+			}
+			candidates = append(candidates, b)
+		}
+	}
+	return candidates
+}
+
+// patchMergeMethod returns "squash" for patch ID detections, which only
+// match squash commits, and "" otherwise.
+func patchMergeMethod(method merge.DetectionMethod) string {
+	if method == merge.DetectedByPatch {
+		return "squash"
+	}
+	return ""
+}
+
+// mergedReason describes which detection method found the branch merged.
+func mergedReason(d merge.DetectedBranch, defaultBranch string) string {
+	if d.Method == merge.DetectedByPatch {
+		return fmt.Sprintf("merged: its combined diff matches a squash commit on %s by patch ID (GitHub API unavailable; needs branch -D)", defaultBranch)
+	}
+	if d.Method == merge.DetectedByGitHub {
+		reason := "merged: GitHub reports"
+		if d.PRNumber > 0 {
+			reason += fmt.Sprintf(" PR #%d", d.PRNumber)
+		} else {
+			reason += " its PR"
+		}
+		return reason + " merged (git does not see it, likely a squash or rebase merge; needs branch -D)"
+	}
+	return fmt.Sprintf("merged: git reports it merged into %s", defaultBranch)
+}
+
 // Label returns a display string for the merged branch in the form "repo: branch".
 // Branches with a remote counterpart are annotated with "(backed up remotely)".
 // PR info is appended when available.
@@ -237,7 +377,14 @@ func (m MergedBranch) Label() string {
 			label += fmt.Sprintf(" [merged PR #%d]", m.PRNumber)
 		}
 	} else if m.ForceDelete {
-		label += " [merged]"
+		if m.MergeMethod != "" {
+			label += fmt.Sprintf(" [%s-merged]", m.MergeMethod)
+		} else {
+			label += " [merged]"
+		}
+	}
+	if note := m.CIStatus.Describe(); note != "" {
+		label += " [" + note + "]"
 	}
 	return label
 }
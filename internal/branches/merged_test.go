@@ -4,11 +4,15 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
 	"github.com/agrahamlincoln/katazuke/test/helpers"
 )
 
@@ -22,7 +26,7 @@ func TestFindMerged_NoMergedBranches(t *testing.T) {
 	repo.Commit("wip commit")
 	repo.Checkout("main")
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -42,7 +46,7 @@ func TestFindMerged_OneMergedBranch(t *testing.T) {
 	repo.Checkout("main")
 	repo.Merge("feature/done")
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -60,6 +64,34 @@ func TestFindMerged_OneMergedBranch(t *testing.T) {
 	}
 }
 
+func TestFindMerged_SkipsRepoWithOperationInProgress(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "mid-rebase")
+	repo.CreateBranch("feature/done")
+	repo.WriteFile("done.txt", "completed work")
+	repo.AddFile("done.txt")
+	repo.Commit("done commit")
+	repo.Checkout("main")
+	repo.Merge("feature/done")
+
+	// A rebase detaches HEAD, so the branch being rebased is not current.
+	repo.DetachHead()
+	if err := os.MkdirAll(filepath.Join(repo.Path, ".git", "rebase-merge"), 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	ex := explain.New()
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, ex, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no branches from a repo mid-rebase, got %v", results)
+	}
+	if entries := ex.Entries(); len(entries) != 1 || entries[0].Reason != "rebase in progress" {
+		t.Errorf("expected the skip to be explained, got %+v", entries)
+	}
+}
+
 func TestFindMerged_ExcludesDefaultAndCurrentBranch(t *testing.T) {
 	repo := helpers.NewTestRepo(t, "exclude-special")
 
@@ -71,7 +103,7 @@ func TestFindMerged_ExcludesDefaultAndCurrentBranch(t *testing.T) {
 	repo.Checkout("main")
 	repo.Merge("feature/merged")
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -117,7 +149,7 @@ func TestFindMerged_MultipleRepos(t *testing.T) {
 	repo2.Checkout("main")
 	repo2.Merge("feature/c")
 
-	results, err := branches.FindMerged([]string{repo1.Path, repo2.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo1.Path, repo2.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -147,7 +179,7 @@ func TestFindMerged_CommitDateIsPopulated(t *testing.T) {
 	repo.Checkout("main")
 	repo.Merge("feature/dated")
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -162,7 +194,7 @@ func TestFindMerged_CommitDateIsPopulated(t *testing.T) {
 }
 
 func TestFindMerged_EmptyRepoList(t *testing.T) {
-	results, err := branches.FindMerged(nil, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged(nil, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -323,6 +355,47 @@ func TestEnrichMergeMethod_EnrichesWithRemote(t *testing.T) {
 	}
 }
 
+// mockCIStatusResolver returns a CI status per commit SHA.
+type mockCIStatusResolver struct {
+	statuses map[string]ghclient.CIStatus
+	calls    int
+}
+
+func (m *mockCIStatusResolver) CommitCIStatus(_, _, sha string) (ghclient.CIStatus, error) {
+	m.calls++
+	return m.statuses[sha], nil
+}
+
+func TestEnrichCIStatus(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "enrich-ci")
+	repo.AddRemote("origin", "https://github.com/owner/test-repo.git")
+	repo.CreateBranch("feat-a")
+	repo.WriteFile("a.txt", "a")
+	repo.AddFile("a.txt")
+	repo.Commit("a")
+	sha, err := git.RevParse(repo.Path, "feat-a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo.Checkout("main")
+
+	mock := &mockCIStatusResolver{statuses: map[string]ghclient.CIStatus{sha: ghclient.CIFailing}}
+	input := []branches.MergedBranch{
+		{RepoPath: repo.Path, RepoName: "test-repo", Branch: "feat-a", PRNumber: 3},
+		{RepoPath: repo.Path, RepoName: "test-repo", Branch: "main"}, // no PR, not looked up
+	}
+	result := branches.EnrichCIStatus(input, mock, 1)
+	if mock.calls != 1 {
+		t.Errorf("expected 1 API call, got %d", mock.calls)
+	}
+	if result[0].CIStatus != ghclient.CIFailing || result[1].CIStatus != ghclient.CINone {
+		t.Errorf("unexpected statuses %q and %q", result[0].CIStatus, result[1].CIStatus)
+	}
+	if want := "test-repo: feat-a [merged PR #3] [CI failing]"; result[0].Label() != want {
+		t.Errorf("Label() = %q, want %q", result[0].Label(), want)
+	}
+}
+
 func TestFindMerged_HasRemoteField(t *testing.T) {
 	// Create a bare remote and a clone with a proper origin.
 	origin := helpers.NewTestRepo(t, "remote-merged-origin")
@@ -370,7 +443,7 @@ func TestFindMerged_HasRemoteField(t *testing.T) {
 	gitRun(t, clonePath, "checkout", "main")
 	gitRun(t, clonePath, "merge", "--no-ff", "feature/local-only", "-m", "Merge feature/local-only")
 
-	results, err := branches.FindMerged([]string{clonePath}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{clonePath}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -411,7 +484,7 @@ func TestFindMerged_HasRemoteFalseWithoutOrigin(t *testing.T) {
 	repo.Checkout("main")
 	repo.Merge("feature/done")
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -437,7 +510,7 @@ func TestFindMerged_DetachedHEAD(t *testing.T) {
 	// Detach HEAD.
 	repo.DetachHead()
 
-	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -470,3 +543,69 @@ func writeFile(t *testing.T, dir, name, content string) {
 		t.Fatalf("failed to write %s: %v", name, err)
 	}
 }
+
+func TestFindMerged_Explain(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "explain-merged")
+
+	repo.CreateBranch("feature/done")
+	repo.WriteFile("done.txt", "completed work")
+	repo.AddFile("done.txt")
+	repo.Commit("done commit")
+	repo.Checkout("main")
+	repo.Merge("feature/done")
+
+	repo.CreateBranch("feature/wip")
+	repo.WriteFile("wip.txt", "work in progress")
+	repo.AddFile("wip.txt")
+	repo.Commit("wip commit")
+
+	ex := explain.New()
+	if _, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, ex, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[string]string)
+	for _, e := range ex.Entries() {
+		got[e.Subject] = e.Verdict
+	}
+	want := map[string]string{
+		"main":         explain.Excluded,
+		"feature/wip":  explain.Excluded, // currently checked out
+		"feature/done": explain.Reported,
+	}
+	for branch, verdict := range want {
+		if got[branch] != verdict {
+			t.Errorf("%s: got verdict %q, want %q", branch, got[branch], verdict)
+		}
+	}
+}
+
+func TestFindMerged_SquashMergedByPatch(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "squash-patch")
+
+	repo.CreateBranch("feature/squashed")
+	repo.WriteFile("s.txt", "one\n")
+	repo.AddFile("s.txt")
+	repo.Commit("one")
+	repo.WriteFile("s.txt", "one\ntwo\n")
+	repo.AddFile("s.txt")
+	repo.Commit("two")
+	repo.Checkout("main")
+	repo.Git("merge", "--squash", "feature/squashed")
+	repo.Commit("Squashed feature (#7)")
+
+	results, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected squash-merged branch to be detected, got %v", results)
+	}
+	r := results[0]
+	if r.Branch != "feature/squashed" || !r.ForceDelete || r.MergeMethod != "squash" {
+		t.Errorf("expected force-deletable squash-merged branch, got %+v", r)
+	}
+	if label := r.Label(); !strings.Contains(label, "[squash-merged]") {
+		t.Errorf("expected squash-merged label, got %q", label)
+	}
+}
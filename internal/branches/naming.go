@@ -0,0 +1,117 @@
+package branches
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// NamingRules are the conventions LintNames checks branch names against.
+// Zero values disable a check.
+type NamingRules struct {
+	// Prefixes are the allowed name prefixes, e.g. "feature/".
+	Prefixes []string
+	// MaxLength is the longest allowed name.
+	MaxLength int
+	// Lowercase rejects names containing uppercase letters.
+	Lowercase bool
+}
+
+// IsZero reports whether no convention is set.
+func (r NamingRules) IsZero() bool {
+	return len(r.Prefixes) == 0 && r.MaxLength == 0 && !r.Lowercase
+}
+
+// Check returns the conventions branch breaks, e.g. "uppercase letters",
+// or nil when it follows them all.
+func (r NamingRules) Check(branch string) []string {
+	var problems []string
+	if len(r.Prefixes) > 0 && !hasAnyPrefix(branch, r.Prefixes) {
+		problems = append(problems, "no allowed prefix ("+strings.Join(r.Prefixes, ", ")+")")
+	}
+	if r.MaxLength > 0 && len(branch) > r.MaxLength {
+		problems = append(problems, fmt.Sprintf("%d characters, over %d", len(branch), r.MaxLength))
+	}
+	if r.Lowercase && strings.IndexFunc(branch, unicode.IsUpper) >= 0 {
+		problems = append(problems, "uppercase letters")
+	}
+	return problems
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// NamingViolation is a local branch whose name breaks a convention.
+type NamingViolation struct {
+	RepoPath string
+	RepoName string
+	Branch   string
+	// Problems describes each convention the name breaks.
+	Problems []string
+}
+
+// LintNames checks the local branches of repos against rules and returns
+// the violations, sorted by repository and branch. Default, protected,
+// and automation branches are not checked, since their names are not
+// the user's to choose.
+func LintNames(repos []string, rules NamingRules, workers int, onProgress func(completed, total int)) []NamingViolation {
+	var resultCb func(int, int, []NamingViolation)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ []NamingViolation) {
+			onProgress(completed, total)
+		}
+	}
+
+	repoResults := parallel.Run(repos, workers, func(repoPath string) []NamingViolation {
+		return lintRepo(repoPath, rules)
+	}, resultCb)
+
+	var violations []NamingViolation
+	for _, rr := range repoResults {
+		violations = append(violations, rr...)
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].RepoPath != violations[j].RepoPath {
+			return violations[i].RepoPath < violations[j].RepoPath
+		}
+		return violations[i].Branch < violations[j].Branch
+	})
+	return violations
+}
+
+func lintRepo(repoPath string, rules NamingRules) []NamingViolation {
+	repoName := filepath.Base(repoPath)
+
+	names, err := git.ListBranches(repoPath)
+	if err != nil {
+		slog.Warn("skipping repo: could not list branches", "repo", repoName, "error", err)
+		return nil
+	}
+	defaultBranch, err := git.DefaultBranch(repoPath)
+	if err != nil {
+		slog.Debug("could not determine default branch", "repo", repoName, "error", err)
+	}
+
+	var violations []NamingViolation
+	for _, name := range names {
+		if name == defaultBranch || IsProtectedBranch(name) || IsAutomationBranch(name) {
+			continue
+		}
+		if problems := rules.Check(name); len(problems) > 0 {
+			violations = append(violations, NamingViolation{RepoPath: repoPath, RepoName: repoName, Branch: name, Problems: problems})
+		}
+	}
+	return violations
+}
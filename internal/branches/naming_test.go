@@ -0,0 +1,56 @@
+package branches_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestNamingRulesCheck(t *testing.T) {
+	rules := branches.NamingRules{Prefixes: []string{"feature/", "fix/"}, MaxLength: 20, Lowercase: true}
+	tests := []struct {
+		branch string
+		want   int
+	}{
+		{"feature/login", 0},
+		{"fix/typo", 0},
+		{"login", 1},
+		{"feature/Login", 1},
+		{"feature/a-very-long-branch-name", 1},
+		{"Spike-Everything-At-Once", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := rules.Check(tt.branch); len(got) != tt.want {
+				t.Errorf("Check(%q) = %v, want %d problems", tt.branch, got, tt.want)
+			}
+		})
+	}
+	if !(branches.NamingRules{}).IsZero() || rules.IsZero() {
+		t.Error("IsZero should only be true without any rules")
+	}
+}
+
+func TestLintNames(t *testing.T) {
+	branches.SetProtectedPatterns([]string{"release/*"})
+	t.Cleanup(func() { branches.SetProtectedPatterns(nil) })
+
+	repo := helpers.NewTestRepo(t, "naming")
+	for _, name := range []string{"feature/ok", "Bad_Name", "release/1.0", "dependabot/go_modules/x"} {
+		repo.CreateBranch(name)
+		repo.Checkout("main")
+	}
+
+	violations := branches.LintNames([]string{repo.Path}, branches.NamingRules{Prefixes: []string{"feature/"}}, 1, nil)
+	var got []string
+	for _, v := range violations {
+		got = append(got, v.Branch)
+	}
+	// main is the default branch; release/1.0 is protected and the
+	// dependabot branch is named by the bot.
+	if !slices.Equal(got, []string{"Bad_Name"}) {
+		t.Errorf("expected only Bad_Name to be reported, got %v", got)
+	}
+}
@@ -0,0 +1,57 @@
+package branches
+
+import (
+	"path"
+	"sync"
+)
+
+// patterns holds the branch globs set by SetProtectedPatterns and
+// SetAutomationPatterns.
+var patterns struct {
+	mu         sync.RWMutex
+	protected  []string
+	automation []string
+}
+
+// SetProtectedPatterns sets the branch name globs (e.g. "release/*") that
+// FindMerged and FindStale never report. None are protected until set.
+func SetProtectedPatterns(globs []string) {
+	patterns.mu.Lock()
+	patterns.protected = globs
+	patterns.mu.Unlock()
+}
+
+// SetAutomationPatterns adds branch name globs that IsAutomationBranch
+// matches in addition to the built-in dependabot, renovate, and
+// release-please prefixes.
+func SetAutomationPatterns(globs []string) {
+	patterns.mu.Lock()
+	patterns.automation = globs
+	patterns.mu.Unlock()
+}
+
+// IsProtectedBranch reports whether branch matches a protected pattern.
+func IsProtectedBranch(branch string) bool {
+	patterns.mu.RLock()
+	defer patterns.mu.RUnlock()
+	return matchAny(patterns.protected, branch)
+}
+
+// matchAny reports whether branch matches any glob. A "*" does not cross
+// "/", so "release/*" matches release/1.0 but not release/1.0/hotfix.
+func matchAny(globs []string, branch string) bool {
+	for _, glob := range globs {
+		if ok, _ := path.Match(glob, branch); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isExtraAutomation reports whether branch matches a pattern set by
+// SetAutomationPatterns.
+func isExtraAutomation(branch string) bool {
+	patterns.mu.RLock()
+	defer patterns.mu.RUnlock()
+	return matchAny(patterns.automation, branch)
+}
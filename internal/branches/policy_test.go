@@ -0,0 +1,61 @@
+package branches_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/merge"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestProtectedBranchesAreNotReported(t *testing.T) {
+	branches.SetProtectedPatterns([]string{"release/*"})
+	t.Cleanup(func() { branches.SetProtectedPatterns(nil) })
+
+	repo := helpers.NewTestRepo(t, "protected")
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for i, name := range []string{"release/1.0", "feature/old"} {
+		file := fmt.Sprintf("work%d.txt", i)
+		repo.CreateBranch(name)
+		repo.WriteFile(file, name)
+		repo.AddFile(file)
+		repo.CommitWithDate(name, old)
+		repo.Checkout("main")
+	}
+	// A merged release branch is protected too.
+	repo.CreateBranch("release/0.9")
+	repo.Checkout("main")
+
+	stale, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stale) != 1 || stale[0].Branch != "feature/old" {
+		t.Errorf("expected only feature/old to be stale, got %+v", stale)
+	}
+
+	merged, err := branches.FindMerged([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merged) != 0 {
+		t.Errorf("expected the merged release branch to be protected, got %+v", merged)
+	}
+}
+
+func TestSetAutomationPatterns(t *testing.T) {
+	branches.SetAutomationPatterns([]string{"snyk-*"})
+	t.Cleanup(func() { branches.SetAutomationPatterns(nil) })
+
+	if !branches.IsAutomationBranch("snyk-fix-1234") {
+		t.Error("expected snyk-fix-1234 to match the added pattern")
+	}
+	if !branches.IsAutomationBranch("renovate/all-minor") {
+		t.Error("expected the built-in prefixes to still apply")
+	}
+	if branches.IsAutomationBranch("feature/snyk-fix") {
+		t.Error("expected feature/snyk-fix not to match")
+	}
+}
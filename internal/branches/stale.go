@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	ghclient "github.com/agrahamlincoln/katazuke/internal/github"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
@@ -20,23 +22,68 @@ type StaleBranch struct {
 	Branch            string
 	LastCommit        time.Time
 	LastCommitMessage string
-	CommitsAhead      int
-	CommitsBehind     int
-	HasRemote         bool
+	// LastCheckout is when the branch was last checked out according to
+	// the HEAD reflog; zero when it is not in the reflog.
+	LastCheckout  time.Time
+	CommitsAhead  int
+	CommitsBehind int
+	HasRemote     bool
+	// Remote is the remote that has a branch of the same name, where
+	// remote deletion happens; "" when HasRemote is false.
+	Remote string
 	// IsLocalOnly is true when the branch has no remote tracking branch.
 	// These are candidates for cleanup but require extra caution since
 	// commits may not exist anywhere else.
 	IsLocalOnly bool
+	// UpstreamAhead and UpstreamBehind count the commits the branch has
+	// that its upstream lacks, and the other way round.
+	UpstreamAhead  int
+	UpstreamBehind int
+	// Diverged is true when both counts are non-zero: the remote branch
+	// was force-pushed or the local one rewritten, so neither copy holds
+	// all the work.
+	Diverged bool
+	// IsUpstreamGone is true when the branch's configured upstream has
+	// been deleted from the remote, usually after its PR merged. Such
+	// branches are stale whatever their age.
+	IsUpstreamGone bool
+	// TipReachable is true when the branch tip is in the default branch or
+	// a remote-tracking branch, so deleting the branch loses no commits.
+	TipReachable bool
 	// IsAutomation is true for branches matching known automation patterns
 	// (e.g. dependabot/*, renovate/*, release-please--*).
 	IsAutomation bool
 	// IsOwnBranch is true when the user is the sole author of all commits
 	// on this branch since it diverged from the default branch.
 	IsOwnBranch bool
+	// Authors lists the distinct author emails of commits on this branch
+	// since it diverged from the default branch. Nil if unknown.
+	Authors []string
 	// PRNumber is the GitHub PR number if the branch has a merged PR.
 	PRNumber int
 	// PRMergedAt is the timestamp when the PR was merged.
 	PRMergedAt time.Time
+	// OpenPRNumber is the open draft PR for the branch, kept as a
+	// candidate with --include-draft-prs; 0 otherwise.
+	OpenPRNumber int
+	// OpenPRState describes that PR, e.g. "draft" or "draft, changes
+	// requested".
+	OpenPRState string
+	// ClosedPRNumber is the GitHub PR number if the branch's PR was
+	// closed without merging.
+	ClosedPRNumber int
+	// CIStatus is the CI status of the head of the branch's PR;
+	// ghclient.CINone when unknown or there is no PR.
+	CIStatus ghclient.CIStatus
+	// IssueKey is the issue tracker key in the branch name, e.g.
+	// "ABC-123", or "#42" for an issue in the branch's GitHub repository;
+	// "" when the name has none.
+	IssueKey string
+	// IssueStatus is the issue's status in its tracker, e.g. "Done";
+	// "" when it was not looked up.
+	IssueStatus string
+	// IssueDone is true when the issue is resolved.
+	IssueDone bool
 }
 
 // Label returns a display string for the stale branch in the form "repo: branch".
@@ -54,22 +101,25 @@ var automationPrefixes = []string{
 }
 
 // IsAutomationBranch returns true if the branch name matches a known
-// automation pattern.
+// automation pattern or one set by SetAutomationPatterns.
 func IsAutomationBranch(branch string) bool {
 	for _, prefix := range automationPrefixes {
 		if strings.HasPrefix(branch, prefix) {
 			return true
 		}
 	}
-	return false
+	return isExtraAutomation(branch)
 }
 
 // FindStale scans the given repositories and returns branches whose last commit
-// is older than the given threshold. Merged branches, the default branch, and
+// is older than the given threshold, and branches whose upstream is gone
+// regardless of age. Merged branches, the default branch, and
 // the currently checked out branch are excluded. Work is parallelized across
 // the given number of workers. The detector combines local git checks with
-// GitHub API lookups to determine which branches are merged.
-func FindStale(repos []string, threshold time.Duration, detector *merge.Detector, workers int, onProgress func(completed, total int)) ([]StaleBranch, error) {
+// GitHub API lookups to determine which branches are merged. Commits by any
+// email in identity, or by the repo's user.email, count as the user's own.
+// Each decision is recorded to ex when it is non-nil.
+func FindStale(repos []string, threshold time.Duration, detector *merge.Detector, identity Identity, workers int, ex *explain.Log, onProgress func(completed, total int)) ([]StaleBranch, error) {
 	cutoff := time.Now().Add(-threshold)
 
 	var resultCb func(int, int, []StaleBranch)
@@ -80,7 +130,7 @@ func FindStale(repos []string, threshold time.Duration, detector *merge.Detector
 	}
 
 	repoResults := parallel.Run(repos, workers, func(repoPath string) []StaleBranch {
-		return findStaleInRepo(repoPath, cutoff, detector)
+		return findStaleInRepo(repoPath, cutoff, detector, identity, ex)
 	}, resultCb)
 
 	results := make([]StaleBranch, 0, len(repoResults))
@@ -90,13 +140,25 @@ func FindStale(repos []string, threshold time.Duration, detector *merge.Detector
 	return results, nil
 }
 
-func findStaleInRepo(repoPath string, cutoff time.Time, detector *merge.Detector) []StaleBranch {
+func findStaleInRepo(repoPath string, cutoff time.Time, detector *merge.Detector, identity Identity, ex *explain.Log) []StaleBranch {
 	repoName := filepath.Base(repoPath)
 
+	if op := git.ConflictState(repoPath); op != "" {
+		slog.Warn("skipping repo: operation in progress", "repo", repoName, "operation", op)
+		ex.Addf(repoName, "", explain.Skipped, "%s in progress", op)
+		return nil
+	}
+	if err := git.CheckOwnership(repoPath); err != nil {
+		slog.Warn("skipping repo: owned by another user", "repo", repoName)
+		ex.Addf(repoName, "", explain.Skipped, "owned by another user and not listed in safe.directory")
+		return nil
+	}
+
 	defaultBranch, err := git.DefaultBranch(repoPath)
 	if err != nil {
 		slog.Warn("skipping repo: could not determine default branch",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine default branch")
 		return nil
 	}
 
@@ -104,6 +166,7 @@ func findStaleInRepo(repoPath string, cutoff time.Time, detector *merge.Detector
 	if err != nil {
 		slog.Warn("skipping repo: could not determine current branch",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not determine current branch")
 		return nil
 	}
 
@@ -111,119 +174,161 @@ func findStaleInRepo(repoPath string, cutoff time.Time, detector *merge.Detector
 		slog.Debug("repo has detached HEAD, no branch to exclude", "repo", repoName)
 	}
 
-	allBranches, err := git.ListBranches(repoPath)
+	infos, err := git.BranchInfos(repoPath)
 	if err != nil {
 		slog.Warn("skipping repo: could not list branches",
 			"repo", repoName, "error", err)
 		return nil
 	}
+	allBranches := branchNames(infos)
 
 	// Filter out default and current branches before passing to the detector
 	// to avoid unnecessary API calls for branches we'd discard anyway.
-	candidates := make([]string, 0, len(allBranches))
-	for _, b := range allBranches {
-		if b != defaultBranch && b != currentBranch {
-			candidates = append(candidates, b)
-		}
-	}
+	candidates := excludeDefaultAndCurrent(allBranches, defaultBranch, currentBranch, repoName, ex)
 
 	detected, err := detector.MergedBranches(repoPath, defaultBranch, candidates)
 	if err != nil {
 		slog.Warn("skipping repo: could not list merged branches",
 			"repo", repoName, "error", err)
+		ex.Add(repoName, "", explain.Skipped, "could not list merged branches")
 		return nil
 	}
 	mergedSet := make(map[string]bool, len(detected))
 	for _, d := range detected {
 		mergedSet[d.Name] = true
+		if d.Name != defaultBranch && d.Name != currentBranch {
+			ex.Add(repoName, d.Name, explain.Excluded,
+				mergedReason(d, defaultBranch)+"; handled by --merged")
+		}
 	}
 
-	// Get the user's identity for authorship checking.
-	userEmail, _ := git.ConfigValue(repoPath, "user.email")
-
-	var results []StaleBranch
-	for _, branch := range allBranches {
-		if branch == defaultBranch || branch == currentBranch {
+	// Everything below the cutoff check comes from the batched branch
+	// metadata; only ahead/behind counts and authorship need more git calls,
+	// and only for the branches that turn out to be stale.
+	var stale []git.BranchInfo
+	for _, info := range infos {
+		if info.Name == defaultBranch || info.Name == currentBranch || IsProtectedBranch(info.Name) {
 			continue
 		}
-		if mergedSet[branch] {
+		if mergedSet[info.Name] {
 			continue
 		}
-
-		commitDate, err := git.CommitDate(repoPath, branch)
-		if err != nil {
-			slog.Warn("could not get commit date, skipping branch",
-				"repo", repoName, "branch", branch, "error", err)
+		if info.CommitDate.After(cutoff) && !info.UpstreamGone {
+			ex.Addf(repoName, info.Name, explain.Excluded, "last commit %s is newer than the stale cutoff %s",
+				info.CommitDate.Format("2006-01-02"), cutoff.Format("2006-01-02"))
 			continue
 		}
+		stale = append(stale, info)
+	}
+	if len(stale) == 0 {
+		return nil
+	}
 
-		if commitDate.After(cutoff) {
-			continue
-		}
+	counts, err := git.AheadBehind(repoPath, defaultBranch, branchNames(stale))
+	if err != nil {
+		slog.Warn("could not get ahead/behind counts",
+			"repo", repoName, "error", err)
+	}
 
-		ahead, behind, err := git.CommitsAheadBehind(repoPath, branch, defaultBranch)
-		if err != nil {
-			slog.Warn("could not get ahead/behind counts",
-				"repo", repoName, "branch", branch, "error", err)
-		}
+	checkouts, err := git.LastCheckouts(repoPath)
+	if err != nil {
+		slog.Debug("could not read checkout times from the reflog",
+			"repo", repoName, "error", err)
+	}
 
-		hasRemote := false
-		if git.HasRemote(repoPath, "origin") {
-			hasRemote, err = git.HasRemoteBranch(repoPath, "origin", branch)
-			if err != nil {
-				slog.Debug("could not check remote branch",
-					"repo", repoName, "branch", branch, "error", err)
-			}
-		}
+	// Get the user's identity for authorship checking.
+	userEmail, _ := git.ConfigValue(repoPath, "user.email")
+	identity = identity.With(userEmail)
 
-		subject, err := git.CommitSubject(repoPath, branch)
+	results := make([]StaleBranch, 0, len(stale))
+	for _, info := range stale {
+		hasRemote := info.Remote != ""
+		isOwn, authors := checkAuthorship(repoPath, info.Name, defaultBranch, identity, repoName)
+		reachable, err := git.IsReachable(repoPath, "refs/heads/"+info.Name, defaultBranch)
 		if err != nil {
-			slog.Warn("could not get commit subject",
-				"repo", repoName, "branch", branch, "error", err)
+			slog.Debug("could not check whether the branch tip is reachable",
+				"repo", repoName, "branch", info.Name, "error", err)
 		}
 
-		isOwn := checkAuthorship(repoPath, branch, defaultBranch, userEmail, repoName)
-		isLocalOnly := !hasRemote && !git.HasUpstream(repoPath, branch)
+		if info.UpstreamGone {
+			ex.Add(repoName, info.Name, explain.Reported, "stale: its upstream branch was deleted from the remote")
+		} else {
+			ex.Addf(repoName, info.Name, explain.Reported, "stale: last commit %s is older than the cutoff %s",
+				info.CommitDate.Format("2006-01-02"), cutoff.Format("2006-01-02"))
+		}
 
 		results = append(results, StaleBranch{
 			RepoPath:          repoPath,
 			RepoName:          repoName,
-			Branch:            branch,
-			LastCommit:        commitDate,
-			LastCommitMessage: subject,
-			CommitsAhead:      ahead,
-			CommitsBehind:     behind,
+			Branch:            info.Name,
+			LastCommit:        info.CommitDate,
+			LastCommitMessage: info.Subject,
+			LastCheckout:      checkouts[info.Name],
+			CommitsAhead:      counts[info.Name][0],
+			CommitsBehind:     counts[info.Name][1],
 			HasRemote:         hasRemote,
-			IsLocalOnly:       isLocalOnly,
-			IsAutomation:      IsAutomationBranch(branch),
+			Remote:            info.Remote,
+			IsLocalOnly:       !hasRemote && info.Upstream == "" && !info.UpstreamGone,
+			UpstreamAhead:     info.UpstreamAhead,
+			UpstreamBehind:    info.UpstreamBehind,
+			Diverged:          info.UpstreamAhead > 0 && info.UpstreamBehind > 0,
+			IsUpstreamGone:    info.UpstreamGone,
+			TipReachable:      reachable,
+			IsAutomation:      IsAutomationBranch(info.Name),
 			IsOwnBranch:       isOwn,
+			Authors:           authors,
 		})
 	}
 
 	return results
 }
 
-// checkAuthorship returns true if all commits on branch (since diverging from
-// base) were authored by the given email. Returns true if the email is empty
-// (can't determine identity) or if the branch has no unique commits (diverged
-// at the same point).
-func checkAuthorship(repoPath, branch, base, userEmail, repoName string) bool {
-	if userEmail == "" {
-		return true
+// WithoutRecentCheckouts drops the stale branches checked out after
+// cutoff, for users who still switch to a branch now and then without
+// committing to it. Branches whose upstream is gone are kept, as they are
+// stale whatever their age. Exclusions are recorded to ex when it is
+// non-nil.
+func WithoutRecentCheckouts(stale []StaleBranch, cutoff time.Time, ex *explain.Log) []StaleBranch {
+	kept := make([]StaleBranch, 0, len(stale))
+	for _, s := range stale {
+		if s.LastCheckout.After(cutoff) && !s.IsUpstreamGone {
+			ex.Addf(s.RepoName, s.Branch, explain.Excluded, "checked out %s, after the stale cutoff %s",
+				s.LastCheckout.Format("2006-01-02"), cutoff.Format("2006-01-02"))
+			continue
+		}
+		kept = append(kept, s)
 	}
+	return kept
+}
+
+// branchNames returns the names of infos, in order.
+func branchNames(infos []git.BranchInfo) []string {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// checkAuthorship returns true if all commits on branch (since diverging from
+// base) were authored by one of the identity's emails, along with the
+// distinct authors found. Returns true if the identity is empty (can't
+// determine ownership) or if the branch has no unique commits (diverged at
+// the same point).
+func checkAuthorship(repoPath, branch, base string, identity Identity, repoName string) (bool, []string) {
 	authors, err := git.CommitAuthors(repoPath, branch, base)
 	if err != nil {
 		slog.Debug("could not check commit authors",
 			"repo", repoName, "branch", branch, "error", err)
-		return true
+		return true, nil
 	}
-	if len(authors) == 0 {
-		return true
+	if identity.Empty() || len(authors) == 0 {
+		return true, authors
 	}
 	for _, a := range authors {
-		if !strings.EqualFold(a, userEmail) {
-			return false
+		if !identity.Matches(a) {
+			return false, authors
 		}
 	}
-	return true
+	return true, authors
 }
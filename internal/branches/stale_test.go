@@ -1,10 +1,12 @@
 package branches_test
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/agrahamlincoln/katazuke/internal/branches"
+	"github.com/agrahamlincoln/katazuke/internal/explain"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/test/helpers"
 )
@@ -19,7 +21,7 @@ func TestFindStale_NoStaleBranches(t *testing.T) {
 	repo.Commit("active commit")
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -39,7 +41,7 @@ func TestFindStale_OneStaleBranch(t *testing.T) {
 	repo.CommitWithDate("old commit", staleDate)
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -69,7 +71,7 @@ func TestFindStale_ExcludesMergedBranches(t *testing.T) {
 	repo.Checkout("main")
 	repo.Merge("feature/merged-old")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -89,7 +91,7 @@ func TestFindStale_ExcludesDefaultAndCurrentBranch(t *testing.T) {
 	repo.CommitWithDate("stale commit", staleDate)
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -118,7 +120,7 @@ func TestFindStale_RespectsThreshold(t *testing.T) {
 	repo.Checkout("main")
 
 	// With a 30-day threshold, this should not be stale.
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -127,7 +129,7 @@ func TestFindStale_RespectsThreshold(t *testing.T) {
 	}
 
 	// With a 7-day threshold, this should be stale.
-	results, err = branches.FindStale([]string{repo.Path}, 7*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err = branches.FindStale([]string{repo.Path}, 7*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -162,7 +164,7 @@ func TestFindStale_MultipleRepos(t *testing.T) {
 	repo2.CommitWithDate("old c", staleDate)
 	repo2.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo1.Path, repo2.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo1.Path, repo2.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -194,7 +196,7 @@ func TestFindStale_DetachedHEAD(t *testing.T) {
 	// Detach HEAD.
 	repo.DetachHead()
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -228,7 +230,7 @@ func TestFindStale_CommitsAheadBehind(t *testing.T) {
 	repo.AddFile("main-update.txt")
 	repo.Commit("main update")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -244,7 +246,7 @@ func TestFindStale_CommitsAheadBehind(t *testing.T) {
 }
 
 func TestFindStale_EmptyRepoList(t *testing.T) {
-	results, err := branches.FindStale(nil, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale(nil, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -309,7 +311,7 @@ func TestFindStale_IsAutomationField(t *testing.T) {
 	repo.CommitWithDate("normal commit", staleDate)
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -345,7 +347,7 @@ func TestFindStale_IsOwnBranch(t *testing.T) {
 	repo.CommitWithDate("own commit", staleDate)
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -369,7 +371,7 @@ func TestFindStale_IsLocalOnly(t *testing.T) {
 	repo.CommitWithDate("local commit", staleDate)
 	repo.Checkout("main")
 
-	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), 1, nil)
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -380,3 +382,210 @@ func TestFindStale_IsLocalOnly(t *testing.T) {
 		t.Error("expected branch to be marked as local-only")
 	}
 }
+
+func TestFindStale_Diverged(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "diverged")
+
+	staleDate := time.Now().Add(-60 * 24 * time.Hour)
+
+	// "rewritten" is amended after pushing, so it and origin/rewritten each
+	// have a commit the other lacks. "unpushed" only has local commits on
+	// top of what was pushed.
+	for _, name := range []string{"rewritten", "unpushed"} {
+		repo.CreateBranch(name)
+		repo.WriteFile(name+".txt", name)
+		repo.AddFile(name + ".txt")
+		repo.CommitWithDate(name+" commit", staleDate)
+		repo.Git("push", "-u", "origin", name)
+	}
+	repo.Checkout("rewritten")
+	repo.Git("commit", "--amend", "-m", "rewritten commit, reworded")
+	repo.Checkout("unpushed")
+	repo.WriteFile("more.txt", "more")
+	repo.AddFile("more.txt")
+	repo.CommitWithDate("unpushed commit", staleDate)
+	repo.Checkout("main")
+
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := make(map[string]branches.StaleBranch)
+	for _, s := range results {
+		byName[s.Branch] = s
+	}
+	if len(byName) != 2 {
+		t.Fatalf("expected 2 stale branches, got %+v", results)
+	}
+	if s := byName["rewritten"]; !s.Diverged || s.UpstreamAhead != 1 || s.UpstreamBehind != 1 {
+		t.Errorf("expected rewritten to have diverged from its upstream, got %+v", s)
+	}
+	if s := byName["unpushed"]; s.Diverged || s.UpstreamAhead != 1 || s.UpstreamBehind != 0 {
+		t.Errorf("expected unpushed to be ahead of its upstream only, got %+v", s)
+	}
+}
+
+func TestFindStale_UpstreamGone(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "upstream-gone")
+
+	// Both branches are recent; only "done" has had its remote branch
+	// deleted, as when a merged PR auto-deletes its head branch.
+	for _, name := range []string{"done", "active"} {
+		repo.CreateBranch(name)
+		repo.WriteFile(name+".txt", name)
+		repo.AddFile(name + ".txt")
+		repo.Commit(name + " commit")
+		repo.Git("push", "-u", "origin", name)
+		repo.Checkout("main")
+	}
+	repo.Git("push", "origin", "--delete", "done")
+
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || results[0].Branch != "done" {
+		t.Fatalf("expected only done to be stale, got %+v", results)
+	}
+	if !results[0].IsUpstreamGone {
+		t.Error("expected done to be marked as upstream gone")
+	}
+	if results[0].IsLocalOnly {
+		t.Error("a branch whose upstream is gone was pushed once and is not local-only")
+	}
+	if results[0].TipReachable {
+		t.Error("expected done's commit, in no other branch, to be unreachable")
+	}
+
+	// Checking it out recently does not keep it either.
+	kept := branches.WithoutRecentCheckouts([]branches.StaleBranch{{Branch: "done", IsUpstreamGone: true, LastCheckout: time.Now()}},
+		time.Now().Add(-time.Hour), nil)
+	if len(kept) != 1 {
+		t.Error("expected a branch whose upstream is gone to survive --respect-checkouts")
+	}
+}
+
+func TestFindStale_Explain(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "explain-stale")
+	old := time.Now().Add(-60 * 24 * time.Hour)
+
+	repo.CreateBranch("feature/old")
+	repo.WriteFile("old.txt", "old work")
+	repo.AddFile("old.txt")
+	repo.CommitWithDate("old commit", old)
+	repo.Checkout("main")
+
+	repo.CreateBranch("feature/recent")
+	repo.WriteFile("recent.txt", "recent work")
+	repo.AddFile("recent.txt")
+	repo.Commit("recent commit")
+	repo.Checkout("main")
+
+	repo.CreateBranch("feature/done")
+	repo.WriteFile("done.txt", "done")
+	repo.AddFile("done.txt")
+	repo.CommitWithDate("done commit", old)
+	repo.Checkout("main")
+	repo.Merge("feature/done")
+
+	ex := explain.New()
+	if _, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, ex, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	verdicts := make(map[string]explain.Entry)
+	for _, e := range ex.Entries() {
+		verdicts[e.Subject] = e
+	}
+	want := map[string]struct{ verdict, reason string }{
+		"main":           {explain.Excluded, "default branch"},
+		"feature/old":    {explain.Reported, "stale"},
+		"feature/recent": {explain.Excluded, "newer than the stale cutoff"},
+		"feature/done":   {explain.Excluded, "git reports it merged into main"},
+	}
+	for branch, w := range want {
+		e, ok := verdicts[branch]
+		if !ok {
+			t.Errorf("%s: no explanation recorded", branch)
+			continue
+		}
+		if e.Verdict != w.verdict || !strings.Contains(e.Reason, w.reason) {
+			t.Errorf("%s: got %s %q, want %s containing %q", branch, e.Verdict, e.Reason, w.verdict, w.reason)
+		}
+	}
+}
+
+func TestFindStale_OtherAuthors(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "other-authors")
+	staleDate := time.Now().Add(-60 * 24 * time.Hour)
+
+	repo.CreateBranch("feature/shared")
+	repo.WriteFile("mine.txt", "mine")
+	repo.AddFile("mine.txt")
+	repo.CommitWithDate("my commit", staleDate)
+	repo.WriteFile("theirs.txt", "theirs")
+	repo.AddFile("theirs.txt")
+	repo.Git("commit", "-m", "their commit",
+		"--author", "Other Dev <other@example.com>", "--date", staleDate.Format(time.RFC3339))
+	repo.Checkout("main")
+
+	results, err := branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected 1 stale branch, got %d", len(results))
+	}
+	if results[0].IsOwnBranch {
+		t.Error("expected branch with another author to not be marked as own")
+	}
+	if len(results[0].Authors) != 2 {
+		t.Errorf("expected 2 distinct authors, got %v", results[0].Authors)
+	}
+
+	// Listing the second email as one of the user's identities makes the
+	// branch theirs.
+	identity := branches.NewIdentity("OTHER@example.com")
+	results, err = branches.FindStale([]string{repo.Path}, 30*24*time.Hour, merge.GitOnlyDetector(), identity, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 1 || !results[0].IsOwnBranch {
+		t.Errorf("expected branch to be own with identity emails, got %+v", results)
+	}
+}
+
+func TestFindStale_RecentCheckouts(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "recent-checkouts")
+
+	// Both branches have old commits, but only one was ever checked out.
+	repo.CreateBranch("feature/visited")
+	repo.WriteFile("old.txt", "old work")
+	repo.AddFile("old.txt")
+	repo.CommitWithDate("old commit", time.Now().Add(-60*24*time.Hour))
+	repo.Checkout("main")
+	repo.Git("branch", "feature/untouched", "feature/visited")
+
+	threshold := 30 * 24 * time.Hour
+	results, err := branches.FindStale([]string{repo.Path}, threshold, merge.GitOnlyDetector(), branches.Identity{}, 1, nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 stale branches, got %d", len(results))
+	}
+	for _, r := range results {
+		if visited := r.Branch == "feature/visited"; visited == r.LastCheckout.IsZero() {
+			t.Errorf("unexpected checkout time for %s: %v", r.Branch, r.LastCheckout)
+		}
+	}
+
+	ex := explain.New()
+	kept := branches.WithoutRecentCheckouts(results, time.Now().Add(-threshold), ex)
+	if len(kept) != 1 || kept[0].Branch != "feature/untouched" {
+		t.Errorf("expected only feature/untouched to be kept, got %v", kept)
+	}
+	if len(ex.Entries()) != 1 {
+		t.Errorf("expected the exclusion to be explained, got %v", ex.Entries())
+	}
+}
@@ -6,11 +6,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
-	"strconv"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+
+	"github.com/agrahamlincoln/katazuke/internal/ui"
 )
 
 // SyncConfig holds configuration for the sync command.
@@ -19,18 +24,198 @@ type SyncConfig struct {
 	SkipDirty          bool   `yaml:"skip_dirty"`           // skip dirty repos without merge-tree check
 	AutoStash          bool   `yaml:"auto_stash"`           // attempt stash/pop for dirty repos
 	SwitchMergedBranch bool   `yaml:"switch_merged_branch"` // auto-switch repos on merged branches to default
+	DirtyAction        string `yaml:"dirty_action"`         // "stash" or "wip-commit"
+	// Retries is how many times a fetch or pull that failed to reach the
+	// remote is retried, waiting RetryDelaySeconds before the first retry
+	// and twice as long before each one after it. 0 disables retries.
+	Retries           int `yaml:"retries"`
+	RetryDelaySeconds int `yaml:"retry_delay_seconds"`
 	// Deprecated: Use the top-level Workers field in Config instead.
 	Workers int `yaml:"workers"`
 }
 
+// GitHubConfig holds configuration for GitHub API lookups.
+type GitHubConfig struct {
+	// APIOrgsAllow limits API lookups to repos owned by these orgs/users.
+	// Empty means all owners are allowed.
+	APIOrgsAllow []string `yaml:"api_orgs_allow"`
+	// APIOrgsDeny skips API lookups for these owners. Takes precedence
+	// over APIOrgsAllow.
+	APIOrgsDeny []string `yaml:"api_orgs_deny"`
+	// TokenCommand is run through sh when no plaintext token is set; its
+	// output is the token, e.g. "op read op://Private/GitHub/token".
+	TokenCommand string `yaml:"token_command"`
+	// TokenKeychain names the macOS Keychain or libsecret service that
+	// holds the token, used when neither a plaintext token nor
+	// TokenCommand is set.
+	TokenKeychain string `yaml:"token_keychain"`
+}
+
+// IdentityConfig lists the author emails that belong to the user.
+type IdentityConfig struct {
+	// Emails are treated as the user's own in addition to each repo's
+	// user.email, e.g. work and personal addresses or GitHub noreply
+	// aliases.
+	Emails []string `yaml:"emails"`
+}
+
+// BranchNamingConfig holds the conventions `branches --lint` checks
+// local branch names against. Zero values disable a check.
+type BranchNamingConfig struct {
+	// Prefixes are the allowed branch name prefixes, e.g. "feature/" or
+	// "fix/". Empty allows any name.
+	Prefixes []string `yaml:"prefixes"`
+	// MaxLength is the longest allowed branch name, 0 = unlimited.
+	MaxLength int `yaml:"max_length"`
+	// Lowercase rejects branch names containing uppercase letters.
+	Lowercase bool `yaml:"lowercase"`
+}
+
+// IssuesConfig controls how issue keys in branch names (e.g. ABC-123 in
+// "ABC-123-fix-login") are recognized and looked up.
+type IssuesConfig struct {
+	// Projects are issue tracker project keys also recognized in
+	// lowercase branch names, e.g. "abc" matches "abc-123-fix". Uppercase
+	// keys are recognized for any project.
+	Projects []string `yaml:"projects"`
+	// JiraURL is the base URL of the Jira site to look up issue status,
+	// e.g. https://acme.atlassian.net. Empty skips Jira lookups.
+	JiraURL string `yaml:"jira_url"`
+	// JiraEmail is the account the Jira API token belongs to. Set for
+	// Jira Cloud; leave empty to send JiraToken as a personal access
+	// token (Jira Data Center).
+	JiraEmail string `yaml:"jira_email"`
+	JiraToken string `yaml:"jira_token"`
+}
+
+// SafetyConfig controls backups taken before destructive operations and
+// how katazuke runs git.
+type SafetyConfig struct {
+	// BundleBeforeDelete writes a git bundle of each branch or tag, or of
+	// the whole repository for removals, before it is deleted.
+	BundleBeforeDelete bool `yaml:"bundle_before_delete"`
+	// BackupDir is where bundles are written. Empty means
+	// ~/.local/share/katazuke/backups.
+	BackupDir string `yaml:"backup_dir"`
+	// BackupRetentionDays is how long bundles are kept, 0 = forever.
+	BackupRetentionDays int `yaml:"backup_retention_days"`
+	// IsolateHooks runs katazuke's git commands without the repository's
+	// hooks and without commit or tag signing (see git.SetIsolation).
+	IsolateHooks bool `yaml:"isolate_hooks"`
+	// MaxDeletionsPerRun is how many branches, or repositories, one run
+	// may delete before --force or a typed confirmation is required.
+	// 0 disables the check.
+	MaxDeletionsPerRun int `yaml:"max_deletions_per_run"`
+	// TypedConfirmThreshold is how many remote branches, or permanently
+	// removed repositories, it takes before the yes/no confirmation is
+	// replaced by typing the name or "delete N ...". 0 disables it.
+	TypedConfirmThreshold int `yaml:"typed_confirm_threshold"`
+	// MineOnly limits branch deletions and repository removals to the
+	// user's own work, for shared machines where several people's
+	// checkouts live in one projects directory. See --mine-only.
+	MineOnly bool `yaml:"mine_only"`
+}
+
+// AuditConfig holds configuration for the audit command.
+type AuditConfig struct {
+	// AutoQuarantineAfterDays makes audit --non-git default to moving
+	// directories untouched for longer than this many days to the
+	// quarantine, so --yes quarantines them without asking. 0 disables.
+	AutoQuarantineAfterDays int `yaml:"auto_quarantine_after_days"`
+	// NoteTTLDays is how long a KATAZUKE-NOTE.md written by audit
+	// --non-git keeps its directory out of audits, 0 = until the
+	// directory changes.
+	NoteTTLDays int `yaml:"note_ttl_days"`
+	// Rules are conformance checks the audit dashboard reports
+	// violations of.
+	Rules []AuditRule `yaml:"rules"`
+}
+
+// AuditRule is a conformance check on where repositories live and what
+// they contain. Empty fields are not checked.
+type AuditRule struct {
+	// Name labels the rule's violations; defaults to a description of
+	// its checks.
+	Name string `yaml:"name"`
+	// Orgs limits the rule to repositories whose GitHub remote belongs
+	// to one of these owners. Empty applies it to every repository.
+	Orgs []string `yaml:"orgs"`
+	// Group is the group path, relative to projects_dir, the repository
+	// must live in. "{org}" stands for its GitHub owner, so "github/{org}"
+	// files each org's repos under their own group.
+	Group string `yaml:"group"`
+	// NameMatchesRemote requires the directory to be named after the
+	// repository on its remote.
+	NameMatchesRemote bool `yaml:"name_matches_remote"`
+	// RequiredFiles are file names or globs (e.g. "LICENSE*") that must
+	// exist at the repository root, in .github/, or in docs/.
+	RequiredFiles []string `yaml:"required_files"`
+}
+
+// MetricsConfig bounds how much local metrics history is retained and
+// where, if anywhere, it is shared.
+type MetricsConfig struct {
+	RetentionMonths int `yaml:"retention_months"` // monthly files to keep, 0 = unlimited
+	MaxTotalMB      int `yaml:"max_total_mb"`     // cap on metrics directory size, 0 = unlimited
+
+	// RemoteURL is an endpoint that anonymized events are POSTed to in
+	// batches, once the user has agreed to it. Empty keeps metrics local.
+	RemoteURL string `yaml:"remote_url"`
+	// RemoteToken is sent as a bearer token with each batch.
+	RemoteToken string `yaml:"remote_token"`
+}
+
+// UIConfig holds output settings.
+type UIConfig struct {
+	// Color is "auto" (color a terminal unless NO_COLOR is set),
+	// "always", or "never".
+	Color string `yaml:"color"`
+	// Theme sets the colors of success, warning, error, and muted output,
+	// in prompts as well; colors left unset keep their default.
+	Theme ui.Theme `yaml:"theme"`
+}
+
 // Config holds all katazuke configuration.
 type Config struct {
-	ProjectsDir        string     `yaml:"projects_dir"`
-	StaleThresholdDays int        `yaml:"stale_threshold_days"`
-	GithubToken        string     `yaml:"github_token"`
-	ExcludePatterns    []string   `yaml:"exclude_patterns"`
-	Workers            int        `yaml:"workers"` // parallel worker count for all commands
-	Sync               SyncConfig `yaml:"sync"`
+	ProjectsDir        string         `yaml:"projects_dir"`
+	StaleThresholdDays int            `yaml:"stale_threshold_days"`
+	GithubToken        string         `yaml:"github_token"`
+	ExcludePatterns    []string       `yaml:"exclude_patterns"`
+	ExcludeRemotes     []string       `yaml:"exclude_remotes"`  // remote URL globs, or "re:" regexps, of repos to skip
+	IncludePatterns    []string       `yaml:"include_patterns"` // when set, only repos whose directory name or remote URL matches
+	PrimaryRemote      string         `yaml:"primary_remote"`
+	ScanDepth          int            `yaml:"scan_depth"`  // directory levels searched for repos below projects_dir
+	Workers            int            `yaml:"workers"`     // parallel worker count for all commands, 0 = sized per task
+	HostLimits         map[string]int `yaml:"host_limits"` // concurrency cap per remote host, api.github.com, or repo path prefix
+	LogFile            string         `yaml:"log_file"`    // debug log destination, empty disables file logging
+	Sync               SyncConfig     `yaml:"sync"`
+	GitHub             GitHubConfig   `yaml:"github"`
+	Identity           IdentityConfig `yaml:"identity"`
+	Safety             SafetyConfig   `yaml:"safety"`
+	Audit              AuditConfig    `yaml:"audit"`
+	Metrics            MetricsConfig  `yaml:"metrics"`
+	Issues             IssuesConfig   `yaml:"issues"`
+	UI                 UIConfig       `yaml:"ui"`
+
+	// BranchNaming is checked by `branches --lint`.
+	BranchNaming BranchNamingConfig `yaml:"branch_naming"`
+
+	// ProtectedBranches are branch name globs (e.g. "release/*") that are
+	// never offered for deletion.
+	ProtectedBranches []string `yaml:"protected_branches"`
+	// AutomationPatterns are branch name globs handled like dependabot and
+	// renovate branches: cleaned up locally, never deleted from remotes.
+	AutomationPatterns []string `yaml:"automation_patterns"`
+	// PolicyURL points to a shared Policy that is fetched, cached, and
+	// merged under this config.
+	PolicyURL string `yaml:"policy_url"`
+	// UpdateCheck looks for a newer release at most once a day and
+	// mentions it when a command starts.
+	UpdateCheck bool `yaml:"update_check"`
+
+	// Profile is the name of the profile applied on top of the file's
+	// top-level values, or "" when none was selected.
+	Profile string `yaml:"-"`
 }
 
 // Defaults returns a Config with default values.
@@ -40,23 +225,55 @@ func Defaults() Config {
 		ProjectsDir:        filepath.Join(home, "projects"),
 		StaleThresholdDays: 30,
 		ExcludePatterns:    []string{".archive", "vendor"},
-		Workers:            min(4, runtime.NumCPU()),
+		ScanDepth:          1,
+		Workers:            0, // sized per task by parallel.AutoWorkers
 		Sync: SyncConfig{
 			Strategy:           "rebase",
 			SkipDirty:          false,
 			AutoStash:          true,
 			SwitchMergedBranch: true,
+			DirtyAction:        "stash",
+			Retries:            2,
+			RetryDelaySeconds:  2,
+		},
+		Safety: SafetyConfig{
+			BackupRetentionDays:   30,
+			IsolateHooks:          true,
+			MaxDeletionsPerRun:    100,
+			TypedConfirmThreshold: 5,
+		},
+		Audit: AuditConfig{
+			NoteTTLDays: 180,
+		},
+		Metrics: MetricsConfig{
+			RetentionMonths: 12,
+			MaxTotalMB:      50,
 		},
+		UI: UIConfig{
+			Color: ui.ColorAuto,
+		},
+		UpdateCheck: true,
 	}
 }
 
 // Load reads configuration from the config file and environment variables.
-// Values are layered: defaults < config file < environment variables.
+// Values are layered: defaults < config file < selected profile <
+// environment variables. The profile is named by KATAZUKE_PROFILE.
 func Load() (Config, error) {
+	return load(nil)
+}
+
+// load is Load, recording the origin of settings in sources when it is
+// not nil.
+func load(sources Sources) (Config, error) {
 	cfg := Defaults()
 	defaultWorkers := cfg.Workers
 
-	if err := loadFile(&cfg); err != nil {
+	cfg.Profile = strings.TrimSpace(os.Getenv("KATAZUKE_PROFILE"))
+	if sources != nil && cfg.Profile != "" {
+		sources["profile"] = Origin{Source: SourceEnv, Via: "KATAZUKE_PROFILE"}
+	}
+	if err := loadFile(&cfg, sources); err != nil {
 		return cfg, err
 	}
 
@@ -67,17 +284,142 @@ func Load() (Config, error) {
 	// AND sets sync.workers to something different, sync.workers wins.
 	if cfg.Sync.Workers > 0 && cfg.Workers == defaultWorkers {
 		cfg.Workers = cfg.Sync.Workers
+		if sources != nil {
+			sources["workers"] = Origin{Source: sources.Of("sync.workers").Source, Via: "sync.workers"}
+		}
 	}
 
-	applyEnv(&cfg)
+	applyEnv(&cfg, sources)
+
+	if u := cfg.PolicyURL; u != "" {
+		if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+			return cfg, fmt.Errorf("invalid policy_url %q (must be an http or https URL)", u)
+		}
+		policy, err := loadPolicy(u)
+		if err != nil {
+			return cfg, err
+		}
+		before := cfg
+		cfg.applyPolicy(policy)
+		if sources != nil {
+			markChanged(sources, SourcePolicy, before, cfg, leafKeys(reflect.TypeFor[Policy](), ""))
+		}
+	}
 
 	if !isValidStrategy(cfg.Sync.Strategy) {
 		return cfg, fmt.Errorf("invalid sync strategy %q (valid: rebase, merge, ff-only)", cfg.Sync.Strategy)
 	}
+	if !isValidDirtyAction(cfg.Sync.DirtyAction) {
+		return cfg, fmt.Errorf("invalid sync dirty_action %q (valid: stash, wip-commit)", cfg.Sync.DirtyAction)
+	}
+	if cfg.Sync.Retries < 0 {
+		return cfg, fmt.Errorf("invalid sync retries %d (use 0 to disable)", cfg.Sync.Retries)
+	}
+	if cfg.Sync.RetryDelaySeconds < 0 {
+		return cfg, fmt.Errorf("invalid sync retry_delay_seconds %d", cfg.Sync.RetryDelaySeconds)
+	}
+	if !isValidColorMode(cfg.UI.Color) {
+		return cfg, fmt.Errorf("invalid ui color %q (valid: auto, always, never)", cfg.UI.Color)
+	}
+	for _, c := range []struct{ role, name string }{
+		{"success", cfg.UI.Theme.Success},
+		{"warn", cfg.UI.Theme.Warn},
+		{"error", cfg.UI.Theme.Error},
+		{"muted", cfg.UI.Theme.Muted},
+	} {
+		if c.name == "" {
+			continue
+		}
+		if err := ui.ValidateColor(c.name); err != nil {
+			return cfg, fmt.Errorf("invalid ui theme %s: %w", c.role, err)
+		}
+	}
+	if cfg.Workers < 0 {
+		return cfg, fmt.Errorf("invalid workers %d (use 0 for automatic sizing)", cfg.Workers)
+	}
+	if cfg.ScanDepth < 1 {
+		return cfg, fmt.Errorf("invalid scan_depth %d (must be at least 1)", cfg.ScanDepth)
+	}
+	for host, limit := range cfg.HostLimits {
+		if host == "" || limit < 1 {
+			return cfg, fmt.Errorf("invalid host_limits entry %q: %d (need a host and a limit of at least 1)", host, limit)
+		}
+	}
+	if cfg.Safety.MaxDeletionsPerRun < 0 {
+		return cfg, fmt.Errorf("invalid safety max_deletions_per_run %d (use 0 to disable)", cfg.Safety.MaxDeletionsPerRun)
+	}
+	if cfg.Safety.TypedConfirmThreshold < 0 {
+		return cfg, fmt.Errorf("invalid safety typed_confirm_threshold %d (use 0 to disable)", cfg.Safety.TypedConfirmThreshold)
+	}
+	if cfg.Audit.AutoQuarantineAfterDays < 0 {
+		return cfg, fmt.Errorf("invalid audit auto_quarantine_after_days %d (use 0 to disable)", cfg.Audit.AutoQuarantineAfterDays)
+	}
+	if cfg.Audit.NoteTTLDays < 0 {
+		return cfg, fmt.Errorf("invalid audit note_ttl_days %d (use 0 for no expiry)", cfg.Audit.NoteTTLDays)
+	}
+	for i, rule := range cfg.Audit.Rules {
+		if rule.Group == "" && !rule.NameMatchesRemote && len(rule.RequiredFiles) == 0 {
+			return cfg, fmt.Errorf("invalid audit rule %d: set group, name_matches_remote, or required_files", i+1)
+		}
+		if g := filepath.Clean(rule.Group); filepath.IsAbs(rule.Group) || g == ".." || strings.HasPrefix(g, "../") {
+			return cfg, fmt.Errorf("invalid audit rule %d: group %q must be a path inside projects_dir", i+1, rule.Group)
+		}
+		for _, f := range rule.RequiredFiles {
+			if _, err := filepath.Match(f, ""); err != nil {
+				return cfg, fmt.Errorf("invalid audit rule %d: required file %q: %w", i+1, f, err)
+			}
+		}
+	}
+	if cfg.BranchNaming.MaxLength < 0 {
+		return cfg, fmt.Errorf("invalid branch_naming max_length %d (use 0 for no limit)", cfg.BranchNaming.MaxLength)
+	}
+	for _, pattern := range cfg.ExcludeRemotes {
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			if _, err := regexp.Compile(expr); err != nil {
+				return cfg, fmt.Errorf("invalid exclude_remotes pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	for _, pattern := range cfg.IncludePatterns {
+		if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+			if _, err := regexp.Compile(expr); err != nil {
+				return cfg, fmt.Errorf("invalid include_patterns pattern %q: %w", pattern, err)
+			}
+		} else if _, err := filepath.Match(pattern, ""); err != nil {
+			return cfg, fmt.Errorf("invalid include_patterns pattern %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range append(slices.Clone(cfg.ProtectedBranches), cfg.AutomationPatterns...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return cfg, fmt.Errorf("invalid branch pattern %q: %w", pattern, err)
+		}
+	}
+	if u := cfg.Metrics.RemoteURL; u != "" && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		return cfg, fmt.Errorf("invalid metrics remote_url %q (must be an http or https URL)", u)
+	}
+	if u := cfg.Issues.JiraURL; u != "" && !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+		return cfg, fmt.Errorf("invalid issues jira_url %q (must be an http or https URL)", u)
+	}
 
 	return cfg, nil
 }
 
+func isValidDirtyAction(s string) bool {
+	switch s {
+	case "stash", "wip-commit":
+		return true
+	}
+	return false
+}
+
+func isValidColorMode(s string) bool {
+	switch s {
+	case ui.ColorAuto, ui.ColorAlways, ui.ColorNever:
+		return true
+	}
+	return false
+}
+
 func isValidStrategy(s string) bool {
 	switch s {
 	case "rebase", "merge", "ff-only":
@@ -95,84 +437,142 @@ func configPath() string {
 	return filepath.Join(home, ".config", "katazuke", "config.yaml")
 }
 
-func loadFile(cfg *Config) error {
+// loadFile reads the config file into cfg, then overlays the profile
+// named by cfg.Profile, if any, recording the settings each sets in
+// sources when it is not nil.
+func loadFile(cfg *Config, sources Sources) error {
 	path := filepath.Clean(configPath())
 	data, err := os.ReadFile(path)
 	if os.IsNotExist(err) {
+		if cfg.Profile != "" {
+			return fmt.Errorf("profile %q selected but there is no config file at %s", cfg.Profile, path)
+		}
 		return nil // no config file is fine
 	}
 	if err != nil {
 		return fmt.Errorf("reading config %s: %w", path, err)
 	}
 	if len(strings.TrimSpace(string(data))) == 0 {
-		return nil
+		return applyProfile(cfg, nil, path, sources)
+	}
+	// Decode strictly so a misspelled key or a value of the wrong type is
+	// reported instead of silently having no effect.
+	file := configFile{Config: *cfg}
+	if err := yaml.UnmarshalWithOptions(data, &file, yaml.Strict()); err != nil {
+		return fmt.Errorf("parsing config %s: %w", path, explainYAMLError(data, err))
+	}
+	*cfg = file.Config
+	if sources != nil {
+		markPresent(sources, SourceFile, data)
+	}
+	if err := checkProfiles(file.Profiles, path); err != nil {
+		return explainYAMLError(data, err)
 	}
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return fmt.Errorf("parsing config %s: %w", path, err)
+	if err := applyProfile(cfg, file.Profiles, path, sources); err != nil {
+		return explainYAMLError(data, err)
 	}
 
-	// Expand ~ in projects_dir.
+	// Expand ~ in paths.
 	cfg.ProjectsDir = ExpandHome(cfg.ProjectsDir)
+	cfg.LogFile = ExpandHome(cfg.LogFile)
+	cfg.Safety.BackupDir = ExpandHome(cfg.Safety.BackupDir)
+	cfg.HostLimits = expandHostLimits(cfg.HostLimits)
 	return nil
 }
 
-func applyEnv(cfg *Config) {
-	if v := os.Getenv("KATAZUKE_PROJECTS_DIR"); v != "" {
-		cfg.ProjectsDir = ExpandHome(v)
+// configFile is the layout of the config file: the settings, plus the
+// profiles that can be applied on top of them. Profiles are kept as
+// syntax nodes so errors in them are reported at their place in the file.
+type configFile struct {
+	Config   `yaml:",inline"`
+	Profiles map[string]ast.Node `yaml:"profiles"`
+}
+
+// checkProfiles decodes every profile, so a typo in one is reported
+// before the day it is selected.
+func checkProfiles(profiles map[string]ast.Node, path string) error {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
 	}
-	if v := os.Getenv("KATAZUKE_STALE_THRESHOLD_DAYS"); v != "" {
-		if days, err := strconv.Atoi(v); err == nil && days > 0 {
-			cfg.StaleThresholdDays = days
+	sort.Strings(names)
+	for _, name := range names {
+		var scratch Config
+		if err := yaml.NodeToValue(profiles[name], &scratch, yaml.Strict()); err != nil {
+			return fmt.Errorf("parsing profile %q in %s: %w", name, path, err)
 		}
 	}
-	if v := os.Getenv("KATAZUKE_GITHUB_TOKEN"); v != "" {
-		cfg.GithubToken = v
-	}
-	if v := os.Getenv("GITHUB_TOKEN"); v != "" && cfg.GithubToken == "" {
-		cfg.GithubToken = v
-	}
-	if v := os.Getenv("GH_TOKEN"); v != "" && cfg.GithubToken == "" {
-		cfg.GithubToken = v
-	}
-	if v := os.Getenv("KATAZUKE_SYNC_STRATEGY"); v != "" {
-		cfg.Sync.Strategy = v
+	return nil
+}
+
+// applyProfile overlays the profile named by cfg.Profile from the
+// profiles section of the config file. A profile holds any of the
+// top-level settings; those it sets replace the top-level values, and
+// everything else is inherited.
+func applyProfile(cfg *Config, profiles map[string]ast.Node, path string, sources Sources) error {
+	if cfg.Profile == "" {
+		return nil
 	}
-	if v := os.Getenv("KATAZUKE_SYNC_SKIP_DIRTY"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			cfg.Sync.SkipDirty = b
+	profile, ok := profiles[cfg.Profile]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for name := range profiles {
+			names = append(names, name)
 		}
-	}
-	if v := os.Getenv("KATAZUKE_SYNC_AUTO_STASH"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			cfg.Sync.AutoStash = b
+		sort.Strings(names)
+		available := "none defined"
+		if len(names) > 0 {
+			available = "available: " + strings.Join(names, ", ")
 		}
+		return fmt.Errorf("profile %q not found in %s (%s)", cfg.Profile, path, available)
 	}
-	if v := os.Getenv("KATAZUKE_SYNC_SWITCH_MERGED_BRANCH"); v != "" {
-		if b, err := strconv.ParseBool(v); err == nil {
-			cfg.Sync.SwitchMergedBranch = b
-		}
+	if err := yaml.NodeToValue(profile, cfg, yaml.Strict()); err != nil {
+		return fmt.Errorf("parsing profile %q in %s: %w", cfg.Profile, path, err)
+	}
+	if sources != nil {
+		markPresent(sources, SourceProfile, profile)
+	}
+	return nil
+}
+
+// expandHostLimits expands ~ in path keys of host limits and cleans them,
+// so they compare equal to repository paths.
+func expandHostLimits(limits map[string]int) map[string]int {
+	if len(limits) == 0 {
+		return limits
 	}
-	if v := os.Getenv("KATAZUKE_SYNC_WORKERS"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			cfg.Sync.Workers = n
-			cfg.Workers = n // backward compat: promote to top-level
+	expanded := make(map[string]int, len(limits))
+	for host, limit := range limits {
+		if host = ExpandHome(host); filepath.IsAbs(host) {
+			host = filepath.Clean(host)
 		}
+		expanded[host] = limit
 	}
-	if v := os.Getenv("KATAZUKE_WORKERS"); v != "" {
-		if n, err := strconv.Atoi(v); err == nil && n > 0 {
-			cfg.Workers = n
+	return expanded
+}
+
+// splitList parses a comma-separated environment variable value into a
+// slice, dropping empty entries and surrounding whitespace.
+func splitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
 		}
 	}
+	return items
 }
 
-// ExpandHome replaces a leading ~/ in path with the user's home directory.
+// ExpandHome replaces a leading ~/ in path, or ~\ on Windows, or a bare ~,
+// with the user's home directory.
 func ExpandHome(path string) string {
-	if strings.HasPrefix(path, "~/") {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return path
-		}
-		return filepath.Join(home, path[2:])
+	rest, ok := strings.CutPrefix(path, "~")
+	if !ok || (rest != "" && rest[0] != '/' && rest[0] != filepath.Separator) {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
 	}
-	return path
+	return filepath.Join(home, rest)
 }
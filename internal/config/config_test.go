@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"testing"
 )
@@ -30,9 +29,8 @@ func TestDefaults(t *testing.T) {
 	if !cfg.Sync.SwitchMergedBranch {
 		t.Error("expected sync switch_merged_branch to be true by default")
 	}
-	expectedWorkers := min(4, runtime.NumCPU())
-	if cfg.Workers != expectedWorkers {
-		t.Errorf("expected workers %d, got %d", expectedWorkers, cfg.Workers)
+	if cfg.Workers != 0 {
+		t.Errorf("expected workers 0 (sized per task), got %d", cfg.Workers)
 	}
 	if cfg.Sync.Workers != 0 {
 		t.Errorf("expected deprecated sync workers 0, got %d", cfg.Sync.Workers)
@@ -204,6 +202,32 @@ func TestInvalidSyncStrategy(t *testing.T) {
 	}
 }
 
+func TestSyncDirtyAction(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Sync.DirtyAction != "stash" {
+		t.Errorf("expected default dirty_action stash, got %q", cfg.Sync.DirtyAction)
+	}
+
+	t.Setenv("KATAZUKE_SYNC_DIRTY_ACTION", "wip-commit")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Sync.DirtyAction != "wip-commit" {
+		t.Errorf("expected wip-commit, got %q", cfg.Sync.DirtyAction)
+	}
+
+	t.Setenv("KATAZUKE_SYNC_DIRTY_ACTION", "shelve")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "invalid sync dirty_action") {
+		t.Fatalf("expected invalid dirty_action error, got %v", err)
+	}
+}
+
 func TestInvalidSyncStrategyFromEnv(t *testing.T) {
 	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
 	t.Setenv("KATAZUKE_SYNC_STRATEGY", "invalid")
@@ -365,9 +389,602 @@ func TestExpandHome(t *testing.T) {
 		t.Errorf("expected %s, got %s", want, got)
 	}
 
-	// Non-tilde paths should be unchanged.
+	if got := ExpandHome("~"); got != home {
+		t.Errorf("expected %s, got %s", home, got)
+	}
+
+	// Non-tilde paths, and ~user paths, should be unchanged.
 	got = ExpandHome("/absolute/path")
 	if got != "/absolute/path" {
 		t.Errorf("expected /absolute/path, got %s", got)
 	}
+	if got := ExpandHome("~someone/projects"); got != "~someone/projects" {
+		t.Errorf("expected ~someone/projects to be left alone, got %s", got)
+	}
+}
+
+// writeConfig points XDG_CONFIG_HOME at a temp dir containing the given
+// config.yaml contents.
+func writeConfig(t *testing.T, content string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+
+	configDir := filepath.Join(dir, "katazuke")
+	if err := os.MkdirAll(configDir, 0750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "config.yaml"), []byte(content), 0600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+}
+
+func TestGitHubOrgFilterFromFile(t *testing.T) {
+	writeConfig(t, "github:\n  api_orgs_allow:\n    - acme\n  api_orgs_deny:\n    - golang\n    - kubernetes\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.GitHub.APIOrgsAllow) != 1 || cfg.GitHub.APIOrgsAllow[0] != "acme" {
+		t.Errorf("expected allow [acme], got %v", cfg.GitHub.APIOrgsAllow)
+	}
+	if len(cfg.GitHub.APIOrgsDeny) != 2 {
+		t.Errorf("expected 2 denied orgs, got %v", cfg.GitHub.APIOrgsDeny)
+	}
+}
+
+func TestGitHubOrgFilterEnvOverrides(t *testing.T) {
+	writeConfig(t, "github:\n  api_orgs_allow:\n    - acme\n")
+	t.Setenv("KATAZUKE_GITHUB_API_ORGS_ALLOW", "foo, bar,,")
+	t.Setenv("KATAZUKE_GITHUB_API_ORGS_DENY", "baz")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(cfg.GitHub.APIOrgsAllow) != "[foo bar]" {
+		t.Errorf("expected allow [foo bar], got %v", cfg.GitHub.APIOrgsAllow)
+	}
+	if fmt.Sprint(cfg.GitHub.APIOrgsDeny) != "[baz]" {
+		t.Errorf("expected deny [baz], got %v", cfg.GitHub.APIOrgsDeny)
+	}
+}
+
+func TestMetricsRetentionConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Metrics.RetentionMonths != 12 || cfg.Metrics.MaxTotalMB != 50 {
+		t.Errorf("unexpected metrics defaults: %+v", cfg.Metrics)
+	}
+
+	writeConfig(t, "metrics:\n  retention_months: 6\n  max_total_mb: 10\n")
+	t.Setenv("KATAZUKE_METRICS_MAX_TOTAL_MB", "0")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Metrics.RetentionMonths != 6 {
+		t.Errorf("expected retention 6, got %d", cfg.Metrics.RetentionMonths)
+	}
+	if cfg.Metrics.MaxTotalMB != 0 {
+		t.Errorf("expected env to disable size limit, got %d", cfg.Metrics.MaxTotalMB)
+	}
+}
+
+func TestMetricsRemoteConfig(t *testing.T) {
+	writeConfig(t, "metrics:\n  remote_url: https://metrics.example.com/katazuke\n  remote_token: from-file\n")
+	t.Setenv("KATAZUKE_METRICS_REMOTE_TOKEN", "from-env")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Metrics.RemoteURL != "https://metrics.example.com/katazuke" || cfg.Metrics.RemoteToken != "from-env" {
+		t.Errorf("unexpected remote metrics config: %+v", cfg.Metrics)
+	}
+
+	t.Setenv("KATAZUKE_METRICS_REMOTE_URL", "metrics.example.com")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for a remote_url without a scheme")
+	}
+}
+
+func TestBranchNamingConfig(t *testing.T) {
+	writeConfig(t, "branch_naming:\n  prefixes: [feature/, fix/]\n  max_length: 50\n")
+	t.Setenv("KATAZUKE_BRANCH_NAMING_LOWERCASE", "true")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := cfg.BranchNaming; len(n.Prefixes) != 2 || n.MaxLength != 50 || !n.Lowercase {
+		t.Errorf("unexpected branch naming config: %+v", n)
+	}
+
+	writeConfig(t, "branch_naming:\n  max_length: -1\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for a negative max_length")
+	}
+}
+
+func TestIssuesConfig(t *testing.T) {
+	writeConfig(t, "issues:\n  projects: [abc]\n  jira_url: https://acme.atlassian.net\n  jira_email: me@example.com\n")
+	t.Setenv("KATAZUKE_ISSUES_JIRA_TOKEN", "secret")
+	t.Setenv("KATAZUKE_ISSUES_PROJECTS", "ABC, ops")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Issues.JiraURL != "https://acme.atlassian.net" || cfg.Issues.JiraEmail != "me@example.com" || cfg.Issues.JiraToken != "secret" {
+		t.Errorf("unexpected issues config: %+v", cfg.Issues)
+	}
+	if len(cfg.Issues.Projects) != 2 || cfg.Issues.Projects[1] != "ops" {
+		t.Errorf("expected projects from the environment, got %v", cfg.Issues.Projects)
+	}
+
+	t.Setenv("KATAZUKE_ISSUES_JIRA_URL", "acme.atlassian.net")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for a jira_url without a scheme")
+	}
+}
+
+func TestLogFileConfig(t *testing.T) {
+	writeConfig(t, "log_file: ~/katazuke/debug.log\n")
+	home, _ := os.UserHomeDir()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(home, "katazuke", "debug.log"); cfg.LogFile != want {
+		t.Errorf("expected %s, got %s", want, cfg.LogFile)
+	}
+
+	t.Setenv("KATAZUKE_LOG_FILE", "/var/log/katazuke.log")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LogFile != "/var/log/katazuke.log" {
+		t.Errorf("expected env override, got %s", cfg.LogFile)
+	}
+}
+
+func TestPrimaryRemoteConfig(t *testing.T) {
+	writeConfig(t, "primary_remote: github\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.PrimaryRemote != "github" {
+		t.Errorf("expected github, got %q", cfg.PrimaryRemote)
+	}
+
+	t.Setenv("KATAZUKE_PRIMARY_REMOTE", "upstream")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.PrimaryRemote != "upstream" {
+		t.Errorf("expected env override, got %q", cfg.PrimaryRemote)
+	}
+}
+
+func TestExcludeRemotesConfig(t *testing.T) {
+	writeConfig(t, "exclude_remotes:\n  - \"github.com/some-org/*\"\n  - \"re:^https://gitlab\\\\.example\\\\.com/\"\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.ExcludeRemotes) != 2 || cfg.ExcludeRemotes[1] != `re:^https://gitlab\.example\.com/` {
+		t.Errorf("unexpected exclude_remotes %v", cfg.ExcludeRemotes)
+	}
+
+	writeConfig(t, "exclude_remotes:\n  - \"re:(unclosed\"\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for an invalid exclude_remotes regexp")
+	}
+}
+
+func TestIncludePatternsConfig(t *testing.T) {
+	writeConfig(t, "include_patterns:\n  - \"api-*\"\n  - \"github.com/acme/*\"\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.IncludePatterns) != 2 || cfg.IncludePatterns[0] != "api-*" {
+		t.Errorf("unexpected include_patterns %v", cfg.IncludePatterns)
+	}
+
+	t.Setenv("KATAZUKE_INCLUDE_PATTERNS", "web-*, docs")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(cfg.IncludePatterns, ",") != "web-*,docs" {
+		t.Errorf("expected include_patterns from the environment, got %v", cfg.IncludePatterns)
+	}
+
+	t.Setenv("KATAZUKE_INCLUDE_PATTERNS", "")
+	writeConfig(t, "include_patterns:\n  - \"[unclosed\"\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for an invalid include_patterns glob")
+	}
+}
+
+func TestUIConfig(t *testing.T) {
+	writeConfig(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.UI.Color != "auto" {
+		t.Errorf("expected ui color auto by default, got %q", cfg.UI.Color)
+	}
+
+	writeConfig(t, "ui:\n  color: never\n  theme:\n    warn: blue\n    muted: white\n")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.UI.Color != "never" || cfg.UI.Theme.Warn != "blue" || cfg.UI.Theme.Success != "" {
+		t.Errorf("unexpected ui settings %+v", cfg.UI)
+	}
+
+	t.Setenv("KATAZUKE_UI_COLOR", "always")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.UI.Color != "always" {
+		t.Errorf("expected ui color from the environment, got %q", cfg.UI.Color)
+	}
+	t.Setenv("KATAZUKE_UI_COLOR", "")
+
+	writeConfig(t, "ui:\n  color: sometimes\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for an invalid ui color")
+	}
+	writeConfig(t, "ui:\n  theme:\n    error: orange\n")
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "theme error") {
+		t.Errorf("expected an error naming the theme color, got %v", err)
+	}
+}
+
+func TestIdentityEmails(t *testing.T) {
+	writeConfig(t, "identity:\n  emails:\n    - me@work.example\n    - 12345+me@users.noreply.github.com\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Identity.Emails) != 2 || cfg.Identity.Emails[0] != "me@work.example" {
+		t.Errorf("expected 2 identity emails, got %v", cfg.Identity.Emails)
+	}
+
+	t.Setenv("KATAZUKE_IDENTITY_EMAILS", "me@personal.example")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fmt.Sprint(cfg.Identity.Emails) != "[me@personal.example]" {
+		t.Errorf("expected env override, got %v", cfg.Identity.Emails)
+	}
+}
+
+func TestSafetyConfig(t *testing.T) {
+	writeConfig(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Safety.BundleBeforeDelete || cfg.Safety.BackupRetentionDays != 30 || !cfg.Safety.IsolateHooks || cfg.Safety.MaxDeletionsPerRun != 100 || cfg.Safety.TypedConfirmThreshold != 5 {
+		t.Errorf("unexpected safety defaults: %+v", cfg.Safety)
+	}
+
+	writeConfig(t, "safety:\n  bundle_before_delete: true\n  backup_dir: ~/backups\n  backup_retention_days: 7\n  isolate_hooks: false\n  max_deletions_per_run: 25\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	home, _ := os.UserHomeDir()
+	if !cfg.Safety.BundleBeforeDelete || cfg.Safety.BackupDir != filepath.Join(home, "backups") || cfg.Safety.BackupRetentionDays != 7 || cfg.Safety.IsolateHooks || cfg.Safety.MaxDeletionsPerRun != 25 {
+		t.Errorf("unexpected safety config: %+v", cfg.Safety)
+	}
+
+	t.Setenv("KATAZUKE_SAFETY_BUNDLE_BEFORE_DELETE", "false")
+	t.Setenv("KATAZUKE_SAFETY_BACKUP_RETENTION_DAYS", "0")
+	t.Setenv("KATAZUKE_SAFETY_ISOLATE_HOOKS", "true")
+	t.Setenv("KATAZUKE_SAFETY_MAX_DELETIONS_PER_RUN", "0")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Safety.BundleBeforeDelete || cfg.Safety.BackupRetentionDays != 0 || !cfg.Safety.IsolateHooks || cfg.Safety.MaxDeletionsPerRun != 0 {
+		t.Errorf("expected env overrides, got %+v", cfg.Safety)
+	}
+}
+
+func TestUpdateCheckConfig(t *testing.T) {
+	writeConfig(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.UpdateCheck {
+		t.Error("expected update_check to default to true")
+	}
+
+	writeConfig(t, "update_check: false\n")
+	if cfg, err = Load(); err != nil || cfg.UpdateCheck {
+		t.Errorf("expected update_check false from the file, got %v (%v)", cfg.UpdateCheck, err)
+	}
+
+	t.Setenv("KATAZUKE_UPDATE_CHECK", "true")
+	if cfg, err = Load(); err != nil || !cfg.UpdateCheck {
+		t.Errorf("expected KATAZUKE_UPDATE_CHECK to win, got %v (%v)", cfg.UpdateCheck, err)
+	}
+}
+
+func TestSyncRetryConfig(t *testing.T) {
+	writeConfig(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Sync.Retries != 2 || cfg.Sync.RetryDelaySeconds != 2 {
+		t.Errorf("expected 2 retries 2 seconds apart by default, got %d and %d", cfg.Sync.Retries, cfg.Sync.RetryDelaySeconds)
+	}
+
+	writeConfig(t, "sync:\n  retries: 5\n  retry_delay_seconds: 1\n")
+	if cfg, err = Load(); err != nil || cfg.Sync.Retries != 5 || cfg.Sync.RetryDelaySeconds != 1 {
+		t.Errorf("expected retries from the file, got %d and %d (%v)", cfg.Sync.Retries, cfg.Sync.RetryDelaySeconds, err)
+	}
+
+	t.Setenv("KATAZUKE_SYNC_RETRIES", "0")
+	if cfg, err = Load(); err != nil || cfg.Sync.Retries != 0 {
+		t.Errorf("expected KATAZUKE_SYNC_RETRIES to disable retries, got %d (%v)", cfg.Sync.Retries, err)
+	}
+
+	writeConfig(t, "sync:\n  retry_delay_seconds: -1\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for a negative retry delay")
+	}
+}
+
+func TestScanDepthConfig(t *testing.T) {
+	writeConfig(t, "")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ScanDepth != 1 {
+		t.Errorf("expected default scan_depth 1, got %d", cfg.ScanDepth)
+	}
+
+	writeConfig(t, "scan_depth: 3\n")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ScanDepth != 3 {
+		t.Errorf("expected scan_depth 3, got %d", cfg.ScanDepth)
+	}
+
+	t.Setenv("KATAZUKE_SCAN_DEPTH", "2")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.ScanDepth != 2 {
+		t.Errorf("expected env scan_depth 2, got %d", cfg.ScanDepth)
+	}
+
+	t.Setenv("KATAZUKE_SCAN_DEPTH", "0")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for scan_depth 0")
+	}
+}
+
+func TestHostLimitsConfig(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	writeConfig(t, "host_limits:\n  api.github.com: 2\n  ~/nfs: 1\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.HostLimits["api.github.com"] != 2 || cfg.HostLimits[filepath.Join(home, "nfs")] != 1 {
+		t.Errorf("unexpected host_limits %v", cfg.HostLimits)
+	}
+
+	t.Setenv("KATAZUKE_HOST_LIMITS", "github.com=3, /mnt/share/=1")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.HostLimits) != 2 || cfg.HostLimits["github.com"] != 3 || cfg.HostLimits["/mnt/share"] != 1 {
+		t.Errorf("expected env host limits to replace the file's, got %v", cfg.HostLimits)
+	}
+
+	t.Setenv("KATAZUKE_HOST_LIMITS", "github.com=none")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for a non-numeric host limit")
+	}
+}
+
+func TestWorkersNegative(t *testing.T) {
+	writeConfig(t, "workers: -1\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for negative workers")
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	writeConfig(t, `projects_dir: ~/personal
+stale_threshold_days: 60
+github_token: personal-token
+sync:
+  strategy: merge
+profiles:
+  work:
+    projects_dir: ~/work
+    github_token: work-token
+    exclude_patterns: [generated]
+    stale_threshold_days: 14
+    sync:
+      auto_stash: false
+  oss:
+    projects_dir: ~/oss
+`)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Profile != "" || cfg.ProjectsDir != filepath.Join(home, "personal") || cfg.GithubToken != "personal-token" {
+		t.Errorf("expected top-level values without a profile, got %+v", cfg)
+	}
+
+	t.Setenv("KATAZUKE_PROFILE", "work")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Profile != "work" || cfg.ProjectsDir != filepath.Join(home, "work") || cfg.GithubToken != "work-token" {
+		t.Errorf("expected work profile values, got %+v", cfg)
+	}
+	if cfg.StaleThresholdDays != 14 || strings.Join(cfg.ExcludePatterns, ",") != "generated" {
+		t.Errorf("expected profile threshold and excludes, got %d %v", cfg.StaleThresholdDays, cfg.ExcludePatterns)
+	}
+	// Settings the profile leaves out are inherited, including within
+	// nested sections.
+	if cfg.Sync.Strategy != "merge" || cfg.Sync.AutoStash {
+		t.Errorf("expected inherited strategy and overridden auto_stash, got %+v", cfg.Sync)
+	}
+
+	// Environment variables still win over the profile.
+	t.Setenv("KATAZUKE_STALE_THRESHOLD_DAYS", "7")
+	cfg, err = Load()
+	if err != nil || cfg.StaleThresholdDays != 7 {
+		t.Errorf("expected env to override the profile, got %d, %v", cfg.StaleThresholdDays, err)
+	}
+
+	t.Setenv("KATAZUKE_PROFILE", "missing")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "available: oss, work") {
+		t.Errorf("expected an unknown profile error listing the profiles, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		profile string
+		want    []string
+	}{
+		{
+			name:    "top-level typo",
+			content: "projects_dir: /p\nstale_treshold_days: 14\n",
+			want:    []string{"[2:1]", `unknown field "stale_treshold_days"`, "valid keys: ", "stale_threshold_days", "profiles"},
+		},
+		{
+			name:    "nested typo",
+			content: "sync:\n  strategy: merge\n  auto_stsh: false\n",
+			want:    []string{"[3:3]", `unknown field "auto_stsh"`, "valid keys under sync: auto_stash, dirty_action,"},
+		},
+		{
+			name:    "typo in a list item",
+			content: "audit:\n  rules:\n    - name: layout\n      grop: work\n",
+			want:    []string{"[4:7]", "valid keys under audit.rules: group, name, name_matches_remote, orgs, required_files"},
+		},
+		{
+			name:    "typo in the selected profile",
+			content: "profiles:\n  work:\n    ui:\n      colour: never\n",
+			profile: "work",
+			want:    []string{`profile "work"`, "[4:7]", `unknown field "colour"`, "valid keys under ui: color, theme"},
+		},
+		{
+			name:    "typo in another profile",
+			content: "profiles:\n  work:\n    workers: 2\n  oss:\n    exclude_paterns: [x]\n",
+			want:    []string{`profile "oss"`, "[5:5]", "valid keys: "},
+		},
+		{
+			name:    "wrong type",
+			content: "workers: four\n",
+			want:    []string{"[1:10]", "cannot unmarshal string"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeConfig(t, tt.content)
+			t.Setenv("KATAZUKE_PROFILE", tt.profile)
+			_, err := Load()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in error:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestProfileWithoutConfigFile(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("KATAZUKE_PROFILE", "work")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error when a profile is selected without a config file")
+	}
+}
+
+func TestAuditConfig(t *testing.T) {
+	writeConfig(t, "audit:\n  auto_quarantine_after_days: 180\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Audit.AutoQuarantineAfterDays != 180 || cfg.Audit.NoteTTLDays != 180 {
+		t.Errorf("expected 180, got %d", cfg.Audit.AutoQuarantineAfterDays)
+	}
+
+	t.Setenv("KATAZUKE_AUDIT_AUTO_QUARANTINE_AFTER_DAYS", "0")
+	if cfg, err = Load(); err != nil || cfg.Audit.AutoQuarantineAfterDays != 0 {
+		t.Errorf("expected the environment to disable it, got %d, %v", cfg.Audit.AutoQuarantineAfterDays, err)
+	}
+
+	t.Setenv("KATAZUKE_AUDIT_AUTO_QUARANTINE_AFTER_DAYS", "")
+	writeConfig(t, "audit:\n  auto_quarantine_after_days: -1\n")
+	if _, err := Load(); err == nil {
+		t.Error("expected an error for a negative threshold")
+	}
+}
+
+func TestAuditRulesConfig(t *testing.T) {
+	writeConfig(t, "audit:\n  rules:\n    - orgs: [acme]\n      group: work\n      required_files: [CODEOWNERS, LICENSE*]\n    - name_matches_remote: true\n")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Audit.Rules) != 2 || cfg.Audit.Rules[0].Group != "work" || len(cfg.Audit.Rules[0].RequiredFiles) != 2 || !cfg.Audit.Rules[1].NameMatchesRemote {
+		t.Errorf("unexpected rules: %+v", cfg.Audit.Rules)
+	}
+
+	for _, bad := range []string{
+		"audit:\n  rules:\n    - orgs: [acme]\n",
+		"audit:\n  rules:\n    - group: ../elsewhere\n",
+		"audit:\n  rules:\n    - required_files: ['[']\n",
+	} {
+		writeConfig(t, bad)
+		if _, err := Load(); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
 }
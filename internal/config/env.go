@@ -0,0 +1,182 @@
+package config
+
+import (
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// EnvVar is an environment variable that overrides a config setting.
+type EnvVar struct {
+	// Name is the variable, e.g. "KATAZUKE_SYNC_STRATEGY".
+	Name string
+	// Key is the setting it overrides, e.g. "sync.strategy"; see
+	// Config.Lookup.
+	Key string
+	// Secret marks credentials, whose values are never printed.
+	Secret bool
+
+	// apply sets the value v on cfg, reporting false when v is not a
+	// valid value and was ignored. Nil for variables read elsewhere.
+	apply func(cfg *Config, v string) bool
+	// promotes is another setting apply also sets.
+	promotes string
+}
+
+// envVars lists every variable applyEnv reads, in the order it applies
+// them: where two set the same setting, the later one wins.
+var envVars = []EnvVar{
+	{Name: "KATAZUKE_PROFILE", Key: "profile"}, // read by Load before the config file
+	{Name: "KATAZUKE_PROJECTS_DIR", Key: "projects_dir", apply: setPath(func(c *Config) *string { return &c.ProjectsDir })},
+	{Name: "KATAZUKE_PRIMARY_REMOTE", Key: "primary_remote", apply: setString(func(c *Config) *string { return &c.PrimaryRemote })},
+	{Name: "KATAZUKE_LOG_FILE", Key: "log_file", apply: setPath(func(c *Config) *string { return &c.LogFile })},
+	{Name: "KATAZUKE_STALE_THRESHOLD_DAYS", Key: "stale_threshold_days", apply: setInt(func(c *Config) *int { return &c.StaleThresholdDays }, 1)},
+	{Name: "KATAZUKE_GITHUB_TOKEN", Key: "github_token", Secret: true, apply: setString(func(c *Config) *string { return &c.GithubToken })},
+	{Name: "GITHUB_TOKEN", Key: "github_token", Secret: true, apply: setTokenFallback},
+	{Name: "GH_TOKEN", Key: "github_token", Secret: true, apply: setTokenFallback},
+	{Name: "KATAZUKE_GITHUB_TOKEN_COMMAND", Key: "github.token_command", apply: setString(func(c *Config) *string { return &c.GitHub.TokenCommand })},
+	{Name: "KATAZUKE_GITHUB_TOKEN_KEYCHAIN", Key: "github.token_keychain", apply: setString(func(c *Config) *string { return &c.GitHub.TokenKeychain })},
+	{Name: "KATAZUKE_GITHUB_API_ORGS_ALLOW", Key: "github.api_orgs_allow", apply: setList(func(c *Config) *[]string { return &c.GitHub.APIOrgsAllow })},
+	{Name: "KATAZUKE_GITHUB_API_ORGS_DENY", Key: "github.api_orgs_deny", apply: setList(func(c *Config) *[]string { return &c.GitHub.APIOrgsDeny })},
+	{Name: "KATAZUKE_IDENTITY_EMAILS", Key: "identity.emails", apply: setList(func(c *Config) *[]string { return &c.Identity.Emails })},
+	{Name: "KATAZUKE_BRANCH_NAMING_PREFIXES", Key: "branch_naming.prefixes", apply: setList(func(c *Config) *[]string { return &c.BranchNaming.Prefixes })},
+	{Name: "KATAZUKE_BRANCH_NAMING_MAX_LENGTH", Key: "branch_naming.max_length", apply: setInt(func(c *Config) *int { return &c.BranchNaming.MaxLength }, 0)},
+	{Name: "KATAZUKE_BRANCH_NAMING_LOWERCASE", Key: "branch_naming.lowercase", apply: setBool(func(c *Config) *bool { return &c.BranchNaming.Lowercase })},
+	{Name: "KATAZUKE_ISSUES_PROJECTS", Key: "issues.projects", apply: setList(func(c *Config) *[]string { return &c.Issues.Projects })},
+	{Name: "KATAZUKE_ISSUES_JIRA_URL", Key: "issues.jira_url", apply: setString(func(c *Config) *string { return &c.Issues.JiraURL })},
+	{Name: "KATAZUKE_ISSUES_JIRA_EMAIL", Key: "issues.jira_email", apply: setString(func(c *Config) *string { return &c.Issues.JiraEmail })},
+	{Name: "KATAZUKE_ISSUES_JIRA_TOKEN", Key: "issues.jira_token", Secret: true, apply: setString(func(c *Config) *string { return &c.Issues.JiraToken })},
+	{Name: "KATAZUKE_SAFETY_BUNDLE_BEFORE_DELETE", Key: "safety.bundle_before_delete", apply: setBool(func(c *Config) *bool { return &c.Safety.BundleBeforeDelete })},
+	{Name: "KATAZUKE_SAFETY_ISOLATE_HOOKS", Key: "safety.isolate_hooks", apply: setBool(func(c *Config) *bool { return &c.Safety.IsolateHooks })},
+	{Name: "KATAZUKE_SAFETY_MAX_DELETIONS_PER_RUN", Key: "safety.max_deletions_per_run", apply: setInt(func(c *Config) *int { return &c.Safety.MaxDeletionsPerRun }, 0)},
+	{Name: "KATAZUKE_SAFETY_TYPED_CONFIRM_THRESHOLD", Key: "safety.typed_confirm_threshold", apply: setInt(func(c *Config) *int { return &c.Safety.TypedConfirmThreshold }, 0)},
+	{Name: "KATAZUKE_SAFETY_MINE_ONLY", Key: "safety.mine_only", apply: setBool(func(c *Config) *bool { return &c.Safety.MineOnly })},
+	{Name: "KATAZUKE_AUDIT_AUTO_QUARANTINE_AFTER_DAYS", Key: "audit.auto_quarantine_after_days", apply: setInt(func(c *Config) *int { return &c.Audit.AutoQuarantineAfterDays }, 0)},
+	{Name: "KATAZUKE_AUDIT_NOTE_TTL_DAYS", Key: "audit.note_ttl_days", apply: setInt(func(c *Config) *int { return &c.Audit.NoteTTLDays }, 0)},
+	{Name: "KATAZUKE_SAFETY_BACKUP_DIR", Key: "safety.backup_dir", apply: setPath(func(c *Config) *string { return &c.Safety.BackupDir })},
+	{Name: "KATAZUKE_SAFETY_BACKUP_RETENTION_DAYS", Key: "safety.backup_retention_days", apply: setInt(func(c *Config) *int { return &c.Safety.BackupRetentionDays }, 0)},
+	{Name: "KATAZUKE_METRICS_RETENTION_MONTHS", Key: "metrics.retention_months", apply: setInt(func(c *Config) *int { return &c.Metrics.RetentionMonths }, 0)},
+	{Name: "KATAZUKE_METRICS_MAX_TOTAL_MB", Key: "metrics.max_total_mb", apply: setInt(func(c *Config) *int { return &c.Metrics.MaxTotalMB }, 0)},
+	{Name: "KATAZUKE_METRICS_REMOTE_URL", Key: "metrics.remote_url", apply: setString(func(c *Config) *string { return &c.Metrics.RemoteURL })},
+	{Name: "KATAZUKE_METRICS_REMOTE_TOKEN", Key: "metrics.remote_token", Secret: true, apply: setString(func(c *Config) *string { return &c.Metrics.RemoteToken })},
+	{Name: "KATAZUKE_POLICY_URL", Key: "policy_url", apply: setString(func(c *Config) *string { return &c.PolicyURL })},
+	{Name: "KATAZUKE_UI_COLOR", Key: "ui.color", apply: setString(func(c *Config) *string { return &c.UI.Color })},
+	{Name: "KATAZUKE_UPDATE_CHECK", Key: "update_check", apply: setBool(func(c *Config) *bool { return &c.UpdateCheck })},
+	{Name: "KATAZUKE_PROTECTED_BRANCHES", Key: "protected_branches", apply: setList(func(c *Config) *[]string { return &c.ProtectedBranches })},
+	{Name: "KATAZUKE_INCLUDE_PATTERNS", Key: "include_patterns", apply: setList(func(c *Config) *[]string { return &c.IncludePatterns })},
+	{Name: "KATAZUKE_SYNC_STRATEGY", Key: "sync.strategy", apply: setString(func(c *Config) *string { return &c.Sync.Strategy })},
+	{Name: "KATAZUKE_SYNC_SKIP_DIRTY", Key: "sync.skip_dirty", apply: setBool(func(c *Config) *bool { return &c.Sync.SkipDirty })},
+	{Name: "KATAZUKE_SYNC_AUTO_STASH", Key: "sync.auto_stash", apply: setBool(func(c *Config) *bool { return &c.Sync.AutoStash })},
+	{Name: "KATAZUKE_SYNC_SWITCH_MERGED_BRANCH", Key: "sync.switch_merged_branch", apply: setBool(func(c *Config) *bool { return &c.Sync.SwitchMergedBranch })},
+	{Name: "KATAZUKE_SYNC_DIRTY_ACTION", Key: "sync.dirty_action", apply: setString(func(c *Config) *string { return &c.Sync.DirtyAction })},
+	{Name: "KATAZUKE_SYNC_RETRIES", Key: "sync.retries", apply: setInt(func(c *Config) *int { return &c.Sync.Retries }, 0)},
+	{Name: "KATAZUKE_SYNC_RETRY_DELAY_SECONDS", Key: "sync.retry_delay_seconds", apply: setInt(func(c *Config) *int { return &c.Sync.RetryDelaySeconds }, 0)},
+	// Deprecated: promoted to the top-level workers for backward
+	// compatibility.
+	{Name: "KATAZUKE_SYNC_WORKERS", Key: "sync.workers", promotes: "workers", apply: func(c *Config, v string) bool {
+		if !setInt(func(c *Config) *int { return &c.Sync.Workers }, 1)(c, v) {
+			return false
+		}
+		c.Workers = c.Sync.Workers
+		return true
+	}},
+	{Name: "KATAZUKE_SCAN_DEPTH", Key: "scan_depth", apply: setInt(func(c *Config) *int { return &c.ScanDepth }, math.MinInt)},
+	{Name: "KATAZUKE_HOST_LIMITS", Key: "host_limits", apply: setHostLimits},
+	{Name: "KATAZUKE_WORKERS", Key: "workers", apply: setInt(func(c *Config) *int { return &c.Workers }, 1)},
+}
+
+// EnvVars returns every environment variable that overrides a setting,
+// in the order they are applied.
+func EnvVars() []EnvVar {
+	return slices.Clone(envVars)
+}
+
+// applyEnv overrides settings from the environment. Empty variables and
+// values that do not parse are ignored. When sources is not nil, each
+// setting taken from a variable is recorded in it.
+func applyEnv(cfg *Config, sources Sources) {
+	for _, e := range envVars {
+		v := os.Getenv(e.Name)
+		if v == "" || e.apply == nil || !e.apply(cfg, v) {
+			continue
+		}
+		if sources != nil {
+			sources[e.Key] = Origin{Source: SourceEnv, Via: e.Name}
+			if e.promotes != "" {
+				sources[e.promotes] = Origin{Source: SourceEnv, Via: e.Name}
+			}
+		}
+	}
+}
+
+func setString(field func(*Config) *string) func(*Config, string) bool {
+	return func(c *Config, v string) bool {
+		*field(c) = v
+		return true
+	}
+}
+
+func setPath(field func(*Config) *string) func(*Config, string) bool {
+	return func(c *Config, v string) bool {
+		*field(c) = ExpandHome(v)
+		return true
+	}
+}
+
+func setList(field func(*Config) *[]string) func(*Config, string) bool {
+	return func(c *Config, v string) bool {
+		*field(c) = splitList(v)
+		return true
+	}
+}
+
+// setInt accepts integers of at least minimum.
+func setInt(field func(*Config) *int, minimum int) func(*Config, string) bool {
+	return func(c *Config, v string) bool {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minimum {
+			return false
+		}
+		*field(c) = n
+		return true
+	}
+}
+
+func setBool(field func(*Config) *bool) func(*Config, string) bool {
+	return func(c *Config, v string) bool {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false
+		}
+		*field(c) = b
+		return true
+	}
+}
+
+// setTokenFallback applies GITHUB_TOKEN and GH_TOKEN, which only count
+// when no other token is configured.
+func setTokenFallback(c *Config, v string) bool {
+	if c.GithubToken != "" {
+		return false
+	}
+	c.GithubToken = v
+	return true
+}
+
+// setHostLimits parses "host=N,..." into host_limits. Entries that do
+// not parse get a limit of 0, which validation rejects.
+func setHostLimits(c *Config, v string) bool {
+	limits := make(map[string]int)
+	for _, item := range splitList(v) {
+		host, n, _ := strings.Cut(item, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			limit = 0 // rejected by validation
+		}
+		limits[strings.TrimSpace(host)] = limit
+	}
+	c.HostLimits = expandHostLimits(limits)
+	return true
+}
@@ -0,0 +1,84 @@
+package config
+
+import "testing"
+
+func TestEnvVarKeys(t *testing.T) {
+	cfg := Defaults()
+	seen := make(map[string]bool)
+	for _, e := range EnvVars() {
+		if seen[e.Name] {
+			t.Errorf("%s listed twice", e.Name)
+		}
+		seen[e.Name] = true
+		if _, ok := cfg.Lookup(e.Key); !ok {
+			t.Errorf("%s: no setting %q", e.Name, e.Key)
+		}
+		if e.Name != "KATAZUKE_PROFILE" && e.apply == nil {
+			t.Errorf("%s is never applied", e.Name)
+		}
+	}
+}
+
+func TestLoadWithSources(t *testing.T) {
+	writeConfig(t, `stale_threshold_days: 60
+sync:
+  strategy: merge
+  workers: 6
+profiles:
+  work:
+    stale_threshold_days: 14
+`)
+	t.Setenv("KATAZUKE_PROFILE", "work")
+	t.Setenv("KATAZUKE_UI_COLOR", "never")
+	t.Setenv("GH_TOKEN", "gh-token")
+	t.Setenv("KATAZUKE_SYNC_RETRIES", "often")
+
+	cfg, sources, err := LoadWithSources()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tests := []struct {
+		key   string
+		value any
+		want  Origin
+	}{
+		{"profile", "work", Origin{Source: SourceEnv, Via: "KATAZUKE_PROFILE"}},
+		{"stale_threshold_days", 14, Origin{Source: SourceProfile}},
+		{"sync.strategy", "merge", Origin{Source: SourceFile}},
+		{"workers", 6, Origin{Source: SourceFile, Via: "sync.workers"}},
+		{"sync.auto_stash", true, Origin{Source: SourceDefault}},
+		{"ui.color", "never", Origin{Source: SourceEnv, Via: "KATAZUKE_UI_COLOR"}},
+		{"github_token", "gh-token", Origin{Source: SourceEnv, Via: "GH_TOKEN"}},
+		{"sync.retries", 2, Origin{Source: SourceDefault}},
+	}
+	for _, tt := range tests {
+		got, ok := cfg.Lookup(tt.key)
+		if !ok || got != tt.value {
+			t.Errorf("Lookup(%q) = %v, %v; want %v", tt.key, got, ok, tt.value)
+		}
+		if o := sources.Of(tt.key); o != tt.want {
+			t.Errorf("source of %s = %+v, want %+v", tt.key, o, tt.want)
+		}
+	}
+
+	t.Setenv("KATAZUKE_WORKERS", "3")
+	_, sources, err = LoadWithSources()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if o := sources.Of("workers"); o.Source != SourceEnv || o.Via != "KATAZUKE_WORKERS" {
+		t.Errorf("expected KATAZUKE_WORKERS to win over the promoted sync.workers, got %+v", o)
+	}
+}
+
+func TestLookupUnknownKey(t *testing.T) {
+	cfg := Defaults()
+	for _, key := range []string{"nope", "sync.nope", "sync.strategy.deeper", ""} {
+		if _, ok := cfg.Lookup(key); ok {
+			t.Errorf("Lookup(%q) found a setting", key)
+		}
+	}
+	if v, _ := cfg.Lookup("ui.theme"); v != cfg.UI.Theme {
+		t.Errorf("expected ui.theme to be the theme section, got %v", v)
+	}
+}
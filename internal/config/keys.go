@@ -0,0 +1,169 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+)
+
+// explainYAMLError adds the keys accepted where an unknown key was found
+// in the config file data, e.g. "valid keys under sync: auto_stash, ...".
+// Other errors, which already carry their line and column, are returned
+// unchanged.
+func explainYAMLError(data []byte, err error) error {
+	var unknown *yaml.UnknownFieldError
+	if !errors.As(err, &unknown) || unknown.Token == nil {
+		return err
+	}
+	file, perr := parser.ParseBytes(data, 0)
+	if perr != nil {
+		return err
+	}
+	for _, doc := range file.Docs {
+		keys, ok := keyPath(doc.Body, unknown.Token, nil)
+		if !ok {
+			continue
+		}
+		parents := keys[:len(keys)-1]
+		// A profile holds top-level settings.
+		if len(parents) >= 2 && parents[0] == "profiles" {
+			parents = parents[2:]
+		}
+		valid := validKeys(reflect.TypeFor[configFile](), parents)
+		if len(valid) == 0 {
+			return err
+		}
+		where := "valid keys"
+		if len(parents) > 0 {
+			where += " under " + strings.Join(parents, ".")
+		}
+		return fmt.Errorf("%w\n%s: %s", err, where, strings.Join(valid, ", "))
+	}
+	return err
+}
+
+// keyPath returns the mapping keys leading from node to the key at tok,
+// e.g. [sync auto_stsh], and whether tok was found. Sequence items add
+// nothing to the path.
+func keyPath(node ast.Node, tok *token.Token, parents []string) ([]string, bool) {
+	switch n := node.(type) {
+	case *ast.MappingNode:
+		for _, v := range n.Values {
+			if keys, ok := keyPath(v, tok, parents); ok {
+				return keys, true
+			}
+		}
+	case *ast.MappingValueNode:
+		keys := append(slices.Clone(parents), n.Key.GetToken().Value)
+		if n.Key.GetToken().Position.Offset == tok.Position.Offset {
+			return keys, true
+		}
+		return keyPath(n.Value, tok, keys)
+	case *ast.SequenceNode:
+		for _, v := range n.Values {
+			if keys, ok := keyPath(v, tok, parents); ok {
+				return keys, true
+			}
+		}
+	case *ast.TagNode:
+		return keyPath(n.Value, tok, parents)
+	case *ast.AnchorNode:
+		return keyPath(n.Value, tok, parents)
+	}
+	return nil, false
+}
+
+// validKeys returns the sorted yaml keys of the struct reached from t by
+// following path, or nil if path does not lead to a struct. Slices are
+// looked through, so a path into a list of rules lists a rule's keys.
+func validKeys(t reflect.Type, path []string) []string {
+	t = structType(t)
+	for _, key := range path {
+		if t == nil {
+			return nil
+		}
+		field, ok := fieldByKey(t, key)
+		if !ok {
+			return nil
+		}
+		t = structType(field.Type)
+	}
+	if t == nil {
+		return nil
+	}
+	var keys []string
+	collectKeys(t, &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// structType returns the struct type t holds, looking through pointers
+// and slices, or nil.
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+// fieldByKey finds the field of struct t decoded from key, including
+// fields of inlined structs.
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, inline := yamlKey(f)
+		if inline {
+			if inner, ok := fieldByKey(f.Type, key); ok {
+				inner.Index = append(slices.Clone(f.Index), inner.Index...)
+				return inner, true
+			}
+			continue
+		}
+		if name != "" && name == key {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// collectKeys appends the yaml keys of struct t to keys.
+func collectKeys(t reflect.Type, keys *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, inline := yamlKey(f)
+		switch {
+		case inline:
+			collectKeys(f.Type, keys)
+		case name != "":
+			*keys = append(*keys, name)
+		}
+	}
+}
+
+// yamlKey returns the key a struct field is decoded from, or "" for
+// fields that are not decoded, and whether the field is inlined.
+func yamlKey(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("yaml")
+	name, opts, _ := strings.Cut(tag, ",")
+	if strings.Contains(","+opts+",", ",inline,") {
+		return "", true
+	}
+	if name == "-" || tag == "" {
+		return "", false
+	}
+	return name, false
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+// Policy is a set of guardrails an organization publishes at a URL so
+// every engineer's katazuke enforces them (see Config.PolicyURL). It is
+// merged under the local config: lists are combined, and the stricter of
+// two deletion limits wins, so local settings can add guardrails but not
+// remove the policy's. It has no automation patterns: those widen what is
+// treated as automation and deleted, which a guardrail must not do.
+type Policy struct {
+	ProtectedBranches []string `yaml:"protected_branches"`
+	ExcludePatterns   []string `yaml:"exclude_patterns"`
+	ExcludeRemotes    []string `yaml:"exclude_remotes"`
+	// Safety sets the team's deletion threshold.
+	Safety PolicySafety `yaml:"safety"`
+	// BranchNaming sets the team's branch naming conventions.
+	BranchNaming BranchNamingConfig `yaml:"branch_naming"`
+}
+
+// PolicySafety is the part of SafetyConfig a policy can set.
+type PolicySafety struct {
+	// MaxDeletionsPerRun tightens safety.max_deletions_per_run.
+	MaxDeletionsPerRun int `yaml:"max_deletions_per_run"`
+}
+
+// policyTTL is how long a fetched policy is used before it is fetched
+// again.
+const policyTTL = time.Hour
+
+// policyClient fetches policies; replaced in tests.
+var policyClient = &http.Client{Timeout: 10 * time.Second}
+
+// policyCache is the on-disk copy of the last fetched policy.
+type policyCache struct {
+	URL       string    `json:"url"`
+	FetchedAt time.Time `json:"fetched_at"`
+	Body      string    `json:"body"`
+}
+
+// policyCachePath returns ~/.cache/katazuke/policy-cache.json.
+func policyCachePath() string {
+	path, _ := state.CachePath(state.PolicyCache)
+	return path
+}
+
+// loadPolicy returns the policy at url, from the cache while it is
+// fresh. When the policy cannot be fetched, a cached copy of any age is
+// used with a warning; without one, loading fails rather than running
+// without the organization's guardrails.
+func loadPolicy(url string) (Policy, error) {
+	path := policyCachePath()
+	cached, hasCache := readPolicyCache(path, url)
+	if hasCache && time.Since(cached.FetchedAt) < policyTTL {
+		return parsePolicy([]byte(cached.Body), url)
+	}
+
+	body, fetchErr := fetchPolicy(url)
+	if fetchErr == nil {
+		p, err := parsePolicy(body, url)
+		if err != nil {
+			return Policy{}, err
+		}
+		writePolicyCache(path, policyCache{URL: url, FetchedAt: time.Now(), Body: string(body)})
+		return p, nil
+	}
+	if hasCache {
+		slog.Warn("could not fetch policy, using cached copy",
+			"url", url, "fetched_at", cached.FetchedAt.Format(time.RFC3339), "error", fetchErr)
+		return parsePolicy([]byte(cached.Body), url)
+	}
+	return Policy{}, fmt.Errorf("fetching policy %s: %w", url, fetchErr)
+}
+
+func fetchPolicy(url string) ([]byte, error) {
+	resp, err := policyClient.Get(url) // #nosec G107 - URL comes from the user's config
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// parsePolicy decodes a policy, rejecting unknown fields so a typo in a
+// shared guardrail is not silently ignored.
+func parsePolicy(data []byte, url string) (Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalWithOptions(data, &p, yaml.Strict()); err != nil {
+		return Policy{}, fmt.Errorf("parsing policy %s: %w", url, err)
+	}
+	if p.Safety.MaxDeletionsPerRun < 0 {
+		return Policy{}, fmt.Errorf("policy %s: invalid safety max_deletions_per_run %d", url, p.Safety.MaxDeletionsPerRun)
+	}
+	if p.BranchNaming.MaxLength < 0 {
+		return Policy{}, fmt.Errorf("policy %s: invalid branch_naming max_length %d", url, p.BranchNaming.MaxLength)
+	}
+	return p, nil
+}
+
+func readPolicyCache(path, url string) (policyCache, bool) {
+	var c policyCache
+	// #nosec G304 - fixed file name in the katazuke data directory
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Debug("could not read policy cache", "path", path, "error", err)
+		}
+		return c, false
+	}
+	if err := json.Unmarshal(data, &c); err != nil || c.URL != url {
+		return c, false
+	}
+	return c, true
+}
+
+func writePolicyCache(path string, c policyCache) {
+	data, err := json.Marshal(c)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0o750)
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0o600)
+	}
+	if err != nil {
+		slog.Debug("could not write policy cache", "path", path, "error", err)
+	}
+}
+
+// applyPolicy merges p under cfg. The policy's naming prefixes replace
+// local ones, since adding to an allowlist would loosen it.
+func (cfg *Config) applyPolicy(p Policy) {
+	cfg.ProtectedBranches = union(p.ProtectedBranches, cfg.ProtectedBranches)
+	cfg.ExcludePatterns = union(p.ExcludePatterns, cfg.ExcludePatterns)
+	cfg.ExcludeRemotes = union(p.ExcludeRemotes, cfg.ExcludeRemotes)
+	if n := p.Safety.MaxDeletionsPerRun; n > 0 && (cfg.Safety.MaxDeletionsPerRun == 0 || n < cfg.Safety.MaxDeletionsPerRun) {
+		cfg.Safety.MaxDeletionsPerRun = n
+	}
+	naming := &cfg.BranchNaming
+	if len(p.BranchNaming.Prefixes) > 0 {
+		naming.Prefixes = p.BranchNaming.Prefixes
+	}
+	if n := p.BranchNaming.MaxLength; n > 0 && (naming.MaxLength == 0 || n < naming.MaxLength) {
+		naming.MaxLength = n
+	}
+	naming.Lowercase = naming.Lowercase || p.BranchNaming.Lowercase
+}
+
+// union returns the items of a followed by those of b not already in a.
+func union(a, b []string) []string {
+	out := slices.Clone(a)
+	for _, item := range b {
+		if !slices.Contains(out, item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CACHE_HOME", "")
+	policy := "protected_branches:\n  - release/*\nexclude_patterns:\n  - forks\nsafety:\n  max_deletions_per_run: 20\nbranch_naming:\n  prefixes: [feature/, fix/]\n  max_length: 60\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(policy))
+	}))
+	writeConfig(t, "policy_url: "+srv.URL+"\nprotected_branches:\n  - keep/*\nsafety:\n  max_deletions_per_run: 50\nbranch_naming:\n  prefixes: [wip/]\n  max_length: 40\n  lowercase: true\n")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"release/*", "keep/*"}; !slices.Equal(cfg.ProtectedBranches, want) {
+		t.Errorf("ProtectedBranches = %v, want %v", cfg.ProtectedBranches, want)
+	}
+	if want := []string{"forks", ".archive", "vendor"}; !slices.Equal(cfg.ExcludePatterns, want) {
+		t.Errorf("ExcludePatterns = %v, want %v", cfg.ExcludePatterns, want)
+	}
+	// The stricter limit wins, so local config cannot loosen the policy.
+	if cfg.Safety.MaxDeletionsPerRun != 20 {
+		t.Errorf("Safety.MaxDeletionsPerRun = %d, want 20", cfg.Safety.MaxDeletionsPerRun)
+	}
+	// Local naming rules can only tighten the policy's.
+	if n := cfg.BranchNaming; !slices.Equal(n.Prefixes, []string{"feature/", "fix/"}) || n.MaxLength != 40 || !n.Lowercase {
+		t.Errorf("BranchNaming = %+v, want the policy's prefixes and the stricter limits", n)
+	}
+
+	// While fresh, the cached copy is used without fetching.
+	srv.Close()
+	if cfg, err := Load(); err != nil || cfg.Safety.MaxDeletionsPerRun != 20 {
+		t.Fatalf("expected the cached policy, got %d (%v)", cfg.Safety.MaxDeletionsPerRun, err)
+	}
+
+	// An expired cache is still used when the policy cannot be fetched.
+	path := policyCachePath()
+	c, ok := readPolicyCache(path, srv.URL)
+	if !ok {
+		t.Fatal("expected a cached policy")
+	}
+	c.FetchedAt = time.Now().Add(-2 * policyTTL)
+	data, _ := json.Marshal(c)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write cache: %v", err)
+	}
+	if cfg, err := Load(); err != nil || cfg.Safety.MaxDeletionsPerRun != 20 {
+		t.Fatalf("expected the expired cached policy, got %d (%v)", cfg.Safety.MaxDeletionsPerRun, err)
+	}
+
+	// Without any cached copy, an unreachable policy is an error.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("remove cache: %v", err)
+	}
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "fetching policy") {
+		t.Errorf("expected a fetch error, got %v", err)
+	}
+}
+
+func TestLoadPolicyRejectsUnknownFields(t *testing.T) {
+	// A typo, and automation_patterns, which a policy may not widen.
+	for _, body := range []string{"protected_branch:\n  - main\n", "automation_patterns:\n  - snyk-*\n"} {
+		t.Setenv("HOME", t.TempDir())
+		t.Setenv("XDG_CACHE_HOME", "")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		writeConfig(t, "policy_url: "+srv.URL+"\n")
+
+		if _, err := Load(); err == nil || !strings.Contains(err.Error(), "parsing policy") {
+			t.Errorf("%q: expected a parse error, got %v", body, err)
+		}
+		srv.Close()
+	}
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+)
+
+// Source names the layer a setting's effective value came from.
+type Source string
+
+// Layers, lowest precedence first. SourceFlag is never recorded by Load;
+// it is for callers that apply command-line flags on top.
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceProfile Source = "profile"
+	SourceEnv     Source = "env"
+	SourcePolicy  Source = "policy"
+	SourceFlag    Source = "flag"
+)
+
+// Origin records where a setting's value came from.
+type Origin struct {
+	Source Source
+	// Via is what set it within that layer: the variable for SourceEnv,
+	// or the deprecated key a value was promoted from, e.g.
+	// "sync.workers" for workers.
+	Via string
+}
+
+// Sources maps setting keys (e.g. "sync.strategy") to their origin.
+type Sources map[string]Origin
+
+// Of returns the origin of key. Settings nothing set are defaults.
+func (s Sources) Of(key string) Origin {
+	if o, ok := s[key]; ok {
+		return o
+	}
+	return Origin{Source: SourceDefault}
+}
+
+// LoadWithSources is Load, also reporting which layer each setting came
+// from, for debugging precedence.
+func LoadWithSources() (Config, Sources, error) {
+	sources := Sources{}
+	cfg, err := load(sources)
+	return cfg, sources, err
+}
+
+// Lookup returns the value of the setting at key, e.g. "sync.strategy",
+// and whether there is such a setting. "profile" is the selected profile.
+func (cfg Config) Lookup(key string) (any, bool) {
+	if key == "profile" {
+		return cfg.Profile, true
+	}
+	v := reflect.ValueOf(cfg)
+	for _, part := range strings.Split(key, ".") {
+		if v.Kind() != reflect.Struct {
+			return nil, false
+		}
+		f, ok := fieldByKey(v.Type(), part)
+		if !ok {
+			return nil, false
+		}
+		v = v.FieldByIndex(f.Index)
+	}
+	return v.Interface(), true
+}
+
+// markPresent records source for every setting data sets, a decoded
+// config file or profile. Sections are descended into, so a file setting
+// only sync.strategy leaves the rest of sync at their defaults; other
+// values, lists and maps included, are marked as a whole.
+func markPresent(sources Sources, source Source, data any) {
+	var m map[string]any
+	if err := remarshal(data, &m); err != nil {
+		return
+	}
+	markKeys(sources, source, reflect.TypeFor[Config](), m, "")
+}
+
+func markKeys(sources Sources, source Source, t reflect.Type, m map[string]any, prefix string) {
+	for key, value := range m {
+		f, ok := fieldByKey(t, key)
+		if !ok {
+			continue
+		}
+		path := prefix + key
+		if nested, isMap := value.(map[string]any); isMap && f.Type.Kind() == reflect.Struct {
+			markKeys(sources, source, f.Type, nested, path+".")
+			continue
+		}
+		sources[path] = Origin{Source: source}
+	}
+}
+
+// remarshal decodes data, raw file contents or a profile's node, into v.
+func remarshal(data any, v any) error {
+	switch d := data.(type) {
+	case []byte:
+		return yaml.Unmarshal(d, v)
+	case ast.Node:
+		return yaml.NodeToValue(d, v)
+	}
+	return nil
+}
+
+// markChanged records source for each of keys whose value differs
+// between before and after.
+func markChanged(sources Sources, source Source, before, after Config, keys []string) {
+	for _, key := range keys {
+		b, _ := before.Lookup(key)
+		a, _ := after.Lookup(key)
+		if !reflect.DeepEqual(b, a) {
+			sources[key] = Origin{Source: source}
+		}
+	}
+}
+
+// leafKeys returns the keys of the settings in struct t, descending into
+// sections, e.g. [protected_branches branch_naming.prefixes ...].
+func leafKeys(t reflect.Type, prefix string) []string {
+	var keys []string
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _ := yamlKey(f)
+		if name == "" {
+			continue
+		}
+		if f.Type.Kind() == reflect.Struct {
+			keys = append(keys, leafKeys(f.Type, prefix+name+".")...)
+			continue
+		}
+		keys = append(keys, prefix+name)
+	}
+	return keys
+}
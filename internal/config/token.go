@@ -0,0 +1,94 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// tokenCommandTimeout bounds how long a token command or keychain lookup
+// may run, leaving time to unlock a password manager interactively.
+const tokenCommandTimeout = 2 * time.Minute
+
+// ResolveGithubToken returns the token for GitHub API calls. A plaintext
+// github_token (or KATAZUKE_GITHUB_TOKEN, GITHUB_TOKEN, GH_TOKEN) wins,
+// then the output of github.token_command, then the secret stored in the
+// OS keychain under github.token_keychain. It returns "" when none is
+// configured. Commands run on each call, so callers resolve the token only
+// once they need it.
+func (c Config) ResolveGithubToken() (string, error) {
+	if c.GithubToken != "" {
+		return c.GithubToken, nil
+	}
+	if c.GitHub.TokenCommand != "" {
+		name, args := shellCommand(runtime.GOOS, c.GitHub.TokenCommand)
+		token, err := runTokenCommand(name, args...)
+		if err != nil {
+			return "", fmt.Errorf("github.token_command: %w", err)
+		}
+		return token, nil
+	}
+	if c.GitHub.TokenKeychain != "" {
+		name, args, err := keychainCommand(runtime.GOOS, c.GitHub.TokenKeychain)
+		if err != nil {
+			return "", err
+		}
+		token, err := runTokenCommand(name, args...)
+		if err != nil {
+			return "", fmt.Errorf("reading %q from the keychain: %w", c.GitHub.TokenKeychain, err)
+		}
+		return token, nil
+	}
+	return "", nil
+}
+
+// keychainCommand returns the command that prints the secret stored under
+// service: the login Keychain on macOS and libsecret (GNOME Keyring,
+// KWallet) elsewhere.
+func keychainCommand(goos, service string) (string, []string, error) {
+	switch goos {
+	case "darwin":
+		return "security", []string{"find-generic-password", "-s", service, "-w"}, nil
+	case "windows":
+		return "", nil, errors.New("github.token_keychain is not supported on Windows; use github.token_command instead")
+	default:
+		return "secret-tool", []string{"lookup", "service", service}, nil
+	}
+}
+
+// shellCommand returns the command that runs script in the platform's
+// shell: sh, or cmd on Windows.
+func shellCommand(goos, script string) (string, []string) {
+	if goos == "windows" {
+		return "cmd", []string{"/C", script}
+	}
+	return "sh", []string{"-c", script}
+}
+
+// runTokenCommand runs a command and returns its trimmed output. The
+// terminal stays attached to stdin and stderr so password managers can
+// prompt for an unlock.
+func runTokenCommand(name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	var stdout bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(stdout.String())
+	if token == "" {
+		return "", errors.New("no token in output")
+	}
+	return token, nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenCommandFromFile(t *testing.T) {
+	writeConfig(t, "github:\n  token_command: printf ' ghp_from_command\\n'\n")
+	t.Setenv("KATAZUKE_GITHUB_TOKEN", "")
+	t.Setenv("GITHUB_TOKEN", "")
+	t.Setenv("GH_TOKEN", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token, err := cfg.ResolveGithubToken()
+	if err != nil {
+		t.Fatalf("ResolveGithubToken failed: %v", err)
+	}
+	if token != "ghp_from_command" {
+		t.Errorf("expected trimmed command output, got %q", token)
+	}
+
+	// A plaintext token takes precedence over the command.
+	t.Setenv("GH_TOKEN", "ghp_plain")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if token, _ := cfg.ResolveGithubToken(); token != "ghp_plain" {
+		t.Errorf("expected ghp_plain, got %q", token)
+	}
+}
+
+func TestResolveGithubTokenErrors(t *testing.T) {
+	for _, command := range []string{"exit 1", "true"} {
+		cfg := Config{GitHub: GitHubConfig{TokenCommand: command}}
+		if token, err := cfg.ResolveGithubToken(); err == nil {
+			t.Errorf("%q: expected an error, got token %q", command, token)
+		}
+	}
+
+	if token, err := (Config{}).ResolveGithubToken(); token != "" || err != nil {
+		t.Errorf("expected no token without sources, got %q, %v", token, err)
+	}
+}
+
+func TestTokenSourcesFromEnv(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("KATAZUKE_GITHUB_TOKEN_COMMAND", "op read op://Private/GitHub/token")
+	t.Setenv("KATAZUKE_GITHUB_TOKEN_KEYCHAIN", "katazuke")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.GitHub.TokenCommand != "op read op://Private/GitHub/token" || cfg.GitHub.TokenKeychain != "katazuke" {
+		t.Errorf("unexpected token sources: %+v", cfg.GitHub)
+	}
+}
+
+func TestKeychainCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		want string
+	}{
+		{"darwin", "security find-generic-password -s katazuke -w"},
+		{"linux", "secret-tool lookup service katazuke"},
+		{"freebsd", "secret-tool lookup service katazuke"},
+	}
+	for _, tt := range tests {
+		name, args, err := keychainCommand(tt.goos, "katazuke")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.goos, err)
+		}
+		if got := name + " " + strings.Join(args, " "); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.goos, tt.want, got)
+		}
+	}
+
+	if _, _, err := keychainCommand("windows", "katazuke"); err == nil {
+		t.Error("expected an error on windows")
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	if name, args := shellCommand("linux", "pass github"); name+" "+strings.Join(args, " ") != "sh -c pass github" {
+		t.Errorf("unexpected command %s %q", name, args)
+	}
+	if name, args := shellCommand("windows", "op read op://dev/github/token"); name != "cmd" || strings.Join(args, " ") != "/C op read op://dev/github/token" {
+		t.Errorf("expected cmd /C on windows, got %s %q", name, args)
+	}
+}
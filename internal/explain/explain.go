@@ -0,0 +1,79 @@
+// Package explain collects the reasoning behind each classification a
+// command makes (why a branch was reported, excluded, or protected) so it
+// can be shown to the user with --explain instead of only in debug logs.
+package explain
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Verdicts used by the scanners. Callers may use other short strings;
+// these cover the common outcomes.
+const (
+	Reported  = "reported"
+	Excluded  = "excluded"
+	Skipped   = "skipped"
+	Protected = "protected"
+)
+
+// Entry is a single decision about a subject (a branch, or a repository
+// when Subject is empty) within a repository.
+type Entry struct {
+	Repo    string
+	Subject string
+	Verdict string
+	Reason  string
+}
+
+// Log accumulates entries from concurrent scanners. A nil *Log discards
+// everything, so code paths without --explain pass nil and pay nothing.
+type Log struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New returns an empty Log.
+func New() *Log {
+	return &Log{}
+}
+
+// Add records a decision. Safe to call on a nil Log.
+func (l *Log) Add(repo, subject, verdict, reason string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, Entry{Repo: repo, Subject: subject, Verdict: verdict, Reason: reason})
+}
+
+// Addf records a decision with a formatted reason. Safe to call on a nil Log.
+func (l *Log) Addf(repo, subject, verdict, format string, args ...any) {
+	if l == nil {
+		return
+	}
+	l.Add(repo, subject, verdict, fmt.Sprintf(format, args...))
+}
+
+// Entries returns the recorded decisions sorted by repository and
+// subject. Entries for the same subject keep the order they were added
+// in, so a scanner's verdict is followed by any later refinements.
+func (l *Log) Entries() []Entry {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	out := make([]Entry, len(l.entries))
+	copy(out, l.entries)
+	l.mu.Unlock()
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Repo != out[j].Repo {
+			return out[i].Repo < out[j].Repo
+		}
+		return out[i].Subject < out[j].Subject
+	})
+	return out
+}
@@ -0,0 +1,55 @@
+package explain
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestNilLogDiscards(t *testing.T) {
+	var l *Log
+	l.Add("repo", "branch", Excluded, "default branch")
+	l.Addf("repo", "branch", Excluded, "last commit %d days ago", 3)
+	if l.Entries() != nil {
+		t.Error("nil log should record nothing")
+	}
+}
+
+func TestEntriesSortedAndStable(t *testing.T) {
+	l := New()
+	l.Add("zeta", "main", Excluded, "default branch")
+	l.Add("alpha", "feature", Reported, "stale")
+	l.Add("alpha", "feature", Protected, "remote deletion blocked")
+	l.Add("alpha", "", Skipped, "no origin remote")
+
+	got := l.Entries()
+	want := []Entry{
+		{"alpha", "", Skipped, "no origin remote"},
+		{"alpha", "feature", Reported, "stale"},
+		{"alpha", "feature", Protected, "remote deletion blocked"},
+		{"zeta", "main", Excluded, "default branch"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	l := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Add("repo", "branch", Reported, "x")
+		}()
+	}
+	wg.Wait()
+	if got := len(l.Entries()); got != 50 {
+		t.Errorf("expected 50 entries, got %d", got)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package fsops
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice reports whether a rename failed because the source and
+// destination are on different filesystems.
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,112 @@
+// Package fsops provides a seam over destructive filesystem operations so
+// that cleanup flows can be tested without touching disk.
+package fsops
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileOps performs the filesystem mutations used by cleanup flows.
+type FileOps interface {
+	// Remove deletes path and everything beneath it.
+	Remove(path string) error
+	// Move renames src to dest. The parent of dest must exist.
+	Move(src, dest string) error
+	// EnsureDir creates path and any missing parents.
+	EnsureDir(path string) error
+}
+
+// OS implements FileOps against the real filesystem.
+type OS struct{}
+
+// Remove deletes path recursively.
+func (OS) Remove(path string) error {
+	return os.RemoveAll(path)
+}
+
+// Move renames src to dest, refusing if dest already exists so that an
+// existing directory is never replaced. When they are on different
+// filesystems or drives, which a rename cannot cross, src is copied to dest
+// and then removed; a failed copy removes only what it created.
+func (OS) Move(src, dest string) error {
+	if _, err := os.Lstat(dest); err == nil {
+		return fmt.Errorf("moving %s: %s already exists", src, dest)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	err := os.Rename(src, dest)
+	if err == nil || !isCrossDevice(err) {
+		return err
+	}
+	created, err := copyTree(src, dest)
+	if err != nil {
+		if created {
+			_ = os.RemoveAll(dest)
+		}
+		return fmt.Errorf("copying %s to %s: %w", src, dest, err)
+	}
+	return os.RemoveAll(src)
+}
+
+// copyTree copies the directory, file, or symlink at src to dest, keeping
+// permission bits and leaving symlinks unresolved. Every entry is created
+// exclusively, so nothing already at dest is overwritten; created reports
+// whether dest itself was created by this copy.
+func copyTree(src, dest string) (created bool, err error) {
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			err = os.Mkdir(target, info.Mode().Perm())
+		case d.Type()&fs.ModeSymlink != 0:
+			var link string
+			if link, err = os.Readlink(path); err == nil {
+				err = os.Symlink(link, target)
+			}
+		default:
+			err = copyFile(path, target, info.Mode().Perm())
+		}
+		if err == nil && path == src {
+			created = true
+		}
+		return err
+	})
+	return created, err
+}
+
+func copyFile(src, dest string, perm fs.FileMode) error {
+	in, err := os.Open(src) // #nosec G304 - src is inside the tree being moved
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm) // #nosec G304 - dest mirrors src under the destination
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// EnsureDir creates path with owner-only permissions.
+func (OS) EnsureDir(path string) error {
+	return os.MkdirAll(path, 0o750)
+}
@@ -0,0 +1,91 @@
+package fsops
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOS(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	var fs FileOps = OS{}
+	destDir := filepath.Join(root, "a", "b")
+	if err := fs.EnsureDir(destDir); err != nil {
+		t.Fatalf("EnsureDir: %v", err)
+	}
+	dest := filepath.Join(destDir, "moved")
+	if err := fs.Move(src, dest); err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "nested")); err != nil {
+		t.Errorf("expected moved tree at %s: %v", dest, err)
+	}
+	if err := fs.Remove(dest); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, stat err=%v", dest, err)
+	}
+}
+
+func TestMoveRefusesExistingDest(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	dest := filepath.Join(root, "dest")
+	for _, dir := range []string{src, dest} {
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dest, "existing.txt"), []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (OS{}).Move(src, dest); err == nil {
+		t.Fatal("expected moving onto an existing path to fail")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("expected src to be left in place: %v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "existing.txt")); err != nil || string(data) != "keep me" {
+		t.Errorf("expected the existing dest to be untouched, got %q (%v)", data, err)
+	}
+
+	// A copy that fails on an existing root must not remove it.
+	if created, err := copyTree(src, dest); err == nil || created {
+		t.Errorf("expected copyTree onto an existing root to fail without creating it, got created=%v, %v", created, err)
+	}
+	if _, err := os.Stat(filepath.Join(dest, "existing.txt")); err != nil {
+		t.Errorf("expected the existing dest to survive: %v", err)
+	}
+}
+
+func TestCopyTree(t *testing.T) {
+	root := t.TempDir()
+	src := filepath.Join(root, "src")
+	if err := os.MkdirAll(filepath.Join(src, "nested"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "nested", "notes.txt"), []byte("keep me"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("nested/notes.txt", filepath.Join(src, "link")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	dest := filepath.Join(root, "dest")
+	if created, err := copyTree(src, dest); err != nil || !created {
+		t.Fatalf("copyTree: created=%v, %v", created, err)
+	}
+	if data, err := os.ReadFile(filepath.Join(dest, "nested", "notes.txt")); err != nil || string(data) != "keep me" {
+		t.Errorf("expected the file to be copied, got %q (%v)", data, err)
+	}
+	if link, err := os.Readlink(filepath.Join(dest, "link")); err != nil || link != "nested/notes.txt" {
+		t.Errorf("expected the symlink to be copied as a link, got %q (%v)", link, err)
+	}
+}
@@ -0,0 +1,101 @@
+package github
+
+import "fmt"
+
+// CIStatus summarizes the CI checks reported for a commit.
+type CIStatus string
+
+const (
+	// CINone means no checks were reported for the commit.
+	CINone CIStatus = ""
+	// CIPassing means every check that ran succeeded.
+	CIPassing CIStatus = "passing"
+	// CIFailing means at least one check failed.
+	CIFailing CIStatus = "failing"
+	// CIPending means no check failed but some have not finished.
+	CIPending CIStatus = "pending"
+)
+
+// checkRunsResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/commits/{ref}/check-runs.
+type checkRunsResponse struct {
+	CheckRuns []struct {
+		Status     string `json:"status"`
+		Conclusion string `json:"conclusion"`
+	} `json:"check_runs"`
+}
+
+// combinedStatusResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/commits/{ref}/status.
+type combinedStatusResponse struct {
+	State      string `json:"state"`
+	TotalCount int    `json:"total_count"`
+}
+
+// failedConclusions are the check run conclusions that count as a
+// failure. Cancelled, skipped, neutral, and stale runs count for nothing.
+var failedConclusions = map[string]bool{
+	"failure":         true,
+	"timed_out":       true,
+	"action_required": true,
+	"startup_failure": true,
+}
+
+// CommitCIStatus combines the check runs (GitHub Actions and other apps)
+// and the commit statuses (older integrations) reported for sha.
+func (c *Client) CommitCIStatus(owner, repo, sha string) (CIStatus, error) {
+	if c.rest == nil {
+		return CINone, fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return CINone, err
+	}
+
+	var runs checkRunsResponse
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s/commits/%s/check-runs?per_page=100", owner, repo, sha), &runs); err != nil {
+		return CINone, fmt.Errorf("querying check runs for %s/%s@%s: %w", owner, repo, sha, err)
+	}
+	var combined combinedStatusResponse
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s/commits/%s/status", owner, repo, sha), &combined); err != nil {
+		return CINone, fmt.Errorf("querying statuses for %s/%s@%s: %w", owner, repo, sha, err)
+	}
+
+	status := CINone
+	for _, run := range runs.CheckRuns {
+		switch {
+		case failedConclusions[run.Conclusion]:
+			return CIFailing, nil
+		case run.Status != "completed":
+			status = CIPending
+		case run.Conclusion == "success" && status == CINone:
+			status = CIPassing
+		}
+	}
+	if combined.TotalCount > 0 {
+		switch combined.State {
+		case "failure", "error":
+			return CIFailing, nil
+		case "pending":
+			status = CIPending
+		case "success":
+			if status == CINone {
+				status = CIPassing
+			}
+		}
+	}
+	return status, nil
+}
+
+// Describe returns how a branch prompt words the status, e.g. "CI
+// failing", or "" for CINone.
+func (s CIStatus) Describe() string {
+	switch s {
+	case CIPassing:
+		return "all checks green"
+	case CIFailing:
+		return "CI failing"
+	case CIPending:
+		return "CI pending"
+	}
+	return ""
+}
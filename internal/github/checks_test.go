@@ -0,0 +1,38 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCommitCIStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		runs   string
+		status string
+		want   CIStatus
+	}{
+		{"no checks", `[]`, `{"state": "pending", "total_count": 0}`, CINone},
+		{"all green", `[{"status": "completed", "conclusion": "success"}, {"status": "completed", "conclusion": "skipped"}]`, `{"state": "pending", "total_count": 0}`, CIPassing},
+		{"failed run", `[{"status": "completed", "conclusion": "success"}, {"status": "completed", "conclusion": "failure"}]`, `{"state": "success", "total_count": 1}`, CIFailing},
+		{"running", `[{"status": "in_progress", "conclusion": null}]`, `{"state": "success", "total_count": 1}`, CIPending},
+		{"failed status", `[{"status": "completed", "conclusion": "success"}]`, `{"state": "error", "total_count": 1}`, CIFailing},
+		{"statuses only", `[]`, `{"state": "success", "total_count": 2}`, CIPassing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/repos/acme/app/commits/abc/check-runs", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprintf(w, `{"check_runs": %s}`, tt.runs)
+			})
+			mux.HandleFunc("/repos/acme/app/commits/abc/status", func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprint(w, tt.status)
+			})
+			got, err := newTestClient(t, mux).CommitCIStatus("acme", "app", "abc")
+			if err != nil || got != tt.want {
+				t.Errorf("CommitCIStatus = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
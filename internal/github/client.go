@@ -3,26 +3,51 @@
 package github
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
-	"regexp"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/agrahamlincoln/katazuke/pkg/git"
 	"github.com/cli/go-gh/v2/pkg/api"
 )
 
+// ErrOrgNotAllowed is returned when a lookup targets an owner excluded by
+// the configured org allowlist/denylist. Callers treat it like any other
+// API failure, but it is not worth surfacing as a warning.
+var ErrOrgNotAllowed = errors.New("owner excluded from GitHub API lookups")
+
 // Client wraps GitHub API access.
 type Client struct {
 	rest  *api.RESTClient
 	token string
+	// allowOrgs and denyOrgs restrict which owners may be queried.
+	// Stored lowercased since GitHub owner names are case-insensitive.
+	allowOrgs map[string]bool
+	denyOrgs  map[string]bool
 }
 
+// TokenSource returns the token to authenticate with, or "" for none. It
+// is only called when gh CLI authentication is unavailable, so sources that
+// run a password manager do not prompt needlessly.
+type TokenSource func() (string, error)
+
 // NewClient creates a GitHub client. It attempts to use authentication from
 // the gh CLI config, falling back to the provided token, falling back to
 // unauthenticated access.
 func NewClient(token string) *Client {
-	c := &Client{token: token}
+	return NewClientWithTokenSource(func() (string, error) { return token, nil })
+}
+
+// NewClientWithTokenSource is NewClient with a token that is looked up only
+// if the gh CLI config cannot be used. A source that fails is logged and
+// treated as no token.
+func NewClientWithTokenSource(source TokenSource) *Client {
+	c := &Client{}
 
 	// Try default gh CLI authentication first.
 	rest, err := api.DefaultRESTClient()
@@ -34,6 +59,11 @@ func NewClient(token string) *Client {
 	slog.Debug("gh CLI auth not available", "error", err)
 
 	// Fall back to explicit token.
+	token, err := source()
+	if err != nil {
+		slog.Warn("could not read GitHub token", "error", err)
+	}
+	c.token = token
 	if token != "" {
 		rest, err = api.NewRESTClient(api.ClientOptions{
 			AuthToken: token,
@@ -57,9 +87,49 @@ func NewClient(token string) *Client {
 	return c
 }
 
+// WithOrgFilter restricts API lookups to owners in allow (when non-empty)
+// and never queries owners in deny. Deny takes precedence. Filtering avoids
+// spending rate limit on mirrors and keeps the set of locally checked-out
+// repos from leaking to the API.
+func (c *Client) WithOrgFilter(allow, deny []string) *Client {
+	c.allowOrgs = lowerSet(allow)
+	c.denyOrgs = lowerSet(deny)
+	return c
+}
+
+// AllowsOrg reports whether the given owner may be queried under the
+// configured org filter.
+func (c *Client) AllowsOrg(owner string) bool {
+	owner = strings.ToLower(owner)
+	if c.denyOrgs[owner] {
+		return false
+	}
+	return len(c.allowOrgs) == 0 || c.allowOrgs[owner]
+}
+
+// checkOrg returns ErrOrgNotAllowed for owners outside the org filter.
+func (c *Client) checkOrg(owner string) error {
+	if !c.AllowsOrg(owner) {
+		return fmt.Errorf("%s: %w", owner, ErrOrgNotAllowed)
+	}
+	return nil
+}
+
+func lowerSet(items []string) map[string]bool {
+	if len(items) == 0 {
+		return nil
+	}
+	s := make(map[string]bool, len(items))
+	for _, item := range items {
+		s[strings.ToLower(item)] = true
+	}
+	return s
+}
+
 // repoResponse holds the fields we care about from GET /repos/{owner}/{repo}.
 type repoResponse struct {
-	Archived bool `json:"archived"`
+	Archived bool   `json:"archived"`
+	FullName string `json:"full_name"`
 }
 
 // IsArchived checks if a repository is archived on GitHub.
@@ -67,6 +137,9 @@ func (c *Client) IsArchived(owner, repo string) (bool, error) {
 	if c.rest == nil {
 		return false, fmt.Errorf("no GitHub API client available")
 	}
+	if err := c.checkOrg(owner); err != nil {
+		return false, err
+	}
 
 	var resp repoResponse
 	err := c.rest.Get(fmt.Sprintf("repos/%s/%s", owner, repo), &resp)
@@ -76,6 +149,28 @@ func (c *Client) IsArchived(owner, repo string) (bool, error) {
 	return resp.Archived, nil
 }
 
+// CanonicalName returns the current owner and name of a repository.
+// GitHub redirects lookups of a renamed or transferred repository to its
+// new location, so they differ from owner and repo when it has moved.
+func (c *Client) CanonicalName(owner, repo string) (string, string, error) {
+	if c.rest == nil {
+		return "", "", fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return "", "", err
+	}
+
+	var resp repoResponse
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s", owner, repo), &resp); err != nil {
+		return "", "", fmt.Errorf("querying %s/%s: %w", owner, repo, err)
+	}
+	newOwner, newRepo, ok := strings.Cut(resp.FullName, "/")
+	if !ok {
+		return "", "", fmt.Errorf("querying %s/%s: response has no full name", owner, repo)
+	}
+	return newOwner, newRepo, nil
+}
+
 // PRState represents the state of a GitHub pull request for a branch.
 type PRState string
 
@@ -96,9 +191,13 @@ type prSearchResponse struct {
 	State          string `json:"state"`
 	MergedAt       string `json:"merged_at"`
 	MergeCommitSHA string `json:"merge_commit_sha"`
+	Draft          bool   `json:"draft"`
 	Head           struct {
 		SHA string `json:"sha"`
 	} `json:"head"`
+	User struct {
+		Login string `json:"login"`
+	} `json:"user"`
 }
 
 // PRInfo contains detailed information about a pull request for a branch.
@@ -108,6 +207,10 @@ type PRInfo struct {
 	MergedAt       time.Time
 	HeadSHA        string
 	MergeCommitSHA string
+	// Draft is true for a pull request marked as a draft.
+	Draft bool
+	// Author is the login of the user who opened the pull request.
+	Author string
 }
 
 // BranchPRInfo returns detailed PR information for a branch. When no PR exists,
@@ -116,6 +219,9 @@ func (c *Client) BranchPRInfo(owner, repo, branch string) (*PRInfo, error) {
 	if c.rest == nil {
 		return nil, fmt.Errorf("no GitHub API client available")
 	}
+	if err := c.checkOrg(owner); err != nil {
+		return nil, err
+	}
 
 	var prs []prSearchResponse
 	err := c.rest.Get(
@@ -136,6 +242,8 @@ func (c *Client) BranchPRInfo(owner, repo, branch string) (*PRInfo, error) {
 		Number:         pr.Number,
 		HeadSHA:        pr.Head.SHA,
 		MergeCommitSHA: pr.MergeCommitSHA,
+		Draft:          pr.Draft,
+		Author:         pr.User.Login,
 	}
 
 	switch {
@@ -153,11 +261,57 @@ func (c *Client) BranchPRInfo(owner, repo, branch string) (*PRInfo, error) {
 	return info, nil
 }
 
-// commitResponse holds the fields needed to determine merge method.
+// reviewResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/pulls/{number}/reviews.
+type reviewResponse struct {
+	State string `json:"state"`
+	User  struct {
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// ChangesRequested reports whether a reviewer's latest verdict on a pull
+// request asks for changes. Comments without a verdict do not count, and
+// a dismissed review no longer does.
+func (c *Client) ChangesRequested(owner, repo string, number int) (bool, error) {
+	if c.rest == nil {
+		return false, fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return false, err
+	}
+
+	var reviews []reviewResponse
+	err := c.rest.Get(fmt.Sprintf("repos/%s/%s/pulls/%d/reviews?per_page=100", owner, repo, number), &reviews)
+	if err != nil {
+		return false, fmt.Errorf("querying reviews of %s/%s#%d: %w", owner, repo, number, err)
+	}
+	// Reviews are listed oldest first, so later verdicts replace earlier ones.
+	verdicts := make(map[string]string)
+	for _, r := range reviews {
+		switch r.State {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			verdicts[r.User.Login] = r.State
+		}
+	}
+	for _, v := range verdicts {
+		if v == "CHANGES_REQUESTED" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// commitResponse holds the fields needed to determine merge method and
+// the commit's GitHub author. Author is null when the commit's email
+// belongs to no GitHub account.
 type commitResponse struct {
 	Parents []struct {
 		SHA string `json:"sha"`
 	} `json:"parents"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
 }
 
 // PRMergeMethod determines how a PR was merged by inspecting the merge commit.
@@ -171,6 +325,9 @@ func (c *Client) PRMergeMethod(owner, repo, mergeCommitSHA string) (string, erro
 	if mergeCommitSHA == "" {
 		return "", nil
 	}
+	if err := c.checkOrg(owner); err != nil {
+		return "", err
+	}
 
 	var resp commitResponse
 	err := c.rest.Get(fmt.Sprintf("repos/%s/%s/commits/%s", owner, repo, mergeCommitSHA), &resp)
@@ -184,31 +341,196 @@ func (c *Client) PRMergeMethod(owner, repo, mergeCommitSHA string) (string, erro
 	return "squash", nil
 }
 
-// sshRemoteRe matches SSH-style GitHub remote URLs:
-//
-//	git@github.com:owner/repo.git
-var sshRemoteRe = regexp.MustCompile(`^git@github\.com:([^/]+)/([^/]+?)(?:\.git)?$`)
+// CommitAuthorLogin returns the login of the GitHub account that the
+// author email of sha is linked to, or "" when it is linked to none.
+func (c *Client) CommitAuthorLogin(owner, repo, sha string) (string, error) {
+	if c.rest == nil {
+		return "", fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return "", err
+	}
 
-// ParseGitHubRemote extracts owner and repo from a GitHub remote URL.
-// Supports both SSH (git@github.com:owner/repo.git) and HTTPS
-// (https://github.com/owner/repo.git) formats.
-func ParseGitHubRemote(url string) (owner, repo string, ok bool) {
-	// Try SSH format first.
-	if m := sshRemoteRe.FindStringSubmatch(url); m != nil {
-		return m[1], m[2], true
-	}
-
-	// Try HTTPS format.
-	url = strings.TrimSuffix(url, ".git")
-	for _, prefix := range []string{"https://github.com/", "http://github.com/"} {
-		if strings.HasPrefix(url, prefix) {
-			rest := strings.TrimPrefix(url, prefix)
-			parts := strings.SplitN(rest, "/", 3)
-			if len(parts) >= 2 && parts[0] != "" && parts[1] != "" {
-				return parts[0], parts[1], true
+	var resp commitResponse
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s/commits/%s", owner, repo, sha), &resp); err != nil {
+		return "", fmt.Errorf("querying commit %s for %s/%s: %w", sha, owner, repo, err)
+	}
+	if resp.Author == nil {
+		return "", nil
+	}
+	return resp.Author.Login, nil
+}
+
+// Issue is a GitHub issue.
+type Issue struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"` // "open" or "closed"
+	// User is the account that filed the issue.
+	User userResponse `json:"user"`
+	// PullRequest is set when the issues API returns a pull request.
+	PullRequest *struct{} `json:"pull_request,omitempty"`
+}
+
+// issueRequest is the body of an issue create or update.
+type issueRequest struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// maxIssuePages caps how many pages of open issues FindIssue reads.
+const maxIssuePages = 10
+
+// FindIssue returns the open issue in owner/repo filed by author whose
+// body contains marker, or nil when there is none, so issues other people
+// filed with the same marker are left alone. The org filter does not
+// apply: the repository is named by the user rather than discovered
+// locally.
+func (c *Client) FindIssue(owner, repo, author, marker string) (*Issue, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("no GitHub API client available")
+	}
+	for page := 1; page <= maxIssuePages; page++ {
+		var issues []Issue
+		err := c.rest.Get(fmt.Sprintf("repos/%s/%s/issues?state=open&creator=%s&per_page=100&page=%d", owner, repo, url.QueryEscape(author), page), &issues)
+		if err != nil {
+			return nil, fmt.Errorf("listing issues in %s/%s: %w", owner, repo, err)
+		}
+		for _, issue := range issues {
+			if issue.PullRequest == nil && strings.EqualFold(issue.User.Login, author) && strings.Contains(issue.Body, marker) {
+				return &issue, nil
 			}
 		}
+		if len(issues) < 100 {
+			break
+		}
+	}
+	return nil, nil
+}
+
+// GetIssue returns issue number in owner/repo. Repositories outside the
+// org filter are not queried.
+func (c *Client) GetIssue(owner, repo string, number int) (*Issue, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return nil, err
+	}
+	var issue Issue
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s/issues/%d", owner, repo, number), &issue); err != nil {
+		return nil, fmt.Errorf("querying issue #%d in %s/%s: %w", number, owner, repo, err)
+	}
+	return &issue, nil
+}
+
+// CreateIssue opens an issue in owner/repo.
+func (c *Client) CreateIssue(owner, repo, title, body string) (*Issue, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("no GitHub API client available")
+	}
+	payload, err := json.Marshal(issueRequest{Title: title, Body: body})
+	if err != nil {
+		return nil, err
+	}
+	var issue Issue
+	if err := c.rest.Post(fmt.Sprintf("repos/%s/%s/issues", owner, repo), bytes.NewReader(payload), &issue); err != nil {
+		return nil, fmt.Errorf("creating issue in %s/%s: %w", owner, repo, err)
+	}
+	return &issue, nil
+}
+
+// UpdateIssue replaces the title and body of an existing issue.
+func (c *Client) UpdateIssue(owner, repo string, number int, title, body string) (*Issue, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("no GitHub API client available")
+	}
+	payload, err := json.Marshal(issueRequest{Title: title, Body: body})
+	if err != nil {
+		return nil, err
+	}
+	var issue Issue
+	if err := c.rest.Patch(fmt.Sprintf("repos/%s/%s/issues/%d", owner, repo, number), bytes.NewReader(payload), &issue); err != nil {
+		return nil, fmt.Errorf("updating issue #%d in %s/%s: %w", number, owner, repo, err)
+	}
+	return &issue, nil
+}
+
+// githubHosts are the host names that serve github.com repositories.
+// ssh.github.com is the SSH-over-port-443 endpoint.
+var githubHosts = map[string]bool{
+	"github.com":     true,
+	"www.github.com": true,
+	"ssh.github.com": true,
+}
+
+// sshHostname resolves SSH host aliases; replaced in tests.
+var sshHostname = git.SSHHostname
+
+// ParseGitHubRemote extracts owner and repo from a GitHub remote URL.
+// Supports scp-like SSH (git@github.com:owner/repo.git), ssh:// and
+// git+ssh:// URLs (ssh://git@github.com/owner/repo), and HTTPS
+// (https://github.com/owner/repo.git). The host of an SSH remote may be a
+// Host alias from ~/.ssh/config whose HostName is github.com, e.g.
+// git@gh-work:owner/repo.git.
+func ParseGitHubRemote(url string) (owner, repo string, ok bool) {
+	host, path, isSSH := splitRemote(url)
+	if host == "" {
+		return "", "", false
+	}
+	if !githubHosts[host] && isSSH {
+		host = sshHostname(host)
+	}
+	if !githubHosts[host] {
+		return "", "", false
 	}
 
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || (isSSH && len(parts) != 2) {
+		return "", "", false
+	}
+	owner, repo = parts[0], strings.TrimSuffix(parts[1], ".git")
+	if owner == "" || repo == "" {
+		return "", "", false
+	}
+	return owner, repo, true
+}
+
+// ParseGitHubRemotes returns the owner and repo of the first GitHub remote
+// URL in urls, as returned by git.RemoteURLs.
+func ParseGitHubRemotes(urls []string) (owner, repo string, ok bool) {
+	for _, u := range urls {
+		if owner, repo, ok = ParseGitHubRemote(u); ok {
+			return owner, repo, true
+		}
+	}
 	return "", "", false
 }
+
+// splitRemote splits a remote URL into its lowercased host, without user
+// or port, and its path. isSSH reports whether the remote is reached over
+// SSH, where the host may be an alias. host is "" for local paths and
+// unsupported schemes.
+func splitRemote(remoteURL string) (host, path string, isSSH bool) {
+	if scheme, rest, found := strings.Cut(remoteURL, "://"); found {
+		switch strings.ToLower(scheme) {
+		case "ssh", "git+ssh", "ssh+git":
+			isSSH = true
+		case "https", "http", "git":
+		default:
+			return "", "", false
+		}
+		authority, path, _ := strings.Cut(rest, "/")
+		return git.URLHost(strings.ToLower(scheme) + "://" + authority), path, isSSH
+	}
+
+	// scp-like syntax: [user@]host:path. Git treats a colon after a slash
+	// as part of a local path.
+	colon := strings.IndexByte(remoteURL, ':')
+	if colon <= 0 || strings.Contains(remoteURL[:colon], "/") {
+		return "", "", false
+	}
+	return git.URLHost(remoteURL), remoteURL[colon+1:], true
+}
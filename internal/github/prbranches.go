@@ -0,0 +1,217 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// PRBranch is the head branch of one of the user's closed or merged pull
+// requests.
+type PRBranch struct {
+	// Owner and Repo name the repository the pull request was opened
+	// against.
+	Owner  string
+	Repo   string
+	Number int
+	State  PRState // PRStateMerged or PRStateClosed
+	// ClosedAt is when the pull request was merged or closed.
+	ClosedAt time.Time
+	URL      string
+	// HeadOwner and HeadRepo name the repository holding the branch,
+	// which differs from Owner and Repo for a pull request from a fork.
+	// They and Branch and SHA are set by ResolvePRBranch.
+	HeadOwner string
+	HeadRepo  string
+	Branch    string
+	// SHA is the pull request's head commit.
+	SHA string
+}
+
+// FullName returns the branch as owner/repo:branch.
+func (b PRBranch) FullName() string {
+	return fmt.Sprintf("%s/%s:%s", b.HeadOwner, b.HeadRepo, b.Branch)
+}
+
+// userResponse holds the fields we care about from GET /user.
+type userResponse struct {
+	Login string `json:"login"`
+}
+
+// CurrentUser returns the login of the authenticated user.
+func (c *Client) CurrentUser() (string, error) {
+	if c.rest == nil {
+		return "", fmt.Errorf("no GitHub API client available")
+	}
+	var resp userResponse
+	if err := c.rest.Get("user", &resp); err != nil {
+		return "", fmt.Errorf("looking up the authenticated user: %w", err)
+	}
+	if resp.Login == "" {
+		return "", fmt.Errorf("looking up the authenticated user: not authenticated")
+	}
+	return resp.Login, nil
+}
+
+// searchResponse holds the fields we care about from GET /search/issues.
+type searchResponse struct {
+	Items []struct {
+		Number        int    `json:"number"`
+		HTMLURL       string `json:"html_url"`
+		RepositoryURL string `json:"repository_url"`
+		ClosedAt      string `json:"closed_at"`
+		PullRequest   struct {
+			MergedAt string `json:"merged_at"`
+		} `json:"pull_request"`
+	} `json:"items"`
+}
+
+// maxSearchPages caps how many pages of search results ClosedPRs reads;
+// the search API returns at most 1000 results anyway.
+const maxSearchPages = 10
+
+// ClosedPRs returns the closed and merged pull requests opened by author,
+// most recently updated first. When owners is non-empty only repositories
+// of those users and orgs are searched. Repositories outside the org
+// filter are left out.
+func (c *Client) ClosedPRs(author string, owners []string) ([]PRBranch, error) {
+	if c.rest == nil {
+		return nil, fmt.Errorf("no GitHub API client available")
+	}
+	query := "is:pr is:closed author:" + author
+	for _, o := range owners {
+		query += " user:" + o
+	}
+
+	var prs []PRBranch
+	for page := 1; page <= maxSearchPages; page++ {
+		var resp searchResponse
+		err := c.rest.Get(fmt.Sprintf("search/issues?q=%s&sort=updated&order=desc&per_page=100&page=%d", url.QueryEscape(query), page), &resp)
+		if err != nil {
+			return nil, fmt.Errorf("searching pull requests by %s: %w", author, err)
+		}
+		for _, item := range resp.Items {
+			parts := strings.Split(item.RepositoryURL, "/")
+			if len(parts) < 2 {
+				continue
+			}
+			owner, repo := parts[len(parts)-2], parts[len(parts)-1]
+			if !c.AllowsOrg(owner) {
+				continue
+			}
+			pr := PRBranch{Owner: owner, Repo: repo, Number: item.Number, URL: item.HTMLURL, State: PRStateClosed}
+			if item.PullRequest.MergedAt != "" {
+				pr.State = PRStateMerged
+			}
+			if t, err := time.Parse(time.RFC3339, item.ClosedAt); err == nil {
+				pr.ClosedAt = t
+			}
+			prs = append(prs, pr)
+		}
+		if len(resp.Items) < 100 {
+			break
+		}
+	}
+	return prs, nil
+}
+
+// pullResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/pulls/{number}.
+type pullResponse struct {
+	Head struct {
+		Ref  string `json:"ref"`
+		SHA  string `json:"sha"`
+		Repo *struct {
+			Name          string `json:"name"`
+			DefaultBranch string `json:"default_branch"`
+			Owner         struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+// refResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/git/ref/{ref}.
+type refResponse struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+// ResolvePRBranch fills in the head branch of a closed pull request and
+// reports whether it is left over: it still exists, still points at the
+// pull request's head commit, is not its repository's default branch,
+// and no open pull request uses it.
+func (c *Client) ResolvePRBranch(pr *PRBranch) (bool, error) {
+	if c.rest == nil {
+		return false, fmt.Errorf("no GitHub API client available")
+	}
+
+	var pull pullResponse
+	if err := c.rest.Get(fmt.Sprintf("repos/%s/%s/pulls/%d", pr.Owner, pr.Repo, pr.Number), &pull); err != nil {
+		return false, fmt.Errorf("querying %s/%s#%d: %w", pr.Owner, pr.Repo, pr.Number, err)
+	}
+	head := pull.Head.Repo
+	if head == nil || pull.Head.Ref == head.DefaultBranch || !c.AllowsOrg(head.Owner.Login) {
+		// The fork was deleted, or the branch is one to keep.
+		return false, nil
+	}
+	pr.HeadOwner, pr.HeadRepo = head.Owner.Login, head.Name
+	pr.Branch, pr.SHA = pull.Head.Ref, pull.Head.SHA
+
+	var ref refResponse
+	err := c.rest.Get(fmt.Sprintf("repos/%s/%s/git/ref/heads/%s", pr.HeadOwner, pr.HeadRepo, escapeRef(pr.Branch)), &ref)
+	if isNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("querying branch %s: %w", pr.FullName(), err)
+	}
+	if ref.Object.SHA != pr.SHA {
+		// Pushed to since the pull request was closed.
+		return false, nil
+	}
+
+	var open []struct{}
+	err = c.rest.Get(fmt.Sprintf("repos/%s/%s/pulls?head=%s:%s&state=open&per_page=1",
+		pr.Owner, pr.Repo, pr.HeadOwner, url.QueryEscape(pr.Branch)), &open)
+	if err != nil {
+		return false, fmt.Errorf("querying open pull requests for %s: %w", pr.FullName(), err)
+	}
+	return len(open) == 0, nil
+}
+
+// DeleteBranch deletes a branch from a GitHub repository.
+func (c *Client) DeleteBranch(owner, repo, branch string) error {
+	if c.rest == nil {
+		return fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return err
+	}
+	if err := c.rest.Delete(fmt.Sprintf("repos/%s/%s/git/refs/heads/%s", owner, repo, escapeRef(branch)), nil); err != nil {
+		return fmt.Errorf("deleting %s/%s:%s: %w", owner, repo, branch, err)
+	}
+	return nil
+}
+
+// escapeRef escapes each path segment of a branch name for a URL path.
+func escapeRef(branch string) string {
+	parts := strings.Split(branch, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+// isNotFound reports whether an API call failed with 404 Not Found.
+func isNotFound(err error) bool {
+	var httpErr *api.HTTPError
+	return errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,118 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+// redirect sends every request to a test server.
+type redirect struct{ target *url.URL }
+
+func (r redirect) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = r.target.Scheme, r.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a Client whose API calls are served by handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL)
+	rest, err := api.NewRESTClient(api.ClientOptions{Host: "github.com", AuthToken: "test", Transport: redirect{target}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{rest: rest}
+}
+
+func TestClosedPRBranches(t *testing.T) {
+	refs := map[string]string{
+		"feature/done": "aaa",
+		"moved-on":     "new",
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/user", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"login": "me"}`)
+	})
+	mux.HandleFunc("/search/issues", func(w http.ResponseWriter, r *http.Request) {
+		if q := r.URL.Query().Get("q"); q != "is:pr is:closed author:me user:acme" {
+			t.Errorf("unexpected query %q", q)
+		}
+		fmt.Fprint(w, `{"items": [
+			{"number": 1, "repository_url": "https://api.github.com/repos/acme/app", "closed_at": "2026-01-02T03:04:05Z", "pull_request": {"merged_at": "2026-01-02T03:04:05Z"}},
+			{"number": 2, "repository_url": "https://api.github.com/repos/acme/app", "closed_at": "2026-01-03T00:00:00Z", "pull_request": {}},
+			{"number": 3, "repository_url": "https://api.github.com/repos/acme/app", "closed_at": "2026-01-04T00:00:00Z", "pull_request": {}},
+			{"number": 4, "repository_url": "https://api.github.com/repos/mirror/app", "closed_at": "2026-01-05T00:00:00Z", "pull_request": {}}
+		]}`)
+	})
+	mux.HandleFunc("/repos/acme/app/pulls/", func(w http.ResponseWriter, r *http.Request) {
+		branches := map[string]string{"/repos/acme/app/pulls/1": "feature/done", "/repos/acme/app/pulls/2": "gone", "/repos/acme/app/pulls/3": "moved-on"}
+		fmt.Fprintf(w, `{"head": {"ref": %q, "sha": "aaa", "repo": {"name": "app", "default_branch": "main", "owner": {"login": "acme"}}}}`, branches[r.URL.Path])
+	})
+	mux.HandleFunc("/repos/acme/app/git/ref/heads/", func(w http.ResponseWriter, r *http.Request) {
+		sha, ok := refs[r.URL.Path[len("/repos/acme/app/git/ref/heads/"):]]
+		if !ok {
+			http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"object": {"sha": %q}}`, sha)
+	})
+	mux.HandleFunc("/repos/acme/app/pulls", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/repos/acme/app/git/refs/heads/feature/done", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expected DELETE, got %s", r.Method)
+		}
+		delete(refs, "feature/done")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	c := newTestClient(t, mux).WithOrgFilter(nil, []string{"mirror"})
+
+	login, err := c.CurrentUser()
+	if err != nil || login != "me" {
+		t.Fatalf("CurrentUser = %q, %v", login, err)
+	}
+	prs, err := c.ClosedPRs(login, []string{"acme"})
+	if err != nil {
+		t.Fatalf("ClosedPRs: %v", err)
+	}
+	if len(prs) != 3 {
+		t.Fatalf("expected the denied owner's PR left out, got %d PRs", len(prs))
+	}
+	if prs[0].State != PRStateMerged || prs[1].State != PRStateClosed || prs[0].ClosedAt.IsZero() {
+		t.Errorf("unexpected states %v and %v", prs[0], prs[1])
+	}
+
+	var left []string
+	for i := range prs {
+		ok, err := c.ResolvePRBranch(&prs[i])
+		if err != nil {
+			t.Fatalf("ResolvePRBranch(#%d): %v", prs[i].Number, err)
+		}
+		if ok {
+			left = append(left, prs[i].FullName())
+		}
+	}
+	// #2's branch is gone and #3's has moved on since the PR closed.
+	if len(left) != 1 || left[0] != "acme/app:feature/done" {
+		t.Errorf("expected only acme/app:feature/done left over, got %v", left)
+	}
+
+	if err := c.DeleteBranch("acme", "app", "feature/done"); err != nil {
+		t.Fatalf("DeleteBranch: %v", err)
+	}
+	if _, ok := refs["feature/done"]; ok {
+		t.Error("expected the branch to be deleted")
+	}
+	if err := c.DeleteBranch("mirror", "app", "x"); err == nil {
+		t.Error("expected the org filter to block deletion")
+	}
+}
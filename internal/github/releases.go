@@ -0,0 +1,31 @@
+package github
+
+import "fmt"
+
+// releaseResponse holds the fields we care about from GET
+// /repos/{owner}/{repo}/releases/latest.
+type releaseResponse struct {
+	TagName string `json:"tag_name"`
+}
+
+// LatestRelease returns the tag of the repository's latest published
+// release, which GitHub takes to be the newest non-draft, non-prerelease
+// one. It returns "" when the repository has no releases.
+func (c *Client) LatestRelease(owner, repo string) (string, error) {
+	if c.rest == nil {
+		return "", fmt.Errorf("no GitHub API client available")
+	}
+	if err := c.checkOrg(owner); err != nil {
+		return "", err
+	}
+
+	var resp releaseResponse
+	err := c.rest.Get(fmt.Sprintf("repos/%s/%s/releases/latest", owner, repo), &resp)
+	if isNotFound(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("querying latest release of %s/%s: %w", owner, repo, err)
+	}
+	return resp.TagName, nil
+}
@@ -0,0 +1,25 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestLatestRelease(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/acme/tool/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"tag_name": "v2.1.0", "name": "Tool 2.1"}`)
+	})
+	mux.HandleFunc("/repos/acme/unreleased/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+	})
+	c := newTestClient(t, mux)
+
+	if tag, err := c.LatestRelease("acme", "tool"); err != nil || tag != "v2.1.0" {
+		t.Errorf("expected v2.1.0, got %q, %v", tag, err)
+	}
+	if tag, err := c.LatestRelease("acme", "unreleased"); err != nil || tag != "" {
+		t.Errorf("expected no release, got %q, %v", tag, err)
+	}
+}
@@ -0,0 +1,270 @@
+// Package ignore persists the repositories, branches, and directories the
+// user has asked not to be offered for cleanup again, either for good or,
+// when snoozed, until a given time. Repositories and branches are also
+// recorded by their repository's fingerprint, so an entry follows a repo
+// that is moved or cloned again elsewhere.
+package ignore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+// Kind is what an Entry ignores.
+type Kind string
+
+// Entry kinds.
+const (
+	// KindRepo keeps a repository out of the repos command's suggestions.
+	KindRepo Kind = "repo"
+	// KindBranch keeps a branch out of merged and stale branch cleanup.
+	KindBranch Kind = "branch"
+	// KindDir keeps a directory out of non-git and artifact audits.
+	KindDir Kind = "dir"
+	// KindHealth keeps a repository's fixes out of audit --health.
+	KindHealth Kind = "health"
+	// KindLFSPrune keeps a repository out of audit --large's offer to run
+	// git lfs prune.
+	KindLFSPrune Kind = "lfs_prune"
+)
+
+// Entry is one ignored repository, branch, directory, or repository check.
+type Entry struct {
+	Kind Kind `json:"kind"`
+	// Path is the repository or directory path.
+	Path string `json:"path"`
+	// Branch is the branch name, for KindBranch.
+	Branch string `json:"branch,omitempty"`
+	// Fingerprint identifies the repository independently of its path;
+	// empty for directories and repos without one.
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	Added       time.Time `json:"added"`
+	// Until is when a snoozed entry expires; zero ignores it for good.
+	Until time.Time `json:"until,omitzero"`
+}
+
+// active reports whether e still hides its item at now.
+func (e Entry) active(now time.Time) bool {
+	return e.Until.IsZero() || now.Before(e.Until)
+}
+
+// List is the persisted ignore list. A nil *List ignores nothing, so
+// callers can use it when the file could not be read.
+type List struct {
+	Entries []Entry `json:"entries"`
+
+	path string
+	// set maps each entry, keyed by path without its timestamps, to its
+	// position in Entries; byFingerprint does the same keyed by
+	// fingerprint, for entries that have one.
+	set           map[Entry]int
+	byFingerprint map[Entry]int
+	// fingerprint is set by SetFingerprint; fingerprints caches its
+	// results by repository path.
+	fingerprint  func(repoPath string) string
+	fingerprints map[string]string
+}
+
+// DefaultPath returns the ignore file in the XDG state directory:
+// $XDG_STATE_HOME/katazuke/ignore.json, or
+// ~/.local/state/katazuke/ignore.json.
+func DefaultPath() (string, error) {
+	path, err := state.Path(state.IgnoreList)
+	if err != nil {
+		return "", fmt.Errorf("ignore list: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads the ignore list at path. A missing file is an empty list.
+func Load(path string) (*List, error) {
+	l := &List{path: path}
+	// #nosec G304 - path is the katazuke ignore file
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("ignore list: read %s: %w", path, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, l); err != nil {
+			return nil, fmt.Errorf("ignore list: parse %s: %w", path, err)
+		}
+	}
+	l.index()
+	return l, nil
+}
+
+// Path returns the file the list is stored in, or "" for a nil List.
+func (l *List) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// SetFingerprint makes the list record repositories and branches with
+// fn's fingerprint of their repository, such as a hash of its remote URL,
+// and match them by it when their path does not match. fn returns "" for
+// a repository without one.
+func (l *List) SetFingerprint(fn func(repoPath string) string) {
+	if l == nil {
+		return
+	}
+	l.fingerprint = fn
+	l.fingerprints = make(map[string]string)
+}
+
+// repoFingerprint returns the fingerprint of the repository at path, or
+// "" when there is no fingerprint function or the repository has none.
+func (l *List) repoFingerprint(path string) string {
+	if l.fingerprint == nil {
+		return ""
+	}
+	fp, ok := l.fingerprints[path]
+	if !ok {
+		fp = l.fingerprint(path)
+		l.fingerprints[path] = fp
+	}
+	return fp
+}
+
+func (l *List) index() {
+	l.set = make(map[Entry]int, len(l.Entries))
+	l.byFingerprint = make(map[Entry]int)
+	for i, e := range l.Entries {
+		l.set[key(e)] = i
+		if e.Fingerprint != "" {
+			l.byFingerprint[fingerprintKey(e)] = i
+		}
+	}
+}
+
+// key is an entry by path, without its fingerprint and timestamps, for
+// lookups.
+func key(e Entry) Entry {
+	return Entry{Kind: e.Kind, Path: filepath.Clean(e.Path), Branch: e.Branch}
+}
+
+// fingerprintKey is an entry by fingerprint, without its path and
+// timestamps, for lookups.
+func fingerprintKey(e Entry) Entry {
+	return Entry{Kind: e.Kind, Fingerprint: e.Fingerprint, Branch: e.Branch}
+}
+
+// find returns the position of the entry matching e by path, or else by
+// fingerprint.
+func (l *List) find(e Entry) (int, bool) {
+	if i, ok := l.set[key(e)]; ok {
+		return i, true
+	}
+	if e.Fingerprint == "" {
+		return 0, false
+	}
+	i, ok := l.byFingerprint[fingerprintKey(e)]
+	return i, ok
+}
+
+// HasRepo reports whether the repository at path is ignored.
+func (l *List) HasRepo(path string) bool {
+	return l.has(Entry{Kind: KindRepo, Path: path})
+}
+
+// HasBranch reports whether branch of the repository at repoPath is
+// ignored.
+func (l *List) HasBranch(repoPath, branch string) bool {
+	return l.has(Entry{Kind: KindBranch, Path: repoPath, Branch: branch})
+}
+
+// HasDir reports whether the directory at path is ignored.
+func (l *List) HasDir(path string) bool {
+	return l.has(Entry{Kind: KindDir, Path: path})
+}
+
+// HasHealth reports whether audit --health leaves the fixes of the
+// repository at path alone.
+func (l *List) HasHealth(path string) bool {
+	return l.has(Entry{Kind: KindHealth, Path: path})
+}
+
+// HasLFSPrune reports whether audit --large leaves the repository at path
+// out of its git lfs prune offer.
+func (l *List) HasLFSPrune(path string) bool {
+	return l.has(Entry{Kind: KindLFSPrune, Path: path})
+}
+
+// has reports whether e's item is hidden. The repository's fingerprint
+// is only computed when the path does not match and some entry has one.
+func (l *List) has(e Entry) bool {
+	if l == nil {
+		return false
+	}
+	if e.Kind != KindDir && len(l.byFingerprint) > 0 {
+		if _, ok := l.set[key(e)]; !ok {
+			e.Fingerprint = l.repoFingerprint(e.Path)
+		}
+	}
+	i, ok := l.find(e)
+	return ok && l.Entries[i].active(time.Now())
+}
+
+// Add adds the entries to the list, stamped with now, and saves it.
+// Repositories and branches are recorded with their fingerprint. An entry
+// already in the list, by path or fingerprint, takes the new entry's path
+// and Until, so an item can be snoozed again or ignored for good. Expired
+// snoozes are dropped.
+func (l *List) Add(now time.Time, entries ...Entry) error {
+	if l == nil {
+		return errors.New("ignore list: not loaded")
+	}
+	for _, e := range entries {
+		k := key(e)
+		if e.Kind != KindDir && e.Fingerprint == "" {
+			e.Fingerprint = l.repoFingerprint(e.Path)
+		}
+		k.Fingerprint, k.Added, k.Until = e.Fingerprint, now, e.Until
+		if i, ok := l.find(e); ok {
+			l.Entries[i] = k
+		} else {
+			l.Entries = append(l.Entries, k)
+		}
+		l.index()
+	}
+	l.Entries = slices.DeleteFunc(l.Entries, func(e Entry) bool { return !e.active(now) })
+	l.index()
+	return l.save()
+}
+
+// save writes the list, replacing the file atomically.
+func (l *List) save() error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return fmt.Errorf("ignore list: encode: %w", err)
+	}
+	dir := filepath.Dir(l.path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("ignore list: create directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("ignore list: save: %w", err)
+	}
+	tmp := f.Name()
+	_, werr := f.Write(data)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp, l.path)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("ignore list: save: %w", werr)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListAddAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "ignore.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if l.HasRepo("/p/api") {
+		t.Fatal("expected an empty list")
+	}
+
+	now := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	err = l.Add(now,
+		Entry{Kind: KindRepo, Path: "/p/api/"},
+		Entry{Kind: KindBranch, Path: "/p/web", Branch: "experiment"},
+		Entry{Kind: KindDir, Path: "/p/scans"},
+		Entry{Kind: KindHealth, Path: "/p/old"},
+		Entry{Kind: KindLFSPrune, Path: "/p/assets"},
+		Entry{Kind: KindRepo, Path: "/p/api"},
+	)
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if len(l.Entries) != 5 {
+		t.Errorf("expected duplicates to be dropped, got %+v", l.Entries)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reloaded.HasRepo("/p/api") || !reloaded.HasBranch("/p/web", "experiment") || !reloaded.HasDir("/p/scans") ||
+		!reloaded.HasHealth("/p/old") || !reloaded.HasLFSPrune("/p/assets") {
+		t.Errorf("entries were not persisted: %+v", reloaded.Entries)
+	}
+	if reloaded.HasBranch("/p/web", "main") || reloaded.HasDir("/p/api") || reloaded.HasHealth("/p/assets") || !reloaded.Entries[0].Added.Equal(now) {
+		t.Errorf("unexpected matches or timestamps: %+v", reloaded.Entries)
+	}
+}
+
+func TestNilList(t *testing.T) {
+	var l *List
+	if l.HasRepo("/p/api") || l.Path() != "" {
+		t.Error("a nil list ignores nothing")
+	}
+	if err := l.Add(time.Now(), Entry{Kind: KindRepo, Path: "/p/api"}); err == nil {
+		t.Error("expected adding to a nil list to fail")
+	}
+}
+
+func TestLoadRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.json")
+	if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected an error for a corrupt file")
+	}
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	if got, _ := DefaultPath(); got != filepath.Join("/state", "katazuke", "ignore.json") {
+		t.Errorf("got %q", got)
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	if got, _ := DefaultPath(); got != filepath.Join(home, ".local", "state", "katazuke", "ignore.json") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestSnooze(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.json")
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	err = l.Add(now,
+		Entry{Kind: KindBranch, Path: "/p/api", Branch: "spike", Until: now.AddDate(0, 0, 7)},
+		Entry{Kind: KindDir, Path: "/p/old", Until: now.Add(-time.Minute)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.HasBranch("/p/api", "spike") {
+		t.Error("expected the snoozed branch to be hidden")
+	}
+	if l.HasDir("/p/old") || len(l.Entries) != 1 {
+		t.Errorf("expected the expired snooze to be dropped, got %+v", l.Entries)
+	}
+
+	// Ignoring a snoozed item for good replaces the snooze.
+	if err := l.Add(now, Entry{Kind: KindBranch, Path: "/p/api", Branch: "spike"}); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Entries) != 1 || !reloaded.Entries[0].Until.IsZero() {
+		t.Errorf("expected one permanent entry, got %+v", reloaded.Entries)
+	}
+}
+
+func TestFingerprintFollowsMovedRepos(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ignore.json")
+	fingerprints := map[string]string{"/p/api": "fp-api", "/q/api": "fp-api", "/p/web": "fp-web"}
+	calls := 0
+	fingerprint := func(repoPath string) string {
+		calls++
+		return fingerprints[repoPath]
+	}
+
+	l, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.SetFingerprint(fingerprint)
+	if l.HasRepo("/p/api") || calls != 0 {
+		t.Fatalf("expected no fingerprint lookups without fingerprinted entries, got %d", calls)
+	}
+	err = l.Add(time.Now(),
+		Entry{Kind: KindRepo, Path: "/p/api"},
+		Entry{Kind: KindBranch, Path: "/p/api", Branch: "spike"},
+		Entry{Kind: KindDir, Path: "/p/api/node_modules"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The repo was cloned again under /q.
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.SetFingerprint(fingerprint)
+	if !reloaded.HasRepo("/q/api") || !reloaded.HasBranch("/q/api", "spike") {
+		t.Errorf("expected the entries to follow the repo's fingerprint: %+v", reloaded.Entries)
+	}
+	if reloaded.HasRepo("/p/web") || reloaded.HasBranch("/q/api", "main") || reloaded.HasDir("/q/api/node_modules") {
+		t.Errorf("unexpected matches: %+v", reloaded.Entries)
+	}
+
+	// Snoozing it again at its new path updates the entry rather than
+	// adding one.
+	if err := reloaded.Add(time.Now(), Entry{Kind: KindRepo, Path: "/q/api"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.Entries) != 3 || reloaded.Entries[0].Path != "/q/api" {
+		t.Errorf("expected the repo entry to move to /q/api, got %+v", reloaded.Entries)
+	}
+}
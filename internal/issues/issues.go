@@ -0,0 +1,136 @@
+// Package issues recognizes issue tracker keys embedded in branch names,
+// e.g. ABC-123 in "ABC-123-fix-login" or #42 in "gh-42-typo", and looks
+// up whether the issue is done.
+package issues
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// trackerKey matches a Jira-style key that is not part of a longer
+	// word or number, e.g. ABC-123 but not xABC-123 or ABC-123x.
+	trackerKey = regexp.MustCompile(`(?:^|[^A-Za-z0-9])([A-Za-z][A-Za-z0-9]*)-([1-9][0-9]*)(?:$|[^A-Za-z0-9])`)
+	// githubRef matches a GitHub issue reference such as gh-42, issue/42,
+	// or #42.
+	githubRef = regexp.MustCompile(`(?i)(?:^|[^A-Za-z0-9])(?:(?:gh|issues?)[-_/#]?|#)([1-9][0-9]*)(?:$|[^A-Za-z0-9])`)
+)
+
+// Extract returns the issue key in a branch name, or "" when there is
+// none. Tracker keys are uppercased, e.g. "ABC-123", and GitHub
+// references are returned as "#42". Uppercase keys are recognized for
+// any project; lowercase ones only for the given projects, since many
+// lowercase words followed by a number (e.g. "step-3") are not keys.
+func Extract(branch string, projects []string) string {
+	for _, m := range trackerKey.FindAllStringSubmatch(branch, -1) {
+		project := m[1]
+		if githubPrefixes[strings.ToLower(project)] {
+			continue
+		}
+		if project == strings.ToUpper(project) || slices.ContainsFunc(projects, func(p string) bool { return strings.EqualFold(p, project) }) {
+			return strings.ToUpper(project) + "-" + m[2]
+		}
+	}
+	if m := githubRef.FindStringSubmatch(branch); m != nil {
+		return "#" + m[1]
+	}
+	return ""
+}
+
+// githubPrefixes mark GitHub issue references rather than tracker
+// projects.
+var githubPrefixes = map[string]bool{"gh": true, "issue": true, "issues": true}
+
+// GitHubNumber returns the issue number of a "#42" key, or 0 when key is
+// not a GitHub reference.
+func GitHubNumber(key string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(key, "#"))
+	if !strings.HasPrefix(key, "#") || err != nil {
+		return 0
+	}
+	return n
+}
+
+// Status is where an issue stands in its tracker.
+type Status struct {
+	// Name is the tracker's name for the status, e.g. "In Progress" or
+	// "closed".
+	Name string
+	// Done is true when the issue is resolved, which makes deleting its
+	// branches an easy call.
+	Done bool
+}
+
+// Jira looks up issues through the Jira REST API.
+type Jira struct {
+	baseURL string
+	email   string
+	token   string
+	client  *http.Client
+}
+
+// NewJira returns a client for the Jira site at baseURL. With an email
+// the token is sent as a Jira Cloud API token; without, as a personal
+// access token.
+func NewJira(baseURL, email, token string) *Jira {
+	return &Jira{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		email:   email,
+		token:   token,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// jiraIssueResponse holds the fields we care about from GET
+// /rest/api/2/issue/{key}.
+type jiraIssueResponse struct {
+	Fields struct {
+		Status struct {
+			Name           string `json:"name"`
+			StatusCategory struct {
+				Key string `json:"key"`
+			} `json:"statusCategory"`
+		} `json:"status"`
+	} `json:"fields"`
+}
+
+// Status returns the status of the issue with the given key. Issues in
+// the "done" status category count as done, whatever their workflow
+// calls the status.
+func (j *Jira) Status(key string) (Status, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/rest/api/2/issue/%s?fields=status", j.baseURL, url.PathEscape(key)), nil)
+	if err != nil {
+		return Status{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	switch {
+	case j.email != "":
+		req.SetBasicAuth(j.email, j.token)
+	case j.token != "":
+		req.Header.Set("Authorization", "Bearer "+j.token)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return Status{}, fmt.Errorf("querying %s: %w", key, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("querying %s: %s", key, resp.Status)
+	}
+
+	var issue jiraIssueResponse
+	if err := json.NewDecoder(resp.Body).Decode(&issue); err != nil {
+		return Status{}, fmt.Errorf("decoding %s: %w", key, err)
+	}
+	status := issue.Fields.Status
+	return Status{Name: status.Name, Done: status.StatusCategory.Key == "done"}, nil
+}
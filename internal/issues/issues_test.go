@@ -0,0 +1,78 @@
+package issues
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		branch string
+		want   string
+	}{
+		{"ABC-123-fix-login", "ABC-123"},
+		{"feature/ABC-123", "ABC-123"},
+		{"feature/abc-123-fix", "ABC-123"},
+		{"bugfix/OPS2-7_retry", "OPS2-7"},
+		{"xyz-9-lowercase", ""},
+		{"ABC-123x", ""},
+		{"ABC-0", ""},
+		{"gh-42-typo", "#42"},
+		{"issue/42", "#42"},
+		{"fix-#7", "#7"},
+		{"ABC-12-gh-3", "ABC-12"},
+		{"release-2", ""},
+		{"dependabot/npm_and_yarn/lodash-4.17.21", ""},
+		{"main", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.branch, func(t *testing.T) {
+			if got := Extract(tt.branch, []string{"abc"}); got != tt.want {
+				t.Errorf("Extract(%q) = %q, want %q", tt.branch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubNumber(t *testing.T) {
+	if n := GitHubNumber("#42"); n != 42 {
+		t.Errorf("expected 42, got %d", n)
+	}
+	if n := GitHubNumber("ABC-42"); n != 0 {
+		t.Errorf("expected 0 for a tracker key, got %d", n)
+	}
+}
+
+func TestJiraStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "me@example.com" || pass != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/rest/api/2/issue/ABC-1":
+			fmt.Fprint(w, `{"fields": {"status": {"name": "Shipped", "statusCategory": {"key": "done"}}}}`)
+		case "/rest/api/2/issue/ABC-2":
+			fmt.Fprint(w, `{"fields": {"status": {"name": "In Progress", "statusCategory": {"key": "indeterminate"}}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	j := NewJira(srv.URL+"/", "me@example.com", "secret")
+	if s, err := j.Status("ABC-1"); err != nil || s != (Status{Name: "Shipped", Done: true}) {
+		t.Errorf("ABC-1: got %+v, %v", s, err)
+	}
+	if s, err := j.Status("ABC-2"); err != nil || s.Done || s.Name != "In Progress" {
+		t.Errorf("ABC-2: got %+v, %v", s, err)
+	}
+	if _, err := j.Status("ABC-3"); err == nil {
+		t.Error("expected an error for a missing issue")
+	}
+	if _, err := NewJira(srv.URL, "", "wrong").Status("ABC-1"); err == nil {
+		t.Error("expected an error when unauthorized")
+	}
+}
@@ -0,0 +1,215 @@
+// Package lock keeps two katazuke runs from working on the same projects
+// directory at once, which could delete a branch twice or quarantine two
+// repositories to the same place.
+package lock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+// Holder describes the run that holds a lock. It is the content of the
+// lock file.
+type Holder struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	Command   string    `json:"command"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// HeldError is returned by Acquire when another run holds the lock.
+type HeldError struct {
+	Holder Holder
+}
+
+func (e *HeldError) Error() string {
+	h := e.Holder
+	return fmt.Sprintf("another katazuke run is in progress (%s, pid %d on %s, started %s); wait for it to finish or pass --force-lock to override",
+		h.Command, h.PID, h.Host, h.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// Lock is a held lock. Release removes it.
+type Lock struct {
+	path string
+}
+
+// DefaultDir returns the default lock directory
+// (~/.local/state/katazuke/locks/).
+func DefaultDir() (string, error) {
+	dir, err := state.Path(state.Locks)
+	if err != nil {
+		return "", fmt.Errorf("lock: %w", err)
+	}
+	return dir, nil
+}
+
+// Path returns the lock file in dir for projectsDir. The name is a hash
+// of the cleaned absolute path, so each projects directory has its own
+// lock.
+func Path(dir, projectsDir string) string {
+	if abs, err := filepath.Abs(projectsDir); err == nil {
+		projectsDir = abs
+	}
+	sum := sha256.Sum256([]byte(filepath.Clean(projectsDir)))
+	return filepath.Join(dir, hex.EncodeToString(sum[:8])+".lock")
+}
+
+// Acquire takes the lock for projectsDir in dir on behalf of command. A
+// lock left by a run that is no longer alive on this host is taken over.
+// When a live run holds it, Acquire returns a *HeldError unless force is
+// set, in which case the lock is taken over anyway. Takeovers go through
+// takeOver, so two runs that find the same stale lock cannot both win.
+func Acquire(dir, projectsDir, command string, force bool) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("lock: create directory: %w", err)
+	}
+	path := Path(dir, projectsDir)
+	host, _ := os.Hostname()
+	self := Holder{PID: os.Getpid(), Host: host, Command: command, StartedAt: time.Now()}
+	data, err := json.Marshal(self)
+	if err != nil {
+		return nil, fmt.Errorf("lock: encode: %w", err)
+	}
+
+	// Two attempts: the second follows a takeover that lost to another run,
+	// which now holds the lock or has just released it.
+	for range 2 {
+		err = create(path, data)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock: %w", err)
+		}
+
+		// #nosec G304 - path is a katazuke lock file
+		seen, _ := os.ReadFile(path)
+		holder, ok := decode(seen)
+		switch {
+		case !ok:
+			slog.Debug("replacing unreadable lock", "path", path)
+		case stale(holder, host):
+			slog.Debug("replacing stale lock", "path", path, "pid", holder.PID, "host", holder.Host)
+		case force:
+			slog.Debug("overriding lock", "path", path, "pid", holder.PID, "host", holder.Host)
+		default:
+			return nil, &HeldError{Holder: holder}
+		}
+		err = takeOver(path, seen, data)
+		if err == nil {
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+	}
+	if holder, ok := read(path); ok {
+		return nil, &HeldError{Holder: holder}
+	}
+	return nil, fmt.Errorf("lock: %w", err)
+}
+
+// takeOver replaces the lock at path, last seen holding seen, with a new
+// lock holding data. It works under a guard file created exclusively next
+// to the lock: only the run holding the guard may remove the lock, and
+// only if it still holds seen, so a run that looked at the same stale
+// lock cannot remove the lock another run has just taken. The new lock is
+// created exclusively too. It fails with os.ErrExist when another run
+// took the lock first.
+func takeOver(path string, seen, data []byte) error {
+	guard := path + ".takeover"
+	if err := create(guard, nil); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("lock: another run is taking over %s; if none is, remove %s", path, guard)
+		}
+		return fmt.Errorf("lock: %w", err)
+	}
+	defer func() { _ = os.Remove(guard) }()
+
+	// #nosec G304 - path is a katazuke lock file
+	current, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return fmt.Errorf("lock: %w", err)
+	case !bytes.Equal(current, seen):
+		return os.ErrExist
+	default:
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("lock: remove stale lock: %w", err)
+		}
+	}
+	return create(path, data)
+}
+
+// Release removes the lock. It is safe to call on a nil Lock.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("lock: release: %w", err)
+	}
+	return nil
+}
+
+// create writes data to a new file at path, failing with os.ErrExist if
+// it already exists.
+func create(path string, data []byte) error {
+	// #nosec G304 - path is a katazuke lock file
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// read returns the holder recorded in the lock file at path, or false if
+// it cannot be read or decoded.
+func read(path string) (Holder, bool) {
+	// #nosec G304 - path is a katazuke lock file
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Holder{}, false
+	}
+	return decode(data)
+}
+
+// decode returns the holder recorded in a lock file's content, or false if
+// it cannot be decoded.
+func decode(data []byte) (Holder, bool) {
+	var h Holder
+	if err := json.Unmarshal(data, &h); err != nil || h.PID <= 0 {
+		return Holder{}, false
+	}
+	return h, true
+}
+
+// stale reports whether the run holding a lock has gone. Only runs on
+// this host can be checked; a lock from another host (a projects
+// directory on a shared filesystem) is never considered stale.
+func stale(h Holder, host string) bool {
+	if h.Host != host {
+		return false
+	}
+	return !processAlive(h.PID)
+}
+
+// processAlive reports whether a process with pid is running; replaced in
+// tests.
+var processAlive = isRunning
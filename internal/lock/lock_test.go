@@ -0,0 +1,178 @@
+package lock
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubAlive makes processAlive report alive for the test's duration.
+func stubAlive(t *testing.T, alive bool) {
+	t.Helper()
+	orig := processAlive
+	processAlive = func(int) bool { return alive }
+	t.Cleanup(func() { processAlive = orig })
+}
+
+// writeHolder writes a lock file for projectsDir held by h.
+func writeHolder(t *testing.T, dir, projectsDir string, h Holder) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(Path(dir, projectsDir), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireRelease(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "locks")
+	l, err := Acquire(dir, "/home/me/projects", "branches", false)
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	_, err = Acquire(dir, "/home/me/projects", "sync", false)
+	var held *HeldError
+	if !errors.As(err, &held) {
+		t.Fatalf("expected HeldError for a second run, got %v", err)
+	}
+	if held.Holder.PID != os.Getpid() || held.Holder.Command != "branches" {
+		t.Errorf("unexpected holder: %+v", held.Holder)
+	}
+	if !strings.Contains(err.Error(), "another katazuke run is in progress") || !strings.Contains(err.Error(), "--force-lock") {
+		t.Errorf("unexpected message: %v", err)
+	}
+
+	other, err := Acquire(dir, "/home/me/other", "sync", false)
+	if err != nil {
+		t.Fatalf("expected a different projects directory to have its own lock, got %v", err)
+	}
+	_ = other.Release()
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	l, err = Acquire(dir, "/home/me/projects", "sync", false)
+	if err != nil {
+		t.Fatalf("expected Acquire after Release to succeed, got %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquireStaleAndForced(t *testing.T) {
+	host, _ := os.Hostname()
+	tests := []struct {
+		name    string
+		holder  Holder
+		alive   bool
+		force   bool
+		wantErr bool
+	}{
+		{"dead process on this host", Holder{PID: 4242, Host: host}, false, false, false},
+		{"live process on this host", Holder{PID: 4242, Host: host}, true, false, true},
+		{"live process overridden", Holder{PID: 4242, Host: host}, true, true, false},
+		{"other host is never stale", Holder{PID: 4242, Host: host + "-other"}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stubAlive(t, tt.alive)
+			dir := t.TempDir()
+			tt.holder.Command = "repos"
+			tt.holder.StartedAt = time.Now().Add(-time.Hour)
+			writeHolder(t, dir, "/p", tt.holder)
+
+			l, err := Acquire(dir, "/p", "branches", tt.force)
+			if tt.wantErr {
+				var held *HeldError
+				if !errors.As(err, &held) || held.Holder.PID != 4242 {
+					t.Fatalf("expected HeldError for pid 4242, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Acquire failed: %v", err)
+			}
+			defer func() { _ = l.Release() }()
+			h, ok := read(Path(dir, "/p"))
+			if !ok || h.PID != os.Getpid() || h.Command != "branches" {
+				t.Errorf("expected the lock to be taken over, got %+v", h)
+			}
+		})
+	}
+}
+
+func TestAcquireUnreadableLock(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(Path(dir, "/p"), []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := Acquire(dir, "/p", "branches", false)
+	if err != nil {
+		t.Fatalf("expected an unreadable lock to be replaced, got %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestTakeOver(t *testing.T) {
+	dir := t.TempDir()
+	path := Path(dir, "/p")
+	stale := []byte(`{"pid":4242,"host":"h","command":"repos"}`)
+	fresh := []byte(`{"pid":4343,"host":"h","command":"sync"}`)
+
+	// Another run replaced the stale lock after this one looked at it: the
+	// fresh lock is left alone.
+	if err := os.WriteFile(path, fresh, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := takeOver(path, stale, []byte(`{"pid":1}`)); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected ErrExist for a lock that changed, got %v", err)
+	}
+	if h, ok := read(path); !ok || h.PID != 4343 {
+		t.Errorf("expected the fresh lock to be kept, got %+v", h)
+	}
+
+	// The lock still holds what was seen: it is replaced.
+	if err := takeOver(path, fresh, []byte(`{"pid":1}`)); err != nil {
+		t.Fatalf("takeOver failed: %v", err)
+	}
+	if h, ok := read(path); !ok || h.PID != 1 {
+		t.Errorf("expected the lock to be taken over, got %+v", h)
+	}
+	if _, err := os.Stat(path + ".takeover"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the takeover guard to be removed, got %v", err)
+	}
+
+	// Another run is taking over: this one backs off.
+	if err := os.WriteFile(path+".takeover", nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := takeOver(path, []byte(`{"pid":1}`), fresh)
+	if err == nil || !strings.Contains(err.Error(), "taking over") {
+		t.Fatalf("expected the guard to block the takeover, got %v", err)
+	}
+	if h, ok := read(path); !ok || h.PID != 1 {
+		t.Errorf("expected the lock to be kept, got %+v", h)
+	}
+}
+
+func TestIsRunning(t *testing.T) {
+	if !isRunning(os.Getpid()) {
+		t.Error("expected the current process to be running")
+	}
+}
+
+func TestReleaseNil(t *testing.T) {
+	var l *Lock
+	if err := l.Release(); err != nil {
+		t.Errorf("Release on nil lock: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package lock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isRunning reports whether a process with pid exists. Signal 0 checks
+// for the process without signaling it; EPERM means it exists but belongs
+// to another user.
+func isRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,24 @@
+//go:build windows
+
+package lock
+
+import "golang.org/x/sys/windows"
+
+// stillActive is the exit code GetExitCodeProcess reports for a process
+// that has not exited.
+const stillActive = 259
+
+// isRunning reports whether a process with pid exists and has not exited.
+func isRunning(pid int) bool {
+	h, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid)) // #nosec G115 - pid comes from os.Getpid
+	if err != nil {
+		// Access denied still means the process exists.
+		return err == windows.ERROR_ACCESS_DENIED
+	}
+	defer func() { _ = windows.CloseHandle(h) }()
+	var code uint32
+	if err := windows.GetExitCodeProcess(h, &code); err != nil {
+		return true
+	}
+	return code == stillActive
+}
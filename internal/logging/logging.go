@@ -0,0 +1,171 @@
+// Package logging provides the pieces used to send slog output to more
+// than one destination: a size-rotated log file and a handler that fans
+// records out to several handlers.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Default rotation limits for the log file.
+const (
+	DefaultMaxBytes = 10 * 1024 * 1024
+	DefaultBackups  = 3
+)
+
+// RotatingFile is an io.Writer that appends to a file and rotates it once
+// it grows past MaxBytes. Rotated files are renamed path.1, path.2, ... up
+// to Backups, with the oldest discarded. It is safe for concurrent use.
+type RotatingFile struct {
+	path     string
+	maxBytes int64
+	backups  int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotating opens path for appending, creating it and its parent
+// directory if needed. A non-positive maxBytes disables rotation.
+func OpenRotating(path string, maxBytes int64, backups int) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("logging: create directory: %w", err)
+	}
+	r := &RotatingFile{path: path, maxBytes: maxBytes, backups: backups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *RotatingFile) open() error {
+	// #nosec G304 - path is the user-configured log file
+	f, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("logging: open %s: %w", r.path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("logging: stat %s: %w", r.path, err)
+	}
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p to the log file, rotating first if p would push the
+// file past the size limit.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return 0, errors.New("logging: write to closed file")
+	}
+	if r.maxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxBytes {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts path.N-1 to path.N, ..., path to path.1 and reopens path.
+// Must be called with r.mu held.
+func (r *RotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("logging: close %s: %w", r.path, err)
+	}
+	r.file = nil
+
+	if r.backups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logging: remove %s: %w", r.path, err)
+		}
+		return r.open()
+	}
+
+	_ = os.Remove(backupName(r.path, r.backups))
+	for i := r.backups - 1; i >= 1; i-- {
+		if err := os.Rename(backupName(r.path, i), backupName(r.path, i+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("logging: rotate %s: %w", r.path, err)
+		}
+	}
+	if err := os.Rename(r.path, backupName(r.path, 1)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("logging: rotate %s: %w", r.path, err)
+	}
+	return r.open()
+}
+
+// Close closes the underlying file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func backupName(path string, n int) string {
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// teeHandler forwards each record to every handler that accepts its level.
+type teeHandler struct {
+	handlers []slog.Handler
+}
+
+// Tee returns a handler that sends records to all of the given handlers.
+// Each handler applies its own level, so a debug-level file handler can
+// sit alongside an info-level console handler.
+func Tee(handlers ...slog.Handler) slog.Handler {
+	return &teeHandler{handlers: handlers}
+}
+
+func (t *teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *teeHandler) Handle(ctx context.Context, rec slog.Record) error {
+	var errs []error
+	for _, h := range t.handlers {
+		if h.Enabled(ctx, rec.Level) {
+			errs = append(errs, h.Handle(ctx, rec.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hs := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		hs[i] = h.WithAttrs(attrs)
+	}
+	return &teeHandler{handlers: hs}
+}
+
+func (t *teeHandler) WithGroup(name string) slog.Handler {
+	hs := make([]slog.Handler, len(t.handlers))
+	for i, h := range t.handlers {
+		hs[i] = h.WithGroup(name)
+	}
+	return &teeHandler{handlers: hs}
+}
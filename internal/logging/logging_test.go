@@ -0,0 +1,91 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "katazuke.log")
+	r, err := OpenRotating(path, 10, 2)
+	if err != nil {
+		t.Fatalf("OpenRotating: %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	want := map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	}
+	for p, content := range want {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("reading %s: %v", p, err)
+		}
+		if string(data) != content {
+			t.Errorf("%s: got %q, want %q", filepath.Base(p), data, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("expected only 2 backups to be kept")
+	}
+}
+
+func TestRotatingFile_AppendsAcrossOpens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "katazuke.log")
+	for _, line := range []string{"run 1\n", "run 2\n"} {
+		r, err := OpenRotating(path, DefaultMaxBytes, DefaultBackups)
+		if err != nil {
+			t.Fatalf("OpenRotating: %v", err)
+		}
+		_, _ = r.Write([]byte(line))
+		_ = r.Close()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "run 1\nrun 2\n" {
+		t.Errorf("got %q", data)
+	}
+
+	info, _ := os.Stat(path)
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected 0600 permissions, got %o", perm)
+	}
+}
+
+func TestTee_RespectsPerHandlerLevels(t *testing.T) {
+	var console, file bytes.Buffer
+	logger := slog.New(Tee(
+		slog.NewTextHandler(&console, &slog.HandlerOptions{Level: slog.LevelInfo}),
+		slog.NewTextHandler(&file, &slog.HandlerOptions{Level: slog.LevelDebug}),
+	)).With("repo", "katazuke")
+
+	logger.Debug("skipping branch", "branch", "feature")
+	logger.Warn("fetch failed")
+
+	if strings.Contains(console.String(), "skipping branch") {
+		t.Error("console handler should not receive debug records")
+	}
+	if !strings.Contains(console.String(), "fetch failed") {
+		t.Error("console handler should receive warnings")
+	}
+	for _, want := range []string{"skipping branch", "branch=feature", "fetch failed", "repo=katazuke"} {
+		if !strings.Contains(file.String(), want) {
+			t.Errorf("file output missing %q:\n%s", want, file.String())
+		}
+	}
+}
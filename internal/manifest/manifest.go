@@ -0,0 +1,181 @@
+// Package manifest exports a description of a projects directory (remotes,
+// group layout, checked-out branches) and restores it on another machine.
+package manifest
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// Version is the manifest schema version written by Build.
+const Version = 1
+
+// Manifest describes every repository under a projects directory.
+type Manifest struct {
+	Version     int       `yaml:"version"`
+	GeneratedAt time.Time `yaml:"generated_at"`
+	// Indexes holds the .katazuke index files keyed by directory relative
+	// to the projects directory ("." for the root).
+	Indexes map[string]scanner.IndexFile `yaml:"indexes,omitempty"`
+	Repos   []Repo                       `yaml:"repos"`
+}
+
+// Repo is a single repository entry in a manifest.
+type Repo struct {
+	// Path is relative to the projects directory, using forward slashes.
+	Path      string `yaml:"path"`
+	RemoteURL string `yaml:"remote_url"`
+	// Group is the group directory containing the repo, empty at the root.
+	Group string `yaml:"group,omitempty"`
+	// Branch is the checked-out branch, empty for detached HEAD.
+	Branch  string `yaml:"branch,omitempty"`
+	Stashes int    `yaml:"stashes,omitempty"`
+}
+
+// Build inspects the given repositories and produces a manifest. Repos
+// without a primary remote cannot be re-cloned and are returned in skipped
+// instead.
+func Build(projectsDir string, repos []string, workers int) (m Manifest, skipped []string, err error) {
+	m = Manifest{Version: Version, GeneratedAt: time.Now().UTC()}
+
+	m.Indexes, err = collectIndexes(projectsDir)
+	if err != nil {
+		return m, nil, err
+	}
+
+	type entry struct {
+		repo Repo
+		ok   bool
+		path string
+	}
+	results := parallel.Run(repos, workers, func(repoPath string) entry {
+		rel, relErr := filepath.Rel(projectsDir, repoPath)
+		if relErr != nil {
+			return entry{path: repoPath}
+		}
+		url, urlErr := git.RemoteURL(repoPath, git.PrimaryRemote(repoPath))
+		if urlErr != nil || url == "" {
+			slog.Debug("no primary remote", "repo", repoPath, "error", urlErr)
+			return entry{path: repoPath}
+		}
+		r := Repo{Path: filepath.ToSlash(rel), RemoteURL: url}
+		if dir := filepath.ToSlash(filepath.Dir(rel)); dir != "." {
+			r.Group = dir
+		}
+		if branch, brErr := git.CurrentBranch(repoPath); brErr == nil {
+			r.Branch = branch
+		}
+		if n, stErr := git.StashCount(repoPath); stErr == nil {
+			r.Stashes = n
+		}
+		return entry{repo: r, ok: true, path: repoPath}
+	}, nil)
+
+	for _, e := range results {
+		if e.ok {
+			m.Repos = append(m.Repos, e.repo)
+		} else {
+			skipped = append(skipped, e.path)
+		}
+	}
+	sort.Slice(m.Repos, func(i, j int) bool { return m.Repos[i].Path < m.Repos[j].Path })
+	return m, skipped, nil
+}
+
+// collectIndexes reads the .katazuke index at root and in every group it
+// references, recursively.
+func collectIndexes(root string) (map[string]scanner.IndexFile, error) {
+	indexes := make(map[string]scanner.IndexFile)
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		idx, ok, err := scanner.LoadIndex(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return nil
+		}
+		indexes[rel] = idx
+		for _, g := range idx.Groups {
+			child := g
+			if rel != "." {
+				child = rel + "/" + g
+			}
+			if _, seen := indexes[child]; seen {
+				continue
+			}
+			if err := walk(child); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk("."); err != nil {
+		return nil, err
+	}
+	if len(indexes) == 0 {
+		return nil, nil
+	}
+	return indexes, nil
+}
+
+// Marshal encodes a manifest as YAML.
+func Marshal(m Manifest) ([]byte, error) {
+	return yaml.Marshal(m)
+}
+
+// Read loads a manifest from path and validates it.
+func Read(path string) (Manifest, error) {
+	var m Manifest
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return m, fmt.Errorf("reading manifest: %w", err)
+	}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("parsing manifest %s: %w", path, err)
+	}
+	if m.Version != Version {
+		return m, fmt.Errorf("manifest %s: unsupported version %d (expected %d)", path, m.Version, Version)
+	}
+	for rel := range m.Indexes {
+		if err := validateRelPath(rel); err != nil {
+			return m, fmt.Errorf("manifest %s: index %w", path, err)
+		}
+	}
+	for _, r := range m.Repos {
+		if err := validateRelPath(r.Path); err != nil {
+			return m, fmt.Errorf("manifest %s: repo %w", path, err)
+		}
+		if r.RemoteURL == "" {
+			return m, fmt.Errorf("manifest %s: repo %q has no remote_url", path, r.Path)
+		}
+		// A URL starting with "-" would be read by git as an option.
+		if strings.HasPrefix(r.RemoteURL, "-") {
+			return m, fmt.Errorf("manifest %s: repo %q has an invalid remote_url %q", path, r.Path, r.RemoteURL)
+		}
+	}
+	return m, nil
+}
+
+// validateRelPath rejects paths that would escape the projects directory.
+func validateRelPath(p string) error {
+	if p == "" {
+		return fmt.Errorf("path is empty")
+	}
+	clean := filepath.Clean(filepath.FromSlash(p))
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %q escapes the projects directory", p)
+	}
+	return nil
+}
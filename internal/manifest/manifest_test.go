@@ -0,0 +1,156 @@
+package manifest_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/manifest"
+	"github.com/agrahamlincoln/katazuke/internal/scanner"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func cloneInto(t *testing.T, url, dest string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(dest), 0750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// #nosec G204 - git command with controlled inputs in test code
+	if out, err := exec.Command("git", "clone", "--quiet", url, dest).CombinedOutput(); err != nil {
+		t.Fatalf("clone: %v\n%s", err, out)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	api, apiBare := helpers.NewClonedRepo(t, "api")
+	api.CreateBranch("feature")
+	api.Push("origin", "feature")
+	_, svcBare := helpers.NewClonedRepo(t, "svc")
+
+	src := t.TempDir()
+	if err := os.WriteFile(filepath.Join(src, ".katazuke"), []byte("groups:\n  - work\n"), 0600); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+	cloneInto(t, apiBare, filepath.Join(src, "api"))
+	cloneInto(t, svcBare, filepath.Join(src, "work", "svc"))
+	if err := git.Checkout(filepath.Join(src, "api"), "feature"); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	// A repo without origin is skipped.
+	local := helpers.NewTestRepo(t, "scratch")
+	if err := os.Rename(local.Path, filepath.Join(src, "scratch")); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	repoPaths, err := scanner.Scan(src, scanner.Options{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	m, skipped, err := manifest.Build(src, repoPaths, 2)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(skipped) != 1 || filepath.Base(skipped[0]) != "scratch" {
+		t.Errorf("expected scratch to be skipped, got %v", skipped)
+	}
+	if len(m.Repos) != 2 {
+		t.Fatalf("expected 2 repos, got %+v", m.Repos)
+	}
+	if m.Repos[0].Path != "api" || m.Repos[0].Branch != "feature" || m.Repos[0].Group != "" {
+		t.Errorf("unexpected api entry: %+v", m.Repos[0])
+	}
+	if m.Repos[1].Path != "work/svc" || m.Repos[1].Group != "work" {
+		t.Errorf("unexpected svc entry: %+v", m.Repos[1])
+	}
+
+	data, err := manifest.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	manifestPath := filepath.Join(t.TempDir(), "manifest.yaml")
+	if err := os.WriteFile(manifestPath, data, 0600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	read, err := manifest.Read(manifestPath)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	dst := t.TempDir()
+	results, err := manifest.Restore(dst, read, false, 2, nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, r := range results {
+		if r.Status != manifest.Cloned {
+			t.Errorf("%s: expected cloned, got %s: %s", r.Repo.Path, r.Status, r.Message)
+		}
+	}
+	if branch, _ := git.CurrentBranch(filepath.Join(dst, "api")); branch != "feature" {
+		t.Errorf("expected api on feature, got %q", branch)
+	}
+
+	restored, err := scanner.Scan(dst, scanner.Options{})
+	if err != nil {
+		t.Fatalf("scan restored: %v", err)
+	}
+	if len(restored) != 2 {
+		t.Errorf("expected restored layout to scan as 2 repos, got %v", restored)
+	}
+
+	// Importing again leaves existing checkouts alone.
+	results, err = manifest.Restore(dst, read, false, 1, nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	for _, r := range results {
+		if r.Status != manifest.Exists {
+			t.Errorf("%s: expected exists, got %s", r.Repo.Path, r.Status)
+		}
+	}
+}
+
+func TestRestoreDryRun(t *testing.T) {
+	m := manifest.Manifest{
+		Version: manifest.Version,
+		Indexes: map[string]scanner.IndexFile{".": {Groups: []string{"work"}}},
+		Repos:   []manifest.Repo{{Path: "work/svc", RemoteURL: "https://example.com/svc.git"}},
+	}
+	dst := t.TempDir()
+	results, err := manifest.Restore(dst, m, true, 1, nil)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(results) != 1 || results[0].Status != manifest.Planned {
+		t.Fatalf("expected planned result, got %+v", results)
+	}
+	if entries, _ := os.ReadDir(dst); len(entries) != 0 {
+		t.Errorf("dry run should not write anything, found %d entries", len(entries))
+	}
+}
+
+func TestReadRejectsEscapingPaths(t *testing.T) {
+	for _, p := range []string{"../outside", "/etc/repo", ""} {
+		path := filepath.Join(t.TempDir(), "manifest.yaml")
+		content := "version: 1\nrepos:\n  - path: \"" + p + "\"\n    remote_url: https://example.com/x.git\n"
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if _, err := manifest.Read(path); err == nil {
+			t.Errorf("expected error for path %q", p)
+		}
+	}
+}
+
+func TestReadRejectsOptionLikeURLs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "manifest.yaml")
+	content := "version: 1\nrepos:\n  - path: api\n    remote_url: \"--upload-pack=false\"\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := manifest.Read(path); err == nil {
+		t.Error("expected error for a remote_url starting with -")
+	}
+}
@@ -0,0 +1,115 @@
+package manifest
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/goccy/go-yaml"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// RestoreStatus is the outcome of restoring a single repository.
+type RestoreStatus string
+
+// Restore status values.
+const (
+	Cloned  RestoreStatus = "cloned"
+	Exists  RestoreStatus = "exists"
+	Planned RestoreStatus = "planned" // dry run
+	Failed  RestoreStatus = "failed"
+)
+
+// RestoreResult describes what Restore did for a single manifest entry.
+type RestoreResult struct {
+	Repo    Repo
+	Dest    string
+	Status  RestoreStatus
+	Message string
+}
+
+// Restore clones every repository in m into projectsDir, reproducing the
+// manifest's directory layout and .katazuke index files. Existing
+// directories are never touched. When a non-default branch was checked out
+// at export time, it is checked out again after cloning.
+func Restore(projectsDir string, m Manifest, dryRun bool, workers int, onResult func(completed, total int, r RestoreResult)) ([]RestoreResult, error) {
+	if !dryRun {
+		if err := writeIndexes(projectsDir, m); err != nil {
+			return nil, err
+		}
+	}
+
+	results := parallel.Run(m.Repos, workers, func(r Repo) RestoreResult {
+		return restoreOne(projectsDir, r, dryRun)
+	}, onResult)
+	return results, nil
+}
+
+func restoreOne(projectsDir string, r Repo, dryRun bool) RestoreResult {
+	dest := filepath.Join(projectsDir, filepath.FromSlash(r.Path))
+	result := RestoreResult{Repo: r, Dest: dest}
+
+	if _, err := os.Stat(dest); err == nil {
+		result.Status = Exists
+		return result
+	}
+
+	if dryRun {
+		result.Status = Planned
+		result.Message = fmt.Sprintf("would clone %s", r.RemoteURL)
+		return result
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o750); err != nil {
+		result.Status = Failed
+		result.Message = err.Error()
+		return result
+	}
+
+	slog.Debug("cloning", "url", r.RemoteURL, "dest", dest)
+	if err := git.Clone(r.RemoteURL, dest); err != nil {
+		result.Status = Failed
+		result.Message = err.Error()
+		return result
+	}
+	result.Status = Cloned
+
+	if r.Branch == "" {
+		return result
+	}
+	current, err := git.CurrentBranch(dest)
+	if err != nil || current == r.Branch {
+		return result
+	}
+	if err := git.Checkout(dest, r.Branch); err != nil {
+		// Local-only branches cannot be restored; the clone itself is fine.
+		result.Message = fmt.Sprintf("could not check out %s (left on %s)", r.Branch, current)
+	}
+	return result
+}
+
+// writeIndexes creates the .katazuke index files recorded in m. Existing
+// index files are left alone.
+func writeIndexes(projectsDir string, m Manifest) error {
+	for rel, idx := range m.Indexes {
+		dir := filepath.Join(projectsDir, filepath.FromSlash(rel))
+		path := filepath.Join(dir, ".katazuke")
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
+		}
+		data, err := yaml.Marshal(idx)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
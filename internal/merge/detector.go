@@ -5,6 +5,7 @@
 package merge
 
 import (
+	"errors"
 	"log/slog"
 	"time"
 
@@ -21,8 +22,26 @@ const (
 	// DetectedByGitHub means the GitHub API reported the branch's PR as
 	// merged (e.g. squash-merge, which git does not recognize locally).
 	DetectedByGitHub
+	// DetectedByPatch means the GitHub API was unavailable and the
+	// branch's cumulative diff matched a single commit on the base branch
+	// by patch ID, the signature of a squash-merge.
+	DetectedByPatch
 )
 
+// String returns a short description of the detection method for display.
+func (m DetectionMethod) String() string {
+	switch m {
+	case DetectedByGit:
+		return "git"
+	case DetectedByGitHub:
+		return "GitHub PR"
+	case DetectedByPatch:
+		return "patch ID"
+	default:
+		return "unknown"
+	}
+}
+
 // DetectedBranch pairs a branch name with the method used to detect it
 // as merged. Callers use the method to decide whether force-deletion is
 // needed (GitHub-detected branches require git branch -D).
@@ -35,22 +54,36 @@ type DetectedBranch struct {
 }
 
 // GitChecker defines the git operations needed for merge detection.
-// RemoteURL is included because the detector needs it to determine the
-// GitHub owner/repo for API fallback on non-git-merged branches.
+// PrimaryRemote and RemoteURLs are included because the detector needs
+// them to determine the GitHub owner/repo for API fallback on
+// non-git-merged branches.
+// IsSquashMerged is the local fallback used when the API is unavailable.
+// RevParse resolves the local branch tip, which must match a merged PR's
+// head before the PR is trusted.
 type GitChecker interface {
 	IsMerged(repoPath, branch, base string) (bool, error)
 	MergedBranches(repoPath, base string) ([]string, error)
-	RemoteURL(repoPath, remote string) (string, error)
+	PrimaryRemote(repoPath string) string
+	RemoteURLs(repoPath, remote string) ([]string, error)
+	IsSquashMerged(repoPath, branch, base string) (bool, error)
+	RevParse(repoPath, ref string) (string, error)
 }
 
+// errTipMoved is returned by isPRMerged when a branch's PR was merged but
+// the local branch no longer points at the PR's head, so the PR says
+// nothing about the commits on it.
+var errTipMoved = errors.New("local branch tip does not match the merged PR's head")
+
 // PRChecker defines the GitHub API operations needed for merge detection.
 type PRChecker interface {
 	BranchPRInfo(owner, repo, branch string) (*github.PRInfo, error)
 }
 
 // Detector combines local git merge checks with GitHub PR state lookups
-// to determine whether a branch has been merged. When no PRChecker is
-// provided, it operates in git-only mode.
+// to determine whether a branch has been merged. A merged PR only counts
+// while the local branch still points at the PR's head. When no PRChecker
+// is provided, or the API cannot answer for a repo or branch, it falls
+// back to detecting squash-merges locally by patch ID.
 type Detector struct {
 	git GitChecker
 	pr  PRChecker
@@ -63,16 +96,17 @@ func NewDetector(git GitChecker, pr PRChecker) *Detector {
 	return &Detector{git: git, pr: pr}
 }
 
-// GitOnlyDetector returns a Detector that only uses local git operations,
-// without any GitHub API fallback. Intended for tests and environments
-// without GitHub access.
+// GitOnlyDetector returns a Detector that only uses local git operations
+// (including the patch ID squash check), without any GitHub API lookups.
+// Intended for tests and environments without GitHub access.
 func GitOnlyDetector() *Detector {
 	return NewDetector(RealGitChecker{}, nil)
 }
 
 // IsMerged returns true if branch has been merged into base. It first
 // checks the local git state (fast path), then falls back to querying
-// the GitHub API for PR merge status. Callers that need to know the
+// the GitHub API for PR merge status, and finally to the local patch ID
+// check when the API is unavailable. Callers that need to know the
 // detection method (e.g. for force-deletion decisions) should use
 // MergedBranches instead.
 func (d *Detector) IsMerged(repoPath, branch, base string) (bool, error) {
@@ -84,17 +118,19 @@ func (d *Detector) IsMerged(repoPath, branch, base string) (bool, error) {
 		return true, nil
 	}
 
-	if d.pr == nil {
-		return false, nil
+	if owner, repo, ok := d.githubRepo(repoPath); ok {
+		if _, merged, err := d.isPRMerged(repoPath, owner, repo, branch); err == nil {
+			return merged, nil
+		}
 	}
-
-	return d.checkPR(repoPath, branch), nil
+	return d.isSquashMerged(repoPath, branch, base), nil
 }
 
 // MergedBranches returns branches that have been merged into base. It
 // first collects the git-local merged set, then checks any remaining
-// branches against the GitHub API. Each result includes the detection
-// method so callers can decide whether force-deletion is needed.
+// branches against the GitHub API, falling back to the local patch ID
+// check for branches the API cannot answer for. Each result includes the
+// detection method so callers can decide whether force-deletion is needed.
 func (d *Detector) MergedBranches(repoPath, base string, allBranches []string) ([]DetectedBranch, error) {
 	gitMerged, err := d.git.MergedBranches(repoPath, base)
 	if err != nil {
@@ -111,74 +147,102 @@ func (d *Detector) MergedBranches(repoPath, base string, allBranches []string) (
 		result = append(result, DetectedBranch{Name: b, Method: DetectedByGit})
 	}
 
-	if d.pr == nil {
-		return result, nil
-	}
-
-	owner, repo, ok := d.resolveGitHubRepo(repoPath)
-	if !ok {
-		return result, nil
-	}
+	owner, repo, apiAvailable := d.githubRepo(repoPath)
 
-	// Check branches not in the git-merged set via GitHub API.
+	// Check branches not in the git-merged set via GitHub API, or by
+	// patch ID when the API cannot answer.
 	for _, branch := range allBranches {
 		if gitMergedSet[branch] {
 			continue
 		}
-		info, merged := d.isPRMerged(owner, repo, branch)
-		if merged {
-			result = append(result, DetectedBranch{
-				Name:           branch,
-				Method:         DetectedByGitHub,
-				PRNumber:       info.Number,
-				PRMergedAt:     info.MergedAt,
-				MergeCommitSHA: info.MergeCommitSHA,
-			})
+		if apiAvailable {
+			info, merged, err := d.isPRMerged(repoPath, owner, repo, branch)
+			if err == nil {
+				if merged {
+					result = append(result, DetectedBranch{
+						Name:           branch,
+						Method:         DetectedByGitHub,
+						PRNumber:       info.Number,
+						PRMergedAt:     info.MergedAt,
+						MergeCommitSHA: info.MergeCommitSHA,
+					})
+				}
+				continue
+			}
+		}
+		if d.isSquashMerged(repoPath, branch, base) {
+			result = append(result, DetectedBranch{Name: branch, Method: DetectedByPatch})
 		}
 	}
 
 	return result, nil
 }
 
+// githubRepo returns the GitHub owner/repo to query for repoPath, or
+// ok=false when there is no PR checker or the remote is not on GitHub.
+func (d *Detector) githubRepo(repoPath string) (owner, repo string, ok bool) {
+	if d.pr == nil {
+		return "", "", false
+	}
+	return d.resolveGitHubRepo(repoPath)
+}
+
 // resolveGitHubRepo resolves the remote URL for a repository and parses
 // the GitHub owner/repo. Returns ok=false for non-GitHub remotes or
 // when the remote URL cannot be determined.
 func (d *Detector) resolveGitHubRepo(repoPath string) (owner, repo string, ok bool) {
-	remoteURL, err := d.git.RemoteURL(repoPath, "origin")
+	remoteURLs, err := d.git.RemoteURLs(repoPath, d.git.PrimaryRemote(repoPath))
 	if err != nil {
 		slog.Debug("could not get remote URL, skipping PR check",
 			"repo", repoPath, "error", err)
 		return "", "", false
 	}
-	owner, repo, ok = github.ParseGitHubRemote(remoteURL)
+	owner, repo, ok = github.ParseGitHubRemotes(remoteURLs)
 	if !ok {
 		slog.Debug("non-GitHub remote, skipping PR check",
-			"repo", repoPath, "url", remoteURL)
+			"repo", repoPath, "urls", remoteURLs)
 	}
 	return owner, repo, ok
 }
 
 // isPRMerged queries the GitHub API for the PR state of a single branch.
-// Returns the PRInfo and true only if the PR was merged. Any error is
-// logged and treated as "not merged" (graceful degradation).
-func (d *Detector) isPRMerged(owner, repo, branch string) (*github.PRInfo, bool) {
+// Returns the PRInfo and true only if the PR was merged and the local
+// branch tip still matches the PR's head SHA, which guards against a
+// branch name reused after its PR merged. An error means the API could
+// not answer for this branch and the caller should fall back to local
+// checks.
+func (d *Detector) isPRMerged(repoPath, owner, repo, branch string) (*github.PRInfo, bool, error) {
 	info, err := d.pr.BranchPRInfo(owner, repo, branch)
 	if err != nil {
-		slog.Debug("PR check failed, assuming not merged",
+		slog.Debug("PR check failed, falling back to patch ID check",
 			"repo", owner+"/"+repo, "branch", branch, "error", err)
-		return nil, false
+		return nil, false, err
 	}
-	return info, info.State == github.PRStateMerged
+	if info.State != github.PRStateMerged {
+		return info, false, nil
+	}
+	localSHA, err := d.git.RevParse(repoPath, "refs/heads/"+branch)
+	if err != nil || localSHA != info.HeadSHA {
+		slog.Debug("merged PR head does not match local branch, falling back to patch ID check",
+			"repo", owner+"/"+repo, "branch", branch, "pr", info.Number,
+			"pr_head", info.HeadSHA, "local", localSHA, "error", err)
+		return info, false, errTipMoved
+	}
+	return info, true, nil
 }
 
-// checkPR queries the GitHub API for the PR state of a branch. Returns
-// true only if the PR was merged. Used by IsMerged for single-branch checks
-// where resolving the repo per call is acceptable.
-func (d *Detector) checkPR(repoPath, branch string) bool {
-	owner, repo, ok := d.resolveGitHubRepo(repoPath)
-	if !ok {
+// isSquashMerged runs the local patch ID check. Errors are logged and
+// treated as "not merged" (graceful degradation).
+func (d *Detector) isSquashMerged(repoPath, branch, base string) bool {
+	merged, err := d.git.IsSquashMerged(repoPath, branch, base)
+	if err != nil {
+		slog.Debug("patch ID check failed, assuming not merged",
+			"repo", repoPath, "branch", branch, "error", err)
 		return false
 	}
-	_, merged := d.isPRMerged(owner, repo, branch)
+	if merged {
+		slog.Debug("branch matches a squash commit by patch ID",
+			"repo", repoPath, "branch", branch, "base", base)
+	}
 	return merged
 }
@@ -2,6 +2,7 @@ package merge_test
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,10 +17,15 @@ type mockGitChecker struct {
 	mergedErr      error
 	remoteURL      string
 	remoteURLErr   error
+	squashMerged   map[string]bool
+	// tips maps branch names to their local tip; unlisted branches are
+	// at prHead.
+	tips map[string]string
 
 	isMergedCalls  int
 	mergedBrCalls  int
 	remoteURLCalls int
+	squashCalls    int
 }
 
 func (m *mockGitChecker) IsMerged(_, _, _ string) (bool, error) {
@@ -32,9 +38,32 @@ func (m *mockGitChecker) MergedBranches(_, _ string) ([]string, error) {
 	return m.mergedBranches, m.mergedErr
 }
 
-func (m *mockGitChecker) RemoteURL(_, _ string) (string, error) {
+func (m *mockGitChecker) PrimaryRemote(string) string {
+	return "origin"
+}
+
+func (m *mockGitChecker) RemoteURLs(_, _ string) ([]string, error) {
 	m.remoteURLCalls++
-	return m.remoteURL, m.remoteURLErr
+	if m.remoteURLErr != nil {
+		return nil, m.remoteURLErr
+	}
+	return []string{m.remoteURL}, nil
+}
+
+func (m *mockGitChecker) IsSquashMerged(_, branch, _ string) (bool, error) {
+	m.squashCalls++
+	return m.squashMerged[branch], nil
+}
+
+// prHead is the head SHA of the merged PRs in these tests, and the local
+// tip of any branch mockGitChecker.tips does not list.
+const prHead = "5f0c2e1a9b7d"
+
+func (m *mockGitChecker) RevParse(_, ref string) (string, error) {
+	if tip, ok := m.tips[strings.TrimPrefix(ref, "refs/heads/")]; ok {
+		return tip, nil
+	}
+	return prHead, nil
 }
 
 type mockPRChecker struct {
@@ -85,7 +114,7 @@ func TestIsMerged_GitNotMerged_APIMerged(t *testing.T) {
 		isMerged:  false,
 		remoteURL: "git@github.com:owner/repo.git",
 	}
-	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateMerged}}
+	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateMerged, HeadSHA: prHead}}
 	d := merge.NewDetector(gitMock, prMock)
 
 	merged, err := d.IsMerged("/repo", "feature", "main")
@@ -172,7 +201,7 @@ func TestMergedBranches_UnionOfGitAndAPI(t *testing.T) {
 		mergedBranches: []string{"branch-a"},
 		remoteURL:      "https://github.com/owner/repo.git",
 	}
-	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateMerged}}
+	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateMerged, HeadSHA: prHead}}
 	d := merge.NewDetector(gitMock, prMock)
 
 	all := []string{"branch-a", "branch-b", "branch-c"}
@@ -222,7 +251,7 @@ func TestMergedBranches_PassesCorrectBranchNames(t *testing.T) {
 	}
 	prMock := &branchAwarePRMock{
 		states: map[string]github.PRInfo{
-			"squash-merged": {State: github.PRStateMerged},
+			"squash-merged": {State: github.PRStateMerged, HeadSHA: prHead},
 			"still-open":    {State: github.PRStateOpen},
 		},
 	}
@@ -284,6 +313,7 @@ func TestMergedBranches_ThreadsPRInfo(t *testing.T) {
 				Number:         42,
 				MergedAt:       time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC),
 				MergeCommitSHA: "abc123deadbeef",
+				HeadSHA:        prHead,
 			},
 		},
 	}
@@ -334,3 +364,119 @@ func TestMergedBranches_NilPRChecker(t *testing.T) {
 		t.Error("expected DetectedByGit in git-only mode")
 	}
 }
+
+func TestMergedBranches_PatchFallbackWithoutAPI(t *testing.T) {
+	gitMock := &mockGitChecker{
+		mergedBranches: []string{"branch-a"},
+		squashMerged:   map[string]bool{"branch-b": true},
+	}
+	d := merge.NewDetector(gitMock, nil)
+
+	result, err := d.MergedBranches("/repo", "main", []string{"branch-a", "branch-b", "branch-c"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 2 {
+		t.Fatalf("expected 2 merged branches, got %v", result)
+	}
+	if result[1].Name != "branch-b" || result[1].Method != merge.DetectedByPatch {
+		t.Errorf("expected branch-b detected by patch, got %+v", result[1])
+	}
+	if gitMock.squashCalls != 2 {
+		t.Errorf("expected patch check for the 2 non-git-merged branches, got %d", gitMock.squashCalls)
+	}
+}
+
+func TestMergedBranches_PatchFallbackOnAPIError(t *testing.T) {
+	gitMock := &mockGitChecker{
+		remoteURL:    "git@github.com:owner/repo.git",
+		squashMerged: map[string]bool{"feature": true},
+	}
+	prMock := &mockPRChecker{err: fmt.Errorf("API rate limit")}
+	d := merge.NewDetector(gitMock, prMock)
+
+	result, err := d.MergedBranches("/repo", "main", []string{"feature"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 1 || result[0].Method != merge.DetectedByPatch {
+		t.Fatalf("expected feature detected by patch, got %v", result)
+	}
+
+	merged, err := d.IsMerged("/repo", "feature", "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !merged {
+		t.Error("expected IsMerged to use the patch fallback on API error")
+	}
+}
+
+func TestMergedBranches_NoPatchFallbackWhenAPIAnswers(t *testing.T) {
+	gitMock := &mockGitChecker{
+		remoteURL:    "git@github.com:owner/repo.git",
+		squashMerged: map[string]bool{"feature": true},
+	}
+	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateOpen}}
+	d := merge.NewDetector(gitMock, prMock)
+
+	result, err := d.MergedBranches("/repo", "main", []string{"feature"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result) != 0 {
+		t.Errorf("expected API answer to be trusted, got %v", result)
+	}
+	if gitMock.squashCalls != 0 {
+		t.Errorf("patch check should not run when the API answers, got %d calls", gitMock.squashCalls)
+	}
+}
+
+func TestMergedBranches_ReusedBranchName(t *testing.T) {
+	gitMock := &mockGitChecker{
+		remoteURL: "git@github.com:owner/repo.git",
+		tips:      map[string]string{"reused": "9e4b7c3d2a10", "squashed": "0d8a6f5e4c21"},
+		// The new work on "squashed" has itself been squash-merged since.
+		squashMerged: map[string]bool{"squashed": true},
+	}
+	prMock := &mockPRChecker{info: &github.PRInfo{State: github.PRStateMerged, Number: 7, HeadSHA: prHead}}
+	d := merge.NewDetector(gitMock, prMock)
+
+	result, err := d.MergedBranches("/repo", "main", []string{"reused", "squashed", "feature"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	methods := make(map[string]merge.DetectionMethod, len(result))
+	for _, b := range result {
+		methods[b.Name] = b.Method
+	}
+	if _, ok := methods["reused"]; ok {
+		t.Error("a branch whose tip moved past its merged PR should not be reported as merged")
+	}
+	if m, ok := methods["squashed"]; !ok || m != merge.DetectedByPatch {
+		t.Errorf("expected squashed to fall back to the patch check, got %v", result)
+	}
+	if m, ok := methods["feature"]; !ok || m != merge.DetectedByGitHub {
+		t.Errorf("expected feature, still at the PR head, to be DetectedByGitHub, got %v", result)
+	}
+
+	merged, err := d.IsMerged("/repo", "reused", "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if merged {
+		t.Error("expected IsMerged to ignore a merged PR whose head is not the local tip")
+	}
+}
+
+func TestDetectionMethodString(t *testing.T) {
+	for method, want := range map[merge.DetectionMethod]string{
+		merge.DetectedByGit:    "git",
+		merge.DetectedByGitHub: "GitHub PR",
+		merge.DetectedByPatch:  "patch ID",
+	} {
+		if got := method.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", method, got, want)
+		}
+	}
+}
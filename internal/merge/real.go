@@ -15,7 +15,23 @@ func (RealGitChecker) MergedBranches(repoPath, base string) ([]string, error) {
 	return git.MergedBranches(repoPath, base)
 }
 
-// RemoteURL returns the fetch URL of the given remote.
-func (RealGitChecker) RemoteURL(repoPath, remote string) (string, error) {
-	return git.RemoteURL(repoPath, remote)
+// PrimaryRemote returns the remote that holds the default branch, or ""
+// when there is no clear choice.
+func (RealGitChecker) PrimaryRemote(repoPath string) string {
+	return git.PrimaryRemote(repoPath)
+}
+
+// RemoteURLs returns the fetch and push URLs of the given remote.
+func (RealGitChecker) RemoteURLs(repoPath, remote string) ([]string, error) {
+	return git.RemoteURLs(repoPath, remote)
+}
+
+// IsSquashMerged returns true if branch's combined diff matches a commit on base.
+func (RealGitChecker) IsSquashMerged(repoPath, branch, base string) (bool, error) {
+	return git.IsSquashMerged(repoPath, branch, base)
+}
+
+// RevParse resolves ref to a commit SHA.
+func (RealGitChecker) RevParse(repoPath, ref string) (string, error) {
+	return git.RevParse(repoPath, ref)
 }
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MonthTotals aggregates the events of one monthly metrics file.
+type MonthTotals struct {
+	Month time.Time
+	// Commands counts invocations per command name.
+	Commands map[string]int
+	// Suggestions and Accepted count cleanup suggestions shown and taken.
+	Suggestions int
+	Accepted    int
+	// ReposScanned and ScanDurationMs sum the scans of the month.
+	ReposScanned   int
+	ScanDurationMs int64
+	Impact         ImpactEvent
+}
+
+// Aggregate totals the events in dir by month, oldest first. Months
+// already dropped by retention are not included.
+func Aggregate(dir string) ([]MonthTotals, error) {
+	usage, err := DiskUsage(dir)
+	if err != nil {
+		return nil, err
+	}
+	var months []MonthTotals
+	for _, f := range usage.Files {
+		m := MonthTotals{Month: f.Month, Commands: make(map[string]int)}
+		err := readEvents(filepath.Join(dir, f.Name), f.Compressed, func(e Event) {
+			if e.Command != nil {
+				m.Commands[e.Command.Name]++
+			}
+			if e.Suggestion != nil {
+				m.Suggestions++
+				if e.Suggestion.Accepted {
+					m.Accepted++
+				}
+			}
+			if e.Perf != nil {
+				m.ReposScanned += e.Perf.ReposScanned
+				m.ScanDurationMs += int64(e.Perf.ScanDurationMs)
+			}
+			if e.Impact != nil {
+				m.Impact.Add(*e.Impact)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		months = append(months, m)
+	}
+	return months, nil
+}
+
+// commandCount sums the invocations of every command.
+func (m MonthTotals) commandCount() int {
+	n := 0
+	for _, c := range m.Commands {
+		n += c
+	}
+	return n
+}
+
+// WriteCSV writes one row per month with a header row.
+func WriteCSV(w io.Writer, months []MonthTotals) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{
+		"month", "commands", "suggestions", "accepted", "repos_scanned", "scan_duration_ms",
+		"branches_deleted", "remote_branches_deleted", "repos_removed", "bytes_freed", "cleanup_duration_ms",
+	})
+	for _, m := range months {
+		_ = cw.Write([]string{
+			m.Month.Format("2006-01"),
+			strconv.Itoa(m.commandCount()),
+			strconv.Itoa(m.Suggestions),
+			strconv.Itoa(m.Accepted),
+			strconv.Itoa(m.ReposScanned),
+			strconv.FormatInt(m.ScanDurationMs, 10),
+			strconv.Itoa(m.Impact.BranchesDeleted),
+			strconv.Itoa(m.Impact.RemoteBranchesDeleted),
+			strconv.Itoa(m.Impact.ReposRemoved),
+			strconv.FormatInt(m.Impact.BytesFreed, 10),
+			strconv.FormatInt(m.Impact.DurationMs, 10),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WritePrometheus writes the totals across all months in the Prometheus
+// text exposition format, for node_exporter's textfile collector. The
+// counters only cover months within retention, so they drop when an old
+// month is pruned; graph them with increase() rather than raw values.
+func WritePrometheus(w io.Writer, months []MonthTotals) error {
+	var total MonthTotals
+	commands := make(map[string]int)
+	for _, m := range months {
+		for name, n := range m.Commands {
+			commands[name] += n
+		}
+		total.Suggestions += m.Suggestions
+		total.Accepted += m.Accepted
+		total.ReposScanned += m.ReposScanned
+		total.ScanDurationMs += m.ScanDurationMs
+		total.Impact.Add(m.Impact)
+	}
+
+	var b strings.Builder
+	metric := func(name, help string, samples ...string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, s := range samples {
+			fmt.Fprintf(&b, "%s%s\n", name, s)
+		}
+	}
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var commandSamples []string
+	for _, name := range names {
+		commandSamples = append(commandSamples, fmt.Sprintf("{command=%q} %d", name, commands[name]))
+	}
+	metric("katazuke_commands_total", "Commands invoked.", commandSamples...)
+	metric("katazuke_suggestions_total", "Cleanup suggestions shown, by whether they were accepted.",
+		fmt.Sprintf(`{accepted="true"} %d`, total.Accepted),
+		fmt.Sprintf(`{accepted="false"} %d`, total.Suggestions-total.Accepted))
+	metric("katazuke_repos_scanned_total", "Repositories scanned.", fmt.Sprintf(" %d", total.ReposScanned))
+	metric("katazuke_scan_duration_seconds_total", "Time spent scanning.", " "+seconds(total.ScanDurationMs))
+	metric("katazuke_branches_deleted_total", "Local branches deleted.", fmt.Sprintf(" %d", total.Impact.BranchesDeleted))
+	metric("katazuke_remote_branches_deleted_total", "Remote branches deleted.", fmt.Sprintf(" %d", total.Impact.RemoteBranchesDeleted))
+	metric("katazuke_repos_removed_total", "Repository checkouts removed.", fmt.Sprintf(" %d", total.Impact.ReposRemoved))
+	metric("katazuke_freed_bytes_total", "Disk space freed by removing checkouts.", fmt.Sprintf(" %d", total.Impact.BytesFreed))
+	metric("katazuke_cleanup_duration_seconds_total", "Time spent in runs that cleaned something up.", " "+seconds(total.Impact.DurationMs))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func seconds(ms int64) string {
+	return strconv.FormatFloat(float64(ms)/1000, 'f', -1, 64)
+}
@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeEvents(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	data := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAggregateAndExport(t *testing.T) {
+	dir := t.TempDir()
+	writeEvents(t, dir, "events-2026-01.jsonl",
+		`{"schema_version":1,"command":{"name":"branches --stale"}}`,
+		`{"schema_version":1,"suggestion":{"action_type":"delete_stale_branch","accepted":true}}`,
+		`{"schema_version":1,"suggestion":{"action_type":"delete_stale_branch","accepted":false}}`,
+		`{"schema_version":1,"perf":{"repos_scanned":40,"scan_duration_ms":1200}}`,
+	)
+	writeEvents(t, dir, "events-2026-02.jsonl",
+		`{"schema_version":2,"command":{"name":"branches --stale"}}`,
+		`{"schema_version":2,"command":{"name":"repos --archived"}}`,
+		`{"schema_version":2,"impact":{"branches_deleted":3,"remote_branches_deleted":1,"repos_removed":1,"bytes_freed":4096,"duration_ms":2500}}`,
+	)
+
+	months, err := Aggregate(dir)
+	if err != nil {
+		t.Fatalf("Aggregate failed: %v", err)
+	}
+	if len(months) != 2 {
+		t.Fatalf("expected 2 months, got %d", len(months))
+	}
+	if jan := months[0]; jan.Suggestions != 2 || jan.Accepted != 1 || jan.ReposScanned != 40 || jan.Commands["branches --stale"] != 1 {
+		t.Errorf("unexpected January totals: %+v", jan)
+	}
+	if feb := months[1]; feb.Impact.BranchesDeleted != 3 || feb.commandCount() != 2 {
+		t.Errorf("unexpected February totals: %+v", feb)
+	}
+
+	var csvOut strings.Builder
+	if err := WriteCSV(&csvOut, months); err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(rows) != 3 || !strings.HasPrefix(rows[0], "month,commands,") {
+		t.Fatalf("unexpected CSV:\n%s", csvOut.String())
+	}
+	if rows[2] != "2026-02,2,0,0,0,0,3,1,1,4096,2500" {
+		t.Errorf("unexpected February row %q", rows[2])
+	}
+
+	var prom strings.Builder
+	if err := WritePrometheus(&prom, months); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"# TYPE katazuke_commands_total counter\n",
+		`katazuke_commands_total{command="branches --stale"} 2` + "\n",
+		`katazuke_suggestions_total{accepted="false"} 1` + "\n",
+		"katazuke_scan_duration_seconds_total 1.2\n",
+		"katazuke_branches_deleted_total 3\n",
+		"katazuke_freed_bytes_total 4096\n",
+		"katazuke_cleanup_duration_seconds_total 2.5\n",
+	} {
+		if !strings.Contains(prom.String(), want) {
+			t.Errorf("Prometheus output missing %q:\n%s", want, prom.String())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ImpactEvent records what a destructive run cleaned up and how long it
+// took. Added in schema version 2.
+type ImpactEvent struct {
+	BranchesDeleted       int   `json:"branches_deleted"`
+	RemoteBranchesDeleted int   `json:"remote_branches_deleted"`
+	ReposRemoved          int   `json:"repos_removed"`
+	BytesFreed            int64 `json:"bytes_freed"`
+	DurationMs            int64 `json:"duration_ms"`
+}
+
+// IsZero reports whether nothing was cleaned up. The duration alone does
+// not count.
+func (e ImpactEvent) IsZero() bool {
+	return e.BranchesDeleted == 0 && e.RemoteBranchesDeleted == 0 && e.ReposRemoved == 0 && e.BytesFreed == 0
+}
+
+// Add adds the counts and duration of other to e.
+func (e *ImpactEvent) Add(other ImpactEvent) {
+	e.BranchesDeleted += other.BranchesDeleted
+	e.RemoteBranchesDeleted += other.RemoteBranchesDeleted
+	e.ReposRemoved += other.ReposRemoved
+	e.BytesFreed += other.BytesFreed
+	e.DurationMs += other.DurationMs
+}
+
+// LogImpact logs the cleanup totals of a run.
+func (l *Logger) LogImpact(impact ImpactEvent) error {
+	return l.Log(Event{Impact: &impact})
+}
+
+// TotalImpact sums the impact events of every metrics file in dir,
+// compressed or not, and returns the sum with the number of runs it covers.
+// Months dropped by retention are no longer counted. A missing directory
+// has no impact rather than an error.
+func TotalImpact(dir string) (ImpactEvent, int, error) {
+	var total ImpactEvent
+	runs := 0
+	usage, err := DiskUsage(dir)
+	if err != nil {
+		return total, 0, err
+	}
+	for _, f := range usage.Files {
+		err := readEvents(filepath.Join(dir, f.Name), f.Compressed, func(e Event) {
+			if e.Impact != nil {
+				total.Add(*e.Impact)
+				runs++
+			}
+		})
+		if err != nil {
+			return total, runs, err
+		}
+	}
+	return total, runs, nil
+}
+
+// readEvents calls fn for each event in a metrics file. Lines that do not
+// parse, such as one cut short by a crash, are skipped, as are events from
+// a newer schema version than this build knows.
+func readEvents(path string, compressed bool, fn func(Event)) error {
+	// #nosec G304 - path is a metrics file discovered in the metrics directory
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("metrics: open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var r io.Reader = f
+	if compressed {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("metrics: read %s: %w", path, err)
+		}
+		defer func() { _ = zr.Close() }()
+		r = zr
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Event
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.SchemaVersion <= schemaVersion {
+			fn(e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("metrics: read %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTotalImpact(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatalf("NewWithDir failed: %v", err)
+	}
+	if err := logger.LogImpact(ImpactEvent{BranchesDeleted: 3, RemoteBranchesDeleted: 1, DurationMs: 500}); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.LogCommand("branches", nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An older, compressed month counts too; events from a newer schema
+	// and partial lines are skipped.
+	old := filepath.Join(dir, "events-2020-01.jsonl")
+	line := `{"schema_version":2,"impact":{"branches_deleted":2,"repos_removed":1,"bytes_freed":2048,"duration_ms":1500}}` + "\n" +
+		`{"schema_version":1,"command":{"name":"repos"}}` + "\n" +
+		`{"schema_version":99,"impact":{"branches_deleted":100}}` + "\n" +
+		"{truncated\n"
+	if err := os.WriteFile(old, []byte(line), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := compressFile(old); err != nil {
+		t.Fatal(err)
+	}
+
+	total, runs, err := TotalImpact(dir)
+	if err != nil {
+		t.Fatalf("TotalImpact failed: %v", err)
+	}
+	want := ImpactEvent{BranchesDeleted: 5, RemoteBranchesDeleted: 1, ReposRemoved: 1, BytesFreed: 2048, DurationMs: 2000}
+	if total != want || runs != 2 {
+		t.Errorf("TotalImpact = %+v over %d runs, want %+v over 2", total, runs, want)
+	}
+}
+
+func TestTotalImpact_MissingDir(t *testing.T) {
+	total, runs, err := TotalImpact(filepath.Join(t.TempDir(), "missing"))
+	if err != nil || !total.IsZero() || runs != 0 {
+		t.Errorf("expected no impact for a missing directory, got %+v, %d, %v", total, runs, err)
+	}
+}
@@ -1,21 +1,29 @@
-// Package metrics implements a write-only JSONL event logger for tracking
-// katazuke usage patterns and informing product decisions.
+// Package metrics implements a JSONL event logger for tracking katazuke
+// usage patterns and informing product decisions. Apart from cumulative
+// cleanup totals, events are only ever written.
 package metrics
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
 )
 
-const schemaVersion = 1
+// schemaVersion is written to every event. Version 2 added ImpactEvent;
+// version 1 files remain readable.
+const schemaVersion = 2
 
 // Event represents a single metrics event written to the JSONL log.
 type Event struct {
@@ -26,6 +34,7 @@ type Event struct {
 	Command    *CommandEvent    `json:"command,omitempty"`
 	Suggestion *SuggestionEvent `json:"suggestion,omitempty"`
 	Perf       *PerfEvent       `json:"perf,omitempty"`
+	Impact     *ImpactEvent     `json:"impact,omitempty"`
 	AgeDays    *int             `json:"age_days,omitempty"`
 }
 
@@ -60,11 +69,10 @@ type Logger struct {
 // New creates a Logger that writes to the default metrics directory
 // (~/.local/share/katazuke/metrics/). The directory is created if needed.
 func New() (*Logger, error) {
-	home, err := os.UserHomeDir()
+	dir, err := DefaultDir()
 	if err != nil {
-		return nil, fmt.Errorf("metrics: home directory: %w", err)
+		return nil, err
 	}
-	dir := filepath.Join(home, ".local", "share", "katazuke", "metrics")
 	return NewWithDir(dir)
 }
 
@@ -178,17 +186,84 @@ func (l *Logger) Close() error {
 	return nil
 }
 
-// Fingerprint produces a SHA-256 hex digest suitable for tracking
-// repeat suggestions without storing raw paths. Each part is
-// length-prefixed so that ("ab","c") and ("a","bc") hash differently.
+// Fingerprint produces an HMAC-SHA-256 hex digest suitable for tracking
+// repeat suggestions without storing raw paths. It is keyed with a secret
+// kept in the state directory, so a fingerprint cannot be matched by
+// hashing guessed remote URLs or paths. Each part is length-prefixed so
+// that ("ab","c") and ("a","bc") hash differently.
 func Fingerprint(parts ...string) string {
-	h := sha256.New()
+	h := hmac.New(sha256.New, fingerprintKey())
 	for _, p := range parts {
-		_, _ = fmt.Fprintf(h, "%d:%s", len(p), p) // sha256.Write never returns an error
+		_, _ = fmt.Fprintf(h, "%d:%s", len(p), p) // hmac Write never returns an error
 	}
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// fingerprintKeySize is the length in bytes of the fingerprint key.
+const fingerprintKeySize = 32
+
+// fingerprintKey returns this install's fingerprint key, creating it on
+// first use. When it cannot be read or saved, a key for this run only is
+// used: fingerprints then do not match earlier runs, but they are never
+// left unkeyed.
+var fingerprintKey = sync.OnceValue(func() []byte {
+	path, err := state.Path(state.FingerprintKey)
+	if err == nil {
+		var key []byte
+		if key, err = loadOrCreateKey(path); err == nil {
+			return key
+		}
+	}
+	slog.Debug("metrics: using a fingerprint key for this run only", "error", err)
+	key := make([]byte, fingerprintKeySize)
+	_, _ = rand.Read(key) // crypto/rand.Read never returns an error
+	return key
+})
+
+// loadOrCreateKey reads the key at path, or writes a new random one when
+// there is none. The file is created exclusively, so when two runs race
+// both end up with the key that was written first.
+func loadOrCreateKey(path string) ([]byte, error) {
+	key, err := readKey(path)
+	if !errors.Is(err, fs.ErrNotExist) {
+		return key, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	key = make([]byte, fingerprintKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) // #nosec G304 - fixed file name in the state directory
+	if errors.Is(err, fs.ErrExist) {
+		return readKey(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = f.Write(key)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		return nil, err
+	}
+	return key, nil
+}
+
+func readKey(path string) ([]byte, error) {
+	key, err := os.ReadFile(path) // #nosec G304 - fixed file name in the state directory
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != fingerprintKeySize {
+		return nil, fmt.Errorf("metrics: %s is not a fingerprint key", path)
+	}
+	return key, nil
+}
+
 // openFile returns the file handle for the current month's JSONL file,
 // opening or rotating as needed. Caller must hold l.mu.
 func (l *Logger) openFile() (*os.File, error) {
@@ -1,6 +1,9 @@
 package metrics
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"os"
 	"path/filepath"
@@ -112,8 +115,8 @@ func TestLog_WritesJSONL(t *testing.T) {
 		t.Fatalf("could not unmarshal event: %v", err)
 	}
 
-	if event.SchemaVersion != 1 {
-		t.Errorf("expected schema_version 1, got %d", event.SchemaVersion)
+	if event.SchemaVersion != 2 {
+		t.Errorf("expected schema_version 2, got %d", event.SchemaVersion)
 	}
 	if event.SessionID != logger.sessionID {
 		t.Errorf("expected session_id %q, got %q", logger.sessionID, event.SessionID)
@@ -293,6 +296,35 @@ func TestFingerprint(t *testing.T) {
 	}
 }
 
+func TestFingerprintIsKeyed(t *testing.T) {
+	plain := sha256.Sum256([]byte("14:/repos/myrepo"))
+	if Fingerprint("/repos/myrepo") == hex.EncodeToString(plain[:]) {
+		t.Error("fingerprint should not be a plain SHA-256 of its parts")
+	}
+}
+
+func TestLoadOrCreateKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "fingerprint.key")
+	key, err := loadOrCreateKey(path)
+	if err != nil || len(key) != fingerprintKeySize {
+		t.Fatalf("loadOrCreateKey() = %d bytes, %v", len(key), err)
+	}
+	if again, err := loadOrCreateKey(path); err != nil || !bytes.Equal(again, key) {
+		t.Errorf("expected the saved key to be reused, got %x (%v)", again, err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected an owner-only key file, got %v (%v)", info, err)
+	}
+
+	// A file that is not a key is an error, not something to overwrite.
+	if err := os.WriteFile(path, []byte("short"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadOrCreateKey(path); err == nil {
+		t.Error("expected an error for a malformed key file")
+	}
+}
+
 func TestFingerprint_SeparatorPreventsCollision(t *testing.T) {
 	// "a:b" + "c" should differ from "a" + "b:c"
 	fp1 := Fingerprint("a:b", "c")
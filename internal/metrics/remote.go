@@ -0,0 +1,229 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// remoteStateFile records consent and upload progress for the remote
+// endpoint, next to the event files.
+const remoteStateFile = "remote.json"
+
+// RemoteState is what katazuke remembers about the remote endpoint.
+type RemoteState struct {
+	// URL is the endpoint the user answered the consent prompt for. A
+	// different configured URL asks again.
+	URL string `json:"url"`
+	// Consent is the user's answer.
+	Consent bool `json:"consent"`
+	// SentUntil is the timestamp of the newest event already submitted.
+	SentUntil time.Time `json:"sent_until"`
+}
+
+// LoadRemoteState reads the remote state in dir. A missing file is the
+// zero state.
+func LoadRemoteState(dir string) (RemoteState, error) {
+	var st RemoteState
+	// #nosec G304 - fixed file name in the metrics directory
+	data, err := os.ReadFile(filepath.Join(dir, remoteStateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("metrics: read remote state: %w", err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("metrics: parse remote state: %w", err)
+	}
+	return st, nil
+}
+
+// SaveRemoteState writes the remote state to dir.
+func SaveRemoteState(dir string, st RemoteState) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return fmt.Errorf("metrics: encode remote state: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("metrics: create directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, remoteStateFile), data, 0o600); err != nil {
+		return fmt.Errorf("metrics: write remote state: %w", err)
+	}
+	return nil
+}
+
+// Submitter POSTs local events to a remote endpoint in batches. Events are
+// anonymized first; see Anonymize.
+type Submitter struct {
+	Dir   string
+	URL   string
+	Token string
+	// Client defaults to an http.Client with a 10 second timeout.
+	Client *http.Client
+	// BatchSize caps the events per request, 500 when zero.
+	BatchSize int
+	// Attempts is how often a batch is tried before giving up, 3 when
+	// zero. Network errors, 429, and 5xx responses are retried.
+	Attempts int
+	// Backoff is the wait before the first retry, doubling after each.
+	// 1 second when zero.
+	Backoff time.Duration
+}
+
+// Batch is the request body of a submission.
+type Batch struct {
+	Events []Event `json:"events"`
+}
+
+// Submit sends every event newer than the state's SentUntil and advances
+// it after each accepted batch, so an interrupted submission resumes where
+// it stopped. It returns the number of events sent. The caller is
+// responsible for having the user's consent.
+func (s *Submitter) Submit(ctx context.Context) (int, error) {
+	st, err := LoadRemoteState(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+	events, err := s.pending(st.SentUntil)
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := s.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	sent := 0
+	for start := 0; start < len(events); start += batchSize {
+		batch := events[start:min(start+batchSize, len(events))]
+		anon := make([]Event, len(batch))
+		for i, e := range batch {
+			anon[i] = Anonymize(e)
+		}
+		if err := s.post(ctx, Batch{Events: anon}); err != nil {
+			return sent, err
+		}
+		sent += len(batch)
+		st.SentUntil = batch[len(batch)-1].Timestamp
+		if err := SaveRemoteState(s.Dir, st); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// pending returns the events newer than after, oldest first.
+func (s *Submitter) pending(after time.Time) ([]Event, error) {
+	usage, err := DiskUsage(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var events []Event
+	for _, f := range usage.Files {
+		err := readEvents(filepath.Join(s.Dir, f.Name), f.Compressed, func(e Event) {
+			if e.Timestamp.After(after) {
+				events = append(events, e)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.Before(events[j].Timestamp) })
+	return events, nil
+}
+
+// post sends one batch, retrying transient failures.
+func (s *Submitter) post(ctx context.Context, batch Batch) error {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return fmt.Errorf("metrics: encode batch: %w", err)
+	}
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	attempts := s.Attempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		retry, err := s.postOnce(ctx, client, body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// postOnce sends one request and reports whether a failure is worth
+// retrying.
+func (s *Submitter) postOnce(ctx context.Context, client *http.Client, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("metrics: build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("metrics: submit: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("metrics: submit: %s", resp.Status)
+}
+
+// Anonymize strips anything from an event that could name a repository,
+// branch, or path. Suggestions already carry only fingerprints; command
+// flags keep their names but lose their values, since values like
+// --pattern or --group can name projects.
+func Anonymize(e Event) Event {
+	if e.Command != nil {
+		flags := make([]string, len(e.Command.Flags))
+		for i, f := range e.Command.Flags {
+			name, _, _ := strings.Cut(f, "=")
+			flags[i] = name
+		}
+		cmd := *e.Command
+		cmd.Flags = flags
+		e.Command = &cmd
+	}
+	return e
+}
@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSubmit_BatchesRetriesAndResumes(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.LogCommand("branches", []string{"--pattern=secret-project", "--dry-run"}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := logger.LogSuggestion("delete_stale_branch", Fingerprint("repo", "branch"), true, 40); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var requests atomic.Int32
+	var batches []Batch
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer tok" {
+			t.Errorf("unexpected Authorization header %q", got)
+		}
+		var b Batch
+		if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+			t.Errorf("decoding batch: %v", err)
+		}
+		batches = append(batches, b)
+	}))
+	defer srv.Close()
+
+	sub := &Submitter{Dir: dir, URL: srv.URL, Token: "tok", BatchSize: 2, Backoff: time.Millisecond}
+	sent, err := sub.Submit(context.Background())
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if sent != 3 || len(batches) != 2 || len(batches[0].Events) != 2 {
+		t.Fatalf("expected 3 events in batches of 2, got %d in %d batches", sent, len(batches))
+	}
+	flags := batches[0].Events[0].Command.Flags
+	if len(flags) != 2 || flags[0] != "--pattern" || flags[1] != "--dry-run" {
+		t.Errorf("expected flag values to be stripped, got %v", flags)
+	}
+
+	// Everything is sent; another run has nothing new.
+	sent, err = sub.Submit(context.Background())
+	if err != nil || sent != 0 {
+		t.Errorf("expected nothing left to send, got %d, %v", sent, err)
+	}
+}
+
+func TestSubmit_GivesUpOnClientError(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logger.LogCommand("sync", nil); err != nil {
+		t.Fatal(err)
+	}
+	_ = logger.Close()
+
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	sub := &Submitter{Dir: dir, URL: srv.URL, Backoff: time.Millisecond}
+	if _, err := sub.Submit(context.Background()); err == nil {
+		t.Fatal("expected an error for 401")
+	}
+	if requests.Load() != 1 {
+		t.Errorf("expected no retries for 401, got %d requests", requests.Load())
+	}
+	st, err := LoadRemoteState(dir)
+	if err != nil || !st.SentUntil.IsZero() {
+		t.Errorf("expected no progress to be saved, got %+v, %v", st, err)
+	}
+}
+
+func TestRemoteState_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if st, err := LoadRemoteState(dir); err != nil || st.URL != "" {
+		t.Fatalf("expected the zero state, got %+v, %v", st, err)
+	}
+	want := RemoteState{URL: "https://metrics.example.com", Consent: true, SentUntil: time.Unix(1700000000, 0).UTC()}
+	if err := SaveRemoteState(dir, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadRemoteState(dir)
+	if err != nil || got.URL != want.URL || !got.Consent || !got.SentUntil.Equal(want.SentUntil) {
+		t.Errorf("LoadRemoteState = %+v, %v", got, err)
+	}
+
+	// The state file is not mistaken for an event file.
+	if usage, _ := DiskUsage(dir); len(usage.Files) != 0 {
+		t.Errorf("expected no event files, got %+v", usage.Files)
+	}
+}
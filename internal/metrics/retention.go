@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention bounds how much metrics history is kept on disk. Zero values
+// disable the corresponding limit.
+type Retention struct {
+	// Months is how many monthly files to keep, including the current one.
+	Months int
+	// MaxBytes caps the total size of the metrics directory.
+	MaxBytes int64
+}
+
+// FileInfo describes a single metrics file on disk.
+type FileInfo struct {
+	Name       string
+	Month      time.Time
+	Size       int64
+	Compressed bool
+}
+
+// Usage summarizes the metrics directory's disk footprint.
+type Usage struct {
+	Dir        string
+	Files      []FileInfo // sorted oldest first
+	TotalBytes int64
+}
+
+// PruneResult reports what a retention pass changed.
+type PruneResult struct {
+	Deleted    []string
+	Compressed []string
+}
+
+// DefaultDir returns the default metrics directory
+// (~/.local/share/katazuke/metrics/).
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("metrics: home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "katazuke", "metrics"), nil
+}
+
+// DiskUsage lists the metrics files in dir. A missing directory reports
+// zero usage rather than an error.
+func DiskUsage(dir string) (Usage, error) {
+	u := Usage{Dir: dir}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return u, nil
+		}
+		return u, fmt.Errorf("metrics: read directory: %w", err)
+	}
+
+	for _, e := range entries {
+		month, compressed, ok := parseEventFileName(e.Name())
+		if !ok {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		u.Files = append(u.Files, FileInfo{
+			Name:       e.Name(),
+			Month:      month,
+			Size:       info.Size(),
+			Compressed: compressed,
+		})
+		u.TotalBytes += info.Size()
+	}
+
+	sort.Slice(u.Files, func(i, j int) bool {
+		return u.Files[i].Month.Before(u.Files[j].Month)
+	})
+	return u, nil
+}
+
+// Prune enforces r on dir. Files older than the retention window are
+// deleted, completed months are gzip-compressed, and if the directory is
+// still over MaxBytes the oldest files are deleted until it fits. The
+// current month's file is never touched because a Logger may be appending
+// to it.
+func Prune(dir string, r Retention, now time.Time) (PruneResult, error) {
+	var result PruneResult
+
+	usage, err := DiskUsage(dir)
+	if err != nil {
+		return result, err
+	}
+
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+	var cutoff time.Time
+	if r.Months > 0 {
+		cutoff = currentMonth.AddDate(0, -(r.Months - 1), 0)
+	}
+
+	var kept []FileInfo
+	for _, f := range usage.Files {
+		path := filepath.Join(dir, f.Name)
+		switch {
+		case !f.Month.Before(currentMonth):
+			kept = append(kept, f)
+		case !cutoff.IsZero() && f.Month.Before(cutoff):
+			if err := os.Remove(path); err != nil {
+				return result, fmt.Errorf("metrics: remove %s: %w", f.Name, err)
+			}
+			result.Deleted = append(result.Deleted, f.Name)
+		case !f.Compressed:
+			gzName, size, err := compressFile(path)
+			if err != nil {
+				return result, err
+			}
+			result.Compressed = append(result.Compressed, f.Name)
+			kept = append(kept, FileInfo{Name: gzName, Month: f.Month, Size: size, Compressed: true})
+		default:
+			kept = append(kept, f)
+		}
+	}
+
+	if r.MaxBytes <= 0 {
+		return result, nil
+	}
+
+	var total int64
+	for _, f := range kept {
+		total += f.Size
+	}
+	for _, f := range kept {
+		if total <= r.MaxBytes || !f.Month.Before(currentMonth) {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, f.Name)); err != nil {
+			return result, fmt.Errorf("metrics: remove %s: %w", f.Name, err)
+		}
+		result.Deleted = append(result.Deleted, f.Name)
+		total -= f.Size
+	}
+	return result, nil
+}
+
+// compressFile gzips path to path+".gz" and removes the original.
+// Returns the compressed file's name and size.
+func compressFile(path string) (string, int64, error) {
+	// #nosec G304 - path is a metrics file discovered in the metrics directory
+	src, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("metrics: open %s: %w", path, err)
+	}
+	defer func() { _ = src.Close() }()
+
+	gzPath := path + ".gz"
+	// #nosec G304 - path derived from a metrics file in the metrics directory
+	dst, err := os.OpenFile(gzPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", 0, fmt.Errorf("metrics: create %s: %w", gzPath, err)
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, copyErr := io.Copy(zw, src)
+	closeErr := zw.Close()
+	fileErr := dst.Close()
+	if err := firstErr(copyErr, closeErr, fileErr); err != nil {
+		_ = os.Remove(gzPath)
+		return "", 0, fmt.Errorf("metrics: compress %s: %w", path, err)
+	}
+
+	if err := os.Remove(path); err != nil {
+		return "", 0, fmt.Errorf("metrics: remove %s: %w", path, err)
+	}
+
+	info, err := os.Stat(gzPath)
+	if err != nil {
+		return "", 0, fmt.Errorf("metrics: stat %s: %w", gzPath, err)
+	}
+	return filepath.Base(gzPath), info.Size(), nil
+}
+
+// parseEventFileName extracts the month from an events-YYYY-MM.jsonl or
+// events-YYYY-MM.jsonl.gz file name.
+func parseEventFileName(name string) (month time.Time, compressed bool, ok bool) {
+	base := name
+	if strings.HasSuffix(base, ".gz") {
+		compressed = true
+		base = strings.TrimSuffix(base, ".gz")
+	}
+	if !strings.HasPrefix(base, "events-") || !strings.HasSuffix(base, ".jsonl") {
+		return time.Time{}, false, false
+	}
+	monthStr := strings.TrimSuffix(strings.TrimPrefix(base, "events-"), ".jsonl")
+	// Use time.Local to match eventFileName() which uses time.Now() (local).
+	month, err := time.ParseInLocation("2006-01", monthStr, time.Local)
+	if err != nil {
+		return time.Time{}, false, false
+	}
+	return month, compressed, true
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeEventFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
+
+func TestDiskUsage(t *testing.T) {
+	dir := t.TempDir()
+	writeEventFile(t, dir, "events-2026-02.jsonl", "12345")
+	writeEventFile(t, dir, "events-2026-01.jsonl.gz", "123")
+	writeEventFile(t, dir, "notes.txt", "ignored")
+
+	u, err := DiskUsage(dir)
+	if err != nil {
+		t.Fatalf("DiskUsage: %v", err)
+	}
+	if len(u.Files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(u.Files))
+	}
+	if u.Files[0].Name != "events-2026-01.jsonl.gz" || !u.Files[0].Compressed {
+		t.Errorf("expected oldest compressed file first, got %+v", u.Files[0])
+	}
+	if u.TotalBytes != 8 {
+		t.Errorf("expected 8 total bytes, got %d", u.TotalBytes)
+	}
+}
+
+func TestDiskUsage_MissingDir(t *testing.T) {
+	u, err := DiskUsage(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(u.Files) != 0 || u.TotalBytes != 0 {
+		t.Errorf("expected empty usage, got %+v", u)
+	}
+}
+
+func TestPrune_RetentionAndCompression(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local)
+
+	writeEventFile(t, dir, "events-2026-06.jsonl", "current\n")
+	writeEventFile(t, dir, "events-2026-05.jsonl", "last month\n")
+	writeEventFile(t, dir, "events-2026-04.jsonl.gz", "already compressed")
+	writeEventFile(t, dir, "events-2026-01.jsonl", "too old\n")
+
+	result, err := Prune(dir, Retention{Months: 3}, now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+
+	if strings.Join(result.Deleted, ",") != "events-2026-01.jsonl" {
+		t.Errorf("unexpected deletions: %v", result.Deleted)
+	}
+	if strings.Join(result.Compressed, ",") != "events-2026-05.jsonl" {
+		t.Errorf("unexpected compressions: %v", result.Compressed)
+	}
+
+	// Current month must stay uncompressed for the logger to append to.
+	if _, err := os.Stat(filepath.Join(dir, "events-2026-06.jsonl")); err != nil {
+		t.Errorf("current month file should remain: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "events-2026-05.jsonl.gz"))
+	if err != nil {
+		t.Fatalf("compressed file missing: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip: %v", err)
+	}
+	if string(data) != "last month\n" {
+		t.Errorf("compressed content mismatch: %q", data)
+	}
+}
+
+func TestPrune_SizeLimitDeletesOldestFirst(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local)
+
+	writeEventFile(t, dir, "events-2026-06.jsonl", strings.Repeat("x", 100))
+	writeEventFile(t, dir, "events-2026-05.jsonl.gz", strings.Repeat("x", 100))
+	writeEventFile(t, dir, "events-2026-04.jsonl.gz", strings.Repeat("x", 100))
+
+	result, err := Prune(dir, Retention{MaxBytes: 250}, now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if strings.Join(result.Deleted, ",") != "events-2026-04.jsonl.gz" {
+		t.Errorf("expected oldest file deleted, got %v", result.Deleted)
+	}
+}
+
+func TestPrune_NeverDeletesCurrentMonth(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 6, 15, 12, 0, 0, 0, time.Local)
+	writeEventFile(t, dir, "events-2026-06.jsonl", strings.Repeat("x", 100))
+
+	result, err := Prune(dir, Retention{Months: 1, MaxBytes: 10}, now)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if len(result.Deleted) != 0 {
+		t.Errorf("current month file should never be deleted, got %v", result.Deleted)
+	}
+}
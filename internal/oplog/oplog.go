@@ -14,6 +14,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
 )
 
 const schemaVersion = 1
@@ -28,6 +30,9 @@ const (
 	OpDeleteDir    OpType = "delete_dir"
 	OpMoveDir      OpType = "move_dir"
 	OpSwitchBranch OpType = "switch_branch"
+	OpDeleteTag    OpType = "delete_tag"
+	OpStashWIP     OpType = "stash_wip"
+	OpCommitWIP    OpType = "commit_wip"
 )
 
 // Operation represents a single logged destructive action.
@@ -44,6 +49,7 @@ type Operation struct {
 	RemoteURL     string `json:"remote_url,omitempty"`
 	WasForce      bool   `json:"was_force,omitempty"`
 	DeletedRemote bool   `json:"deleted_remote,omitempty"`
+	Tag           string `json:"tag,omitempty"`
 
 	// Repo/dir operations
 	Path        string `json:"path,omitempty"`
@@ -64,13 +70,12 @@ type Logger struct {
 }
 
 // New creates a Logger that writes to the default operations directory
-// (~/.local/share/katazuke/operations/). The directory is created if needed.
+// (~/.local/state/katazuke/operations/). The directory is created if needed.
 func New() (*Logger, error) {
-	home, err := os.UserHomeDir()
+	dir, err := state.Path(state.Operations)
 	if err != nil {
-		return nil, fmt.Errorf("oplog: home directory: %w", err)
+		return nil, fmt.Errorf("oplog: %w", err)
 	}
-	dir := filepath.Join(home, ".local", "share", "katazuke", "operations")
 	return NewWithDir(dir)
 }
 
@@ -165,11 +170,10 @@ func (l *Logger) Close() error {
 // operations directory. Unlike Logger methods, this does not create
 // directories or generate a session ID.
 func ReadOps(since time.Time) ([]Operation, error) {
-	home, err := os.UserHomeDir()
+	dir, err := state.Path(state.Operations)
 	if err != nil {
-		return nil, fmt.Errorf("oplog: home directory: %w", err)
+		return nil, fmt.Errorf("oplog: %w", err)
 	}
-	dir := filepath.Join(home, ".local", "share", "katazuke", "operations")
 	return readOpsFromDir(dir, since)
 }
 
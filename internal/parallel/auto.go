@@ -0,0 +1,45 @@
+package parallel
+
+import "runtime"
+
+// Workload describes what a pool's items mostly spend their time on, for
+// AutoWorkers.
+type Workload int
+
+const (
+	// LocalWork runs git against local repositories and is bound by CPU
+	// and disk.
+	LocalWork Workload = iota
+	// NetworkWork mostly waits on remotes or APIs: fetches, clones, and
+	// GitHub lookups.
+	NetworkWork
+)
+
+// Caps on automatically sized pools.
+const (
+	maxLocalWorkers   = 8
+	maxNetworkWorkers = 16
+)
+
+// AutoWorkers sizes a pool for items of workload w on this machine. Local
+// work gets one worker per CPU, since more would only contend for the same
+// cores and disk; network work gets four per CPU because its workers are
+// mostly idle. The result never exceeds items when items is positive.
+func AutoWorkers(w Workload, items int) int {
+	return autoWorkers(w, items, runtime.NumCPU())
+}
+
+func autoWorkers(w Workload, items, cpus int) int {
+	cpus = max(cpus, 1)
+	var n int
+	switch w {
+	case NetworkWork:
+		n = min(max(4*cpus, 4), maxNetworkWorkers)
+	default:
+		n = min(cpus, maxLocalWorkers)
+	}
+	if items > 0 {
+		n = min(n, items)
+	}
+	return n
+}
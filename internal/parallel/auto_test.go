@@ -0,0 +1,26 @@
+package parallel
+
+import "testing"
+
+func TestAutoWorkers(t *testing.T) {
+	tests := []struct {
+		name  string
+		w     Workload
+		items int
+		cpus  int
+		want  int
+	}{
+		{"local uses one per CPU", LocalWork, 100, 4, 4},
+		{"local is capped", LocalWork, 100, 32, maxLocalWorkers},
+		{"network oversubscribes", NetworkWork, 100, 2, 8},
+		{"network is capped", NetworkWork, 100, 32, maxNetworkWorkers},
+		{"network on one CPU", NetworkWork, 100, 1, 4},
+		{"no more than items", NetworkWork, 3, 8, 3},
+		{"unknown item count", LocalWork, 0, 6, 6},
+	}
+	for _, tt := range tests {
+		if got := autoWorkers(tt.w, tt.items, tt.cpus); got != tt.want {
+			t.Errorf("%s: autoWorkers(%d, %d, %d) = %d, want %d", tt.name, tt.w, tt.items, tt.cpus, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package parallel
+
+import "sync"
+
+// Limits caps how many items for the same host run at once, keyed by the
+// names a RunHosts host function returns (e.g. "api.github.com" or an NFS
+// mount point). Hosts without a positive limit, including the "" host, are
+// bounded only by the worker count.
+type Limits map[string]int
+
+// RunHosts is Run for items that depend on a shared host such as an API or
+// a network mount. host names the host of each item, "" for none. At most
+// limits[host] items of a host run at once, and free workers take items
+// from the hosts in turn, skipping hosts at their limit, so a slow or
+// throttled host neither starves the others nor is starved by them. As
+// with Run, onResult is called sequentially and results are returned in
+// completion order.
+func RunHosts[T any, R any](items []T, workers int, host func(T) string, limits Limits, fn func(T) R, onResult func(completed, total int, result R)) []R {
+	total := len(items)
+	if total == 0 {
+		return nil
+	}
+	if host == nil || len(limits) == 0 {
+		return Run(items, workers, fn, onResult)
+	}
+
+	// Clamp workers to [1, len(items)].
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > total {
+		workers = total
+	}
+
+	s := newHostScheduler(items, host, limits)
+	resultsCh := make(chan R, total)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				item, h, ok := s.next()
+				if !ok {
+					return
+				}
+				resultsCh <- fn(item)
+				s.done(h)
+			}
+		}()
+	}
+
+	// Close results channel once all workers finish.
+	go func() {
+		wg.Wait()
+		close(resultsCh)
+	}()
+
+	results := make([]R, 0, total)
+	for r := range resultsCh {
+		results = append(results, r)
+		if onResult != nil {
+			onResult(len(results), total, r)
+		}
+	}
+	return results
+}
+
+// hostScheduler hands out queued items round-robin across hosts while
+// keeping each host under its limit.
+type hostScheduler[T any] struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	hosts   []string       // in order of first appearance
+	queues  map[string][]T // pending items per host
+	running map[string]int
+	limits  Limits
+	pending int
+	cursor  int // index into hosts where the next search starts
+}
+
+func newHostScheduler[T any](items []T, host func(T) string, limits Limits) *hostScheduler[T] {
+	s := &hostScheduler[T]{
+		queues:  make(map[string][]T),
+		running: make(map[string]int),
+		limits:  limits,
+		pending: len(items),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	for _, item := range items {
+		h := host(item)
+		if _, seen := s.queues[h]; !seen {
+			s.hosts = append(s.hosts, h)
+		}
+		s.queues[h] = append(s.queues[h], item)
+	}
+	return s
+}
+
+// next blocks until an item can run and returns it with its host. ok is
+// false once every item has been handed out.
+func (s *hostScheduler[T]) next() (item T, host string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.pending > 0 {
+		for i := range s.hosts {
+			h := s.hosts[(s.cursor+i)%len(s.hosts)]
+			queue := s.queues[h]
+			if len(queue) == 0 {
+				continue
+			}
+			if limit := s.limits[h]; limit > 0 && s.running[h] >= limit {
+				continue
+			}
+			item = queue[0]
+			s.queues[h] = queue[1:]
+			s.running[h]++
+			s.pending--
+			s.cursor = (s.cursor + i + 1) % len(s.hosts)
+			return item, h, true
+		}
+		// Every host with pending items is at its limit.
+		s.cond.Wait()
+	}
+	return item, "", false
+}
+
+// done releases the slot an item of host held.
+func (s *hostScheduler[T]) done(host string) {
+	s.mu.Lock()
+	s.running[host]--
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
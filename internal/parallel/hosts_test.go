@@ -0,0 +1,103 @@
+package parallel
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type hostItem struct {
+	host string
+	n    int
+}
+
+func TestRunHosts_EnforcesLimits(t *testing.T) {
+	var items []hostItem
+	for i := range 12 {
+		h := "api"
+		if i%2 == 1 {
+			h = "local"
+		}
+		items = append(items, hostItem{h, i})
+	}
+
+	var mu sync.Mutex
+	running := map[string]int{}
+	peak := map[string]int{}
+	results := RunHosts(items, 6, func(it hostItem) string { return it.host }, Limits{"api": 2}, func(it hostItem) int {
+		mu.Lock()
+		running[it.host]++
+		peak[it.host] = max(peak[it.host], running[it.host])
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running[it.host]--
+		mu.Unlock()
+		return it.n
+	}, nil)
+
+	if len(results) != 12 {
+		t.Fatalf("expected 12 results, got %d", len(results))
+	}
+	if peak["api"] > 2 {
+		t.Errorf("expected at most 2 api items at once, got %d", peak["api"])
+	}
+	// The unlimited host uses the workers the limited one cannot.
+	if peak["local"] < 3 {
+		t.Errorf("expected local items to fill the free workers, peak %d", peak["local"])
+	}
+}
+
+func TestRunHosts_LimitedHostDoesNotStarveOthers(t *testing.T) {
+	// Slow API items come first; with a plain FIFO queue they would hold
+	// every worker until they finish.
+	var items []hostItem
+	for i := range 4 {
+		items = append(items, hostItem{"api", i})
+	}
+	for i := range 4 {
+		items = append(items, hostItem{"local", i})
+	}
+
+	var localDone atomic.Int32
+	var apiStartedAfterLocal atomic.Bool
+	RunHosts(items, 2, func(it hostItem) string { return it.host }, Limits{"api": 1}, func(it hostItem) int {
+		if it.host == "local" {
+			localDone.Add(1)
+			return it.n
+		}
+		if localDone.Load() > 0 {
+			apiStartedAfterLocal.Store(true)
+		}
+		time.Sleep(20 * time.Millisecond)
+		return it.n
+	}, nil)
+
+	if !apiStartedAfterLocal.Load() {
+		t.Error("expected local items to run while api items were throttled")
+	}
+}
+
+func TestRunHosts_CallbackAndNoLimits(t *testing.T) {
+	items := []hostItem{{"a", 1}, {"b", 2}, {"a", 3}}
+	var calls int
+	results := RunHosts(items, 2, func(it hostItem) string { return it.host }, nil, func(it hostItem) int {
+		return it.n
+	}, func(completed, total int, _ int) {
+		calls++
+		if completed != calls || total != 3 {
+			t.Errorf("unexpected callback (%d, %d) on call %d", completed, total, calls)
+		}
+	})
+	if len(results) != 3 || calls != 3 {
+		t.Errorf("expected 3 results and callbacks, got %d and %d", len(results), calls)
+	}
+
+	limited := RunHosts(items, 2, func(it hostItem) string { return it.host }, Limits{"a": 1}, func(it hostItem) int {
+		return it.n
+	}, nil)
+	if len(limited) != 3 {
+		t.Errorf("expected 3 results with limits, got %d", len(limited))
+	}
+}
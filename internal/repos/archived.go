@@ -3,9 +3,11 @@
 package repos
 
 import (
+	"errors"
 	"log/slog"
 	"path/filepath"
 
+	"github.com/agrahamlincoln/katazuke/internal/explain"
 	"github.com/agrahamlincoln/katazuke/internal/github"
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
@@ -27,8 +29,9 @@ type ArchivedRepo struct {
 
 // FindArchived scans the given repository paths and checks their GitHub
 // archive status. Repos without a GitHub remote are silently skipped.
-// Work is parallelized across the given number of workers.
-func FindArchived(repos []string, checker ArchiveChecker, workers int, onProgress func(completed, total int)) []ArchivedRepo {
+// Work is parallelized across the given number of workers. Each decision
+// is recorded to ex when it is non-nil.
+func FindArchived(repos []string, checker ArchiveChecker, workers int, ex *explain.Log, onProgress func(completed, total int)) []ArchivedRepo {
 	var resultCb func(int, int, *ArchivedRepo)
 	if onProgress != nil {
 		resultCb = func(completed, total int, _ *ArchivedRepo) {
@@ -37,7 +40,7 @@ func FindArchived(repos []string, checker ArchiveChecker, workers int, onProgres
 	}
 
 	results := parallel.Run(repos, workers, func(repoPath string) *ArchivedRepo {
-		return checkArchived(repoPath, checker)
+		return checkArchived(repoPath, checker, ex)
 	}, resultCb)
 
 	var archived []ArchivedRepo
@@ -49,35 +52,47 @@ func FindArchived(repos []string, checker ArchiveChecker, workers int, onProgres
 	return archived
 }
 
-func checkArchived(repoPath string, checker ArchiveChecker) *ArchivedRepo {
+func checkArchived(repoPath string, checker ArchiveChecker, ex *explain.Log) *ArchivedRepo {
 	name := filepath.Base(repoPath)
 
-	if !git.HasRemote(repoPath, "origin") {
-		slog.Debug("skipping repo without origin remote", "repo", name)
+	remote := git.PrimaryRemote(repoPath)
+	if remote == "" {
+		slog.Debug("skipping repo without a remote", "repo", name)
+		ex.Add(name, "", explain.Skipped, "no remote")
 		return nil
 	}
 
-	remoteURL, err := git.RemoteURL(repoPath, "origin")
+	remoteURLs, err := git.RemoteURLs(repoPath, remote)
 	if err != nil {
 		slog.Debug("could not get remote URL", "repo", name, "error", err)
+		ex.Add(name, "", explain.Skipped, "could not read "+remote+" URL")
 		return nil
 	}
 
-	owner, repo, ok := github.ParseGitHubRemote(remoteURL)
+	owner, repo, ok := github.ParseGitHubRemotes(remoteURLs)
 	if !ok {
-		slog.Debug("not a GitHub remote", "repo", name, "url", remoteURL)
+		slog.Debug("not a GitHub remote", "repo", name, "urls", remoteURLs)
+		ex.Add(name, "", explain.Skipped, remote+" is not a GitHub remote")
 		return nil
 	}
 
 	isArchived, err := checker.IsArchived(owner, repo)
+	if errors.Is(err, github.ErrOrgNotAllowed) {
+		slog.Debug("skipping archive check for filtered org", "repo", name, "owner", owner)
+		ex.Addf(name, "", explain.Skipped, "owner %s is excluded from GitHub API lookups", owner)
+		return nil
+	}
 	if err != nil {
 		slog.Warn("could not check archive status", "repo", name, "error", err)
+		ex.Addf(name, "", explain.Skipped, "archive status unavailable: %v", err)
 		return nil
 	}
 
 	if !isArchived {
+		ex.Addf(name, "", explain.Excluded, "%s/%s is not archived", owner, repo)
 		return nil
 	}
+	ex.Addf(name, "", explain.Reported, "%s/%s is archived on GitHub", owner, repo)
 
 	clean, err := git.IsClean(repoPath)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"log/slog"
 	"path/filepath"
 
+	"github.com/agrahamlincoln/katazuke/internal/explain"
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
@@ -23,12 +24,12 @@ type MergedBranchRepo struct {
 // that are checked out on a branch that has been merged into the default
 // branch. Work is parallelized across the given number of workers. The
 // detector combines local git checks with GitHub API lookups to catch
-// squash-merges.
+// squash-merges. Each decision is recorded to ex when it is non-nil.
 //
 // Note: local git checks operate on locally cached remote refs without
 // fetching first, so results reflect the last fetch rather than current
 // remote state.
-func FindOnMergedBranch(repos []string, detector *merge.Detector, workers int, onProgress func(completed, total int)) []MergedBranchRepo {
+func FindOnMergedBranch(repos []string, detector *merge.Detector, workers int, ex *explain.Log, onProgress func(completed, total int)) []MergedBranchRepo {
 	var resultCb func(int, int, *MergedBranchRepo)
 	if onProgress != nil {
 		resultCb = func(completed, total int, _ *MergedBranchRepo) {
@@ -37,7 +38,7 @@ func FindOnMergedBranch(repos []string, detector *merge.Detector, workers int, o
 	}
 
 	results := parallel.Run(repos, workers, func(repoPath string) *MergedBranchRepo {
-		return checkMergedBranch(repoPath, detector)
+		return checkMergedBranch(repoPath, detector, ex)
 	}, resultCb)
 
 	var merged []MergedBranchRepo
@@ -49,39 +50,54 @@ func FindOnMergedBranch(repos []string, detector *merge.Detector, workers int, o
 	return merged
 }
 
-func checkMergedBranch(repoPath string, detector *merge.Detector) *MergedBranchRepo {
+func checkMergedBranch(repoPath string, detector *merge.Detector, ex *explain.Log) *MergedBranchRepo {
 	name := filepath.Base(repoPath)
 
+	if op := git.ConflictState(repoPath); op != "" {
+		ex.Addf(name, "", explain.Skipped, "%s in progress", op)
+		return nil
+	}
+
 	currentBranch, err := git.CurrentBranch(repoPath)
 	if err != nil {
 		slog.Debug("could not get current branch", "repo", name, "error", err)
+		ex.Add(name, "", explain.Skipped, "could not determine current branch")
 		return nil
 	}
 
 	if currentBranch == "" {
+		ex.Add(name, "", explain.Excluded, "detached HEAD")
 		return nil
 	}
 
 	defaultBranch, err := git.DefaultBranch(repoPath)
 	if err != nil {
 		slog.Debug("could not get default branch", "repo", name, "error", err)
+		ex.Add(name, "", explain.Skipped, "could not determine default branch")
 		return nil
 	}
 
 	if currentBranch == defaultBranch {
+		ex.Addf(name, "", explain.Excluded, "on the default branch %s", defaultBranch)
 		return nil
 	}
 
 	// Determine merge base: use remote default branch if available.
 	base := defaultBranch
-	if git.HasRemote(repoPath, "origin") {
-		base = "origin/" + defaultBranch
+	if remote := git.PrimaryRemote(repoPath); remote != "" {
+		base = remote + "/" + defaultBranch
 	}
 
 	merged, err := detector.IsMerged(repoPath, currentBranch, base)
-	if err != nil || !merged {
+	if err != nil {
+		ex.Addf(name, "", explain.Skipped, "could not check whether %s is merged: %v", currentBranch, err)
+		return nil
+	}
+	if !merged {
+		ex.Addf(name, "", explain.Excluded, "on %s, which is not merged into %s (git or GitHub PR)", currentBranch, base)
 		return nil
 	}
+	ex.Addf(name, "", explain.Reported, "on %s, which is merged into %s", currentBranch, base)
 
 	clean, err := git.IsClean(repoPath)
 	if err != nil {
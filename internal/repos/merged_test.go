@@ -32,7 +32,7 @@ func TestFindOnMergedBranch(t *testing.T) {
 	onDefault := helpers.NewTestRepo(t, "on-default")
 
 	repoPaths := []string{merged.Path, unmerged.Path, onDefault.Path}
-	result := repos.FindOnMergedBranch(repoPaths, merge.GitOnlyDetector(), 1, nil)
+	result := repos.FindOnMergedBranch(repoPaths, merge.GitOnlyDetector(), 1, nil, nil)
 
 	if len(result) != 1 {
 		t.Fatalf("expected 1 merged branch repo, got %d", len(result))
@@ -66,7 +66,7 @@ func TestFindOnMergedBranchDirty(t *testing.T) {
 		t.Fatalf("write file: %v", err)
 	}
 
-	result := repos.FindOnMergedBranch([]string{dirtyMerged.Path}, merge.GitOnlyDetector(), 1, nil)
+	result := repos.FindOnMergedBranch([]string{dirtyMerged.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 
 	if len(result) != 1 {
 		t.Fatalf("expected 1 result, got %d", len(result))
@@ -90,7 +90,7 @@ func TestFindOnMergedBranch_DetachedHEAD(t *testing.T) {
 	// Detach HEAD -- not "on a merged branch", should return no results.
 	repo.DetachHead()
 
-	result := repos.FindOnMergedBranch([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil)
+	result := repos.FindOnMergedBranch([]string{repo.Path}, merge.GitOnlyDetector(), 1, nil, nil)
 
 	if len(result) != 0 {
 		t.Fatalf("expected 0 results for detached HEAD, got %d", len(result))
@@ -98,7 +98,7 @@ func TestFindOnMergedBranch_DetachedHEAD(t *testing.T) {
 }
 
 func TestFindOnMergedBranchEmpty(t *testing.T) {
-	result := repos.FindOnMergedBranch(nil, merge.GitOnlyDetector(), 1, nil)
+	result := repos.FindOnMergedBranch(nil, merge.GitOnlyDetector(), 1, nil, nil)
 	if len(result) != 0 {
 		t.Fatalf("expected 0 results for empty input, got %d", len(result))
 	}
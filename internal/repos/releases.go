@@ -0,0 +1,233 @@
+package repos
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	"github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// ReleaseChecker finds the latest release of a GitHub repository.
+type ReleaseChecker interface {
+	LatestRelease(owner, repo string) (string, error)
+}
+
+// OutdatedRelease is a repository checked out at a release tag that is far
+// behind the latest release.
+type OutdatedRelease struct {
+	Path string
+	Name string
+	// Current is the release tag HEAD is at; Latest is the newest release.
+	Current string
+	Latest  string
+	// Behind is how many releases came out after Current, Latest included.
+	Behind int
+	// Branch is the checked-out branch, "" when HEAD is detached at Current.
+	Branch string
+	// Remote is the primary remote, to fetch Latest from when it is not
+	// local yet.
+	Remote  string
+	IsClean bool
+}
+
+// FindOutdatedReleases reports repositories whose HEAD is at a release tag
+// (v1.2.3, 1.2, ...) at least minBehind releases, or a major version,
+// behind the latest one. The latest release comes from GitHub when the
+// repository has a GitHub remote with releases, and otherwise from the
+// newest local release tag. Work is parallelized across the given number
+// of workers. Each decision is recorded to ex when it is non-nil.
+func FindOutdatedReleases(repos []string, checker ReleaseChecker, minBehind, workers int, ex *explain.Log, onProgress func(completed, total int)) []OutdatedRelease {
+	var resultCb func(int, int, *OutdatedRelease)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *OutdatedRelease) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, func(repoPath string) *OutdatedRelease {
+		return checkOutdatedRelease(repoPath, checker, minBehind, ex)
+	}, resultCb)
+
+	var outdated []OutdatedRelease
+	for _, r := range results {
+		if r != nil {
+			outdated = append(outdated, *r)
+		}
+	}
+	return outdated
+}
+
+func checkOutdatedRelease(repoPath string, checker ReleaseChecker, minBehind int, ex *explain.Log) *OutdatedRelease {
+	name := filepath.Base(repoPath)
+
+	atHead, err := git.TagsAt(repoPath, "HEAD")
+	if err != nil {
+		slog.Debug("could not list tags at HEAD", "repo", name, "error", err)
+		return nil
+	}
+	current, currentVersion, ok := newestRelease(atHead)
+	if !ok {
+		// Not tracked by tag; nothing to report.
+		return nil
+	}
+
+	tags, err := git.ListTags(repoPath)
+	if err != nil {
+		slog.Debug("could not list tags", "repo", name, "error", err)
+		ex.Addf(name, "", explain.Skipped, "could not list tags: %v", err)
+		return nil
+	}
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+
+	remote := git.PrimaryRemote(repoPath)
+	latest, latestVersion, ok := newestRelease(names)
+	if published := latestPublished(repoPath, remote, checker, ex); published != "" {
+		if v, valid := parseRelease(published); valid {
+			latest, latestVersion, ok = published, v, true
+		}
+	}
+	if !ok || !latestVersion.newerThan(currentVersion) {
+		ex.Addf(name, "", explain.Excluded, "%s is the latest release", current)
+		return nil
+	}
+
+	// Count the releases after current. Latest counts even when its tag
+	// is not fetched yet.
+	newer := map[releaseVersion]bool{latestVersion: true}
+	for _, n := range names {
+		if v, valid := parseRelease(n); valid && v.newerThan(currentVersion) && !v.newerThan(latestVersion) {
+			newer[v] = true
+		}
+	}
+	behind := len(newer)
+
+	if behind < minBehind && latestVersion[0] == currentVersion[0] {
+		ex.Addf(name, "", explain.Excluded, "%s is %d release(s) behind %s", current, behind, latest)
+		return nil
+	}
+	ex.Addf(name, "", explain.Reported, "%s is %d release(s) behind %s", current, behind, latest)
+
+	branch, err := git.CurrentBranch(repoPath)
+	if err != nil {
+		slog.Debug("could not read current branch", "repo", name, "error", err)
+	}
+	clean, err := git.IsClean(repoPath)
+	if err != nil {
+		slog.Warn("could not check working tree status", "repo", name, "error", err)
+		clean = false // assume dirty when in doubt
+	}
+	return &OutdatedRelease{
+		Path:    repoPath,
+		Name:    name,
+		Current: current,
+		Latest:  latest,
+		Behind:  behind,
+		Branch:  branch,
+		Remote:  remote,
+		IsClean: clean,
+	}
+}
+
+// latestPublished returns the latest GitHub release of the repository, or
+// "" when it has no GitHub remote, no releases, or cannot be queried.
+func latestPublished(repoPath, remote string, checker ReleaseChecker, ex *explain.Log) string {
+	if remote == "" || checker == nil {
+		return ""
+	}
+	name := filepath.Base(repoPath)
+	urls, err := git.RemoteURLs(repoPath, remote)
+	if err != nil {
+		return ""
+	}
+	owner, repo, ok := github.ParseGitHubRemotes(urls)
+	if !ok {
+		return ""
+	}
+	tag, err := checker.LatestRelease(owner, repo)
+	if errors.Is(err, github.ErrOrgNotAllowed) {
+		ex.Addf(name, "", explain.Skipped, "owner %s is excluded from GitHub API lookups, using local tags", owner)
+		return ""
+	}
+	if err != nil {
+		slog.Warn("could not check latest release, using local tags", "repo", name, "error", err)
+		return ""
+	}
+	return tag
+}
+
+// UpdateRelease checks out the latest release with a detached HEAD,
+// fetching its tag from the primary remote first when it is not local.
+// A branch that was checked out is left where it was.
+func UpdateRelease(r OutdatedRelease) error {
+	if _, err := git.RevParse(r.Path, "refs/tags/"+r.Latest); err != nil {
+		if r.Remote == "" {
+			return fmt.Errorf("%s is not fetched and there is no remote to fetch it from", r.Latest)
+		}
+		if err := git.FetchTag(r.Path, r.Remote, r.Latest); err != nil {
+			return fmt.Errorf("fetching %s from %s: %w", r.Latest, r.Remote, err)
+		}
+	}
+	if err := git.CheckoutDetached(r.Path, "refs/tags/"+r.Latest); err != nil {
+		return fmt.Errorf("checking out %s: %w", r.Latest, err)
+	}
+	return nil
+}
+
+// releaseVersion is the MAJOR.MINOR.PATCH of a release tag.
+type releaseVersion [3]int
+
+// newerThan reports whether v is a later release than other.
+func (v releaseVersion) newerThan(other releaseVersion) bool {
+	for i := range v {
+		if v[i] != other[i] {
+			return v[i] > other[i]
+		}
+	}
+	return false
+}
+
+// parseRelease parses a release tag: one to three dot-separated numbers
+// with an optional "v" prefix, e.g. v1.2.3, 1.2, or v4. Prereleases
+// (v1.2.3-rc1) and other tags are not releases.
+func parseRelease(tag string) (releaseVersion, bool) {
+	var v releaseVersion
+	parts := strings.Split(strings.TrimPrefix(tag, "v"), ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || p[0] == '+' {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+// newestRelease returns the newest release tag among tags.
+func newestRelease(tags []string) (string, releaseVersion, bool) {
+	sorted := append([]string(nil), tags...)
+	sort.Strings(sorted)
+	var best string
+	var bestVersion releaseVersion
+	found := false
+	for _, t := range sorted {
+		v, ok := parseRelease(t)
+		if ok && (!found || v.newerThan(bestVersion)) {
+			best, bestVersion, found = t, v, true
+		}
+	}
+	return best, bestVersion, found
+}
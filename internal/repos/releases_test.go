@@ -0,0 +1,94 @@
+package repos_test
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// mockReleases implements repos.ReleaseChecker for testing.
+type mockReleases map[string]string
+
+func (m mockReleases) LatestRelease(owner, repo string) (string, error) {
+	return m[owner+"/"+repo], nil
+}
+
+// tagReleases commits and tags each release in turn.
+func tagReleases(repo *helpers.TestRepo, tags ...string) {
+	for _, tag := range tags {
+		repo.WriteFile("VERSION", tag)
+		repo.AddFile("VERSION")
+		repo.Commit("Release " + tag)
+		repo.Git("tag", tag)
+	}
+}
+
+func TestFindOutdatedReleases(t *testing.T) {
+	pinned := helpers.NewTestRepo(t, "pinned")
+	tagReleases(pinned, "v1.0.0", "v1.1.0", "v1.2.0", "v1.3.0-rc1", "v1.3.0")
+	pinned.Git("checkout", "--detach", "v1.0.0")
+
+	recent := helpers.NewTestRepo(t, "recent")
+	tagReleases(recent, "v1.0.0", "v1.1.0")
+	recent.Git("checkout", "--detach", "v1.0.0")
+
+	major := helpers.NewTestRepo(t, "major")
+	tagReleases(major, "1.0", "2.0")
+	major.Git("checkout", "-b", "pinned-branch", "1.0")
+
+	untagged := helpers.NewTestRepo(t, "untagged")
+	tagReleases(untagged, "v1.0.0")
+	untagged.WriteFile("README.md", "after the release")
+	untagged.AddFile("README.md")
+	untagged.Commit("Unreleased work")
+
+	// The GitHub release is newer than any fetched tag.
+	published := helpers.NewTestRepo(t, "published")
+	tagReleases(published, "v3.0.0", "v3.1.0")
+	published.Git("checkout", "--detach", "v3.1.0")
+	published.Git("remote", "add", "origin", "https://github.com/acme/tool.git")
+
+	checker := mockReleases{"acme/tool": "v4.0.0"}
+	found := repos.FindOutdatedReleases(
+		[]string{pinned.Path, recent.Path, major.Path, untagged.Path, published.Path},
+		checker, 3, 2, nil, nil)
+
+	byName := make(map[string]repos.OutdatedRelease)
+	for _, r := range found {
+		byName[r.Name] = r
+	}
+	if len(byName) != 3 {
+		t.Fatalf("expected pinned, major, and published, got %+v", found)
+	}
+	if r := byName["pinned"]; r.Current != "v1.0.0" || r.Latest != "v1.3.0" || r.Behind != 3 || r.Branch != "" {
+		t.Errorf("unexpected pinned result: %+v", r)
+	}
+	if r := byName["major"]; r.Latest != "2.0" || r.Behind != 1 || r.Branch != "pinned-branch" {
+		t.Errorf("expected a major release behind to be reported, got %+v", r)
+	}
+	if r := byName["published"]; r.Latest != "v4.0.0" || r.Behind != 1 || r.Remote != "origin" {
+		t.Errorf("expected the GitHub release to be latest, got %+v", r)
+	}
+}
+
+func TestUpdateRelease(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "tool")
+	tagReleases(repo, "v1.0.0", "v2.0.0")
+	repo.Git("checkout", "--detach", "v1.0.0")
+
+	if err := repos.UpdateRelease(repos.OutdatedRelease{Path: repo.Path, Latest: "v2.0.0"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	head, _ := git.RevParse(repo.Path, "HEAD")
+	tagged, _ := git.RevParse(repo.Path, "v2.0.0^{commit}")
+	if head != tagged {
+		t.Errorf("expected HEAD at v2.0.0 (%s), got %s", tagged, head)
+	}
+
+	err := repos.UpdateRelease(repos.OutdatedRelease{Path: repo.Path, Latest: "v3.0.0"})
+	if err == nil {
+		t.Error("expected an error for a tag that cannot be fetched")
+	}
+}
@@ -0,0 +1,97 @@
+package repos
+
+import (
+	"log/slog"
+	"path/filepath"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// RemoteHeadRepo is a repository whose remote HEAD symref (e.g.
+// refs/remotes/origin/HEAD) no longer matches the remote. DefaultBranch
+// reads that symref, so every command that compares against the default
+// branch uses the wrong one until it is repaired.
+type RemoteHeadRepo struct {
+	Path   string
+	Name   string
+	Remote string
+	// Recorded is the branch the local symref points to, "" when unset.
+	Recorded string
+	// Actual is the branch the remote reports as its HEAD.
+	Actual string
+	// Dangling is true when the recorded branch has no remote-tracking
+	// ref, so the symref resolves to nothing.
+	Dangling bool
+}
+
+// FindStaleRemoteHeads asks the primary remote of each repository for its
+// HEAD branch and reports the repositories whose local symref is missing,
+// dangling, or names a different branch. This contacts every remote.
+// Repositories without a remote, or whose remote cannot be reached, are
+// omitted. Work is parallelized across the given number of workers.
+func FindStaleRemoteHeads(repos []string, workers int, onProgress func(completed, total int)) []RemoteHeadRepo {
+	var resultCb func(int, int, *RemoteHeadRepo)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *RemoteHeadRepo) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, checkRemoteHead, resultCb)
+
+	var stale []RemoteHeadRepo
+	for _, r := range results {
+		if r != nil {
+			stale = append(stale, *r)
+		}
+	}
+	return stale
+}
+
+func checkRemoteHead(repoPath string) *RemoteHeadRepo {
+	name := filepath.Base(repoPath)
+	remote := git.PrimaryRemote(repoPath)
+	if remote == "" {
+		return nil
+	}
+
+	ref, err := git.RemoteHead(repoPath, remote)
+	if err != nil {
+		slog.Debug("could not read remote HEAD", "repo", name, "remote", remote, "error", err)
+		return nil
+	}
+	actual, err := git.RemoteDefaultBranch(repoPath, remote)
+	if err != nil {
+		slog.Warn("could not query remote HEAD", "repo", name, "remote", remote, "error", err)
+		return nil
+	}
+
+	recorded := strings.TrimPrefix(ref, "refs/remotes/"+remote+"/")
+	dangling := false
+	if ref != "" {
+		_, err := git.RevParse(repoPath, ref)
+		dangling = err != nil
+	}
+	if ref != "" && !dangling && recorded == actual {
+		return nil
+	}
+	return &RemoteHeadRepo{
+		Path:     repoPath,
+		Name:     name,
+		Remote:   remote,
+		Recorded: recorded,
+		Actual:   actual,
+		Dangling: dangling,
+	}
+}
+
+// RepairRemoteHead fetches the remote, so the branch it now reports has a
+// remote-tracking ref, and points the remote HEAD symref at it.
+func RepairRemoteHead(r RemoteHeadRepo) error {
+	if err := git.Fetch(r.Path, r.Remote); err != nil {
+		return err
+	}
+	return git.RefreshRemoteHead(r.Path, r.Remote)
+}
@@ -0,0 +1,46 @@
+package repos_test
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFindStaleRemoteHeads(t *testing.T) {
+	current, _ := helpers.NewClonedRepo(t, "current")
+
+	// The remote renamed main to trunk after the clone.
+	renamed, bare := helpers.NewClonedRepo(t, "renamed")
+	renamed.Git("--git-dir", bare, "branch", "-m", "main", "trunk")
+
+	stale := repos.FindStaleRemoteHeads([]string{current.Path, renamed.Path}, 1, nil)
+	if len(stale) != 1 {
+		t.Fatalf("expected 1 stale remote HEAD, got %d: %+v", len(stale), stale)
+	}
+	r := stale[0]
+	if r.Name != "renamed" || r.Remote != "origin" || r.Recorded != "main" || r.Actual != "trunk" {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if r.Dangling {
+		t.Error("origin/main is still fetched, expected the symref not to dangle")
+	}
+
+	// Once origin/main is pruned the recorded symref resolves to nothing.
+	renamed.Git("fetch", "--prune", "origin")
+	stale = repos.FindStaleRemoteHeads([]string{renamed.Path}, 1, nil)
+	if len(stale) != 1 || !stale[0].Dangling {
+		t.Fatalf("expected a dangling remote HEAD, got %+v", stale)
+	}
+
+	if err := repos.RepairRemoteHead(stale[0]); err != nil {
+		t.Fatalf("RepairRemoteHead: %v", err)
+	}
+	if got, err := git.DefaultBranch(renamed.Path); err != nil || got != "trunk" {
+		t.Errorf("expected default branch trunk after repair, got %q (%v)", got, err)
+	}
+	if stale := repos.FindStaleRemoteHeads([]string{renamed.Path}, 1, nil); len(stale) != 0 {
+		t.Errorf("expected no stale remote HEAD after repair, got %+v", stale)
+	}
+}
@@ -0,0 +1,183 @@
+package repos
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/agrahamlincoln/katazuke/internal/explain"
+	"github.com/agrahamlincoln/katazuke/internal/github"
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// RenameChecker finds the current owner and name of a GitHub repository.
+type RenameChecker interface {
+	CanonicalName(owner, repo string) (string, string, error)
+}
+
+// RenamedRepo is a local repository whose GitHub remote was renamed or
+// transferred, so its remote URL only works through a redirect.
+type RenamedRepo struct {
+	Path   string
+	Name   string
+	Remote string
+	// Owner and Repo are what the remote URL names; NewOwner and NewRepo
+	// are where the repository lives now.
+	Owner    string
+	Repo     string
+	NewOwner string
+	NewRepo  string
+	// URL and PushURL are the remote's configured URLs, PushURL "" when
+	// it has none of its own. NewURL and NewPushURL name the new location
+	// and are "" for a URL that does not name the old one.
+	URL        string
+	NewURL     string
+	PushURL    string
+	NewPushURL string
+	// NewPath is where the checkout would be renamed to match the new
+	// name, "" when its directory is not named after the repository, the
+	// name did not change, or NewPath already exists.
+	NewPath string
+}
+
+// OldName returns the repository's old "owner/repo".
+func (r RenamedRepo) OldName() string {
+	return r.Owner + "/" + r.Repo
+}
+
+// NewName returns the repository's new "owner/repo".
+func (r RenamedRepo) NewName() string {
+	return r.NewOwner + "/" + r.NewRepo
+}
+
+// FindRenamed scans the given repository paths and reports those whose
+// GitHub remote now lives under a different owner or name. Repos without a
+// GitHub remote are silently skipped. Work is parallelized across the given
+// number of workers. Each decision is recorded to ex when it is non-nil.
+func FindRenamed(repos []string, checker RenameChecker, workers int, ex *explain.Log, onProgress func(completed, total int)) []RenamedRepo {
+	var resultCb func(int, int, *RenamedRepo)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *RenamedRepo) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, func(repoPath string) *RenamedRepo {
+		return checkRenamed(repoPath, checker, ex)
+	}, resultCb)
+
+	var renamed []RenamedRepo
+	for _, r := range results {
+		if r != nil {
+			renamed = append(renamed, *r)
+		}
+	}
+	return renamed
+}
+
+func checkRenamed(repoPath string, checker RenameChecker, ex *explain.Log) *RenamedRepo {
+	name := filepath.Base(repoPath)
+
+	remote := git.PrimaryRemote(repoPath)
+	if remote == "" {
+		slog.Debug("skipping repo without a remote", "repo", name)
+		ex.Add(name, "", explain.Skipped, "no remote")
+		return nil
+	}
+	remoteURLs, err := git.RemoteURLs(repoPath, remote)
+	if err != nil {
+		slog.Debug("could not get remote URL", "repo", name, "error", err)
+		ex.Add(name, "", explain.Skipped, "could not read "+remote+" URL")
+		return nil
+	}
+	owner, repo, ok := github.ParseGitHubRemotes(remoteURLs)
+	if !ok {
+		slog.Debug("not a GitHub remote", "repo", name, "urls", remoteURLs)
+		ex.Add(name, "", explain.Skipped, remote+" is not a GitHub remote")
+		return nil
+	}
+
+	newOwner, newRepo, err := checker.CanonicalName(owner, repo)
+	if errors.Is(err, github.ErrOrgNotAllowed) {
+		slog.Debug("skipping rename check for filtered org", "repo", name, "owner", owner)
+		ex.Addf(name, "", explain.Skipped, "owner %s is excluded from GitHub API lookups", owner)
+		return nil
+	}
+	if err != nil {
+		slog.Warn("could not check for a rename", "repo", name, "error", err)
+		ex.Addf(name, "", explain.Skipped, "repository lookup failed: %v", err)
+		return nil
+	}
+	// GitHub names are case-insensitive; only a case change is no move.
+	if strings.EqualFold(owner+"/"+repo, newOwner+"/"+newRepo) {
+		ex.Addf(name, "", explain.Excluded, "%s/%s has not moved", owner, repo)
+		return nil
+	}
+	ex.Addf(name, "", explain.Reported, "%s/%s is now %s/%s", owner, repo, newOwner, newRepo)
+
+	r := &RenamedRepo{
+		Path:     repoPath,
+		Name:     name,
+		Remote:   remote,
+		Owner:    owner,
+		Repo:     repo,
+		NewOwner: newOwner,
+		NewRepo:  newRepo,
+	}
+	// Read the configured URLs rather than the ones git resolves, so
+	// insteadOf shorthands survive the update.
+	r.URL, _ = git.ConfigValue(repoPath, "remote."+remote+".url")
+	r.NewURL = renameRemoteURL(r.URL, owner, repo, newOwner, newRepo)
+	r.PushURL, _ = git.ConfigValue(repoPath, "remote."+remote+".pushurl")
+	r.NewPushURL = renameRemoteURL(r.PushURL, owner, repo, newOwner, newRepo)
+
+	if strings.EqualFold(name, repo) && name != newRepo {
+		newPath := filepath.Join(filepath.Dir(repoPath), newRepo)
+		if _, err := os.Lstat(newPath); errors.Is(err, os.ErrNotExist) {
+			r.NewPath = newPath
+		}
+	}
+	return r
+}
+
+// renameRemoteURL returns remoteURL with its trailing owner/repo path
+// replaced by newOwner/newRepo, keeping the host, scheme, and any .git
+// suffix. It returns "" when remoteURL does not end in owner/repo.
+func renameRemoteURL(remoteURL, owner, repo, newOwner, newRepo string) string {
+	trimmed := strings.TrimSuffix(remoteURL, "/")
+	suffix := remoteURL[len(trimmed):]
+	if base, found := strings.CutSuffix(trimmed, ".git"); found {
+		trimmed, suffix = base, ".git"+suffix
+	}
+	old := owner + "/" + repo
+	if len(trimmed) <= len(old) || !strings.EqualFold(trimmed[len(trimmed)-len(old):], old) {
+		return ""
+	}
+	prefix := trimmed[:len(trimmed)-len(old)]
+	if sep := prefix[len(prefix)-1]; sep != '/' && sep != ':' {
+		return ""
+	}
+	return prefix + newOwner + "/" + newRepo + suffix
+}
+
+// UpdateRemote points the repository's remote at the new location.
+func UpdateRemote(r RenamedRepo) error {
+	if r.NewURL == "" && r.NewPushURL == "" {
+		return fmt.Errorf("%s does not name %s, update it by hand", r.URL, r.OldName())
+	}
+	if r.NewURL != "" {
+		if err := git.SetRemoteURL(r.Path, r.Remote, r.NewURL, false); err != nil {
+			return fmt.Errorf("updating %s URL: %w", r.Remote, err)
+		}
+	}
+	if r.NewPushURL != "" {
+		if err := git.SetRemoteURL(r.Path, r.Remote, r.NewPushURL, true); err != nil {
+			return fmt.Errorf("updating %s push URL: %w", r.Remote, err)
+		}
+	}
+	return nil
+}
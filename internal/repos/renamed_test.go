@@ -0,0 +1,93 @@
+package repos_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// mockRenames implements repos.RenameChecker for testing. Repos missing
+// from the map have not moved.
+type mockRenames map[string][2]string
+
+func (m mockRenames) CanonicalName(owner, repo string) (string, string, error) {
+	if moved, ok := m[owner+"/"+repo]; ok {
+		return moved[0], moved[1], nil
+	}
+	return owner, repo, nil
+}
+
+func TestFindRenamed(t *testing.T) {
+	renamed := helpers.NewTestRepo(t, "old-name")
+	renamed.Git("remote", "add", "origin", "git@github.com:acme/old-name.git")
+
+	transferred := helpers.NewTestRepo(t, "app")
+	transferred.Git("remote", "add", "origin", "https://github.com/acme/app")
+	transferred.Git("remote", "set-url", "--push", "origin", "ssh://git@github.com/acme/app.git")
+
+	unmoved := helpers.NewTestRepo(t, "tool")
+	unmoved.Git("remote", "add", "origin", "https://github.com/acme/tool.git")
+
+	// A checkout whose new name is taken is not offered a rename.
+	taken := helpers.NewTestRepo(t, "lib")
+	taken.Git("remote", "add", "origin", "https://github.com/acme/lib.git")
+	if err := os.Mkdir(filepath.Join(filepath.Dir(taken.Path), "lib2"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	checker := mockRenames{
+		"acme/old-name": {"acme", "new-name"},
+		"acme/app":      {"new-org", "app"},
+		"acme/lib":      {"acme", "lib2"},
+	}
+	found := repos.FindRenamed([]string{renamed.Path, transferred.Path, unmoved.Path, taken.Path}, checker, 2, nil, nil)
+
+	byName := make(map[string]repos.RenamedRepo)
+	for _, r := range found {
+		byName[r.Name] = r
+	}
+	if len(byName) != 3 {
+		t.Fatalf("expected 3 moved repos, got %+v", found)
+	}
+
+	r := byName["old-name"]
+	if r.NewName() != "acme/new-name" || r.NewURL != "git@github.com:acme/new-name.git" {
+		t.Errorf("unexpected rename: %+v", r)
+	}
+	if want := filepath.Join(filepath.Dir(renamed.Path), "new-name"); r.NewPath != want {
+		t.Errorf("expected the directory to be offered a rename to %s, got %q", want, r.NewPath)
+	}
+
+	r = byName["app"]
+	if r.NewURL != "https://github.com/new-org/app" || r.NewPushURL != "ssh://git@github.com/new-org/app.git" {
+		t.Errorf("expected both URLs to follow the transfer, got %+v", r)
+	}
+	if r.NewPath != "" {
+		t.Errorf("the name did not change, expected no directory rename, got %q", r.NewPath)
+	}
+
+	if r := byName["lib"]; r.NewPath != "" {
+		t.Errorf("expected no rename onto an existing directory, got %q", r.NewPath)
+	}
+}
+
+func TestUpdateRemote(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "old-name")
+	repo.Git("remote", "add", "origin", "git@github.com:acme/old-name.git")
+
+	err := repos.UpdateRemote(repos.RenamedRepo{Path: repo.Path, Remote: "origin", NewURL: "git@github.com:acme/new-name.git"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if url, _ := git.RemoteURL(repo.Path, "origin"); url != "git@github.com:acme/new-name.git" {
+		t.Errorf("expected the remote to point at the new name, got %q", url)
+	}
+
+	if err := repos.UpdateRemote(repos.RenamedRepo{Path: repo.Path, Remote: "origin", URL: "gh:elsewhere"}); err == nil {
+		t.Error("expected an error when no URL names the old location")
+	}
+}
@@ -0,0 +1,111 @@
+package repos
+
+import (
+	"errors"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// UnpushedBranch is a local branch with commits that exist on no remote.
+type UnpushedBranch struct {
+	Name string
+	// Commits is the number of commits not reachable from any remote.
+	Commits int
+	// HasUpstream is false when the branch was never pushed or its
+	// tracking branch is not configured.
+	HasUpstream bool
+	// Behind is the number of upstream commits not yet pulled, as of the
+	// last fetch. A branch both ahead and behind needs a rebase or merge
+	// before it can be pushed.
+	Behind int
+}
+
+// UnpushedRepo summarizes local-only work in a single repository: branches
+// with unpushed commits, stash entries, and uncommitted changes. All of
+// these are lost if the checkout is deleted.
+type UnpushedRepo struct {
+	Path     string
+	Name     string
+	Branches []UnpushedBranch
+	Stashes  int
+	IsClean  bool
+}
+
+// FindUnpushed scans the given repositories for work that exists only
+// locally. Repos with nothing unpushed, no stashes, and a clean working
+// tree are omitted. Work is parallelized across the given number of workers.
+//
+// Note: remote-tracking refs are not fetched first, so a commit pushed from
+// another machine since the last fetch is still reported as unpushed. This
+// errs on the side of caution.
+func FindUnpushed(repos []string, workers int, onProgress func(completed, total int)) []UnpushedRepo {
+	var resultCb func(int, int, *UnpushedRepo)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *UnpushedRepo) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, checkUnpushed, resultCb)
+
+	var unpushed []UnpushedRepo
+	for _, r := range results {
+		if r != nil {
+			unpushed = append(unpushed, *r)
+		}
+	}
+	return unpushed
+}
+
+func checkUnpushed(repoPath string) *UnpushedRepo {
+	name := filepath.Base(repoPath)
+	r := &UnpushedRepo{Path: repoPath, Name: name}
+
+	localBranches, err := git.ListBranches(repoPath)
+	if err != nil {
+		slog.Warn("could not list branches", "repo", name, "error", err)
+		return nil
+	}
+
+	for _, branch := range localBranches {
+		count, err := git.UnpushedCount(repoPath, branch)
+		if err != nil {
+			slog.Debug("could not count unpushed commits",
+				"repo", name, "branch", branch, "error", err)
+			continue
+		}
+		if count == 0 {
+			continue
+		}
+		b := UnpushedBranch{Name: branch, Commits: count}
+		_, behind, err := git.AheadBehindUpstream(repoPath, branch)
+		switch {
+		case err == nil:
+			b.HasUpstream, b.Behind = true, behind
+		case !errors.Is(err, git.ErrNoUpstream):
+			slog.Debug("could not compare with upstream",
+				"repo", name, "branch", branch, "error", err)
+			b.HasUpstream = true
+		}
+		r.Branches = append(r.Branches, b)
+	}
+
+	r.Stashes, err = git.StashCount(repoPath)
+	if err != nil {
+		slog.Debug("could not count stash entries", "repo", name, "error", err)
+	}
+
+	r.IsClean, err = git.IsClean(repoPath)
+	if err != nil {
+		slog.Warn("could not check working tree status", "repo", name, "error", err)
+		r.IsClean = false // assume dirty when in doubt
+	}
+
+	if len(r.Branches) == 0 && r.Stashes == 0 && r.IsClean {
+		return nil
+	}
+	return r
+}
@@ -0,0 +1,73 @@
+package repos_test
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFindUnpushed(t *testing.T) {
+	// Fully pushed clone: nothing to report.
+	pushed, _ := helpers.NewClonedRepo(t, "pushed")
+
+	// Clone with an unpushed commit on main and a never-pushed branch.
+	local, _ := helpers.NewClonedRepo(t, "local-work")
+	local.WriteFile("main.txt", "ahead of origin")
+	local.AddFile("main.txt")
+	local.Commit("local commit on main")
+	local.CreateBranch("graham/experiment")
+	local.WriteFile("exp.txt", "experiment")
+	local.AddFile("exp.txt")
+	local.Commit("experiment 1")
+	local.WriteFile("exp.txt", "experiment 2")
+	local.AddFile("exp.txt")
+	local.Commit("experiment 2")
+	local.Checkout("main")
+
+	// Clone with only a stash entry.
+	stashed, _ := helpers.NewClonedRepo(t, "stashed")
+	stashed.WriteFile("README.md", "changed\n")
+	stashed.Git("stash", "push", "-m", "wip")
+
+	result := repos.FindUnpushed([]string{pushed.Path, local.Path, stashed.Path}, 1, nil)
+	if len(result) != 2 {
+		t.Fatalf("expected 2 repos with local-only work, got %d: %+v", len(result), result)
+	}
+
+	byName := make(map[string]repos.UnpushedRepo)
+	for _, r := range result {
+		byName[r.Name] = r
+	}
+
+	lw, ok := byName["local-work"]
+	if !ok {
+		t.Fatal("expected local-work in results")
+	}
+	if len(lw.Branches) != 2 {
+		t.Fatalf("expected 2 unpushed branches, got %+v", lw.Branches)
+	}
+	for _, b := range lw.Branches {
+		switch b.Name {
+		case "main":
+			if b.Commits != 1 || !b.HasUpstream || b.Behind != 0 {
+				t.Errorf("main: expected 1 commit with upstream, got %+v", b)
+			}
+		case "graham/experiment":
+			// The branch includes main's unpushed commit plus its own two.
+			if b.Commits != 3 || b.HasUpstream {
+				t.Errorf("experiment: expected 3 commits without upstream, got %+v", b)
+			}
+		default:
+			t.Errorf("unexpected branch %q", b.Name)
+		}
+	}
+
+	st, ok := byName["stashed"]
+	if !ok {
+		t.Fatal("expected stashed in results")
+	}
+	if st.Stashes != 1 || len(st.Branches) != 0 || !st.IsClean {
+		t.Errorf("expected only a stash entry, got %+v", st)
+	}
+}
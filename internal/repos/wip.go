@@ -0,0 +1,223 @@
+package repos
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// ForgottenWIPRepo is a repository with uncommitted changes that nobody
+// has touched in a while: work in progress that was likely forgotten.
+type ForgottenWIPRepo struct {
+	Path string
+	Name string
+	// Branch is the checked-out branch, "" when HEAD is detached.
+	Branch string
+	// Files is the number of changed and untracked files.
+	Files int
+	// LastModified is the newest modification time among the changed
+	// files.
+	LastModified time.Time
+}
+
+// FindForgottenWIP reports repositories whose working tree is dirty but
+// whose changed files were all last modified before cutoff. Deleted files
+// count with the modification time of their directory. Work is
+// parallelized across the given number of workers.
+func FindForgottenWIP(repos []string, cutoff time.Time, workers int, onProgress func(completed, total int)) []ForgottenWIPRepo {
+	var resultCb func(int, int, *ForgottenWIPRepo)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ *ForgottenWIPRepo) {
+			onProgress(completed, total)
+		}
+	}
+
+	results := parallel.Run(repos, workers, func(repoPath string) *ForgottenWIPRepo {
+		return checkForgottenWIP(repoPath, cutoff)
+	}, resultCb)
+
+	var forgotten []ForgottenWIPRepo
+	for _, r := range results {
+		if r != nil {
+			forgotten = append(forgotten, *r)
+		}
+	}
+	return forgotten
+}
+
+func checkForgottenWIP(repoPath string, cutoff time.Time) *ForgottenWIPRepo {
+	name := filepath.Base(repoPath)
+	// Changes in the middle of a rebase or merge belong to it, not to a
+	// forgotten branch.
+	if op := git.ConflictState(repoPath); op != "" {
+		slog.Debug("skipping repo: operation in progress", "repo", name, "operation", op)
+		return nil
+	}
+	files, err := git.ChangedFiles(repoPath)
+	if err != nil {
+		slog.Debug("could not list changed files", "repo", name, "error", err)
+		return nil
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	var newest time.Time
+	for _, f := range files {
+		if mtime := modTime(filepath.Join(repoPath, f)); mtime.After(newest) {
+			newest = mtime
+		}
+	}
+	if !newest.Before(cutoff) {
+		return nil
+	}
+
+	branch, err := git.CurrentBranch(repoPath)
+	if err != nil {
+		slog.Debug("could not read current branch", "repo", name, "error", err)
+	}
+	return &ForgottenWIPRepo{
+		Path:         repoPath,
+		Name:         name,
+		Branch:       branch,
+		Files:        len(files),
+		LastModified: newest,
+	}
+}
+
+// modTime returns the modification time of path, or of the nearest
+// directory above it that still exists when path was deleted.
+func modTime(path string) time.Time {
+	for {
+		if info, err := os.Lstat(path); err == nil {
+			return info.ModTime()
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return time.Time{}
+		}
+		path = parent
+	}
+}
+
+// StashWIP stashes every change in the repository, untracked files
+// included, and returns the stash commit so it can be found again.
+func StashWIP(r ForgottenWIPRepo, message string) (string, error) {
+	created, err := git.StashPushAll(r.Path, message)
+	if err != nil {
+		return "", err
+	}
+	if !created {
+		return "", fmt.Errorf("nothing to stash")
+	}
+	return git.RevParse(r.Path, "refs/stash")
+}
+
+// CommitWIP commits every change in the repository to a new
+// wip/katazuke-<date> branch and returns to the branch (or detached
+// commit) it was on, leaving a clean working tree. It returns the WIP
+// branch and its commit.
+func CommitWIP(r ForgottenWIPRepo, now time.Time) (branch, sha string, err error) {
+	back, err := checkedOut(r)
+	if err != nil {
+		return "", "", err
+	}
+	branch, sha, err = commitToNewBranch(r, "wip/katazuke-"+now.Format("2006-01-02"), "katazuke: forgotten WIP", back)
+	if err != nil {
+		return "", "", err
+	}
+	if err := git.Checkout(r.Path, back); err != nil {
+		return branch, sha, fmt.Errorf("committed to %s but could not return to %s: %w", branch, back, err)
+	}
+	return branch, sha, nil
+}
+
+// ParkResult is where Park put a repository's changes.
+type ParkResult struct {
+	// Branch is the wip/ branch holding the changes.
+	Branch string
+	SHA    string
+	// DefaultBranch is the branch checked out afterwards.
+	DefaultBranch string
+	// Remote is where the branch was pushed, "" when it was not.
+	Remote string
+}
+
+// Park commits every change in the repository to a new
+// wip/<date>-<branch> branch, optionally pushes it to the primary
+// remote, and checks out a clean default branch. A failed push leaves the
+// branch parked locally and is returned as an error alongside the result.
+func Park(r ForgottenWIPRepo, now time.Time, push bool) (ParkResult, error) {
+	back, err := checkedOut(r)
+	if err != nil {
+		return ParkResult{}, err
+	}
+	defaultBranch, err := git.DefaultBranch(r.Path)
+	if err != nil {
+		return ParkResult{}, fmt.Errorf("finding the default branch: %w", err)
+	}
+
+	from := r.Branch
+	if from == "" {
+		from = "detached"
+	}
+	var result ParkResult
+	result.Branch, result.SHA, err = commitToNewBranch(r, "wip/"+now.Format("2006-01-02")+"-"+from, "katazuke: park WIP from "+from, back)
+	if err != nil {
+		return ParkResult{}, err
+	}
+	if err := git.Checkout(r.Path, defaultBranch); err != nil {
+		return result, fmt.Errorf("parked on %s but could not check out %s: %w", result.Branch, defaultBranch, err)
+	}
+	result.DefaultBranch = defaultBranch
+
+	if push {
+		remote := git.PrimaryRemote(r.Path)
+		if remote == "" {
+			return result, fmt.Errorf("parked on %s but there is no remote to push it to", result.Branch)
+		}
+		if err := git.PushBranch(r.Path, remote, result.Branch); err != nil {
+			return result, fmt.Errorf("parked on %s but pushing it to %s failed: %w", result.Branch, remote, err)
+		}
+		result.Remote = remote
+	}
+	return result, nil
+}
+
+// checkedOut returns the branch the repository is on, or the HEAD commit
+// when it is detached, for returning to later.
+func checkedOut(r ForgottenWIPRepo) (string, error) {
+	if r.Branch != "" {
+		return r.Branch, nil
+	}
+	return git.RevParse(r.Path, "HEAD")
+}
+
+// commitToNewBranch creates a branch named after base, commits every
+// change to it, and returns the branch and its commit. On failure it
+// returns to back with the changes still in the working tree.
+func commitToNewBranch(r ForgottenWIPRepo, base, message, back string) (branch, sha string, err error) {
+	branch = git.UnusedBranchName(base, func(name string) bool { return git.BranchExists(r.Path, name) })
+	if err := git.CreateBranch(r.Path, branch); err != nil {
+		return "", "", fmt.Errorf("creating %s: %w", branch, err)
+	}
+	if err := git.CommitAll(r.Path, message); err != nil {
+		// The changes are still in the working tree; carry them back.
+		if coErr := git.Checkout(r.Path, back); coErr != nil {
+			slog.Debug("could not return to previous branch", "repo", r.Name, "error", coErr)
+		} else if delErr := git.DeleteLocalBranch(r.Path, branch, true); delErr != nil {
+			slog.Debug("could not remove empty WIP branch", "repo", r.Name, "branch", branch, "error", delErr)
+		}
+		return "", "", fmt.Errorf("committing to %s (changes left in working tree): %w", branch, err)
+	}
+	sha, err = git.RevParse(r.Path, "HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	return branch, sha, nil
+}
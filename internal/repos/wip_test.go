@@ -0,0 +1,151 @@
+package repos_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/repos"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// age sets the modification time of a file in repo to d ago.
+func age(t *testing.T, repo *helpers.TestRepo, filename string, d time.Duration) {
+	t.Helper()
+	old := time.Now().Add(-d)
+	if err := os.Chtimes(filepath.Join(repo.Path, filename), old, old); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFindForgottenWIP(t *testing.T) {
+	clean := helpers.NewTestRepo(t, "clean")
+
+	fresh := helpers.NewTestRepo(t, "fresh")
+	fresh.WriteFile("README.md", "edited today")
+
+	forgotten := helpers.NewTestRepo(t, "forgotten")
+	forgotten.WriteFile("README.md", "edited long ago")
+	forgotten.WriteFile("notes.txt", "untracked")
+	age(t, forgotten, "README.md", 30*24*time.Hour)
+	age(t, forgotten, "notes.txt", 20*24*time.Hour)
+
+	// One old file and one recent one: the repo is still being worked on.
+	mixed := helpers.NewTestRepo(t, "mixed")
+	mixed.WriteFile("README.md", "edited long ago")
+	mixed.WriteFile("today.txt", "untracked")
+	age(t, mixed, "README.md", 30*24*time.Hour)
+
+	cutoff := time.Now().Add(-14 * 24 * time.Hour)
+	found := repos.FindForgottenWIP([]string{clean.Path, fresh.Path, forgotten.Path, mixed.Path}, cutoff, 2, nil)
+	if len(found) != 1 {
+		t.Fatalf("expected 1 repo with forgotten WIP, got %d: %+v", len(found), found)
+	}
+	r := found[0]
+	if r.Name != "forgotten" || r.Branch != "main" || r.Files != 2 {
+		t.Errorf("unexpected result: %+v", r)
+	}
+	if got := time.Since(r.LastModified); got < 19*24*time.Hour || got > 21*24*time.Hour {
+		t.Errorf("expected last modified 20 days ago, got %s ago", got)
+	}
+}
+
+func TestStashWIP(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "repo")
+	repo.WriteFile("README.md", "edited")
+	repo.WriteFile("notes.txt", "untracked")
+
+	sha, err := repos.StashWIP(repos.ForgottenWIPRepo{Path: repo.Path, Name: "repo", Branch: "main"}, "forgotten WIP")
+	if err != nil {
+		t.Fatalf("StashWIP: %v", err)
+	}
+	if want, _ := git.RevParse(repo.Path, "stash@{0}"); sha == "" || sha != want {
+		t.Errorf("expected stash commit %q, got %q", want, sha)
+	}
+	if files, _ := git.ChangedFiles(repo.Path); len(files) != 0 {
+		t.Errorf("expected a clean working tree, got %v", files)
+	}
+}
+
+func TestCommitWIP(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "repo")
+	repo.CreateBranch("wip/katazuke-2026-03-01")
+	repo.Checkout("main")
+	repo.WriteFile("README.md", "edited")
+	repo.WriteFile("notes.txt", "untracked")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	branch, sha, err := repos.CommitWIP(repos.ForgottenWIPRepo{Path: repo.Path, Name: "repo", Branch: "main"}, now)
+	if err != nil {
+		t.Fatalf("CommitWIP: %v", err)
+	}
+	if branch != "wip/katazuke-2026-03-01-2" {
+		t.Errorf("expected a suffixed branch name, got %q", branch)
+	}
+	if want, _ := git.RevParse(repo.Path, branch); sha != want {
+		t.Errorf("expected %s at %q, got %q", branch, want, sha)
+	}
+	if got, _ := git.CurrentBranch(repo.Path); got != "main" {
+		t.Errorf("expected to be back on main, got %q", got)
+	}
+	if files, _ := git.ChangedFiles(repo.Path); len(files) != 0 {
+		t.Errorf("expected a clean working tree, got %v", files)
+	}
+}
+
+func TestCommitWIPDetached(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "repo")
+	repo.DetachHead()
+	head, _ := git.RevParse(repo.Path, "HEAD")
+	repo.WriteFile("notes.txt", "untracked")
+
+	if _, _, err := repos.CommitWIP(repos.ForgottenWIPRepo{Path: repo.Path, Name: "repo"}, time.Now()); err != nil {
+		t.Fatalf("CommitWIP: %v", err)
+	}
+	if got, _ := git.RevParse(repo.Path, "HEAD"); got != head {
+		t.Errorf("expected to be back at %s, got %s", head, got)
+	}
+}
+
+func TestPark(t *testing.T) {
+	repo, bare := helpers.NewClonedRepo(t, "repo")
+	repo.CreateBranch("feature/login")
+	repo.WriteFile("README.md", "edited")
+	repo.WriteFile("notes.txt", "untracked")
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	parked, err := repos.Park(repos.ForgottenWIPRepo{Path: repo.Path, Name: "repo", Branch: "feature/login"}, now, true)
+	if err != nil {
+		t.Fatalf("Park: %v", err)
+	}
+	if parked.Branch != "wip/2026-03-01-feature/login" || parked.DefaultBranch != "main" || parked.Remote != "origin" {
+		t.Errorf("unexpected result: %+v", parked)
+	}
+	if got, _ := git.CurrentBranch(repo.Path); got != "main" {
+		t.Errorf("expected to be on main, got %q", got)
+	}
+	if files, _ := git.ChangedFiles(repo.Path); len(files) != 0 {
+		t.Errorf("expected a clean working tree, got %v", files)
+	}
+	if got, err := git.RevParse(bare, "refs/heads/"+parked.Branch); err != nil || got != parked.SHA {
+		t.Errorf("expected %s pushed at %s, got %q (%v)", parked.Branch, parked.SHA, got, err)
+	}
+}
+
+func TestParkWithoutRemoteKeepsBranchLocal(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "repo")
+	repo.WriteFile("README.md", "edited")
+
+	parked, err := repos.Park(repos.ForgottenWIPRepo{Path: repo.Path, Name: "repo", Branch: "main"}, time.Now(), true)
+	if err == nil {
+		t.Fatal("expected an error pushing without a remote")
+	}
+	if parked.Branch == "" || !git.BranchExists(repo.Path, parked.Branch) {
+		t.Errorf("expected the WIP to stay parked locally, got %+v", parked)
+	}
+	if files, _ := git.ChangedFiles(repo.Path); len(files) != 0 {
+		t.Errorf("expected a clean working tree, got %v", files)
+	}
+}
@@ -4,12 +4,15 @@ package scanner
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/goccy/go-yaml"
 
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
@@ -22,6 +25,42 @@ type IndexFile struct {
 // Options controls scanning behavior.
 type Options struct {
 	ExcludePatterns []string
+	// MaxDepth is how many directory levels below a directory without a
+	// .katazuke file are searched for repositories. 1 (or 0) treats only
+	// immediate children as repositories; 2 also finds <org>/<repo>.
+	// Descent stops at the first git repository on each path.
+	MaxDepth int
+	// Workers is how many directories are checked for a .git entry
+	// concurrently. Values below 1 check serially.
+	Workers int
+	// IncludeBare also returns bare repositories and mirrors. They are
+	// never descended into either way, but most commands inspect branches
+	// and working trees, which bare repositories do not have.
+	IncludeBare bool
+	// ExcludeRemotes drops repositories whose primary remote URL matches
+	// any of the patterns, whatever their directory is called. A pattern
+	// starting with "re:" is a regular expression; any other pattern is a
+	// case-insensitive glob in which "*" also matches "/". Each pattern is
+	// tried against the URL as written and against its host/path form, so
+	// "github.com/acme/*" matches both git@github.com:acme/tool.git and
+	// https://github.com/acme/tool. Checking them reads each repository's
+	// git config.
+	ExcludeRemotes []string
+	// IncludePatterns, when set, keeps only repositories whose directory
+	// name matches one of the globs (as ExcludePatterns) or whose primary
+	// remote URL matches one of the patterns (as ExcludeRemotes).
+	// Directories are still searched whatever their name.
+	IncludePatterns []string
+
+	excludeMatchers []*regexp.Regexp
+	includeMatchers []*regexp.Regexp
+}
+
+func (o Options) maxDepth() int {
+	if o.MaxDepth < 1 {
+		return 1
+	}
+	return o.MaxDepth
 }
 
 // Scan discovers git repositories under rootPath.
@@ -29,20 +68,37 @@ type Options struct {
 // The algorithm:
 //  1. If a .katazuke file exists in a directory, parse it for groups/ignores
 //     and recurse into group subdirectories.
-//  2. If no .katazuke file exists, treat all immediate children as potential repositories.
+//  2. If no .katazuke file exists, treat all immediate children as potential
+//     repositories. Children that are not repositories are searched up to
+//     opts.MaxDepth levels deep, stopping at the first repository found.
 //  3. Hidden directories (starting with ".") are always skipped.
 //  4. Symlink cycles are detected via visited-path tracking.
+//
+// Repositories are recognized by statting their .git entry (or, for bare
+// repositories, their HEAD, objects, and refs) rather than running git,
+// and the children of each directory are checked across opts.Workers
+// goroutines, which matters on network filesystems.
 func Scan(rootPath string, opts Options) ([]string, error) {
+	var err error
+	if opts.excludeMatchers, err = compileRemotePatterns("exclude_remotes", opts.ExcludeRemotes); err != nil {
+		return nil, err
+	}
+	if opts.includeMatchers, err = compileRemotePatterns("include_patterns", opts.IncludePatterns); err != nil {
+		return nil, err
+	}
+
 	visited := make(map[string]bool)
 	var repos []string
 
-	if err := scan(rootPath, opts, visited, &repos); err != nil {
+	if err := scan(rootPath, opts, visited, &repos, 1); err != nil {
 		return nil, err
 	}
 	return repos, nil
 }
 
-func scan(dir string, opts Options, visited map[string]bool, repos *[]string) error {
+// scan discovers repositories in dir. depth is the level of dir's children
+// below the nearest indexed directory (or the scan root), starting at 1.
+func scan(dir string, opts Options, visited map[string]bool, repos *[]string, depth int) error {
 	resolved, err := filepath.EvalSymlinks(dir)
 	if err != nil {
 		return fmt.Errorf("resolving symlink %s: %w", dir, err)
@@ -60,7 +116,7 @@ func scan(dir string, opts Options, visited map[string]bool, repos *[]string) er
 	if hasIndex {
 		return scanWithIndex(dir, idx, opts, visited, repos)
 	}
-	return scanFlat(dir, opts, repos)
+	return scanFlat(dir, opts, visited, repos, depth)
 }
 
 func scanWithIndex(dir string, idx IndexFile, opts Options, visited map[string]bool, repos *[]string) error {
@@ -80,40 +136,59 @@ func scanWithIndex(dir string, idx IndexFile, opts Options, visited map[string]b
 		if !info.IsDir() {
 			continue
 		}
-		if err := scan(groupPath, opts, visited, repos); err != nil {
+		// Groups are declared explicitly, so depth restarts inside them.
+		if err := scan(groupPath, opts, visited, repos, 1); err != nil {
 			return err
 		}
 	}
 
 	// Scan non-group, non-ignored children at this level as potential repos.
-	entries, err := os.ReadDir(dir)
+	children, err := listChildren(dir, opts, func(name string) bool {
+		return groupSet[name] || ignoreSet[name]
+	})
 	if err != nil {
-		return fmt.Errorf("reading directory %s: %w", dir, err)
+		return err
 	}
-	for _, entry := range entries {
-		name := entry.Name()
-		if strings.HasPrefix(name, ".") {
+	for _, c := range checkChildren(children, opts) {
+		if c.excluded {
 			continue
 		}
-		if !entry.IsDir() {
-			continue
+		if c.isRepo || (c.isBare && opts.IncludeBare) {
+			*repos = append(*repos, c.path)
 		}
-		if groupSet[name] || ignoreSet[name] || IsExcluded(name, opts.ExcludePatterns) {
+	}
+	return nil
+}
+
+func scanFlat(dir string, opts Options, visited map[string]bool, repos *[]string, depth int) error {
+	children, err := listChildren(dir, opts, nil)
+	if err != nil {
+		return err
+	}
+	for _, c := range checkChildren(children, opts) {
+		if c.isRepo || c.isBare {
+			if !c.excluded && (c.isRepo || opts.IncludeBare) {
+				*repos = append(*repos, c.path)
+			}
 			continue
 		}
-		child := filepath.Join(dir, name)
-		if git.IsRepo(child) {
-			*repos = append(*repos, child)
+		if depth < opts.maxDepth() {
+			if err := scan(c.path, opts, visited, repos, depth+1); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
-func scanFlat(dir string, opts Options, repos *[]string) error {
+// listChildren returns the non-hidden, non-excluded subdirectories of dir
+// in name order. skip, if non-nil, drops additional names.
+func listChildren(dir string, opts Options, skip func(name string) bool) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		return fmt.Errorf("reading directory %s: %w", dir, err)
+		return nil, fmt.Errorf("reading directory %s: %w", dir, err)
 	}
+	var children []string
 	for _, entry := range entries {
 		name := entry.Name()
 		if strings.HasPrefix(name, ".") {
@@ -122,15 +197,48 @@ func scanFlat(dir string, opts Options, repos *[]string) error {
 		if !entry.IsDir() {
 			continue
 		}
-		if IsExcluded(name, opts.ExcludePatterns) {
+		if IsExcluded(name, opts.ExcludePatterns) || (skip != nil && skip(name)) {
 			continue
 		}
-		child := filepath.Join(dir, name)
-		if git.IsRepo(child) {
-			*repos = append(*repos, child)
+		children = append(children, filepath.Join(dir, name))
+	}
+	return children, nil
+}
+
+// child is a directory, whether it is a git working tree or a bare
+// repository, and whether ExcludeRemotes or IncludePatterns leave it out.
+type child struct {
+	path     string
+	isRepo   bool
+	isBare   bool
+	excluded bool
+}
+
+// checkChildren checks each path for a .git entry across opts.Workers
+// workers, returning the results in the order of paths.
+func checkChildren(paths []string, opts Options) []child {
+	results := parallel.Run(paths, opts.Workers, func(path string) child {
+		c := child{path: path}
+		if git.HasGitDir(path) {
+			c.isRepo = true
+		} else {
+			c.isBare = git.IsBareRepo(path)
+		}
+		if c.isRepo || c.isBare {
+			c.excluded = filtered(path, opts)
 		}
+		return c
+	}, nil)
+
+	byPath := make(map[string]child, len(results))
+	for _, r := range results {
+		byPath[r.path] = r
 	}
-	return nil
+	ordered := make([]child, len(paths))
+	for i, path := range paths {
+		ordered[i] = byPath[path]
+	}
+	return ordered
 }
 
 // LoadIndex loads and validates a .katazuke file from the given directory.
@@ -186,3 +294,88 @@ func IsExcluded(name string, patterns []string) bool {
 	}
 	return false
 }
+
+// filtered reports whether the repository at path is left out by
+// opts.ExcludeRemotes or opts.IncludePatterns.
+func filtered(path string, opts Options) bool {
+	if len(opts.excludeMatchers) > 0 && remoteMatches(path, opts.excludeMatchers) {
+		slog.Debug("excluding repo by remote", "repo", filepath.Base(path))
+		return true
+	}
+	if len(opts.IncludePatterns) == 0 || IsExcluded(filepath.Base(path), opts.IncludePatterns) {
+		return false
+	}
+	if remoteMatches(path, opts.includeMatchers) {
+		return false
+	}
+	slog.Debug("repo matches no include pattern", "repo", filepath.Base(path))
+	return true
+}
+
+// remoteMatches reports whether any URL of the repository's primary remote
+// matches one of matchers.
+func remoteMatches(repoPath string, matchers []*regexp.Regexp) bool {
+	remote := git.PrimaryRemote(repoPath)
+	if remote == "" {
+		return false
+	}
+	urls, err := git.RemoteURLs(repoPath, remote)
+	if err != nil {
+		return false
+	}
+	for _, u := range urls {
+		if matchRemote(u, matchers) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchRemote(remoteURL string, matchers []*regexp.Regexp) bool {
+	hostPath := remoteHostPath(remoteURL)
+	for _, re := range matchers {
+		if re.MatchString(remoteURL) || (hostPath != "" && re.MatchString(hostPath)) {
+			return true
+		}
+	}
+	return false
+}
+
+// remoteHostPath returns remoteURL as host/path without scheme, user, port,
+// or .git suffix, e.g. "github.com/acme/tool", or "" for a local path.
+func remoteHostPath(remoteURL string) string {
+	host := git.URLHost(remoteURL)
+	if host == "" {
+		return ""
+	}
+	var path string
+	if _, rest, found := strings.Cut(remoteURL, "://"); found {
+		_, path, _ = strings.Cut(rest, "/")
+	} else {
+		_, path, _ = strings.Cut(remoteURL, ":")
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return host + "/" + path
+}
+
+func compileRemotePatterns(setting string, patterns []string) ([]*regexp.Regexp, error) {
+	matchers := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := compileRemotePattern(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %q: %w", setting, pattern, err)
+		}
+		matchers = append(matchers, re)
+	}
+	return matchers, nil
+}
+
+func compileRemotePattern(pattern string) (*regexp.Regexp, error) {
+	if expr, ok := strings.CutPrefix(pattern, "re:"); ok {
+		return regexp.Compile(expr)
+	}
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	expr = strings.ReplaceAll(expr, `\?`, ".")
+	return regexp.Compile("(?i)^" + expr + "$")
+}
@@ -5,6 +5,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/agrahamlincoln/katazuke/internal/scanner"
@@ -67,6 +68,37 @@ func TestScanFlat(t *testing.T) {
 	}
 }
 
+func TestScanBareRepos(t *testing.T) {
+	root := t.TempDir()
+	initRepo(t, filepath.Join(root, "checkout"))
+	mirror := filepath.Join(root, "mirror.git")
+	mkdirAll(t, mirror)
+	cmd := exec.Command("git", "init", "--bare")
+	cmd.Dir = mirror
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git init --bare: %v\n%s", err, out)
+	}
+
+	// Bare repositories are skipped by default and never descended into,
+	// even with a depth that would reach their refs directories.
+	repos, err := scanner.Scan(root, scanner.Options{MaxDepth: 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0] != filepath.Join(root, "checkout") {
+		t.Errorf("expected only the checkout, got %v", repos)
+	}
+
+	repos, err = scanner.Scan(root, scanner.Options{MaxDepth: 3, IncludeBare: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(repos)
+	if len(repos) != 2 || repos[1] != mirror {
+		t.Errorf("expected the checkout and the mirror, got %v", repos)
+	}
+}
+
 func TestScanWithIndex(t *testing.T) {
 	root := t.TempDir()
 
@@ -161,6 +193,128 @@ func TestScanExcludePatterns(t *testing.T) {
 	}
 }
 
+func TestScanExcludeRemotes(t *testing.T) {
+	root := t.TempDir()
+
+	for name, url := range map[string]string{
+		"keep":   "https://github.com/acme/keep.git",
+		"vendor": "git@github.com:some-org/lib.git",
+		"mirror": "https://gitlab.example.com/group/sub/mirror",
+		"local":  "",
+	} {
+		path := filepath.Join(root, name)
+		initRepo(t, path)
+		if url == "" {
+			continue
+		}
+		cmd := exec.Command("git", "remote", "add", "origin", url)
+		cmd.Dir = path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git remote add: %v\n%s", err, out)
+		}
+	}
+
+	repos, err := scanner.Scan(root, scanner.Options{
+		ExcludeRemotes: []string{"github.com/some-org/*", "re:^https://gitlab\\.example\\.com/"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(repos)
+	want := []string{filepath.Join(root, "keep"), filepath.Join(root, "local")}
+	if strings.Join(repos, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	if _, err := scanner.Scan(root, scanner.Options{ExcludeRemotes: []string{"re:("}}); err == nil {
+		t.Error("expected an error for an invalid regexp")
+	}
+}
+
+func TestScanIncludePatterns(t *testing.T) {
+	root := t.TempDir()
+
+	for name, url := range map[string]string{
+		"api-gateway": "https://github.com/other/gateway.git",
+		"tool":        "git@github.com:acme/tool.git",
+		"theirs":      "https://github.com/other/theirs.git",
+		"local":       "",
+	} {
+		path := filepath.Join(root, "work", name)
+		initRepo(t, path)
+		if url == "" {
+			continue
+		}
+		cmd := exec.Command("git", "remote", "add", "origin", url)
+		cmd.Dir = path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git remote add: %v\n%s", err, out)
+		}
+	}
+
+	repos, err := scanner.Scan(root, scanner.Options{
+		IncludePatterns: []string{"api-*", "github.com/acme/*"},
+		MaxDepth:        2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sort.Strings(repos)
+	want := []string{filepath.Join(root, "work", "api-gateway"), filepath.Join(root, "work", "tool")}
+	if strings.Join(repos, ",") != strings.Join(want, ",") {
+		t.Errorf("expected %v, got %v", want, repos)
+	}
+
+	// Exclusions still apply to included repositories.
+	repos, err = scanner.Scan(root, scanner.Options{
+		IncludePatterns: []string{"api-*", "github.com/acme/*"},
+		ExcludePatterns: []string{"tool"},
+		MaxDepth:        2,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0] != filepath.Join(root, "work", "api-gateway") {
+		t.Errorf("expected only api-gateway, got %v", repos)
+	}
+}
+
+func TestScanExcludeRemotePatterns(t *testing.T) {
+	tests := []struct {
+		url     string
+		pattern string
+		want    bool
+	}{
+		{"git@github.com:acme/tool.git", "github.com/acme/*", true},
+		{"https://user@GitHub.com/acme/tool", "github.com/acme/*", true},
+		{"ssh://git@github.com:22/acme/tool.git", "github.com/acme/*", true},
+		{"https://github.com/acme-labs/tool", "github.com/acme/*", false},
+		{"https://gitlab.example.com/a/b/c", "gitlab.example.com/*", true},
+		{"https://github.com/acme/tool", "https://github.com/*", true},
+		{"https://github.com/acme/tool", "re:/acme/", true},
+		{"https://github.com/other/tool", "re:/acme/", false},
+		{"/srv/git/tool.git", "github.com/*", false},
+	}
+	for _, tt := range tests {
+		root := t.TempDir()
+		path := filepath.Join(root, "tool")
+		initRepo(t, path)
+		cmd := exec.Command("git", "remote", "add", "origin", tt.url)
+		cmd.Dir = path
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git remote add: %v\n%s", err, out)
+		}
+
+		repos, err := scanner.Scan(root, scanner.Options{ExcludeRemotes: []string{tt.pattern}})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := len(repos) == 0; got != tt.want {
+			t.Errorf("excluded %q by %q = %v, want %v", tt.url, tt.pattern, got, tt.want)
+		}
+	}
+}
+
 func TestScanRejectsUnknownFields(t *testing.T) {
 	root := t.TempDir()
 	writeFile(t, filepath.Join(root, ".katazuke"), []byte("groups:\n  - foo\nunknown: bar\n"))
@@ -185,3 +339,74 @@ func TestScanEmptyIndex(t *testing.T) {
 		t.Fatalf("expected 1 repo, got %d: %v", len(repos), repos)
 	}
 }
+
+func TestScanDepth(t *testing.T) {
+	root := t.TempDir()
+
+	initRepo(t, filepath.Join(root, "top"))
+	initRepo(t, filepath.Join(root, "org", "api"))
+	initRepo(t, filepath.Join(root, "org", "team", "web"))
+	// Repos nested inside a repo are never reported separately.
+	initRepo(t, filepath.Join(root, "top", "vendored", "lib"))
+
+	tests := []struct {
+		depth int
+		want  []string
+	}{
+		{0, []string{"top"}},
+		{1, []string{"top"}},
+		{2, []string{"org/api", "top"}},
+		{3, []string{"org/api", "org/team/web", "top"}},
+	}
+	for _, tt := range tests {
+		repos, err := scanner.Scan(root, scanner.Options{MaxDepth: tt.depth})
+		if err != nil {
+			t.Fatalf("depth %d: unexpected error: %v", tt.depth, err)
+		}
+		var got []string
+		for _, r := range repos {
+			rel, _ := filepath.Rel(root, r)
+			got = append(got, filepath.ToSlash(rel))
+		}
+		sort.Strings(got)
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("depth %d: got %v, want %v", tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestScanDepthSymlinkCycle(t *testing.T) {
+	root := t.TempDir()
+
+	initRepo(t, filepath.Join(root, "org", "api"))
+	if err := os.Symlink(root, filepath.Join(root, "org", "loop")); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	repos, err := scanner.Scan(root, scanner.Options{MaxDepth: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(repos) != 1 || repos[0] != filepath.Join(root, "org", "api") {
+		t.Errorf("expected only org/api, got %v", repos)
+	}
+}
+
+func TestScanParallelKeepsOrder(t *testing.T) {
+	root := t.TempDir()
+
+	var want []string
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		initRepo(t, filepath.Join(root, name))
+		want = append(want, filepath.Join(root, name))
+	}
+	mkdirAll(t, filepath.Join(root, "notes"))
+
+	repos, err := scanner.Scan(root, scanner.Options{Workers: 4})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(repos, ",") != strings.Join(want, ",") {
+		t.Errorf("expected repos in directory order %v, got %v", want, repos)
+	}
+}
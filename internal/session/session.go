@@ -0,0 +1,142 @@
+// Package session saves the state of interrupted interactive commands
+// (scan results and the answers given so far) so they can be resumed
+// without scanning again.
+package session
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+const schemaVersion = 1
+
+// ErrNotFound is returned by Load when no session with the given name
+// has been saved.
+var ErrNotFound = errors.New("session: not found")
+
+// Store keeps named sessions as JSON files in one directory.
+type Store struct {
+	dir string
+}
+
+// envelope is the on-disk form of a session.
+type envelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	SavedAt       time.Time       `json:"saved_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// New creates a Store in the default sessions directory
+// (~/.local/state/katazuke/sessions/). The directory is created if needed.
+func New() (*Store, error) {
+	dir, err := state.Path(state.Sessions)
+	if err != nil {
+		return nil, fmt.Errorf("session: %w", err)
+	}
+	return NewWithDir(dir)
+}
+
+// NewOrNil returns a Store using the default directory, or nil if
+// initialization fails. A nil Store saves nothing and finds nothing.
+func NewOrNil() *Store {
+	s, err := New()
+	if err != nil {
+		slog.Debug("sessions disabled", "error", err)
+		return nil
+	}
+	return s
+}
+
+// NewWithDir creates a Store keeping sessions in dir. Primarily useful for
+// testing.
+func NewWithDir(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("session: create directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+".json")
+}
+
+// Save writes v as the session called name, replacing any previous one.
+// The file is replaced atomically so an interrupted save never leaves a
+// truncated session behind.
+func (s *Store) Save(name string, v any) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("session: encode %s: %w", name, err)
+	}
+	out, err := json.Marshal(envelope{SchemaVersion: schemaVersion, SavedAt: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("session: encode %s: %w", name, err)
+	}
+
+	f, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("session: save %s: %w", name, err)
+	}
+	tmp := f.Name()
+	_, werr := f.Write(out)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp, s.path(name))
+	}
+	if werr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("session: save %s: %w", name, werr)
+	}
+	return nil
+}
+
+// Load reads the session called name into v and returns when it was last
+// saved. It returns ErrNotFound when there is no such session.
+func (s *Store) Load(name string, v any) (time.Time, error) {
+	if s == nil {
+		return time.Time{}, ErrNotFound
+	}
+	data, err := os.ReadFile(s.path(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, ErrNotFound
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("session: load %s: %w", name, err)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return time.Time{}, fmt.Errorf("session: load %s: %w", name, err)
+	}
+	if env.SchemaVersion != schemaVersion {
+		return time.Time{}, fmt.Errorf("session: %s was saved by an incompatible version of katazuke (schema %d)", name, env.SchemaVersion)
+	}
+	if err := json.Unmarshal(env.Data, v); err != nil {
+		return time.Time{}, fmt.Errorf("session: load %s: %w", name, err)
+	}
+	return env.SavedAt, nil
+}
+
+// Remove deletes the session called name. Removing a session that does
+// not exist is not an error.
+func (s *Store) Remove(name string) error {
+	if s == nil {
+		return nil
+	}
+	if err := os.Remove(s.path(name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("session: remove %s: %w", name, err)
+	}
+	return nil
+}
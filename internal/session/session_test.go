@@ -0,0 +1,95 @@
+package session
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testState struct {
+	Branches []string          `json:"branches"`
+	Picks    map[string]string `json:"picks"`
+}
+
+func TestSaveLoadRemove(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sessions")
+	s, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatalf("NewWithDir failed: %v", err)
+	}
+
+	var got testState
+	if _, err := s.Load("branches", &got); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound before saving, got %v", err)
+	}
+
+	want := testState{Branches: []string{"a", "b"}, Picks: map[string]string{"tier": "a"}}
+	before := time.Now()
+	if err := s.Save("branches", want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	savedAt, err := s.Load("branches", &got)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if strings.Join(got.Branches, ",") != "a,b" || got.Picks["tier"] != "a" {
+		t.Errorf("unexpected state: %+v", got)
+	}
+	if savedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("unexpected save time %v", savedAt)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "branches.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("expected no temporary files left, got %d entries", len(entries))
+	}
+
+	if err := s.Remove("branches"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if err := s.Remove("branches"); err != nil {
+		t.Errorf("removing a missing session should succeed, got %v", err)
+	}
+	if _, err := s.Load("branches", &got); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after removing, got %v", err)
+	}
+}
+
+func TestLoadRejectsOtherSchema(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewWithDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `{"schema_version": 99, "saved_at": "2026-01-01T00:00:00Z", "data": {}}`
+	if err := os.WriteFile(filepath.Join(dir, "old.json"), []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got testState
+	if _, err := s.Load("old", &got); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected an incompatible version error, got %v", err)
+	}
+}
+
+func TestNilStore(t *testing.T) {
+	var s *Store
+	if err := s.Save("x", testState{}); err != nil {
+		t.Errorf("nil Save: %v", err)
+	}
+	if _, err := s.Load("x", &testState{}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("nil Load: expected ErrNotFound, got %v", err)
+	}
+	if err := s.Remove("x"); err != nil {
+		t.Errorf("nil Remove: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+// Package state locates the files katazuke keeps between runs, following
+// the XDG base directory spec: state that should survive (sessions, the
+// operation log, the ignore list, sync state, locks) lives under
+// $XDG_STATE_HOME/katazuke, and caches that can be rebuilt at any time
+// under $XDG_CACHE_HOME/katazuke.
+//
+// Older versions kept all of these in ~/.local/share/katazuke; Migrate
+// moves them to their new homes.
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Names of the files and directories kept in the state and cache
+// directories.
+const (
+	Sessions    = "sessions"
+	Operations  = "operations"
+	IgnoreList  = "ignore.json"
+	SyncState   = "sync-state.json"
+	Locks       = "locks"
+	UpdateCheck = "update-check.json"
+	PolicyCache = "policy-cache.json"
+	// FingerprintKey is the per-install secret metrics fingerprints are
+	// keyed with.
+	FingerprintKey = "fingerprint.key"
+)
+
+// Dir returns the state directory: $XDG_STATE_HOME/katazuke, or
+// ~/.local/state/katazuke when XDG_STATE_HOME is unset or relative (the
+// spec says to ignore relative paths).
+func Dir() (string, error) {
+	return xdgDir("XDG_STATE_HOME", ".local", "state")
+}
+
+// CacheDir returns the cache directory: $XDG_CACHE_HOME/katazuke, or
+// ~/.cache/katazuke.
+func CacheDir() (string, error) {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// Path returns name inside the state directory.
+func Path(name string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// CachePath returns name inside the cache directory.
+func CachePath(name string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+// LegacyDir returns ~/.local/share/katazuke, where state was kept before
+// it moved to the XDG state and cache directories. Backups and metrics
+// are user data and still live there.
+func LegacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("state: home directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "share", "katazuke"), nil
+}
+
+func xdgDir(env string, fallback ...string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "katazuke"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("state: home directory: %w", err)
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), "katazuke")...), nil
+}
+
+// migrations lists what moved out of the legacy directory: to the state
+// directory, or to the cache directory when cache is set.
+var migrations = []struct {
+	name  string
+	cache bool
+}{
+	{Sessions, false},
+	{Operations, false},
+	{SyncState, false},
+	{Locks, false},
+	{UpdateCheck, true},
+	{PolicyCache, true},
+}
+
+// Migrate moves files left in the legacy directory by older versions to
+// the state and cache directories. Anything already present at the new
+// location is left alone, along with its legacy copy. It is best-effort
+// and safe to call on every run: failures are logged and the rest still
+// move.
+func Migrate() {
+	legacy, err := LegacyDir()
+	if err != nil {
+		return
+	}
+	stateDir, err := Dir()
+	if err != nil {
+		return
+	}
+	cacheDir, err := CacheDir()
+	if err != nil {
+		return
+	}
+	migrate(legacy, stateDir, cacheDir)
+}
+
+// migrate moves each migrated name from legacy to stateDir or cacheDir,
+// returning the names it moved.
+func migrate(legacy, stateDir, cacheDir string) []string {
+	var moved []string
+	for _, m := range migrations {
+		from := filepath.Join(legacy, m.name)
+		if _, err := os.Lstat(from); err != nil {
+			continue
+		}
+		dir := stateDir
+		if m.cache {
+			dir = cacheDir
+		}
+		to := filepath.Join(dir, m.name)
+		if _, err := os.Lstat(to); !errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			slog.Debug("state migration failed", "path", from, "error", err)
+			continue
+		}
+		if err := os.Rename(from, to); err != nil {
+			slog.Debug("state migration failed", "path", from, "error", err)
+			continue
+		}
+		slog.Debug("moved state to XDG directory", "from", from, "to", to)
+		moved = append(moved, m.name)
+	}
+	return moved
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDirs(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/state")
+	t.Setenv("XDG_CACHE_HOME", "/cache")
+	if got, _ := Path(Sessions); got != filepath.Join("/state", "katazuke", "sessions") {
+		t.Errorf("Path = %q", got)
+	}
+	if got, _ := CachePath(UpdateCheck); got != filepath.Join("/cache", "katazuke", "update-check.json") {
+		t.Errorf("CachePath = %q", got)
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "relative/cache")
+	if got, _ := Dir(); got != filepath.Join(home, ".local", "state", "katazuke") {
+		t.Errorf("Dir = %q", got)
+	}
+	if got, _ := CacheDir(); got != filepath.Join(home, ".cache", "katazuke") {
+		t.Errorf("expected a relative XDG_CACHE_HOME to be ignored, got %q", got)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	root := t.TempDir()
+	legacy := filepath.Join(root, "share")
+	stateDir := filepath.Join(root, "state")
+	cacheDir := filepath.Join(root, "cache")
+
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(legacy, Sessions, "branches.json"), "old session")
+	write(filepath.Join(legacy, UpdateCheck), "old cache")
+	write(filepath.Join(legacy, SyncState), "old sync")
+	write(filepath.Join(legacy, "backups", "a.bundle"), "bundle")
+	// Already migrated: the newer copy wins and the legacy one stays.
+	write(filepath.Join(stateDir, SyncState), "new sync")
+
+	moved := migrate(legacy, stateDir, cacheDir)
+	slices.Sort(moved)
+	if want := []string{Sessions, UpdateCheck}; !slices.Equal(moved, want) {
+		t.Errorf("moved %v, want %v", moved, want)
+	}
+
+	read := func(path string) string {
+		t.Helper()
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		return string(data)
+	}
+	if got := read(filepath.Join(stateDir, Sessions, "branches.json")); got != "old session" {
+		t.Errorf("session = %q", got)
+	}
+	if got := read(filepath.Join(cacheDir, UpdateCheck)); got != "old cache" {
+		t.Errorf("update cache = %q", got)
+	}
+	if got := read(filepath.Join(stateDir, SyncState)); got != "new sync" {
+		t.Errorf("expected the existing sync state to be kept, got %q", got)
+	}
+	if got := read(filepath.Join(legacy, SyncState)); got != "old sync" {
+		t.Errorf("expected the legacy sync state to be left alone, got %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(legacy, "backups", "a.bundle")); err != nil {
+		t.Errorf("expected backups to stay in the legacy directory: %v", err)
+	}
+
+	if moved := migrate(legacy, stateDir, cacheDir); len(moved) != 0 {
+		t.Errorf("expected a second migration to move nothing, got %v", moved)
+	}
+}
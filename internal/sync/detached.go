@@ -0,0 +1,121 @@
+package sync
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// DetachedHead describes the commit a detached HEAD points at, so the
+// user can tell a deliberate checkout from work that would be left
+// behind by switching away.
+type DetachedHead struct {
+	SHA     string
+	Subject string
+	Date    time.Time
+	// Tags point at the commit.
+	Tags []string
+	// Branches are the local and remote-tracking branches containing the
+	// commit. When there are none and no tags either, only the reflog
+	// would keep it after switching away.
+	Branches []string
+}
+
+// Orphaned reports whether no branch or tag keeps the commit.
+func (d DetachedHead) Orphaned() bool {
+	return len(d.Tags) == 0 && len(d.Branches) == 0
+}
+
+// Describe returns a short description of the commit, e.g. "abc1234
+// (tag v1.2)" or "abc1234 (on origin/main)".
+func (d DetachedHead) Describe() string {
+	s := d.SHA[:min(7, len(d.SHA))]
+	switch {
+	case len(d.Tags) > 0:
+		s += " (tag " + strings.Join(d.Tags, ", ") + ")"
+	case len(d.Branches) > 0:
+		s += " (on " + d.Branches[0]
+		if len(d.Branches) > 1 {
+			s += fmt.Sprintf(" and %d more", len(d.Branches)-1)
+		}
+		s += ")"
+	case d.Subject != "":
+		s += fmt.Sprintf(" %q, on no branch", d.Subject)
+	default:
+		s += ", on no branch"
+	}
+	return s
+}
+
+// inProgressHint says how to finish an operation in progress.
+func inProgressHint(op string) string {
+	switch op {
+	case "bisect":
+		return "finish with git bisect reset"
+	case "rebase":
+		return "continue it or run git rebase --abort"
+	}
+	return fmt.Sprintf("finish it or run git %s --abort", op)
+}
+
+// Resolutions for a Detached result.
+const (
+	// ResolveKeepBranch creates a backup branch at the detached commit,
+	// then switches to the default branch and syncs it.
+	ResolveKeepBranch = "branch"
+	// ResolveSwitch switches to the default branch and syncs it, leaving
+	// the detached commit to the reflog.
+	ResolveSwitch = "switch"
+)
+
+// ResolveDetached applies a resolution (ResolveKeepBranch, ResolveSwitch,
+// or ResolveSkip) to a Detached result and returns the new outcome.
+func ResolveDetached(r Result, resolution string, opts Options, git GitOps, now time.Time) Result {
+	result := Result{RepoPath: r.RepoPath, RepoName: r.RepoName}
+	head := r.Detached
+	if head == nil {
+		head = &DetachedHead{}
+	}
+
+	if resolution == ResolveSkip {
+		result.Status = Skipped
+		result.Message = fmt.Sprintf("detached HEAD at %s, left as is", head.Describe())
+		return result
+	}
+
+	clean, err := git.IsClean(r.RepoPath)
+	if err != nil {
+		result.Status = Failed
+		result.Message = fmt.Sprintf("could not check working tree: %v", err)
+		return result
+	}
+	if !clean {
+		result.Status = Skipped
+		result.Message = fmt.Sprintf("detached HEAD at %s with dirty working tree, left as is", head.Describe())
+		return result
+	}
+
+	var kept string
+	switch resolution {
+	case ResolveKeepBranch:
+		kept = backupBranchName(r.RepoPath, "detached", git, now)
+		slog.Debug("saving detached HEAD", "repo", r.RepoName, "branch", kept)
+		if err := git.CreateBranchAt(r.RepoPath, kept, "HEAD"); err != nil {
+			result.Status = Failed
+			result.Message = fmt.Sprintf("could not create branch %s, not switching: %v", kept, err)
+			return result
+		}
+	case ResolveSwitch:
+	default:
+		result.Status = Failed
+		result.Message = fmt.Sprintf("unknown resolution %q", resolution)
+		return result
+	}
+
+	switched := switchFromDetached(r.RepoPath, r.RepoName, r.Remote, r.Branch, *head, opts, git)
+	if kept != "" {
+		switched.Message += fmt.Sprintf(", commit kept on %s", kept)
+	}
+	return switched
+}
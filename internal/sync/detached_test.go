@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAll_DetachedHEAD_OrphanedCommitNotSwitched(t *testing.T) {
+	mock := defaultMock()
+	mock.currentBranch = ""
+	mock.detached = DetachedHead{SHA: "abc1234def", Subject: "Try a fix"}
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]
+	if r.Status != Detached {
+		t.Fatalf("expected Detached, got %s: %s", r.Status, r.Message)
+	}
+	if r.Detached == nil || r.Detached.SHA != "abc1234def" || r.Remote != "origin" || r.Branch != "main" {
+		t.Errorf("unexpected detached details: %+v", r)
+	}
+	if !strings.Contains(r.Message, `"Try a fix", on no branch`) {
+		t.Errorf("expected the message to describe the commit, got %q", r.Message)
+	}
+	if len(mock.checkoutCalls) != 0 || len(mock.pullCalls) != 0 {
+		t.Error("should not switch away from a commit nothing keeps")
+	}
+}
+
+func TestAll_DetachedHEAD_SwitchMentionsTag(t *testing.T) {
+	mock := defaultMock()
+	mock.currentBranch = ""
+	mock.detached = DetachedHead{SHA: "abc1234def", Tags: []string{"v1.2"}}
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]
+	if r.Status != Switched {
+		t.Fatalf("expected Switched, got %s: %s", r.Status, r.Message)
+	}
+	if !strings.Contains(r.Message, "abc1234 (tag v1.2)") {
+		t.Errorf("expected the message to name the tag, got %q", r.Message)
+	}
+}
+
+func detachedResult() Result {
+	return Result{
+		RepoPath: "/repos/project",
+		RepoName: "project",
+		Status:   Detached,
+		Remote:   "origin",
+		Branch:   "main",
+		Detached: &DetachedHead{SHA: "abc1234def"},
+	}
+}
+
+func TestResolveDetached_KeepBranch(t *testing.T) {
+	mock := defaultMock()
+	mock.currentBranch = ""
+	mock.existingBranches = map[string]bool{"backup/detached-2026-03-01": true}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	r := ResolveDetached(detachedResult(), ResolveKeepBranch, Options{Strategy: "rebase"}, mock, now)
+	if r.Status != Switched {
+		t.Fatalf("expected Switched, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.branchAtCalls) != 1 || !strings.HasPrefix(mock.branchAtCalls[0], "backup/detached-2026-03-01-2") {
+		t.Errorf("expected a suffixed backup branch, got %v", mock.branchAtCalls)
+	}
+	if len(mock.checkoutCalls) != 1 || mock.checkoutCalls[0] != "main" {
+		t.Errorf("expected checkout to main, got %v", mock.checkoutCalls)
+	}
+	if !strings.Contains(r.Message, "kept on backup/detached-2026-03-01-2") {
+		t.Errorf("expected the message to name the backup branch, got %q", r.Message)
+	}
+}
+
+func TestResolveDetached_SwitchAndSkip(t *testing.T) {
+	mock := defaultMock()
+	if r := ResolveDetached(detachedResult(), ResolveSwitch, Options{Strategy: "rebase"}, mock, time.Now()); r.Status != Switched {
+		t.Errorf("expected Switched, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.branchAtCalls) != 0 {
+		t.Errorf("should not create a branch, got %v", mock.branchAtCalls)
+	}
+
+	mock = defaultMock()
+	if r := ResolveDetached(detachedResult(), ResolveSkip, Options{Strategy: "rebase"}, mock, time.Now()); r.Status != Skipped {
+		t.Errorf("expected Skipped, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.checkoutCalls) != 0 {
+		t.Error("should not switch when skipped")
+	}
+}
+
+func TestResolveDetached_DirtyTree(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	r := ResolveDetached(detachedResult(), ResolveKeepBranch, Options{Strategy: "rebase"}, mock, time.Now())
+	if r.Status != Skipped {
+		t.Errorf("expected Skipped, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.branchAtCalls) != 0 || len(mock.checkoutCalls) != 0 {
+		t.Error("should not touch a tree that became dirty")
+	}
+}
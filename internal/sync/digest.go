@@ -0,0 +1,103 @@
+package sync
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+)
+
+// DigestWindow is how far back a digest looks in a repository that has
+// never synced successfully.
+const DigestWindow = 7 * 24 * time.Hour
+
+// MaxDigestMerges caps the merges listed per repository in a digest.
+const MaxDigestMerges = 20
+
+// Tag is a tag and when it was created.
+type Tag struct {
+	Name string
+	// Date is the tagger date for annotated tags and the commit date for
+	// lightweight tags.
+	Date time.Time
+}
+
+// Digest is what changed upstream in one repository since it last synced
+// successfully: the tags created and the merges into its default branch.
+type Digest struct {
+	RepoPath string
+	RepoName string
+	// Since is the last successful sync before this one, or DigestWindow
+	// ago when there was none.
+	Since time.Time
+	// Ref is the branch the merges landed on, e.g. origin/main.
+	Ref string
+	// Tags are newest first.
+	Tags []Tag
+	// Merges are newest first, at most MaxDigestMerges of them.
+	Merges []Commit
+}
+
+// Digests builds the digest of each synced repository from its
+// remote-tracking default branch and tags, looking back to the repository's
+// last successful sync in prior (the state from before this run).
+// Repositories that failed, whose remote could not be used, that git
+// refused to work in, or with nothing notable are left out. Work is
+// parallelized across the given number of workers.
+func Digests(results []Result, prior State, git GitOps, workers int, now time.Time) []Digest {
+	var paths []string
+	for _, r := range results {
+		if r.Status == Failed || r.SkipReason == SkipAuthRequired || r.SkipReason == SkipUnreachable ||
+			r.SkipReason == SkipDubiousOwnership {
+			continue
+		}
+		paths = append(paths, r.RepoPath)
+	}
+
+	digests := parallel.Run(paths, workers, func(repoPath string) *Digest {
+		since := prior.Repos[repoPath].LastSuccess
+		if since.IsZero() {
+			since = now.Add(-DigestWindow)
+		}
+		return digest(repoPath, since, git)
+	}, nil)
+
+	var out []Digest
+	for _, d := range digests {
+		if d != nil {
+			out = append(out, *d)
+		}
+	}
+	return out
+}
+
+// digest collects one repository's digest, or nil when nothing notable
+// happened since. Anything that cannot be read is left out.
+func digest(repoPath string, since time.Time, git GitOps) *Digest {
+	d := Digest{RepoPath: repoPath, RepoName: filepath.Base(repoPath), Since: since}
+
+	tags, err := git.TagsSince(repoPath, since)
+	if err != nil {
+		slog.Debug("could not list tags", "repo", d.RepoName, "error", err)
+	}
+	d.Tags = tags
+
+	if branch, err := git.DefaultBranch(repoPath); err == nil {
+		d.Ref = branch
+		// Bare mirrors keep the remote's branches as local ones.
+		if remote := git.PrimaryRemote(repoPath); remote != "" && !git.IsBare(repoPath) {
+			d.Ref = remote + "/" + branch
+		}
+		merges, err := git.MergesSince(repoPath, d.Ref, since, MaxDigestMerges)
+		if err != nil {
+			slog.Debug("could not list merges", "repo", d.RepoName, "ref", d.Ref, "error", err)
+		}
+		d.Merges = merges
+	}
+
+	if len(d.Tags) == 0 && len(d.Merges) == 0 {
+		return nil
+	}
+	return &d
+}
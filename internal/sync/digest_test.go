@@ -0,0 +1,52 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigests(t *testing.T) {
+	mock := defaultMock()
+	mock.tagsSince = []Tag{{Name: "v1.3.0"}}
+	mock.mergesSince = []Commit{{SHA: "abc1234def", Subject: "Merge pull request #42"}}
+	now := time.Date(2026, 3, 8, 12, 0, 0, 0, time.UTC)
+	lastSync := now.AddDate(0, 0, -2)
+	prior := State{Repos: map[string]RepoState{"/repos/synced": {LastSuccess: lastSync}}}
+	results := []Result{
+		{RepoPath: "/repos/synced", Status: Synced},
+		{RepoPath: "/repos/new", Status: UpToDate},
+		{RepoPath: "/repos/broken", Status: Failed},
+		{RepoPath: "/repos/offline", Status: Skipped, SkipReason: SkipUnreachable},
+	}
+
+	digests := Digests(results, prior, mock, 1, now)
+	if len(digests) != 2 {
+		t.Fatalf("expected digests for the two usable repos, got %+v", digests)
+	}
+	for _, d := range digests {
+		want := now.Add(-DigestWindow)
+		if d.RepoPath == "/repos/synced" {
+			want = lastSync
+		}
+		if !d.Since.Equal(want) {
+			t.Errorf("%s: expected since %v, got %v", d.RepoPath, want, d.Since)
+		}
+		if d.Ref != "origin/main" || len(d.Tags) != 1 || len(d.Merges) != 1 {
+			t.Errorf("unexpected digest: %+v", d)
+		}
+	}
+}
+
+func TestDigests_NothingNotable(t *testing.T) {
+	mock := defaultMock()
+	mock.isBare = true
+	mock.mergesSince = nil
+	results := []Result{{RepoPath: "/repos/mirror", Status: Synced}}
+
+	if digests := Digests(results, State{}, mock, 1, time.Now()); len(digests) != 0 {
+		t.Errorf("expected no digest, got %+v", digests)
+	}
+	if len(mock.mergesRefs) != 1 || mock.mergesRefs[0] != "main" {
+		t.Errorf("expected a bare mirror's own branch to be read, got %v", mock.mergesRefs)
+	}
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Resolutions for a Diverged default branch.
+const (
+	// ResolveRebase replays the local commits on top of the remote branch.
+	ResolveRebase = "rebase"
+	// ResolveReset saves the local commits on a backup branch, then resets
+	// the default branch to the remote one.
+	ResolveReset = "reset"
+	// ResolveSkip leaves the branch as it is.
+	ResolveSkip = "skip"
+)
+
+// ResolveDiverged applies a resolution to a Diverged result and returns
+// the new outcome. The working tree must be clean: a rebase cannot start
+// over local changes and a reset would discard them.
+func ResolveDiverged(r Result, resolution string, git GitOps, now time.Time) Result {
+	result := Result{RepoPath: r.RepoPath, RepoName: r.RepoName, WIPBranch: r.WIPBranch}
+	remoteRef := r.Remote + "/" + r.Branch
+
+	if resolution == ResolveSkip {
+		result.Status = Skipped
+		result.Message = fmt.Sprintf("diverged from %s, left as is", remoteRef)
+		return result
+	}
+
+	clean, err := git.IsClean(r.RepoPath)
+	if err != nil {
+		result.Status = Failed
+		result.Message = fmt.Sprintf("could not check working tree: %v", err)
+		return result
+	}
+	if !clean {
+		result.Status = Skipped
+		result.Message = fmt.Sprintf("diverged from %s with a dirty working tree, left as is", remoteRef)
+		return result
+	}
+
+	switch resolution {
+	case ResolveRebase:
+		slog.Debug("rebasing diverged branch", "repo", r.RepoName, "onto", remoteRef)
+		if err := git.Pull(r.RepoPath, r.Remote, r.Branch, "rebase"); err != nil {
+			abortPull(r.RepoPath, "rebase", git)
+			result.Status = Failed
+			result.Message = fmt.Sprintf("rebase onto %s failed (aborted, local commits unchanged): %v", remoteRef, err)
+			return result
+		}
+		result.Status = Synced
+		result.CommitsPulled = r.CommitsBehind
+		result.Message = fmt.Sprintf("rebased %d local %s onto %s", r.CommitsAhead, pluralCommit(r.CommitsAhead), remoteRef)
+
+	case ResolveReset:
+		backup := backupBranchName(r.RepoPath, r.Branch, git, now)
+		slog.Debug("resetting diverged branch", "repo", r.RepoName, "to", remoteRef, "backup", backup)
+		if err := git.CreateBranchAt(r.RepoPath, backup, "HEAD"); err != nil {
+			result.Status = Failed
+			result.Message = fmt.Sprintf("could not create backup branch %s, not resetting: %v", backup, err)
+			return result
+		}
+		if err := git.ResetHard(r.RepoPath, remoteRef); err != nil {
+			result.Status = Failed
+			result.Message = fmt.Sprintf("reset to %s failed (local commits on %s): %v", remoteRef, backup, err)
+			return result
+		}
+		result.Status = Synced
+		result.CommitsPulled = r.CommitsBehind
+		result.Message = fmt.Sprintf("reset to %s, %d local %s kept on %s", remoteRef, r.CommitsAhead, pluralCommit(r.CommitsAhead), backup)
+
+	default:
+		result.Status = Failed
+		result.Message = fmt.Sprintf("unknown resolution %q", resolution)
+	}
+	return result
+}
+
+// backupBranchName returns backup/<branch>-<date>, adding a numeric suffix
+// when that branch already exists.
+func backupBranchName(repoPath, branch string, git GitOps, now time.Time) string {
+	return unusedBranchName(repoPath, "backup/"+branch+"-"+now.Format("2006-01-02"), git)
+}
@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAll_DivergedOnFFOnly(t *testing.T) {
+	mock := defaultMock()
+	mock.revListCount = 3
+	mock.aheadCount = 2
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "ff-only"}, mock, 1, nil)[0]
+	if r.Status != Diverged {
+		t.Fatalf("expected Diverged, got %s: %s", r.Status, r.Message)
+	}
+	if r.Remote != "origin" || r.Branch != "main" || r.CommitsAhead != 2 || r.CommitsBehind != 3 {
+		t.Errorf("unexpected divergence details: %+v", r)
+	}
+	if len(mock.pullCalls) != 0 {
+		t.Error("should not pull a diverged branch")
+	}
+
+	// Strategies that can reconcile the histories pull as before.
+	mock = defaultMock()
+	mock.aheadCount = 2
+	if r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]; r.Status != Synced {
+		t.Errorf("expected Synced with rebase, got %s: %s", r.Status, r.Message)
+	}
+}
+
+func TestAll_DivergedWithDirtyTree(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	mock.aheadCount = 1
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "ff-only", AutoStash: true}, mock, 1, nil)[0]
+	if r.Status != Diverged {
+		t.Fatalf("expected Diverged, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.stashPushCalls) != 0 {
+		t.Error("should not stash before reporting divergence")
+	}
+}
+
+func divergedResult() Result {
+	return Result{RepoPath: "/repos/project", RepoName: "project", Status: Diverged, Remote: "origin", Branch: "main", CommitsAhead: 2, CommitsBehind: 3}
+}
+
+func TestResolveDiverged_Rebase(t *testing.T) {
+	mock := defaultMock()
+	r := ResolveDiverged(divergedResult(), ResolveRebase, mock, time.Now())
+	if r.Status != Synced || r.CommitsPulled != 3 {
+		t.Errorf("expected Synced with 3 commits pulled, got %s (%d): %s", r.Status, r.CommitsPulled, r.Message)
+	}
+	if len(mock.pullCalls) != 1 || mock.pullCalls[0] != "rebase" {
+		t.Errorf("expected a rebase pull, got %v", mock.pullCalls)
+	}
+
+	mock = defaultMock()
+	mock.pullErr = fmt.Errorf("conflict")
+	r = ResolveDiverged(divergedResult(), ResolveRebase, mock, time.Now())
+	if r.Status != Failed || mock.rebaseAbortCalls != 1 {
+		t.Errorf("expected a failed, aborted rebase, got %s with %d aborts", r.Status, mock.rebaseAbortCalls)
+	}
+}
+
+func TestResolveDiverged_Reset(t *testing.T) {
+	mock := defaultMock()
+	now := time.Date(2026, 5, 4, 0, 0, 0, 0, time.UTC)
+	mock.existingBranches = map[string]bool{"backup/main-2026-05-04": true}
+
+	r := ResolveDiverged(divergedResult(), ResolveReset, mock, now)
+	if r.Status != Synced {
+		t.Fatalf("expected Synced, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.branchAtCalls) != 1 || mock.branchAtCalls[0] != "backup/main-2026-05-04-2@HEAD" {
+		t.Errorf("expected a suffixed backup branch at HEAD, got %v", mock.branchAtCalls)
+	}
+	if len(mock.resetCalls) != 1 || mock.resetCalls[0] != "origin/main" {
+		t.Errorf("expected a reset to origin/main, got %v", mock.resetCalls)
+	}
+	if !strings.Contains(r.Message, "backup/main-2026-05-04-2") {
+		t.Errorf("expected the message to name the backup branch: %s", r.Message)
+	}
+
+	mock = defaultMock()
+	mock.createBranchErr = fmt.Errorf("exists")
+	if r := ResolveDiverged(divergedResult(), ResolveReset, mock, now); r.Status != Failed || len(mock.resetCalls) != 0 {
+		t.Errorf("expected no reset without a backup, got %s and %v", r.Status, mock.resetCalls)
+	}
+}
+
+func TestResolveDiverged_SkipAndDirty(t *testing.T) {
+	mock := defaultMock()
+	if r := ResolveDiverged(divergedResult(), ResolveSkip, mock, time.Now()); r.Status != Skipped {
+		t.Errorf("expected Skipped, got %s", r.Status)
+	}
+
+	mock.isClean = false
+	if r := ResolveDiverged(divergedResult(), ResolveReset, mock, time.Now()); r.Status != Skipped || len(mock.resetCalls) != 0 {
+		t.Errorf("expected a dirty tree to be left alone, got %s and %v", r.Status, mock.resetCalls)
+	}
+}
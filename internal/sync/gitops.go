@@ -1,10 +1,22 @@
 package sync
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/agrahamlincoln/katazuke/internal/merge"
 	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
+// unusedBranchName returns base, or base with a numeric suffix, naming no
+// existing branch in repoPath according to ops.
+func unusedBranchName(repoPath, base string, ops GitOps) string {
+	return git.UnusedBranchName(base, func(branch string) bool { return ops.BranchExists(repoPath, branch) })
+}
+
 // RealGitOps implements GitOps using the pkg/git package and the hybrid
 // merge detector for IsMerged checks.
 type RealGitOps struct {
@@ -22,6 +34,27 @@ func (r *RealGitOps) Fetch(repoPath, remote string) error {
 	return git.Fetch(repoPath, remote)
 }
 
+// Probe checks that the remote answers without asking for credentials,
+// waiting at most git.ProbeTimeout.
+func (r *RealGitOps) Probe(repoPath, remote string) error {
+	return git.Probe(repoPath, remote, git.ProbeTimeout)
+}
+
+// RefreshRemoteHead updates the remote's HEAD symref from the remote.
+func (r *RealGitOps) RefreshRemoteHead(repoPath, remote string) error {
+	return git.RefreshRemoteHead(repoPath, remote)
+}
+
+// IsBare reports whether the repository is bare (a mirror or bare clone).
+func (r *RealGitOps) IsBare(repoPath string) bool {
+	return git.IsBareRepo(repoPath)
+}
+
+// RemoteUpdate fetches every remote of a bare repository.
+func (r *RealGitOps) RemoteUpdate(repoPath string) (bool, error) {
+	return git.RemoteUpdate(repoPath)
+}
+
 // IsClean returns true if the working tree has no uncommitted changes.
 func (r *RealGitOps) IsClean(repoPath string) (bool, error) {
 	return git.IsClean(repoPath)
@@ -37,14 +70,15 @@ func (r *RealGitOps) DefaultBranch(repoPath string) (string, error) {
 	return git.DefaultBranch(repoPath)
 }
 
-// HasRemote returns true if the given remote exists.
-func (r *RealGitOps) HasRemote(repoPath, remote string) bool {
-	return git.HasRemote(repoPath, remote)
+// PrimaryRemote returns the remote that holds the default branch, or ""
+// when there is none.
+func (r *RealGitOps) PrimaryRemote(repoPath string) string {
+	return git.PrimaryRemote(repoPath)
 }
 
-// Pull pulls from the default remote using the given strategy.
-func (r *RealGitOps) Pull(repoPath string, strategy string) error {
-	return git.Pull(repoPath, strategy)
+// Pull pulls branch from remote using the given strategy.
+func (r *RealGitOps) Pull(repoPath, remote, branch, strategy string) error {
+	return git.Pull(repoPath, remote, branch, strategy)
 }
 
 // IsMerged returns true if the given branch has been merged into base.
@@ -94,3 +128,132 @@ func (r *RealGitOps) MergeAbort(repoPath string) error {
 func (r *RealGitOps) RevListCount(repoPath, spec string) (int, error) {
 	return git.RevListCount(repoPath, spec)
 }
+
+// CreateBranch creates a branch at HEAD and switches to it.
+func (r *RealGitOps) CreateBranch(repoPath, branch string) error {
+	return git.CreateBranch(repoPath, branch)
+}
+
+// BranchExists returns true if the local branch exists.
+func (r *RealGitOps) BranchExists(repoPath, branch string) bool {
+	return git.BranchExists(repoPath, branch)
+}
+
+// CommitAll stages and commits all working tree changes.
+func (r *RealGitOps) CommitAll(repoPath, message string) error {
+	return git.CommitAll(repoPath, message)
+}
+
+// CreateBranchAt creates a branch at ref without switching to it.
+func (r *RealGitOps) CreateBranchAt(repoPath, branch, ref string) error {
+	return git.CreateBranchAt(repoPath, branch, ref)
+}
+
+// ResetHard resets the current branch and working tree to ref.
+func (r *RealGitOps) ResetHard(repoPath, ref string) error {
+	return git.ResetHard(repoPath, ref)
+}
+
+// ConflictState returns the operation in progress in the repository, or
+// "" when there is none.
+func (r *RealGitOps) ConflictState(repoPath string) string {
+	return git.ConflictState(repoPath)
+}
+
+// CheckOwnership returns an error when git refuses to work in the
+// repository because another user owns it.
+func (r *RealGitOps) CheckOwnership(repoPath string) error {
+	return git.CheckOwnership(repoPath)
+}
+
+// DescribeDetached describes the commit HEAD points at. Only the commit
+// itself is required; the rest is filled in as far as it can be read.
+func (r *RealGitOps) DescribeDetached(repoPath string) (DetachedHead, error) {
+	sha, err := git.RevParse(repoPath, "HEAD")
+	if err != nil {
+		return DetachedHead{}, err
+	}
+	head := DetachedHead{SHA: sha}
+	head.Subject, _ = git.CommitSubject(repoPath, sha)
+	head.Date, _ = git.CommitDate(repoPath, sha)
+	head.Tags, _ = git.TagsAt(repoPath, sha)
+	head.Branches, _ = git.BranchesContaining(repoPath, sha)
+	return head, nil
+}
+
+// Log returns up to max commits in the revision range spec, newest first.
+func (r *RealGitOps) Log(repoPath, spec string, max int) ([]Commit, error) {
+	return commits(git.Log(repoPath, spec, max))
+}
+
+// MergesSince returns up to max merges into ref after since, newest first.
+func (r *RealGitOps) MergesSince(repoPath, ref string, since time.Time, max int) ([]Commit, error) {
+	return commits(git.MergesSince(repoPath, ref, since, max))
+}
+
+// commits converts a git log to Commits.
+func commits(summaries []git.CommitSummary, err error) ([]Commit, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Commit, 0, len(summaries))
+	for _, c := range summaries {
+		out = append(out, Commit{SHA: c.SHA, Author: c.Author, Subject: c.Subject})
+	}
+	return out, nil
+}
+
+// TagsSince returns the tags created after since, newest first.
+func (r *RealGitOps) TagsSince(repoPath string, since time.Time) ([]Tag, error) {
+	refs, err := git.ListTags(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	for _, t := range refs {
+		if t.Date.After(since) {
+			tags = append(tags, Tag{Name: t.Name, Date: t.Date})
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Date.After(tags[j].Date) })
+	return tags, nil
+}
+
+// ChangedTopLevelPaths returns the top-level paths changed on to since it
+// diverged from from.
+func (r *RealGitOps) ChangedTopLevelPaths(repoPath, from, to string) ([]string, error) {
+	return git.ChangedTopLevelPaths(repoPath, from, to)
+}
+
+// needsAuth reports whether a git command failed because the remote
+// asked for credentials.
+func needsAuth(err error) bool {
+	return errors.Is(err, git.ErrAuthFailed)
+}
+
+// isUnreachable reports whether a git command failed because the remote
+// could not be reached.
+func isUnreachable(err error) bool {
+	return errors.Is(err, git.ErrUnreachable)
+}
+
+// firstLine returns the first line of an error, leaving out the stderr
+// git error messages carry.
+func firstLine(err error) string {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return msg
+}
+
+// pullFailure returns the outcome of a failed pull of a clean default
+// branch. Local changes blocking it, e.g. files git could not see as
+// untracked until the pull, leave the repository as it is rather than
+// failing it.
+func pullFailure(remoteRef string, err error) (Status, string) {
+	switch {
+	case errors.Is(err, git.ErrDirtyWorkingTree):
+		return Skipped, fmt.Sprintf("local changes would be overwritten by pulling %s, left as is", remoteRef)
+	case errors.Is(err, git.ErrNotFastForward):
+		return Failed, fmt.Sprintf("pull failed: cannot fast-forward to %s, the branch has diverged", remoteRef)
+	}
+	return Failed, fmt.Sprintf("pull failed: %v", err)
+}
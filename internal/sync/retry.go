@@ -0,0 +1,73 @@
+package sync
+
+import (
+	"log/slog"
+	"path/filepath"
+	"time"
+)
+
+// sleep waits between retries; replaced in tests.
+var sleep = time.Sleep
+
+// Retry calls fn until it succeeds, fails for a reason other than the
+// remote being unreachable, or has been retried retries times. It waits
+// delay before the first retry and twice as long before each one after
+// it. It returns how many retries were made and fn's last error.
+func Retry(retries int, delay time.Duration, fn func() error) (int, error) {
+	err := fn()
+	n := 0
+	for ; n < retries && isUnreachable(err); n++ {
+		sleep(delay)
+		delay *= 2
+		err = fn()
+	}
+	return n, err
+}
+
+// retryGitOps retries the GitOps calls that talk to a remote when the
+// remote could not be reached, and counts the retries made.
+type retryGitOps struct {
+	GitOps
+	retries int
+	delay   time.Duration
+	retried int
+}
+
+// retry runs fn with Retry, adding its retries to the count.
+func (r *retryGitOps) retry(op, repoPath string, fn func() error) error {
+	n, err := Retry(r.retries, r.delay, func() error {
+		err := fn()
+		if isUnreachable(err) {
+			slog.Debug("remote unreachable", "op", op, "repo", filepath.Base(repoPath), "error", firstLine(err))
+		}
+		return err
+	})
+	r.retried += n
+	return err
+}
+
+// Probe retries GitOps.Probe.
+func (r *retryGitOps) Probe(repoPath, remote string) error {
+	return r.retry("probe", repoPath, func() error { return r.GitOps.Probe(repoPath, remote) })
+}
+
+// Fetch retries GitOps.Fetch.
+func (r *retryGitOps) Fetch(repoPath, remote string) error {
+	return r.retry("fetch", repoPath, func() error { return r.GitOps.Fetch(repoPath, remote) })
+}
+
+// Pull retries GitOps.Pull.
+func (r *retryGitOps) Pull(repoPath, remote, branch, strategy string) error {
+	return r.retry("pull", repoPath, func() error { return r.GitOps.Pull(repoPath, remote, branch, strategy) })
+}
+
+// RemoteUpdate retries GitOps.RemoteUpdate.
+func (r *retryGitOps) RemoteUpdate(repoPath string) (bool, error) {
+	var updated bool
+	err := r.retry("remote update", repoPath, func() error {
+		var err error
+		updated, err = r.GitOps.RemoteUpdate(repoPath)
+		return err
+	})
+	return updated, err
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// stubSleep records the waits between retries instead of sleeping.
+func stubSleep(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var waits []time.Duration
+	prev := sleep
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	t.Cleanup(func() { sleep = prev })
+	return &waits
+}
+
+// flakyFetch fails its first failures fetches with an unreachable remote.
+type flakyFetch struct {
+	*mockGitOps
+	failures int
+}
+
+func (f *flakyFetch) Fetch(repoPath, remote string) error {
+	if f.failures > 0 {
+		f.failures--
+		_ = f.mockGitOps.Fetch(repoPath, remote)
+		return fmt.Errorf("fetch: %w", git.ErrUnreachable)
+	}
+	return f.mockGitOps.Fetch(repoPath, remote)
+}
+
+func TestRetry(t *testing.T) {
+	waits := stubSleep(t)
+	unreachable := fmt.Errorf("fetch: %w", git.ErrUnreachable)
+
+	calls := 0
+	n, err := Retry(3, time.Second, func() error {
+		calls++
+		if calls < 3 {
+			return unreachable
+		}
+		return nil
+	})
+	if err != nil || n != 2 {
+		t.Errorf("expected success after 2 retries, got %d (%v)", n, err)
+	}
+	if want := []time.Duration{time.Second, 2 * time.Second}; fmt.Sprint(*waits) != fmt.Sprint(want) {
+		t.Errorf("expected waits %v, got %v", want, *waits)
+	}
+
+	n, err = Retry(2, time.Second, func() error { return unreachable })
+	if !errors.Is(err, git.ErrUnreachable) || n != 2 {
+		t.Errorf("expected the last error after 2 retries, got %d (%v)", n, err)
+	}
+
+	for _, kind := range []error{git.ErrAuthFailed, git.ErrRepoNotFound} {
+		calls = 0
+		n, err = Retry(2, time.Second, func() error {
+			calls++
+			return fmt.Errorf("fetch: %w", kind)
+		})
+		if n != 0 || calls != 1 || err == nil {
+			t.Errorf("expected no retry of %v, got %d retries over %d calls (%v)", kind, n, calls, err)
+		}
+	}
+}
+
+func TestAll_RetriesUnreachableFetch(t *testing.T) {
+	stubSleep(t)
+	mock := &flakyFetch{mockGitOps: defaultMock(), failures: 1}
+	opts := Options{Strategy: "rebase", Retries: 2, RetryDelay: time.Second}
+
+	r := All([]string{"/repos/project"}, opts, mock, 1, nil)[0]
+	if r.Status == Failed || r.Retries != 1 {
+		t.Fatalf("expected the sync to succeed after 1 retry, got %s after %d: %s", r.Status, r.Retries, r.Message)
+	}
+	if !strings.Contains(r.Message, "retried 1 time(s) after network errors") {
+		t.Errorf("expected the retry noted in the message, got %q", r.Message)
+	}
+	if len(mock.fetchCalls) != 2 {
+		t.Errorf("expected 2 fetches, got %d", len(mock.fetchCalls))
+	}
+
+	// Without retries the first failure is final.
+	mock = &flakyFetch{mockGitOps: defaultMock(), failures: 1}
+	opts.Retries = 0
+	r = All([]string{"/repos/project"}, opts, mock, 1, nil)[0]
+	if r.Status != Failed || r.Retries != 0 {
+		t.Errorf("expected the fetch to fail without retries, got %s after %d", r.Status, r.Retries)
+	}
+}
@@ -0,0 +1,108 @@
+package sync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+// RepoState is the recorded sync history of one repository.
+type RepoState struct {
+	// LastRun is when the repository was last synced, whatever the outcome.
+	LastRun       time.Time `json:"last_run"`
+	LastStatus    string    `json:"last_status"`
+	Message       string    `json:"message,omitempty"`
+	CommitsPulled int       `json:"commits_pulled,omitempty"`
+	// LastSuccess is when the repository last synced, switched, or was
+	// found up to date. Zero when it never has.
+	LastSuccess time.Time `json:"last_success,omitempty"`
+}
+
+// State is the sync history of every repository synced so far, keyed by
+// repository path. Each run updates the repositories it synced and keeps
+// the rest, so syncing a subset does not forget the others.
+type State struct {
+	Repos map[string]RepoState `json:"repos"`
+}
+
+// DefaultStatePath returns the default sync state file
+// (~/.local/state/katazuke/sync-state.json).
+func DefaultStatePath() (string, error) {
+	path, err := state.Path(state.SyncState)
+	if err != nil {
+		return "", fmt.Errorf("sync state: %w", err)
+	}
+	return path, nil
+}
+
+// LoadState reads the sync state at path. A missing file is an empty
+// state.
+func LoadState(path string) (State, error) {
+	st := State{Repos: make(map[string]RepoState)}
+	// #nosec G304 - path is the katazuke sync state file
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return st, nil
+	}
+	if err != nil {
+		return st, fmt.Errorf("sync state: read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &st); err != nil {
+		return st, fmt.Errorf("sync state: parse %s: %w", path, err)
+	}
+	if st.Repos == nil {
+		st.Repos = make(map[string]RepoState)
+	}
+	return st, nil
+}
+
+// Record stores the outcome of syncing one repository at the given time.
+func (s *State) Record(r Result, at time.Time) {
+	if s.Repos == nil {
+		s.Repos = make(map[string]RepoState)
+	}
+	rs := s.Repos[r.RepoPath]
+	rs.LastRun = at
+	rs.LastStatus = r.Status.String()
+	rs.Message = r.Message
+	rs.CommitsPulled = r.CommitsPulled
+	if r.Status == Synced || r.Status == UpToDate || r.Status == Switched {
+		rs.LastSuccess = at
+	}
+	s.Repos[r.RepoPath] = rs
+}
+
+// Save writes the state to path, replacing the file atomically so an
+// interrupted save never leaves a truncated file behind.
+func (s State) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("sync state: encode: %w", err)
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("sync state: create directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("sync state: save: %w", err)
+	}
+	tmp := f.Name()
+	_, werr := f.Write(data)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		werr = os.Rename(tmp, path)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("sync state: save: %w", werr)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStateRecordSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "sync-state.json")
+	st, err := LoadState(path)
+	if err != nil || len(st.Repos) != 0 {
+		t.Fatalf("expected an empty state for a missing file, got %+v, %v", st, err)
+	}
+
+	day1 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	st.Record(Result{RepoPath: "/p/api", Status: Synced, CommitsPulled: 3}, day1)
+	st.Record(Result{RepoPath: "/p/web", Status: Failed, Message: "fetch failed"}, day1)
+	st.Record(Result{RepoPath: "/p/api", Status: Failed, Message: "conflict"}, day2)
+
+	if err := st.Save(path); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected mode 0600, got %o", perm)
+	}
+
+	got, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	api := got.Repos["/p/api"]
+	if !api.LastSuccess.Equal(day1) || !api.LastRun.Equal(day2) || api.LastStatus != "Failed" || api.Message != "conflict" {
+		t.Errorf("a failed run should keep the earlier success: %+v", api)
+	}
+	if web := got.Repos["/p/web"]; !web.LastSuccess.IsZero() || web.LastStatus != "Failed" {
+		t.Errorf("expected web never to have synced: %+v", web)
+	}
+}
+
+func TestLoadStateRejectsCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync-state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadState(path); err == nil {
+		t.Error("expected an error for a corrupt state file")
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log/slog"
 	"path/filepath"
+	"time"
 
 	"github.com/agrahamlincoln/katazuke/internal/parallel"
 )
@@ -24,6 +25,14 @@ const (
 	Switched
 	// UpToDate indicates the repository was already current with the remote.
 	UpToDate
+	// Diverged indicates the default branch has local commits that are not
+	// on the remote while also being behind it, so a fast-forward pull is
+	// impossible. See ResolveDiverged.
+	Diverged
+	// Detached indicates HEAD is detached at a commit no branch or tag
+	// keeps, so switching to the default branch would leave it to the
+	// reflog. See ResolveDetached.
+	Detached
 )
 
 // String returns the human-readable name of a Status value.
@@ -39,6 +48,10 @@ func (s Status) String() string {
 		return "Switched"
 	case UpToDate:
 		return "UpToDate"
+	case Diverged:
+		return "Diverged"
+	case Detached:
+		return "Detached"
 	default:
 		return fmt.Sprintf("Status(%d)", int(s))
 	}
@@ -51,8 +64,62 @@ type Result struct {
 	Status        Status
 	Message       string
 	CommitsPulled int // number of commits pulled, populated when known
+	// WIPBranch is the branch that dirty changes were committed to before
+	// syncing when the wip-commit dirty action is used.
+	WIPBranch string
+	// Remote, Branch, CommitsAhead, and CommitsBehind describe a Diverged
+	// default branch: the remote and branch it diverged from and the
+	// commits only it and only the remote have. For a Detached result,
+	// Remote and Branch are the default branch to switch to.
+	Remote        string
+	Branch        string
+	CommitsAhead  int
+	CommitsBehind int
+	// Detached describes the commit of a Detached result.
+	Detached *DetachedHead
+	// NewCommits and ChangedPaths describe what the pull of a Synced or
+	// Switched result brought in when Options.Details is set: the new
+	// commits, newest first and at most MaxDetailCommits of them, and the
+	// top-level files and directories they changed.
+	NewCommits   []Commit
+	ChangedPaths []string
+	// SkipReason singles out Skipped results callers report apart from
+	// the rest; "" for an ordinary skip.
+	SkipReason string
+	// Retries is how many times a call to the remote was retried after
+	// the remote could not be reached.
+	Retries int
 }
 
+// SkipReasons of repositories whose remote could not be used, or that
+// are in the middle of another operation.
+const (
+	// SkipAuthRequired is the SkipReason of a repository whose remote
+	// asked for credentials that git could not supply without a prompt.
+	SkipAuthRequired = "auth-required"
+	// SkipUnreachable is the SkipReason of a repository whose remote
+	// could not be reached, or did not answer the probe in time.
+	SkipUnreachable = "unreachable"
+	// SkipInProgress is the SkipReason of a repository in the middle of a
+	// rebase, merge, cherry-pick, revert, or bisect.
+	SkipInProgress = "in-progress"
+	// SkipDubiousOwnership is the SkipReason of a repository owned by
+	// another user that git refuses to work in until it is listed in the
+	// safe.directory setting.
+	SkipDubiousOwnership = "dubious-ownership"
+)
+
+// Dirty actions control how repos with uncommitted changes on the default
+// branch are handled.
+const (
+	// DirtyActionStash stashes changes, pulls, and pops the stash
+	// (subject to AutoStash and SkipDirty).
+	DirtyActionStash = "stash"
+	// DirtyActionWIPCommit commits changes to a wip/katazuke-<date> branch,
+	// leaving the default branch clean for the pull.
+	DirtyActionWIPCommit = "wip-commit"
+)
+
 // Options controls sync behavior.
 type Options struct {
 	Strategy           string // "rebase", "merge", "ff-only"
@@ -61,17 +128,46 @@ type Options struct {
 	DryRun             bool
 	Verbose            bool
 	SwitchMergedBranch bool
+	DirtyAction        string // DirtyActionStash (default) or DirtyActionWIPCommit
+	// HostOf names the host each repo's fetch depends on, and HostLimits
+	// caps concurrent syncs per host (see parallel.RunHosts). A nil HostOf
+	// applies no caps.
+	HostOf     func(repoPath string) string
+	HostLimits parallel.Limits
+	// Retries is how many times a probe, fetch, or pull that could not
+	// reach the remote is retried, waiting RetryDelay before the first
+	// retry and doubling the wait for each one after it.
+	Retries    int
+	RetryDelay time.Duration
+	// Details collects the commits and changed paths each pull brings in
+	// (Result.NewCommits and Result.ChangedPaths).
+	Details bool
+}
+
+// MaxDetailCommits caps the commits listed in Result.NewCommits;
+// CommitsPulled still counts them all.
+const MaxDetailCommits = 20
+
+// Commit is a commit brought in by a pull.
+type Commit struct {
+	SHA     string
+	Author  string
+	Subject string
 }
 
 // GitOps defines the git operations needed by the sync logic.
 // This interface enables testing with mocks.
 type GitOps interface {
 	Fetch(repoPath, remote string) error
+	Probe(repoPath, remote string) error
+	RefreshRemoteHead(repoPath, remote string) error
+	IsBare(repoPath string) bool
+	RemoteUpdate(repoPath string) (bool, error)
 	IsClean(repoPath string) (bool, error)
 	CurrentBranch(repoPath string) (string, error)
 	DefaultBranch(repoPath string) (string, error)
-	HasRemote(repoPath, remote string) bool
-	Pull(repoPath string, strategy string) error
+	PrimaryRemote(repoPath string) string
+	Pull(repoPath, remote, branch, strategy string) error
 	IsMerged(repoPath, branch, base string) (bool, error)
 	Checkout(repoPath, branch string) error
 	MergeBase(repoPath string, ref1, ref2 string) (string, error)
@@ -81,6 +177,18 @@ type GitOps interface {
 	RebaseAbort(repoPath string) error
 	MergeAbort(repoPath string) error
 	RevListCount(repoPath, spec string) (int, error)
+	CreateBranch(repoPath, branch string) error
+	BranchExists(repoPath, branch string) bool
+	CommitAll(repoPath, message string) error
+	CreateBranchAt(repoPath, branch, ref string) error
+	ResetHard(repoPath, ref string) error
+	ConflictState(repoPath string) string
+	CheckOwnership(repoPath string) error
+	DescribeDetached(repoPath string) (DetachedHead, error)
+	Log(repoPath, spec string, max int) ([]Commit, error)
+	ChangedTopLevelPaths(repoPath, from, to string) ([]string, error)
+	TagsSince(repoPath string, since time.Time) ([]Tag, error)
+	MergesSince(repoPath, ref string, since time.Time, max int) ([]Commit, error)
 }
 
 // ResultFunc is called sequentially as each repo finishes syncing.
@@ -92,7 +200,7 @@ type ResultFunc func(completed, total int, result Result)
 // workers and returns results. An optional callback is called
 // sequentially as each repo completes.
 func All(repos []string, opts Options, git GitOps, workers int, onResult ResultFunc) []Result {
-	return parallel.Run(repos, workers, func(repoPath string) Result {
+	return parallel.RunHosts(repos, workers, opts.HostOf, opts.HostLimits, func(repoPath string) Result {
 		return syncOne(repoPath, opts, git)
 	}, func(completed, total int, result Result) {
 		if onResult != nil {
@@ -101,28 +209,99 @@ func All(repos []string, opts Options, git GitOps, workers int, onResult ResultF
 	})
 }
 
+// syncOne syncs one repository, retrying calls to its remote as opts
+// allows and noting any retries in the result.
 func syncOne(repoPath string, opts Options, git GitOps) Result {
+	if opts.Retries <= 0 {
+		return syncRepo(repoPath, opts, git)
+	}
+	rg := &retryGitOps{GitOps: git, retries: opts.Retries, delay: opts.RetryDelay}
+	result := syncRepo(repoPath, opts, rg)
+	if rg.retried > 0 {
+		result.Retries = rg.retried
+		note := fmt.Sprintf("retried %d time(s) after network errors", rg.retried)
+		if result.Message == "" {
+			result.Message = note
+		} else {
+			result.Message += " (" + note + ")"
+		}
+	}
+	return result
+}
+
+func syncRepo(repoPath string, opts Options, git GitOps) Result {
 	repoName := filepath.Base(repoPath)
 	result := Result{
 		RepoPath: repoPath,
 		RepoName: repoName,
 	}
 
-	// Check for origin remote.
-	if !git.HasRemote(repoPath, "origin") {
+	// Git refuses every command in a repository another user owns, which
+	// would otherwise surface below as a missing remote or failed fetch.
+	if err := git.CheckOwnership(repoPath); err != nil {
+		result.Status = Skipped
+		result.SkipReason = SkipDubiousOwnership
+		result.Message = "owned by another user and not listed in safe.directory"
+		return result
+	}
+
+	// Find the remote that holds the default branch.
+	remote := git.PrimaryRemote(repoPath)
+	if remote == "" {
+		result.Status = Skipped
+		result.Message = "no remote to sync from"
+		return result
+	}
+
+	// Bare repositories and mirrors have no branch checked out or working
+	// tree to update; fetching every remote is the whole sync.
+	if git.IsBare(repoPath) {
+		return syncBare(repoPath, repoName, opts, git)
+	}
+
+	// Pulling or switching branches in the middle of a rebase, merge, or
+	// bisect would at best fail obscurely; leave it to be finished first.
+	if op := git.ConflictState(repoPath); op != "" {
 		result.Status = Skipped
-		result.Message = "no origin remote"
+		result.SkipReason = SkipInProgress
+		result.Message = fmt.Sprintf("%s in progress (%s)", op, inProgressHint(op))
 		return result
 	}
 
+	// A quick ls-remote sets aside remotes that want credentials or do
+	// not answer, which a fetch would wait on far longer. Other probe
+	// failures are left for the fetch to report.
+	slog.Debug("probing", "repo", repoName, "remote", remote)
+	if err := git.Probe(repoPath, remote); err != nil {
+		switch {
+		case needsAuth(err):
+			return authRequired(result, remote)
+		case isUnreachable(err):
+			result.Status = Skipped
+			result.SkipReason = SkipUnreachable
+			result.Message = fmt.Sprintf("%s is unreachable: %v", remote, firstLine(err))
+			return result
+		}
+	}
+
 	// Always fetch first (safe operation).
-	slog.Debug("fetching", "repo", repoName)
-	if err := git.Fetch(repoPath, "origin"); err != nil {
+	slog.Debug("fetching", "repo", repoName, "remote", remote)
+	if err := git.Fetch(repoPath, remote); err != nil {
+		if needsAuth(err) {
+			return authRequired(result, remote)
+		}
 		result.Status = Failed
 		result.Message = fmt.Sprintf("fetch failed: %v", err)
 		return result
 	}
 
+	// Follow default branch renames on the remote; a stale origin/HEAD
+	// would make DefaultBranch name a branch that no longer exists. A
+	// failure here is not fatal since DefaultBranch has fallbacks.
+	if err := git.RefreshRemoteHead(repoPath, remote); err != nil {
+		slog.Debug("could not refresh remote HEAD", "repo", repoName, "remote", remote, "error", err)
+	}
+
 	// Determine the default branch.
 	defaultBranch, err := git.DefaultBranch(repoPath)
 	if err != nil {
@@ -140,11 +319,11 @@ func syncOne(repoPath string, opts Options, git GitOps) Result {
 	}
 
 	if currentBranch == "" {
-		return syncDetachedHEAD(repoPath, repoName, defaultBranch, opts, git)
+		return syncDetachedHEAD(repoPath, repoName, remote, defaultBranch, opts, git)
 	}
 
 	if currentBranch != defaultBranch {
-		return syncNonDefault(repoPath, repoName, currentBranch, defaultBranch, opts, git)
+		return syncNonDefault(repoPath, repoName, remote, currentBranch, defaultBranch, opts, git)
 	}
 
 	// Check working tree status.
@@ -156,53 +335,119 @@ func syncOne(repoPath string, opts Options, git GitOps) Result {
 	}
 
 	if clean {
-		return syncClean(repoPath, repoName, defaultBranch, opts, git)
+		return syncClean(repoPath, repoName, remote, defaultBranch, opts, git)
 	}
-	return syncDirty(repoPath, repoName, defaultBranch, opts, git)
+	return syncDirty(repoPath, repoName, remote, defaultBranch, opts, git)
 }
 
-func syncDetachedHEAD(repoPath, repoName, defaultBranch string, opts Options, git GitOps) Result {
+// authRequired skips a repository whose remote needs credentials, which
+// katazuke never prompts for.
+func authRequired(result Result, remote string) Result {
+	result.Status = Skipped
+	result.SkipReason = SkipAuthRequired
+	result.Message = fmt.Sprintf("%s needs credentials, not prompting", remote)
+	return result
+}
+
+// syncBare updates a bare repository or mirror with git remote update.
+func syncBare(repoPath, repoName string, opts Options, git GitOps) Result {
+	result := Result{RepoPath: repoPath, RepoName: repoName}
+	if opts.DryRun {
+		result.Status = Skipped
+		result.Message = "would update bare repository from its remotes (dry run)"
+		return result
+	}
+
+	slog.Debug("updating bare repository", "repo", repoName)
+	changed, err := git.RemoteUpdate(repoPath)
+	if err != nil {
+		if needsAuth(err) {
+			return authRequired(result, "a remote")
+		}
+		result.Status = Failed
+		result.Message = fmt.Sprintf("remote update failed: %v", err)
+		return result
+	}
+	if !changed {
+		result.Status = UpToDate
+		return result
+	}
+	result.Status = Synced
+	result.Message = "bare repository updated from its remotes"
+	return result
+}
+
+// syncDetachedHEAD switches a clean detached HEAD to the default branch
+// and syncs it when a branch or tag keeps the commit. A commit nothing
+// keeps is reported as Detached for the caller to resolve.
+func syncDetachedHEAD(repoPath, repoName, remote, defaultBranch string, opts Options, git GitOps) Result {
 	result := Result{
 		RepoPath: repoPath,
 		RepoName: repoName,
 	}
 
+	head, err := git.DescribeDetached(repoPath)
+	if err != nil {
+		result.Status = Failed
+		result.Message = fmt.Sprintf("could not inspect detached HEAD: %v", err)
+		return result
+	}
+
 	clean, err := git.IsClean(repoPath)
 	if err != nil {
 		result.Status = Failed
 		result.Message = fmt.Sprintf("could not check working tree: %v", err)
 		return result
 	}
-
 	if !clean {
 		result.Status = Skipped
-		result.Message = "detached HEAD with dirty working tree"
+		result.Message = fmt.Sprintf("detached HEAD at %s with dirty working tree", head.Describe())
+		return result
+	}
+
+	if head.Orphaned() {
+		result.Status = Detached
+		result.Remote = remote
+		result.Branch = defaultBranch
+		result.Detached = &head
+		result.Message = fmt.Sprintf("detached HEAD at %s", head.Describe())
 		return result
 	}
 
 	if opts.DryRun {
 		result.Status = Skipped
-		result.Message = fmt.Sprintf("would switch from detached HEAD to %s and sync (dry run)", defaultBranch)
+		result.Message = fmt.Sprintf("would switch from detached HEAD at %s to %s and sync (dry run)", head.Describe(), defaultBranch)
 		return result
 	}
+	return switchFromDetached(repoPath, repoName, remote, defaultBranch, head, opts, git)
+}
+
+// switchFromDetached checks out the default branch and syncs it.
+func switchFromDetached(repoPath, repoName, remote, defaultBranch string, head DetachedHead, opts Options, git GitOps) Result {
+	result := Result{
+		RepoPath: repoPath,
+		RepoName: repoName,
+	}
 
-	slog.Debug("switching from detached HEAD", "repo", repoName, "to", defaultBranch)
+	slog.Debug("switching from detached HEAD", "repo", repoName, "at", head.SHA, "to", defaultBranch)
 	if err := git.Checkout(repoPath, defaultBranch); err != nil {
 		result.Status = Failed
 		result.Message = fmt.Sprintf("could not switch to %s: %v", defaultBranch, err)
 		return result
 	}
 
-	pullResult := syncClean(repoPath, repoName, defaultBranch, opts, git)
-	if pullResult.Status == Failed {
+	pullResult := syncClean(repoPath, repoName, remote, defaultBranch, opts, git)
+	if pullResult.Status == Failed || pullResult.Status == Diverged {
 		return pullResult
 	}
 
 	result.Status = Switched
+	result.NewCommits, result.ChangedPaths = pullResult.NewCommits, pullResult.ChangedPaths
+	from := "detached HEAD at " + head.Describe()
 	if pullResult.Status == UpToDate {
-		result.Message = fmt.Sprintf("switched from detached HEAD to %s (up-to-date)", defaultBranch)
+		result.Message = fmt.Sprintf("switched from %s to %s (up-to-date)", from, defaultBranch)
 	} else {
-		msg := fmt.Sprintf("switched from detached HEAD to %s and synced", defaultBranch)
+		msg := fmt.Sprintf("switched from %s to %s and synced", from, defaultBranch)
 		if pullResult.CommitsPulled > 0 {
 			msg += fmt.Sprintf(" (%d %s)", pullResult.CommitsPulled, pluralCommit(pullResult.CommitsPulled))
 		}
@@ -211,14 +456,14 @@ func syncDetachedHEAD(repoPath, repoName, defaultBranch string, opts Options, gi
 	return result
 }
 
-func syncNonDefault(repoPath, repoName, currentBranch, defaultBranch string, opts Options, git GitOps) Result {
+func syncNonDefault(repoPath, repoName, remote, currentBranch, defaultBranch string, opts Options, git GitOps) Result {
 	result := Result{
 		RepoPath: repoPath,
 		RepoName: repoName,
 	}
 
-	// Check if the current branch is merged into origin/<default>.
-	remoteDefault := "origin/" + defaultBranch
+	// Check if the current branch is merged into <remote>/<default>.
+	remoteDefault := remote + "/" + defaultBranch
 	merged, err := git.IsMerged(repoPath, currentBranch, remoteDefault)
 	if err != nil {
 		// If we can't determine merge status, fall back to the original skip behavior.
@@ -268,12 +513,13 @@ func syncNonDefault(repoPath, repoName, currentBranch, defaultBranch string, opt
 	}
 
 	// Now continue with normal sync (clean working tree on default branch).
-	pullResult := syncClean(repoPath, repoName, defaultBranch, opts, git)
-	if pullResult.Status == Failed {
+	pullResult := syncClean(repoPath, repoName, remote, defaultBranch, opts, git)
+	if pullResult.Status == Failed || pullResult.Status == Diverged {
 		return pullResult
 	}
 
 	result.Status = Switched
+	result.NewCommits, result.ChangedPaths = pullResult.NewCommits, pullResult.ChangedPaths
 	if pullResult.Status == UpToDate {
 		result.Message = fmt.Sprintf("switched from merged branch %q to %s (up-to-date)", currentBranch, defaultBranch)
 	} else {
@@ -286,21 +532,27 @@ func syncNonDefault(repoPath, repoName, currentBranch, defaultBranch string, opt
 	return result
 }
 
-func syncClean(repoPath, repoName, defaultBranch string, opts Options, git GitOps) Result {
+func syncClean(repoPath, repoName, remote, defaultBranch string, opts Options, git GitOps) Result {
 	result := Result{
 		RepoPath: repoPath,
 		RepoName: repoName,
 	}
 
 	// Check how many commits we're behind the remote. This uses the
-	// already-fetched origin ref, so the count matches what pull will apply.
-	remoteRef := "origin/" + defaultBranch
+	// already-fetched remote ref, so the count matches what pull will apply.
+	remoteRef := remote + "/" + defaultBranch
 	behindCount, countErr := git.RevListCount(repoPath, "HEAD.."+remoteRef)
 	if countErr == nil && behindCount == 0 {
 		result.Status = UpToDate
 		return result
 	}
 
+	if countErr == nil {
+		if diverged, ok := checkDiverged(result, remote, defaultBranch, behindCount, opts, git); ok {
+			return diverged
+		}
+	}
+
 	if opts.DryRun {
 		result.Status = Skipped
 		if countErr == nil {
@@ -311,14 +563,18 @@ func syncClean(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 		return result
 	}
 
+	commits, paths := incoming(repoPath, remoteRef, opts, git)
 	slog.Debug("pulling", "repo", repoName, "strategy", opts.Strategy)
-	if err := git.Pull(repoPath, opts.Strategy); err != nil {
-		result.Status = Failed
-		result.Message = fmt.Sprintf("pull failed: %v", err)
+	if err := git.Pull(repoPath, remote, defaultBranch, opts.Strategy); err != nil {
+		if needsAuth(err) {
+			return authRequired(result, remote)
+		}
+		result.Status, result.Message = pullFailure(remoteRef, err)
 		return result
 	}
 
 	result.Status = Synced
+	result.NewCommits, result.ChangedPaths = commits, paths
 	if countErr == nil {
 		result.CommitsPulled = behindCount
 		result.Message = fmt.Sprintf("%d %s", behindCount, pluralCommit(behindCount))
@@ -328,7 +584,7 @@ func syncClean(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	return result
 }
 
-func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOps) Result {
+func syncDirty(repoPath, repoName, remote, defaultBranch string, opts Options, git GitOps) Result {
 	result := Result{
 		RepoPath: repoPath,
 		RepoName: repoName,
@@ -340,6 +596,10 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 		return result
 	}
 
+	if opts.DirtyAction == DirtyActionWIPCommit {
+		return syncDirtyWIP(repoPath, repoName, remote, defaultBranch, opts, git)
+	}
+
 	if !opts.AutoStash {
 		result.Status = Skipped
 		result.Message = "dirty working tree (auto_stash disabled)"
@@ -347,7 +607,7 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	}
 
 	// Simulate the merge with merge-tree to check for conflicts.
-	remoteRef := "origin/" + defaultBranch
+	remoteRef := remote + "/" + defaultBranch
 	base, err := git.MergeBase(repoPath, "HEAD", remoteRef)
 	if err != nil {
 		result.Status = Failed
@@ -369,12 +629,17 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	}
 
 	// Check how many commits we're behind the remote. This uses the
-	// already-fetched origin ref, so the count matches what pull will apply.
+	// already-fetched remote ref, so the count matches what pull will apply.
 	behindCount, countErr := git.RevListCount(repoPath, "HEAD.."+remoteRef)
 	if countErr == nil && behindCount == 0 {
 		result.Status = UpToDate
 		return result
 	}
+	if countErr == nil {
+		if diverged, ok := checkDiverged(result, remote, defaultBranch, behindCount, opts, git); ok {
+			return diverged
+		}
+	}
 
 	if opts.DryRun {
 		result.Status = Skipped
@@ -387,6 +652,7 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	}
 
 	// Stash, pull, pop.
+	commits, paths := incoming(repoPath, remoteRef, opts, git)
 	stashed, err := git.StashPush(repoPath, "katazuke: auto-stash before sync")
 	if err != nil {
 		result.Status = Failed
@@ -396,7 +662,7 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	slog.Debug("stash push completed", "repo", repoName, "created", stashed)
 
 	slog.Debug("pulling with stash", "repo", repoName, "strategy", opts.Strategy)
-	if err := git.Pull(repoPath, opts.Strategy); err != nil {
+	if err := git.Pull(repoPath, remote, defaultBranch, opts.Strategy); err != nil {
 		// Pull failed -- abort the partial pull to restore pre-pull state.
 		slog.Debug("aborting partial pull", "repo", repoName, "strategy", opts.Strategy)
 		abortPull(repoPath, opts.Strategy, git)
@@ -415,6 +681,7 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	}
 
 	result.Status = Synced
+	result.NewCommits, result.ChangedPaths = commits, paths
 	if countErr == nil {
 		result.CommitsPulled = behindCount
 		result.Message = fmt.Sprintf("%d %s, auto-stash", behindCount, pluralCommit(behindCount))
@@ -424,6 +691,119 @@ func syncDirty(repoPath, repoName, defaultBranch string, opts Options, git GitOp
 	return result
 }
 
+// syncDirtyWIP commits uncommitted changes to a fresh wip branch so the
+// default branch can be pulled with a clean working tree. Unlike stash/pop,
+// this can never leave the user's changes in a conflicted state: they are
+// preserved as a commit regardless of how the pull goes.
+func syncDirtyWIP(repoPath, repoName, remote, defaultBranch string, opts Options, git GitOps) Result {
+	result := Result{
+		RepoPath: repoPath,
+		RepoName: repoName,
+	}
+
+	// Only move work aside when there is something to pull.
+	remoteRef := remote + "/" + defaultBranch
+	behindCount, countErr := git.RevListCount(repoPath, "HEAD.."+remoteRef)
+	if countErr == nil && behindCount == 0 {
+		result.Status = UpToDate
+		return result
+	}
+
+	wipBranch := wipBranchName(repoPath, git, time.Now())
+
+	if opts.DryRun {
+		result.Status = Skipped
+		result.Message = fmt.Sprintf("would commit dirty changes to %s and pull (dry run)", wipBranch)
+		return result
+	}
+
+	slog.Debug("committing dirty changes to wip branch", "repo", repoName, "branch", wipBranch)
+	if err := git.CreateBranch(repoPath, wipBranch); err != nil {
+		result.Status = Failed
+		result.Message = fmt.Sprintf("could not create %s: %v", wipBranch, err)
+		return result
+	}
+	if err := git.CommitAll(repoPath, "katazuke: WIP before sync"); err != nil {
+		// The changes are still in the working tree; carry them back.
+		if coErr := git.Checkout(repoPath, defaultBranch); coErr != nil {
+			slog.Debug("could not return to default branch", "repo", repoName, "error", coErr)
+		}
+		result.Status = Failed
+		result.Message = fmt.Sprintf("wip commit failed (changes left in working tree): %v", err)
+		return result
+	}
+	result.WIPBranch = wipBranch
+
+	if err := git.Checkout(repoPath, defaultBranch); err != nil {
+		result.Status = Failed
+		result.Message = fmt.Sprintf("changes committed to %s but could not switch back to %s: %v", wipBranch, defaultBranch, err)
+		return result
+	}
+
+	pullResult := syncClean(repoPath, repoName, remote, defaultBranch, opts, git)
+	if pullResult.Status == Failed || pullResult.Status == Diverged {
+		pullResult.WIPBranch = wipBranch
+		pullResult.Message = fmt.Sprintf("%s (changes saved on %s)", pullResult.Message, wipBranch)
+		return pullResult
+	}
+
+	result.Status = Synced
+	result.CommitsPulled = pullResult.CommitsPulled
+	result.NewCommits, result.ChangedPaths = pullResult.NewCommits, pullResult.ChangedPaths
+	if pullResult.CommitsPulled > 0 {
+		result.Message = fmt.Sprintf("%d %s, changes saved on %s", pullResult.CommitsPulled, pluralCommit(pullResult.CommitsPulled), wipBranch)
+	} else {
+		result.Message = fmt.Sprintf("pulled, changes saved on %s", wipBranch)
+	}
+	return result
+}
+
+// incoming returns the commits and top-level paths pulling remoteRef
+// would bring in when opts.Details is set. Details that cannot be read are
+// left out rather than failing the sync.
+func incoming(repoPath, remoteRef string, opts Options, git GitOps) ([]Commit, []string) {
+	if !opts.Details {
+		return nil, nil
+	}
+	commits, err := git.Log(repoPath, "HEAD.."+remoteRef, MaxDetailCommits)
+	if err != nil {
+		slog.Debug("could not list incoming commits", "repo", filepath.Base(repoPath), "error", err)
+	}
+	paths, err := git.ChangedTopLevelPaths(repoPath, "HEAD", remoteRef)
+	if err != nil {
+		slog.Debug("could not list incoming changes", "repo", filepath.Base(repoPath), "error", err)
+	}
+	return commits, paths
+}
+
+// checkDiverged reports result as Diverged when an ff-only pull cannot
+// succeed because the default branch, behind the remote by behind commits,
+// also has commits of its own. Other strategies reconcile the two on their
+// own, so they are never reported.
+func checkDiverged(result Result, remote, defaultBranch string, behind int, opts Options, git GitOps) (Result, bool) {
+	if opts.Strategy != "ff-only" {
+		return result, false
+	}
+	remoteRef := remote + "/" + defaultBranch
+	ahead, err := git.RevListCount(result.RepoPath, remoteRef+"..HEAD")
+	if err != nil || ahead == 0 {
+		return result, false
+	}
+	result.Status = Diverged
+	result.Remote = remote
+	result.Branch = defaultBranch
+	result.CommitsAhead = ahead
+	result.CommitsBehind = behind
+	result.Message = fmt.Sprintf("diverged from %s: %d local and %d remote %s", remoteRef, ahead, behind, pluralCommit(behind))
+	return result, true
+}
+
+// wipBranchName returns wip/katazuke-<date>, adding a numeric suffix when
+// a branch from an earlier sync on the same day already exists.
+func wipBranchName(repoPath string, git GitOps, now time.Time) string {
+	return unusedBranchName(repoPath, "wip/katazuke-"+now.Format("2006-01-02"), git)
+}
+
 func pluralCommit(n int) string {
 	if n == 1 {
 		return "commit"
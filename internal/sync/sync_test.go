@@ -5,8 +5,11 @@ import (
 	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	gosync "sync"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
 )
 
 // mockGitOps implements GitOps for testing.
@@ -14,13 +17,18 @@ type mockGitOps struct {
 	mu gosync.Mutex
 
 	fetchErr         error
+	probeErr         error
+	refreshHeadErr   error
+	isBare           bool
+	remoteUpdated    bool
+	remoteUpdateErr  error
 	isClean          bool
 	isCleanErr       error
 	currentBranch    string
 	currentBrErr     error
 	defaultBranch    string
 	defaultBrErr     error
-	hasRemote        bool
+	remote           string
 	pullErr          error
 	isMerged         bool
 	isMergedErr      error
@@ -37,10 +45,26 @@ type mockGitOps struct {
 	mergeAbortErr    error
 	revListCount     int
 	revListCountErr  error
+	aheadCount       int // returned for <remote>/<branch>..HEAD specs
+	resetErr         error
+	createBranchErr  error
+	commitAllErr     error
+	existingBranches map[string]bool
+	detached         DetachedHead
+	describeErr      error
+	conflictState    string
+	ownershipErr     error
+	logCommits       []Commit
+	changedPaths     []string
+	tagsSince        []Tag
+	mergesSince      []Commit
 
 	// Track calls for verification.
 	fetchCalls        []string
+	fetchRemotes      []string
+	refreshHeadCalls  []string
 	pullCalls         []string
+	pullRefs          []string
 	revListCountCalls []string
 	isMergedCalls     []string
 	checkoutCalls     []string
@@ -48,15 +72,41 @@ type mockGitOps struct {
 	stashPopCalls     int
 	rebaseAbortCalls  int
 	mergeAbortCalls   int
+	createBranchCalls []string
+	commitAllCalls    []string
+	branchAtCalls     []string
+	resetCalls        []string
+	logCalls          []string
+	mergesRefs        []string
 }
 
-func (m *mockGitOps) Fetch(repoPath, _ string) error {
+func (m *mockGitOps) Fetch(repoPath, remote string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.fetchCalls = append(m.fetchCalls, repoPath)
+	m.fetchRemotes = append(m.fetchRemotes, remote)
 	return m.fetchErr
 }
 
+func (m *mockGitOps) Probe(_, _ string) error {
+	return m.probeErr
+}
+
+func (m *mockGitOps) RefreshRemoteHead(_, remote string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshHeadCalls = append(m.refreshHeadCalls, remote)
+	return m.refreshHeadErr
+}
+
+func (m *mockGitOps) IsBare(_ string) bool {
+	return m.isBare
+}
+
+func (m *mockGitOps) RemoteUpdate(_ string) (bool, error) {
+	return m.remoteUpdated, m.remoteUpdateErr
+}
+
 func (m *mockGitOps) IsClean(_ string) (bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -75,16 +125,17 @@ func (m *mockGitOps) DefaultBranch(_ string) (string, error) {
 	return m.defaultBranch, m.defaultBrErr
 }
 
-func (m *mockGitOps) HasRemote(_, _ string) bool {
+func (m *mockGitOps) PrimaryRemote(_ string) string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.hasRemote
+	return m.remote
 }
 
-func (m *mockGitOps) Pull(_ string, strategy string) error {
+func (m *mockGitOps) Pull(_, remote, branch, strategy string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.pullCalls = append(m.pullCalls, strategy)
+	m.pullRefs = append(m.pullRefs, remote+"/"+branch)
 	return m.pullErr
 }
 
@@ -146,12 +197,92 @@ func (m *mockGitOps) RevListCount(_ string, spec string) (int, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.revListCountCalls = append(m.revListCountCalls, spec)
+	if strings.HasSuffix(spec, "..HEAD") {
+		return m.aheadCount, m.revListCountErr
+	}
 	return m.revListCount, m.revListCountErr
 }
 
+func (m *mockGitOps) CreateBranchAt(_ string, branch, ref string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.branchAtCalls = append(m.branchAtCalls, branch+"@"+ref)
+	return m.createBranchErr
+}
+
+func (m *mockGitOps) ResetHard(_ string, ref string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetCalls = append(m.resetCalls, ref)
+	return m.resetErr
+}
+
+func (m *mockGitOps) CreateBranch(_ string, branch string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createBranchCalls = append(m.createBranchCalls, branch)
+	return m.createBranchErr
+}
+
+func (m *mockGitOps) BranchExists(_ string, branch string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.existingBranches[branch]
+}
+
+func (m *mockGitOps) CommitAll(_ string, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.commitAllCalls = append(m.commitAllCalls, message)
+	return m.commitAllErr
+}
+
+func (m *mockGitOps) ConflictState(_ string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.conflictState
+}
+
+func (m *mockGitOps) CheckOwnership(_ string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ownershipErr
+}
+
+func (m *mockGitOps) DescribeDetached(_ string) (DetachedHead, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.detached, m.describeErr
+}
+
+func (m *mockGitOps) Log(_ string, spec string, _ int) ([]Commit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logCalls = append(m.logCalls, spec)
+	return m.logCommits, nil
+}
+
+func (m *mockGitOps) ChangedTopLevelPaths(_, _, _ string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.changedPaths, nil
+}
+
+func (m *mockGitOps) TagsSince(_ string, _ time.Time) ([]Tag, error) {
+	return m.tagsSince, nil
+}
+
+func (m *mockGitOps) MergesSince(_ string, ref string, _ time.Time, _ int) ([]Commit, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mergesRefs = append(m.mergesRefs, ref)
+	return m.mergesSince, nil
+}
+
 func defaultMock() *mockGitOps {
 	return &mockGitOps{
-		hasRemote:        true,
+		detached:         DetachedHead{SHA: "abc1234def", Branches: []string{"origin/main"}},
+		remote:           "origin",
 		isClean:          true,
 		currentBranch:    "main",
 		defaultBranch:    "main",
@@ -182,9 +313,46 @@ func TestAll_CleanRepo(t *testing.T) {
 	}
 }
 
+func TestAll_Details(t *testing.T) {
+	mock := defaultMock()
+	mock.logCommits = []Commit{{SHA: "abc1234def", Author: "Ada", Subject: "Fix login"}}
+	mock.changedPaths = []string{"README.md", "cmd/"}
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]
+	if len(mock.logCalls) != 0 || r.NewCommits != nil {
+		t.Errorf("should not collect details unless asked, got %v", mock.logCalls)
+	}
+
+	mock = defaultMock()
+	mock.logCommits = []Commit{{SHA: "abc1234def", Author: "Ada", Subject: "Fix login"}}
+	mock.changedPaths = []string{"README.md", "cmd/"}
+	r = All([]string{"/repos/project"}, Options{Strategy: "rebase", Details: true}, mock, 1, nil)[0]
+	if r.Status != Synced {
+		t.Fatalf("expected Synced, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.logCalls) != 1 || mock.logCalls[0] != "HEAD..origin/main" {
+		t.Errorf("expected the incoming range to be logged, got %v", mock.logCalls)
+	}
+	if len(r.NewCommits) != 1 || r.NewCommits[0].Subject != "Fix login" || len(r.ChangedPaths) != 2 {
+		t.Errorf("expected the pulled commits and paths, got %+v", r)
+	}
+}
+
+func TestAll_DetailsCarriedThroughSwitch(t *testing.T) {
+	mock := defaultMock()
+	mock.currentBranch = "feature/done"
+	mock.isMerged = true
+	mock.logCommits = []Commit{{SHA: "abc1234def", Author: "Ada", Subject: "Fix login"}}
+
+	r := All([]string{"/repos/project"}, Options{Strategy: "rebase", SwitchMergedBranch: true, Details: true}, mock, 1, nil)[0]
+	if r.Status != Switched || len(r.NewCommits) != 1 {
+		t.Errorf("expected Switched with the pulled commits, got %+v", r)
+	}
+}
+
 func TestAll_NoRemote(t *testing.T) {
 	mock := defaultMock()
-	mock.hasRemote = false
+	mock.remote = ""
 	opts := Options{Strategy: "rebase"}
 
 	results := All([]string{"/repos/local-only"}, opts, mock, 1, nil)
@@ -198,6 +366,27 @@ func TestAll_NoRemote(t *testing.T) {
 	}
 }
 
+func TestAll_UsesPrimaryRemote(t *testing.T) {
+	mock := defaultMock()
+	mock.remote = "upstream"
+	opts := Options{Strategy: "ff-only"}
+
+	results := All([]string{"/repos/fork"}, opts, mock, 1, nil)
+
+	if r := results[0]; r.Status != Synced {
+		t.Errorf("expected Synced, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.fetchRemotes) != 1 || mock.fetchRemotes[0] != "upstream" {
+		t.Errorf("expected fetch from upstream, got %v", mock.fetchRemotes)
+	}
+	if len(mock.revListCountCalls) == 0 || mock.revListCountCalls[0] != "HEAD..upstream/main" {
+		t.Errorf("expected behind count against upstream/main, got %v", mock.revListCountCalls)
+	}
+	if len(mock.pullRefs) != 1 || mock.pullRefs[0] != "upstream/main" {
+		t.Errorf("expected pull of upstream/main, got %v", mock.pullRefs)
+	}
+}
+
 func TestAll_FetchFails(t *testing.T) {
 	mock := defaultMock()
 	mock.fetchErr = fmt.Errorf("network error")
@@ -211,6 +400,101 @@ func TestAll_FetchFails(t *testing.T) {
 	}
 }
 
+func TestAll_FetchNeedsCredentials(t *testing.T) {
+	mock := defaultMock()
+	mock.fetchErr = fmt.Errorf("fetch: %w", git.ErrAuthFailed)
+	opts := Options{Strategy: "rebase"}
+
+	r := All([]string{"/repos/project"}, opts, mock, 1, nil)[0]
+	if r.Status != Skipped || r.SkipReason != SkipAuthRequired {
+		t.Errorf("expected an auth-required skip, got %s (%q): %s", r.Status, r.SkipReason, r.Message)
+	}
+}
+
+func TestAll_ProbeSetsAsideUnusableRemotes(t *testing.T) {
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{fmt.Errorf("probe: %w", git.ErrAuthFailed), SkipAuthRequired},
+		{fmt.Errorf("probe: %w", git.ErrUnreachable), SkipUnreachable},
+	}
+	for _, tt := range tests {
+		mock := defaultMock()
+		mock.probeErr = tt.err
+		r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]
+		if r.Status != Skipped || r.SkipReason != tt.reason {
+			t.Errorf("expected a %s skip, got %s (%q): %s", tt.reason, r.Status, r.SkipReason, r.Message)
+		}
+		if len(mock.fetchCalls) != 0 {
+			t.Errorf("expected no fetch after a %s probe, got %v", tt.reason, mock.fetchCalls)
+		}
+	}
+
+	// Any other probe failure is left for the fetch to report.
+	mock := defaultMock()
+	mock.probeErr = fmt.Errorf("ls-remote crashed")
+	if r := All([]string{"/repos/project"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]; r.SkipReason != "" || len(mock.fetchCalls) != 1 {
+		t.Errorf("expected the fetch to go ahead, got %s (%q) after %d fetches", r.Status, r.SkipReason, len(mock.fetchCalls))
+	}
+}
+
+func TestAll_PullBlockedByLocalChanges(t *testing.T) {
+	mock := defaultMock()
+	mock.pullErr = fmt.Errorf("pull: %w", git.ErrDirtyWorkingTree)
+	opts := Options{Strategy: "ff-only"}
+
+	r := All([]string{"/repos/project"}, opts, mock, 1, nil)[0]
+	if r.Status != Skipped || !strings.Contains(r.Message, "local changes would be overwritten") {
+		t.Errorf("expected the repo to be skipped, got %s: %s", r.Status, r.Message)
+	}
+}
+
+func TestAll_RefreshesRemoteHead(t *testing.T) {
+	mock := defaultMock()
+	mock.remote = "upstream"
+	mock.refreshHeadErr = fmt.Errorf("remote HEAD is ambiguous")
+	opts := Options{Strategy: "rebase"}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	if len(mock.refreshHeadCalls) != 1 || mock.refreshHeadCalls[0] != "upstream" {
+		t.Errorf("expected remote HEAD refresh for upstream, got %v", mock.refreshHeadCalls)
+	}
+	// A failed refresh falls back to the recorded default branch.
+	if r := results[0]; r.Status != Synced {
+		t.Errorf("expected Synced despite refresh failure, got %s: %s", r.Status, r.Message)
+	}
+}
+
+func TestAll_BareRepo(t *testing.T) {
+	mock := defaultMock()
+	mock.isBare = true
+	mock.remoteUpdated = true
+
+	results := All([]string{"/repos/mirror.git"}, Options{Strategy: "rebase"}, mock, 1, nil)
+	if r := results[0]; r.Status != Synced {
+		t.Errorf("expected Synced, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.fetchCalls) != 0 || len(mock.pullCalls) != 0 {
+		t.Errorf("expected no fetch or pull for a bare repo, got fetch %v pull %v", mock.fetchCalls, mock.pullCalls)
+	}
+
+	mock.remoteUpdated = false
+	if r := All([]string{"/repos/mirror.git"}, Options{}, mock, 1, nil)[0]; r.Status != UpToDate {
+		t.Errorf("expected UpToDate when no ref changed, got %s", r.Status)
+	}
+
+	mock.remoteUpdateErr = fmt.Errorf("could not fetch")
+	if r := All([]string{"/repos/mirror.git"}, Options{}, mock, 1, nil)[0]; r.Status != Failed {
+		t.Errorf("expected Failed, got %s", r.Status)
+	}
+
+	if r := All([]string{"/repos/mirror.git"}, Options{DryRun: true}, mock, 1, nil)[0]; r.Status != Skipped {
+		t.Errorf("expected Skipped in dry run, got %s", r.Status)
+	}
+}
+
 func TestAll_NotOnDefaultBranch(t *testing.T) {
 	mock := defaultMock()
 	mock.currentBranch = "feature/work"
@@ -978,3 +1262,174 @@ func TestAll_DryRun_UpToDate(t *testing.T) {
 		t.Errorf("expected UpToDate even in dry run, got %s: %s", r.Status, r.Message)
 	}
 }
+
+func TestAll_DirtyWIPCommit(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	mock.revListCount = 2
+	opts := Options{Strategy: "rebase", DirtyAction: DirtyActionWIPCommit}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	r := results[0]
+	if r.Status != Synced {
+		t.Fatalf("expected Synced, got %s: %s", r.Status, r.Message)
+	}
+	wantBranch := wipBranchName("/repos/project", mock, time.Now())
+	if r.WIPBranch != wantBranch {
+		t.Errorf("expected WIPBranch %q, got %q", wantBranch, r.WIPBranch)
+	}
+	if len(mock.createBranchCalls) != 1 || mock.createBranchCalls[0] != wantBranch {
+		t.Errorf("expected branch %q to be created, got %v", wantBranch, mock.createBranchCalls)
+	}
+	if len(mock.commitAllCalls) != 1 {
+		t.Errorf("expected one wip commit, got %v", mock.commitAllCalls)
+	}
+	if len(mock.checkoutCalls) != 1 || mock.checkoutCalls[0] != "main" {
+		t.Errorf("expected checkout back to main, got %v", mock.checkoutCalls)
+	}
+	if len(mock.pullCalls) != 1 {
+		t.Errorf("expected one pull, got %d", len(mock.pullCalls))
+	}
+	if len(mock.stashPushCalls) != 0 {
+		t.Error("wip-commit should not stash")
+	}
+	if !strings.Contains(r.Message, wantBranch) {
+		t.Errorf("expected message to mention %q, got %q", wantBranch, r.Message)
+	}
+}
+
+func TestAll_DirtyWIPCommitUpToDate(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	mock.revListCount = 0
+	opts := Options{Strategy: "rebase", DirtyAction: DirtyActionWIPCommit}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	if results[0].Status != UpToDate {
+		t.Errorf("expected UpToDate, got %s: %s", results[0].Status, results[0].Message)
+	}
+	if len(mock.createBranchCalls) != 0 || len(mock.commitAllCalls) != 0 {
+		t.Error("should not move changes aside when already up-to-date")
+	}
+}
+
+func TestAll_DirtyWIPCommitDryRun(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	opts := Options{Strategy: "rebase", DirtyAction: DirtyActionWIPCommit, DryRun: true}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	r := results[0]
+	if r.Status != Skipped || !strings.Contains(r.Message, "dry run") {
+		t.Errorf("expected dry-run skip, got %s: %s", r.Status, r.Message)
+	}
+	if len(mock.createBranchCalls) != 0 || len(mock.commitAllCalls) != 0 || len(mock.pullCalls) != 0 {
+		t.Error("dry run should not modify the repo")
+	}
+}
+
+func TestAll_DirtyWIPCommitSkipDirtyWins(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	opts := Options{Strategy: "rebase", SkipDirty: true, DirtyAction: DirtyActionWIPCommit}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	if results[0].Status != Skipped {
+		t.Errorf("expected Skipped, got %s", results[0].Status)
+	}
+	if len(mock.commitAllCalls) != 0 {
+		t.Error("skip_dirty should prevent the wip commit")
+	}
+}
+
+func TestAll_DirtyWIPCommitFails(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	mock.commitAllErr = fmt.Errorf("nothing to commit")
+	opts := Options{Strategy: "rebase", DirtyAction: DirtyActionWIPCommit}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	if results[0].Status != Failed {
+		t.Errorf("expected Failed, got %s", results[0].Status)
+	}
+	if len(mock.checkoutCalls) != 1 || mock.checkoutCalls[0] != "main" {
+		t.Errorf("expected checkout back to main, got %v", mock.checkoutCalls)
+	}
+	if len(mock.pullCalls) != 0 {
+		t.Error("should not pull after a failed wip commit")
+	}
+}
+
+func TestAll_DirtyWIPCommitPullFails(t *testing.T) {
+	mock := defaultMock()
+	mock.isClean = false
+	mock.pullErr = fmt.Errorf("network error")
+	opts := Options{Strategy: "rebase", DirtyAction: DirtyActionWIPCommit}
+
+	results := All([]string{"/repos/project"}, opts, mock, 1, nil)
+
+	r := results[0]
+	if r.Status != Failed {
+		t.Fatalf("expected Failed, got %s", r.Status)
+	}
+	if r.WIPBranch == "" || !strings.Contains(r.Message, r.WIPBranch) {
+		t.Errorf("expected failure message to point at the wip branch, got %q", r.Message)
+	}
+}
+
+func TestWIPBranchName(t *testing.T) {
+	now := time.Date(2025, 3, 7, 12, 0, 0, 0, time.UTC)
+	mock := defaultMock()
+
+	if got := wipBranchName("/r", mock, now); got != "wip/katazuke-2025-03-07" {
+		t.Errorf("got %q", got)
+	}
+
+	mock.existingBranches = map[string]bool{
+		"wip/katazuke-2025-03-07":   true,
+		"wip/katazuke-2025-03-07-2": true,
+	}
+	if got := wipBranchName("/r", mock, now); got != "wip/katazuke-2025-03-07-3" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestAll_OperationInProgressSkipped(t *testing.T) {
+	for op, hint := range map[string]string{
+		"rebase": "git rebase --abort",
+		"merge":  "git merge --abort",
+		"bisect": "git bisect reset",
+	} {
+		mock := defaultMock()
+		mock.conflictState = op
+
+		r := All([]string{"/repos/project"}, Options{Strategy: "rebase", SwitchMergedBranch: true}, mock, 1, nil)[0]
+		if r.Status != Skipped || r.SkipReason != SkipInProgress {
+			t.Errorf("%s: expected Skipped as in progress, got %s (%q): %s", op, r.Status, r.SkipReason, r.Message)
+		}
+		if !strings.Contains(r.Message, op+" in progress") || !strings.Contains(r.Message, hint) {
+			t.Errorf("%s: expected the message to explain how to finish, got %q", op, r.Message)
+		}
+		if len(mock.fetchCalls) != 0 || len(mock.pullCalls) != 0 || len(mock.checkoutCalls) != 0 {
+			t.Errorf("%s: should not fetch, pull, or switch during an unfinished %s", op, op)
+		}
+	}
+}
+
+func TestAll_DubiousOwnershipSkipped(t *testing.T) {
+	mock := defaultMock()
+	mock.ownershipErr = git.ErrDubiousOwnership
+
+	r := All([]string{"/repos/shared"}, Options{Strategy: "rebase"}, mock, 1, nil)[0]
+	if r.Status != Skipped || r.SkipReason != SkipDubiousOwnership {
+		t.Errorf("expected Skipped for dubious ownership, got %s (%q): %s", r.Status, r.SkipReason, r.Message)
+	}
+	if len(mock.fetchCalls) != 0 || len(mock.pullCalls) != 0 {
+		t.Error("should not fetch or pull a repository git refuses to work in")
+	}
+}
@@ -0,0 +1,155 @@
+// Package tags finds tags that are candidates for cleanup: tags that were
+// never pushed, expired archive/* tags, and tags pointing at commits no
+// branch reaches anymore.
+package tags
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/parallel"
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+)
+
+// ArchivePrefix is the prefix of tags katazuke creates to preserve the tip
+// of a branch before deleting it.
+const ArchivePrefix = "archive/"
+
+// Tag is a tag flagged for cleanup, with the reasons it was flagged.
+type Tag struct {
+	RepoPath string
+	RepoName string
+	Name     string
+	Commit   string
+	Date     time.Time
+	// Remote is the repository's primary remote when it was checked, ""
+	// otherwise.
+	Remote string
+	// OnRemote is true when Remote has a tag with the same name. Only
+	// meaningful when the remote was checked.
+	OnRemote bool
+	// LocalOnly is true when the remote was checked and the tag is missing
+	// from it.
+	LocalOnly bool
+	// ExpiredArchive is true for archive/* tags older than the configured age.
+	ExpiredArchive bool
+	// Unreachable is true when no local or remote-tracking branch contains
+	// the tagged commit.
+	Unreachable bool
+}
+
+// Label returns a display string for the tag in the form "repo: tag".
+func (t Tag) Label() string {
+	return fmt.Sprintf("%s: %s", t.RepoName, t.Name)
+}
+
+// Reasons returns short human-readable descriptions of why the tag was
+// flagged.
+func (t Tag) Reasons() []string {
+	var reasons []string
+	if t.ExpiredArchive {
+		reasons = append(reasons, "expired archive tag")
+	}
+	if t.Unreachable {
+		reasons = append(reasons, "unreachable commit")
+	}
+	if t.LocalOnly {
+		reasons = append(reasons, "not on "+t.Remote)
+	}
+	return reasons
+}
+
+// Options controls which checks Find performs.
+type Options struct {
+	// ArchiveMaxAge flags archive/* tags older than this. Zero disables
+	// the check.
+	ArchiveMaxAge time.Duration
+	// CheckRemote compares local tags against the primary remote. This
+	// contacts the remote once per repository.
+	CheckRemote bool
+}
+
+// Find scans the given repositories and returns flagged tags. Work is
+// parallelized across the given number of workers.
+func Find(repos []string, opts Options, workers int, onProgress func(completed, total int)) []Tag {
+	var resultCb func(int, int, []Tag)
+	if onProgress != nil {
+		resultCb = func(completed, total int, _ []Tag) {
+			onProgress(completed, total)
+		}
+	}
+
+	now := time.Now()
+	results := parallel.Run(repos, workers, func(repoPath string) []Tag {
+		return checkRepo(repoPath, opts, now)
+	}, resultCb)
+
+	var all []Tag
+	for _, r := range results {
+		all = append(all, r...)
+	}
+	return all
+}
+
+func checkRepo(repoPath string, opts Options, now time.Time) []Tag {
+	name := filepath.Base(repoPath)
+
+	refs, err := git.ListTags(repoPath)
+	if err != nil {
+		slog.Warn("could not list tags", "repo", name, "error", err)
+		return nil
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	unreachable := make(map[string]bool)
+	names, err := git.UnreachableTags(repoPath)
+	if err != nil {
+		slog.Debug("could not check tag reachability", "repo", name, "error", err)
+	}
+	for _, n := range names {
+		unreachable[n] = true
+	}
+
+	var remote map[string]bool
+	remoteName := ""
+	if opts.CheckRemote {
+		remoteName = git.PrimaryRemote(repoPath)
+	}
+	if remoteName != "" {
+		remote, err = git.RemoteTags(repoPath, remoteName)
+		if err != nil {
+			slog.Debug("could not list remote tags", "repo", name, "error", err)
+			remote = nil
+		}
+	}
+
+	var flagged []Tag
+	for _, ref := range refs {
+		t := Tag{
+			RepoPath:    repoPath,
+			RepoName:    name,
+			Name:        ref.Name,
+			Commit:      ref.Commit,
+			Date:        ref.Date,
+			Unreachable: unreachable[ref.Name],
+		}
+		if remote != nil {
+			t.Remote = remoteName
+			t.OnRemote = remote[ref.Name]
+			t.LocalOnly = !t.OnRemote
+		}
+		if opts.ArchiveMaxAge > 0 && strings.HasPrefix(ref.Name, ArchivePrefix) &&
+			!ref.Date.IsZero() && now.Sub(ref.Date) > opts.ArchiveMaxAge {
+			t.ExpiredArchive = true
+		}
+		if t.LocalOnly || t.ExpiredArchive || t.Unreachable {
+			flagged = append(flagged, t)
+		}
+	}
+	return flagged
+}
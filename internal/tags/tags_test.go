@@ -0,0 +1,62 @@
+package tags_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/tags"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFind(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "tagged")
+
+	// Pushed release tag on main: not flagged.
+	repo.Git("tag", "v1.0")
+	repo.Git("push", "origin", "v1.0")
+
+	// Local-only tag on main.
+	repo.Git("tag", "local-marker")
+
+	// Old archive tag on a deleted branch: expired, unreachable, local-only.
+	repo.CreateBranch("old-feature")
+	repo.WriteFile("f.txt", "f")
+	repo.AddFile("f.txt")
+	repo.CommitWithDate("old work", time.Now().AddDate(0, 0, -200))
+	repo.Git("tag", "archive/old-feature")
+	repo.Checkout("main")
+	repo.Git("branch", "-D", "old-feature")
+
+	opts := tags.Options{ArchiveMaxAge: 90 * 24 * time.Hour, CheckRemote: true}
+	found := tags.Find([]string{repo.Path}, opts, 1, nil)
+
+	byName := make(map[string]tags.Tag)
+	for _, tg := range found {
+		byName[tg.Name] = tg
+	}
+	if len(found) != 2 {
+		t.Fatalf("expected 2 flagged tags, got %+v", found)
+	}
+	if _, ok := byName["v1.0"]; ok {
+		t.Error("pushed reachable tag should not be flagged")
+	}
+
+	lm := byName["local-marker"]
+	if !lm.LocalOnly || lm.Unreachable || lm.ExpiredArchive {
+		t.Errorf("local-marker: unexpected flags %+v", lm)
+	}
+
+	arch := byName["archive/old-feature"]
+	if !arch.ExpiredArchive || !arch.Unreachable || !arch.LocalOnly {
+		t.Errorf("archive/old-feature: unexpected flags %+v", arch)
+	}
+	if got := len(arch.Reasons()); got != 3 {
+		t.Errorf("expected 3 reasons, got %v", arch.Reasons())
+	}
+
+	// Without the remote check, only the archive tag is flagged.
+	found = tags.Find([]string{repo.Path}, tags.Options{ArchiveMaxAge: opts.ArchiveMaxAge}, 1, nil)
+	if len(found) != 1 || found[0].Name != "archive/old-feature" || found[0].LocalOnly {
+		t.Errorf("expected only archive tag offline, got %+v", found)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package ui
+
+import "os"
+
+// enableVirtualTerminal reports how the terminal f writes to handles
+// escape sequences. Outside Windows they always work and TERM says more.
+func enableVirtualTerminal(*os.File) console {
+	return consoleANSI
+}
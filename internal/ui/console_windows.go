@@ -0,0 +1,27 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// enableVirtualTerminal turns on virtual terminal processing for the
+// console f writes to, so it interprets ANSI escape sequences. Consoles
+// older than Windows 10 cannot, and are reported as consoleLegacy.
+func enableVirtualTerminal(f *os.File) console {
+	h := windows.Handle(f.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return consoleLegacy
+	}
+	if mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 {
+		return consoleVT
+	}
+	if err := windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		return consoleLegacy
+	}
+	return consoleVT
+}
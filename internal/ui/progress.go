@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	gosync "sync"
+	"time"
+)
+
+// clearLine moves the cursor to the start of the line and erases it.
+const clearLine = "\r\033[2K"
+
+// rateWindow is how far back completions count toward the rate, so the
+// estimate follows slowdowns (a slow network share, a large repo) instead
+// of averaging over the whole run.
+const rateWindow = 30 * time.Second
+
+// etaWarmup is how long progress must be observed before a rate and time
+// remaining are shown; earlier estimates swing too much to be useful.
+const etaWarmup = 2 * time.Second
+
+// Progress renders result lines and a "[n/total] N remaining..." counter.
+// Once enough progress has been observed the counter also shows the recent
+// rate and estimated time remaining: "[120/430] 310 remaining, 4.2/s, ~1m left...".
+// All output goes through a single writer goroutine, so result lines from
+// concurrent workers never interleave with the counter. In plain mode no
+// escape sequences are written: result lines are printed as-is and the
+// counter is emitted as an occasional standalone line.
+//
+// Callers must call Stop before writing to the same output directly.
+type Progress struct {
+	out   io.Writer
+	plain bool
+	now   func() time.Time
+
+	mu     gosync.Mutex
+	closed bool
+	events chan progressEvent
+	done   chan struct{}
+}
+
+type progressEvent struct {
+	line      string
+	isLine    bool
+	completed int
+	total     int
+	at        time.Time
+}
+
+// progressSample is a completion count observed at a point in time.
+type progressSample struct {
+	at        time.Time
+	completed int
+}
+
+// NewProgress starts a progress renderer writing to w.
+func NewProgress(w io.Writer, plain bool) *Progress {
+	return newProgress(w, plain, time.Now)
+}
+
+func newProgress(w io.Writer, plain bool, now func() time.Time) *Progress {
+	p := &Progress{
+		out:    w,
+		plain:  plain,
+		now:    now,
+		events: make(chan progressEvent, 64),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Update records that completed of total items are done. It has the
+// signature of the onProgress callbacks used throughout katazuke.
+func (p *Progress) Update(completed, total int) {
+	p.send(progressEvent{completed: completed, total: total, at: p.now()})
+}
+
+// Printf prints a result line above the counter. A trailing newline is
+// added if missing.
+func (p *Progress) Printf(format string, args ...any) {
+	line := fmt.Sprintf(format, args...)
+	if len(line) == 0 || line[len(line)-1] != '\n' {
+		line += "\n"
+	}
+	p.send(progressEvent{line: line, isLine: true})
+}
+
+// Stop clears the counter and waits until all pending output is written.
+// It is safe to call more than once.
+func (p *Progress) Stop() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+	<-p.done
+}
+
+func (p *Progress) send(ev progressEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.events <- ev
+}
+
+func (p *Progress) run() {
+	defer close(p.done)
+
+	dim := Muted()
+	status := ""
+	lastPlainStep := 0
+	var samples []progressSample
+
+	for ev := range p.events {
+		if ev.isLine {
+			if p.plain {
+				_, _ = io.WriteString(p.out, ev.line)
+				continue
+			}
+			_, _ = io.WriteString(p.out, clearLine+ev.line+status)
+			continue
+		}
+
+		samples = addSample(samples, progressSample{at: ev.at, completed: ev.completed})
+
+		remaining := ev.total - ev.completed
+		counter := fmt.Sprintf("%d remaining", remaining)
+		if estimate := formatEstimate(samples, remaining); estimate != "" {
+			counter += ", " + estimate
+		}
+		if p.plain {
+			// Report roughly every 10% so logs show progress without
+			// one line per item.
+			step := max(1, ev.total/10)
+			if remaining > 0 && ev.completed/step > lastPlainStep {
+				lastPlainStep = ev.completed / step
+				_, _ = fmt.Fprintf(p.out, "  [%d/%d] %s...\n", ev.completed, ev.total, counter)
+			}
+			continue
+		}
+
+		if remaining > 0 {
+			status = fmt.Sprintf("  %s %s...", dim.Sprintf("[%d/%d]", ev.completed, ev.total), counter)
+			_, _ = io.WriteString(p.out, clearLine+status)
+		} else {
+			status = ""
+			_, _ = io.WriteString(p.out, clearLine)
+		}
+	}
+
+	if status != "" && !p.plain {
+		_, _ = io.WriteString(p.out, clearLine)
+	}
+}
+
+// addSample appends s and drops samples older than rateWindow, always
+// keeping at least two so a rate can be computed across long gaps.
+func addSample(samples []progressSample, s progressSample) []progressSample {
+	samples = append(samples, s)
+	for len(samples) > 2 && s.at.Sub(samples[0].at) > rateWindow {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// formatEstimate returns the rate and time remaining implied by samples,
+// e.g. "4.2/s, ~1m left", or "" until etaWarmup has passed with progress
+// being made.
+func formatEstimate(samples []progressSample, remaining int) string {
+	if len(samples) < 2 || remaining <= 0 {
+		return ""
+	}
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.at.Sub(first.at)
+	done := last.completed - first.completed
+	if elapsed < etaWarmup || done <= 0 {
+		return ""
+	}
+	rate := float64(done) / elapsed.Seconds()
+	left := time.Duration(float64(remaining) / rate * float64(time.Second))
+
+	rateStr := fmt.Sprintf("%.0f/s", rate)
+	if rate < 10 {
+		rateStr = fmt.Sprintf("%.1f/s", rate)
+	}
+	return fmt.Sprintf("%s, ~%s left", rateStr, formatRemaining(left))
+}
+
+// formatRemaining renders a duration coarsely: seconds under a minute,
+// whole minutes under an hour, then hours and minutes.
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", max(1, int(d.Seconds()+0.5)))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()+0.5))
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
@@ -0,0 +1,154 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// disableColor turns color output off for the rest of the test.
+func disableColor(t *testing.T) {
+	t.Helper()
+	saved := color.NoColor
+	t.Cleanup(func() { color.NoColor = saved })
+	color.NoColor = true
+}
+
+func TestProgress_Rich(t *testing.T) {
+	disableColor(t)
+	var buf bytes.Buffer
+	p := NewProgress(&buf, false)
+	p.Update(1, 3)
+	p.Printf("  [synced] repo-a")
+	p.Update(3, 3)
+	p.Stop()
+
+	want := clearLine + "  [1/3] 2 remaining..." +
+		clearLine + "  [synced] repo-a\n" + "  [1/3] 2 remaining..." +
+		clearLine
+	if got := buf.String(); got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+}
+
+func TestProgress_RichStopClearsCounter(t *testing.T) {
+	disableColor(t)
+	var buf bytes.Buffer
+	p := NewProgress(&buf, false)
+	p.Update(1, 2)
+	p.Stop()
+
+	if !strings.HasSuffix(buf.String(), clearLine) {
+		t.Errorf("expected Stop to clear the counter, got %q", buf.String())
+	}
+}
+
+func TestProgress_Plain(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, true)
+	for i := 1; i <= 20; i++ {
+		p.Update(i, 20)
+		if i == 5 {
+			p.Printf("  [fail] repo-e: boom\n")
+		}
+	}
+	p.Stop()
+
+	out := buf.String()
+	if strings.Contains(out, "\033") || strings.Contains(out, "\r") {
+		t.Errorf("plain output must not contain control sequences: %q", out)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	// 9 counter lines (every 2 items, excluding completion) + 1 result line.
+	if len(lines) != 10 {
+		t.Errorf("expected 10 lines, got %d: %q", len(lines), out)
+	}
+	if !strings.Contains(out, "  [fail] repo-e: boom\n") {
+		t.Errorf("missing result line in %q", out)
+	}
+}
+
+func TestProgress_ConcurrentWriters(t *testing.T) {
+	var buf bytes.Buffer
+	p := NewProgress(&buf, true)
+	var wg gosync.WaitGroup
+	for i := range 50 {
+		wg.Go(func() {
+			p.Printf("line %d", i)
+			p.Update(i+1, 50)
+		})
+	}
+	wg.Wait()
+	p.Stop()
+	p.Stop()               // idempotent
+	p.Printf("after stop") // ignored
+
+	n := 0
+	for line := range strings.SplitSeq(buf.String(), "\n") {
+		if strings.HasPrefix(line, "line ") {
+			n++
+		}
+	}
+	if n != 50 {
+		t.Errorf("expected 50 intact result lines, got %d", n)
+	}
+}
+
+func TestProgress_Estimate(t *testing.T) {
+	disableColor(t)
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := start
+	var buf bytes.Buffer
+	p := newProgress(&buf, false, func() time.Time { return clock })
+
+	p.Update(1, 430)
+	clock = start.Add(time.Second)
+	p.Update(2, 430)
+	// Too early for an estimate.
+	clock = start.Add(30 * time.Second)
+	p.Update(121, 430)
+	p.Stop()
+
+	out := buf.String()
+	if strings.Contains(out, "[2/430] 428 remaining, ") {
+		t.Errorf("expected no estimate during warmup, got %q", out)
+	}
+	// 120 items in 30s is 4/s; 309 remaining is about 77s.
+	if !strings.Contains(out, "[121/430] 309 remaining, 4.0/s, ~1m left...") {
+		t.Errorf("expected rate and time remaining, got %q", out)
+	}
+}
+
+func TestProgress_EstimateUsesRecentRate(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var samples []progressSample
+	// Fast for the first minute, then one item every 10s.
+	samples = addSample(samples, progressSample{at: start, completed: 0})
+	samples = addSample(samples, progressSample{at: start.Add(time.Minute), completed: 600})
+	for i := 1; i <= 6; i++ {
+		at := start.Add(time.Minute + time.Duration(i)*10*time.Second)
+		samples = addSample(samples, progressSample{at: at, completed: 600 + i})
+	}
+
+	if got := formatEstimate(samples, 30); got != "0.1/s, ~5m left" {
+		t.Errorf("expected the estimate to follow the recent slowdown, got %q", got)
+	}
+}
+
+func TestFormatRemaining(t *testing.T) {
+	tests := map[time.Duration]string{
+		200 * time.Millisecond:         "1s",
+		45 * time.Second:               "45s",
+		150 * time.Second:              "3m",
+		time.Hour + 5*time.Minute + 10: "1h05m",
+	}
+	for d, want := range tests {
+		if got := formatRemaining(d); got != want {
+			t.Errorf("formatRemaining(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+// Package ui detects terminal capabilities and provides the shared
+// output primitives that commands use to render progress and prompts.
+package ui
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+)
+
+// Mode selects how much terminal functionality katazuke relies on.
+type Mode string
+
+const (
+	// ModeRich uses colors, in-place progress counters, and full-screen prompts.
+	ModeRich Mode = "rich"
+	// ModePlain avoids escape sequences entirely and uses line-oriented prompts.
+	ModePlain Mode = "plain"
+)
+
+// console says how the terminal stdout is attached to handles ANSI escape
+// sequences.
+type console int
+
+const (
+	// consoleANSI interprets escape sequences, and TERM names it.
+	consoleANSI console = iota
+	// consoleVT is a Windows console with virtual terminal processing
+	// enabled. It interprets escape sequences but sets no TERM.
+	consoleVT
+	// consoleLegacy is a Windows console that cannot interpret escape
+	// sequences, so they would be printed as text.
+	consoleLegacy
+)
+
+// Capabilities describes what the attached terminal supports and which
+// UI mode katazuke will use as a result.
+type Capabilities struct {
+	Term    string
+	NoColor bool // NO_COLOR is set, or color is otherwise unavailable
+	IsTTY   bool // stdout is attached to a terminal
+	// StdinTTY reports whether stdin is attached to a terminal.
+	StdinTTY bool
+	Width    int // terminal width in columns, 0 if unknown
+	Mode     Mode
+	// Reason explains why plain mode was selected. Empty in rich mode.
+	Reason string
+}
+
+// Plain reports whether output should avoid ANSI escape sequences.
+// The zero value is treated as rich so callers that never ran Detect
+// (e.g. unit tests) keep the default behavior.
+func (c Capabilities) Plain() bool {
+	return c.Mode == ModePlain
+}
+
+// Interactive reports whether prompts can be shown: both stdout and
+// stdin must be attached to a terminal. When false (CI, pipes, cron),
+// commands must not block waiting for input.
+func (c Capabilities) Interactive() bool {
+	return c.IsTTY && c.StdinTTY
+}
+
+// Detect probes the process environment and stdout to determine terminal
+// capabilities.
+func Detect() Capabilities {
+	fd := int(os.Stdout.Fd()) // #nosec G115 - file descriptors fit in int
+	isTTY := term.IsTerminal(fd)
+	width := 0
+	if isTTY {
+		if w, _, err := term.GetSize(fd); err == nil {
+			width = w
+		}
+	}
+	con := consoleANSI
+	if isTTY {
+		con = enableVirtualTerminal(os.Stdout)
+	}
+	c := detect(os.LookupEnv, isTTY, con, width)
+	c.StdinTTY = term.IsTerminal(int(os.Stdin.Fd())) // #nosec G115 - file descriptors fit in int
+	return c
+}
+
+// detect derives capabilities from injected probes so the degradation
+// rules can be tested without a real terminal.
+func detect(lookupEnv func(string) (string, bool), isTTY bool, con console, width int) Capabilities {
+	termName, _ := lookupEnv("TERM")
+	// See https://no-color.org: only a non-empty NO_COLOR disables color.
+	noColorEnv, _ := lookupEnv("NO_COLOR")
+	noColor := noColorEnv != ""
+
+	c := Capabilities{
+		Term:    termName,
+		NoColor: noColor,
+		IsTTY:   isTTY,
+		Width:   width,
+		Mode:    ModeRich,
+	}
+
+	switch {
+	case !isTTY:
+		c.Mode = ModePlain
+		c.Reason = "stdout is not a terminal"
+	case con == consoleLegacy:
+		c.Mode = ModePlain
+		c.Reason = "the console does not support ANSI escape sequences"
+	case termName == "dumb" || (termName == "" && con != consoleVT):
+		c.Mode = ModePlain
+		c.Reason = "TERM is unset or dumb"
+	}
+
+	if c.Mode == ModePlain {
+		c.NoColor = true
+	}
+	return c
+}
+
+// Apply configures process-wide output libraries to match the detected
+// capabilities.
+func (c Capabilities) Apply() {
+	if c.NoColor {
+		color.NoColor = true
+	}
+}
@@ -0,0 +1,119 @@
+package ui
+
+import "testing"
+
+func envFunc(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		isTTY       bool
+		console     console
+		wantMode    Mode
+		wantNoColor bool
+	}{
+		{
+			name:     "regular terminal",
+			env:      map[string]string{"TERM": "xterm-256color"},
+			isTTY:    true,
+			wantMode: ModeRich,
+		},
+		{
+			name:        "piped output",
+			env:         map[string]string{"TERM": "xterm-256color"},
+			isTTY:       false,
+			wantMode:    ModePlain,
+			wantNoColor: true,
+		},
+		{
+			name:        "dumb terminal",
+			env:         map[string]string{"TERM": "dumb"},
+			isTTY:       true,
+			wantMode:    ModePlain,
+			wantNoColor: true,
+		},
+		{
+			name:        "unset TERM",
+			env:         map[string]string{},
+			isTTY:       true,
+			wantMode:    ModePlain,
+			wantNoColor: true,
+		},
+		{
+			name:        "NO_COLOR keeps rich mode",
+			env:         map[string]string{"TERM": "screen", "NO_COLOR": "1"},
+			isTTY:       true,
+			wantMode:    ModeRich,
+			wantNoColor: true,
+		},
+		{
+			name:     "empty NO_COLOR is ignored",
+			env:      map[string]string{"TERM": "screen", "NO_COLOR": ""},
+			isTTY:    true,
+			wantMode: ModeRich,
+		},
+		{
+			name:     "windows console with virtual terminal processing",
+			env:      map[string]string{},
+			isTTY:    true,
+			console:  consoleVT,
+			wantMode: ModeRich,
+		},
+		{
+			name:        "legacy windows console",
+			env:         map[string]string{},
+			isTTY:       true,
+			console:     consoleLegacy,
+			wantMode:    ModePlain,
+			wantNoColor: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := detect(envFunc(tt.env), tt.isTTY, tt.console, 80)
+			if c.Mode != tt.wantMode {
+				t.Errorf("mode: got %q, want %q", c.Mode, tt.wantMode)
+			}
+			if c.NoColor != tt.wantNoColor {
+				t.Errorf("no color: got %v, want %v", c.NoColor, tt.wantNoColor)
+			}
+			if c.Plain() && c.Reason == "" {
+				t.Error("expected a reason for plain mode")
+			}
+		})
+	}
+}
+
+func TestZeroCapabilitiesAreRich(t *testing.T) {
+	var c Capabilities
+	if c.Plain() {
+		t.Error("zero-value capabilities should not be plain")
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	tests := []struct {
+		name     string
+		caps     Capabilities
+		expected bool
+	}{
+		{"both terminals", Capabilities{IsTTY: true, StdinTTY: true}, true},
+		{"piped stdout", Capabilities{IsTTY: false, StdinTTY: true}, false},
+		{"piped stdin", Capabilities{IsTTY: true, StdinTTY: false}, false},
+		{"zero value", Capabilities{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.caps.Interactive(); got != tt.expected {
+				t.Errorf("Interactive() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+)
+
+// Color settings for when output is colored (config ui.color).
+const (
+	// ColorAuto colors output unless NO_COLOR is set or stdout is not a
+	// terminal.
+	ColorAuto = "auto"
+	// ColorAlways colors output even when piped or NO_COLOR is set.
+	ColorAlways = "always"
+	// ColorNever never colors output.
+	ColorNever = "never"
+)
+
+// Theme names the color of each kind of output (config ui.theme). The
+// names are the terminal's 16 ANSI colors, so they follow its palette; see
+// colorNames.
+type Theme struct {
+	Success string `yaml:"success"` // completed actions, e.g. "[deleted]"
+	Warn    string `yaml:"warn"`    // skips and things to look at, e.g. "[skip]"
+	Error   string `yaml:"error"`   // failures, e.g. "[fail]"
+	Muted   string `yaml:"muted"`   // secondary details such as paths and dates
+}
+
+// DefaultTheme is used for any color a config leaves unset.
+var DefaultTheme = Theme{Success: "green", Warn: "yellow", Error: "red", Muted: "hiblack"}
+
+// colorNames lists the accepted color names in ANSI order, so a name's
+// index is its ANSI color number.
+var colorNames = []string{
+	"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white",
+	"hiblack", "hired", "higreen", "hiyellow", "hiblue", "himagenta", "hicyan", "hiwhite",
+}
+
+// ValidateColor returns an error naming the accepted colors when name is
+// not one of them.
+func ValidateColor(name string) error {
+	if !slices.Contains(colorNames, name) {
+		return fmt.Errorf("unknown color %q (use one of %v)", name, colorNames)
+	}
+	return nil
+}
+
+// withDefaults fills the colors t leaves empty from DefaultTheme.
+func (t Theme) withDefaults() Theme {
+	if t.Success == "" {
+		t.Success = DefaultTheme.Success
+	}
+	if t.Warn == "" {
+		t.Warn = DefaultTheme.Warn
+	}
+	if t.Error == "" {
+		t.Error = DefaultTheme.Error
+	}
+	if t.Muted == "" {
+		t.Muted = DefaultTheme.Muted
+	}
+	return t
+}
+
+// theme is set by SetTheme.
+var theme = DefaultTheme
+
+// SetTheme makes Success, Warn, Error, Muted, and FormTheme use t.
+// Colors t leaves empty keep their default. It is called once, before any
+// output.
+func SetTheme(t Theme) {
+	theme = t.withDefaults()
+}
+
+// SetColor applies a ui.color setting on top of what Detect found:
+// "always" and "never" force colors on or off, and "auto" (or "") keeps
+// the detected choice.
+func SetColor(mode string) {
+	switch mode {
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	}
+}
+
+// ansi returns the ANSI color number of name, defaulting to white for a
+// name that ValidateColor would reject.
+func ansi(name string) int {
+	if i := slices.Index(colorNames, name); i >= 0 {
+		return i
+	}
+	return 7
+}
+
+// colorFor returns a fatih/color printer for name.
+func colorFor(name string) *color.Color {
+	n := ansi(name)
+	if n < 8 {
+		return color.New(color.FgBlack + color.Attribute(n))
+	}
+	return color.New(color.FgHiBlack + color.Attribute(n-8))
+}
+
+// Success returns the printer for completed actions.
+func Success() *color.Color { return colorFor(theme.Success) }
+
+// Warn returns the printer for skips and warnings.
+func Warn() *color.Color { return colorFor(theme.Warn) }
+
+// Error returns the printer for failures.
+func Error() *color.Color { return colorFor(theme.Error) }
+
+// Muted returns the printer for secondary details.
+func Muted() *color.Color { return colorFor(theme.Muted) }
+
+// FormTheme returns the huh theme for prompts: the theme's colors, with
+// option and input text left in the terminal's own foreground so they stay
+// readable on light and dark backgrounds alike, or no colors at all when
+// color is off.
+func FormTheme() *huh.Theme {
+	if color.NoColor {
+		return huh.ThemeBase()
+	}
+	c := func(name string) lipgloss.Color { return lipgloss.Color(fmt.Sprint(ansi(name))) }
+	success, warn, errc, muted := c(theme.Success), c(theme.Warn), c(theme.Error), c(theme.Muted)
+
+	t := huh.ThemeBase16()
+	for _, s := range []*huh.FieldStyles{&t.Focused, &t.Blurred} {
+		s.Description = s.Description.Foreground(muted)
+		s.ErrorIndicator = s.ErrorIndicator.Foreground(errc)
+		s.ErrorMessage = s.ErrorMessage.Foreground(errc)
+		s.SelectSelector = s.SelectSelector.Foreground(warn)
+		s.MultiSelectSelector = s.MultiSelectSelector.Foreground(warn)
+		s.SelectedOption = s.SelectedOption.Foreground(success)
+		s.SelectedPrefix = s.SelectedPrefix.Foreground(success)
+		s.Option = s.Option.UnsetForeground()
+		s.UnselectedOption = s.UnselectedOption.UnsetForeground()
+		s.FocusedButton = s.FocusedButton.UnsetForeground().UnsetBackground().Reverse(true)
+		s.BlurredButton = s.BlurredButton.UnsetForeground().UnsetBackground()
+		s.TextInput.Placeholder = s.TextInput.Placeholder.Foreground(muted)
+		s.TextInput.Text = s.TextInput.Text.UnsetForeground()
+	}
+	t.Focused.TextInput.Prompt = t.Focused.TextInput.Prompt.Foreground(warn)
+	t.Group.Description = t.Focused.Description
+	return t
+}
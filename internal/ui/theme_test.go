@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/fatih/color"
+)
+
+func TestThemeColors(t *testing.T) {
+	saved, savedNoColor := theme, color.NoColor
+	t.Cleanup(func() { theme, color.NoColor = saved, savedNoColor })
+	color.NoColor = false
+
+	SetTheme(Theme{Warn: "blue", Muted: "white"})
+	if got, want := Warn().Sprint("x"), color.New(color.FgBlue).Sprint("x"); got != want {
+		t.Errorf("warn: got %q, want %q", got, want)
+	}
+	if got, want := Muted().Sprint("x"), color.New(color.FgWhite).Sprint("x"); got != want {
+		t.Errorf("muted: got %q, want %q", got, want)
+	}
+	if got, want := Success().Sprint("x"), color.New(color.FgGreen).Sprint("x"); got != want {
+		t.Errorf("expected the default success color, got %q, want %q", got, want)
+	}
+
+	SetTheme(Theme{Error: "hired"})
+	if got, want := Error().Sprint("x"), color.New(color.FgHiRed).Sprint("x"); got != want {
+		t.Errorf("error: got %q, want %q", got, want)
+	}
+}
+
+func TestFormTheme(t *testing.T) {
+	saved, savedNoColor := theme, color.NoColor
+	t.Cleanup(func() { theme, color.NoColor = saved, savedNoColor })
+	color.NoColor = false
+
+	SetTheme(Theme{Success: "blue"})
+	ft := FormTheme()
+	if fg := ft.Focused.SelectedOption.GetForeground(); fg != lipgloss.Color("4") {
+		t.Errorf("expected selected options in the success color, got %v", fg)
+	}
+	if fg := ft.Focused.Option.GetForeground(); fg != (lipgloss.NoColor{}) {
+		t.Errorf("expected options in the terminal's own color, got %v", fg)
+	}
+}
+
+func TestValidateColor(t *testing.T) {
+	for _, name := range []string{"black", "magenta", "hiblack", "hiwhite"} {
+		if err := ValidateColor(name); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "orange", "Green", "#ff0000"} {
+		if err := ValidateColor(name); err == nil {
+			t.Errorf("%q: expected an error", name)
+		}
+	}
+}
+
+func TestSetColor(t *testing.T) {
+	saved := color.NoColor
+	t.Cleanup(func() { color.NoColor = saved })
+
+	color.NoColor = true
+	SetColor(ColorAlways)
+	if color.NoColor {
+		t.Error("always should turn color on")
+	}
+	SetColor(ColorAuto)
+	if color.NoColor {
+		t.Error("auto should keep the detected setting")
+	}
+	SetColor(ColorNever)
+	if !color.NoColor {
+		t.Error("never should turn color off")
+	}
+	if fg := FormTheme().Focused.SelectedOption.GetForeground(); fg != (lipgloss.NoColor{}) {
+		t.Errorf("expected prompts without colors when color is off, got %v", fg)
+	}
+}
@@ -0,0 +1,72 @@
+package update
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// signingKey is the minisign public key releases are signed with, in the
+// base64 form minisign prints. Release builds set it with
+// -ldflags "-X github.com/agrahamlincoln/katazuke/internal/update.signingKey=...";
+// other builds have none and cannot verify, so they do not self-update.
+var signingKey string
+
+// SignatureName is the minisign signature a release publishes for its
+// checksums file.
+func SignatureName(version string) string {
+	return ChecksumsName(version) + ".minisig"
+}
+
+// minisign key and signature blobs start with a two-byte algorithm and an
+// eight-byte key ID. Only "Ed", a plain Ed25519 signature of the file
+// (minisign -S -l), is accepted; the prehashed "ED" form needs BLAKE2b.
+const (
+	minisignAlg   = "Ed"
+	minisignIDLen = 8
+)
+
+// verifySignature checks a minisign signature of data against key: the
+// signature of the file itself and the global signature covering its
+// trusted comment.
+func verifySignature(key string, data, sig []byte) error {
+	if key == "" {
+		return errors.New("this build has no release signing key")
+	}
+	pub, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(pub) != len(minisignAlg)+minisignIDLen+ed25519.PublicKeySize || string(pub[:2]) != minisignAlg {
+		return errors.New("invalid release signing key")
+	}
+	keyID, pubKey := pub[2:2+minisignIDLen], ed25519.PublicKey(pub[2+minisignIDLen:])
+
+	lines := strings.Split(strings.ReplaceAll(string(sig), "\r\n", "\n"), "\n")
+	if len(lines) < 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return errors.New("malformed signature")
+	}
+	blob, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(blob) != len(minisignAlg)+minisignIDLen+ed25519.SignatureSize {
+		return errors.New("malformed signature")
+	}
+	if string(blob[:2]) != minisignAlg {
+		return fmt.Errorf("unsupported signature algorithm %q", blob[:2])
+	}
+	if !bytes.Equal(blob[2:2+minisignIDLen], keyID) {
+		return errors.New("signed with a different key")
+	}
+	fileSig := blob[2+minisignIDLen:]
+	if !ed25519.Verify(pubKey, data, fileSig) {
+		return errors.New("signature does not match")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return errors.New("malformed signature")
+	}
+	trusted := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(pubKey, append(bytes.Clone(fileSig), trusted...), globalSig) {
+		return errors.New("trusted comment signature does not match")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package update
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/internal/state"
+)
+
+// CheckInterval is how often the latest release is looked up, and how
+// often a newer one is mentioned.
+const CheckInterval = 24 * time.Hour
+
+// Cache records the last release check, so it runs at most once per
+// CheckInterval.
+type Cache struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Latest    string    `json:"latest"`
+	// NotifiedAt is when the user was last told about Latest.
+	NotifiedAt time.Time `json:"notified_at,omitzero"`
+}
+
+// DefaultCachePath returns ~/.cache/katazuke/update-check.json.
+func DefaultCachePath() string {
+	path, _ := state.CachePath(state.UpdateCheck)
+	return path
+}
+
+// LoadCache reads the cache at path. A missing or unreadable cache is
+// empty, which makes the next check due.
+func LoadCache(path string) Cache {
+	var c Cache
+	// #nosec G304 - fixed file name in the katazuke data directory
+	data, err := os.ReadFile(path)
+	if err == nil {
+		_ = json.Unmarshal(data, &c)
+	}
+	return c
+}
+
+// Save writes the cache to path.
+func (c Cache) Save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// CheckDue reports whether the latest release should be looked up again.
+func (c Cache) CheckDue(now time.Time) bool {
+	return now.Sub(c.CheckedAt) >= CheckInterval
+}
+
+// Notice returns the newer release to mention to a user running
+// current, or "" when there is none or it was mentioned within the last
+// CheckInterval.
+func (c Cache) Notice(current string, now time.Time) string {
+	if !Newer(current, c.Latest) || now.Sub(c.NotifiedAt) < CheckInterval {
+		return ""
+	}
+	return c.Latest
+}
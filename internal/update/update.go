@@ -0,0 +1,277 @@
+// Package update finds newer katazuke releases on GitHub and replaces the
+// running binary with one, after checking it against the release's
+// published checksums and their signature.
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository katazuke is released from.
+const Repo = "agrahamlincoln/katazuke"
+
+// maxDownload bounds the size of a downloaded release asset.
+const maxDownload = 200 << 20
+
+// apiURL is the GitHub API base URL; replaced in tests.
+var apiURL = "https://api.github.com"
+
+// client fetches releases and their assets; replaced in tests.
+var client = &http.Client{Timeout: 60 * time.Second}
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Release is a published katazuke release.
+type Release struct {
+	Tag     string  `json:"tag_name"`
+	HTMLURL string  `json:"html_url"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Version returns the release version without the "v" prefix.
+func (r Release) Version() string {
+	return strings.TrimPrefix(r.Tag, "v")
+}
+
+// Latest returns the most recent published release.
+func Latest() (Release, error) {
+	var r Release
+	body, err := get(fmt.Sprintf("%s/repos/%s/releases/latest", apiURL, Repo), 1<<20)
+	if err != nil {
+		return r, fmt.Errorf("checking latest release: %w", err)
+	}
+	if err := json.Unmarshal(body, &r); err != nil {
+		return r, fmt.Errorf("checking latest release: %w", err)
+	}
+	if r.Tag == "" {
+		return r, errors.New("checking latest release: response has no tag")
+	}
+	return r, nil
+}
+
+// IsRelease reports whether version is a release version (e.g. 1.2.3 or
+// v1.2.3) rather than a development build.
+func IsRelease(version string) bool {
+	_, ok := parseVersion(version)
+	return ok
+}
+
+// Newer reports whether latest is a later release than current. It is
+// false when either is not a release version, so development builds are
+// never told to update.
+func Newer(current, latest string) bool {
+	cur, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+	lat, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+	for i := range cur {
+		if lat[i] != cur[i] {
+			return lat[i] > cur[i]
+		}
+	}
+	return false
+}
+
+// parseVersion splits MAJOR.MINOR.PATCH, with an optional "v" prefix.
+func parseVersion(v string) ([3]int, bool) {
+	var out [3]int
+	parts := strings.Split(strings.TrimPrefix(v, "v"), ".")
+	if len(parts) != 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	return out, true
+}
+
+// AssetName is the tarball a release publishes for a platform.
+func AssetName(version, goos, goarch string) string {
+	return fmt.Sprintf("katazuke-%s-%s-%s.tar.gz", version, goos, goarch)
+}
+
+// ChecksumsName is the file of SHA-256 sums a release publishes for its
+// tarballs, in sha256sum format.
+func ChecksumsName(version string) string {
+	return fmt.Sprintf("katazuke-%s-checksums.txt", version)
+}
+
+// Download fetches the release's binary for a platform, verifies the
+// release's checksums against their signature and the tarball against
+// the checksums, and returns the binary. A release without a signed
+// checksums file is refused rather than installed unverified.
+func Download(r Release, goos, goarch string) ([]byte, error) {
+	name := AssetName(r.Version(), goos, goarch)
+	tarball, ok := r.asset(name)
+	if !ok {
+		return nil, fmt.Errorf("release %s has no build for %s/%s", r.Tag, goos, goarch)
+	}
+	sums, ok := r.asset(ChecksumsName(r.Version()))
+	if !ok {
+		return nil, fmt.Errorf("release %s publishes no checksums; not installing an unverified binary", r.Tag)
+	}
+	sig, ok := r.asset(SignatureName(r.Version()))
+	if !ok {
+		return nil, fmt.Errorf("release %s publishes no signature; not installing an unverified binary", r.Tag)
+	}
+
+	sumData, err := get(sums.URL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", sums.Name, err)
+	}
+	sigData, err := get(sig.URL, 1<<20)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", sig.Name, err)
+	}
+	if err := verifySignature(signingKey, sumData, sigData); err != nil {
+		return nil, fmt.Errorf("verifying %s: %w; not installing an unverified binary", sums.Name, err)
+	}
+	want, err := checksumFor(sumData, name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := get(tarball.URL, maxDownload)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", name, err)
+	}
+	got := sha256.Sum256(data)
+	if hex.EncodeToString(got[:]) != want {
+		return nil, fmt.Errorf("checksum mismatch for %s; not installing it", name)
+	}
+	return extractBinary(data, fmt.Sprintf("katazuke-%s-%s", goos, goarch))
+}
+
+func (r Release) asset(name string) (Asset, bool) {
+	for _, a := range r.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// checksumFor returns the SHA-256 listed for name in a sha256sum file.
+func checksumFor(sums []byte, name string) (string, error) {
+	sc := bufio.NewScanner(bytes.NewReader(sums))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("no checksum listed for %s", name)
+}
+
+// extractBinary returns the file called name from a gzipped tarball.
+func extractBinary(tarball []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, fmt.Errorf("reading release tarball: %w", err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("release tarball has no %s", name)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading release tarball: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && filepath.Base(hdr.Name) == name {
+			return io.ReadAll(io.LimitReader(tr, maxDownload))
+		}
+	}
+}
+
+// Replace swaps the binary at exe for a new one. The new binary is
+// written next to it and renamed over it, so an interrupted update
+// leaves the old binary in place.
+func Replace(exe string, binary []byte) error {
+	dir := filepath.Dir(exe)
+	f, err := os.CreateTemp(dir, ".katazuke-update-*")
+	if err != nil {
+		return fmt.Errorf("replacing %s: %w", exe, err)
+	}
+	tmp := f.Name()
+	_, werr := f.Write(binary)
+	if cerr := f.Close(); werr == nil {
+		werr = cerr
+	}
+	if werr == nil {
+		// #nosec G302 - the binary must stay executable by everyone who could run the old one
+		werr = os.Chmod(tmp, 0o755)
+	}
+	if werr == nil {
+		werr = os.Rename(tmp, exe)
+	}
+	if werr != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replacing %s: %w", exe, werr)
+	}
+	return nil
+}
+
+// Installers ManagedBy reports.
+const (
+	Homebrew = "Homebrew"
+	// SystemPackage is a binary in a system directory, which only a
+	// package manager should change, whichever one it is.
+	SystemPackage = "system package"
+)
+
+// ManagedBy names what installed the binary at exe, or returns "" for a
+// binary installed by hand. Such binaries are upgraded through their
+// package manager, not replaced in place.
+func ManagedBy(exe string) string {
+	switch {
+	case strings.Contains(exe, "/Cellar/"), strings.Contains(exe, "/homebrew/"), strings.Contains(exe, "/linuxbrew/"):
+		return Homebrew
+	case strings.HasPrefix(exe, "/usr/bin/"), strings.HasPrefix(exe, "/usr/sbin/"), strings.HasPrefix(exe, "/bin/"):
+		return SystemPackage
+	}
+	return ""
+}
+
+func get(url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
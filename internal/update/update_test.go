@@ -0,0 +1,270 @@
+package update
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		current, latest string
+		want            bool
+	}{
+		{"1.2.3", "1.2.4", true},
+		{"1.2.3", "v1.10.0", true},
+		{"v2.0.0", "1.9.9", false},
+		{"1.2.3", "1.2.3", false},
+		{"dev", "1.2.3", false},
+		{"1.2.3", "", false},
+		{"1.2.3-dirty", "1.2.4", false},
+	}
+	for _, tt := range tests {
+		if got := Newer(tt.current, tt.latest); got != tt.want {
+			t.Errorf("Newer(%q, %q) = %v, want %v", tt.current, tt.latest, got, tt.want)
+		}
+	}
+}
+
+func TestManagedBy(t *testing.T) {
+	tests := map[string]string{
+		"/opt/homebrew/Cellar/katazuke/1.2.3/bin/katazuke": "Homebrew",
+		"/home/linuxbrew/.linuxbrew/bin/katazuke":          "Homebrew",
+		"/usr/bin/katazuke":                                SystemPackage,
+		"/usr/local/bin/katazuke":                          "",
+		"/home/me/go/bin/katazuke":                         "",
+	}
+	for exe, want := range tests {
+		if got := ManagedBy(exe); got != want {
+			t.Errorf("ManagedBy(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
+
+// tarball returns a gzipped tarball holding one file.
+func tarball(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testKeyID is the minisign key ID of the keys made by useSigningKey.
+var testKeyID = []byte("katazuke")
+
+// useSigningKey makes a minisign key pair, sets its public key as the
+// release signing key for the test, and returns the private key.
+func useSigningKey(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := signingKey
+	signingKey = base64.StdEncoding.EncodeToString(append(append([]byte(minisignAlg), testKeyID...), pub...))
+	t.Cleanup(func() { signingKey = prev })
+	return priv
+}
+
+// minisign returns a legacy minisign signature of data, as minisign -S -l
+// writes it.
+func minisign(priv ed25519.PrivateKey, data []byte) []byte {
+	fileSig := ed25519.Sign(priv, data)
+	trusted := "timestamp:1772366400\tfile:checksums.txt"
+	globalSig := ed25519.Sign(priv, append(bytes.Clone(fileSig), trusted...))
+	blob := append(append([]byte(minisignAlg), testKeyID...), fileSig...)
+	return fmt.Appendf(nil, "untrusted comment: signature from minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(blob), trusted, base64.StdEncoding.EncodeToString(globalSig))
+}
+
+func TestVerifySignature(t *testing.T) {
+	priv := useSigningKey(t)
+	key := signingKey
+	data := []byte("checksums")
+	sig := minisign(priv, data)
+	if err := verifySignature(key, data, sig); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	if err := verifySignature(key, []byte("tampered"), sig); err == nil {
+		t.Error("expected a signature of other data to be rejected")
+	}
+	if err := verifySignature("", data, sig); err == nil {
+		t.Error("expected a build without a signing key to refuse")
+	}
+	if err := verifySignature(key, data, minisign(useSigningKey(t), data)); err == nil {
+		t.Error("expected a signature by another key to be rejected")
+	}
+	forged := bytes.Replace(sig, []byte("file:checksums.txt"), []byte("file:other.txt"), 1)
+	if err := verifySignature(key, data, forged); err == nil {
+		t.Error("expected an altered trusted comment to be rejected")
+	}
+}
+
+// serveRelease serves a release of version 1.3.0 for linux/amd64 whose
+// checksums file lists sum for the tarball ("" for the real one), or no
+// checksums file when withSums is false. The checksums are signed with a
+// key set as the signing key for the test.
+func serveRelease(t *testing.T, sum string, withSums bool) {
+	t.Helper()
+	archive := tarball(t, "katazuke-linux-amd64", []byte("new binary"))
+	if sum == "" {
+		s := sha256.Sum256(archive)
+		sum = hex.EncodeToString(s[:])
+	}
+	name := AssetName("1.3.0", "linux", "amd64")
+	sums := []byte(fmt.Sprintf("%s  %s\n", sum, name))
+	sig := minisign(useSigningKey(t), sums)
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/" + Repo + "/releases/latest":
+			assets := fmt.Sprintf(`{"name": %q, "browser_download_url": "%s/dl/tarball"}`, name, srv.URL)
+			if withSums {
+				assets += fmt.Sprintf(`, {"name": %q, "browser_download_url": "%s/dl/sums"}`, ChecksumsName("1.3.0"), srv.URL)
+				assets += fmt.Sprintf(`, {"name": %q, "browser_download_url": "%s/dl/sig"}`, SignatureName("1.3.0"), srv.URL)
+			}
+			fmt.Fprintf(w, `{"tag_name": "v1.3.0", "html_url": "https://example.com/v1.3.0", "assets": [%s]}`, assets)
+		case "/dl/tarball":
+			_, _ = w.Write(archive)
+		case "/dl/sums":
+			_, _ = w.Write(sums)
+		case "/dl/sig":
+			_, _ = w.Write(sig)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	prevURL, prevClient := apiURL, client
+	apiURL, client = srv.URL, srv.Client()
+	t.Cleanup(func() { apiURL, client = prevURL, prevClient })
+}
+
+func TestDownload(t *testing.T) {
+	serveRelease(t, "", true)
+	rel, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if rel.Version() != "1.3.0" {
+		t.Errorf("expected version 1.3.0, got %q", rel.Version())
+	}
+
+	binary, err := Download(rel, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if string(binary) != "new binary" {
+		t.Errorf("unexpected binary %q", binary)
+	}
+
+	if _, err := Download(rel, "windows", "amd64"); err == nil {
+		t.Error("expected an error for a platform without a build")
+	}
+}
+
+func TestDownloadRejectsUnverified(t *testing.T) {
+	serveRelease(t, strings.Repeat("0", 64), true)
+	rel, err := Latest()
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if _, err := Download(rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("expected a checksum mismatch, got %v", err)
+	}
+
+	serveRelease(t, "", false)
+	if rel, err = Latest(); err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if _, err := Download(rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "no checksums") {
+		t.Errorf("expected a refusal without checksums, got %v", err)
+	}
+
+	// Checksums signed by a key other than the pinned one are refused.
+	serveRelease(t, "", true)
+	if rel, err = Latest(); err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	useSigningKey(t)
+	if _, err := Download(rel, "linux", "amd64"); err == nil || !strings.Contains(err.Error(), "verifying") {
+		t.Errorf("expected a refusal of checksums signed by another key, got %v", err)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	exe := filepath.Join(t.TempDir(), "katazuke")
+	if err := os.WriteFile(exe, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Replace(exe, []byte("new")); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil || string(data) != "new" {
+		t.Errorf("expected the new binary, got %q (%v)", data, err)
+	}
+	if info, err := os.Stat(exe); err != nil || info.Mode().Perm()&0o100 == 0 {
+		t.Errorf("expected the new binary to be executable, got %v (%v)", info.Mode(), err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	c := LoadCache(path)
+	if !c.CheckDue(now) {
+		t.Error("expected a check to be due without a cache")
+	}
+
+	c = Cache{CheckedAt: now.Add(-time.Hour), Latest: "1.3.0"}
+	if err := c.Save(path); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	c = LoadCache(path)
+	if c.CheckDue(now) {
+		t.Error("expected no check within a day of the last one")
+	}
+	if got := c.Notice("1.2.0", now); got != "1.3.0" {
+		t.Errorf("expected a notice for 1.3.0, got %q", got)
+	}
+	if got := c.Notice("1.3.0", now); got != "" {
+		t.Errorf("expected no notice when up to date, got %q", got)
+	}
+
+	c.NotifiedAt = now.Add(-time.Hour)
+	if got := c.Notice("1.2.0", now); got != "" {
+		t.Errorf("expected no second notice the same day, got %q", got)
+	}
+	if got := c.Notice("1.2.0", now.Add(CheckInterval)); got != "1.3.0" {
+		t.Errorf("expected the notice again a day later, got %q", got)
+	}
+}
@@ -0,0 +1,226 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BranchInfo is the metadata of one local branch, as read by BranchInfos.
+type BranchInfo struct {
+	Name string
+	// CommitDate is the author date of the tip, the same date CommitDate
+	// returns.
+	CommitDate time.Time
+	// Subject is the subject line of the tip commit.
+	Subject string
+	// Upstream is the short name of the branch's upstream, e.g.
+	// "origin/main", or "" when none is configured or it no longer exists.
+	Upstream string
+	// UpstreamAhead and UpstreamBehind count the commits the branch has
+	// that its upstream lacks, and the other way round; both are 0 when
+	// there is no upstream.
+	UpstreamAhead  int
+	UpstreamBehind int
+	// UpstreamGone is true when the branch has an upstream configured but
+	// its remote-tracking branch no longer exists, as "[gone]" in git
+	// branch -vv. Upstream is "" then.
+	UpstreamGone bool
+	// Remote is the remote with a remote-tracking branch of the same name,
+	// e.g. "origin" when origin/<Name> exists, or "" when none has one.
+	// The branch's upstream remote is checked first, then origin, then
+	// the repository's PrimaryRemote.
+	Remote string
+}
+
+// branchInfoFormat is the for-each-ref format read by BranchInfos. Fields
+// are NUL-separated; the subject goes last since it is free text.
+const branchInfoFormat = "%(refname)%00%(authordate:iso-strict)%00%(upstream)%00%(upstream:remotename)%00%(upstream:track,nobracket)%00%(subject)"
+
+// BranchInfos returns the metadata of every local branch in a single git
+// for-each-ref call, sorted by name as git branch sorts them. Use it
+// instead of per-branch CommitDate, CommitSubject, HasRemoteBranch, and
+// HasUpstream calls when looking at many branches.
+func BranchInfos(repoPath string) ([]BranchInfo, error) {
+	out, err := run(repoPath, "for-each-ref", "--format="+branchInfoFormat, "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]bool)
+	var infos []BranchInfo
+	var upstreams, upstreamRemotes []string
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.SplitN(line, "\x00", 6)
+		if len(fields) != 6 {
+			return nil, fmt.Errorf("parsing for-each-ref output %q", line)
+		}
+		refs[fields[0]] = true
+		name, local := strings.CutPrefix(fields[0], "refs/heads/")
+		if !local {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("parsing date of branch %s: %w", name, err)
+		}
+		ahead, behind := parseTrack(fields[4])
+		infos = append(infos, BranchInfo{
+			Name:           name,
+			CommitDate:     date,
+			Subject:        fields[5],
+			UpstreamAhead:  ahead,
+			UpstreamBehind: behind,
+			UpstreamGone:   fields[4] == "gone",
+		})
+		upstreams = append(upstreams, fields[2])
+		upstreamRemotes = append(upstreamRemotes, fields[3])
+	}
+
+	// Upstreams and remote branches are only known once all refs are read.
+	primary := PrimaryRemote(repoPath)
+	for i := range infos {
+		if up := upstreams[i]; refs[up] {
+			infos[i].Upstream = shortRef(up)
+		}
+		infos[i].Remote = branchRemote(refs, infos[i].Name, upstreamRemotes[i], primary)
+	}
+	return infos, nil
+}
+
+// parseTrack parses %(upstream:track,nobracket), e.g. "ahead 2, behind
+// 1", into its counts. "gone" and "" parse as 0 and 0.
+func parseTrack(track string) (ahead, behind int) {
+	for _, part := range strings.Split(track, ", ") {
+		if n, ok := strings.CutPrefix(part, "ahead "); ok {
+			ahead, _ = strconv.Atoi(n)
+		} else if n, ok := strings.CutPrefix(part, "behind "); ok {
+			behind, _ = strconv.Atoi(n)
+		}
+	}
+	return ahead, behind
+}
+
+// shortRef strips the refs/heads/ or refs/remotes/ prefix from a ref.
+func shortRef(ref string) string {
+	if short, found := strings.CutPrefix(ref, "refs/heads/"); found {
+		return short
+	}
+	return strings.TrimPrefix(ref, "refs/remotes/")
+}
+
+// AheadBehind returns how many commits each branch is ahead of and behind
+// base, keyed by branch name. With git 2.41 or newer this is a single
+// for-each-ref call; older versions fall back to one rev-list per branch.
+// Branches whose counts could not be read are omitted.
+func AheadBehind(repoPath, base string, branches []string) (map[string][2]int, error) {
+	counts := make(map[string][2]int, len(branches))
+	if len(branches) == 0 {
+		return counts, nil
+	}
+
+	if !supportsAheadBehind() {
+		var firstErr error
+		for _, b := range branches {
+			ahead, behind, err := CommitsAheadBehind(repoPath, b, base)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			counts[b] = [2]int{ahead, behind}
+		}
+		return counts, firstErr
+	}
+
+	args := []string{"for-each-ref", "--format=%(refname:strip=2) %(ahead-behind:" + base + ")"}
+	for _, b := range branches {
+		args = append(args, "refs/heads/"+b)
+	}
+	out, err := run(repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("parsing ahead-behind output %q", line)
+		}
+		ahead, err1 := strconv.Atoi(fields[1])
+		behind, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			return nil, fmt.Errorf("parsing ahead-behind output %q", line)
+		}
+		counts[fields[0]] = [2]int{ahead, behind}
+	}
+	return counts, nil
+}
+
+var (
+	aheadBehindOnce      sync.Once
+	aheadBehindSupported bool
+)
+
+var gitVersionRe = regexp.MustCompile(`git version (\d+)\.(\d+)`)
+
+// supportsAheadBehind reports whether the installed git understands the
+// for-each-ref %(ahead-behind) atom, added in git 2.41.
+func supportsAheadBehind() bool {
+	aheadBehindOnce.Do(func() {
+		out, err := run("", "version")
+		if err != nil {
+			return
+		}
+		m := gitVersionRe.FindStringSubmatch(out)
+		if m == nil {
+			return
+		}
+		major, _ := strconv.Atoi(m[1])
+		minor, _ := strconv.Atoi(m[2])
+		aheadBehindSupported = major > 2 || (major == 2 && minor >= 41)
+	})
+	return aheadBehindSupported
+}
+
+// checkoutRe matches the reflog subject git writes when HEAD moves between
+// branches, by checkout and switch alike.
+var checkoutRe = regexp.MustCompile(`^checkout: moving from (\S+) to (\S+)$`)
+
+// LastCheckouts returns when each branch was last checked out, keyed by
+// branch name, read from the HEAD reflog. A branch counts as checked out
+// until HEAD moves away from it, so both ends of a move are recorded.
+// Branches not in the reflog, including those whose entries have expired,
+// are omitted.
+func LastCheckouts(repoPath string) (map[string]time.Time, error) {
+	out, err := run(repoPath, "reflog", "show", "--date=unix", "--format=%gd%x00%gs", "HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+	last := make(map[string]time.Time)
+	for _, line := range splitNonEmpty(out) {
+		selector, subject, ok := strings.Cut(line, "\x00")
+		if !ok {
+			return nil, fmt.Errorf("parsing reflog output %q", line)
+		}
+		m := checkoutRe.FindStringSubmatch(subject)
+		if m == nil {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(selector, "HEAD@{"), "}")
+		secs, err := strconv.ParseInt(stamp, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parsing reflog date %q: %w", selector, err)
+		}
+		at := time.Unix(secs, 0)
+		for _, branch := range m[1:] {
+			if at.After(last[branch]) {
+				last[branch] = at
+			}
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,124 @@
+package git_test
+
+import (
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestBranchInfos(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "branch-infos")
+	repo.CreateBranch("pushed")
+	repo.WriteFile("p.txt", "p")
+	repo.AddFile("p.txt")
+	repo.Commit("pushed work")
+	repo.Git("push", "-u", "origin", "pushed")
+	repo.CreateBranch("local")
+	repo.Checkout("main")
+
+	infos, err := git.BranchInfos(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	byName := make(map[string]git.BranchInfo)
+	var names []string
+	for _, info := range infos {
+		byName[info.Name] = info
+		names = append(names, info.Name)
+	}
+	if len(names) != 3 || names[0] != "local" || names[1] != "main" || names[2] != "pushed" {
+		t.Fatalf("expected sorted branches [local main pushed], got %v", names)
+	}
+
+	pushed := byName["pushed"]
+	date, err := git.CommitDate(repo.Path, "pushed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pushed.CommitDate.Equal(date) || pushed.Subject != "pushed work" {
+		t.Errorf("unexpected tip metadata: %+v", pushed)
+	}
+	if pushed.Upstream != "origin/pushed" || pushed.Remote != "origin" {
+		t.Errorf("expected pushed branch tracking origin, got %+v", pushed)
+	}
+	if local := byName["local"]; local.Upstream != "" || local.Remote != "" {
+		t.Errorf("expected local branch without upstream, got %+v", local)
+	}
+	if pushed.UpstreamAhead != 0 || pushed.UpstreamBehind != 0 {
+		t.Errorf("expected pushed branch level with its upstream, got %+v", pushed)
+	}
+
+	// Rewriting the pushed commit leaves the branch and its upstream with
+	// one commit each that the other lacks.
+	repo.Checkout("pushed")
+	repo.Git("commit", "--amend", "-m", "pushed work, reworded")
+	repo.Checkout("main")
+	infos, err = git.BranchInfos(repo.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.Name == "pushed" && (info.UpstreamAhead != 1 || info.UpstreamBehind != 1) {
+			t.Errorf("expected rewritten branch 1 ahead and 1 behind its upstream, got %+v", info)
+		}
+	}
+
+	// An upstream whose remote branch was deleted no longer counts.
+	repo.Git("update-ref", "-d", "refs/remotes/origin/pushed")
+	infos, err = git.BranchInfos(repo.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if info.Name == "pushed" && (info.Upstream != "" || info.Remote != "" || !info.UpstreamGone) {
+			t.Errorf("expected gone upstream to be dropped and flagged, got %+v", info)
+		}
+		if info.Name != "pushed" && info.UpstreamGone {
+			t.Errorf("expected only pushed to have a gone upstream, got %+v", info)
+		}
+	}
+}
+
+func TestAheadBehind(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "ahead-behind-batch")
+	repo.CreateBranch("feature/ahead")
+	repo.WriteFile("f1.txt", "first")
+	repo.AddFile("f1.txt")
+	repo.Commit("feature commit")
+	repo.Checkout("main")
+	repo.CreateBranch("same")
+	repo.Checkout("main")
+	repo.WriteFile("main.txt", "main work")
+	repo.AddFile("main.txt")
+	repo.Commit("main commit")
+
+	counts, err := git.AheadBehind(repo.Path, "main", []string{"feature/ahead", "same"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := counts["feature/ahead"]; got != [2]int{1, 1} {
+		t.Errorf("feature/ahead: expected 1 ahead 1 behind, got %v", got)
+	}
+	if got := counts["same"]; got != [2]int{0, 1} {
+		t.Errorf("same: expected 0 ahead 1 behind, got %v", got)
+	}
+}
+
+func TestLastCheckouts(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "last-checkouts")
+	repo.CreateBranch("visited")
+	repo.Checkout("main")
+	repo.Git("branch", "untouched")
+
+	last, err := git.LastCheckouts(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if last["visited"].IsZero() || last["main"].IsZero() {
+		t.Errorf("expected checkout times for visited and main, got %v", last)
+	}
+	if _, ok := last["untouched"]; ok {
+		t.Errorf("expected no checkout time for a branch never checked out, got %v", last["untouched"])
+	}
+}
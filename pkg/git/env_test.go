@@ -0,0 +1,65 @@
+package git_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestFetchNeverPromptsForCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	// An askpass program that would answer the prompt, leaving a marker
+	// behind when git runs it.
+	marker := filepath.Join(t.TempDir(), "asked")
+	askpass := filepath.Join(t.TempDir(), "askpass.sh")
+	if err := os.WriteFile(askpass, []byte("#!/bin/sh\ntouch "+marker+"\necho secret\n"), 0o700); err != nil { // #nosec G306 - test script must be executable
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_ASKPASS", askpass)
+	t.Setenv("SSH_ASKPASS", askpass)
+	t.Setenv("GIT_TERMINAL_PROMPT", "1")
+	t.Setenv("LANG", "de_DE.UTF-8")
+
+	repo := helpers.NewTestRepo(t, "private")
+	repo.AddRemote("origin", srv.URL+"/private.git")
+	err := git.Fetch(repo.Path, "origin")
+	if !errors.Is(err, git.ErrAuthFailed) {
+		t.Errorf("expected ErrAuthFailed, got %v", err)
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("expected git not to run GIT_ASKPASS")
+	}
+}
+
+func TestProbe(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "probe")
+	if err := git.Probe(repo.Path, "origin", git.ProbeTimeout); err != nil {
+		t.Errorf("expected the clone's origin to answer, got %v", err)
+	}
+
+	repo.AddRemote("gone", filepath.Join(t.TempDir(), "missing.git"))
+	if err := git.Probe(repo.Path, "gone", git.ProbeTimeout); !errors.Is(err, git.ErrRepoNotFound) || errors.Is(err, git.ErrUnreachable) {
+		t.Errorf("expected only ErrRepoNotFound for a missing remote, got %v", err)
+	}
+
+	hang := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { <-hang }))
+	defer srv.Close()
+	defer close(hang)
+	repo.AddRemote("slow", srv.URL+"/slow.git")
+	if err := git.Probe(repo.Path, "slow", 200*time.Millisecond); !errors.Is(err, git.ErrUnreachable) {
+		t.Errorf("expected ErrUnreachable for a remote that does not answer, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Failures that callers branch on. A failed command's *Error matches at
+// most one of them with errors.Is.
+var (
+	// ErrRemoteRefNotFound is returned when a push deletes a branch or tag
+	// the remote no longer has.
+	ErrRemoteRefNotFound = errors.New("remote ref does not exist")
+	// ErrNotFastForward is returned when a pull or push would need a
+	// merge or rebase that was not allowed, e.g. a pull --ff-only from a
+	// diverged branch or a push the remote rejected.
+	ErrNotFastForward = errors.New("not a fast-forward")
+	// ErrDirtyWorkingTree is returned when local changes block a
+	// checkout, pull, or rebase.
+	ErrDirtyWorkingTree = errors.New("local changes would be overwritten")
+	// ErrAuthFailed is returned when the remote rejected, or could not
+	// ask for, credentials.
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrUnreachable is returned when the remote's host cannot be
+	// resolved or connected to, or does not answer in time.
+	ErrUnreachable = errors.New("remote unreachable")
+	// ErrRepoNotFound is returned when the remote answered but has no
+	// repository at the configured URL, e.g. it was deleted or renamed,
+	// or the URL is a path that does not exist. Retrying does not help.
+	ErrRepoNotFound = errors.New("remote repository not found")
+	// ErrDubiousOwnership is returned when git refuses to work in a
+	// repository owned by another user that is not listed in the
+	// safe.directory setting.
+	ErrDubiousOwnership = errors.New("dubious ownership")
+)
+
+// Error is a git command that exited with an error.
+type Error struct {
+	Args   []string
+	Stderr string
+	// Kind is one of the sentinel errors above, or nil when the failure
+	// is not one callers branch on.
+	Kind error
+	// Err is the error from running the command, usually an
+	// *exec.ExitError.
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("git %s: %v", strings.Join(e.Args, " "), e.Err)
+	}
+	return fmt.Sprintf("git %s: %v\n%s", strings.Join(e.Args, " "), e.Err, e.Stderr)
+}
+
+// Unwrap makes errors.Is match Kind and errors.As reach Err.
+func (e *Error) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// commandError wraps the error of running git with args.
+func commandError(args []string, err error) error {
+	e := &Error{Args: args, Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		e.Stderr = string(exitErr.Stderr)
+		e.Kind = classify(e.Stderr)
+	}
+	return e
+}
+
+// stderrKinds maps messages git prints to the failure they mean. Commands
+// run with LC_ALL=C (see gitCommand), so the messages are not translated.
+var stderrKinds = []struct {
+	kind     error
+	messages []string
+}{
+	{ErrRemoteRefNotFound, []string{
+		"remote ref does not exist",
+	}},
+	{ErrDirtyWorkingTree, []string{
+		"would be overwritten by",
+		"Please commit your changes or stash them",
+		"You have unstaged changes",
+		"Your index contains uncommitted changes",
+	}},
+	{ErrNotFastForward, []string{
+		"Not possible to fast-forward",
+		"Diverging branches can't be fast-forwarded",
+		"non-fast-forward",
+		"(fetch first)",
+	}},
+	{ErrAuthFailed, []string{
+		"Authentication failed",
+		"Permission denied (publickey",
+		"could not read Username",
+		"could not read Password",
+		"terminal prompts disabled",
+		"HTTP Basic: Access denied",
+		"The requested URL returned error: 403",
+	}},
+	{ErrRepoNotFound, []string{
+		"does not appear to be a git repository",
+		"Repository not found",
+	}},
+	{ErrUnreachable, []string{
+		"Could not resolve host",
+		"Could not resolve hostname",
+		"Connection refused",
+		"Connection timed out",
+		"Network is unreachable",
+		"No route to host",
+		"Failed to connect to",
+		"Connection reset by peer",
+		"Operation timed out",
+		"The remote end hung up unexpectedly",
+		"early EOF",
+	}},
+	{ErrDubiousOwnership, []string{
+		"detected dubious ownership in repository",
+	}},
+}
+
+// classify returns the sentinel error matching a command's stderr, or nil.
+func classify(stderr string) error {
+	for _, k := range stderrKinds {
+		for _, msg := range k.messages {
+			if strings.Contains(stderr, msg) {
+				return k.kind
+			}
+		}
+	}
+	return nil
+}
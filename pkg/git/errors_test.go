@@ -0,0 +1,90 @@
+package git_test
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+func TestErrRemoteRefNotFound(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "gone")
+	err := git.DeleteRemoteBranch(repo.Path, "origin", "never-pushed")
+	if !errors.Is(err, git.ErrRemoteRefNotFound) {
+		t.Fatalf("expected ErrRemoteRefNotFound, got %v", err)
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Errorf("expected the exit error to stay reachable, got %T", err)
+	}
+	if errors.Is(err, git.ErrAuthFailed) {
+		t.Error("expected only one kind to match")
+	}
+}
+
+func TestErrNotFastForward(t *testing.T) {
+	repo, bare := helpers.NewClonedRepo(t, "diverged")
+	other := helpers.NewTestRepo(t, "other")
+	other.Git("fetch", "--quiet", bare, "main")
+	other.Git("reset", "--quiet", "--hard", "FETCH_HEAD")
+	other.WriteFile("theirs.txt", "theirs")
+	other.AddFile("theirs.txt")
+	other.Commit("theirs")
+	other.Git("push", "--quiet", bare, "HEAD:main")
+
+	repo.WriteFile("ours.txt", "ours")
+	repo.AddFile("ours.txt")
+	repo.Commit("ours")
+	if err := git.Pull(repo.Path, "origin", "main", "ff-only"); !errors.Is(err, git.ErrNotFastForward) {
+		t.Errorf("expected ErrNotFastForward, got %v", err)
+	}
+}
+
+func TestErrDirtyWorkingTree(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "dirty")
+	repo.CreateBranch("feature")
+	repo.WriteFile("shared.txt", "feature")
+	repo.AddFile("shared.txt")
+	repo.Commit("feature change")
+	repo.Checkout("main")
+	repo.WriteFile("shared.txt", "uncommitted")
+
+	if err := git.Checkout(repo.Path, "feature"); !errors.Is(err, git.ErrDirtyWorkingTree) {
+		t.Errorf("expected ErrDirtyWorkingTree, got %v", err)
+	}
+}
+
+func TestErrorUnclassified(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "plain")
+	err := git.Checkout(repo.Path, "no-such-branch")
+	var gitErr *git.Error
+	if !errors.As(err, &gitErr) {
+		t.Fatalf("expected a *git.Error, got %T", err)
+	}
+	if gitErr.Kind != nil {
+		t.Errorf("expected no kind for an unknown branch, got %v", gitErr.Kind)
+	}
+}
+
+func TestErrDubiousOwnership(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "shared")
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	t.Setenv("GIT_TEST_ASSUME_DIFFERENT_OWNER", "1")
+
+	if _, err := git.IsClean(repo.Path); !errors.Is(err, git.ErrDubiousOwnership) {
+		t.Errorf("expected ErrDubiousOwnership, got %v", err)
+	}
+	if err := git.CheckOwnership(repo.Path); !errors.Is(err, git.ErrDubiousOwnership) {
+		t.Fatalf("expected CheckOwnership to report ErrDubiousOwnership, got %v", err)
+	}
+
+	if err := git.AddSafeDirectory(repo.Path); err != nil {
+		t.Fatalf("AddSafeDirectory: %v", err)
+	}
+	if err := git.CheckOwnership(repo.Path); err != nil {
+		t.Errorf("expected no error once the repo is a safe directory, got %v", err)
+	}
+}
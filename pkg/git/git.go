@@ -3,11 +3,14 @@
 package git
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -15,16 +18,39 @@ import (
 
 // run wraps git command execution with consistent error formatting and output trimming.
 func run(repoPath string, args ...string) (string, error) {
-	// #nosec G204 - all git args are controlled by internal callers
-	cmd := exec.Command("git", args...)
+	return runContext(context.Background(), repoPath, args...)
+}
+
+// runContext is run, killing git when ctx is done.
+func runContext(ctx context.Context, repoPath string, args ...string) (string, error) {
+	cmd := gitCommandContext(ctx, args...)
 	cmd.Dir = repoPath
 	out, err := cmd.Output()
 	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return "", fmt.Errorf("git %s: %w\n%s", strings.Join(args, " "), err, exitErr.Stderr)
-		}
-		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+		return "", commandError(args, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runUnisolated is run without isolation, so the user's hooks run.
+func runUnisolated(repoPath string, args ...string) (string, error) {
+	cmd := unisolatedCommandContext(context.Background(), args...)
+	cmd.Dir = repoPath
+	out, err := cmd.Output()
+	if err != nil {
+		return "", commandError(args, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// runInput runs git with input on stdin and returns its trimmed stdout.
+func runInput(repoPath, input string, args ...string) (string, error) {
+	cmd := gitCommand(args...)
+	cmd.Dir = repoPath
+	cmd.Stdin = strings.NewReader(input)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", commandError(args, err)
 	}
 	return strings.TrimSpace(string(out)), nil
 }
@@ -36,6 +62,45 @@ func TopLevel(path string) (string, error) {
 	return run(path, "rev-parse", "--show-toplevel")
 }
 
+// HasGitDir reports whether path is the top of a git working tree: it holds
+// a .git directory containing HEAD, or a .git file pointing at one, as
+// worktrees and submodules do. Unlike IsRepo it only stats the filesystem,
+// so it is cheap enough to call for every directory in a large scan, and it
+// does not match subdirectories of a repository.
+func HasGitDir(path string) bool {
+	dotGit := filepath.Join(path, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		_, err := os.Stat(filepath.Join(dotGit, "HEAD"))
+		return err == nil
+	}
+	data, err := os.ReadFile(filepath.Clean(dotGit))
+	return err == nil && strings.HasPrefix(string(data), "gitdir: ")
+}
+
+// IsBareRepo reports whether path is a bare repository or mirror: a git
+// directory with HEAD, objects, and refs but no working tree. Like
+// HasGitDir it only stats the filesystem.
+func IsBareRepo(path string) bool {
+	if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		return false
+	}
+	head, err := os.Stat(filepath.Join(path, "HEAD"))
+	if err != nil || head.IsDir() {
+		return false
+	}
+	for _, dir := range []string{"objects", "refs"} {
+		info, err := os.Stat(filepath.Join(path, dir))
+		if err != nil || !info.IsDir() {
+			return false
+		}
+	}
+	return true
+}
+
 // IsRepo returns true if the given path is inside a git repository.
 func IsRepo(path string) bool {
 	// #nosec G204 - path is a filesystem path, not user input
@@ -45,21 +110,26 @@ func IsRepo(path string) bool {
 
 // CurrentBranch returns the name of the currently checked-out branch.
 func CurrentBranch(repoPath string) (string, error) {
+	if branch, ok := fastCurrentBranch(repoPath); ok {
+		return branch, nil
+	}
 	return run(repoPath, "branch", "--show-current")
 }
 
 // DefaultBranch returns the default branch name (main or master) by checking
-// what the origin HEAD points to, falling back to a local heuristic.
+// what the PrimaryRemote's HEAD points to, falling back to a local
+// heuristic.
 func DefaultBranch(repoPath string) (string, error) {
-	// Try the remote HEAD symref first.
-	out, err := run(repoPath, "symbolic-ref", "refs/remotes/origin/HEAD", "--short")
-	if err == nil {
-		// Output is like "origin/main" -- strip the remote prefix.
-		parts := strings.SplitN(out, "/", 2)
-		if len(parts) == 2 {
-			return parts[1], nil
+	// Try the primary remote's HEAD symref first.
+	if remote := PrimaryRemote(repoPath); remote != "" {
+		out, err := run(repoPath, "symbolic-ref", "refs/remotes/"+remote+"/HEAD", "--short")
+		if err == nil {
+			// Output is like "origin/main" -- strip the remote prefix.
+			if branch, found := strings.CutPrefix(out, remote+"/"); found {
+				return branch, nil
+			}
+			return out, nil
 		}
-		return out, nil
 	}
 
 	// Fallback: check if "main" or "master" exists locally.
@@ -80,8 +150,53 @@ func DefaultBranch(repoPath string) (string, error) {
 	return "", fmt.Errorf("could not determine default branch for %s", repoPath)
 }
 
+// RemoteHead returns the ref the remote's HEAD symref points to (e.g.
+// refs/remotes/origin/main), or "" when it is not set.
+func RemoteHead(repoPath, remote string) (string, error) {
+	out, err := run(repoPath, "symbolic-ref", "-q", "refs/remotes/"+remote+"/HEAD")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return out, nil
+}
+
+// RemoteDefaultBranch asks the remote which branch its HEAD points to.
+// This needs network access, unlike RemoteHead, which reads the local
+// symref recorded at clone time.
+func RemoteDefaultBranch(repoPath, remote string) (string, error) {
+	out, err := run(repoPath, "ls-remote", "--symref", remote, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	// The first line is "ref: refs/heads/main\tHEAD".
+	for _, line := range splitNonEmpty(out) {
+		target, found := strings.CutPrefix(line, "ref: ")
+		if !found {
+			continue
+		}
+		target, _, _ = strings.Cut(target, "\t")
+		return strings.TrimPrefix(target, "refs/heads/"), nil
+	}
+	return "", fmt.Errorf("remote %s does not report a HEAD branch", remote)
+}
+
+// RefreshRemoteHead updates the remote's HEAD symref to the branch the
+// remote currently reports (git remote set-head --auto), so DefaultBranch
+// follows default branch renames. The new branch must have been fetched.
+func RefreshRemoteHead(repoPath, remote string) error {
+	_, err := run(repoPath, "remote", "set-head", remote, "--auto")
+	return err
+}
+
 // ListBranches returns all local branch names.
 func ListBranches(repoPath string) ([]string, error) {
+	if branches, ok := fastListBranches(repoPath); ok {
+		return branches, nil
+	}
 	out, err := run(repoPath, "branch", "--format=%(refname:short)")
 	if err != nil {
 		return nil, err
@@ -126,17 +241,219 @@ func IsMerged(repoPath, branch, base string) (bool, error) {
 	return false, nil
 }
 
+// maxPatchScanCommits bounds how many commits on the base branch are
+// compared when looking for a squash merge.
+const maxPatchScanCommits = 1000
+
+// IsSquashMerged reports whether the cumulative change of branch since its
+// merge base with base appears as a single commit on base, which is what a
+// squash merge produces. Stable patch IDs are compared, so the squash
+// commit's message, author, and line offsets may differ. Returns false when
+// the branch has no changes of its own or base has moved on in a way that
+// changed the combined diff (e.g. conflicts resolved during the squash).
+func IsSquashMerged(repoPath, branch, base string) (bool, error) {
+	mergeBase, err := MergeBase(repoPath, base, branch)
+	if err != nil {
+		return false, err
+	}
+
+	diff, err := run(repoPath, "diff", "--no-color", "--no-ext-diff", mergeBase, branch)
+	if err != nil {
+		return false, err
+	}
+	if diff == "" {
+		return false, nil
+	}
+	branchIDs, err := patchIDs(repoPath, diff)
+	if err != nil {
+		return false, err
+	}
+	if len(branchIDs) == 0 {
+		return false, nil
+	}
+
+	log, err := run(repoPath, "log", "-p", "--no-merges", "--no-color", "--no-ext-diff",
+		fmt.Sprintf("--max-count=%d", maxPatchScanCommits), mergeBase+".."+base)
+	if err != nil {
+		return false, err
+	}
+	if log == "" {
+		return false, nil
+	}
+	baseIDs, err := patchIDs(repoPath, log)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range baseIDs {
+		if id == branchIDs[0] {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// patchIDs runs git patch-id --stable over patch text and returns the
+// patch ID of each patch in order.
+func patchIDs(repoPath, patch string) ([]string, error) {
+	out, err := runInput(repoPath, patch+"\n", "patch-id", "--stable")
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, line := range splitNonEmpty(out) {
+		if id, _, ok := strings.Cut(line, " "); ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
 // RemoteURL returns the fetch URL of the given remote (usually "origin").
 func RemoteURL(repoPath, remote string) (string, error) {
 	return run(repoPath, "remote", "get-url", remote)
 }
 
+// RemoteURLs returns the fetch URL of the given remote followed by its push
+// URL when that differs, e.g. when a repo fetches from a mirror and pushes
+// to GitHub. Both have url.<base>.insteadOf (and pushInsteadOf) rewrites
+// applied, as git itself would use them.
+func RemoteURLs(repoPath, remote string) ([]string, error) {
+	fetch, err := RemoteURL(repoPath, remote)
+	if err != nil {
+		return nil, err
+	}
+	urls := []string{fetch}
+	if push, err := run(repoPath, "remote", "get-url", "--push", remote); err == nil && push != fetch {
+		urls = append(urls, push)
+	}
+	return urls, nil
+}
+
+// SetRemoteURL sets the fetch URL of remote, or its push URL when push is
+// true.
+func SetRemoteURL(repoPath, remote, url string, push bool) error {
+	args := []string{"remote", "set-url"}
+	if push {
+		args = append(args, "--push")
+	}
+	_, err := run(repoPath, append(args, remote, url)...)
+	return err
+}
+
+// URLHost returns the host name of a remote URL, lowercased and without
+// user or port: "github.com" for both https://github.com/o/r.git and
+// git@github.com:o/r.git. Local paths and file:// URLs yield "".
+func URLHost(remoteURL string) string {
+	if scheme, rest, found := strings.Cut(remoteURL, "://"); found {
+		if scheme == "file" {
+			return ""
+		}
+		host, _, _ := strings.Cut(rest, "/")
+		if _, h, found := strings.Cut(host, "@"); found {
+			host = h
+		}
+		if u, err := url.Parse("//" + host); err == nil {
+			return strings.ToLower(u.Hostname())
+		}
+		return ""
+	}
+
+	// scp-like syntax: [user@]host:path. Git treats a colon after a slash
+	// as part of a local path.
+	colon := strings.IndexByte(remoteURL, ':')
+	if colon <= 0 || strings.Contains(remoteURL[:colon], "/") {
+		return ""
+	}
+	host := remoteURL[:colon]
+	if _, h, found := strings.Cut(host, "@"); found {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// Clone clones url into dest. The parent of dest must exist.
+func Clone(url, dest string) error {
+	_, err := run("", "clone", "--quiet", "--", url, dest)
+	return err
+}
+
+// CreateBundle writes every ref in the repository to a git bundle at dest.
+// The bundle can be restored with git clone.
+func CreateBundle(repoPath, dest string) error {
+	_, err := run(repoPath, "bundle", "create", dest, "--all")
+	return err
+}
+
+// CreateBranchBundle writes a single local branch to a git bundle at dest.
+// The branch can be restored with git fetch <bundle> <branch>:<branch>.
+func CreateBranchBundle(repoPath, dest, branch string) error {
+	_, err := run(repoPath, "bundle", "create", dest, "refs/heads/"+branch)
+	return err
+}
+
+// CreateTagBundle writes a single tag to a git bundle at dest. The tag
+// can be restored with git fetch <bundle> refs/tags/<tag>:refs/tags/<tag>.
+func CreateTagBundle(repoPath, dest, tag string) error {
+	_, err := run(repoPath, "bundle", "create", dest, "refs/tags/"+tag)
+	return err
+}
+
 // Fetch fetches from the given remote.
 func Fetch(repoPath, remote string) error {
 	_, err := run(repoPath, "fetch", remote)
 	return err
 }
 
+// FetchTag fetches a single tag from remote, without the rest of its tags.
+func FetchTag(repoPath, remote, tag string) error {
+	ref := "refs/tags/" + tag
+	_, err := run(repoPath, "fetch", "--no-tags", remote, ref+":"+ref)
+	return err
+}
+
+// FetchPrune fetches from the given remote and removes remote-tracking
+// refs whose branches no longer exist on it.
+func FetchPrune(repoPath, remote string) error {
+	_, err := run(repoPath, "fetch", "--prune", remote)
+	return err
+}
+
+// ProbeTimeout is how long Probe waits for a remote to answer.
+const ProbeTimeout = 10 * time.Second
+
+// Probe checks that remote can be read without prompting by asking it
+// for its HEAD with git ls-remote, which is much cheaper than a fetch.
+// It returns an error matching ErrAuthFailed when the remote wants
+// credentials, ErrRepoNotFound when it has no repository at its URL, and
+// ErrUnreachable when it cannot be reached or does not answer within
+// timeout.
+func Probe(repoPath, remote string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := runContext(ctx, repoPath, "ls-remote", "--quiet", remote, "HEAD")
+	if err != nil && ctx.Err() != nil {
+		return fmt.Errorf("%w: %s did not answer within %s", ErrUnreachable, remote, timeout)
+	}
+	return err
+}
+
+// RemoteUpdate fetches every remote of a bare repository or mirror with
+// git remote update --prune, and reports whether any ref changed.
+func RemoteUpdate(repoPath string) (bool, error) {
+	before, err := run(repoPath, "for-each-ref", "--format=%(objectname) %(refname)")
+	if err != nil {
+		return false, err
+	}
+	if _, err := run(repoPath, "remote", "update", "--prune"); err != nil {
+		return false, err
+	}
+	after, err := run(repoPath, "for-each-ref", "--format=%(objectname) %(refname)")
+	if err != nil {
+		return false, err
+	}
+	return before != after, nil
+}
+
 // DeleteLocalBranch deletes a local branch. If force is true, uses -D instead of -d.
 func DeleteLocalBranch(repoPath, branch string, force bool) error {
 	flag := "-d"
@@ -153,6 +470,15 @@ func DeleteRemoteBranch(repoPath, remote, branch string) error {
 	return err
 }
 
+// PushBranch pushes a local branch to the given remote and sets it as the
+// branch's upstream. It ignores SetIsolation: the pre-push hook always
+// runs, since it may guard what leaves the machine, such as a secret
+// scanner.
+func PushBranch(repoPath, remote, branch string) error {
+	_, err := runUnisolated(repoPath, "push", "--set-upstream", remote, branch)
+	return err
+}
+
 // RevParse returns the full SHA of the given ref.
 func RevParse(repoPath, ref string) (string, error) {
 	return run(repoPath, "rev-parse", "--verify", ref)
@@ -160,6 +486,9 @@ func RevParse(repoPath, ref string) (string, error) {
 
 // CommitDate returns the author date of the latest commit on the given branch.
 func CommitDate(repoPath, branch string) (time.Time, error) {
+	if date, ok := fastCommitDate(repoPath, branch); ok {
+		return date, nil
+	}
 	out, err := run(repoPath, "log", "-1", "--format=%aI", branch)
 	if err != nil {
 		return time.Time{}, err
@@ -176,15 +505,67 @@ func IsClean(repoPath string) (bool, error) {
 	return out == "", nil
 }
 
+// ChangedFiles returns the paths, relative to the repository root, of
+// files with uncommitted changes: staged, unstaged, conflicted, or
+// untracked (ignored files are left out). A renamed file is listed under
+// its new path.
+func ChangedFiles(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "status", "--porcelain=v2", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		e := entries[i]
+		// Fields before the path: 8 for ordinary changes, 9 for renames
+		// and copies (followed by the original path), 10 for conflicts.
+		fields := 0
+		switch {
+		case strings.HasPrefix(e, "1 "):
+			fields = 8
+		case strings.HasPrefix(e, "2 "):
+			fields = 9
+			i++ // skip the original path
+		case strings.HasPrefix(e, "u "):
+			fields = 10
+		case strings.HasPrefix(e, "? "):
+			fields = 1
+		default:
+			continue
+		}
+		if parts := strings.SplitN(e, " ", fields+1); len(parts) == fields+1 {
+			paths = append(paths, parts[fields])
+		}
+	}
+	return paths, nil
+}
+
+// UntrackedFiles returns the paths, relative to the repository root, of
+// untracked files that are not ignored.
+func UntrackedFiles(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "ls-files", "-z", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, p := range strings.Split(out, "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
 // HasRemote returns true if the given remote exists.
 func HasRemote(repoPath, remote string) bool {
 	_, err := run(repoPath, "remote", "get-url", remote)
 	return err == nil
 }
 
-// Pull pulls from the default remote using the given strategy.
-// Valid strategies: "rebase", "merge", "ff-only".
-func Pull(repoPath string, strategy string) error {
+// Pull pulls branch from remote into the current branch using the given
+// strategy. Valid strategies: "rebase", "merge", "ff-only".
+func Pull(repoPath, remote, branch, strategy string) error {
 	args := []string{"pull"}
 	switch strategy {
 	case "rebase":
@@ -196,6 +577,7 @@ func Pull(repoPath string, strategy string) error {
 	default:
 		return fmt.Errorf("unknown pull strategy: %q", strategy)
 	}
+	args = append(args, remote, branch)
 	_, err := run(repoPath, args...)
 	return err
 }
@@ -204,12 +586,22 @@ func Pull(repoPath string, strategy string) error {
 // It returns true if a stash entry was actually created, false if there was
 // nothing to stash (git stash push exits 0 either way).
 func StashPush(repoPath string, message string) (bool, error) {
+	return stashPush(repoPath, "stash", "push", "-m", message)
+}
+
+// StashPushAll is StashPush including untracked files, leaving a clean
+// working tree.
+func StashPushAll(repoPath string, message string) (bool, error) {
+	return stashPush(repoPath, "stash", "push", "--include-untracked", "-m", message)
+}
+
+func stashPush(repoPath string, args ...string) (bool, error) {
 	// Capture the stash ref before pushing so we can detect whether a new
 	// entry was created. This avoids parsing porcelain output which varies
 	// by locale.
 	beforeRef, _ := run(repoPath, "rev-parse", "--quiet", "--verify", "refs/stash")
 
-	_, err := run(repoPath, "stash", "push", "-m", message)
+	_, err := run(repoPath, args...)
 	if err != nil {
 		return false, err
 	}
@@ -230,6 +622,13 @@ func RebaseAbort(repoPath string) error {
 	return err
 }
 
+// RebaseQuit discards in-progress rebase state without touching HEAD or the
+// working tree. Used to clear leftovers of a rebase that was abandoned.
+func RebaseQuit(repoPath string) error {
+	_, err := run(repoPath, "rebase", "--quit")
+	return err
+}
+
 // MergeAbort aborts an in-progress merge, restoring the branch to its pre-merge state.
 func MergeAbort(repoPath string) error {
 	_, err := run(repoPath, "merge", "--abort")
@@ -245,8 +644,7 @@ func MergeBase(repoPath string, ref1, ref2 string) (string, error) {
 // references. It returns the merge output, whether conflicts were detected, and any error.
 // This is a read-only operation that does not modify the working tree.
 func MergeTree(repoPath string, base, local, remote string) (string, bool, error) {
-	// #nosec G204 - git refs are controlled by internal callers
-	cmd := exec.Command("git", "merge-tree", base, local, remote)
+	cmd := gitCommand("merge-tree", base, local, remote)
 	cmd.Dir = repoPath
 	out, err := cmd.CombinedOutput()
 	output := strings.TrimSpace(string(out))
@@ -265,12 +663,350 @@ func Checkout(repoPath, branch string) error {
 	return err
 }
 
+// CheckoutDetached checks out ref with a detached HEAD, e.g. a tag.
+func CheckoutDetached(repoPath, ref string) error {
+	_, err := run(repoPath, "checkout", "--detach", ref)
+	return err
+}
+
+// CreateBranch creates a new branch at HEAD and switches to it, carrying
+// any uncommitted changes along.
+func CreateBranch(repoPath, branch string) error {
+	_, err := run(repoPath, "checkout", "-b", branch)
+	return err
+}
+
+// CreateBranchAt creates a branch pointing at ref without switching to it.
+func CreateBranchAt(repoPath, branch, ref string) error {
+	_, err := run(repoPath, "branch", branch, ref)
+	return err
+}
+
+// ResetHard moves the current branch to ref and discards any changes in
+// the working tree and index.
+func ResetHard(repoPath, ref string) error {
+	_, err := run(repoPath, "reset", "--hard", ref)
+	return err
+}
+
+// BranchExists returns true if a local branch with the given name exists.
+func BranchExists(repoPath, branch string) bool {
+	_, err := run(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	return err == nil
+}
+
+// UnusedBranchName returns base, or base with the lowest numeric suffix
+// from -2 up, whichever exists reports is not taken. Callers pass
+// BranchExists for the repository, or their own lookup.
+func UnusedBranchName(base string, exists func(branch string) bool) string {
+	name := base
+	for i := 2; exists(name); i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
+
+// CommitAll stages every change in the working tree, including untracked
+// files, and commits it. Hooks are skipped because this records the user's
+// in-progress work verbatim rather than producing a reviewed commit.
+func CommitAll(repoPath, message string) error {
+	if _, err := run(repoPath, "add", "-A"); err != nil {
+		return err
+	}
+	_, err := run(repoPath, "commit", "--no-verify", "-m", message)
+	return err
+}
+
 // CreateTag creates a lightweight tag at the given ref.
 func CreateTag(repoPath, tagName, ref string) error {
 	_, err := run(repoPath, "tag", tagName, ref)
 	return err
 }
 
+// TagRef describes a tag and the commit it points to.
+type TagRef struct {
+	Name string
+	// Commit is the commit the tag resolves to. For annotated tags this is
+	// the peeled target, not the tag object.
+	Commit string
+	// Date is the tagger date for annotated tags and the commit date for
+	// lightweight tags.
+	Date time.Time
+}
+
+// ListTags returns all local tags.
+func ListTags(repoPath string) ([]TagRef, error) {
+	out, err := run(repoPath, "for-each-ref",
+		"--format=%(refname:short)\t%(objectname)\t%(*objectname)\t%(creatordate:unix)",
+		"refs/tags")
+	if err != nil {
+		return nil, err
+	}
+	var tags []TagRef
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 4 {
+			continue
+		}
+		t := TagRef{Name: fields[0], Commit: fields[1]}
+		if fields[2] != "" {
+			t.Commit = fields[2]
+		}
+		if ts, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			t.Date = time.Unix(ts, 0)
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// RemoteTags returns the set of tag names that exist on the given remote.
+// This contacts the remote.
+func RemoteTags(repoPath, remote string) (map[string]bool, error) {
+	out, err := run(repoPath, "ls-remote", "--tags", "--refs", remote)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]bool)
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		tags[strings.TrimPrefix(fields[1], "refs/tags/")] = true
+	}
+	return tags, nil
+}
+
+// UnreachableTags returns the tags whose commits are not reachable from any
+// local or remote-tracking branch. Returns nil when the repo has no
+// branches to compare against.
+func UnreachableTags(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "for-each-ref", "--format=%(refname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+	args := []string{"for-each-ref", "--format=%(refname:short)"}
+	n := 0
+	for _, ref := range splitNonEmpty(out) {
+		if strings.HasSuffix(ref, "/HEAD") {
+			continue
+		}
+		args = append(args, "--no-merged", ref)
+		n++
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	args = append(args, "refs/tags")
+	out, err = run(repoPath, args...)
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmpty(out), nil
+}
+
+// DeleteTag deletes a local tag.
+func DeleteTag(repoPath, tag string) error {
+	_, err := run(repoPath, "tag", "-d", tag)
+	return err
+}
+
+// DeleteRemoteTag deletes a tag on the given remote.
+func DeleteRemoteTag(repoPath, remote, tag string) error {
+	_, err := run(repoPath, "push", remote, "--delete", "refs/tags/"+tag)
+	return err
+}
+
+// GitDir returns the absolute path of the repository's git directory.
+func GitDir(repoPath string) (string, error) {
+	return run(repoPath, "rev-parse", "--absolute-git-dir")
+}
+
+// CheckOwnership returns an error matching ErrDubiousOwnership when git
+// refuses to work in repoPath because another user owns it and it is not
+// listed in safe.directory, and nil otherwise.
+func CheckOwnership(repoPath string) error {
+	if _, err := GitDir(repoPath); errors.Is(err, ErrDubiousOwnership) {
+		return err
+	}
+	return nil
+}
+
+// AddSafeDirectory adds path to safe.directory in the user's global git
+// config, so that git works in it although another user owns it.
+func AddSafeDirectory(path string) error {
+	_, err := run("", "config", "--global", "--add", "safe.directory", path)
+	return err
+}
+
+// Blob is a blob object with the path it was first seen at.
+type Blob struct {
+	OID  string
+	Path string
+	// Size is the uncompressed size; DiskSize is the packed size on disk.
+	Size     int64
+	DiskSize int64
+}
+
+// LargeBlobs returns blobs in the object database whose uncompressed size
+// is at least minSize, including blobs only reachable from history.
+// Unreachable (dangling) objects are included with an empty Path.
+func LargeBlobs(repoPath string, minSize int64) ([]Blob, error) {
+	out, err := run(repoPath, "cat-file", "--batch-all-objects", "--unordered",
+		"--batch-check=%(objecttype) %(objectname) %(objectsize) %(objectsize:disk)")
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []Blob
+	index := make(map[string]int)
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "blob" {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil || size < minSize {
+			continue
+		}
+		disk, _ := strconv.ParseInt(fields[3], 10, 64)
+		index[fields[1]] = len(blobs)
+		blobs = append(blobs, Blob{OID: fields[1], Size: size, DiskSize: disk})
+	}
+	if len(blobs) == 0 {
+		return nil, nil
+	}
+
+	// Resolve paths only when there is something to report: listing every
+	// reachable object is the expensive part.
+	out, err = run(repoPath, "rev-list", "--objects", "--all")
+	if err != nil {
+		return blobs, nil
+	}
+	for _, line := range splitNonEmpty(out) {
+		oid, path, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if i, found := index[oid]; found && blobs[i].Path == "" {
+			blobs[i].Path = path
+		}
+	}
+	return blobs, nil
+}
+
+// IgnoredDirs returns the untracked directories of a repository that are
+// ignored as a whole by .gitignore or the other exclude files, relative
+// to the repository root and without a trailing slash. Ignored directories
+// are not descended into, so nested ones are not listed.
+func IgnoredDirs(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "ls-files", "-z", "--others", "--ignored", "--exclude-standard", "--directory")
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, entry := range strings.Split(out, "\x00") {
+		if dir, ok := strings.CutSuffix(entry, "/"); ok && dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// LFSAvailable returns true if the git-lfs extension is installed.
+func LFSAvailable() bool {
+	_, err := exec.LookPath("git-lfs")
+	return err == nil
+}
+
+// LFSPrunable returns the object IDs that `git lfs prune` would delete.
+func LFSPrunable(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "lfs", "prune", "--dry-run", "--verbose")
+	if err != nil {
+		return nil, err
+	}
+	return parseLFSPruneOIDs(out), nil
+}
+
+// parseLFSPruneOIDs extracts object IDs from `git lfs prune --verbose`
+// output, where each object is listed as " * <oid> (<size>)".
+func parseLFSPruneOIDs(out string) []string {
+	var oids []string
+	for _, line := range splitNonEmpty(out) {
+		rest, ok := strings.CutPrefix(line, "* ")
+		if !ok {
+			continue
+		}
+		oid, _, _ := strings.Cut(rest, " ")
+		if len(oid) == 64 {
+			oids = append(oids, oid)
+		}
+	}
+	return oids
+}
+
+// Fsck verifies the object database with git fsck --no-dangling and
+// returns the problems it reports. An empty result means fsck passed;
+// warnings from a passing fsck are not reported.
+func Fsck(repoPath string) ([]string, error) {
+	cmd := gitCommand("fsck", "--no-dangling", "--no-progress")
+	cmd.Dir = repoPath
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil, nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return nil, fmt.Errorf("git fsck: %w", err)
+	}
+	var problems []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			problems = append(problems, line)
+		}
+	}
+	if len(problems) == 0 {
+		problems = append(problems, err.Error())
+	}
+	return problems, nil
+}
+
+// IsShallow returns true if the repository is a shallow clone.
+func IsShallow(repoPath string) (bool, error) {
+	out, err := run(repoPath, "rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return out == "true", nil
+}
+
+// Unshallow fetches the complete history from remote into a shallow clone.
+func Unshallow(repoPath, remote string) error {
+	_, err := run(repoPath, "fetch", "--unshallow", remote)
+	return err
+}
+
+// SymbolicHead returns the ref HEAD points to (e.g. refs/heads/main), or
+// "" when HEAD is detached.
+func SymbolicHead(repoPath string) (string, error) {
+	out, err := run(repoPath, "symbolic-ref", "-q", "HEAD")
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return out, nil
+}
+
+// LFSPrune deletes local LFS objects that are no longer needed.
+func LFSPrune(repoPath string) error {
+	_, err := run(repoPath, "lfs", "prune")
+	return err
+}
+
 // CommitsAheadBehind returns the number of commits that branch is ahead of and
 // behind base. This uses rev-list to count commits reachable from one ref but
 // not the other.
@@ -286,6 +1022,20 @@ func CommitsAheadBehind(repoPath, branch, base string) (ahead int, behind int, e
 	return ahead, behind, nil
 }
 
+// ErrNoUpstream is returned by AheadBehindUpstream for a branch with no
+// upstream configured, or whose upstream's remote-tracking ref is gone.
+var ErrNoUpstream = errors.New("no upstream configured")
+
+// AheadBehindUpstream returns the number of commits branch is ahead of and
+// behind its upstream, as of the last fetch. It returns ErrNoUpstream when
+// there is nothing to compare with.
+func AheadBehindUpstream(repoPath, branch string) (ahead int, behind int, err error) {
+	if !HasUpstream(repoPath, branch) {
+		return 0, 0, fmt.Errorf("%s: %w", branch, ErrNoUpstream)
+	}
+	return CommitsAheadBehind(repoPath, branch, branch+"@{upstream}")
+}
+
 // RevListCount runs git rev-list --count with the given spec and returns the count.
 // This is useful for checking how many commits one ref is ahead/behind another,
 // e.g. RevListCount(repo, "HEAD..origin/main") returns how many commits HEAD is behind.
@@ -301,8 +1051,35 @@ func RevListCount(repoPath, spec string) (int, error) {
 	return count, nil
 }
 
+// UnpushedCount returns the number of commits reachable from ref that are
+// not reachable from any remote-tracking branch. A non-zero count means the
+// work exists only in this checkout, whether or not an upstream is set.
+func UnpushedCount(repoPath, ref string) (int, error) {
+	out, err := run(repoPath, "rev-list", "--count", ref, "--not", "--remotes")
+	if err != nil {
+		return 0, err
+	}
+	count, err := strconv.Atoi(out)
+	if err != nil {
+		return 0, fmt.Errorf("parsing rev-list count output %q: %w", out, err)
+	}
+	return count, nil
+}
+
+// StashCount returns the number of entries in the stash.
+func StashCount(repoPath string) (int, error) {
+	out, err := run(repoPath, "stash", "list")
+	if err != nil {
+		return 0, err
+	}
+	return len(splitNonEmpty(out)), nil
+}
+
 // HasRemoteBranch returns true if the given branch exists on the specified remote.
 func HasRemoteBranch(repoPath, remote, branch string) (bool, error) {
+	if exists, ok := fastHasRemoteBranch(repoPath, remote, branch); ok {
+		return exists, nil
+	}
 	out, err := run(repoPath, "branch", "-r", "--list", remote+"/"+branch)
 	if err != nil {
 		return false, err
@@ -315,6 +1092,25 @@ func CommitSubject(repoPath, ref string) (string, error) {
 	return run(repoPath, "log", "-1", "--format=%s", ref)
 }
 
+// CommitAuthor returns the author email of the latest commit on the given
+// ref.
+func CommitAuthor(repoPath, ref string) (string, error) {
+	return run(repoPath, "log", "-1", "--format=%ae", ref)
+}
+
+// LastCommitBy returns the latest commit on ref authored by email, or ""
+// when there is none.
+func LastCommitBy(repoPath, ref, email string) (string, error) {
+	return run(repoPath, "log", "-1", "--format=%H", "--fixed-strings", "--author=<"+email+">", ref)
+}
+
+// LastHeadUser returns the email of whoever last moved HEAD in the
+// repository (by committing, checking out, pulling, or cloning), read from
+// the HEAD reflog. It is "" when the reflog is empty or expired.
+func LastHeadUser(repoPath string) (string, error) {
+	return run(repoPath, "reflog", "show", "-1", "--format=%ge", "HEAD", "--")
+}
+
 // ConfigValue returns the value of a git config key in the given repo.
 func ConfigValue(repoPath, key string) (string, error) {
 	return run(repoPath, "config", key)
@@ -342,6 +1138,130 @@ func CommitAuthors(repoPath, branch, base string) ([]string, error) {
 	return authors, nil
 }
 
+// CommitSummary is one commit of a log: its full hash, author name, and
+// subject line.
+type CommitSummary struct {
+	SHA     string
+	Author  string
+	Subject string
+}
+
+// Log returns up to max commits in the revision range spec (e.g.
+// "HEAD..origin/main"), newest first. A max of 0 or less returns them all.
+func Log(repoPath, spec string, max int) ([]CommitSummary, error) {
+	return logSummaries(repoPath, max, spec)
+}
+
+// MergesSince returns up to max merge commits made on ref's first-parent
+// history after since, newest first: the pull requests and branches
+// merged into it. A max of 0 or less returns them all.
+func MergesSince(repoPath, ref string, since time.Time, max int) ([]CommitSummary, error) {
+	return logSummaries(repoPath, max, "--merges", "--first-parent", fmt.Sprintf("--since=%d", since.Unix()), ref)
+}
+
+// logSummaries runs git log with args, limited to max commits when max is
+// positive, and parses each commit into a CommitSummary.
+func logSummaries(repoPath string, max int, args ...string) ([]CommitSummary, error) {
+	cmd := []string{"log", "--format=%H%x00%an%x00%s"}
+	if max > 0 {
+		cmd = append(cmd, fmt.Sprintf("--max-count=%d", max))
+	}
+	out, err := run(repoPath, append(append(cmd, args...), "--")...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []CommitSummary
+	for _, line := range splitNonEmpty(out) {
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, CommitSummary{SHA: fields[0], Author: fields[1], Subject: fields[2]})
+	}
+	return commits, nil
+}
+
+// ChangedTopLevelPaths returns the top-level files and directories changed
+// on to since it diverged from from, sorted. Directories carry a trailing
+// slash, e.g. "README.md" and "cmd/".
+func ChangedTopLevelPaths(repoPath, from, to string) ([]string, error) {
+	out, err := run(repoPath, "diff", "--name-only", "--no-renames", "-z", from+"..."+to, "--")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var paths []string
+	for _, name := range strings.Split(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		if dir, _, found := strings.Cut(name, "/"); found {
+			name = dir + "/"
+		}
+		if !seen[name] {
+			seen[name] = true
+			paths = append(paths, name)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// TagsAt returns the tags pointing at ref.
+func TagsAt(repoPath, ref string) ([]string, error) {
+	out, err := run(repoPath, "tag", "--points-at", ref)
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmpty(out), nil
+}
+
+// BranchesContaining returns the local and remote-tracking branches whose
+// history includes ref, e.g. "main" and "origin/main". Remote HEAD
+// symrefs are left out.
+func BranchesContaining(repoPath, ref string) ([]string, error) {
+	out, err := run(repoPath, "for-each-ref", "--contains", ref, "--format=%(refname)", "refs/heads", "refs/remotes")
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for _, name := range splitNonEmpty(out) {
+		if strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		name = strings.TrimPrefix(name, "refs/heads/")
+		branches = append(branches, strings.TrimPrefix(name, "refs/remotes/"))
+	}
+	return branches, nil
+}
+
+// IsAncestor reports whether ref is an ancestor of (or the same commit as)
+// base, per git merge-base --is-ancestor.
+func IsAncestor(repoPath, ref, base string) (bool, error) {
+	_, err := run(repoPath, "merge-base", "--is-ancestor", ref, base)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// IsReachable reports whether every commit on ref is also in base or in a
+// remote-tracking branch, so deleting a branch at ref loses no work.
+func IsReachable(repoPath, ref, base string) (bool, error) {
+	if ok, err := IsAncestor(repoPath, ref, base); err != nil || ok {
+		return ok, err
+	}
+	out, err := run(repoPath, "for-each-ref", "--count=1", "--contains", ref, "--format=%(refname)", "refs/remotes")
+	if err != nil {
+		return false, err
+	}
+	return out != "", nil
+}
+
 // HasUpstream returns true if the given branch has a remote tracking branch configured.
 func HasUpstream(repoPath, branch string) bool {
 	_, err := run(repoPath, "rev-parse", "--abbrev-ref", branch+"@{upstream}")
@@ -349,7 +1269,9 @@ func HasUpstream(repoPath, branch string) bool {
 }
 
 // ConflictState returns the type of in-progress operation in the repo, if any.
-// Returns "rebase", "merge", "cherry-pick", or "" if the repo is in a normal state.
+// Returns "rebase", "merge", "cherry-pick", "revert", "bisect", or "" if the
+// repo is in a normal state. Commands that move HEAD or delete branches
+// leave a repo with an operation in progress alone.
 func ConflictState(repoPath string) string {
 	gitDir, err := run(repoPath, "rev-parse", "--git-dir")
 	if err != nil {
@@ -371,6 +1293,12 @@ func ConflictState(repoPath string) string {
 	if _, err := os.Stat(filepath.Join(gitDir, "CHERRY_PICK_HEAD")); err == nil {
 		return "cherry-pick"
 	}
+	if _, err := os.Stat(filepath.Join(gitDir, "REVERT_HEAD")); err == nil {
+		return "revert"
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "BISECT_LOG")); err == nil {
+		return "bisect"
+	}
 	return ""
 }
 
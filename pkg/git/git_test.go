@@ -1,9 +1,14 @@
 package git_test
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -55,6 +60,109 @@ func TestIsRepo(t *testing.T) {
 	}
 }
 
+func TestURLHost(t *testing.T) {
+	for url, want := range map[string]string{
+		"https://github.com/owner/repo.git":        "github.com",
+		"https://token@GitHub.com/owner/repo":      "github.com",
+		"ssh://git@gitlab.example.com:2222/team/r": "gitlab.example.com",
+		"git@github.com:owner/repo.git":            "github.com",
+		"bitbucket.org:team/repo.git":              "bitbucket.org",
+		"file:///srv/git/repo.git":                 "",
+		"/srv/git/repo.git":                        "",
+		"./relative/with:colon":                    "",
+		"":                                         "",
+	} {
+		if got := git.URLHost(url); got != want {
+			t.Errorf("URLHost(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+func TestRemoteURLs(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "remote-urls")
+	repo.AddRemote("origin", "gh:owner/repo")
+	repo.Git("config", "url.git@github.com:.insteadOf", "gh:")
+
+	urls, err := git.RemoteURLs(repo.Path, "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 1 || urls[0] != "git@github.com:owner/repo" {
+		t.Errorf("expected the rewritten fetch URL only, got %v", urls)
+	}
+
+	repo.Git("remote", "set-url", "--push", "origin", "ssh://git@github.com/owner/repo.git")
+	urls, err = git.RemoteURLs(repo.Path, "origin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(urls) != 2 || urls[1] != "ssh://git@github.com/owner/repo.git" {
+		t.Errorf("expected fetch and push URLs, got %v", urls)
+	}
+
+	if _, err := git.RemoteURLs(repo.Path, "missing"); err == nil {
+		t.Error("expected an error for a missing remote")
+	}
+}
+
+func TestPrimaryRemoteInFork(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "fork")
+	if got := git.PrimaryRemote(repo.Path); got != "origin" {
+		t.Errorf("expected origin without an upstream, got %q", got)
+	}
+
+	// The upstream project uses trunk as its default branch.
+	repo.CreateBranch("trunk")
+	repo.Checkout("main")
+	upstream := filepath.Join(t.TempDir(), "upstream.git")
+	repo.Git("clone", "--quiet", "--bare", repo.Path, upstream)
+	repo.Git("--git-dir", upstream, "symbolic-ref", "HEAD", "refs/heads/trunk")
+	repo.AddRemote("upstream", upstream)
+	repo.Git("fetch", "--quiet", "upstream")
+	repo.Git("remote", "set-head", "upstream", "--auto")
+
+	if got := git.PrimaryRemote(repo.Path); got != "upstream" {
+		t.Errorf("expected upstream in a fork, got %q", got)
+	}
+	if branch, err := git.DefaultBranch(repo.Path); err != nil || branch != "trunk" {
+		t.Errorf("expected upstream's default branch trunk, got %q, %v", branch, err)
+	}
+
+	git.SetPreferredRemote("origin")
+	t.Cleanup(func() { git.SetPreferredRemote("") })
+	if got := git.PrimaryRemote(repo.Path); got != "origin" {
+		t.Errorf("expected the preferred remote, got %q", got)
+	}
+	if branch, err := git.DefaultBranch(repo.Path); err != nil || branch != "main" {
+		t.Errorf("expected origin's default branch main, got %q, %v", branch, err)
+	}
+}
+
+func TestHasGitDir(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "has-git-dir")
+	if !git.HasGitDir(repo.Path) {
+		t.Error("expected repo root to have a git dir")
+	}
+
+	sub := filepath.Join(repo.Path, "sub")
+	if err := os.Mkdir(sub, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if git.HasGitDir(sub) {
+		t.Error("expected subdirectory of a repo not to have a git dir")
+	}
+
+	wt := filepath.Join(t.TempDir(), "wt")
+	repo.Git("worktree", "add", "-q", wt)
+	if !git.HasGitDir(wt) {
+		t.Error("expected worktree with a .git file to have a git dir")
+	}
+
+	if git.HasGitDir(t.TempDir()) {
+		t.Error("expected empty directory not to have a git dir")
+	}
+}
+
 func TestCurrentBranch(t *testing.T) {
 	repo := helpers.NewTestRepo(t, "current-branch")
 	branch, err := git.CurrentBranch(repo.Path)
@@ -90,6 +198,73 @@ func TestDefaultBranch(t *testing.T) {
 	}
 }
 
+func TestRemoteHead(t *testing.T) {
+	repo, bare := helpers.NewClonedRepo(t, "remote-head")
+	if got, err := git.RemoteHead(repo.Path, "origin"); err != nil || got != "refs/remotes/origin/main" {
+		t.Errorf("expected refs/remotes/origin/main, got %q (%v)", got, err)
+	}
+
+	repo.Git("--git-dir", bare, "branch", "-m", "main", "trunk")
+	if got, err := git.RemoteDefaultBranch(repo.Path, "origin"); err != nil || got != "trunk" {
+		t.Errorf("expected the remote to report trunk, got %q (%v)", got, err)
+	}
+	repo.Git("fetch", "--quiet", "origin")
+	if err := git.RefreshRemoteHead(repo.Path, "origin"); err != nil {
+		t.Fatalf("RefreshRemoteHead: %v", err)
+	}
+	if got, err := git.DefaultBranch(repo.Path); err != nil || got != "trunk" {
+		t.Errorf("expected default branch trunk after refresh, got %q (%v)", got, err)
+	}
+
+	repo.Git("remote", "set-head", "origin", "--delete")
+	if got, err := git.RemoteHead(repo.Path, "origin"); err != nil || got != "" {
+		t.Errorf("expected no remote HEAD, got %q (%v)", got, err)
+	}
+}
+
+func TestFetchPrune(t *testing.T) {
+	repo, bare := helpers.NewClonedRepo(t, "prune")
+	repo.Git("--git-dir", bare, "branch", "gone")
+	repo.Git("fetch", "--quiet", "origin")
+	if _, err := git.RevParse(repo.Path, "refs/remotes/origin/gone"); err != nil {
+		t.Fatalf("expected origin/gone after fetch: %v", err)
+	}
+
+	repo.Git("--git-dir", bare, "branch", "-D", "gone")
+	if err := git.FetchPrune(repo.Path, "origin"); err != nil {
+		t.Fatalf("FetchPrune: %v", err)
+	}
+	if _, err := git.RevParse(repo.Path, "refs/remotes/origin/gone"); err == nil {
+		t.Error("expected origin/gone to be pruned")
+	}
+}
+
+func TestBareRepoRemoteUpdate(t *testing.T) {
+	origin := helpers.NewTestRepo(t, "mirrored")
+	mirror := filepath.Join(t.TempDir(), "mirrored.git")
+	origin.Git("clone", "--quiet", "--mirror", origin.Path, mirror)
+
+	if !git.IsBareRepo(mirror) {
+		t.Error("expected the mirror to be detected as bare")
+	}
+	if git.IsBareRepo(origin.Path) {
+		t.Error("expected a checkout not to be detected as bare")
+	}
+
+	changed, err := git.RemoteUpdate(mirror)
+	if err != nil || changed {
+		t.Errorf("expected an up-to-date mirror, got changed=%v err=%v", changed, err)
+	}
+
+	origin.WriteFile("new.txt", "new")
+	origin.AddFile("new.txt")
+	origin.Commit("new commit")
+	changed, err = git.RemoteUpdate(mirror)
+	if err != nil || !changed {
+		t.Errorf("expected the mirror to pick up the new commit, got changed=%v err=%v", changed, err)
+	}
+}
+
 func TestListBranches(t *testing.T) {
 	repo := helpers.NewTestRepo(t, "list-branches")
 	repo.CreateBranch("feature/one")
@@ -175,6 +350,80 @@ func TestIsMerged(t *testing.T) {
 	}
 }
 
+func TestIsSquashMerged(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "squash-merged")
+
+	// Two commits on the branch, squashed into one on main.
+	repo.CreateBranch("feature/squashed")
+	repo.WriteFile("a.txt", "first\n")
+	repo.AddFile("a.txt")
+	repo.Commit("first")
+	repo.WriteFile("a.txt", "first\nsecond\n")
+	repo.AddFile("a.txt")
+	repo.Commit("second")
+	repo.Checkout("main")
+	repo.WriteFile("unrelated.txt", "moves main forward\n")
+	repo.AddFile("unrelated.txt")
+	repo.Commit("unrelated work")
+	repo.Git("merge", "--squash", "feature/squashed")
+	repo.Commit("Add a.txt (#12)")
+
+	// A branch whose changes never landed.
+	repo.CreateBranch("feature/open")
+	repo.WriteFile("b.txt", "unmerged\n")
+	repo.AddFile("b.txt")
+	repo.Commit("open work")
+	repo.Checkout("main")
+
+	// A branch with no changes of its own.
+	repo.Git("branch", "feature/empty")
+
+	tests := []struct {
+		branch string
+		want   bool
+	}{
+		{"feature/squashed", true},
+		{"feature/open", false},
+		{"feature/empty", false},
+	}
+	for _, tt := range tests {
+		got, err := git.IsSquashMerged(repo.Path, tt.branch, "main")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.branch, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: IsSquashMerged = %v, want %v", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestCreateBundle(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "bundled")
+	repo.CreateBranch("feature/kept")
+	repo.WriteFile("kept.txt", "kept\n")
+	repo.AddFile("kept.txt")
+	repo.Commit("kept work")
+	repo.Checkout("main")
+
+	dest := filepath.Join(t.TempDir(), "bundled.bundle")
+	if err := git.CreateBundle(repo.Path, dest); err != nil {
+		t.Fatalf("CreateBundle: %v", err)
+	}
+
+	clone := filepath.Join(t.TempDir(), "restored")
+	// #nosec G204 - test-controlled arguments
+	if out, err := exec.Command("git", "clone", "--quiet", dest, clone).CombinedOutput(); err != nil {
+		t.Fatalf("clone from bundle: %v: %s", err, out)
+	}
+	if !git.BranchExists(clone, "main") {
+		t.Error("expected main in restored clone")
+	}
+	// #nosec G204 - test-controlled arguments
+	if out, err := exec.Command("git", "-C", clone, "rev-parse", "--verify", "origin/feature/kept").CombinedOutput(); err != nil {
+		t.Errorf("expected feature/kept in restored clone: %v: %s", err, out)
+	}
+}
+
 func TestCommitDate(t *testing.T) {
 	repo := helpers.NewTestRepo(t, "commit-date")
 
@@ -325,7 +574,7 @@ func TestPull(t *testing.T) {
 	pushToRemote(t, pusherPath, "origin", "main")
 
 	t.Run("rebase", func(t *testing.T) {
-		err := git.Pull(clonePath, "rebase")
+		err := git.Pull(clonePath, "origin", "main", "rebase")
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -336,7 +585,7 @@ func TestPull(t *testing.T) {
 	})
 
 	t.Run("invalid_strategy", func(t *testing.T) {
-		err := git.Pull(clonePath, "invalid")
+		err := git.Pull(clonePath, "origin", "main", "invalid")
 		if err == nil {
 			t.Error("expected error for invalid strategy")
 		}
@@ -402,6 +651,66 @@ func TestStashPush_NothingToStash(t *testing.T) {
 	}
 }
 
+func TestStashPushAllIncludesUntracked(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "stash-all")
+	repo.WriteFile("README.md", "changed\n")
+	repo.WriteFile("notes.txt", "untracked")
+
+	created, err := git.StashPushAll(repo.Path, "wip")
+	if err != nil || !created {
+		t.Fatalf("StashPushAll = %v, %v", created, err)
+	}
+	if clean, err := git.IsClean(repo.Path); err != nil || !clean {
+		t.Errorf("expected a clean tree including untracked files, got clean=%v (%v)", clean, err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "changed")
+	repo.WriteFile("a.txt", "a")
+	repo.WriteFile("b.txt", "b")
+	repo.AddFile("a.txt")
+	repo.AddFile("b.txt")
+	repo.Commit("add files")
+
+	repo.WriteFile("README.md", "modified\n")
+	repo.WriteFile("new file.txt", "untracked")
+	repo.Git("mv", "a.txt", "renamed.txt")
+	repo.Git("rm", "-q", "b.txt")
+
+	files, err := git.ChangedFiles(repo.Path)
+	if err != nil {
+		t.Fatalf("ChangedFiles error: %v", err)
+	}
+	sort.Strings(files)
+	want := []string{"README.md", "b.txt", "new file.txt", "renamed.txt"}
+	if !slices.Equal(files, want) {
+		t.Errorf("ChangedFiles = %q, want %q", files, want)
+	}
+
+	clean := helpers.NewTestRepo(t, "unchanged")
+	if files, err := git.ChangedFiles(clean.Path); err != nil || len(files) != 0 {
+		t.Errorf("expected no changed files, got %q (%v)", files, err)
+	}
+}
+
+func TestUntrackedFiles(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "untracked")
+	repo.WriteFile(".gitignore", "*.log\n")
+	repo.WriteFile("README.md", "modified\n")
+	repo.WriteFile("todo.txt", "untracked")
+	repo.WriteFile("debug.log", "ignored")
+
+	files, err := git.UntrackedFiles(repo.Path)
+	if err != nil {
+		t.Fatalf("UntrackedFiles error: %v", err)
+	}
+	sort.Strings(files)
+	if want := []string{".gitignore", "todo.txt"}; !slices.Equal(files, want) {
+		t.Errorf("UntrackedFiles = %q, want %q", files, want)
+	}
+}
+
 func TestMergeBase(t *testing.T) {
 	repo := helpers.NewTestRepo(t, "merge-base")
 
@@ -490,6 +799,67 @@ func TestCommitsAheadBehind_NoDivergence(t *testing.T) {
 	}
 }
 
+func TestRevListCount(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "rev-list-count")
+	repo.CreateBranch("feature")
+	for _, f := range []string{"a.txt", "b.txt", "c.txt"} {
+		repo.WriteFile(f, f)
+		repo.AddFile(f)
+		repo.Commit("add " + f)
+	}
+
+	for spec, want := range map[string]int{"main..feature": 3, "feature..main": 0, "HEAD~1..HEAD": 1} {
+		got, err := git.RevListCount(repo.Path, spec)
+		if err != nil {
+			t.Fatalf("RevListCount(%s): %v", spec, err)
+		}
+		if got != want {
+			t.Errorf("RevListCount(%s) = %d, want %d", spec, got, want)
+		}
+	}
+	if _, err := git.RevListCount(repo.Path, "main..nonexistent"); err == nil {
+		t.Error("expected an error for an unknown ref")
+	}
+}
+
+func TestAheadBehindUpstream(t *testing.T) {
+	repo, bare := helpers.NewClonedRepo(t, "ahead-behind-upstream")
+
+	// Two local commits, and one pushed to the remote from elsewhere.
+	repo.WriteFile("local1.txt", "one")
+	repo.AddFile("local1.txt")
+	repo.Commit("local one")
+	repo.WriteFile("local2.txt", "two")
+	repo.AddFile("local2.txt")
+	repo.Commit("local two")
+	other := filepath.Join(t.TempDir(), "other")
+	if out, err := exec.Command("git", "clone", "-q", bare, other).CombinedOutput(); err != nil {
+		t.Fatalf("clone: %v: %s", err, out)
+	}
+	for _, args := range [][]string{
+		{"-c", "user.name=Other", "-c", "user.email=other@example.com", "commit", "-q", "--allow-empty", "-m", "remote"},
+		{"push", "-q", "origin", "main"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", other}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	repo.Git("fetch", "-q", "origin")
+
+	ahead, behind, err := git.AheadBehindUpstream(repo.Path, "main")
+	if err != nil {
+		t.Fatalf("AheadBehindUpstream: %v", err)
+	}
+	if ahead != 2 || behind != 1 {
+		t.Errorf("expected 2 ahead and 1 behind, got %d ahead and %d behind", ahead, behind)
+	}
+
+	repo.CreateBranch("feature/local")
+	if _, _, err := git.AheadBehindUpstream(repo.Path, "feature/local"); !errors.Is(err, git.ErrNoUpstream) {
+		t.Errorf("expected ErrNoUpstream for a branch never pushed, got %v", err)
+	}
+}
+
 func TestHasRemoteBranch(t *testing.T) {
 	clonePath, _ := setupRemotePair(t, "has-remote-branch")
 
@@ -553,6 +923,39 @@ func TestCommitSubject(t *testing.T) {
 	}
 }
 
+func TestCommitAuthorship(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "commit-author")
+
+	repo.CreateBranch("feature/author")
+	repo.WriteFile("author.txt", "test")
+	repo.AddFile("author.txt")
+	repo.Commit("authored")
+
+	author, err := git.CommitAuthor(repo.Path, "feature/author")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if author != "test@example.com" {
+		t.Errorf("expected author test@example.com, got %q", author)
+	}
+
+	sha, err := git.LastCommitBy(repo.Path, "feature/author", "test@example.com")
+	if err != nil || sha == "" {
+		t.Errorf("expected a commit by test@example.com, got %q, %v", sha, err)
+	}
+	if sha, _ := git.LastCommitBy(repo.Path, "feature/author", "someone+else@example.com"); sha != "" {
+		t.Errorf("expected no commit by someone+else@example.com, got %q", sha)
+	}
+
+	user, err := git.LastHeadUser(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user != "test@example.com" {
+		t.Errorf("expected last HEAD user test@example.com, got %q", user)
+	}
+}
+
 func TestMergeTree(t *testing.T) {
 	t.Run("no_conflict", func(t *testing.T) {
 		repo := helpers.NewTestRepo(t, "merge-tree-clean")
@@ -666,6 +1069,76 @@ func TestCommitAuthors_NoUniqueCommits(t *testing.T) {
 	}
 }
 
+func TestLogAndChangedTopLevelPaths(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "log-range")
+
+	repo.CreateBranch("feature/landed")
+	if err := os.MkdirAll(filepath.Join(repo.Path, "docs", "guide"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+	repo.WriteFile("docs/guide/intro.md", "intro")
+	repo.AddFile("docs/guide/intro.md")
+	repo.Commit("Add guide")
+	repo.WriteFile("README.md", "updated")
+	repo.AddFile("README.md")
+	repo.Commit("Update readme")
+	repo.Checkout("main")
+	// A commit only on main is not part of what the branch changed.
+	repo.WriteFile("main-only.txt", "main")
+	repo.AddFile("main-only.txt")
+	repo.Commit("Main only")
+
+	commits, err := git.Log(repo.Path, "main..feature/landed", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "Update readme" || commits[1].Subject != "Add guide" {
+		t.Fatalf("expected the two branch commits newest first, got %+v", commits)
+	}
+	if commits[0].Author != "Test User" || len(commits[0].SHA) < 40 {
+		t.Errorf("expected author and full hash, got %+v", commits[0])
+	}
+
+	if limited, _ := git.Log(repo.Path, "main..feature/landed", 1); len(limited) != 1 {
+		t.Errorf("expected max to limit the log, got %+v", limited)
+	}
+
+	paths, err := git.ChangedTopLevelPaths(repo.Path, "main", "feature/landed")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"README.md", "docs/"}; !slices.Equal(paths, want) {
+		t.Errorf("expected %v, got %v", want, paths)
+	}
+}
+
+func TestMergesSince(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "merges-since")
+	start := time.Now().Add(-time.Minute)
+
+	repo.CreateBranch("feature/one")
+	repo.WriteFile("one.txt", "one")
+	repo.AddFile("one.txt")
+	repo.Commit("Add one")
+	repo.Checkout("main")
+	repo.Merge("feature/one")
+	repo.WriteFile("direct.txt", "direct")
+	repo.AddFile("direct.txt")
+	repo.Commit("Direct commit")
+
+	merges, err := git.MergesSince(repo.Path, "main", start, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(merges) != 1 || merges[0].Subject != "Merge branch 'feature/one'" {
+		t.Errorf("expected only the merge commit, got %+v", merges)
+	}
+
+	if later, _ := git.MergesSince(repo.Path, "main", time.Now().Add(time.Hour), 0); len(later) != 0 {
+		t.Errorf("expected no merges after since, got %+v", later)
+	}
+}
+
 func TestConflictState(t *testing.T) {
 	t.Run("clean_repo", func(t *testing.T) {
 		repo := helpers.NewTestRepo(t, "conflict-clean")
@@ -731,6 +1204,85 @@ func TestConflictState(t *testing.T) {
 			t.Errorf("expected %q (rebase takes priority), got %q", "rebase", got)
 		}
 	})
+
+	t.Run("mid_revert", func(t *testing.T) {
+		repo := helpers.NewTestRepo(t, "conflict-revert")
+		gitDir := filepath.Join(repo.Path, ".git")
+		if err := os.WriteFile(filepath.Join(gitDir, "REVERT_HEAD"), []byte("abc123\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if got := git.ConflictState(repo.Path); got != "revert" {
+			t.Errorf("expected %q, got %q", "revert", got)
+		}
+	})
+
+	t.Run("mid_bisect", func(t *testing.T) {
+		repo := helpers.NewTestRepo(t, "conflict-bisect")
+		repo.Git("bisect", "start")
+		if got := git.ConflictState(repo.Path); got != "bisect" {
+			t.Errorf("expected %q, got %q", "bisect", got)
+		}
+	})
+}
+
+func TestTagsAtAndBranchesContaining(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "detached")
+	repo.Git("tag", "v1.0")
+	repo.CreateBranch("feature")
+	repo.WriteFile("feature.txt", "feature")
+	repo.AddFile("feature.txt")
+	repo.Commit("Add feature")
+
+	tags, err := git.TagsAt(repo.Path, "main")
+	if err != nil || !slices.Equal(tags, []string{"v1.0"}) {
+		t.Errorf("TagsAt(main) = %v, %v; want [v1.0]", tags, err)
+	}
+	if tags, _ := git.TagsAt(repo.Path, "feature"); len(tags) != 0 {
+		t.Errorf("TagsAt(feature) = %v, want none", tags)
+	}
+
+	branches, err := git.BranchesContaining(repo.Path, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(branches)
+	if want := []string{"feature", "main", "origin/main"}; !slices.Equal(branches, want) {
+		t.Errorf("BranchesContaining(main) = %v, want %v", branches, want)
+	}
+	if branches, _ := git.BranchesContaining(repo.Path, "feature"); !slices.Equal(branches, []string{"feature"}) {
+		t.Errorf("BranchesContaining(feature) = %v, want [feature]", branches)
+	}
+}
+
+func TestIsReachable(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "reachable")
+	// "pushed" is on origin; "merged" is in main; "unpushed" is nowhere else.
+	repo.CreateBranch("pushed")
+	repo.WriteFile("p.txt", "p")
+	repo.AddFile("p.txt")
+	repo.Commit("pushed work")
+	repo.Git("push", "origin", "pushed")
+	repo.Checkout("main")
+	repo.CreateBranch("merged")
+	repo.Checkout("main")
+	repo.CreateBranch("unpushed")
+	repo.WriteFile("u.txt", "u")
+	repo.AddFile("u.txt")
+	repo.Commit("unpushed work")
+	repo.Checkout("main")
+
+	for branch, want := range map[string]bool{"pushed": true, "merged": true, "unpushed": false} {
+		got, err := git.IsReachable(repo.Path, "refs/heads/"+branch, "main")
+		if err != nil {
+			t.Fatalf("IsReachable(%s): %v", branch, err)
+		}
+		if got != want {
+			t.Errorf("IsReachable(%s) = %v, want %v", branch, got, want)
+		}
+	}
+	if ok, _ := git.IsAncestor(repo.Path, "pushed", "main"); ok {
+		t.Error("expected pushed not to be an ancestor of main")
+	}
 }
 
 func TestHasUpstream(t *testing.T) {
@@ -753,3 +1305,187 @@ func TestHasUpstream_WithRemote(t *testing.T) {
 		t.Error("expected main to have an upstream in a clone")
 	}
 }
+
+func TestUnpushedCount(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "unpushed")
+
+	count, err := git.UnpushedCount(repo.Path, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected 0 unpushed commits in fresh clone, got %d", count)
+	}
+
+	repo.WriteFile("local.txt", "local")
+	repo.AddFile("local.txt")
+	repo.Commit("local only")
+
+	count, err = git.UnpushedCount(repo.Path, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 unpushed commit, got %d", count)
+	}
+}
+
+func TestStashCount(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "stash-count")
+
+	count, err := git.StashCount(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("expected empty stash, got %d", count)
+	}
+
+	for i := range 2 {
+		repo.WriteFile("README.md", fmt.Sprintf("change %d\n", i))
+		repo.Git("stash", "push", "-m", "wip")
+	}
+
+	count, err = git.StashCount(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 stash entries, got %d", count)
+	}
+}
+
+func TestUnusedBranchName(t *testing.T) {
+	taken := map[string]bool{"wip/a": true, "wip/a-2": true}
+	exists := func(branch string) bool { return taken[branch] }
+	if got := git.UnusedBranchName("wip/a", exists); got != "wip/a-3" {
+		t.Errorf("got %q, want wip/a-3", got)
+	}
+	if got := git.UnusedBranchName("wip/b", exists); got != "wip/b" {
+		t.Errorf("got %q, want wip/b", got)
+	}
+}
+
+func TestCreateBranchCommitAll(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "commit-all")
+	repo.WriteFile("README.md", "modified\n")
+	repo.WriteFile("new.txt", "untracked\n")
+
+	if git.BranchExists(repo.Path, "wip/test") {
+		t.Fatal("branch should not exist yet")
+	}
+	if err := git.CreateBranch(repo.Path, "wip/test"); err != nil {
+		t.Fatalf("CreateBranch: %v", err)
+	}
+	if err := git.CommitAll(repo.Path, "wip"); err != nil {
+		t.Fatalf("CommitAll: %v", err)
+	}
+	if !git.BranchExists(repo.Path, "wip/test") {
+		t.Error("expected branch to exist")
+	}
+
+	clean, err := git.IsClean(repo.Path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !clean {
+		t.Error("expected clean working tree after CommitAll")
+	}
+
+	repo.Checkout("main")
+	clean, _ = git.IsClean(repo.Path)
+	if !clean {
+		t.Error("expected main to be clean after the changes moved to wip/test")
+	}
+}
+
+func TestTags(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "tags")
+	repo.Git("tag", "v1.0")
+	repo.Git("push", "origin", "v1.0")
+
+	// Annotated tag on a commit that only lives on a deleted branch.
+	repo.CreateBranch("throwaway")
+	repo.WriteFile("x.txt", "x")
+	repo.AddFile("x.txt")
+	repo.Commit("throwaway work")
+	repo.Git("tag", "-a", "archive/throwaway", "-m", "archived")
+	repo.Checkout("main")
+	repo.Git("branch", "-D", "throwaway")
+
+	tags, err := git.ListTags(repo.Path)
+	if err != nil {
+		t.Fatalf("ListTags: %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("expected 2 tags, got %+v", tags)
+	}
+	head, _ := git.RevParse(repo.Path, "HEAD")
+	for _, tag := range tags {
+		if tag.Date.IsZero() {
+			t.Errorf("%s: expected a date", tag.Name)
+		}
+		if tag.Name == "v1.0" && tag.Commit != head {
+			t.Errorf("v1.0: expected commit %s, got %s", head, tag.Commit)
+		}
+		if tag.Name == "archive/throwaway" && tag.Commit == head {
+			t.Error("annotated tag should resolve to its own commit")
+		}
+	}
+
+	remote, err := git.RemoteTags(repo.Path, "origin")
+	if err != nil {
+		t.Fatalf("RemoteTags: %v", err)
+	}
+	if !remote["v1.0"] || remote["archive/throwaway"] {
+		t.Errorf("unexpected remote tags: %v", remote)
+	}
+
+	unreachable, err := git.UnreachableTags(repo.Path)
+	if err != nil {
+		t.Fatalf("UnreachableTags: %v", err)
+	}
+	if len(unreachable) != 1 || unreachable[0] != "archive/throwaway" {
+		t.Errorf("expected only archive/throwaway unreachable, got %v", unreachable)
+	}
+
+	if err := git.DeleteRemoteTag(repo.Path, "origin", "v1.0"); err != nil {
+		t.Fatalf("DeleteRemoteTag: %v", err)
+	}
+	if err := git.DeleteTag(repo.Path, "v1.0"); err != nil {
+		t.Fatalf("DeleteTag: %v", err)
+	}
+	remote, _ = git.RemoteTags(repo.Path, "origin")
+	tags, _ = git.ListTags(repo.Path)
+	if len(remote) != 0 || len(tags) != 1 {
+		t.Errorf("expected v1.0 gone locally and remotely, got local=%+v remote=%v", tags, remote)
+	}
+}
+
+func TestLargeBlobs(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "large-blobs")
+	repo.WriteFile("big.bin", strings.Repeat("x", 64*1024))
+	repo.AddFile("big.bin")
+	repo.Commit("add big file")
+	repo.Git("rm", "-q", "big.bin")
+	repo.Commit("remove big file")
+
+	blobs, err := git.LargeBlobs(repo.Path, 32*1024)
+	if err != nil {
+		t.Fatalf("LargeBlobs: %v", err)
+	}
+	if len(blobs) != 1 {
+		t.Fatalf("expected 1 large blob, got %+v", blobs)
+	}
+	if blobs[0].Path != "big.bin" || blobs[0].Size != 64*1024 || blobs[0].DiskSize <= 0 {
+		t.Errorf("unexpected blob: %+v", blobs[0])
+	}
+
+	blobs, err = git.LargeBlobs(repo.Path, 1024*1024)
+	if err != nil {
+		t.Fatalf("LargeBlobs: %v", err)
+	}
+	if len(blobs) != 0 {
+		t.Errorf("expected no blobs above 1 MB, got %+v", blobs)
+	}
+}
@@ -0,0 +1,86 @@
+package git
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// isolated is set by SetIsolation.
+var isolated atomic.Bool
+
+// SetIsolation makes every git command run by this package ignore the
+// user's hooks and skip commit and tag signing, so that a hook that blocks,
+// prompts, or rewrites an operation, or a signing key that asks for a
+// passphrase, cannot stall or change automated cleanup. Filters such as
+// Git LFS still apply, and PushBranch keeps the pre-push hook. Isolation
+// is off until it is enabled.
+func SetIsolation(on bool) {
+	isolated.Store(on)
+}
+
+// isolationArgs returns the options gitCommand puts before the git
+// subcommand while isolation is on.
+func isolationArgs() []string {
+	if !isolated.Load() {
+		return nil
+	}
+	return []string{
+		"-c", "core.hooksPath=" + os.DevNull,
+		"-c", "commit.gpgSign=false",
+		"-c", "tag.gpgSign=false",
+	}
+}
+
+// gitCommand returns a git command for args, isolated when SetIsolation is
+// on, in the environment returned by gitEnv.
+func gitCommand(args ...string) *exec.Cmd {
+	return gitCommandContext(context.Background(), args...)
+}
+
+// gitCommandContext is gitCommand, killing git when ctx is done. Output
+// stops being waited for shortly after, in case a helper git started,
+// such as ssh, still holds it open.
+func gitCommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	return unisolatedCommandContext(ctx, append(isolationArgs(), args...)...)
+}
+
+// unisolatedCommandContext is gitCommandContext without isolation, for
+// commands that publish work, whose hooks guard what leaves the machine.
+func unisolatedCommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	// #nosec G204 - all git args are controlled by internal callers
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = gitEnv(os.Environ())
+	cmd.WaitDelay = time.Second
+	return cmd
+}
+
+// promptEnv names the variables that let git or ssh ask for credentials
+// in a window or on the terminal. katazuke runs git in parallel and often
+// without a terminal, so such a prompt would hang the run.
+var promptEnv = map[string]bool{
+	"GIT_ASKPASS":         true,
+	"SSH_ASKPASS":         true,
+	"SSH_ASKPASS_REQUIRE": true,
+}
+
+// gitEnv returns environ with the variables in promptEnv and the locale
+// settings removed, running git in the C locale so the messages
+// commandError classifies are not translated, and with terminal prompts
+// disabled so a remote that needs credentials fails with ErrAuthFailed
+// instead of waiting for input. Credential helpers and SSH agents still
+// work.
+func gitEnv(environ []string) []string {
+	env := make([]string, 0, len(environ)+2)
+	for _, kv := range environ {
+		name, _, _ := strings.Cut(kv, "=")
+		if promptEnv[name] || name == "LANG" || name == "LANGUAGE" || strings.HasPrefix(name, "LC_") || name == "GIT_TERMINAL_PROMPT" {
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "LC_ALL=C", "GIT_TERMINAL_PROMPT=0")
+}
@@ -0,0 +1,56 @@
+package git_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/agrahamlincoln/katazuke/pkg/git"
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// writeHook installs an executable hook that fails.
+func writeHook(t *testing.T, repoPath, name string) {
+	t.Helper()
+	path := filepath.Join(repoPath, ".git", "hooks", name)
+	// #nosec G306 - hooks must be executable
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 1\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsolationBypassesHooks(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "isolation")
+	repo.CreateBranch("doomed")
+	repo.Checkout("main")
+	writeHook(t, repo.Path, "pre-commit")
+	writeHook(t, repo.Path, "reference-transaction")
+	repo.WriteFile("wip.txt", "work in progress")
+
+	if err := git.CommitAll(repo.Path, "wip"); err == nil {
+		t.Fatal("expected the pre-commit hook to block the commit without isolation")
+	}
+
+	git.SetIsolation(true)
+	t.Cleanup(func() { git.SetIsolation(false) })
+
+	if err := git.CommitAll(repo.Path, "wip"); err != nil {
+		t.Errorf("expected the commit to bypass hooks, got %v", err)
+	}
+	if err := git.DeleteLocalBranch(repo.Path, "doomed", true); err != nil {
+		t.Errorf("expected branch deletion to bypass hooks, got %v", err)
+	}
+}
+
+func TestIsolationKeepsPrePushHook(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "isolation-push")
+	repo.CreateBranch("wip/parked")
+	writeHook(t, repo.Path, "pre-push")
+
+	git.SetIsolation(true)
+	t.Cleanup(func() { git.SetIsolation(false) })
+
+	if err := git.PushBranch(repo.Path, "origin", "wip/parked"); err == nil {
+		t.Error("expected the pre-push hook to block the push with isolation on")
+	}
+}
@@ -0,0 +1,22 @@
+package git
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLFSPruneOIDs(t *testing.T) {
+	oid1 := "4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393"
+	oid2 := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	out := "prune: 5 local objects, 3 retained, done.\n" +
+		"prune: 2 files would be pruned (1.5 MB)\n" +
+		" * " + oid1 + " (1.2 MB)\n" +
+		" * " + oid2 + " (300 KB)\n" +
+		" * not-an-oid (1 B)\n"
+
+	got := parseLFSPruneOIDs(out)
+	want := []string{oid1, oid2}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
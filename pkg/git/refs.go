@@ -0,0 +1,384 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file holds fast paths for the hot read-only queries. They read HEAD,
+// loose ref files, packed-refs, and loose objects straight from the .git
+// directory instead of spawning git, which matters when a command touches
+// every branch of hundreds of repos. Each fast path reports ok=false for
+// anything it does not fully understand -- reftable storage, revision
+// expressions, packed objects, annotated tags, ambiguous names -- and the
+// caller then falls back to the git CLI, so results never differ.
+
+// refStore locates the refs of one working tree. gitDir holds HEAD and
+// per-worktree state; commonDir holds the shared refs and objects. They
+// differ only for linked worktrees.
+type refStore struct {
+	gitDir    string
+	commonDir string
+	packed    map[string]string // loaded lazily from packed-refs
+}
+
+// openRefStore returns the ref store for the working tree rooted at
+// repoPath. ok is false when repoPath is not the top of a working tree or
+// the repository uses a ref backend other than files.
+func openRefStore(repoPath string) (*refStore, bool) {
+	dotGit := filepath.Join(repoPath, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return nil, false
+	}
+
+	gitDir := dotGit
+	if !info.IsDir() {
+		data, err := os.ReadFile(filepath.Clean(dotGit))
+		if err != nil {
+			return nil, false
+		}
+		target, found := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir: ")
+		if !found {
+			return nil, false
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(repoPath, target)
+		}
+		gitDir = target
+	}
+
+	commonDir := gitDir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir = strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(commonDir, "reftable")); err == nil {
+		return nil, false
+	}
+	if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+		return nil, false
+	}
+	return &refStore{gitDir: gitDir, commonDir: commonDir}, true
+}
+
+// dirFor returns the directory a ref lives in: pseudo-refs like HEAD and
+// per-worktree refs are in gitDir, everything else in commonDir.
+func (s *refStore) dirFor(name string) string {
+	if !strings.HasPrefix(name, "refs/") ||
+		strings.HasPrefix(name, "refs/worktree/") ||
+		strings.HasPrefix(name, "refs/bisect/") ||
+		strings.HasPrefix(name, "refs/rewritten/") {
+		return s.gitDir
+	}
+	return s.commonDir
+}
+
+// readLoose returns the trimmed contents of a loose ref file. found is
+// false when no such file exists (a directory of the same name does not
+// count).
+func (s *refStore) readLoose(name string) (value string, found bool, ok bool) {
+	path := filepath.Join(s.dirFor(name), filepath.FromSlash(name))
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return "", false, true
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", false, false
+	}
+	return strings.TrimSpace(string(data)), true, true
+}
+
+// packedRefs returns the packed refs, reading packed-refs on first use.
+func (s *refStore) packedRefs() map[string]string {
+	if s.packed != nil {
+		return s.packed
+	}
+	s.packed = make(map[string]string)
+	data, err := os.ReadFile(filepath.Join(s.commonDir, "packed-refs"))
+	if err != nil {
+		return s.packed
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		oid, name, found := strings.Cut(line, " ")
+		if found && isObjectID(oid) {
+			s.packed[name] = oid
+		}
+	}
+	return s.packed
+}
+
+// exists reports whether the fully qualified ref name exists.
+func (s *refStore) exists(name string) (bool, bool) {
+	_, found, ok := s.readLoose(name)
+	if !ok {
+		return false, false
+	}
+	if found {
+		return true, true
+	}
+	_, packed := s.packedRefs()[name]
+	return packed, true
+}
+
+// resolve follows a fully qualified ref to an object ID. found is false
+// when the ref does not exist.
+func (s *refStore) resolve(name string) (oid string, found bool, ok bool) {
+	for range 5 {
+		value, loose, ok := s.readLoose(name)
+		if !ok {
+			return "", false, false
+		}
+		if !loose {
+			oid, packed := s.packedRefs()[name]
+			return oid, packed, true
+		}
+		if target, symbolic := strings.CutPrefix(value, "ref: "); symbolic {
+			name = target
+			continue
+		}
+		if !isObjectID(value) {
+			return "", false, false
+		}
+		return value, true, true
+	}
+	return "", false, false // symref chain too deep
+}
+
+// list returns the names below prefix (e.g. "refs/heads/") with the prefix
+// removed, sorted as git sorts refnames.
+func (s *refStore) list(prefix string) ([]string, bool) {
+	names := make(map[string]bool)
+	for name := range s.packedRefs() {
+		if short, found := strings.CutPrefix(name, prefix); found {
+			names[short] = true
+		}
+	}
+
+	root := filepath.Join(s.dirFor(prefix), filepath.FromSlash(prefix))
+	ok := true
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root && os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		value := strings.TrimSpace(string(data))
+		if !isObjectID(value) && !strings.HasPrefix(value, "ref: ") {
+			ok = false // broken ref: let git report it
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		names[filepath.ToSlash(rel)] = true
+		return nil
+	})
+	if err != nil || !ok {
+		return nil, false
+	}
+
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result, true
+}
+
+// fastCurrentBranch reads the branch HEAD points to. A detached HEAD yields
+// "", matching git branch --show-current.
+func fastCurrentBranch(repoPath string) (string, bool) {
+	s, ok := openRefStore(repoPath)
+	if !ok {
+		return "", false
+	}
+	head, found, ok := s.readLoose("HEAD")
+	if !ok || !found {
+		return "", false
+	}
+	if isObjectID(head) {
+		return "", true
+	}
+	branch, found := strings.CutPrefix(head, "ref: refs/heads/")
+	return branch, found
+}
+
+// fastListBranches lists local branches. Branch names that git would
+// abbreviate differently because they collide with a tag, a remote-tracking
+// ref, or a top-level ref are left to the CLI.
+func fastListBranches(repoPath string) ([]string, bool) {
+	s, ok := openRefStore(repoPath)
+	if !ok {
+		return nil, false
+	}
+	branches, ok := s.list("refs/heads/")
+	if !ok {
+		return nil, false
+	}
+	for _, b := range branches {
+		for _, other := range []string{"refs/" + b, "refs/tags/" + b, "refs/remotes/" + b, "refs/remotes/" + b + "/HEAD"} {
+			exists, ok := s.exists(other)
+			if !ok || exists {
+				return nil, false
+			}
+		}
+	}
+	return branches, true
+}
+
+// fastHasRemoteBranch checks for a remote-tracking ref. Names containing
+// glob characters are left to the CLI, which treats them as patterns.
+func fastHasRemoteBranch(repoPath, remote, branch string) (bool, bool) {
+	if strings.ContainsAny(remote+branch, "*?[\\") {
+		return false, false
+	}
+	s, ok := openRefStore(repoPath)
+	if !ok {
+		return false, false
+	}
+	return s.exists("refs/remotes/" + remote + "/" + branch)
+}
+
+// fastCommitDate returns the author date of the commit rev names, when rev
+// is a plain ref name or object ID and the commit is a loose object.
+func fastCommitDate(repoPath, rev string) (time.Time, bool) {
+	s, ok := openRefStore(repoPath)
+	if !ok {
+		return time.Time{}, false
+	}
+	oid, ok := s.dwim(rev)
+	if !ok {
+		return time.Time{}, false
+	}
+	return readAuthorDate(s.commonDir, oid)
+}
+
+// dwim resolves a short ref name the way git rev-parse does, trying the
+// same prefixes in the same order.
+func (s *refStore) dwim(rev string) (string, bool) {
+	if isObjectID(rev) {
+		return rev, true
+	}
+	if rev == "" || strings.ContainsAny(rev, "~^:@{}*?[\\ ") || strings.Contains(rev, "..") {
+		return "", false
+	}
+	if rev != "HEAD" && strings.Trim(rev, "ABCDEFGHIJKLMNOPQRSTUVWXYZ_") == "" {
+		return "", false // FETCH_HEAD and friends have their own formats
+	}
+	candidates := []string{"refs/" + rev, "refs/tags/" + rev, "refs/heads/" + rev, "refs/remotes/" + rev, "refs/remotes/" + rev + "/HEAD"}
+	if rev == "HEAD" || strings.HasPrefix(rev, "refs/") {
+		candidates = append([]string{rev}, candidates...)
+	}
+	for _, name := range candidates {
+		oid, found, ok := s.resolve(name)
+		if !ok {
+			return "", false
+		}
+		if found {
+			return oid, true
+		}
+	}
+	return "", false
+}
+
+// maxCommitHeader bounds how much of a commit object is inflated while
+// looking for its author line.
+const maxCommitHeader = 64 << 10
+
+// readAuthorDate reads the author timestamp from a loose commit object.
+// Packed objects and non-commits (such as annotated tags) report ok=false.
+func readAuthorDate(commonDir, oid string) (time.Time, bool) {
+	path := filepath.Join(commonDir, "objects", oid[:2], oid[2:])
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = f.Close() }()
+
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer func() { _ = zr.Close() }()
+
+	r := bufio.NewReader(io.LimitReader(zr, maxCommitHeader))
+	header, err := r.ReadString(0)
+	if err != nil || !strings.HasPrefix(header, "commit ") {
+		return time.Time{}, false
+	}
+	for {
+		line, err := r.ReadBytes('\n')
+		if rest, found := bytes.CutPrefix(line, []byte("author ")); found {
+			return parseSignatureTime(string(bytes.TrimSpace(rest)))
+		}
+		if err != nil || len(bytes.TrimSpace(line)) == 0 {
+			return time.Time{}, false // end of headers without an author
+		}
+	}
+}
+
+// parseSignatureTime parses the "<unix> <+hhmm>" suffix of an author or
+// committer line into a time in the recorded offset.
+func parseSignatureTime(sig string) (time.Time, bool) {
+	end := strings.LastIndexByte(sig, '>')
+	if end < 0 {
+		return time.Time{}, false
+	}
+	fields := strings.Fields(sig[end+1:])
+	if len(fields) != 2 || len(fields[1]) != 5 {
+		return time.Time{}, false
+	}
+	unix, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	hours, err1 := strconv.Atoi(fields[1][1:3])
+	minutes, err2 := strconv.Atoi(fields[1][3:5])
+	if err1 != nil || err2 != nil {
+		return time.Time{}, false
+	}
+	offset := hours*3600 + minutes*60
+	if fields[1][0] == '-' {
+		offset = -offset
+	}
+	return time.Unix(unix, 0).In(time.FixedZone("", offset)), true
+}
+
+// isObjectID reports whether s is a full SHA-1 or SHA-256 object ID.
+func isObjectID(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package git
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/agrahamlincoln/katazuke/test/helpers"
+)
+
+// cliBranches lists branches through the CLI, bypassing the fast path.
+func cliBranches(t *testing.T, repoPath string) []string {
+	t.Helper()
+	out, err := run(repoPath, "branch", "--format=%(refname:short)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filterBranches(splitNonEmpty(out))
+}
+
+// cliCommitDate reads a commit date through the CLI, bypassing the fast path.
+func cliCommitDate(t *testing.T, repoPath, rev string) time.Time {
+	t.Helper()
+	out, err := run(repoPath, "log", "-1", "--format=%aI", rev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	date, err := time.Parse(time.RFC3339, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func TestFastPathsMatchCLI(t *testing.T) {
+	repo, _ := helpers.NewClonedRepo(t, "fast")
+	repo.CreateBranch("feature/a")
+	repo.WriteFile("a.txt", "a\n")
+	repo.AddFile("a.txt")
+	repo.CommitWithDate("on feature", time.Date(2024, 3, 1, 12, 0, 0, 0, time.FixedZone("", -5*3600)))
+	repo.Push("origin", "feature/a")
+	repo.Checkout("main")
+	repo.CreateBranch("zzz")
+	repo.Checkout("main")
+
+	check := func(stage string) {
+		t.Helper()
+		branches, ok := fastListBranches(repo.Path)
+		if want := cliBranches(t, repo.Path); !ok || !reflect.DeepEqual(branches, want) {
+			t.Errorf("%s: ListBranches fast path = %v (ok=%v), want %v", stage, branches, ok, want)
+		}
+		if branch, ok := fastCurrentBranch(repo.Path); !ok || branch != "main" {
+			t.Errorf("%s: CurrentBranch fast path = %q (ok=%v), want main", stage, branch, ok)
+		}
+		if exists, ok := fastHasRemoteBranch(repo.Path, "origin", "feature/a"); !ok || !exists {
+			t.Errorf("%s: expected origin/feature/a to exist (ok=%v)", stage, ok)
+		}
+		if exists, ok := fastHasRemoteBranch(repo.Path, "origin", "zzz"); !ok || exists {
+			t.Errorf("%s: expected origin/zzz not to exist (ok=%v)", stage, ok)
+		}
+	}
+
+	check("loose refs")
+	date, ok := fastCommitDate(repo.Path, "feature/a")
+	if want := cliCommitDate(t, repo.Path, "feature/a"); !ok || !date.Equal(want) {
+		t.Errorf("CommitDate fast path = %v (ok=%v), want %v", date, ok, want)
+	}
+	if _, offset := date.Zone(); offset != -5*3600 {
+		t.Errorf("expected the author's UTC offset to be kept, got %d", offset)
+	}
+
+	repo.Git("pack-refs", "--all")
+	check("packed refs")
+
+	// Packed objects are not parsed: the fast path declines and the public
+	// function falls back to the CLI.
+	repo.Git("gc", "--quiet")
+	if _, ok := fastCommitDate(repo.Path, "feature/a"); ok {
+		t.Error("expected fast path to decline packed objects")
+	}
+	got, err := CommitDate(repo.Path, "feature/a")
+	if err != nil || !got.Equal(cliCommitDate(t, repo.Path, "feature/a")) {
+		t.Errorf("CommitDate fallback = %v, %v", got, err)
+	}
+}
+
+func TestFastPathsDecline(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "decline")
+
+	// A branch named like a tag is abbreviated as heads/<name> by git.
+	repo.CreateBranch("v1")
+	repo.Checkout("main")
+	repo.Git("tag", "v1")
+	if _, ok := fastListBranches(repo.Path); ok {
+		t.Error("expected fast path to decline an ambiguous branch name")
+	}
+	if got, err := ListBranches(repo.Path); err != nil || !reflect.DeepEqual(got, cliBranches(t, repo.Path)) {
+		t.Errorf("ListBranches fallback = %v, %v", got, err)
+	}
+
+	for _, rev := range []string{"main~1", "HEAD^", "FETCH_HEAD", "main..v1"} {
+		if _, ok := fastCommitDate(repo.Path, rev); ok {
+			t.Errorf("expected fast path to decline %q", rev)
+		}
+	}
+	if _, ok := fastHasRemoteBranch(repo.Path, "origin", "feat*"); ok {
+		t.Error("expected fast path to decline a glob")
+	}
+
+	// A subdirectory is inside the repo but not its top level.
+	if _, ok := fastCurrentBranch(filepath.Join(repo.Path, "sub")); ok {
+		t.Error("expected fast path to decline a path below the top level")
+	}
+}
+
+func TestFastPathsWorktreeAndDetached(t *testing.T) {
+	repo := helpers.NewTestRepo(t, "worktree")
+	wt := filepath.Join(t.TempDir(), "wt")
+	repo.Git("worktree", "add", "-q", "-b", "side", wt)
+
+	if branch, ok := fastCurrentBranch(wt); !ok || branch != "side" {
+		t.Errorf("worktree CurrentBranch fast path = %q (ok=%v), want side", branch, ok)
+	}
+	if branches, ok := fastListBranches(wt); !ok || strings.Join(branches, ",") != "main,side" {
+		t.Errorf("worktree ListBranches fast path = %v (ok=%v)", branches, ok)
+	}
+
+	repo.DetachHead()
+	if branch, ok := fastCurrentBranch(repo.Path); !ok || branch != "" {
+		t.Errorf("detached CurrentBranch fast path = %q (ok=%v), want empty", branch, ok)
+	}
+	date, ok := fastCommitDate(repo.Path, "HEAD")
+	if want := cliCommitDate(t, repo.Path, "HEAD"); !ok || !date.Equal(want) {
+		t.Errorf("HEAD CommitDate fast path = %v (ok=%v), want %v", date, ok, want)
+	}
+}
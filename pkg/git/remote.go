@@ -0,0 +1,64 @@
+package git
+
+import "sync/atomic"
+
+// preferredRemote is the remote set by SetPreferredRemote, "" for none.
+var preferredRemote atomic.Value
+
+// SetPreferredRemote makes PrimaryRemote pick name in every repository that
+// has a remote of that name, e.g. the primary_remote config setting. An
+// empty name restores the default order.
+func SetPreferredRemote(name string) {
+	preferredRemote.Store(name)
+}
+
+// Remotes returns the names of the repository's remotes.
+func Remotes(repoPath string) ([]string, error) {
+	out, err := run(repoPath, "remote")
+	if err != nil {
+		return nil, err
+	}
+	return splitNonEmpty(out), nil
+}
+
+// PrimaryRemote returns the remote that holds a repository's default
+// branch: the remote set with SetPreferredRemote, "upstream" in forks,
+// "origin", or the only remote. It returns "" when the repository has no
+// remotes, or several without an obvious choice.
+func PrimaryRemote(repoPath string) string {
+	remotes, err := Remotes(repoPath)
+	if err != nil {
+		return ""
+	}
+	preferred, _ := preferredRemote.Load().(string)
+	return choosePrimaryRemote(remotes, preferred)
+}
+
+// choosePrimaryRemote applies the PrimaryRemote order to remotes.
+func choosePrimaryRemote(remotes []string, preferred string) string {
+	for _, want := range []string{preferred, "upstream", "origin"} {
+		for _, r := range remotes {
+			if want != "" && r == want {
+				return r
+			}
+		}
+	}
+	if len(remotes) == 1 {
+		return remotes[0]
+	}
+	return ""
+}
+
+// branchRemote returns the remote that has a remote-tracking branch named
+// like a local branch, given the branch's upstream remote and the
+// repository's primary remote, or "" when none does. A branch's own
+// upstream wins, then origin, which is where a fork's branches are pushed,
+// then the primary remote.
+func branchRemote(refs map[string]bool, branch, upstreamRemote, primary string) string {
+	for _, r := range []string{upstreamRemote, "origin", primary} {
+		if r != "" && refs["refs/remotes/"+r+"/"+branch] {
+			return r
+		}
+	}
+	return ""
+}
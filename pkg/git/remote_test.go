@@ -0,0 +1,49 @@
+package git
+
+import "testing"
+
+func TestChoosePrimaryRemote(t *testing.T) {
+	tests := []struct {
+		name      string
+		remotes   []string
+		preferred string
+		want      string
+	}{
+		{"origin only", []string{"origin"}, "", "origin"},
+		{"fork prefers upstream", []string{"origin", "upstream"}, "", "upstream"},
+		{"configured remote wins", []string{"github", "origin", "upstream"}, "github", "github"},
+		{"configured remote missing", []string{"origin", "upstream"}, "github", "upstream"},
+		{"only remote", []string{"gitlab"}, "", "gitlab"},
+		{"ambiguous", []string{"gitlab", "github"}, "", ""},
+		{"no remotes", nil, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := choosePrimaryRemote(tt.remotes, tt.preferred); got != tt.want {
+				t.Errorf("choosePrimaryRemote(%v, %q) = %q, want %q", tt.remotes, tt.preferred, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBranchRemote(t *testing.T) {
+	refs := map[string]bool{
+		"refs/remotes/origin/feature":   true,
+		"refs/remotes/upstream/feature": true,
+		"refs/remotes/upstream/shared":  true,
+		"refs/remotes/mirror/feature":   true,
+	}
+	tests := []struct {
+		branch, upstreamRemote, primary, want string
+	}{
+		{"feature", "mirror", "upstream", "mirror"},
+		{"feature", "", "upstream", "origin"},
+		{"shared", "", "upstream", "upstream"},
+		{"local", "", "upstream", ""},
+	}
+	for _, tt := range tests {
+		if got := branchRemote(refs, tt.branch, tt.upstreamRemote, tt.primary); got != tt.want {
+			t.Errorf("branchRemote(%q, %q, %q) = %q, want %q", tt.branch, tt.upstreamRemote, tt.primary, got, tt.want)
+		}
+	}
+}
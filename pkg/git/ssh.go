@@ -0,0 +1,56 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sshHostnames caches SSHHostname results by alias.
+var sshHostnames sync.Map
+
+// SSHHostname returns the host name ssh connects to for alias, following
+// Host and HostName entries in ~/.ssh/config: with "Host gh-work" and
+// "HostName github.com", SSHHostname("gh-work") is "github.com". It returns
+// alias itself when ssh is unavailable or has no entry for it. Results are
+// cached for the life of the process.
+func SSHHostname(alias string) string {
+	if alias == "" || strings.HasPrefix(alias, "-") {
+		return alias
+	}
+	if h, ok := sshHostnames.Load(alias); ok {
+		return h.(string)
+	}
+	host := lookupSSHHostname(alias)
+	sshHostnames.Store(alias, host)
+	return host
+}
+
+func lookupSSHHostname(alias string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// ssh -G prints the resolved client configuration without connecting.
+	// #nosec G204 - alias comes from a remote URL and cannot start with "-"
+	out, err := exec.CommandContext(ctx, "ssh", "-G", alias).Output()
+	if err != nil {
+		return alias
+	}
+	return parseSSHHostname(out, alias)
+}
+
+// parseSSHHostname extracts the hostname line from ssh -G output.
+func parseSSHHostname(out []byte, alias string) string {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), " ")
+		if found && strings.EqualFold(key, "hostname") && value != "" {
+			return strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+	return alias
+}
@@ -0,0 +1,19 @@
+package git
+
+import "testing"
+
+func TestParseSSHHostname(t *testing.T) {
+	out := []byte("user git\nhostname GitHub.com\nport 22\n")
+	if got := parseSSHHostname(out, "gh-work"); got != "github.com" {
+		t.Errorf("expected github.com, got %q", got)
+	}
+	if got := parseSSHHostname([]byte("user git\n"), "gh-work"); got != "gh-work" {
+		t.Errorf("expected the alias without a hostname line, got %q", got)
+	}
+}
+
+func TestSSHHostnameRejectsOptions(t *testing.T) {
+	if got := SSHHostname("-oProxyCommand=x"); got != "-oProxyCommand=x" {
+		t.Errorf("expected an option-like alias to be returned unchanged, got %q", got)
+	}
+}
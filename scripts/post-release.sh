@@ -7,11 +7,16 @@ set -euo pipefail
 # environment variables set by tatara:
 #   TATARA_RELEASE_VERSION  -- version without "v" prefix (e.g., 1.2.3)
 #   TATARA_RELEASE_TAG      -- full git tag (e.g., v1.2.3)
+# and the release signing key from the environment:
+#   MINISIGN_SECRET_KEY     -- path to the minisign secret key
+#   MINISIGN_PUBLIC_KEY     -- its public key (the base64 line of the .pub
+#                              file), built into the binaries so
+#                              self-update can verify later releases
 #
 # Steps:
 #   1. Cross-compile darwin-arm64 and linux-amd64 binaries
-#   2. Create release tarballs
-#   3. Upload tarballs to the GitHub release
+#   2. Create release tarballs and their signed checksums file
+#   3. Upload tarballs, checksums, and signature to the GitHub release
 #   4. Update the homebrew-katazuke formula
 #   5. Push homebrew-katazuke
 
@@ -28,6 +33,8 @@ HOMEBREW_REPO="$(cd "$REPO_ROOT/.." && pwd)/homebrew-katazuke"
 
 VERSION="${TATARA_RELEASE_VERSION:?TATARA_RELEASE_VERSION not set}"
 TAG="${TATARA_RELEASE_TAG:?TATARA_RELEASE_TAG not set}"
+SECRET_KEY="${MINISIGN_SECRET_KEY:?MINISIGN_SECRET_KEY not set}"
+PUBLIC_KEY="${MINISIGN_PUBLIC_KEY:?MINISIGN_PUBLIC_KEY not set}"
 
 # ---------------------------------------------------------------------------
 # Utility functions
@@ -83,6 +90,7 @@ step_build() {
     release_commit="$(git rev-parse --short HEAD)"
     release_date="$(date -u +%Y-%m-%dT%H:%M:%SZ)"
     release_ldflags="-X main.version=${VERSION} -X main.commit=${release_commit} -X main.date=${release_date}"
+    release_ldflags+=" -X github.com/agrahamlincoln/katazuke/internal/update.signingKey=${PUBLIC_KEY}"
 
     for platform in "${PLATFORMS[@]}"; do
         IFS='-' read -r goos goarch <<< "$platform"
@@ -99,16 +107,28 @@ step_tarballs() {
         tar -czf "dist/release/${BINARY_NAME}-${VERSION}-${platform}.tar.gz" \
             -C dist "${BINARY_NAME}-${platform}"
     done
+
+    # katazuke self-update refuses to install a tarball that is not
+    # listed here, or checksums without a valid signature. It verifies
+    # legacy (-l) signatures, which need no BLAKE2b.
+    local checksums="dist/release/${BINARY_NAME}-${VERSION}-checksums.txt"
+    : > "$checksums"
+    for platform in "${PLATFORMS[@]}"; do
+        local tarball="${BINARY_NAME}-${VERSION}-${platform}.tar.gz"
+        echo "$(sha256_portable "dist/release/$tarball")  $tarball" >> "$checksums"
+    done
+    minisign -S -l -s "$SECRET_KEY" -m "$checksums"
 }
 
 step_upload() {
-    echo "3. Uploading tarballs to GitHub release..."
+    echo "3. Uploading tarballs, checksums, and signature to GitHub release..."
 
     local tarballs=()
     for platform in "${PLATFORMS[@]}"; do
         tarballs+=("dist/release/${BINARY_NAME}-${VERSION}-${platform}.tar.gz")
     done
-    gh release upload "$TAG" "${tarballs[@]}"
+    gh release upload "$TAG" "${tarballs[@]}" "dist/release/${BINARY_NAME}-${VERSION}-checksums.txt" \
+        "dist/release/${BINARY_NAME}-${VERSION}-checksums.txt.minisig"
 }
 
 step_homebrew() {
@@ -156,7 +176,7 @@ main() {
 
     echo ""
     echo "Homebrew release complete."
-    echo "  - Binary tarballs uploaded to release"
+    echo "  - Binary tarballs, checksums, and signature uploaded to release"
     echo "  - Homebrew formula updated and pushed"
     echo ""
 }
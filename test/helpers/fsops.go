@@ -0,0 +1,72 @@
+package helpers
+
+import (
+	"sync"
+
+	"github.com/agrahamlincoln/katazuke/internal/fsops"
+)
+
+// FSOpKind identifies an operation recorded by an FSRecorder.
+type FSOpKind string
+
+// Recorded operation kinds.
+const (
+	FSRemove    FSOpKind = "remove"
+	FSMove      FSOpKind = "move"
+	FSEnsureDir FSOpKind = "ensure_dir"
+)
+
+// FSOp is a single operation captured by an FSRecorder.
+type FSOp struct {
+	Kind FSOpKind
+	Path string
+	Dest string // only set for FSMove
+}
+
+// FSRecorder implements fsops.FileOps by recording operations without
+// performing them.
+type FSRecorder struct {
+	mu  sync.Mutex
+	ops []FSOp
+	// Errors makes operations on the given paths fail, for testing error
+	// handling. Keyed by the operation's Path.
+	Errors map[string]error
+}
+
+var _ fsops.FileOps = (*FSRecorder)(nil)
+
+// NewFSRecorder returns an empty FSRecorder.
+func NewFSRecorder() *FSRecorder {
+	return &FSRecorder{}
+}
+
+// Ops returns a copy of the operations recorded so far, in call order.
+func (r *FSRecorder) Ops() []FSOp {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]FSOp, len(r.ops))
+	copy(out, r.ops)
+	return out
+}
+
+func (r *FSRecorder) record(op FSOp) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ops = append(r.ops, op)
+	return r.Errors[op.Path]
+}
+
+// Remove records a recursive delete.
+func (r *FSRecorder) Remove(path string) error {
+	return r.record(FSOp{Kind: FSRemove, Path: path})
+}
+
+// Move records a rename.
+func (r *FSRecorder) Move(src, dest string) error {
+	return r.record(FSOp{Kind: FSMove, Path: src, Dest: dest})
+}
+
+// EnsureDir records a directory creation.
+func (r *FSRecorder) EnsureDir(path string) error {
+	return r.record(FSOp{Kind: FSEnsureDir, Path: path})
+}
@@ -45,6 +45,41 @@ func NewTestRepo(t *testing.T, name string) *TestRepo {
 	return repo
 }
 
+// NewClonedRepo creates a test repository cloned from a bare remote, so
+// that origin and its remote-tracking branches exist. Returns the clone
+// and the path to the bare remote.
+func NewClonedRepo(t *testing.T, name string) (*TestRepo, string) {
+	t.Helper()
+
+	origin := NewTestRepo(t, name+"-origin")
+	tmpDir := t.TempDir()
+	barePath := filepath.Join(tmpDir, name+"-bare.git")
+	clonePath := filepath.Join(tmpDir, name)
+
+	for _, args := range [][]string{
+		{"clone", "--bare", origin.Path, barePath},
+		{"clone", barePath, clonePath},
+	} {
+		// #nosec G204 - git command with controlled inputs in test code
+		cmd := exec.Command("git", args...)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("Git command failed: git %v\n%s", args, output)
+		}
+	}
+
+	repo := &TestRepo{Path: clonePath, t: t}
+	repo.run("config", "user.name", "Test User")
+	repo.run("config", "user.email", "test@example.com")
+	return repo, barePath
+}
+
+// Git runs an arbitrary git command in the repository, failing the test
+// on error.
+func (r *TestRepo) Git(args ...string) {
+	r.t.Helper()
+	r.run(args...)
+}
+
 // WriteFile writes a file to the repository
 func (r *TestRepo) WriteFile(filename, content string) {
 	r.t.Helper()